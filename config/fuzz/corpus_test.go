@@ -0,0 +1,52 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestCorpus checks that the seeds are what their names say, so that the
+// fuzzers start from valid configs.
+func TestCorpus(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  int
+		append int
+	}{
+		{name: "minimal.ign", parse: 1},
+		{name: "files-units-users.ign", parse: 1},
+		{name: "references.ign", parse: 1},
+		{name: "v2_1.ign", parse: 1},
+		{name: "append.ign", append: 1},
+		{name: "cloud-config.ign"},
+		{name: "truncated.ign"},
+	}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "corpus", test.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Parse(data); got != test.parse {
+			t.Errorf("%s: bad Parse result: want %d, got %d", test.name, test.parse, got)
+		}
+		if got := Append(data); got != test.append {
+			t.Errorf("%s: bad Append result: want %d, got %d", test.name, test.append, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fuzz provides entry points for fuzzing how Ignition parses and
+// merges configs, in the form go-fuzz and OSS-Fuzz expect:
+//
+//	go-fuzz-build -func Parse github.com/flatcar/ignition/config/fuzz
+//	go-fuzz -bin fuzz-fuzz.zip -workdir config/fuzz/testdata
+//
+// testdata/corpus holds the seed corpus. With Go 1.18 or later, the entry
+// points can also be fuzzed natively, e.g. with
+// "go test -fuzz FuzzParse ./config/fuzz".
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+// Parse parses data like a fetched config, including bundles and older
+// config versions, and runs the checks Ignition runs on parsed configs. It
+// returns 1 if data is a valid config, for the fuzzer to prefer inputs like
+// it, and 0 otherwise. It panics if a valid config breaks an invariant.
+func Parse(data []byte) int {
+	cfg, ok := parse(data)
+	if !ok {
+		return 0
+	}
+	check(cfg)
+	return 1
+}
+
+// Append parses data as two configs separated by a NUL byte and appends the
+// second to the first, like a config appending a referenced one. It returns
+// 1 if both are valid configs and 0 otherwise, and panics if the merged
+// config breaks an invariant.
+func Append(data []byte) int {
+	parts := bytes.SplitN(data, []byte{0}, 2)
+	if len(parts) != 2 {
+		return 0
+	}
+	oldCfg, ok := parse(parts[0])
+	if !ok {
+		return 0
+	}
+	newCfg, ok := parse(parts[1])
+	if !ok {
+		return 0
+	}
+	check(config.Append(oldCfg, newCfg))
+	return 1
+}
+
+func parse(data []byte) (types.Config, bool) {
+	cfg, rpt, err := config.Parse(data)
+	return cfg, err == nil && !rpt.IsFatal()
+}
+
+// check runs the code which works on parsed configs, and checks that the
+// config can be cached, which the later stages depend on.
+func check(cfg types.Config) {
+	config.ValidateRestricted(cfg)
+	config.ValidateFIPS(cfg)
+	config.DependencyGraph(cfg)
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("valid config can't be cached: %v", err))
+	}
+	var cached types.Config
+	if err := json.Unmarshal(b, &cached); err != nil {
+		panic(fmt.Sprintf("cached config can't be read back: %v", err))
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package fuzz
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// addCorpus seeds the fuzzer with the corpus shared with go-fuzz.
+func addCorpus(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "corpus", "*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data)
+	})
+}
+
+func FuzzAppend(f *testing.F) {
+	addCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Append(data)
+	})
+}
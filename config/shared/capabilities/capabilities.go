@@ -0,0 +1,152 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package capabilities encodes file capabilities as the security.capability
+// xattr. The kernel stores the xattr as a little-endian struct vfs_cap_data
+// on every architecture, so it's encoded explicitly rather than in the byte
+// order of the host, which would break on big-endian machines like s390x.
+package capabilities
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Xattr is the name of the xattr holding file capabilities.
+const Xattr = "security.capability"
+
+const (
+	revisionMask  = 0xff000000
+	revision1     = 0x01000000
+	revision2     = 0x02000000
+	revision3     = 0x03000000
+	flagEffective = 0x000001
+
+	sizeRevision1 = 4 + 1*8
+	sizeRevision2 = 4 + 2*8
+	sizeRevision3 = sizeRevision2 + 4
+)
+
+var (
+	ErrInvalid = errors.New("invalid vfs_cap_data")
+)
+
+// names are the capabilities in the order of their numbers, see
+// capability.h.
+var names = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// Lookup returns the number of the named capability, e.g. "CAP_NET_RAW".
+// Names are case-insensitive.
+func Lookup(name string) (uint, bool) {
+	for i, n := range names {
+		if strings.EqualFold(n, name) {
+			return uint(i), true
+		}
+	}
+	return 0, false
+}
+
+// Encode returns the value of the security.capability xattr granting the
+// permitted and inheritable capabilities, as revision 2 vfs_cap_data.
+func Encode(effective bool, permitted, inheritable []string) ([]byte, error) {
+	var sets [2][2]uint32 // permitted and inheritable, low and high words
+	for i, list := range [][]string{permitted, inheritable} {
+		for _, name := range list {
+			c, ok := Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown capability %q", name)
+			}
+			sets[i][c/32] |= 1 << (c % 32)
+		}
+	}
+
+	magic := uint32(revision2)
+	if effective {
+		magic |= flagEffective
+	}
+	b := make([]byte, sizeRevision2)
+	binary.LittleEndian.PutUint32(b[0:], magic)
+	for word := 0; word < 2; word++ {
+		binary.LittleEndian.PutUint32(b[4+word*8:], sets[0][word])
+		binary.LittleEndian.PutUint32(b[8+word*8:], sets[1][word])
+	}
+	return b, nil
+}
+
+// Check returns whether b is a well-formed value of the security.capability
+// xattr: vfs_cap_data of a revision the kernel knows, of its size.
+func Check(b []byte) error {
+	if len(b) < 4 {
+		return ErrInvalid
+	}
+	size := 0
+	switch binary.LittleEndian.Uint32(b) & revisionMask {
+	case revision1:
+		size = sizeRevision1
+	case revision2:
+		size = sizeRevision2
+	case revision3:
+		size = sizeRevision3
+	default:
+		return ErrInvalid
+	}
+	if len(b) != size {
+		return ErrInvalid
+	}
+	return nil
+}
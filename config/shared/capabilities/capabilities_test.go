@@ -0,0 +1,82 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capabilities
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		effective   bool
+		permitted   []string
+		inheritable []string
+		out         []byte
+	}{
+		{
+			out: []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			effective: true,
+			permitted: []string{"CAP_NET_BIND_SERVICE", "cap_net_raw"},
+			out:       []byte{1, 0, 0, 2, 0, 0x24, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			// capabilities past 31 go to the second words
+			permitted:   []string{"CAP_CHOWN", "CAP_BPF"},
+			inheritable: []string{"CAP_SYSLOG"},
+			out:         []byte{0, 0, 0, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0x80, 0, 0, 0, 4, 0, 0, 0},
+		},
+	}
+
+	for i, test := range tests {
+		out, err := Encode(test.effective, test.permitted, test.inheritable)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(out, test.out) {
+			t.Errorf("#%d: want % x, got % x", i, test.out, out)
+		}
+		if err := Check(out); err != nil {
+			t.Errorf("#%d: encoded data doesn't check: %v", i, err)
+		}
+	}
+
+	if _, err := Encode(false, []string{"CAP_FLY"}, nil); err == nil {
+		t.Errorf("unknown capability encoded")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		in  []byte
+		out error
+	}{
+		{in: []byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{in: []byte{1, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{in: nil, out: ErrInvalid},
+		{in: []byte{0, 0, 0, 2, 0, 0, 0, 0}, out: ErrInvalid},
+		// big-endian revision 2
+		{in: []byte{2, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, out: ErrInvalid},
+	}
+
+	for i, test := range tests {
+		if err := Check(test.in); err != test.out {
+			t.Errorf("#%d: want %v, got %v", i, test.out, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dataurl decodes RFC 2397 data URLs as a stream, so large payloads
+// don't have to be held in memory in both their encoded and decoded form.
+package dataurl
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrInvalidDataURL = errors.New("invalid data url")
+	ErrInvalidEscape  = errors.New("invalid url escape in data url")
+)
+
+// NewReader returns a reader of the data encoded in the data URL s. Both the
+// plain (URL-encoded) and the ";base64" forms are supported; in the latter,
+// the base64 data may itself be URL-encoded. Errors in the encoded data are
+// returned when reading.
+func NewReader(s string) (io.Reader, error) {
+	if len(s) < len("data:") || !strings.EqualFold(s[:len("data:")], "data:") {
+		return nil, ErrInvalidDataURL
+	}
+	s = s[len("data:"):]
+	comma := strings.IndexByte(s, ',')
+	if comma < 0 {
+		return nil, ErrInvalidDataURL
+	}
+	header, data := s[:comma], s[comma+1:]
+
+	r := io.Reader(&unescapeReader{r: strings.NewReader(data)})
+	if params := strings.Split(header, ";"); strings.EqualFold(params[len(params)-1], "base64") {
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r, nil
+}
+
+// unescapeReader decodes %XX escapes of the underlying reader.
+type unescapeReader struct {
+	r io.ByteReader
+}
+
+func (u *unescapeReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c, err := u.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if c == '%' {
+			hi, err1 := u.r.ReadByte()
+			lo, err2 := u.r.ReadByte()
+			if err1 != nil || err2 != nil || !isHex(hi) || !isHex(lo) {
+				return n, ErrInvalidEscape
+			}
+			c = unhex(hi)<<4 | unhex(lo)
+		}
+		p[n] = c
+		n++
+	}
+	return n, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
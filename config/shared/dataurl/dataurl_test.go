@@ -0,0 +1,108 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataurl
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestNewReader(t *testing.T) {
+	type in struct {
+		url string
+	}
+	type out struct {
+		data string
+		err  error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{url: "data:,example%20file%0A"},
+			out: out{data: "example file\n"},
+		},
+		{
+			in:  in{url: "data:text/plain;charset=utf-8,hello"},
+			out: out{data: "hello"},
+		},
+		{
+			in:  in{url: "data:;base64,aGVsbG8gd29ybGQ="},
+			out: out{data: "hello world"},
+		},
+		{
+			in:  in{url: "DATA:text/plain;BASE64,aGVsbG8gd29ybGQ%3D"},
+			out: out{data: "hello world"},
+		},
+		{
+			in:  in{url: "data:,"},
+			out: out{data: ""},
+		},
+		{
+			in:  in{url: "data:text/plain"},
+			out: out{err: ErrInvalidDataURL},
+		},
+		{
+			in:  in{url: "http://example.com"},
+			out: out{err: ErrInvalidDataURL},
+		},
+		{
+			in:  in{url: "data:,100%"},
+			out: out{err: ErrInvalidEscape},
+		},
+		{
+			in:  in{url: "data:,%zz"},
+			out: out{err: ErrInvalidEscape},
+		},
+	}
+
+	for i, test := range tests {
+		r, err := NewReader(test.in.url)
+		var data []byte
+		if err == nil {
+			data, err = ioutil.ReadAll(r)
+		}
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			continue
+		}
+		if err == nil && string(data) != test.out.data {
+			t.Errorf("#%d: bad data: want %q, got %q", i, test.out.data, data)
+		}
+	}
+
+	// invalid base64 is reported when reading
+	r, err := NewReader("data:;base64,!!!!")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Errorf("expected an error for invalid base64")
+	}
+
+	// large payloads are decoded in full
+	large := strings.Repeat("a", 1<<20)
+	r, err = NewReader("data:," + large)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil || string(data) != large {
+		t.Errorf("bad large data: got %d bytes, err %v", len(data), err)
+	}
+}
@@ -27,24 +27,67 @@ var (
 	ErrEmpty              = errors.New("not a config (empty)")
 	ErrUnknownVersion     = errors.New("unsupported config version")
 	ErrScript             = errors.New("not a config (found coreos-cloudinit script)")
+	ErrHTML               = errors.New("not a config (found an HTML page, e.g. a captive portal's login page)")
+	ErrConfigTooLarge     = errors.New("config exceeds the maximum size")
 	ErrDeprecated         = errors.New("config format deprecated")
 	ErrCompressionInvalid = errors.New("invalid compression method")
+	ErrEncodingInvalid    = errors.New("invalid encoding (supported: utf-8)")
+	ErrLineEndingsInvalid = errors.New("invalid line endings (supported: lf)")
+	ErrMergeInvalid       = errors.New("invalid merge format (supported: json, ini, toml)")
 
 	// Ignition section errors
-	ErrOldVersion     = errors.New("incorrect config version (too old)")
-	ErrNewVersion     = errors.New("incorrect config version (too new)")
-	ErrInvalidVersion = errors.New("invalid config version (couldn't parse)")
+	ErrOldVersion                 = errors.New("incorrect config version (too old)")
+	ErrNewVersion                 = errors.New("incorrect config version (too new)")
+	ErrInvalidVersion             = errors.New("invalid config version (couldn't parse)")
+	ErrInvalidFragment            = errors.New("fragment names must start with a letter or digit and contain only letters, digits, '.', '_', ':', and '-'")
+	ErrHookStageEmpty             = errors.New("hook stage is required")
+	ErrHookNameInvalid            = errors.New("hook names must start with a letter or digit and contain only letters, digits, '.', '_', and '-'")
+	ErrMirrorTimeout              = errors.New("config source timeouts must be positive")
+	ErrHTTPRetries                = errors.New("httpRetries must not be negative")
+	ErrHTTPBackoff                = errors.New("httpBackoffBaseMs and httpBackoffMaxMs must be positive, and the base can't exceed the maximum")
+	ErrRedirectLimit              = errors.New("redirect limit must not be negative")
+	ErrRateLimit                  = errors.New("rateLimit must not be negative")
+	ErrLintRuleEmpty              = errors.New("lint suppressions must name a rule")
+	ErrAnnotationKeyEmpty         = errors.New("annotation keys can't be empty")
+	ErrConditionMetadataKey       = errors.New("condition metadata keys must be INSTANCE_ID, REGION, HOSTNAME, LOCAL_IPV4 or PUBLIC_IPV4")
+	ErrConditionPlatform          = errors.New("condition platform must be one of the platforms Ignition supports")
+	ErrConfigFallback             = errors.New("config fallback must be \"notFound\" or \"timeout\"")
+	ErrAppendFallback             = errors.New("etag and fallback can only be set for replaced configs")
+	ErrConfigVerificationRequired = errors.New("config references must have a verification hash")
+	ErrConfigFragmentsUnverified  = errors.New("config fragments cannot be verified")
+
+	// Bundle errors
+	ErrBundleSelector   = errors.New("bundle selectors must be \"cmdline:<argument>\" or \"metadata:<attribute>\"")
+	ErrBundleEmpty      = errors.New("bundles must contain at least one config")
+	ErrBundleDefault    = errors.New("the default of a bundle must name one of its configs")
+	ErrBundleNested     = errors.New("the configs of a bundle can't be bundles")
+	ErrBundleNoMatch    = errors.New("the bundle has no config for the value of its selector")
+	ErrBundleWithConfig = errors.New("bundles can't be configs themselves, move the config into the bundle")
+
+	// Format errors
+	ErrFormatVersion          = errors.New("only configs of the current version can be formatted, as older ones would be upgraded")
+	ErrFormatUnrecognizedKeys = errors.New("the config has unrecognized keys, which formatting would drop")
 
 	// Storage section errors
 	ErrPermissionsUnset            = errors.New("permissions unset, defaulting to 0000")
 	ErrDiskDeviceRequired          = errors.New("disk device is required")
+	ErrImageDeviceRequired         = errors.New("image device is required")
+	ErrImageSourceRequired         = errors.New("image source is required")
+	ErrCloneSameDevice             = errors.New("clone source and target must be different devices")
 	ErrPartitionNumbersCollide     = errors.New("partition numbers collide")
 	ErrPartitionsOverlap           = errors.New("partitions overlap")
 	ErrPartitionsMisaligned        = errors.New("partitions misaligned")
 	ErrAppendAndOverwrite          = errors.New("cannot set both append and overwrite to true")
+	ErrAppendAndMerge              = errors.New("cannot both append to and merge into a file")
+	ErrAppendMarkersWithoutAppend  = errors.New("append markers are only valid when appending")
+	ErrAppendMarkersInvalid        = errors.New("append markers must be different, non-empty lines")
+	ErrSparseIncompatible          = errors.New("sparse files cannot be appended to, merged into or normalized as text")
+	ErrFileCheckInvalid            = errors.New("invalid file check (supported: sudoers, sshd)")
 	ErrFilesystemInvalidFormat     = errors.New("invalid filesystem format")
 	ErrFilesystemNoMountPath       = errors.New("filesystem is missing mount or path")
 	ErrFilesystemMountAndPath      = errors.New("filesystem has both mount and path defined")
+	ErrFilesystemHandOverRoot      = errors.New("filesystems cannot be handed over at /")
+	ErrFilesystemHandOverSwap      = errors.New("swap cannot be handed over")
 	ErrUsedCreateAndMountOpts      = errors.New("cannot use both create object and mount-level options field")
 	ErrUsedCreateAndWipeFilesystem = errors.New("cannot use both create object and wipeFilesystem field")
 	ErrWarningCreateDeprecated     = errors.New("the create object has been deprecated in favor of mount-level options")
@@ -67,6 +110,35 @@ var (
 	ErrPartitionsUnitsMismatch     = errors.New("cannot mix MBs and sectors within a disk")
 	ErrSizeDeprecated              = errors.New("size is deprecated; use sizeMB instead")
 	ErrStartDeprecated             = errors.New("start is deprecated; use startMB instead")
+	ErrOEMGrubConfigMarker         = errors.New("oem grub config cannot contain the ignition block markers")
+	ErrOEMReleaseID                = errors.New("oem release id may only contain lowercase letters, digits, \".\", \"_\" and \"-\"")
+	ErrOEMReleaseValue             = errors.New("oem release values cannot contain newlines")
+	ErrManifestSourceRequired      = errors.New("manifest source is required")
+	ErrArchiveSourceRequired       = errors.New("archive source is required")
+	ErrArchiveFormat               = errors.New("archive format must be \"zip\", \"image\" or \"git\"")
+	ErrArchiveImageSource          = errors.New("archives of format \"image\" must have a docker source, and only they")
+	ErrArchiveImageVerification    = errors.New("archives of format \"image\" are verified by the digest of the image, not a hash")
+	ErrArchiveGitSource            = errors.New("archives of format \"git\" must have a git+https source, and only they")
+	ErrArchiveGitVerification      = errors.New("archives of format \"git\" are verified by the commit ID of their ref, not a hash")
+	ErrUdevRuleName                = errors.New("udev rule names can only contain letters, digits, '_', '-', '.' and '@', without the priority or the \".rules\" suffix")
+	ErrUdevRulePriority            = errors.New("udev rule priorities must be between 0 and 99")
+	ErrUdevRuleDuplicate           = errors.New("udev rule names must be unique")
+	ErrEditEmpty                   = errors.New("edit must have lines, ini settings or a patch")
+	ErrEditPatchCombined           = errors.New("patches cannot be combined with lines or ini settings in one edit")
+	ErrEditLineRequired            = errors.New("edit line is required, unless it is absent and has a match")
+	ErrEditLineNewline             = errors.New("edit lines cannot contain newlines")
+	ErrEditMatchInvalid            = errors.New("edit line match is not a valid regular expression")
+	ErrEditIniKeyRequired          = errors.New("ini key is required")
+	ErrEditIniInvalid              = errors.New("ini sections, keys and values cannot contain newlines, sections \"]\" and keys \"=\"")
+	ErrEditPatchFormat             = errors.New("patch format must be \"bsdiff\"")
+	ErrEditPatchSourceRequired     = errors.New("patch source is required")
+	ErrEditPatchHashRequired       = errors.New("patches require the hashes of the original and the resulting file")
+	ErrCapabilityInvalid           = errors.New("unknown capability")
+	ErrXattrNameInvalid            = errors.New("xattr names must be in the user, security or trusted namespace")
+	ErrXattrValueInvalid           = errors.New("xattr values must be base64 encoded")
+	ErrXattrDuplicate              = errors.New("xattrs cannot be set more than once")
+	ErrXattrCapabilityConflict     = errors.New("cannot set security.capability both as xattr and by capabilities")
+	ErrXattrCapabilityInvalid      = errors.New("security.capability must be little-endian vfs_cap_data of revision 1, 2 or 3")
 
 	// Passwd section errors
 	ErrPasswdCreateDeprecated      = errors.New("the create object has been deprecated in favor of user-level options")
@@ -80,23 +152,147 @@ var (
 	ErrPasswdCreateAndShell        = errors.New("cannot use both the create object and the user-level shell field")
 	ErrPasswdCreateAndSystem       = errors.New("cannot use both the create object and the user-level system field")
 	ErrPasswdCreateAndUID          = errors.New("cannot use both the create object and the user-level uid field")
+	ErrSudoerUserOrGroup           = errors.New("sudoers entries must have either a user or a group")
+	ErrSudoerNameInvalid           = errors.New("sudoers user and group names must start with a letter or '_' and contain only letters, digits, '.', '_', and '-'")
+	ErrSudoerCommandInvalid        = errors.New("sudoers commands must start with an absolute path and be a single line")
+	ErrPolicyValueNegative         = errors.New("password policy values must not be negative")
+	ErrPasswordAgingMinOverMax     = errors.New("minDays must not be greater than maxDays")
+
+	// SSH section errors
+	ErrSSHHostKeyType            = errors.New("unrecognized ssh host key type")
+	ErrSSHHostKeyDuplicate       = errors.New("ssh host key types must be unique")
+	ErrSSHConfigSnippetName      = errors.New("ssh config snippet names must end in \".conf\" and cannot contain \"/\"")
+	ErrSSHConfigSnippetDuplicate = errors.New("ssh config snippet names must be unique")
+	ErrSSHPhoneHomeScheme        = errors.New("ssh phone home url must be an http or https url")
+
+	// Audit section errors
+	ErrAuditRulesName      = errors.New("audit rules names must end in \".rules\" and cannot contain \"/\"")
+	ErrAuditRulesDuplicate = errors.New("audit rules names must be unique")
+
+	// Certificate section errors
+	ErrCertificateProtocol     = errors.New("unsupported certificate enrollment protocol")
+	ErrCertificateServerScheme = errors.New("certificate enrollment server must be an https url")
+
+	// Kernel section errors
+	ErrKernelModuleName         = errors.New("kernel module names can only contain letters, digits, '_' and '-'")
+	ErrKernelModuleDuplicate    = errors.New("kernel modules can only be listed once")
+	ErrKernelModuleEmpty        = errors.New("kernel modules must be blacklisted or have options")
+	ErrKernelModuleOption       = errors.New("kernel module options must be a parameter, optionally followed by '=' and a value without blanks, like \"modeset=0\"")
+	ErrKernelDeviceMatch        = errors.New("device rules must match a subsystem or a kernel name")
+	ErrKernelDeviceMatchValue   = errors.New("device rule matches can't contain quotes, backslashes or line breaks")
+	ErrKernelDeviceVendor       = errors.New("device vendors must be PCI vendor IDs like \"0x10de\"")
+	ErrKernelDeviceNoAssignment = errors.New("device rules must set an owner, a group or a mode")
+	ErrKernelDeviceOwnerName    = errors.New("device owners and groups must be user and group names")
+	ErrKernelDeviceMode         = errors.New("device modes must be between 0 and 0777")
+	ErrHugepageSize             = errors.New("hugepage sizes must be a power of two followed by K, M or G, like \"2M\"")
+	ErrHugepageCount            = errors.New("hugepage counts can't be negative")
+	ErrHugepageNode             = errors.New("NUMA nodes can't be negative")
+	ErrHugepageDuplicate        = errors.New("hugepages of a size can only be listed once per NUMA node, and either for all nodes or per node")
+	ErrHugepageShmGroup         = errors.New("the hugepage shm group must be a group ID")
+
+	// Kubernetes section errors
+	ErrKubernetesServerScheme = errors.New("kubernetes api server must be an https url")
+
+	// Reboot section errors
+	ErrRebootMethod = errors.New("reboot method must be \"reboot\" or \"kexec\"")
+
+	// Time section errors
+	ErrTimeServerAddress     = errors.New("time server addresses must be host names or IP addresses, without a port")
+	ErrTimeServerDuplicate   = errors.New("time servers must have distinct addresses")
+	ErrTimeMakeStepThreshold = errors.New("step threshold must be a positive duration like \"100ms\"")
+	ErrTimeMakeStepLimit     = errors.New("step limit must be a number of clock updates, or -1 for no limit")
+
+	// Update section errors
+	ErrUpdateGroup              = errors.New("update group must start with a letter or digit and consist of at most 64 letters, digits, dots, underscores and hyphens")
+	ErrUpdateServer             = errors.New("update server must be an http or https url")
+	ErrUpdateRebootStrategy     = errors.New("reboot strategy must be \"reboot\", \"etcd-lock\", \"best-effort\" or \"off\"")
+	ErrUpdateRebootWindowStart  = errors.New("reboot window start must be a time like \"04:00\", optionally preceded by a weekday like \"Thu\"")
+	ErrUpdateRebootWindowLength = errors.New("reboot window length must be a positive duration like \"1h30m\"")
 
 	// Systemd and Networkd section errors
-	ErrInvalidSystemdExt        = errors.New("invalid systemd unit extension")
-	ErrInvalidSystemdDropinExt  = errors.New("invalid systemd drop-in extension")
-	ErrInvalidNetworkdExt       = errors.New("invalid networkd unit extension")
-	ErrInvalidNetworkdDropinExt = errors.New("invalid networkd drop-in extension")
+	ErrInvalidSystemdExt                  = errors.New("invalid systemd unit extension")
+	ErrInvalidSystemdDropinExt            = errors.New("invalid systemd drop-in extension")
+	ErrInvalidNetworkdExt                 = errors.New("invalid networkd unit extension")
+	ErrInvalidNetworkdDropinExt           = errors.New("invalid networkd drop-in extension")
+	ErrSystemdExtensionName               = errors.New("system extension names must end in \".raw\" and cannot contain \"/\"")
+	ErrSystemdExtensionType               = errors.New("system extension type must be \"sysext\" or \"confext\"")
+	ErrSystemdExtensionSourceRequired     = errors.New("system extensions require a source")
+	ErrSystemdExtensionDuplicate          = errors.New("system extension names must be unique per type")
+	ErrNetworkdLinkNameRequired           = errors.New("networkd links must give a name or alternative names")
+	ErrNetworkdLinkNameInvalid            = errors.New("interface names must be 1 to 15 characters, not \".\" or \"..\", without \"/\", \":\" or whitespace")
+	ErrNetworkdLinkAltNameInvalid         = errors.New("alternative interface names must be 1 to 127 characters, not \".\" or \"..\", without \"/\", \":\" or whitespace")
+	ErrNetworkdLinkMatchRequired          = errors.New("networkd links must match a MAC address, path or driver; an empty match matches every interface")
+	ErrNetworkdLinkMACInvalid             = errors.New("MAC addresses must be 6 bytes, like \"52:54:00:12:34:56\"")
+	ErrNetworkdLinkMatchAmbiguous         = errors.New("match may match several interfaces, only one of which can get the name; match a MAC address or a path")
+	ErrNetworkdLinkMatchDuplicate         = errors.New("networkd links cannot have the same match")
+	ErrNetworkdLinkNameDuplicate          = errors.New("interface names and alternative names must be given by one networkd link only")
+	ErrNetworkdLinkFileNeverMatches       = errors.New(".link files sorting after 99-default.link never apply, as it matches every interface first; prefix the name with a lower number like \"10-\"")
+	ErrNetworkdNetdevNameDuplicate        = errors.New("bonds, bridges and VLANs must have distinct names")
+	ErrNetworkdNetdevSelf                 = errors.New("bonds, bridges and VLANs cannot be built on themselves")
+	ErrNetworkdInterfaceInvalid           = errors.New("interfaces must be given by names of 1 to 127 characters, not \".\" or \"..\", without \"/\", \":\" or whitespace")
+	ErrNetworkdBondModeInvalid            = errors.New("bond mode must be \"balance-rr\", \"active-backup\", \"balance-xor\", \"broadcast\", \"802.3ad\", \"balance-tlb\" or \"balance-alb\"")
+	ErrNetworkdBondMIIMonitorInvalid      = errors.New("bond miimon must be a number of milliseconds, or 0 to disable it")
+	ErrNetworkdBondNested                 = errors.New("bonds cannot contain bonds or bridges")
+	ErrNetworkdBridgeNested               = errors.New("bridges cannot contain bridges")
+	ErrNetworkdMemberDuplicate            = errors.New("interfaces can be in one bond or bridge only")
+	ErrNetworkdVLANIDInvalid              = errors.New("VLAN ids must be between 0 and 4094")
+	ErrNetworkdVLANInterfaceRequired      = errors.New("VLANs must name the interface they are on")
+	ErrNetworkdVLANOnMember               = errors.New("VLANs cannot be on interfaces in a bond or bridge, which don't receive their traffic; put them on the bond or bridge")
+	ErrNetworkdVLANDuplicate              = errors.New("VLANs on the same interface must have distinct ids")
+	ErrNetworkdUnitGenerated              = errors.New("networkd unit has the name of a file generated for a bond, bridge or VLAN; give it drop-ins only to extend the generated file")
+	ErrNetworkdWireGuardMember            = errors.New("WireGuard interfaces cannot be in a bond or bridge or carry VLANs, as they don't carry Ethernet frames")
+	ErrNetworkdWireGuardKeySourceRequired = errors.New("WireGuard private keys require a source")
+	ErrNetworkdWireGuardPortInvalid       = errors.New("WireGuard listen ports must be between 1 and 65535")
+	ErrNetworkdWireGuardAddressInvalid    = errors.New("WireGuard addresses must be addresses with a prefix length like \"10.0.0.2/24\"")
+	ErrNetworkdWireGuardPublicKeyInvalid  = errors.New("WireGuard public keys must be 32 bytes in base64, as printed by \"wg pubkey\"")
+	ErrNetworkdWireGuardPeerDuplicate     = errors.New("WireGuard peers must have distinct public keys")
+	ErrNetworkdWireGuardAllowedIPInvalid  = errors.New("allowed IPs must be addresses or networks like \"10.0.0.0/24\"")
+	ErrNetworkdWireGuardEndpointInvalid   = errors.New("WireGuard endpoints must be a host and port like \"vpn.example.com:51820\"")
+	ErrNetworkdWireGuardKeepaliveInvalid  = errors.New("WireGuard persistent keepalive must be between 0 and 65535 seconds")
+	ErrNetworkdRoutesNotNetwork           = errors.New("routes and routing policy rules can only be given for .network units")
+	ErrNetworkdRoutesDropinReserved       = errors.New("the drop-in name \"10-ignition-routes.conf\" is reserved for the routes and routing policy rules of the unit")
+	ErrNetworkdRouteEmpty                 = errors.New("routes must have a destination or a gateway, networkd ignores them otherwise")
+	ErrNetworkdRouteDestinationInvalid    = errors.New("route destinations must be networks like \"10.1.0.0/16\"")
+	ErrNetworkdRouteGatewayInvalid        = errors.New("route gateways must be IP addresses")
+	ErrNetworkdRouteFamilyMismatch        = errors.New("route destinations and gateways must be of the same address family")
+	ErrNetworkdRouteMetricInvalid         = errors.New("route metrics must be between 0 and 4294967295")
+	ErrNetworkdRouteTableInvalid          = errors.New("routing tables must be between 1 and 4294967295")
+	ErrNetworkdRouteDuplicate             = errors.New("routes of a unit must differ in destination, table or metric")
+	ErrNetworkdRuleAddressInvalid         = errors.New("routing policy rule from and to must be addresses or networks like \"10.1.0.0/16\"")
+	ErrNetworkdRuleFamilyMismatch         = errors.New("routing policy rule from and to must be of the same address family")
+	ErrNetworkdRulePriorityInvalid        = errors.New("routing policy rule priorities must be between 0 and 4294967295")
+	ErrNetworkdPrefixHostBits             = errors.New("networks cannot have bits set after the prefix length, like \"10.1.0.1/16\", the kernel rejects them")
 
 	// Misc errors
 	ErrInvalidScheme                   = errors.New("invalid url scheme")
 	ErrInvalidUrl                      = errors.New("unable to parse url")
+	ErrInvalidProxy                    = errors.New("proxies must be http, https or socks5 urls, or a host and port like \"proxy.example.com:3128\"")
+	ErrInvalidImageUrl                 = errors.New("docker urls must name a registry, an image, and an absolute path in the image after '#'")
+	ErrInvalidOCIUrl                   = errors.New("oci urls must name a registry and an artifact")
+	ErrInvalidGSUrl                    = errors.New("gs urls must name a bucket and an object")
+	ErrInvalidAzblobUrl                = errors.New("azblob urls must name a storage account, a container and a blob")
+	ErrInvalidGitUrl                   = errors.New("git+https urls must name a repository, at most a ref, and a relative path in it after '#'")
+	ErrInvalidSFTPUrl                  = errors.New("sftp urls must name a user, a host and an absolute path without quotes, backslashes or wildcards")
 	ErrEmptyHTTPHeaderName             = errors.New("HTTP header name can't be empty")
 	ErrDuplicateHTTPHeaders            = errors.New("all header names in the list must be unique")
 	ErrUnsupportedSchemeForHTTPHeaders = errors.New("cannot use HTTP headers with this source scheme")
+	ErrUnsupportedSchemeForETag        = errors.New("cannot use an etag with this source scheme")
+	ErrPinHost                         = errors.New("public key pin hosts must be host names, not urls")
+	ErrClientCertificateSource         = errors.New("client certificates and their private keys must be data or oem urls")
+	ErrSFTPHost                        = errors.New("sftp hosts must be a host name or address, optionally followed by a port")
+	ErrSFTPHostDuplicate               = errors.New("sftp hosts can only be listed once")
+	ErrSFTPHostKeys                    = errors.New("sftp hosts must list their host keys")
+	ErrSFTPHostKey                     = errors.New("sftp host keys must be public keys like \"ssh-ed25519 AAAA...\", as in known_hosts")
+	ErrSFTPKeySource                   = errors.New("sftp private keys can't be fetched over sftp")
 	ErrHashMalformed                   = errors.New("malformed hash specifier")
 	ErrHashWrongSize                   = errors.New("incorrect size for hash sum")
 	ErrHashUnrecognized                = errors.New("unrecognized hash function")
 	ErrEngineConfiguration             = errors.New("engine incorrectly configured")
+	ErrConvergeConflict                = errors.New("config conflicts with the system")
+	ErrEAPMethod                       = errors.New("EAP method must be \"TLS\", \"PEAP\" or \"TTLS\"")
+	ErrEAPCredentialsIncomplete        = errors.New("EAP-TLS needs a client certificate and private key, PEAP and TTLS an identity and password")
+	ErrEAPInterfaceRequired            = errors.New("EAP credentials must name the interface to authenticate, unless fetches are bound to an interface")
+	ErrEAPTimeout                      = errors.New("timed out waiting for 802.1X authentication")
 
 	// AWS S3 specific errors
 	ErrInvalidS3ObjectVersionId = errors.New("invalid S3 object VersionId")
@@ -107,3 +303,21 @@ var (
 func NewNoInstallSectionError(name string) error {
 	return fmt.Errorf("unit %q is enabled, but has no install section so enable does nothing", name)
 }
+
+// NewUnknownSSHDOptionError produces an error indicating the given sshd
+// option, named name, is not recognized.
+func NewUnknownSSHDOptionError(name string) error {
+	return fmt.Errorf("unrecognized sshd option %q", name)
+}
+
+// NewUdevRuleError produces an error indicating the given udev rule is not
+// valid udev rule syntax, for the given reason.
+func NewUdevRuleError(rule, reason string) error {
+	return fmt.Errorf("invalid udev rule %q: %s", rule, reason)
+}
+
+// NewAuditRuleError produces an error indicating the given audit rule is not
+// valid auditctl syntax, for the given reason.
+func NewAuditRuleError(rule, reason string) error {
+	return fmt.Errorf("invalid audit rule %q: %s", rule, reason)
+}
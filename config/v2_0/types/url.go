@@ -16,10 +16,11 @@ package types
 
 import (
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/url"
 
-	"github.com/vincent-petithory/dataurl"
-
+	"github.com/flatcar/ignition/config/shared/dataurl"
 	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/validate/report"
 )
@@ -59,7 +60,11 @@ func (u Url) Validate() report.Report {
 	case "http", "https", "oem":
 		return report.Report{}
 	case "data":
-		if _, err := dataurl.DecodeString(u.String()); err != nil {
+		r, err := dataurl.NewReader(u.String())
+		if err == nil {
+			_, err = io.Copy(ioutil.Discard, r)
+		}
+		if err != nil {
 			return report.ReportFromError(err, report.EntryError)
 		}
 		return report.Report{}
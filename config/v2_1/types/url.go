@@ -15,10 +15,12 @@
 package types
 
 import (
+	"io"
+	"io/ioutil"
 	"net/url"
 
+	"github.com/flatcar/ignition/config/shared/dataurl"
 	"github.com/flatcar/ignition/config/shared/errors"
-	"github.com/vincent-petithory/dataurl"
 )
 
 func validateURL(s string) error {
@@ -42,10 +44,12 @@ func validateURL(s string) error {
 		}
 		return nil
 	case "data":
-		if _, err := dataurl.DecodeString(s); err != nil {
+		r, err := dataurl.NewReader(s)
+		if err != nil {
 			return err
 		}
-		return nil
+		_, err = io.Copy(ioutil.Discard, r)
+		return err
 	default:
 		return errors.ErrInvalidScheme
 	}
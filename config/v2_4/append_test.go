@@ -451,3 +451,19 @@ func TestAppend(t *testing.T) {
 		assert.Equal(t, test.out.config, config, "#%d: bad config", i)
 	}
 }
+
+func BenchmarkAppend(b *testing.B) {
+	base, _, err := Parse(largeConfig(10000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	child, _, err := Parse(largeConfig(10000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Append(base, child)
+	}
+}
@@ -15,6 +15,8 @@
 package v2_4
 
 import (
+	"bytes"
+
 	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/v2_3"
 	"github.com/flatcar/ignition/config/v2_4/types"
@@ -34,6 +36,8 @@ func Parse(rawConfig []byte) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, errors.ErrCloudConfig
 	} else if isScript(rawConfig) {
 		return types.Config{}, report.Report{}, errors.ErrScript
+	} else if isHTML(rawConfig) {
+		return types.Config{}, report.Report{}, errors.ErrHTML
 	}
 
 	var err error
@@ -68,3 +72,17 @@ func Parse(rawConfig []byte) (types.Config, report.Report, error) {
 func isEmpty(userdata []byte) bool {
 	return len(userdata) == 0
 }
+
+// isHTML reports whether userdata is an HTML page, as served by captive
+// portals and misconfigured web servers in place of the config.
+func isHTML(userdata []byte) bool {
+	head := bytes.TrimLeft(userdata, "\ufeff \t\r\n")
+	if len(head) == 0 || head[0] != '<' {
+		return false
+	}
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	head = bytes.ToLower(head)
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.Contains(head, []byte("<html"))
+}
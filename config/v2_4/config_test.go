@@ -15,6 +15,8 @@
 package v2_4
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/flatcar/ignition/config/shared/errors"
@@ -126,6 +128,14 @@ func TestParse(t *testing.T) {
 				0xe0, 0x02, 0x00, 0x1d, 0x9d, 0xfb, 0x04, 0x0a, 0x00, 0x00, 0x00}},
 			out: out{err: errors.ErrScript},
 		},
+		{
+			in:  in{config: []byte("\n<!DOCTYPE html>\n<html><head><title>Hotel WiFi</title></head></html>")},
+			out: out{err: errors.ErrHTML},
+		},
+		{
+			in:  in{config: []byte("<?xml version=\"1.0\"?>\n<HTML><BODY>Please log in</BODY></HTML>")},
+			out: out{err: errors.ErrHTML},
+		},
 	}
 
 	for i, test := range tests {
@@ -137,3 +147,50 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, test.out.config, config, "#%d: bad config, report: %+v", i, report)
 	}
 }
+
+// largeConfig returns a config writing n files, as generated by tools
+// provisioning whole application trees.
+func largeConfig(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"ignition": {"version": "2.4.0"}, "storage": {"files": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"filesystem": "root", "path": "/opt/app/%d/config", "mode": 420, "contents": {"source": "data:,file%%20%d"}}`, i, i)
+	}
+	b.WriteString(`]}}`)
+	return b.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		config := largeConfig(n)
+		b.Run(fmt.Sprintf("files=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := Parse(config); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestParseAllocations guards against parsing and validation regressing to
+// work that grows faster than the config.
+func TestParseAllocations(t *testing.T) {
+	const files = 1000
+	// allocations per file, currently about 80
+	const budget = 100
+
+	config := largeConfig(files)
+	allocs := testing.AllocsPerRun(1, func() {
+		if _, _, err := Parse(config); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > files*budget {
+		t.Errorf("parsing %d files took %v allocations, want at most %d", files, allocs, files*budget)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (a Archive) ValidateFilesystem() report.Report {
+	if a.Filesystem == "" {
+		return report.ReportFromError(errors.ErrNoFilesystem, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (a Archive) ValidatePath() report.Report {
+	return report.ReportFromError(validatePath(a.Path), report.EntryError)
+}
+
+func (a Archive) ValidateFormat() report.Report {
+	image := strings.HasPrefix(a.Source, "docker:")
+	git := strings.HasPrefix(a.Source, "git+https:")
+	switch a.Format {
+	case "zip":
+		if image {
+			return report.ReportFromError(errors.ErrArchiveImageSource, report.EntryError)
+		}
+		if git {
+			return report.ReportFromError(errors.ErrArchiveGitSource, report.EntryError)
+		}
+		return report.Report{}
+	case "image":
+		if !image {
+			return report.ReportFromError(errors.ErrArchiveImageSource, report.EntryError)
+		}
+		if a.Verification.Hash != nil {
+			return report.ReportFromError(errors.ErrArchiveImageVerification, report.EntryError)
+		}
+		return report.Report{}
+	case "git":
+		if !git {
+			return report.ReportFromError(errors.ErrArchiveGitSource, report.EntryError)
+		}
+		if a.Verification.Hash != nil {
+			return report.ReportFromError(errors.ErrArchiveGitVerification, report.EntryError)
+		}
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrArchiveFormat, report.EntryError)
+	}
+}
+
+func (a Archive) ValidateSource() report.Report {
+	if a.Source == "" {
+		return report.ReportFromError(errors.ErrArchiveSourceRequired, report.EntryError)
+	}
+	return report.ReportFromError(validateURL(a.Source), report.EntryError)
+}
+
+func (a Archive) ValidateHTTPHeaders() report.Report {
+	if len(a.HTTPHeaders) < 1 {
+		return report.Report{}
+	}
+	u, err := url.Parse(a.Source)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https", "oci", "git+https":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
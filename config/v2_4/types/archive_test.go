@@ -0,0 +1,136 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestArchiveValidate(t *testing.T) {
+	type in struct {
+		archive Archive
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "zip", Source: "https://example.com/app.zip"}},
+			out: out{},
+		},
+		{
+			in:  in{archive: Archive{Path: "/opt/app", Format: "zip", Source: "https://example.com/app.zip"}},
+			out: out{err: errors.ErrNoFilesystem},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "opt/app", Format: "zip", Source: "https://example.com/app.zip"}},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "rar", Source: "https://example.com/app.rar"}},
+			out: out{err: errors.ErrArchiveFormat},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "zip"}},
+			out: out{err: errors.ErrArchiveSourceRequired},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "image", Source: "docker://quay.io/example/app:1.0#/opt/app"}},
+			out: out{},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "zip", Source: "docker://quay.io/example/app:1.0#/opt/app"}},
+			out: out{err: errors.ErrArchiveImageSource},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "image", Source: "https://example.com/app.zip"}},
+			out: out{err: errors.ErrArchiveImageSource},
+		},
+		{
+			in: in{archive: Archive{
+				Filesystem:   "root",
+				Path:         "/opt/app",
+				Format:       "image",
+				Source:       "docker://quay.io/example/app:1.0#/opt/app",
+				Verification: Verification{Hash: strToPtrStrict("sha512-" + strings.Repeat("0", 128))},
+			}},
+			out: out{err: errors.ErrArchiveImageVerification},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/opt/app", Format: "image", Source: "docker://quay.io/example/app:1.0"}},
+			out: out{err: errors.ErrInvalidImageUrl},
+		},
+		{
+			in: in{archive: Archive{
+				Filesystem:  "root",
+				Path:        "/etc/app",
+				Format:      "git",
+				Source:      "git+https://git.example.com/org/config.git?ref=v1.2#app",
+				HTTPHeaders: HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/etc/app", Format: "zip", Source: "git+https://git.example.com/org/config.git#app"}},
+			out: out{err: errors.ErrArchiveGitSource},
+		},
+		{
+			in:  in{archive: Archive{Filesystem: "root", Path: "/etc/app", Format: "git", Source: "https://git.example.com/org/config.git"}},
+			out: out{err: errors.ErrArchiveGitSource},
+		},
+		{
+			in: in{archive: Archive{
+				Filesystem:   "root",
+				Path:         "/etc/app",
+				Format:       "git",
+				Source:       "git+https://git.example.com/org/config.git#app",
+				Verification: Verification{Hash: strToPtrStrict("sha512-" + strings.Repeat("0", 128))},
+			}},
+			out: out{err: errors.ErrArchiveGitVerification},
+		},
+		{
+			in: in{archive: Archive{
+				Filesystem:  "root",
+				Path:        "/opt/app",
+				Format:      "zip",
+				Source:      "s3://bucket/app.zip",
+				HTTPHeaders: HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+			}},
+			out: out{err: errors.ErrUnsupportedSchemeForHTTPHeaders},
+		},
+	}
+
+	for i, test := range tests {
+		a := test.in.archive
+		r := a.ValidateFilesystem()
+		r.Merge(a.ValidatePath())
+		r.Merge(a.ValidateFormat())
+		r.Merge(a.ValidateSource())
+		r.Merge(a.ValidateHTTPHeaders())
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+var (
+	// auditFields are the fields of syscall rules, see auditctl(8)
+	auditFields = setOf(
+		"a0", "a1", "a2", "a3", "arch", "auid", "devmajor", "devminor",
+		"dir", "egid", "euid", "exe", "exit", "filetype",
+		"fsgid", "fstype", "fsuid", "gid", "inode", "key", "loginuid",
+		"msgtype", "obj_gid", "obj_lev_high", "obj_lev_low", "obj_role",
+		"obj_type", "obj_uid", "obj_user", "path", "perm", "pers", "pid",
+		"ppid", "saddr_fam", "sessionid", "sgid", "subj_clr", "subj_role",
+		"subj_sen", "subj_type", "subj_user", "success", "suid", "uid",
+	)
+	// auditCompareFields are the fields -C compares
+	auditCompareFields = setOf(
+		"auid", "uid", "euid", "suid", "fsuid", "obj_uid",
+		"gid", "egid", "sgid", "fsgid", "obj_gid",
+	)
+	auditLists   = setOf("task", "exit", "user", "exclude", "filesystem", "io_uring")
+	auditActions = setOf("always", "never")
+
+	auditFieldRegexp   = regexp.MustCompile(`^([a-z0-9_]+)(!=|<=|>=|&=|=|<|>|&)(.+)$`)
+	auditCompareRegexp = regexp.MustCompile(`^([a-z_]+)(!=|=)([a-z_]+)$`)
+	auditSyscallRegexp = regexp.MustCompile(`^[a-z0-9_]+(,[a-z0-9_]+)*$`)
+)
+
+func setOf(values ...string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// auditOptions are the options of auditctl rules, and whether they take an
+// argument.
+var auditOptions = map[string]bool{
+	"-a": true, "-A": true, "-d": true, "-w": true, "-W": true, "-p": true,
+	"-k": true, "-S": true, "-F": true, "-C": true, "-b": true, "-f": true,
+	"-e": true, "-r": true, "--backlog_wait_time": true,
+	"-D": false, "-i": false, "-c": false, "--loginuid-immutable": false,
+	"--reset-lost": false,
+}
+
+func (a Audit) ValidateRules() report.Report {
+	r := report.Report{}
+	seen := map[string]struct{}{}
+	for _, rule := range a.Rules {
+		if _, ok := seen[rule.Name]; ok {
+			r.Add(report.Entry{
+				Message: errors.ErrAuditRulesDuplicate.Error(),
+				Kind:    report.EntryError,
+			})
+			return r
+		}
+		seen[rule.Name] = struct{}{}
+	}
+	return r
+}
+
+func (a AuditRule) ValidateName() report.Report {
+	if filepath.Ext(a.Name) != ".rules" || strings.Contains(a.Name, "/") || strings.HasPrefix(a.Name, ".") {
+		return report.ReportFromError(errors.ErrAuditRulesName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (a AuditRule) ValidateContents() report.Report {
+	scanner := bufio.NewScanner(strings.NewReader(a.Contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if reason := checkAuditRule(strings.Fields(line)); reason != "" {
+			return report.ReportFromError(errors.NewAuditRuleError(line, reason), report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+// checkAuditRule checks the arguments of an auditctl rule, returning why
+// auditctl would reject it, or "" if it wouldn't. It checks the grammar,
+// not whether the kernel supports the syscalls and fields.
+func checkAuditRule(args []string) string {
+	var syscall, watch, perms, key string
+	var needSyscall []string
+	for i := 0; i < len(args); i++ {
+		opt := args[i]
+		hasArg, ok := auditOptions[opt]
+		if !ok {
+			return fmt.Sprintf("unknown option %q", opt)
+		}
+		var arg string
+		if hasArg {
+			if i+1 == len(args) {
+				return fmt.Sprintf("%s needs an argument", opt)
+			}
+			i++
+			arg = args[i]
+		}
+
+		switch opt {
+		case "-a", "-A", "-d":
+			if syscall != "" {
+				return fmt.Sprintf("%s and %s can't be combined", syscall, opt)
+			}
+			syscall = opt
+			parts := strings.Split(arg, ",")
+			if len(parts) != 2 || !isListAndAction(parts[0], parts[1]) && !isListAndAction(parts[1], parts[0]) {
+				return fmt.Sprintf("%s takes a list and an action, like \"always,exit\", not %q", opt, arg)
+			}
+		case "-w", "-W":
+			if watch != "" {
+				return fmt.Sprintf("%s and %s can't be combined", watch, opt)
+			}
+			watch = opt
+			if !path.IsAbs(arg) {
+				return fmt.Sprintf("%s takes an absolute path, not %q", opt, arg)
+			}
+		case "-p":
+			perms = arg
+			if strings.Trim(arg, "rwxa") != "" {
+				return fmt.Sprintf("-p takes permissions out of \"rwxa\", not %q", arg)
+			}
+		case "-k":
+			key = arg
+			if len(arg) > 256 {
+				return "keys can't be longer than 256 characters"
+			}
+		case "-S":
+			needSyscall = append(needSyscall, opt)
+			if !auditSyscallRegexp.MatchString(arg) {
+				return fmt.Sprintf("-S takes syscall names or numbers, not %q", arg)
+			}
+		case "-F":
+			needSyscall = append(needSyscall, opt)
+			m := auditFieldRegexp.FindStringSubmatch(arg)
+			if m == nil {
+				return fmt.Sprintf("-F takes a field, an operator and a value, like \"auid>=1000\", not %q", arg)
+			}
+			if _, ok := auditFields[m[1]]; !ok {
+				return fmt.Sprintf("unknown field %q", m[1])
+			}
+		case "-C":
+			needSyscall = append(needSyscall, opt)
+			m := auditCompareRegexp.FindStringSubmatch(arg)
+			if m == nil {
+				return fmt.Sprintf("-C takes two fields compared with = or !=, like \"uid!=euid\", not %q", arg)
+			}
+			for _, f := range []string{m[1], m[3]} {
+				if _, ok := auditCompareFields[f]; !ok {
+					return fmt.Sprintf("field %q can't be compared", f)
+				}
+			}
+		case "-b", "-r", "--backlog_wait_time":
+			if _, err := strconv.ParseUint(arg, 10, 32); err != nil {
+				return fmt.Sprintf("%s takes a number, not %q", opt, arg)
+			}
+		case "-f", "-e":
+			if arg != "0" && arg != "1" && arg != "2" {
+				return fmt.Sprintf("%s takes 0, 1 or 2, not %q", opt, arg)
+			}
+		}
+	}
+
+	switch {
+	case syscall != "" && watch != "":
+		return fmt.Sprintf("%s and %s can't be combined", syscall, watch)
+	case perms != "" && watch == "":
+		return "-p is only valid in watches, use -F perm= in syscall rules"
+	case key != "" && syscall == "" && watch == "":
+		return "-k is only valid in watches and syscall rules"
+	case syscall == "" && len(needSyscall) > 0:
+		return fmt.Sprintf("%s is only valid in syscall rules, which start with -a", needSyscall[0])
+	}
+	return ""
+}
+
+func isListAndAction(list, action string) bool {
+	_, isList := auditLists[list]
+	_, isAction := auditActions[action]
+	return isList && isAction
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestAuditValidate(t *testing.T) {
+	type in struct {
+		audit Audit
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{audit: Audit{Rules: []AuditRule{{Name: "50-identity.rules"}, {Name: "99-finalize.rules"}}}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{audit: Audit{Rules: []AuditRule{{Name: "50-identity.rules"}, {Name: "50-identity.rules"}}}},
+			out: out{err: errors.ErrAuditRulesDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.audit.ValidateRules()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestAuditRuleValidateName(t *testing.T) {
+	type in struct {
+		name string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{name: "50-identity.rules"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{name: "50-identity.conf"},
+			out: out{err: errors.ErrAuditRulesName},
+		},
+		{
+			in:  in{name: "../audit.rules"},
+			out: out{err: errors.ErrAuditRulesName},
+		},
+		{
+			in:  in{name: ".rules"},
+			out: out{err: errors.ErrAuditRulesName},
+		},
+	}
+
+	for i, test := range tests {
+		r := AuditRule{Name: test.in.name}.ValidateName()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestAuditRuleValidateContents(t *testing.T) {
+	type in struct {
+		contents string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{contents: `# control
+-D
+-b 8192
+--backlog_wait_time 60000
+-f 1
+
+-w /etc/passwd -p wa -k identity
+-W /tmp/old
+-a always,exit -F arch=b64 -S adjtimex,settimeofday -k time-change
+-a exit,always -F arch=b64 -S openat -F exit=-EACCES -F auid>=1000 -F auid!=unset -k access
+-A never,exclude -F msgtype=CWD
+-a always,exit -F arch=b64 -C uid!=euid -F euid=0 -S execve -k setuid
+--loginuid-immutable
+-e 2
+`},
+			out: out{err: nil},
+		},
+		{
+			in:  in{contents: "-w /etc/passwd -p wa -k"},
+			out: out{err: errors.NewAuditRuleError("-w /etc/passwd -p wa -k", "-k needs an argument")},
+		},
+		{
+			in:  in{contents: "-w etc/passwd"},
+			out: out{err: errors.NewAuditRuleError("-w etc/passwd", `-w takes an absolute path, not "etc/passwd"`)},
+		},
+		{
+			in:  in{contents: "-w /etc/passwd -p rwz"},
+			out: out{err: errors.NewAuditRuleError("-w /etc/passwd -p rwz", `-p takes permissions out of "rwxa", not "rwz"`)},
+		},
+		{
+			in:  in{contents: "-a always,entry -S open"},
+			out: out{err: errors.NewAuditRuleError("-a always,entry -S open", `-a takes a list and an action, like "always,exit", not "always,entry"`)},
+		},
+		{
+			in:  in{contents: "-a always,exit -F auid>=1000 -F color=red"},
+			out: out{err: errors.NewAuditRuleError("-a always,exit -F auid>=1000 -F color=red", `unknown field "color"`)},
+		},
+		{
+			in:  in{contents: "-a always,exit -F auid"},
+			out: out{err: errors.NewAuditRuleError("-a always,exit -F auid", `-F takes a field, an operator and a value, like "auid>=1000", not "auid"`)},
+		},
+		{
+			in:  in{contents: "-a always,exit -C uid>euid"},
+			out: out{err: errors.NewAuditRuleError("-a always,exit -C uid>euid", `-C takes two fields compared with = or !=, like "uid!=euid", not "uid>euid"`)},
+		},
+		{
+			in:  in{contents: "-a always,exit -w /etc/shadow"},
+			out: out{err: errors.NewAuditRuleError("-a always,exit -w /etc/shadow", "-a and -w can't be combined")},
+		},
+		{
+			in:  in{contents: "-a always,exit -p wa"},
+			out: out{err: errors.NewAuditRuleError("-a always,exit -p wa", "-p is only valid in watches, use -F perm= in syscall rules")},
+		},
+		{
+			in:  in{contents: "-S open -k files"},
+			out: out{err: errors.NewAuditRuleError("-S open -k files", "-k is only valid in watches and syscall rules")},
+		},
+		{
+			in:  in{contents: "-F auid>=1000"},
+			out: out{err: errors.NewAuditRuleError("-F auid>=1000", "-F is only valid in syscall rules, which start with -a")},
+		},
+		{
+			in:  in{contents: "-e 3"},
+			out: out{err: errors.NewAuditRuleError("-e 3", `-e takes 0, 1 or 2, not "3"`)},
+		},
+		{
+			in:  in{contents: "-b lots"},
+			out: out{err: errors.NewAuditRuleError("-b lots", `-b takes a number, not "lots"`)},
+		},
+		{
+			in:  in{contents: "auditctl -D"},
+			out: out{err: errors.NewAuditRuleError("auditctl -D", `unknown option "auditctl"`)},
+		},
+	}
+
+	for i, test := range tests {
+		r := AuditRule{Name: "50-test.rules", Contents: test.in.contents}.ValidateContents()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
@@ -46,7 +46,7 @@ func (c CaReference) ValidateHTTPHeaders() report.Report {
 	}
 
 	switch u.Scheme {
-	case "http", "https":
+	case "http", "https", "oci":
 	default:
 		r.Add(report.Entry{
 			Message: errors.ErrUnsupportedSchemeForHTTPHeaders.Error(),
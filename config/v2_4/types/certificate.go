@@ -0,0 +1,50 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (c Certificate) ValidateProtocol() report.Report {
+	switch c.Protocol {
+	case "est":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrCertificateProtocol, report.EntryError)
+	}
+}
+
+func (c Certificate) ValidateServer() report.Report {
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	if u.Scheme != "https" {
+		return report.ReportFromError(errors.ErrCertificateServerScheme, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (c Certificate) ValidateKeyPath() report.Report {
+	return report.ReportFromError(validatePath(c.KeyPath), report.EntryError)
+}
+
+func (c Certificate) ValidateCertificatePath() report.Report {
+	return report.ReportFromError(validatePath(c.CertificatePath), report.EntryError)
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"path/filepath"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (c Clone) Validate() report.Report {
+	if filepath.Clean(c.Source) == filepath.Clean(c.Target) {
+		return report.ReportFromError(errors.ErrCloneSameDevice, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (c Clone) ValidateSource() report.Report {
+	return report.ReportFromError(validatePath(c.Source), report.EntryError)
+}
+
+func (c Clone) ValidateTarget() report.Report {
+	return report.ReportFromError(validatePath(c.Target), report.EntryError)
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// conditionMetadataKeys are the metadata attributes providers report, which
+// conditions can match.
+var conditionMetadataKeys = map[string]bool{
+	"INSTANCE_ID": true,
+	"REGION":      true,
+	"HOSTNAME":    true,
+	"LOCAL_IPV4":  true,
+	"PUBLIC_IPV4": true,
+}
+
+// conditionPlatforms are the platforms Ignition can run on, as passed with
+// --oem, which conditions can match.
+var conditionPlatforms = map[string]bool{
+	"aliyun":             true,
+	"azure":              true,
+	"brightbox":          true,
+	"cloudsigma":         true,
+	"cloudstack":         true,
+	"digitalocean":       true,
+	"ec2":                true,
+	"exoscale":           true,
+	"file":               true,
+	"gce":                true,
+	"hyperv":             true,
+	"interoute":          true,
+	"metal":              true,
+	"niftycloud":         true,
+	"openstack":          true,
+	"packet":             true,
+	"pxe":                true,
+	"qemu":               true,
+	"rackspace":          true,
+	"rackspace-onmetal":  true,
+	"vagrant":            true,
+	"vagrant-virtualbox": true,
+	"virtualbox":         true,
+	"vmware":             true,
+	"vultr":              true,
+}
+
+func (c Condition) ValidatePlatform() report.Report {
+	if c.Platform != "" && !conditionPlatforms[c.Platform] {
+		return report.ReportFromError(errors.ErrConditionPlatform, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (c Condition) ValidateMetadata() report.Report {
+	for key := range c.Metadata {
+		if !conditionMetadataKeys[key] {
+			return report.ReportFromError(errors.ErrConditionMetadataKey, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
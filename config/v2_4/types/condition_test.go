@@ -0,0 +1,105 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestConditionValidateMetadata(t *testing.T) {
+	type in struct {
+		condition Condition
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{condition: Condition{}},
+			out: out{},
+		},
+		{
+			in:  in{condition: Condition{Metadata: map[string]string{"REGION": "us-east-1", "INSTANCE_ID": "i-0abc"}}},
+			out: out{},
+		},
+		{
+			in:  in{condition: Condition{Metadata: map[string]string{"region": "us-east-1"}}},
+			out: out{err: errors.ErrConditionMetadataKey},
+		},
+		{
+			in:  in{condition: Condition{Metadata: map[string]string{"ZONE": "us-east-1a"}}},
+			out: out{err: errors.ErrConditionMetadataKey},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.condition.ValidateMetadata()
+		expect := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expect, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expect, r)
+		}
+	}
+}
+
+func TestConditionValidatePlatform(t *testing.T) {
+	type in struct {
+		condition Condition
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{condition: Condition{}},
+			out: out{},
+		},
+		{
+			in:  in{condition: Condition{Platform: "ec2"}},
+			out: out{},
+		},
+		{
+			in:  in{condition: Condition{Platform: "rackspace-onmetal"}},
+			out: out{},
+		},
+		{
+			in:  in{condition: Condition{Platform: "aws"}},
+			out: out{err: errors.ErrConditionPlatform},
+		},
+		{
+			in:  in{condition: Condition{Platform: "EC2"}},
+			out: out{err: errors.ErrConditionPlatform},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.condition.ValidatePlatform()
+		expect := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expect, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expect, r)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (e Edit) Validate() report.Report {
+	if len(e.Lines) == 0 && len(e.Ini) == 0 && e.Patch == nil {
+		return report.ReportFromError(errors.ErrEditEmpty, report.EntryError)
+	}
+	// the result of a patch is verified, so nothing may change it further
+	if e.Patch != nil && (len(e.Lines) != 0 || len(e.Ini) != 0) {
+		return report.ReportFromError(errors.ErrEditPatchCombined, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (e Edit) ValidateFilesystem() report.Report {
+	if e.Filesystem == "" {
+		return report.ReportFromError(errors.ErrNoFilesystem, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (e Edit) ValidatePath() report.Report {
+	return report.ReportFromError(validatePath(e.Path), report.EntryError)
+}
+
+func (l EditLine) ValidateLine() report.Report {
+	if strings.ContainsAny(l.Line, "\r\n") {
+		return report.ReportFromError(errors.ErrEditLineNewline, report.EntryError)
+	}
+	if l.Line == "" && !(l.Absent && l.Match != nil) {
+		return report.ReportFromError(errors.ErrEditLineRequired, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l EditLine) ValidateMatch() report.Report {
+	if l.Match == nil {
+		return report.Report{}
+	}
+	if _, err := regexp.Compile(*l.Match); err != nil {
+		return report.ReportFromError(errors.ErrEditMatchInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (i EditIni) ValidateSection() report.Report {
+	if strings.ContainsAny(i.Section, "\r\n]") {
+		return report.ReportFromError(errors.ErrEditIniInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (i EditIni) ValidateKey() report.Report {
+	if strings.TrimSpace(i.Key) == "" {
+		return report.ReportFromError(errors.ErrEditIniKeyRequired, report.EntryError)
+	}
+	if strings.ContainsAny(i.Key, "\r\n=") {
+		return report.ReportFromError(errors.ErrEditIniInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (i EditIni) ValidateValue() report.Report {
+	if i.Value != nil && strings.ContainsAny(*i.Value, "\r\n") {
+		return report.ReportFromError(errors.ErrEditIniInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p EditPatch) ValidateFormat() report.Report {
+	if p.Format != "bsdiff" {
+		return report.ReportFromError(errors.ErrEditPatchFormat, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p EditPatch) ValidateSource() report.Report {
+	if p.Source == "" {
+		return report.ReportFromError(errors.ErrEditPatchSourceRequired, report.EntryError)
+	}
+	return report.ReportFromError(validateURL(p.Source), report.EntryError)
+}
+
+func (p EditPatch) ValidateOriginal() report.Report {
+	if p.Original.Hash == nil {
+		return report.ReportFromError(errors.ErrEditPatchHashRequired, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p EditPatch) ValidateResult() report.Report {
+	if p.Result.Hash == nil {
+		return report.ReportFromError(errors.ErrEditPatchHashRequired, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p EditPatch) ValidateHTTPHeaders() report.Report {
+	if len(p.HTTPHeaders) < 1 {
+		return report.Report{}
+	}
+	u, err := url.Parse(p.Source)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https", "oci":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
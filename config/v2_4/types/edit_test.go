@@ -0,0 +1,144 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestEditValidate(t *testing.T) {
+	type in struct {
+		edit Edit
+	}
+	type out struct {
+		err error
+	}
+
+	hash := strToPtrStrict("sha512-" + strings.Repeat("0", 128))
+	patch := func(format, source string) *EditPatch {
+		return &EditPatch{
+			Format:   format,
+			Source:   source,
+			Original: Verification{Hash: hash},
+			Result:   Verification{Hash: hash},
+		}
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Line: "PermitRootLogin no", Match: strToPtrStrict("^#?PermitRootLogin ")}}}},
+			out: out{},
+		},
+		{
+			in:  in{edit: Edit{Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Line: "PermitRootLogin no"}}}},
+			out: out{err: errors.ErrNoFilesystem},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "etc/ssh/sshd_config", Lines: []EditLine{{Line: "PermitRootLogin no"}}}},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config"}},
+			out: out{err: errors.ErrEditEmpty},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/usr/lib/vendor.bin", Patch: patch("bsdiff", "https://example.com/vendor.bsdiff"), Lines: []EditLine{{Line: "a"}}}},
+			out: out{err: errors.ErrEditPatchCombined},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Absent: true}}}},
+			out: out{err: errors.ErrEditLineRequired},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Absent: true, Match: strToPtrStrict("^PermitRootLogin")}}}},
+			out: out{},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Line: "a\nb"}}}},
+			out: out{err: errors.ErrEditLineNewline},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/ssh/sshd_config", Lines: []EditLine{{Line: "a", Match: strToPtrStrict("(")}}}},
+			out: out{err: errors.ErrEditMatchInvalid},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/dnf/dnf.conf", Ini: []EditIni{{Section: "main", Key: "gpgcheck", Value: strToPtrStrict("1")}, {Key: "top"}}}},
+			out: out{},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/dnf/dnf.conf", Ini: []EditIni{{Section: "main", Key: " "}}}},
+			out: out{err: errors.ErrEditIniKeyRequired},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/dnf/dnf.conf", Ini: []EditIni{{Section: "main", Key: "a=b"}}}},
+			out: out{err: errors.ErrEditIniInvalid},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/etc/dnf/dnf.conf", Ini: []EditIni{{Section: "ma]in", Key: "a"}}}},
+			out: out{err: errors.ErrEditIniInvalid},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/usr/lib/vendor.bin", Patch: patch("bsdiff", "https://example.com/vendor.bsdiff")}},
+			out: out{},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/usr/lib/vendor.bin", Patch: patch("xdelta", "https://example.com/vendor.xdelta")}},
+			out: out{err: errors.ErrEditPatchFormat},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/usr/lib/vendor.bin", Patch: patch("bsdiff", "")}},
+			out: out{err: errors.ErrEditPatchSourceRequired},
+		},
+		{
+			in:  in{edit: Edit{Filesystem: "root", Path: "/usr/lib/vendor.bin", Patch: &EditPatch{Format: "bsdiff", Source: "https://example.com/vendor.bsdiff", Original: Verification{Hash: hash}}}},
+			out: out{err: errors.ErrEditPatchHashRequired},
+		},
+	}
+
+	for i, test := range tests {
+		e := test.in.edit
+		r := e.Validate()
+		r.Merge(e.ValidateFilesystem())
+		r.Merge(e.ValidatePath())
+		for _, l := range e.Lines {
+			r.Merge(l.ValidateLine())
+			r.Merge(l.ValidateMatch())
+		}
+		for _, i := range e.Ini {
+			r.Merge(i.ValidateSection())
+			r.Merge(i.ValidateKey())
+			r.Merge(i.ValidateValue())
+		}
+		if p := e.Patch; p != nil {
+			r.Merge(p.ValidateFormat())
+			r.Merge(p.ValidateSource())
+			r.Merge(p.ValidateOriginal())
+			r.Merge(p.ValidateResult())
+			r.Merge(p.ValidateHTTPHeaders())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
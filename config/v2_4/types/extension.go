@@ -0,0 +1,92 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (s Systemd) ValidateExtensions() report.Report {
+	seen := map[string]struct{}{}
+	for _, e := range s.Extensions {
+		key := e.ExtensionType() + "/" + e.Name
+		if _, ok := seen[key]; ok {
+			return report.ReportFromError(errors.ErrSystemdExtensionDuplicate, report.EntryError)
+		}
+		seen[key] = struct{}{}
+	}
+	return report.Report{}
+}
+
+// ExtensionType returns the type of the extension, "sysext" if it isn't set.
+func (e SystemdExtension) ExtensionType() string {
+	if e.Type == "" {
+		return "sysext"
+	}
+	return e.Type
+}
+
+func (e SystemdExtension) ValidateName() report.Report {
+	if path.Ext(e.Name) != ".raw" || strings.Contains(e.Name, "/") {
+		return report.ReportFromError(errors.ErrSystemdExtensionName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (e SystemdExtension) ValidateType() report.Report {
+	switch e.Type {
+	case "", "sysext", "confext":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrSystemdExtensionType, report.EntryError)
+	}
+}
+
+func (e SystemdExtension) ValidateSource() report.Report {
+	if e.Source == "" {
+		return report.ReportFromError(errors.ErrSystemdExtensionSourceRequired, report.EntryError)
+	}
+	return report.ReportFromError(validateURL(e.Source), report.EntryError)
+}
+
+func (e SystemdExtension) ValidateCompression() report.Report {
+	switch e.Compression {
+	case "", "gzip", "xz", "zstd":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrCompressionInvalid, report.EntryError)
+	}
+}
+
+func (e SystemdExtension) ValidateHTTPHeaders() report.Report {
+	if len(e.HTTPHeaders) < 1 {
+		return report.Report{}
+	}
+	u, err := url.Parse(e.Source)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https", "oci":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
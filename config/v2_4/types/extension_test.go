@@ -0,0 +1,110 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestSystemdExtensionValidate(t *testing.T) {
+	type in struct {
+		extension SystemdExtension
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{extension: SystemdExtension{Name: "docker.raw", Source: "https://example.com/docker.raw"}},
+			out: out{},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "motd.raw", Type: "confext", Source: "https://example.com/motd.raw.gz", Compression: "gzip"}},
+			out: out{},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "docker", Source: "https://example.com/docker.raw"}},
+			out: out{err: errors.ErrSystemdExtensionName},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "../docker.raw", Source: "https://example.com/docker.raw"}},
+			out: out{err: errors.ErrSystemdExtensionName},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "docker.raw", Type: "portable", Source: "https://example.com/docker.raw"}},
+			out: out{err: errors.ErrSystemdExtensionType},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "docker.raw"}},
+			out: out{err: errors.ErrSystemdExtensionSourceRequired},
+		},
+		{
+			in:  in{extension: SystemdExtension{Name: "docker.raw", Source: "https://example.com/docker.raw", Compression: "bzip2"}},
+			out: out{err: errors.ErrCompressionInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		e := test.in.extension
+		r := e.ValidateName()
+		r.Merge(e.ValidateType())
+		r.Merge(e.ValidateSource())
+		r.Merge(e.ValidateCompression())
+		r.Merge(e.ValidateHTTPHeaders())
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestSystemdValidateExtensions(t *testing.T) {
+	type in struct {
+		extensions []SystemdExtension
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{extensions: []SystemdExtension{{Name: "a.raw"}, {Name: "a.raw", Type: "confext"}}},
+			out: out{},
+		},
+		{
+			in:  in{extensions: []SystemdExtension{{Name: "a.raw"}, {Name: "a.raw", Type: "sysext"}}},
+			out: out{err: errors.ErrSystemdExtensionDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		r := Systemd{Extensions: test.in.extensions}.ValidateExtensions()
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -15,9 +15,12 @@
 package types
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/url"
+	"strings"
 
+	"github.com/flatcar/ignition/config/shared/capabilities"
 	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/validate/report"
 )
@@ -26,6 +29,84 @@ func (f File) Validate() report.Report {
 	if f.Overwrite != nil && *f.Overwrite && f.Append {
 		return report.ReportFromError(errors.ErrAppendAndOverwrite, report.EntryError)
 	}
+	if f.Append && f.Contents.Merge != "" {
+		return report.ReportFromError(errors.ErrAppendAndMerge, report.EntryError)
+	}
+	if f.Contents.Sparse && (f.Append || f.Contents.Merge != "" || f.Contents.Encoding != "" || f.Contents.LineEndings != "") {
+		return report.ReportFromError(errors.ErrSparseIncompatible, report.EntryError)
+	}
+	return validateLinkFilePath(f.Path)
+}
+
+func (f File) ValidateAppendMarkers() report.Report {
+	m := f.AppendMarkers
+	if m == nil {
+		return report.Report{}
+	}
+	if !f.Append {
+		return report.ReportFromError(errors.ErrAppendMarkersWithoutAppend, report.EntryError)
+	}
+	if strings.TrimSpace(m.Begin) == "" || strings.TrimSpace(m.End) == "" || m.Begin == m.End ||
+		strings.ContainsAny(m.Begin+m.End, "\r\n") {
+		return report.ReportFromError(errors.ErrAppendMarkersInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (f File) ValidateCheck() report.Report {
+	switch f.Check {
+	case "", "sudoers", "sshd":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrFileCheckInvalid, report.EntryError)
+	}
+}
+
+func (f File) ValidateCapabilities() report.Report {
+	if f.Capabilities == nil {
+		return report.Report{}
+	}
+	for _, c := range append(f.Capabilities.Permitted, f.Capabilities.Inheritable...) {
+		if _, ok := capabilities.Lookup(c); !ok {
+			return report.ReportFromError(errors.ErrCapabilityInvalid, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (f File) ValidateXattrs() report.Report {
+	seen := map[string]bool{}
+	for _, x := range f.Xattrs {
+		if seen[x.Name] {
+			return report.ReportFromError(errors.ErrXattrDuplicate, report.EntryError)
+		}
+		seen[x.Name] = true
+	}
+	if f.Capabilities != nil && seen[capabilities.Xattr] {
+		return report.ReportFromError(errors.ErrXattrCapabilityConflict, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (x Xattr) ValidateName() report.Report {
+	for _, ns := range []string{"security.", "trusted.", "user."} {
+		if strings.HasPrefix(x.Name, ns) && len(x.Name) > len(ns) {
+			return report.Report{}
+		}
+	}
+	return report.ReportFromError(errors.ErrXattrNameInvalid, report.EntryError)
+}
+
+func (x Xattr) ValidateValue() report.Report {
+	value, err := base64.StdEncoding.DecodeString(x.Value)
+	if err != nil {
+		return report.ReportFromError(errors.ErrXattrValueInvalid, report.EntryError)
+	}
+	// the value is written as is, so it must be in the byte order the
+	// kernel expects whatever the architecture of the machine
+	if x.Name == capabilities.Xattr && capabilities.Check(value) != nil {
+		return report.ReportFromError(errors.ErrXattrCapabilityInvalid, report.EntryError)
+	}
 	return report.Report{}
 }
 
@@ -49,7 +130,7 @@ func (f File) ValidateMode() report.Report {
 func (fc FileContents) ValidateCompression() report.Report {
 	r := report.Report{}
 	switch fc.Compression {
-	case "", "gzip":
+	case "", "gzip", "xz", "zstd":
 	default:
 		r.Add(report.Entry{
 			Message: errors.ErrCompressionInvalid.Error(),
@@ -59,6 +140,33 @@ func (fc FileContents) ValidateCompression() report.Report {
 	return r
 }
 
+func (fc FileContents) ValidateEncoding() report.Report {
+	switch fc.Encoding {
+	case "", "utf-8":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrEncodingInvalid, report.EntryError)
+	}
+}
+
+func (fc FileContents) ValidateLineEndings() report.Report {
+	switch fc.LineEndings {
+	case "", "lf":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrLineEndingsInvalid, report.EntryError)
+	}
+}
+
+func (fc FileContents) ValidateMerge() report.Report {
+	switch fc.Merge {
+	case "", "json", "ini", "toml":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrMergeInvalid, report.EntryError)
+	}
+}
+
 func (fc FileContents) ValidateSource() report.Report {
 	r := report.Report{}
 	err := validateURL(fc.Source)
@@ -88,7 +196,7 @@ func (fc FileContents) ValidateHTTPHeaders() report.Report {
 	}
 
 	switch u.Scheme {
-	case "http", "https":
+	case "http", "https", "oci", "git+https":
 	default:
 		r.Add(report.Entry{
 			Message: errors.ErrUnsupportedSchemeForHTTPHeaders.Error(),
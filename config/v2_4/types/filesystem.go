@@ -16,6 +16,7 @@ package types
 
 import (
 	"fmt"
+	"path"
 
 	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/validate/report"
@@ -93,6 +94,31 @@ func (m Mount) ValidateDevice() report.Report {
 	return r
 }
 
+func (m Mount) ValidateHandOver() report.Report {
+	r := report.Report{}
+	if m.HandOver == nil {
+		return r
+	}
+	if err := validatePath(*m.HandOver); err != nil {
+		r.Add(report.Entry{
+			Message: err.Error(),
+			Kind:    report.EntryError,
+		})
+	} else if path.Clean(*m.HandOver) == "/" {
+		r.Add(report.Entry{
+			Message: errors.ErrFilesystemHandOverRoot.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	if m.Format == "swap" {
+		r.Add(report.Entry{
+			Message: errors.ErrFilesystemHandOverSwap.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	return r
+}
+
 func (m Mount) ValidateLabel() report.Report {
 	r := report.Report{}
 	if m.Label == nil {
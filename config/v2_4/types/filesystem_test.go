@@ -56,6 +56,49 @@ func TestMountValidate(t *testing.T) {
 	}
 }
 
+func TestMountValidateHandOver(t *testing.T) {
+	type in struct {
+		format   string
+		handOver *string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{format: "ext4"},
+			out: out{},
+		},
+		{
+			in:  in{format: "ext4", handOver: strToPtrStrict("/var")},
+			out: out{},
+		},
+		{
+			in:  in{format: "ext4", handOver: strToPtrStrict("var")},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in:  in{format: "xfs", handOver: strToPtrStrict("/var/..")},
+			out: out{err: errors.ErrFilesystemHandOverRoot},
+		},
+		{
+			in:  in{format: "swap", handOver: strToPtrStrict("/swap")},
+			out: out{err: errors.ErrFilesystemHandOverSwap},
+		},
+	}
+
+	for i, test := range tests {
+		err := Mount{Format: test.in.format, Device: "/", HandOver: test.in.handOver}.ValidateHandOver()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+	}
+}
+
 func TestFilesystemValidate(t *testing.T) {
 	type in struct {
 		filesystem Filesystem
@@ -0,0 +1,42 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"regexp"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+var (
+	// hook names refer to programs in the hooks directory, so they must
+	// not contain slashes or be "." or ".."
+	hookNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+func (h Hook) ValidateStage() report.Report {
+	if h.Stage == "" {
+		return report.ReportFromError(errors.ErrHookStageEmpty, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p HookProgram) ValidateName() report.Report {
+	if !hookNameRegex.MatchString(p.Name) {
+		return report.ReportFromError(errors.ErrHookNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestHookValidateStage(t *testing.T) {
+	type in struct {
+		hook Hook
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{hook: Hook{Stage: "disks"}},
+			out: out{},
+		},
+		{
+			in:  in{hook: Hook{}},
+			out: out{err: errors.ErrHookStageEmpty},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.hook.ValidateStage()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestHookProgramValidateName(t *testing.T) {
+	type in struct {
+		program HookProgram
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{program: HookProgram{Name: "load-firmware"}},
+			out: out{},
+		},
+		{
+			in:  in{program: HookProgram{Name: "settle_v2.sh"}},
+			out: out{},
+		},
+		{
+			in:  in{program: HookProgram{Name: ""}},
+			out: out{err: errors.ErrHookNameInvalid},
+		},
+		{
+			in:  in{program: HookProgram{Name: ".."}},
+			out: out{err: errors.ErrHookNameInvalid},
+		},
+		{
+			in:  in{program: HookProgram{Name: "/usr/bin/bash"}},
+			out: out{err: errors.ErrHookNameInvalid},
+		},
+		{
+			in:  in{program: HookProgram{Name: "../../bin/sh"}},
+			out: out{err: errors.ErrHookNameInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.program.ValidateName()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
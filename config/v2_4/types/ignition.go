@@ -42,25 +42,140 @@ func (c ConfigReference) ValidateHTTPHeaders() report.Report {
 		return r
 	}
 
-	u, err := url.Parse(c.Source)
-	if err != nil {
+	// the headers are sent to the mirrors as well
+	if err := c.validateSources(errors.ErrUnsupportedSchemeForHTTPHeaders, "http", "https", "oci"); err != nil {
 		r.Add(report.Entry{
-			Message: errors.ErrInvalidUrl.Error(),
+			Message: err.Error(),
 			Kind:    report.EntryError,
 		})
-		return r
 	}
 
-	switch u.Scheme {
-	case "http", "https":
+	return r
+}
+
+func (c ConfigReference) ValidateETag() report.Report {
+	if c.ETag == "" {
+		return report.Report{}
+	}
+	if err := c.validateSources(errors.ErrUnsupportedSchemeForETag, "http", "https"); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+// validateSources checks that the source and all mirrors of c are urls of
+// one of schemes, returning errScheme if they aren't.
+func (c ConfigReference) validateSources(errScheme error, schemes ...string) error {
+	sources := []string{c.Source}
+	for _, m := range c.Mirrors {
+		sources = append(sources, m.Source)
+	}
+	for _, source := range sources {
+		u, err := url.Parse(source)
+		if err != nil {
+			return errors.ErrInvalidUrl
+		}
+
+		if !hasScheme(u, schemes) {
+			return errScheme
+		}
+	}
+	return nil
+}
+
+func hasScheme(u *url.URL, schemes []string) bool {
+	for _, s := range schemes {
+		if u.Scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ConfigFallback) Validate() report.Report {
+	switch f {
+	case "notFound", "timeout":
+		return report.Report{}
 	default:
-		r.Add(report.Entry{
-			Message: errors.ErrUnsupportedSchemeForHTTPHeaders.Error(),
-			Kind:    report.EntryError,
-		})
+		return report.ReportFromError(errors.ErrConfigFallback, report.EntryError)
 	}
+}
 
-	return r
+// ValidateAppend rejects settings which only make sense when falling back
+// to the config containing the reference.
+func (c IgnitionConfig) ValidateAppend() report.Report {
+	for _, ref := range c.Append {
+		if ref.ETag != "" || len(ref.Fallback) > 0 {
+			return report.ReportFromError(errors.ErrAppendFallback, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+// ValidateRequireVerification checks that the config's references carry the
+// hashes it requires.
+func (c IgnitionConfig) ValidateRequireVerification() report.Report {
+	if !c.RequireVerification {
+		return report.Report{}
+	}
+	if len(c.Fragments) > 0 {
+		return report.ReportFromError(errors.ErrConfigFragmentsUnverified, report.EntryError)
+	}
+	refs := c.Append
+	if c.Replace != nil {
+		refs = append([]ConfigReference{*c.Replace}, refs...)
+	}
+	for _, ref := range refs {
+		if ref.Verification.Hash == nil {
+			return report.ReportFromError(errors.ErrConfigVerificationRequired, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (c ConfigReference) ValidateCompression() report.Report {
+	switch c.Compression {
+	case "", "gzip", "xz", "zstd":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrCompressionInvalid, report.EntryError)
+	}
+}
+
+func (c ConfigReference) ValidateTimeout() report.Report {
+	return validateMirrorTimeout(c.Timeout)
+}
+
+func (m ConfigMirror) ValidateSource() report.Report {
+	if err := validateURL(m.Source); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m ConfigMirror) ValidateTimeout() report.Report {
+	return validateMirrorTimeout(m.Timeout)
+}
+
+func validateMirrorTimeout(timeout *int) report.Report {
+	if timeout != nil && *timeout <= 0 {
+		return report.ReportFromError(errors.ErrMirrorTimeout, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (v Ignition) ValidateRateLimit() report.Report {
+	if v.RateLimit != nil && *v.RateLimit < 0 {
+		return report.ReportFromError(errors.ErrRateLimit, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (v Ignition) ValidateAnnotations() report.Report {
+	if _, ok := v.Annotations[""]; ok {
+		return report.ReportFromError(errors.ErrAnnotationKeyEmpty, report.EntryError)
+	}
+	return report.Report{}
 }
 
 func (v Ignition) Semver() (*semver.Version, error) {
@@ -0,0 +1,326 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestConfigReferenceValidate(t *testing.T) {
+	type in struct {
+		ref ConfigReference
+	}
+	type out struct {
+		headers     report.Report
+		timeout     report.Report
+		compression report.Report
+	}
+
+	headers := HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{ref: ConfigReference{Source: "https://example.com/config.ign"}},
+			out: out{},
+		},
+		{
+			in: in{ref: ConfigReference{
+				Source:      "https://example.com/config.ign",
+				Timeout:     intToPtr(30),
+				HTTPHeaders: headers,
+				Mirrors: []ConfigMirror{
+					{Source: "https://mirror.example.com/config.ign", Timeout: intToPtr(10)},
+					{Source: "http://192.0.2.1/config.ign"},
+				},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{ref: ConfigReference{Source: "https://example.com/config.ign", Timeout: intToPtr(0)}},
+			out: out{timeout: report.ReportFromError(errors.ErrMirrorTimeout, report.EntryError)},
+		},
+		{
+			in: in{ref: ConfigReference{
+				Source:      "https://example.com/config.ign",
+				HTTPHeaders: headers,
+				Mirrors:     []ConfigMirror{{Source: "tftp://192.0.2.1/config.ign"}},
+			}},
+			out: out{headers: report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)},
+		},
+		{
+			in: in{ref: ConfigReference{
+				Source:      "oci://registry.example.com/configs/worker:v2#worker.ign",
+				HTTPHeaders: HTTPHeaders{{Name: "Authorization", Value: "Basic dXNlcjpwYXNz"}},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{ref: ConfigReference{Source: "https://example.com/config.ign.xz", Compression: "xz"}},
+			out: out{},
+		},
+		{
+			in:  in{ref: ConfigReference{Source: "https://example.com/config.ign.bz2", Compression: "bzip2"}},
+			out: out{compression: report.ReportFromError(errors.ErrCompressionInvalid, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.ref.ValidateHTTPHeaders()
+		if !reflect.DeepEqual(test.out.headers, r) {
+			t.Errorf("#%d: bad headers report: want %v, got %v", i, test.out.headers, r)
+		}
+		r = test.in.ref.ValidateTimeout()
+		if !reflect.DeepEqual(test.out.timeout, r) {
+			t.Errorf("#%d: bad timeout report: want %v, got %v", i, test.out.timeout, r)
+		}
+		r = test.in.ref.ValidateCompression()
+		if !reflect.DeepEqual(test.out.compression, r) {
+			t.Errorf("#%d: bad compression report: want %v, got %v", i, test.out.compression, r)
+		}
+	}
+}
+
+func TestConfigMirrorValidate(t *testing.T) {
+	type in struct {
+		mirror ConfigMirror
+	}
+	type out struct {
+		source  report.Report
+		timeout report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{mirror: ConfigMirror{Source: "https://mirror.example.com/config.ign", Timeout: intToPtr(5)}},
+			out: out{},
+		},
+		{
+			in:  in{mirror: ConfigMirror{Source: "ftp://mirror.example.com/config.ign"}},
+			out: out{source: report.ReportFromError(errors.ErrInvalidScheme, report.EntryError)},
+		},
+		{
+			in:  in{mirror: ConfigMirror{Source: "https://mirror.example.com/config.ign", Timeout: intToPtr(-1)}},
+			out: out{timeout: report.ReportFromError(errors.ErrMirrorTimeout, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.mirror.ValidateSource()
+		if !reflect.DeepEqual(test.out.source, r) {
+			t.Errorf("#%d: bad source report: want %v, got %v", i, test.out.source, r)
+		}
+		r = test.in.mirror.ValidateTimeout()
+		if !reflect.DeepEqual(test.out.timeout, r) {
+			t.Errorf("#%d: bad timeout report: want %v, got %v", i, test.out.timeout, r)
+		}
+	}
+}
+
+func TestConfigFallbackValidate(t *testing.T) {
+	type in struct {
+		config IgnitionConfig
+	}
+	type out struct {
+		etag     report.Report
+		fallback report.Report
+		append   report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{config: IgnitionConfig{Replace: &ConfigReference{
+				Source:   "https://example.com/config.ign",
+				ETag:     `"v1"`,
+				Fallback: []ConfigFallback{"notFound", "timeout"},
+			}}},
+			out: out{},
+		},
+		{
+			in: in{config: IgnitionConfig{Replace: &ConfigReference{
+				Source:   "s3://bucket/config.ign",
+				ETag:     `"v1"`,
+				Fallback: []ConfigFallback{"unauthorized"},
+			}}},
+			out: out{
+				etag:     report.ReportFromError(errors.ErrUnsupportedSchemeForETag, report.EntryError),
+				fallback: report.ReportFromError(errors.ErrConfigFallback, report.EntryError),
+			},
+		},
+		{
+			in: in{config: IgnitionConfig{
+				Replace: &ConfigReference{Source: "https://example.com/config.ign"},
+				Append:  []ConfigReference{{Source: "https://example.com/extra.ign", Fallback: []ConfigFallback{"timeout"}}},
+			}},
+			out: out{append: report.ReportFromError(errors.ErrAppendFallback, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.config.Replace.ValidateETag()
+		if !reflect.DeepEqual(test.out.etag, r) {
+			t.Errorf("#%d: bad etag report: want %v, got %v", i, test.out.etag, r)
+		}
+		r = report.Report{}
+		for _, f := range test.in.config.Replace.Fallback {
+			r.Merge(f.Validate())
+		}
+		if !reflect.DeepEqual(test.out.fallback, r) {
+			t.Errorf("#%d: bad fallback report: want %v, got %v", i, test.out.fallback, r)
+		}
+		r = test.in.config.ValidateAppend()
+		if !reflect.DeepEqual(test.out.append, r) {
+			t.Errorf("#%d: bad append report: want %v, got %v", i, test.out.append, r)
+		}
+	}
+}
+
+func TestIgnitionConfigValidateRequireVerification(t *testing.T) {
+	type in struct {
+		config IgnitionConfig
+	}
+	type out struct {
+		err error
+	}
+
+	hash := strToPtrStrict("sha512-" + strings.Repeat("0", 128))
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{config: IgnitionConfig{Append: []ConfigReference{{Source: "https://example.com/extra.ign"}}}},
+			out: out{},
+		},
+		{
+			in: in{config: IgnitionConfig{
+				RequireVerification: true,
+				Replace:             &ConfigReference{Source: "https://example.com/config.ign", Verification: Verification{Hash: hash}},
+				Append:              []ConfigReference{{Source: "https://example.com/extra.ign", Verification: Verification{Hash: hash}}},
+			}},
+			out: out{},
+		},
+		{
+			in: in{config: IgnitionConfig{
+				RequireVerification: true,
+				Replace:             &ConfigReference{Source: "https://example.com/config.ign"},
+			}},
+			out: out{err: errors.ErrConfigVerificationRequired},
+		},
+		{
+			in: in{config: IgnitionConfig{
+				RequireVerification: true,
+				Append:              []ConfigReference{{Source: "https://example.com/extra.ign", Verification: Verification{Hash: hash}}, {Source: "https://example.com/more.ign"}},
+			}},
+			out: out{err: errors.ErrConfigVerificationRequired},
+		},
+		{
+			in:  in{config: IgnitionConfig{RequireVerification: true, Fragments: []Fragment{"network"}}},
+			out: out{err: errors.ErrConfigFragmentsUnverified},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.config.ValidateRequireVerification()
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestIgnitionValidateRateLimit(t *testing.T) {
+	type in struct {
+		ignition Ignition
+	}
+	type out struct {
+		report report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{ignition: Ignition{}},
+			out: out{},
+		},
+		{
+			in:  in{ignition: Ignition{RateLimit: intToPtr(0)}},
+			out: out{},
+		},
+		{
+			in:  in{ignition: Ignition{RateLimit: intToPtr(10 << 20)}},
+			out: out{},
+		},
+		{
+			in:  in{ignition: Ignition{RateLimit: intToPtr(-1)}},
+			out: out{report: report.ReportFromError(errors.ErrRateLimit, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.ignition.ValidateRateLimit()
+		if !reflect.DeepEqual(test.out.report, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.report, r)
+		}
+	}
+}
+
+func TestIgnitionValidateAnnotations(t *testing.T) {
+	type in struct {
+		ignition Ignition
+	}
+	type out struct {
+		report report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{ignition: Ignition{}},
+			out: out{},
+		},
+		{
+			in:  in{ignition: Ignition{Annotations: map[string]string{"pipeline": "deploy", "run": ""}}},
+			out: out{},
+		},
+		{
+			in:  in{ignition: Ignition{Annotations: map[string]string{"": "4711"}}},
+			out: out{report: report.ReportFromError(errors.ErrAnnotationKeyEmpty, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.ignition.ValidateAnnotations()
+		if !reflect.DeepEqual(test.out.report, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.report, r)
+		}
+	}
+}
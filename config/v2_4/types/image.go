@@ -0,0 +1,77 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (i Image) ValidateDevice() report.Report {
+	if len(i.Device) == 0 {
+		return report.ReportFromError(errors.ErrImageDeviceRequired, report.EntryError)
+	}
+	if err := validatePath(i.Device); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (i Image) ValidateSource() report.Report {
+	// unlike files, an empty source doesn't make sense for an image
+	if i.Source == "" {
+		return report.ReportFromError(errors.ErrImageSourceRequired, report.EntryError)
+	}
+	return report.ReportFromError(validateURL(i.Source), report.EntryError)
+}
+
+func (i Image) ValidateCompression() report.Report {
+	switch i.Compression {
+	case "", "gzip", "xz", "zstd":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrCompressionInvalid, report.EntryError)
+	}
+}
+
+func (i Image) ValidateHTTPHeaders() report.Report {
+	r := report.Report{}
+
+	if len(i.HTTPHeaders) < 1 {
+		return r
+	}
+
+	u, err := url.Parse(i.Source)
+	if err != nil {
+		r.Add(report.Entry{
+			Message: errors.ErrInvalidUrl.Error(),
+			Kind:    report.EntryError,
+		})
+		return r
+	}
+
+	switch u.Scheme {
+	case "http", "https", "oci":
+	default:
+		r.Add(report.Entry{
+			Message: errors.ErrUnsupportedSchemeForHTTPHeaders.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+
+	return r
+}
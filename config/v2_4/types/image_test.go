@@ -0,0 +1,57 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestImageValidateSource(t *testing.T) {
+	type in struct {
+		source string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{source: "https://example.com/disk.img.gz"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{source: ""},
+			out: out{err: errors.ErrImageSourceRequired},
+		},
+		{
+			in:  in{source: "ftp://example.com/disk.img"},
+			out: out{err: errors.ErrInvalidScheme},
+		},
+	}
+
+	for i, test := range tests {
+		r := Image{Source: test.in.source}.ValidateSource()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
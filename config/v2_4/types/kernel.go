@@ -0,0 +1,197 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+var (
+	kernelModuleNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// kernelModuleOptionRegexp matches the parameters modprobe.d(5) options
+	// lines can set; blanks would start the next parameter.
+	kernelModuleOptionRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+(=[^\s]+)?$`)
+	kernelDeviceVendorRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{4}$`)
+	hugepageSizeRegexp       = regexp.MustCompile(`^([0-9]+)([KMG])$`)
+)
+
+func (k Kernel) ValidateModules() report.Report {
+	seen := map[string]struct{}{}
+	for _, m := range k.Modules {
+		// modprobe treats '-' and '_' in module names alike
+		name := strings.Replace(m.Name, "-", "_", -1)
+		if _, ok := seen[name]; ok {
+			return report.ReportFromError(errors.ErrKernelModuleDuplicate, report.EntryError)
+		}
+		seen[name] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (m KernelModule) Validate() report.Report {
+	if !m.Blacklist && len(m.Options) == 0 {
+		return report.ReportFromError(errors.ErrKernelModuleEmpty, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m KernelModule) ValidateName() report.Report {
+	if !kernelModuleNameRegexp.MatchString(m.Name) {
+		return report.ReportFromError(errors.ErrKernelModuleName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m KernelModule) ValidateOptions() report.Report {
+	for _, o := range m.Options {
+		if !kernelModuleOptionRegexp.MatchString(o) {
+			return report.ReportFromError(errors.ErrKernelModuleOption, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (d KernelDevice) Validate() report.Report {
+	if d.Subsystem == "" && d.Kernel == "" {
+		return report.ReportFromError(errors.ErrKernelDeviceMatch, report.EntryError)
+	}
+	if d.Owner == "" && d.Group == "" && d.Mode == nil {
+		return report.ReportFromError(errors.ErrKernelDeviceNoAssignment, report.EntryError)
+	}
+	for _, v := range []string{d.Subsystem, d.Kernel} {
+		if strings.ContainsAny(v, "\"\\\n\r") {
+			return report.ReportFromError(errors.ErrKernelDeviceMatchValue, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (d KernelDevice) ValidateVendor() report.Report {
+	if d.Vendor != "" && !kernelDeviceVendorRegexp.MatchString(d.Vendor) {
+		return report.ReportFromError(errors.ErrKernelDeviceVendor, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (d KernelDevice) ValidateOwner() report.Report {
+	return validateDeviceOwner(d.Owner)
+}
+
+func (d KernelDevice) ValidateGroup() report.Report {
+	return validateDeviceOwner(d.Group)
+}
+
+func validateDeviceOwner(name string) report.Report {
+	if name != "" && !sudoerNameRegexp.MatchString(name) {
+		return report.ReportFromError(errors.ErrKernelDeviceOwnerName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (d KernelDevice) ValidateMode() report.Report {
+	if d.Mode != nil && (*d.Mode < 0 || *d.Mode > 0777) {
+		return report.ReportFromError(errors.ErrKernelDeviceMode, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (h KernelHugepages) ValidateDefaultSize() report.Report {
+	if h.DefaultSize != "" && hugepageSizeKB(h.DefaultSize) == 0 {
+		return report.ReportFromError(errors.ErrHugepageSize, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (h KernelHugepages) ValidateOvercommit() report.Report {
+	if h.Overcommit != nil && *h.Overcommit < 0 {
+		return report.ReportFromError(errors.ErrHugepageCount, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (h KernelHugepages) ValidateShmGroup() report.Report {
+	if h.ShmGroup != nil && *h.ShmGroup < 0 {
+		return report.ReportFromError(errors.ErrHugepageShmGroup, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (h KernelHugepages) ValidatePages() report.Report {
+	// the nodes listed for each size, -1 standing for all nodes
+	nodes := map[int]map[int]struct{}{}
+	for _, p := range h.Pages {
+		size := hugepageSizeKB(p.Size)
+		node := -1
+		if p.Node != nil {
+			node = *p.Node
+		}
+		if nodes[size] == nil {
+			nodes[size] = map[int]struct{}{}
+		}
+		_, dup := nodes[size][node]
+		_, all := nodes[size][-1]
+		if dup || (node == -1 && len(nodes[size]) > 0) || (node != -1 && all) {
+			return report.ReportFromError(errors.ErrHugepageDuplicate, report.EntryError)
+		}
+		nodes[size][node] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (p KernelHugepage) ValidateSize() report.Report {
+	if hugepageSizeKB(p.Size) == 0 {
+		return report.ReportFromError(errors.ErrHugepageSize, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p KernelHugepage) ValidateCount() report.Report {
+	if p.Count < 0 {
+		return report.ReportFromError(errors.ErrHugepageCount, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p KernelHugepage) ValidateNode() report.Report {
+	if p.Node != nil && *p.Node < 0 {
+		return report.ReportFromError(errors.ErrHugepageNode, report.EntryError)
+	}
+	return report.Report{}
+}
+
+// hugepageSizeKB returns the size in KiB of a hugepage size like "2M", or
+// 0 if it isn't one.
+func hugepageSizeKB(size string) int {
+	m := hugepageSizeRegexp.FindStringSubmatch(size)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 || n&(n-1) != 0 {
+		return 0
+	}
+	switch m[2] {
+	case "M":
+		n <<= 10
+	case "G":
+		n <<= 20
+	}
+	return n
+}
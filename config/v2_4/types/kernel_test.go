@@ -0,0 +1,202 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestKernelValidate(t *testing.T) {
+	type in struct {
+		kernel Kernel
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{kernel: Kernel{}},
+			out: out{},
+		},
+		{
+			in: in{kernel: Kernel{
+				Modules: []KernelModule{
+					{Name: "nouveau", Blacklist: true, Options: []string{"modeset=0"}},
+					{Name: "nvidia-drm", Options: []string{"modeset=1", "fbdev=1"}},
+					{Name: "nvidia", Options: []string{"NVreg_RegistryDwords=RMUseSwI2c=0x01;RMI2cSpeed=100"}},
+				},
+				Devices: []KernelDevice{
+					{Subsystem: "drm", Kernel: "renderD*", Vendor: "0x10de", Group: "render", Mode: intToPtr(0660)},
+					{Kernel: "nvidia*", Owner: "root", Group: "video"},
+				},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{kernel: Kernel{Modules: []KernelModule{{Name: "nvidia_drm", Options: []string{"modeset=1"}}, {Name: "nvidia-drm", Blacklist: true}}}},
+			out: out{err: errors.ErrKernelModuleDuplicate},
+		},
+		{
+			in:  in{kernel: Kernel{Modules: []KernelModule{{Name: "nouveau"}}}},
+			out: out{err: errors.ErrKernelModuleEmpty},
+		},
+		{
+			in:  in{kernel: Kernel{Modules: []KernelModule{{Name: "nouveau.ko", Blacklist: true}}}},
+			out: out{err: errors.ErrKernelModuleName},
+		},
+		{
+			in:  in{kernel: Kernel{Modules: []KernelModule{{Name: "nvidia", Options: []string{"NVreg_EnableMSI=1\ninstall nvidia /bin/sh"}}}}},
+			out: out{err: errors.ErrKernelModuleOption},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Vendor: "0x10de", Mode: intToPtr(0666)}}}},
+			out: out{err: errors.ErrKernelDeviceMatch},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Kernel: "nvidia*"}}}},
+			out: out{err: errors.ErrKernelDeviceNoAssignment},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Kernel: `nvidia*", RUN+="/bin/sh`, Mode: intToPtr(0666)}}}},
+			out: out{err: errors.ErrKernelDeviceMatchValue},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Subsystem: "drm", Vendor: "nvidia", Group: "video"}}}},
+			out: out{err: errors.ErrKernelDeviceVendor},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Subsystem: "drm", Group: "video users"}}}},
+			out: out{err: errors.ErrKernelDeviceOwnerName},
+		},
+		{
+			in:  in{kernel: Kernel{Devices: []KernelDevice{{Subsystem: "drm", Mode: intToPtr(04755)}}}},
+			out: out{err: errors.ErrKernelDeviceMode},
+		},
+	}
+
+	for i, test := range tests {
+		k := test.in.kernel
+		r := k.ValidateModules()
+		for _, m := range k.Modules {
+			r.Merge(m.Validate())
+			r.Merge(m.ValidateName())
+			r.Merge(m.ValidateOptions())
+		}
+		for _, d := range k.Devices {
+			r.Merge(d.Validate())
+			r.Merge(d.ValidateVendor())
+			r.Merge(d.ValidateOwner())
+			r.Merge(d.ValidateGroup())
+			r.Merge(d.ValidateMode())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestKernelHugepagesValidate(t *testing.T) {
+	type in struct {
+		hugepages KernelHugepages
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{hugepages: KernelHugepages{}},
+			out: out{},
+		},
+		{
+			in: in{hugepages: KernelHugepages{
+				DefaultSize: "1G",
+				Pages: []KernelHugepage{
+					{Size: "1G", Count: 8, Node: intToPtr(0)},
+					{Size: "1G", Count: 8, Node: intToPtr(1)},
+					{Size: "2048K", Count: 1024},
+				},
+				Overcommit: intToPtr(64),
+				ShmGroup:   intToPtr(1001),
+			}},
+			out: out{},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{DefaultSize: "3M"}},
+			out: out{err: errors.ErrHugepageSize},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "2MB", Count: 1}}}},
+			out: out{err: errors.ErrHugepageSize},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "2M", Count: -1}}}},
+			out: out{err: errors.ErrHugepageCount},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "2M", Count: 1, Node: intToPtr(-1)}}}},
+			out: out{err: errors.ErrHugepageNode},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "2M", Count: 1, Node: intToPtr(0)}, {Size: "2048K", Count: 2, Node: intToPtr(0)}}}},
+			out: out{err: errors.ErrHugepageDuplicate},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "1G", Count: 1}, {Size: "1G", Count: 2, Node: intToPtr(1)}}}},
+			out: out{err: errors.ErrHugepageDuplicate},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Pages: []KernelHugepage{{Size: "1G", Count: 1, Node: intToPtr(1)}, {Size: "1G", Count: 2}}}},
+			out: out{err: errors.ErrHugepageDuplicate},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{Overcommit: intToPtr(-1)}},
+			out: out{err: errors.ErrHugepageCount},
+		},
+		{
+			in:  in{hugepages: KernelHugepages{ShmGroup: intToPtr(-1)}},
+			out: out{err: errors.ErrHugepageShmGroup},
+		},
+	}
+
+	for i, test := range tests {
+		h := test.in.hugepages
+		r := h.ValidateDefaultSize()
+		r.Merge(h.ValidateOvercommit())
+		r.Merge(h.ValidateShmGroup())
+		r.Merge(h.ValidatePages())
+		for _, p := range h.Pages {
+			r.Merge(p.ValidateSize())
+			r.Merge(p.ValidateCount())
+			r.Merge(p.ValidateNode())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
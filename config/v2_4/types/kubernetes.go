@@ -0,0 +1,49 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (k BootstrapKubeconfig) ValidateServer() report.Report {
+	u, err := url.Parse(k.Server)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	if u.Scheme != "https" {
+		return report.ReportFromError(errors.ErrKubernetesServerScheme, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (k BootstrapKubeconfig) ValidatePath() report.Report {
+	if k.Path == "" {
+		return report.Report{}
+	}
+	return report.ReportFromError(validatePath(k.Path), report.EntryError)
+}
+
+// the token is fetched like the certificate authority
+func (t TokenReference) ValidateSource() report.Report {
+	return CaReference(t).ValidateSource()
+}
+
+func (t TokenReference) ValidateHTTPHeaders() report.Report {
+	return CaReference(t).ValidateHTTPHeaders()
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// ValidateRule only checks that a rule is named; which rules exist is up to
+// the linter, which reports suppressions of unknown ones.
+func (s LintSuppression) ValidateRule() report.Report {
+	if s.Rule == "" {
+		return report.ReportFromError(errors.ErrLintRuleEmpty, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (m Manifest) ValidateFilesystem() report.Report {
+	if m.Filesystem == "" {
+		return report.ReportFromError(errors.ErrNoFilesystem, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m Manifest) ValidatePath() report.Report {
+	return report.ReportFromError(validatePath(m.Path), report.EntryError)
+}
+
+func (m Manifest) ValidateSource() report.Report {
+	if m.Source == "" {
+		return report.ReportFromError(errors.ErrManifestSourceRequired, report.EntryError)
+	}
+	return report.ReportFromError(validateURL(m.Source), report.EntryError)
+}
+
+func (m Manifest) ValidateHTTPHeaders() report.Report {
+	if len(m.HTTPHeaders) < 1 {
+		return report.Report{}
+	}
+	u, err := url.Parse(m.Source)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
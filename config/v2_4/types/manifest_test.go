@@ -0,0 +1,75 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestManifestValidate(t *testing.T) {
+	type in struct {
+		manifest Manifest
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{manifest: Manifest{Filesystem: "root", Path: "/opt/tools", Source: "https://example.com/tools.json"}},
+			out: out{},
+		},
+		{
+			in:  in{manifest: Manifest{Path: "/opt/tools", Source: "https://example.com/tools.json"}},
+			out: out{err: errors.ErrNoFilesystem},
+		},
+		{
+			in:  in{manifest: Manifest{Filesystem: "root", Path: "opt/tools", Source: "https://example.com/tools.json"}},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in:  in{manifest: Manifest{Filesystem: "root", Path: "/opt/tools"}},
+			out: out{err: errors.ErrManifestSourceRequired},
+		},
+		{
+			in: in{manifest: Manifest{
+				Filesystem:  "root",
+				Path:        "/opt/tools",
+				Source:      "tftp://example.com/tools.json",
+				HTTPHeaders: HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+			}},
+			out: out{err: errors.ErrUnsupportedSchemeForHTTPHeaders},
+		},
+	}
+
+	for i, test := range tests {
+		m := test.in.manifest
+		r := m.ValidateFilesystem()
+		r.Merge(m.ValidatePath())
+		r.Merge(m.ValidateSource())
+		r.Merge(m.ValidateHTTPHeaders())
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+const (
+	// the default .link file of systemd, which matches every interface
+	defaultLinkFile = "99-default.link"
+
+	maxInterfaceNameLen    = 15
+	maxAltInterfaceNameLen = 127
+)
+
+func (n Networkd) ValidateLinks() report.Report {
+	matches := map[NetworkdLinkMatch]struct{}{}
+	names := map[string]struct{}{}
+	for _, l := range n.Links {
+		m := l.Match.normalized()
+		if _, ok := matches[m]; ok {
+			return report.ReportFromError(errors.ErrNetworkdLinkMatchDuplicate, report.EntryError)
+		}
+		matches[m] = struct{}{}
+
+		for _, name := range append([]string{l.Name}, l.AlternativeNames...) {
+			if name == "" {
+				continue
+			}
+			if _, ok := names[name]; ok {
+				return report.ReportFromError(errors.ErrNetworkdLinkNameDuplicate, report.EntryError)
+			}
+			names[name] = struct{}{}
+		}
+	}
+	return report.Report{}
+}
+
+// Validate checks that the bonds, bridges, VLANs and WireGuard interfaces fit
+// together the way systemd-networkd and the kernel accept, which otherwise
+// only shows when the interfaces fail to come up.
+func (n Networkd) Validate() report.Report {
+	kinds := map[string]string{}
+	for _, netdev := range n.netdevs() {
+		if _, ok := kinds[netdev.name]; ok {
+			return report.ReportFromError(errors.ErrNetworkdNetdevNameDuplicate, report.EntryError)
+		}
+		kinds[netdev.name] = netdev.kind
+	}
+
+	// the bond or bridge of each interface; interfaces given twice by the
+	// same one, or the bond or bridge itself, are left to
+	// validateNetdevMembers
+	members := map[string]string{}
+	for _, b := range n.Bonds {
+		for _, iface := range b.Interfaces {
+			if kinds[iface] == "wireguard" {
+				return report.ReportFromError(errors.ErrNetworkdWireGuardMember, report.EntryError)
+			}
+			if k := kinds[iface]; iface != b.Name && (k == "bond" || k == "bridge") {
+				return report.ReportFromError(errors.ErrNetworkdBondNested, report.EntryError)
+			}
+			if owner, ok := members[iface]; ok && owner != b.Name {
+				return report.ReportFromError(errors.ErrNetworkdMemberDuplicate, report.EntryError)
+			}
+			members[iface] = b.Name
+		}
+	}
+	for _, b := range n.Bridges {
+		for _, iface := range b.Interfaces {
+			if kinds[iface] == "wireguard" {
+				return report.ReportFromError(errors.ErrNetworkdWireGuardMember, report.EntryError)
+			}
+			if iface != b.Name && kinds[iface] == "bridge" {
+				return report.ReportFromError(errors.ErrNetworkdBridgeNested, report.EntryError)
+			}
+			if owner, ok := members[iface]; ok && owner != b.Name {
+				return report.ReportFromError(errors.ErrNetworkdMemberDuplicate, report.EntryError)
+			}
+			members[iface] = b.Name
+		}
+	}
+
+	type vlanKey struct {
+		iface string
+		id    int
+	}
+	vlans := map[vlanKey]struct{}{}
+	for _, v := range n.Vlans {
+		if kinds[v.Interface] == "wireguard" {
+			return report.ReportFromError(errors.ErrNetworkdWireGuardMember, report.EntryError)
+		}
+		if _, ok := members[v.Interface]; ok {
+			return report.ReportFromError(errors.ErrNetworkdVLANOnMember, report.EntryError)
+		}
+		k := vlanKey{v.Interface, v.ID}
+		if _, ok := vlans[k]; ok {
+			return report.ReportFromError(errors.ErrNetworkdVLANDuplicate, report.EntryError)
+		}
+		vlans[k] = struct{}{}
+	}
+
+	generated := map[string]struct{}{}
+	for _, netdev := range n.netdevs() {
+		generated["10-ignition-"+netdev.name+".netdev"] = struct{}{}
+	}
+	for iface := range members {
+		generated["10-ignition-"+iface+".network"] = struct{}{}
+	}
+	for k := range vlans {
+		generated["10-ignition-"+k.iface+".network"] = struct{}{}
+	}
+	for _, w := range n.WireGuard {
+		generated["10-ignition-"+w.Name+".network"] = struct{}{}
+	}
+	for _, u := range n.Units {
+		if _, ok := generated[u.Name]; ok && u.Contents != "" {
+			return report.ReportFromError(errors.ErrNetworkdUnitGenerated, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+type netdev struct {
+	name string
+	kind string
+}
+
+func (n Networkd) netdevs() []netdev {
+	var res []netdev
+	for _, b := range n.Bonds {
+		res = append(res, netdev{b.Name, "bond"})
+	}
+	for _, b := range n.Bridges {
+		res = append(res, netdev{b.Name, "bridge"})
+	}
+	for _, v := range n.Vlans {
+		res = append(res, netdev{v.Name, "vlan"})
+	}
+	for _, w := range n.WireGuard {
+		res = append(res, netdev{w.Name, "wireguard"})
+	}
+	return res
+}
+
+func (b NetworkdBond) ValidateName() report.Report {
+	return validateNetdevName(b.Name)
+}
+
+func (b NetworkdBond) ValidateMode() report.Report {
+	switch b.Mode {
+	case "", "balance-rr", "active-backup", "balance-xor", "broadcast", "802.3ad", "balance-tlb", "balance-alb":
+		return report.Report{}
+	}
+	return report.ReportFromError(errors.ErrNetworkdBondModeInvalid, report.EntryError)
+}
+
+func (b NetworkdBond) ValidateMIIMonitor() report.Report {
+	if b.MIIMonitor != nil && *b.MIIMonitor < 0 {
+		return report.ReportFromError(errors.ErrNetworkdBondMIIMonitorInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (b NetworkdBond) ValidateInterfaces() report.Report {
+	return validateNetdevMembers(b.Name, b.Interfaces)
+}
+
+func (b NetworkdBridge) ValidateName() report.Report {
+	return validateNetdevName(b.Name)
+}
+
+func (b NetworkdBridge) ValidateInterfaces() report.Report {
+	return validateNetdevMembers(b.Name, b.Interfaces)
+}
+
+func (v NetworkdVLAN) ValidateName() report.Report {
+	return validateNetdevName(v.Name)
+}
+
+func (v NetworkdVLAN) ValidateID() report.Report {
+	if v.ID < 0 || v.ID > 4094 {
+		return report.ReportFromError(errors.ErrNetworkdVLANIDInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (v NetworkdVLAN) ValidateInterface() report.Report {
+	switch {
+	case v.Interface == "":
+		return report.ReportFromError(errors.ErrNetworkdVLANInterfaceRequired, report.EntryError)
+	case !validInterfaceName(v.Interface, maxAltInterfaceNameLen):
+		return report.ReportFromError(errors.ErrNetworkdInterfaceInvalid, report.EntryError)
+	case v.Interface == v.Name:
+		return report.ReportFromError(errors.ErrNetworkdNetdevSelf, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func validateNetdevName(name string) report.Report {
+	if !validInterfaceName(name, maxInterfaceNameLen) {
+		return report.ReportFromError(errors.ErrNetworkdLinkNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+// validateNetdevMembers checks the interfaces of a bond or bridge, which
+// may be given by their alternative names as well.
+func validateNetdevMembers(name string, ifaces []string) report.Report {
+	seen := map[string]struct{}{}
+	for _, iface := range ifaces {
+		if !validInterfaceName(iface, maxAltInterfaceNameLen) {
+			return report.ReportFromError(errors.ErrNetworkdInterfaceInvalid, report.EntryError)
+		}
+		if iface == name {
+			return report.ReportFromError(errors.ErrNetworkdNetdevSelf, report.EntryError)
+		}
+		if _, ok := seen[iface]; ok {
+			return report.ReportFromError(errors.ErrNetworkdMemberDuplicate, report.EntryError)
+		}
+		seen[iface] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (l NetworkdLink) ValidateName() report.Report {
+	if l.Name == "" {
+		if len(l.AlternativeNames) == 0 {
+			return report.ReportFromError(errors.ErrNetworkdLinkNameRequired, report.EntryError)
+		}
+		return report.Report{}
+	}
+	if !validInterfaceName(l.Name, maxInterfaceNameLen) {
+		return report.ReportFromError(errors.ErrNetworkdLinkNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l NetworkdLink) ValidateAlternativeNames() report.Report {
+	for _, name := range l.AlternativeNames {
+		if !validInterfaceName(name, maxAltInterfaceNameLen) {
+			return report.ReportFromError(errors.ErrNetworkdLinkAltNameInvalid, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (l NetworkdLink) ValidateMatch() report.Report {
+	m := l.Match
+	if m == (NetworkdLinkMatch{}) {
+		return report.ReportFromError(errors.ErrNetworkdLinkMatchRequired, report.EntryError)
+	}
+	for _, mac := range []string{m.MACAddress, m.PermanentMACAddress} {
+		if _, ok := normalizeMAC(mac); mac != "" && !ok {
+			return report.ReportFromError(errors.ErrNetworkdLinkMACInvalid, report.EntryError)
+		}
+	}
+	// a driver, or a path with wildcards, can match several interfaces,
+	// which can't all be given the same name
+	if m.MACAddress == "" && m.PermanentMACAddress == "" &&
+		(m.Path == "" || strings.ContainsAny(m.Path, "*?[")) {
+		return report.ReportFromError(errors.ErrNetworkdLinkMatchAmbiguous, report.EntryWarning)
+	}
+	return report.Report{}
+}
+
+// normalized returns the match with its MAC addresses normalized, so that
+// matches can be compared.
+func (m NetworkdLinkMatch) normalized() NetworkdLinkMatch {
+	if mac, ok := normalizeMAC(m.MACAddress); ok {
+		m.MACAddress = mac
+	}
+	if mac, ok := normalizeMAC(m.PermanentMACAddress); ok {
+		m.PermanentMACAddress = mac
+	}
+	return m
+}
+
+// normalizeMAC returns the Ethernet MAC address mac, which may be written
+// with colons, hyphens or dots, in the lowercase, colon separated form
+// systemd prints, e.g. "52:54:00:12:34:56".
+func normalizeMAC(mac string) (string, bool) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return "", false
+	}
+	return hw.String(), true
+}
+
+func validInterfaceName(name string, maxLen int) bool {
+	if name == "" || len(name) > maxLen || name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		if r == '/' || r == ':' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateLinkFilePath warns about .link files written where systemd-udevd
+// reads them, which sort after its default and so never apply: udevd only
+// applies the first .link file matching an interface, and the default
+// matches every interface. A file of the default's name replaces it.
+func validateLinkFilePath(p string) report.Report {
+	switch path.Dir(p) {
+	case "/etc/systemd/network", "/run/systemd/network":
+	default:
+		return report.Report{}
+	}
+	if name := path.Base(p); path.Ext(name) == ".link" && name > defaultLinkFile {
+		return report.ReportFromError(errors.ErrNetworkdLinkFileNeverMatches, report.EntryWarning)
+	}
+	return report.Report{}
+}
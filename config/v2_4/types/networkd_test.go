@@ -0,0 +1,316 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestNetworkdLinkValidate(t *testing.T) {
+	type in struct {
+		link NetworkdLink
+	}
+	type out struct {
+		report report.Report
+	}
+
+	mac := NetworkdLinkMatch{MACAddress: "52:54:00:12:34:56"}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{link: NetworkdLink{Name: "uplink", Match: mac}},
+			out: out{},
+		},
+		{
+			in:  in{link: NetworkdLink{AlternativeNames: []string{"uplink", "enp0s3-provisioning"}, Match: NetworkdLinkMatch{Path: "pci-0000:00:03.0"}}},
+			out: out{},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink", Match: NetworkdLinkMatch{PermanentMACAddress: "52-54-00-12-34-56"}}},
+			out: out{},
+		},
+		{
+			in:  in{link: NetworkdLink{Match: mac}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkNameRequired, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink-of-the-rack", Match: mac}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkNameInvalid, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "up link", Match: mac}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkNameInvalid, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "..", Match: mac}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkNameInvalid, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{AlternativeNames: []string{strings.Repeat("a", 128)}, Match: mac}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkAltNameInvalid, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink"}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkMatchRequired, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink", Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34"}}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkMACInvalid, report.EntryError)},
+		},
+		{
+			// an InfiniBand address can't be matched by .link files
+			in:  in{link: NetworkdLink{Name: "uplink", Match: NetworkdLinkMatch{MACAddress: "00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01"}}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkMACInvalid, report.EntryError)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink", Match: NetworkdLinkMatch{Driver: "virtio_net"}}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkMatchAmbiguous, report.EntryWarning)},
+		},
+		{
+			in:  in{link: NetworkdLink{Name: "uplink", Match: NetworkdLinkMatch{Path: "pci-0000:00:0*"}}},
+			out: out{report.ReportFromError(errors.ErrNetworkdLinkMatchAmbiguous, report.EntryWarning)},
+		},
+	}
+
+	for i, test := range tests {
+		l := test.in.link
+		r := l.ValidateName()
+		r.Merge(l.ValidateAlternativeNames())
+		r.Merge(l.ValidateMatch())
+		if !reflect.DeepEqual(test.out.report, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.report, r)
+		}
+	}
+}
+
+func TestNetworkdLinksValidate(t *testing.T) {
+	type in struct {
+		links []NetworkdLink
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{links: []NetworkdLink{
+				{Name: "uplink", Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34:56"}},
+				{Name: "storage", AlternativeNames: []string{"san"}, Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34:57"}},
+			}},
+			out: out{},
+		},
+		{
+			// the same address, written differently
+			in: in{links: []NetworkdLink{
+				{Name: "uplink", Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34:56"}},
+				{Name: "storage", Match: NetworkdLinkMatch{MACAddress: "52-54-00-12-34-56"}},
+			}},
+			out: out{err: errors.ErrNetworkdLinkMatchDuplicate},
+		},
+		{
+			in: in{links: []NetworkdLink{
+				{Name: "uplink", Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34:56"}},
+				{AlternativeNames: []string{"uplink"}, Match: NetworkdLinkMatch{MACAddress: "52:54:00:12:34:57"}},
+			}},
+			out: out{err: errors.ErrNetworkdLinkNameDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		r := Networkd{Links: test.in.links}.ValidateLinks()
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestLinkFilePathValidate(t *testing.T) {
+	tests := []struct {
+		path string
+		warn bool
+	}{
+		{path: "/etc/systemd/network/10-uplink.link"},
+		{path: "/etc/systemd/network/uplink.link", warn: true},
+		{path: "/run/systemd/network/default.link", warn: true},
+		{path: "/etc/systemd/network/99-default.link"},
+		{path: "/etc/systemd/network/uplink.network"},
+		{path: "/etc/uplink.link"},
+	}
+
+	for i, test := range tests {
+		r := File{Node: Node{Filesystem: "root", Path: test.path}}.Validate()
+		var expected report.Report
+		if test.warn {
+			expected = report.ReportFromError(errors.ErrNetworkdLinkFileNeverMatches, report.EntryWarning)
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestNetdevValidate(t *testing.T) {
+	type in struct {
+		networkd Networkd
+	}
+	type out struct {
+		err error
+	}
+
+	miimon := -1
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{networkd: Networkd{
+				Bonds:   []NetworkdBond{{Name: "bond0", Mode: "802.3ad", Interfaces: []string{"eth0", "eth1"}}},
+				Bridges: []NetworkdBridge{{Name: "br0", Interfaces: []string{"bond0.10"}}},
+				Vlans:   []NetworkdVLAN{{Name: "bond0.10", ID: 10, Interface: "bond0"}},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{networkd: Networkd{Bonds: []NetworkdBond{{Name: "bond0", Mode: "lacp"}}}},
+			out: out{err: errors.ErrNetworkdBondModeInvalid},
+		},
+		{
+			in:  in{networkd: Networkd{Bonds: []NetworkdBond{{Name: "bond0", MIIMonitor: &miimon}}}},
+			out: out{err: errors.ErrNetworkdBondMIIMonitorInvalid},
+		},
+		{
+			in:  in{networkd: Networkd{Bonds: []NetworkdBond{{Name: "bond0", Interfaces: []string{"eth0", "bond0"}}}}},
+			out: out{err: errors.ErrNetworkdNetdevSelf},
+		},
+		{
+			in:  in{networkd: Networkd{Bridges: []NetworkdBridge{{Name: "br0", Interfaces: []string{"eth0", "eth0"}}}}},
+			out: out{err: errors.ErrNetworkdMemberDuplicate},
+		},
+		{
+			in:  in{networkd: Networkd{Vlans: []NetworkdVLAN{{Name: "vlan4095", ID: 4095, Interface: "eth0"}}}},
+			out: out{err: errors.ErrNetworkdVLANIDInvalid},
+		},
+		{
+			in:  in{networkd: Networkd{Vlans: []NetworkdVLAN{{Name: "vlan10", ID: 10}}}},
+			out: out{err: errors.ErrNetworkdVLANInterfaceRequired},
+		},
+		{
+			in: in{networkd: Networkd{
+				Bonds: []NetworkdBond{{Name: "uplink"}},
+				Vlans: []NetworkdVLAN{{Name: "uplink", ID: 10, Interface: "eth0"}},
+			}},
+			out: out{err: errors.ErrNetworkdNetdevNameDuplicate},
+		},
+		{
+			in: in{networkd: Networkd{Bonds: []NetworkdBond{
+				{Name: "bond0", Interfaces: []string{"bond1"}},
+				{Name: "bond1", Interfaces: []string{"eth0"}},
+			}}},
+			out: out{err: errors.ErrNetworkdBondNested},
+		},
+		{
+			in: in{networkd: Networkd{Bridges: []NetworkdBridge{
+				{Name: "br0", Interfaces: []string{"br1"}},
+				{Name: "br1"},
+			}}},
+			out: out{err: errors.ErrNetworkdBridgeNested},
+		},
+		{
+			in: in{networkd: Networkd{
+				Bonds:   []NetworkdBond{{Name: "bond0", Interfaces: []string{"eth0"}}},
+				Bridges: []NetworkdBridge{{Name: "br0", Interfaces: []string{"eth0"}}},
+			}},
+			out: out{err: errors.ErrNetworkdMemberDuplicate},
+		},
+		{
+			in: in{networkd: Networkd{
+				Bonds: []NetworkdBond{{Name: "bond0", Interfaces: []string{"eth0"}}},
+				Vlans: []NetworkdVLAN{{Name: "vlan10", ID: 10, Interface: "eth0"}},
+			}},
+			out: out{err: errors.ErrNetworkdVLANOnMember},
+		},
+		{
+			in: in{networkd: Networkd{Vlans: []NetworkdVLAN{
+				{Name: "vlan10", ID: 10, Interface: "eth0"},
+				{Name: "storage", ID: 10, Interface: "eth0"},
+			}}},
+			out: out{err: errors.ErrNetworkdVLANDuplicate},
+		},
+		{
+			in: in{networkd: Networkd{
+				Vlans: []NetworkdVLAN{{Name: "vlan10", ID: 10, Interface: "eth0"}},
+				Units: []Networkdunit{{Name: "10-ignition-eth0.network", Contents: "[Network]\nDHCP=yes"}},
+			}},
+			out: out{err: errors.ErrNetworkdUnitGenerated},
+		},
+		{
+			in: in{networkd: Networkd{
+				Bridges:   []NetworkdBridge{{Name: "br0", Interfaces: []string{"wg0"}}},
+				WireGuard: []NetworkdWireGuard{{Name: "wg0"}},
+			}},
+			out: out{err: errors.ErrNetworkdWireGuardMember},
+		},
+		{
+			in: in{networkd: Networkd{
+				WireGuard: []NetworkdWireGuard{{Name: "wg0"}},
+				Units:     []Networkdunit{{Name: "10-ignition-wg0.network", Contents: "[Network]\nDHCP=no"}},
+			}},
+			out: out{err: errors.ErrNetworkdUnitGenerated},
+		},
+		{
+			// drop-ins extend the generated file
+			in: in{networkd: Networkd{
+				Vlans: []NetworkdVLAN{{Name: "vlan10", ID: 10, Interface: "eth0"}},
+				Units: []Networkdunit{{Name: "10-ignition-eth0.network", Dropins: []NetworkdDropin{{Name: "dhcp.conf", Contents: "[Network]\nDHCP=yes"}}}},
+			}},
+			out: out{},
+		},
+	}
+
+	for i, test := range tests {
+		n := test.in.networkd
+		r := n.Validate()
+		for _, b := range n.Bonds {
+			r.Merge(b.ValidateName())
+			r.Merge(b.ValidateMode())
+			r.Merge(b.ValidateMIIMonitor())
+			r.Merge(b.ValidateInterfaces())
+		}
+		for _, b := range n.Bridges {
+			r.Merge(b.ValidateName())
+			r.Merge(b.ValidateInterfaces())
+		}
+		for _, v := range n.Vlans {
+			r.Merge(v.ValidateName())
+			r.Merge(v.ValidateID())
+			r.Merge(v.ValidateInterface())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
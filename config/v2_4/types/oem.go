@@ -0,0 +1,58 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+var oemReleaseIDRegex = regexp.MustCompile(`^[a-z0-9._-]+$`)
+
+// The files stage keeps the grub.cfg fragment between these markers so it can
+// be replaced on later runs without touching the rest of the file.
+const (
+	oemGrubBeginMarker = "# BEGIN IGNITION"
+	oemGrubEndMarker   = "# END IGNITION"
+)
+
+func (o OEM) ValidateGrubConfig() report.Report {
+	for _, line := range strings.Split(o.GrubConfig, "\n") {
+		line = strings.TrimSpace(line)
+		if line == oemGrubBeginMarker || line == oemGrubEndMarker {
+			return report.ReportFromError(errors.ErrOEMGrubConfigMarker, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (r OEMRelease) ValidateID() report.Report {
+	if r.ID != "" && !oemReleaseIDRegex.MatchString(r.ID) {
+		return report.ReportFromError(errors.ErrOEMReleaseID, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (r OEMRelease) ValidateValues() report.Report {
+	for _, v := range []string{r.BugReportURL, r.HomeURL, r.ID, r.Name, r.VersionID} {
+		if strings.ContainsAny(v, "\r\n") {
+			return report.ReportFromError(errors.ErrOEMReleaseValue, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestOEMValidate(t *testing.T) {
+	type in struct {
+		oem OEM
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{oem: OEM{}},
+			out: out{},
+		},
+		{
+			in:  in{oem: OEM{GrubConfig: "set linux_append=\"$linux_append flatcar.autologin\"\n"}},
+			out: out{},
+		},
+		{
+			in:  in{oem: OEM{GrubConfig: "# BEGIN IGNITION\nset oem_id=\"example\"\n"}},
+			out: out{err: errors.ErrOEMGrubConfigMarker},
+		},
+		{
+			in:  in{oem: OEM{GrubConfig: "set oem_id=\"example\"\n  # END IGNITION\n"}},
+			out: out{err: errors.ErrOEMGrubConfigMarker},
+		},
+		{
+			in:  in{oem: OEM{Release: &OEMRelease{ID: "example", Name: "Example Cloud", VersionID: "1.2"}}},
+			out: out{},
+		},
+		{
+			in:  in{oem: OEM{Release: &OEMRelease{ID: "Example Cloud"}}},
+			out: out{err: errors.ErrOEMReleaseID},
+		},
+		{
+			in:  in{oem: OEM{Release: &OEMRelease{Name: "Example\nID=evil"}}},
+			out: out{err: errors.ErrOEMReleaseValue},
+		},
+	}
+
+	for i, test := range tests {
+		o := test.in.oem
+		r := o.ValidateGrubConfig()
+		if o.Release != nil {
+			r.Merge(o.Release.ValidateID())
+			r.Merge(o.Release.ValidateValues())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
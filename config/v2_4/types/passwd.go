@@ -15,10 +15,17 @@
 package types
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/validate/report"
 )
 
+// sudoerNameRegexp matches the user and group names which can be written
+// to sudoers without quoting, which are those useradd accepts by default.
+var sudoerNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
 func (p PasswdUser) Validate() report.Report {
 	r := report.Report{}
 	if p.Create != nil {
@@ -65,3 +72,45 @@ func (p PasswdUser) Validate() report.Report {
 	}
 	return r
 }
+
+func (s PasswdSudoer) Validate() report.Report {
+	if (s.User == "") == (s.Group == "") {
+		return report.ReportFromError(errors.ErrSudoerUserOrGroup, report.EntryError)
+	}
+	if !sudoerNameRegexp.MatchString(s.User + s.Group) {
+		return report.ReportFromError(errors.ErrSudoerNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (s PasswdSudoer) ValidateCommands() report.Report {
+	for _, c := range s.Commands {
+		if !strings.HasPrefix(c, "/") || strings.ContainsAny(c, "\r\n\x00") {
+			return report.ReportFromError(errors.ErrSudoerCommandInvalid, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (f PasswdFaillock) Validate() report.Report {
+	return validateNotNegative(f.Deny, f.FailInterval, f.UnlockTime)
+}
+
+func (a PasswdPasswordAging) Validate() report.Report {
+	if r := validateNotNegative(a.MaxDays, a.MinDays, a.WarnAge); r.IsFatal() {
+		return r
+	}
+	if a.MinDays != nil && a.MaxDays != nil && *a.MinDays > *a.MaxDays {
+		return report.ReportFromError(errors.ErrPasswordAgingMinOverMax, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func validateNotNegative(values ...*int) report.Report {
+	for _, v := range values {
+		if v != nil && *v < 0 {
+			return report.ReportFromError(errors.ErrPolicyValueNegative, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestPasswdSudoerValidate(t *testing.T) {
+	type in struct {
+		sudoer PasswdSudoer
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{sudoer: PasswdSudoer{User: "core"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{Group: "ops.team_1-a"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{}},
+			out: out{err: errors.ErrSudoerUserOrGroup},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{User: "core", Group: "wheel"}},
+			out: out{err: errors.ErrSudoerUserOrGroup},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{User: "core ALL"}},
+			out: out{err: errors.ErrSudoerNameInvalid},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{Group: "%wheel"}},
+			out: out{err: errors.ErrSudoerNameInvalid},
+		},
+		{
+			in:  in{sudoer: PasswdSudoer{User: "1core"}},
+			out: out{err: errors.ErrSudoerNameInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.sudoer.Validate()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestPasswdSudoerValidateCommands(t *testing.T) {
+	type in struct {
+		commands []string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{},
+			out: out{err: nil},
+		},
+		{
+			in:  in{commands: []string{"/usr/bin/systemctl restart app.service", "/usr/bin/journalctl"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{commands: []string{"systemctl"}},
+			out: out{err: errors.ErrSudoerCommandInvalid},
+		},
+		{
+			in:  in{commands: []string{"ALL"}},
+			out: out{err: errors.ErrSudoerCommandInvalid},
+		},
+		{
+			in:  in{commands: []string{"/usr/bin/true\ncore ALL=(ALL) ALL"}},
+			out: out{err: errors.ErrSudoerCommandInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := PasswdSudoer{User: "core", Commands: test.in.commands}.ValidateCommands()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestPasswdFaillockValidate(t *testing.T) {
+	type in struct {
+		faillock PasswdFaillock
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{faillock: PasswdFaillock{}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{faillock: PasswdFaillock{Deny: intToPtr(5), FailInterval: intToPtr(900), UnlockTime: intToPtr(0), EvenDenyRoot: true}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{faillock: PasswdFaillock{Deny: intToPtr(-1)}},
+			out: out{err: errors.ErrPolicyValueNegative},
+		},
+		{
+			in:  in{faillock: PasswdFaillock{UnlockTime: intToPtr(-1)}},
+			out: out{err: errors.ErrPolicyValueNegative},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.faillock.Validate()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestPasswdPasswordAgingValidate(t *testing.T) {
+	type in struct {
+		aging PasswdPasswordAging
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{aging: PasswdPasswordAging{MaxDays: intToPtr(365), MinDays: intToPtr(1), WarnAge: intToPtr(7)}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{aging: PasswdPasswordAging{MinDays: intToPtr(1)}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{aging: PasswdPasswordAging{WarnAge: intToPtr(-7)}},
+			out: out{err: errors.ErrPolicyValueNegative},
+		},
+		{
+			in:  in{aging: PasswdPasswordAging{MaxDays: intToPtr(7), MinDays: intToPtr(30)}},
+			out: out{err: errors.ErrPasswordAgingMinOverMax},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.aging.Validate()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (p Proxy) ValidateHTTPProxy() report.Report {
+	return validateProxyURL(p.HTTPProxy)
+}
+
+func (p Proxy) ValidateHTTPSProxy() report.Report {
+	return validateProxyURL(p.HTTPSProxy)
+}
+
+// validateProxyURL checks proxy the way the fetcher parses it: as a url, or
+// if it has no http, https or socks5 scheme, as an http proxy's host and port.
+func validateProxyURL(proxy string) report.Report {
+	if proxy == "" {
+		return report.Report{}
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+		u, err = url.Parse("http://" + proxy)
+	}
+	if err != nil || u.Hostname() == "" {
+		return report.ReportFromError(errors.ErrInvalidProxy, report.EntryError)
+	}
+	return report.Report{}
+}
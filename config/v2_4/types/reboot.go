@@ -0,0 +1,33 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (r Reboot) ValidateMethod() report.Report {
+	switch r.Method {
+	case "", "reboot", "kexec":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrRebootMethod, report.EntryError)
+	}
+}
+
+func (p RebootPath) Validate() report.Report {
+	return report.ReportFromError(validatePath(string(p)), report.EntryError)
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestRedirectsValidateLimit(t *testing.T) {
+	type in struct {
+		redirects Redirects
+	}
+	type out struct {
+		report report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{redirects: Redirects{}},
+			out: out{},
+		},
+		{
+			in:  in{redirects: Redirects{Limit: intToPtr(0), SameHost: true, NoDowngrade: true}},
+			out: out{},
+		},
+		{
+			in:  in{redirects: Redirects{Limit: intToPtr(-1)}},
+			out: out{report: report.ReportFromError(errors.ErrRedirectLimit, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.redirects.ValidateLimit()
+		if !reflect.DeepEqual(test.out.report, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.report, r)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"math"
+	"net"
+	"path"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+const (
+	// the drop-in the routes and routing policy rules of a unit are
+	// written to
+	routesDropin = "10-ignition-routes.conf"
+
+	// the table routes are added to by default
+	mainRoutingTable = 254
+)
+
+func (u Networkdunit) ValidateRoutes() report.Report {
+	if len(u.Routes) == 0 {
+		return report.Report{}
+	}
+	if r := validateRoutesUnit(u); len(r.Entries) > 0 {
+		return r
+	}
+
+	type routeKey struct {
+		destination string
+		ipv6        bool
+		table       int
+		metric      int
+	}
+	seen := map[routeKey]struct{}{}
+	for _, route := range u.Routes {
+		k := routeKey{table: mainRoutingTable}
+		if _, n, err := net.ParseCIDR(route.Destination); err == nil {
+			k.destination = n.String()
+			k.ipv6 = n.IP.To4() == nil
+		} else if gw := net.ParseIP(route.Gateway); gw != nil {
+			k.ipv6 = gw.To4() == nil
+		}
+		if route.Table != nil {
+			k.table = *route.Table
+		}
+		if route.Metric != nil {
+			k.metric = *route.Metric
+		}
+		if _, ok := seen[k]; ok {
+			return report.ReportFromError(errors.ErrNetworkdRouteDuplicate, report.EntryError)
+		}
+		seen[k] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (u Networkdunit) ValidateRoutingPolicyRules() report.Report {
+	// units with routes are checked by ValidateRoutes
+	if len(u.RoutingPolicyRules) == 0 || len(u.Routes) > 0 {
+		return report.Report{}
+	}
+	return validateRoutesUnit(u)
+}
+
+// validateRoutesUnit checks that the routes and rules of u can be added as a
+// drop-in, which only .network files have sections for.
+func validateRoutesUnit(u Networkdunit) report.Report {
+	if path.Ext(u.Name) != ".network" {
+		return report.ReportFromError(errors.ErrNetworkdRoutesNotNetwork, report.EntryError)
+	}
+	for _, d := range u.Dropins {
+		if d.Name == routesDropin {
+			return report.ReportFromError(errors.ErrNetworkdRoutesDropinReserved, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (r NetworkdRoute) Validate() report.Report {
+	if r.Destination == "" && r.Gateway == "" {
+		return report.ReportFromError(errors.ErrNetworkdRouteEmpty, report.EntryError)
+	}
+	var dst *net.IPNet
+	if r.Destination != "" {
+		ip, n, err := net.ParseCIDR(r.Destination)
+		if err != nil {
+			return report.ReportFromError(errors.ErrNetworkdRouteDestinationInvalid, report.EntryError)
+		}
+		if !ip.Equal(n.IP) {
+			return report.ReportFromError(errors.ErrNetworkdPrefixHostBits, report.EntryError)
+		}
+		dst = n
+	}
+	if r.Gateway != "" {
+		gw := net.ParseIP(r.Gateway)
+		if gw == nil {
+			return report.ReportFromError(errors.ErrNetworkdRouteGatewayInvalid, report.EntryError)
+		}
+		if dst != nil && (gw.To4() == nil) != (dst.IP.To4() == nil) {
+			return report.ReportFromError(errors.ErrNetworkdRouteFamilyMismatch, report.EntryError)
+		}
+	}
+	if r.Metric != nil && !inUint32Range(*r.Metric, 0) {
+		return report.ReportFromError(errors.ErrNetworkdRouteMetricInvalid, report.EntryError)
+	}
+	if r.Table != nil && !inUint32Range(*r.Table, 1) {
+		return report.ReportFromError(errors.ErrNetworkdRouteTableInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (r NetworkdRoutingPolicyRule) Validate() report.Report {
+	var families []bool
+	for _, a := range []string{r.From, r.To} {
+		if a == "" {
+			continue
+		}
+		ip := net.ParseIP(a)
+		if ip == nil {
+			var n *net.IPNet
+			var err error
+			if ip, n, err = net.ParseCIDR(a); err != nil {
+				return report.ReportFromError(errors.ErrNetworkdRuleAddressInvalid, report.EntryError)
+			}
+			if !ip.Equal(n.IP) {
+				return report.ReportFromError(errors.ErrNetworkdPrefixHostBits, report.EntryError)
+			}
+		}
+		families = append(families, ip.To4() == nil)
+	}
+	if len(families) == 2 && families[0] != families[1] {
+		return report.ReportFromError(errors.ErrNetworkdRuleFamilyMismatch, report.EntryError)
+	}
+	if r.IncomingInterface != "" && !validInterfaceName(r.IncomingInterface, maxAltInterfaceNameLen) {
+		return report.ReportFromError(errors.ErrNetworkdInterfaceInvalid, report.EntryError)
+	}
+	if r.Priority != nil && !inUint32Range(*r.Priority, 0) {
+		return report.ReportFromError(errors.ErrNetworkdRulePriorityInvalid, report.EntryError)
+	}
+	if !inUint32Range(r.Table, 1) {
+		return report.ReportFromError(errors.ErrNetworkdRouteTableInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func inUint32Range(n int, min int64) bool {
+	return int64(n) >= min && int64(n) <= math.MaxUint32
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestRouteValidate(t *testing.T) {
+	type in struct {
+		route NetworkdRoute
+	}
+	type out struct {
+		err error
+	}
+
+	table := 100
+	zero := 0
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{route: NetworkdRoute{Destination: "10.1.0.0/16", Gateway: "10.0.0.1", Table: &table}},
+			out: out{},
+		},
+		{
+			// a default route
+			in:  in{route: NetworkdRoute{Gateway: "fe80::1"}},
+			out: out{},
+		},
+		{
+			in:  in{route: NetworkdRoute{}},
+			out: out{err: errors.ErrNetworkdRouteEmpty},
+		},
+		{
+			in:  in{route: NetworkdRoute{Destination: "10.1.0.0"}},
+			out: out{err: errors.ErrNetworkdRouteDestinationInvalid},
+		},
+		{
+			in:  in{route: NetworkdRoute{Destination: "10.1.0.1/16"}},
+			out: out{err: errors.ErrNetworkdPrefixHostBits},
+		},
+		{
+			in:  in{route: NetworkdRoute{Destination: "10.1.0.0/16", Gateway: "gateway"}},
+			out: out{err: errors.ErrNetworkdRouteGatewayInvalid},
+		},
+		{
+			in:  in{route: NetworkdRoute{Destination: "fd00:1::/64", Gateway: "10.0.0.1"}},
+			out: out{err: errors.ErrNetworkdRouteFamilyMismatch},
+		},
+		{
+			in:  in{route: NetworkdRoute{Gateway: "10.0.0.1", Table: &zero}},
+			out: out{err: errors.ErrNetworkdRouteTableInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.route.Validate()
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestRoutingPolicyRuleValidate(t *testing.T) {
+	type in struct {
+		rule NetworkdRoutingPolicyRule
+	}
+	type out struct {
+		err error
+	}
+
+	priority := -1
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{From: "10.0.0.0/24", To: "10.1.0.5", IncomingInterface: "eth1", Table: 100}},
+			out: out{},
+		},
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{From: "10.0.0.0/24"}},
+			out: out{err: errors.ErrNetworkdRouteTableInvalid},
+		},
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{From: "10.0.0.1/24", Table: 100}},
+			out: out{err: errors.ErrNetworkdPrefixHostBits},
+		},
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{From: "10.0.0.0/24", To: "fd00::/64", Table: 100}},
+			out: out{err: errors.ErrNetworkdRuleFamilyMismatch},
+		},
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{To: "10.0.0.0/33", Table: 100}},
+			out: out{err: errors.ErrNetworkdRuleAddressInvalid},
+		},
+		{
+			in:  in{rule: NetworkdRoutingPolicyRule{Priority: &priority, Table: 100}},
+			out: out{err: errors.ErrNetworkdRulePriorityInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.rule.Validate()
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestNetworkdUnitRoutesValidate(t *testing.T) {
+	type in struct {
+		unit Networkdunit
+	}
+	type out struct {
+		err error
+	}
+
+	mainTable := 254
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{unit: Networkdunit{Name: "10-eth0.network", Routes: []NetworkdRoute{
+				{Gateway: "10.0.0.1"},
+				{Gateway: "fe80::1"},
+				{Destination: "10.1.0.0/16", Gateway: "10.0.0.2"},
+			}}},
+			out: out{},
+		},
+		{
+			in:  in{unit: Networkdunit{Name: "10-bond0.netdev", Routes: []NetworkdRoute{{Gateway: "10.0.0.1"}}}},
+			out: out{err: errors.ErrNetworkdRoutesNotNetwork},
+		},
+		{
+			in:  in{unit: Networkdunit{Name: "10-bond0.netdev", RoutingPolicyRules: []NetworkdRoutingPolicyRule{{Table: 100}}}},
+			out: out{err: errors.ErrNetworkdRoutesNotNetwork},
+		},
+		{
+			in: in{unit: Networkdunit{
+				Name:    "10-eth0.network",
+				Routes:  []NetworkdRoute{{Gateway: "10.0.0.1"}},
+				Dropins: []NetworkdDropin{{Name: "10-ignition-routes.conf"}},
+			}},
+			out: out{err: errors.ErrNetworkdRoutesDropinReserved},
+		},
+		{
+			// the main table is the default
+			in: in{unit: Networkdunit{Name: "10-eth0.network", Routes: []NetworkdRoute{
+				{Destination: "10.1.0.0/16", Gateway: "10.0.0.1"},
+				{Destination: "10.1.0.0/16", Gateway: "10.0.0.2", Table: &mainTable},
+			}}},
+			out: out{err: errors.ErrNetworkdRouteDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		u := test.in.unit
+		r := u.ValidateRoutes()
+		r.Merge(u.ValidateRoutingPolicyRules())
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
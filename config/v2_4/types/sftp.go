@@ -0,0 +1,128 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+var (
+	sftpHostRegexp = regexp.MustCompile(`^[A-Za-z0-9.:_-]+$`)
+	// sftpHostKeyTypes are the types of host keys OpenSSH accepts
+	sftpHostKeyTypes = setOf(
+		"ssh-ed25519",
+		"ssh-rsa",
+		"ecdsa-sha2-nistp256",
+		"ecdsa-sha2-nistp384",
+		"ecdsa-sha2-nistp521",
+		"sk-ssh-ed25519@openssh.com",
+		"sk-ecdsa-sha2-nistp256@openssh.com",
+	)
+)
+
+func (s Security) ValidateSFTP() report.Report {
+	seen := map[string]struct{}{}
+	for _, h := range s.SFTP {
+		host, port, ok := splitSFTPHost(h.Host)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[net.JoinHostPort(host, port)]; ok {
+			return report.ReportFromError(errors.ErrSFTPHostDuplicate, report.EntryError)
+		}
+		seen[net.JoinHostPort(host, port)] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (h SFTPHost) ValidateHost() report.Report {
+	if _, _, ok := splitSFTPHost(h.Host); !ok {
+		return report.ReportFromError(errors.ErrSFTPHost, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (h SFTPHost) ValidateHostKeys() report.Report {
+	if len(h.HostKeys) == 0 {
+		return report.ReportFromError(errors.ErrSFTPHostKeys, report.EntryError)
+	}
+	for _, k := range h.HostKeys {
+		if !validSSHPublicKey(k) {
+			return report.ReportFromError(errors.ErrSFTPHostKey, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (k SFTPPrivateKey) ValidateSource() report.Report {
+	if err := validateURL(k.Source); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	if u, err := url.Parse(k.Source); err == nil && u.Scheme == "sftp" {
+		return report.ReportFromError(errors.ErrSFTPKeySource, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (k SFTPPrivateKey) ValidateHTTPHeaders() report.Report {
+	return CaReference{HTTPHeaders: k.HTTPHeaders, Source: k.Source}.ValidateHTTPHeaders()
+}
+
+// splitSFTPHost splits a host name or address, optionally followed by a
+// port, into the host and the port, defaulting to 22.
+func splitSFTPHost(s string) (string, string, bool) {
+	host, port := s, "22"
+	if strings.HasPrefix(s, "[") || strings.Count(s, ":") == 1 {
+		var err error
+		if host, port, err = net.SplitHostPort(s); err != nil {
+			return "", "", false
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", "", false
+		}
+	}
+	if !sftpHostRegexp.MatchString(host) || (strings.Contains(host, ":") && net.ParseIP(host) == nil) {
+		return "", "", false
+	}
+	return host, port, true
+}
+
+// validSSHPublicKey returns whether k is a public key in the format of
+// known_hosts and authorized_keys, optionally followed by a comment: its
+// type, and the key encoded in base64, which starts with the type again.
+func validSSHPublicKey(k string) bool {
+	fields := strings.Fields(k)
+	if len(fields) < 2 {
+		return false
+	}
+	if _, ok := sftpHostKeyTypes[fields[0]]; !ok {
+		return false
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(blob) < 4 {
+		return false
+	}
+	n := binary.BigEndian.Uint32(blob)
+	return uint64(n) <= uint64(len(blob)-4) && string(blob[4:4+n]) == fields[0]
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestSFTPValidate(t *testing.T) {
+	const hostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f"
+	key := SFTPPrivateKey{Source: "data:,key"}
+
+	type in struct {
+		security Security
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{security: Security{}},
+			out: out{},
+		},
+		{
+			in: in{security: Security{SFTP: []SFTPHost{
+				{Host: "dropbox.example.com", HostKeys: []string{hostKey + " root@dropbox"}, PrivateKey: key},
+				{Host: "dropbox.example.com:2222", HostKeys: []string{hostKey}, PrivateKey: SFTPPrivateKey{Source: "https://vault.example.com/ignition.key"}},
+				{Host: "[fd00::1]:2222", HostKeys: []string{hostKey}, PrivateKey: key},
+				{Host: "fd00::2", HostKeys: []string{hostKey}, PrivateKey: key},
+			}}},
+			out: out{},
+		},
+		{
+			in: in{security: Security{SFTP: []SFTPHost{
+				{Host: "dropbox.example.com", HostKeys: []string{hostKey}, PrivateKey: key},
+				{Host: "dropbox.example.com:22", HostKeys: []string{hostKey}, PrivateKey: key},
+			}}},
+			out: out{err: errors.ErrSFTPHostDuplicate},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "sftp://dropbox.example.com", HostKeys: []string{hostKey}, PrivateKey: key}}}},
+			out: out{err: errors.ErrSFTPHost},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com:0", HostKeys: []string{hostKey}, PrivateKey: key}}}},
+			out: out{err: errors.ErrSFTPHost},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com", PrivateKey: key}}}},
+			out: out{err: errors.ErrSFTPHostKeys},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com", HostKeys: []string{"AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f"}, PrivateKey: key}}}},
+			out: out{err: errors.ErrSFTPHostKey},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com", HostKeys: []string{"ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f"}, PrivateKey: key}}}},
+			out: out{err: errors.ErrSFTPHostKey},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com", HostKeys: []string{hostKey}, PrivateKey: SFTPPrivateKey{Source: "sftp://keys@other.example.com/ignition.key"}}}}},
+			out: out{err: errors.ErrSFTPKeySource},
+		},
+		{
+			in:  in{security: Security{SFTP: []SFTPHost{{Host: "dropbox.example.com", HostKeys: []string{hostKey}, PrivateKey: SFTPPrivateKey{Source: "data:,key", HTTPHeaders: HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}}}}}}},
+			out: out{err: errors.ErrUnsupportedSchemeForHTTPHeaders},
+		},
+	}
+
+	for i, test := range tests {
+		s := test.in.security
+		r := s.ValidateSFTP()
+		for _, h := range s.SFTP {
+			r.Merge(h.ValidateHost())
+			r.Merge(h.ValidateHostKeys())
+			r.Merge(h.PrivateKey.ValidateSource())
+			r.Merge(h.PrivateKey.ValidateHTTPHeaders())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bufio"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// sshdOptions is the set of keywords understood by sshd_config(5), in lower
+// case since keywords are case-insensitive.
+var sshdOptions = map[string]struct{}{}
+
+func init() {
+	for _, o := range []string{
+		"AcceptEnv", "AddressFamily", "AllowAgentForwarding", "AllowGroups",
+		"AllowStreamLocalForwarding", "AllowTcpForwarding", "AllowUsers",
+		"AuthenticationMethods", "AuthorizedKeysCommand",
+		"AuthorizedKeysCommandUser", "AuthorizedKeysFile",
+		"AuthorizedPrincipalsCommand", "AuthorizedPrincipalsCommandUser",
+		"AuthorizedPrincipalsFile", "Banner", "CASignatureAlgorithms",
+		"ChannelTimeout", "ChrootDirectory", "Ciphers", "ClientAliveCountMax",
+		"ClientAliveInterval", "Compression", "DenyGroups", "DenyUsers",
+		"DisableForwarding", "ExposeAuthInfo", "FingerprintHash",
+		"ForceCommand", "GatewayPorts", "GSSAPIAuthentication",
+		"GSSAPICleanupCredentials", "GSSAPIStrictAcceptorCheck",
+		"HostbasedAcceptedAlgorithms", "HostbasedAuthentication",
+		"HostbasedUsesNameFromPacketOnly", "HostCertificate", "HostKey",
+		"HostKeyAgent", "HostKeyAlgorithms", "IgnoreRhosts",
+		"IgnoreUserKnownHosts", "Include", "IPQoS",
+		"KbdInteractiveAuthentication", "KerberosAuthentication",
+		"KerberosGetAFSToken", "KerberosOrLocalPasswd",
+		"KerberosTicketCleanup", "KexAlgorithms", "ListenAddress",
+		"LoginGraceTime", "LogLevel", "LogVerbose", "MACs", "Match",
+		"MaxAuthTries", "MaxSessions", "MaxStartups", "ModuliFile",
+		"PasswordAuthentication", "PermitEmptyPasswords", "PermitListen",
+		"PermitOpen", "PermitRootLogin", "PermitTTY", "PermitTunnel",
+		"PermitUserEnvironment", "PermitUserRC", "PerSourceMaxStartups",
+		"PerSourceNetBlockSize", "PidFile", "Port", "PrintLastLog",
+		"PrintMotd", "PubkeyAcceptedAlgorithms", "PubkeyAuthOptions",
+		"PubkeyAuthentication", "RekeyLimit", "RequiredRSASize",
+		"RevokedKeys", "RDomain", "SecurityKeyProvider", "SetEnv",
+		"StreamLocalBindMask", "StreamLocalBindUnlink", "StrictModes",
+		"Subsystem", "SyslogFacility", "TCPKeepAlive", "TrustedUserCAKeys",
+		"UnusedConnectionTimeout", "UseDNS", "UsePAM", "VersionAddendum",
+		"X11DisplayOffset", "X11Forwarding", "X11UseLocalhost",
+		"XAuthLocation",
+		// deprecated, but still accepted
+		"ChallengeResponseAuthentication", "PubkeyAcceptedKeyTypes",
+		"HostbasedAcceptedKeyTypes",
+	} {
+		sshdOptions[strings.ToLower(o)] = struct{}{}
+	}
+}
+
+func (s SSH) ValidateConfigSnippets() report.Report {
+	r := report.Report{}
+	seen := map[string]struct{}{}
+	for _, c := range s.ConfigSnippets {
+		if _, ok := seen[c.Name]; ok {
+			r.Add(report.Entry{
+				Message: errors.ErrSSHConfigSnippetDuplicate.Error(),
+				Kind:    report.EntryError,
+			})
+			return r
+		}
+		seen[c.Name] = struct{}{}
+	}
+	return r
+}
+
+func (c SSHConfigSnippet) ValidateName() report.Report {
+	if filepath.Ext(c.Name) != ".conf" || strings.Contains(c.Name, "/") {
+		return report.ReportFromError(errors.ErrSSHConfigSnippetName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (c SSHConfigSnippet) ValidateContents() report.Report {
+	scanner := bufio.NewScanner(strings.NewReader(c.Contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// keywords are separated from their arguments by whitespace or an
+		// optional "="
+		keyword := line
+		if i := strings.IndexAny(line, " \t="); i >= 0 {
+			keyword = line[:i]
+		}
+		if _, ok := sshdOptions[strings.ToLower(keyword)]; !ok {
+			return report.ReportFromError(errors.NewUnknownSSHDOptionError(keyword), report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (s SSH) ValidateHostKeys() report.Report {
+	r := report.Report{}
+	seen := map[string]struct{}{}
+	for _, k := range s.HostKeys {
+		if _, ok := seen[k.Type]; ok {
+			r.Add(report.Entry{
+				Message: errors.ErrSSHHostKeyDuplicate.Error(),
+				Kind:    report.EntryError,
+			})
+			return r
+		}
+		seen[k.Type] = struct{}{}
+	}
+	return r
+}
+
+func (k SSHHostKey) ValidateType() report.Report {
+	switch k.Type {
+	case "rsa", "ecdsa", "ed25519", "dsa":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrSSHHostKeyType, report.EntryError)
+	}
+}
+
+func (k SSHHostKey) ValidateSource() report.Report {
+	err := validateURL(k.Source)
+	if err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (k SSHHostKey) ValidateHTTPHeaders() report.Report {
+	r := report.Report{}
+
+	if len(k.HTTPHeaders) < 1 {
+		return r
+	}
+
+	u, err := url.Parse(k.Source)
+	if err != nil {
+		r.Add(report.Entry{
+			Message: errors.ErrInvalidUrl.Error(),
+			Kind:    report.EntryError,
+		})
+		return r
+	}
+
+	switch u.Scheme {
+	case "http", "https", "oci":
+	default:
+		r.Add(report.Entry{
+			Message: errors.ErrUnsupportedSchemeForHTTPHeaders.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+
+	return r
+}
+
+func (p SSHPhoneHome) ValidateURL() report.Report {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrSSHPhoneHomeScheme, report.EntryError)
+	}
+}
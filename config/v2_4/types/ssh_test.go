@@ -0,0 +1,209 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestSSHHostKeyValidateType(t *testing.T) {
+	type in struct {
+		key SSHHostKey
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{key: SSHHostKey{Type: "ed25519"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{key: SSHHostKey{Type: "rsa"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{key: SSHHostKey{Type: ""}},
+			out: out{err: errors.ErrSSHHostKeyType},
+		},
+		{
+			in:  in{key: SSHHostKey{Type: "ssh-ed25519"}},
+			out: out{err: errors.ErrSSHHostKeyType},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.key.ValidateType()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestSSHValidateHostKeys(t *testing.T) {
+	type in struct {
+		ssh SSH
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{ssh: SSH{}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{ssh: SSH{HostKeys: []SSHHostKey{{Type: "rsa"}, {Type: "ed25519"}}}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{ssh: SSH{HostKeys: []SSHHostKey{{Type: "rsa"}, {Type: "rsa"}}}},
+			out: out{err: errors.ErrSSHHostKeyDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.ssh.ValidateHostKeys()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestSSHConfigSnippetValidateName(t *testing.T) {
+	type in struct {
+		name string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{name: "10-hardening.conf"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{name: "10-hardening"},
+			out: out{err: errors.ErrSSHConfigSnippetName},
+		},
+		{
+			in:  in{name: "../sshd_config.conf"},
+			out: out{err: errors.ErrSSHConfigSnippetName},
+		},
+	}
+
+	for i, test := range tests {
+		r := SSHConfigSnippet{Name: test.in.name}.ValidateName()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestSSHConfigSnippetValidateContents(t *testing.T) {
+	type in struct {
+		contents string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{contents: ""},
+			out: out{err: nil},
+		},
+		{
+			in:  in{contents: "# comment\n\nPasswordAuthentication no\npermitrootlogin=no\nMatch User core\n\tAllowTcpForwarding yes\n"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{contents: "PasswordAuthentication no\nPermitRootLogn no\n"},
+			out: out{err: errors.NewUnknownSSHDOptionError("PermitRootLogn")},
+		},
+		{
+			in:  in{contents: "=no"},
+			out: out{err: errors.NewUnknownSSHDOptionError("")},
+		},
+	}
+
+	for i, test := range tests {
+		r := SSHConfigSnippet{Contents: test.in.contents}.ValidateContents()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestSSHPhoneHomeValidateURL(t *testing.T) {
+	type in struct {
+		url string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{url: "https://inventory.example.com/nodes"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{url: "http://10.0.0.1:8080/phone-home"},
+			out: out{err: nil},
+		},
+		{
+			in:  in{url: "tftp://10.0.0.1/phone-home"},
+			out: out{err: errors.ErrSSHPhoneHomeScheme},
+		},
+		{
+			in:  in{url: ""},
+			out: out{err: errors.ErrSSHPhoneHomeScheme},
+		},
+		{
+			in:  in{url: "%"},
+			out: out{err: errors.ErrInvalidUrl},
+		},
+	}
+
+	for i, test := range tests {
+		r := SSHPhoneHome{URL: test.in.url}.ValidateURL()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// timeServerHostRegexp matches the host names time servers can be given
+// as, a dot-separated list of DNS labels.
+var timeServerHostRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*\.?$`)
+
+func (t Time) ValidateServers() report.Report {
+	seen := map[string]struct{}{}
+	for _, s := range t.Servers {
+		if _, ok := seen[s.Address]; ok {
+			return report.ReportFromError(errors.ErrTimeServerDuplicate, report.EntryError)
+		}
+		seen[s.Address] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (s TimeServer) ValidateAddress() report.Report {
+	if net.ParseIP(s.Address) == nil && (len(s.Address) > 253 || !timeServerHostRegexp.MatchString(s.Address)) {
+		return report.ReportFromError(errors.ErrTimeServerAddress, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m TimeMakeStep) ValidateThreshold() report.Report {
+	if d, err := time.ParseDuration(m.Threshold); err != nil || d <= 0 {
+		return report.ReportFromError(errors.ErrTimeMakeStepThreshold, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (m TimeMakeStep) ValidateLimit() report.Report {
+	if m.Limit < -1 {
+		return report.ReportFromError(errors.ErrTimeMakeStepLimit, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestTimeValidate(t *testing.T) {
+	type in struct {
+		time Time
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{time: Time{}},
+			out: out{},
+		},
+		{
+			in:  in{time: Time{Servers: []TimeServer{{Address: "time.cloudflare.com", NTS: true}, {Address: "192.0.2.1"}, {Address: "2001:db8::1"}}}},
+			out: out{},
+		},
+		{
+			in:  in{time: Time{MakeStep: &TimeMakeStep{Threshold: "100ms", Limit: 3}}},
+			out: out{},
+		},
+		{
+			in:  in{time: Time{MakeStep: &TimeMakeStep{Threshold: "1s", Limit: -1}}},
+			out: out{},
+		},
+		{
+			in:  in{time: Time{Servers: []TimeServer{{Address: "ntp.example.com"}, {Address: "ntp.example.com", NTS: true}}}},
+			out: out{err: errors.ErrTimeServerDuplicate},
+		},
+		{
+			in:  in{time: Time{Servers: []TimeServer{{Address: "ntp.example.com:123"}}}},
+			out: out{err: errors.ErrTimeServerAddress},
+		},
+		{
+			in:  in{time: Time{Servers: []TimeServer{{Address: "ntp.example.com iburst"}}}},
+			out: out{err: errors.ErrTimeServerAddress},
+		},
+		{
+			in:  in{time: Time{MakeStep: &TimeMakeStep{Threshold: "1", Limit: 3}}},
+			out: out{err: errors.ErrTimeMakeStepThreshold},
+		},
+		{
+			in:  in{time: Time{MakeStep: &TimeMakeStep{Threshold: "1s", Limit: -2}}},
+			out: out{err: errors.ErrTimeMakeStepLimit},
+		},
+	}
+
+	for i, test := range tests {
+		tm := test.in.time
+		r := tm.ValidateServers()
+		for _, s := range tm.Servers {
+			r.Merge(s.ValidateAddress())
+		}
+		if tm.MakeStep != nil {
+			r.Merge(tm.MakeStep.ValidateThreshold())
+			r.Merge(tm.MakeStep.ValidateLimit())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (t Timeouts) ValidateHTTPRetries() report.Report {
+	if t.HTTPRetries != nil && *t.HTTPRetries < 0 {
+		return report.ReportFromError(errors.ErrHTTPRetries, report.EntryError)
+	}
+	return report.Report{}
+}
+
+// ValidateHTTPBackoffBaseMs checks the backoff before the first retry. The
+// bounds may be left to their defaults, so they're only compared if both
+// are set.
+func (t Timeouts) ValidateHTTPBackoffBaseMs() report.Report {
+	base, max := t.HTTPBackoffBaseMs, t.HTTPBackoffMaxMs
+	if base != nil && (*base <= 0 || (max != nil && *base > *max)) {
+		return report.ReportFromError(errors.ErrHTTPBackoff, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (t Timeouts) ValidateHTTPBackoffMaxMs() report.Report {
+	if t.HTTPBackoffMaxMs != nil && *t.HTTPBackoffMaxMs <= 0 {
+		return report.ReportFromError(errors.ErrHTTPBackoff, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestTimeoutsValidate(t *testing.T) {
+	type in struct {
+		timeouts Timeouts
+	}
+	type out struct {
+		retries report.Report
+		backoff report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{timeouts: Timeouts{}},
+			out: out{},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPRetries: intToPtr(0), HTTPBackoffBaseMs: intToPtr(500), HTTPBackoffMaxMs: intToPtr(30000)}},
+			out: out{},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPBackoffBaseMs: intToPtr(10000)}},
+			out: out{},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPRetries: intToPtr(-1)}},
+			out: out{retries: report.ReportFromError(errors.ErrHTTPRetries, report.EntryError)},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPBackoffBaseMs: intToPtr(0)}},
+			out: out{backoff: report.ReportFromError(errors.ErrHTTPBackoff, report.EntryError)},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPBackoffMaxMs: intToPtr(-5)}},
+			out: out{backoff: report.ReportFromError(errors.ErrHTTPBackoff, report.EntryError)},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPBackoffBaseMs: intToPtr(2000), HTTPBackoffMaxMs: intToPtr(1000)}},
+			out: out{backoff: report.ReportFromError(errors.ErrHTTPBackoff, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.timeouts.ValidateHTTPRetries()
+		if !reflect.DeepEqual(test.out.retries, r) {
+			t.Errorf("#%d: bad retries report: want %v, got %v", i, test.out.retries, r)
+		}
+		r = test.in.timeouts.ValidateHTTPBackoffBaseMs()
+		r.Merge(test.in.timeouts.ValidateHTTPBackoffMaxMs())
+		if !reflect.DeepEqual(test.out.backoff, r) {
+			t.Errorf("#%d: bad backoff report: want %v, got %v", i, test.out.backoff, r)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func (p PublicKeyPin) ValidateHash() report.Report {
+	parts := strings.SplitN(p.Hash, "-", 2)
+	if len(parts) != 2 {
+		return report.ReportFromError(errors.ErrHashMalformed, report.EntryError)
+	}
+	if parts[0] != "sha256" {
+		return report.ReportFromError(errors.ErrHashUnrecognized, report.EntryError)
+	}
+	if sum, err := hex.DecodeString(parts[1]); err != nil || len(sum) != sha256.Size {
+		return report.ReportFromError(errors.ErrHashWrongSize, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p PublicKeyPin) ValidateHost() report.Report {
+	if strings.ContainsAny(p.Host, "/@") {
+		return report.ReportFromError(errors.ErrPinHost, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (c ClientCertificate) ValidateCertificate() report.Report {
+	return validateClientCertificateSource(c.Certificate)
+}
+
+func (c ClientCertificate) ValidatePrivateKey() report.Report {
+	return validateClientCertificateSource(c.PrivateKey)
+}
+
+// validateClientCertificateSource checks that a client certificate or key
+// is at hand before the fetches it's needed for, inline or on the OEM
+// partition, and that keys aren't sent over the network.
+func validateClientCertificateSource(source string) report.Report {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "data" && u.Scheme != "oem") {
+		return report.ReportFromError(errors.ErrClientCertificateSource, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestPublicKeyPinValidate(t *testing.T) {
+	type in struct {
+		pin PublicKeyPin
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{pin: PublicKeyPin{Hash: "sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+			out: out{},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Host: "config.example.com", Hash: "sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+			out: out{},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Hash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+			out: out{err: errors.ErrHashMalformed},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Hash: "sha512-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+			out: out{err: errors.ErrHashUnrecognized},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Hash: "sha256-e3b0c442"}},
+			out: out{err: errors.ErrHashWrongSize},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Hash: "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+			out: out{err: errors.ErrHashWrongSize},
+		},
+		{
+			in:  in{pin: PublicKeyPin{Host: "https://config.example.com/", Hash: "sha256-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+			out: out{err: errors.ErrPinHost},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.pin.ValidateHash()
+		r.Merge(test.in.pin.ValidateHost())
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestClientCertificateValidate(t *testing.T) {
+	type in struct {
+		cert ClientCertificate
+	}
+	type out struct {
+		report report.Report
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{cert: ClientCertificate{Certificate: "data:,cert", PrivateKey: "oem:///client.key"}},
+			out: out{},
+		},
+		{
+			in:  in{cert: ClientCertificate{Certificate: "https://example.com/client.pem", PrivateKey: "data:,key"}},
+			out: out{report: report.ReportFromError(errors.ErrClientCertificateSource, report.EntryError)},
+		},
+		{
+			in:  in{cert: ClientCertificate{Certificate: "data:,cert"}},
+			out: out{report: report.ReportFromError(errors.ErrClientCertificateSource, report.EntryError)},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.cert.ValidateCertificate()
+		r.Merge(test.in.cert.ValidatePrivateKey())
+		if !reflect.DeepEqual(test.out.report, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.report, r)
+		}
+	}
+}
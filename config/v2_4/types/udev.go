@@ -0,0 +1,257 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// udevKey describes how a key of udev rules can be used, see udev(7).
+type udevKey struct {
+	// match is whether it's compared with == and !=
+	match bool
+	// assign is whether it's assigned with =, += and :=; PROGRAM, IMPORT
+	// and TEST take them as matches
+	assign bool
+	// remove is whether values are removed from it with -=
+	remove bool
+	// attr is whether it takes an attribute in braces: "required",
+	// "optional" or "" for none
+	attr string
+	// attrs are the attributes it takes, if they are a fixed set
+	attrs map[string]struct{}
+}
+
+var (
+	udevKeys = map[string]udevKey{
+		"ACTION":     {match: true},
+		"DEVPATH":    {match: true},
+		"KERNEL":     {match: true},
+		"KERNELS":    {match: true},
+		"SUBSYSTEM":  {match: true},
+		"SUBSYSTEMS": {match: true},
+		"DRIVER":     {match: true},
+		"DRIVERS":    {match: true},
+		"ATTRS":      {match: true, attr: "required"},
+		"TAGS":       {match: true},
+		"RESULT":     {match: true},
+		"CONST":      {match: true, attr: "required", attrs: setOf("arch", "virt")},
+		"TEST":       {match: true, assign: true, attr: "optional"},
+		"PROGRAM":    {match: true, assign: true},
+		"IMPORT":     {match: true, assign: true, attr: "required", attrs: setOf("program", "builtin", "file", "db", "cmdline", "parent")},
+		"NAME":       {match: true, assign: true},
+		"SYMLINK":    {match: true, assign: true, remove: true},
+		"ATTR":       {match: true, assign: true, attr: "required"},
+		"SYSCTL":     {match: true, assign: true, attr: "required"},
+		"ENV":        {match: true, assign: true, attr: "required"},
+		"TAG":        {match: true, assign: true, remove: true},
+		"OWNER":      {assign: true},
+		"GROUP":      {assign: true},
+		"MODE":       {assign: true},
+		"SECLABEL":   {assign: true, attr: "required"},
+		"RUN":        {assign: true, attr: "optional", attrs: setOf("program", "builtin")},
+		"GOTO":       {assign: true},
+		"LABEL":      {assign: true},
+		"OPTIONS":    {assign: true},
+	}
+
+	// udevEntryRegexp matches the key, attribute and operator of an entry,
+	// up to the opening quote of its value, which may be prefixed with 'e'
+	// for C-style escapes
+	udevEntryRegexp = regexp.MustCompile(`^([A-Z_]+)(\{([^{}]*)\})?\s*(==|!=|\+=|-=|:=|=)\s*e?"`)
+	udevModeRegexp  = regexp.MustCompile(`^[0-7]{3,4}$`)
+	udevNameRegexp  = regexp.MustCompile(`^[A-Za-z0-9_@][A-Za-z0-9_@.-]*$`)
+	// udevPriorityRegexp matches the priorities of the names of rules
+	// files, which Ignition adds
+	udevPriorityRegexp = regexp.MustCompile(`^[0-9]+-`)
+)
+
+func (s Storage) ValidateUdevRules() report.Report {
+	seen := map[string]struct{}{}
+	for _, u := range s.UdevRules {
+		if _, ok := seen[u.Name]; ok {
+			return report.ReportFromError(errors.ErrUdevRuleDuplicate, report.EntryError)
+		}
+		seen[u.Name] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (u UdevRule) ValidateName() report.Report {
+	if !udevNameRegexp.MatchString(u.Name) || udevPriorityRegexp.MatchString(u.Name) || strings.HasSuffix(u.Name, ".rules") {
+		return report.ReportFromError(errors.ErrUdevRuleName, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (u UdevRule) ValidatePriority() report.Report {
+	if u.Priority != nil && (*u.Priority < 0 || *u.Priority > 99) {
+		return report.ReportFromError(errors.ErrUdevRulePriority, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (u UdevRule) ValidateContents() report.Report {
+	labels := map[string]struct{}{}
+	var gotos []string
+	scanner := bufio.NewScanner(strings.NewReader(u.Contents))
+	var line string
+	for scanner.Scan() {
+		// a backslash at the end of a line continues the rule on the
+		// next one
+		line += scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSuffix(line, "\\")
+			continue
+		}
+		rule := strings.TrimSpace(line)
+		line = ""
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		entries, reason := parseUdevRule(rule)
+		if reason == "" {
+			reason = checkUdevRule(entries)
+		}
+		if reason != "" {
+			return report.ReportFromError(errors.NewUdevRuleError(rule, reason), report.EntryError)
+		}
+		for _, e := range entries {
+			switch e.key {
+			case "LABEL":
+				labels[e.value] = struct{}{}
+			case "GOTO":
+				gotos = append(gotos, e.value)
+			}
+		}
+	}
+	if strings.TrimSpace(line) != "" {
+		return report.ReportFromError(errors.NewUdevRuleError(line, "the last line ends with a backslash"), report.EntryError)
+	}
+	for _, g := range gotos {
+		if _, ok := labels[g]; !ok {
+			return report.ReportFromError(errors.NewUdevRuleError("GOTO=\""+g+"\"", "no LABEL of that name in the file"), report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+// udevEntry is a key, operator and value of a udev rule.
+type udevEntry struct {
+	key     string
+	hasAttr bool
+	attr    string
+	op      string
+	value   string
+}
+
+// parseUdevRule splits a rule into its comma separated entries, returning
+// why it can't be split if it can't.
+func parseUdevRule(rule string) ([]udevEntry, string) {
+	var entries []udevEntry
+	rest := rule
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return entries, ""
+		}
+		m := udevEntryRegexp.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Sprintf("expected a key, an operator and a quoted value at %q", rest)
+		}
+		e := udevEntry{key: m[1], hasAttr: m[2] != "", attr: m[3], op: m[4]}
+		rest = rest[len(m[0]):]
+
+		// the value ends at the first quote not escaped by a backslash
+		end := -1
+		for i := 0; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+			} else if rest[i] == '"' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Sprintf("the value of %s isn't closed with a quote", e.key)
+		}
+		e.value = rest[:end]
+		entries = append(entries, e)
+
+		rest = strings.TrimLeft(rest[end+1:], " \t")
+		if rest == "" {
+			return entries, ""
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Sprintf("expected a comma after the value of %s at %q", e.key, rest)
+		}
+		rest = rest[1:]
+	}
+}
+
+// checkUdevRule checks the entries of a rule against the keys of udev,
+// returning why udev would reject them, or "" if it wouldn't. It doesn't
+// check whether the attributes and programs exist.
+func checkUdevRule(entries []udevEntry) string {
+	for _, e := range entries {
+		k, ok := udevKeys[e.key]
+		if !ok {
+			return fmt.Sprintf("unknown key %q", e.key)
+		}
+
+		switch {
+		case k.attr == "" && e.hasAttr:
+			return fmt.Sprintf("%s doesn't take an attribute", e.key)
+		case k.attr == "required" && e.attr == "":
+			return fmt.Sprintf("%s takes an attribute, like %s{name}", e.key, e.key)
+		}
+		if _, ok := k.attrs[e.attr]; e.attr != "" && k.attrs != nil && !ok {
+			return fmt.Sprintf("unknown attribute %q of %s", e.attr, e.key)
+		}
+
+		switch e.op {
+		case "==", "!=":
+			if !k.match {
+				return fmt.Sprintf("%s can only be assigned, not matched with %s", e.key, e.op)
+			}
+		case "-=":
+			if !k.remove {
+				return fmt.Sprintf("values can't be removed from %s", e.key)
+			}
+		default:
+			if !k.assign {
+				return fmt.Sprintf("%s can only be matched, not assigned with %s", e.key, e.op)
+			}
+		}
+
+		switch e.key {
+		case "GOTO", "LABEL":
+			if e.op != "=" {
+				return fmt.Sprintf("%s can only be set with =", e.key)
+			}
+		case "MODE":
+			if !udevModeRegexp.MatchString(e.value) && !strings.ContainsAny(e.value, "$%") {
+				return fmt.Sprintf("MODE takes an octal mode, like \"0660\", not %q", e.value)
+			}
+		}
+	}
+	return ""
+}
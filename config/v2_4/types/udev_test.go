@@ -0,0 +1,199 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestStorageValidateUdevRules(t *testing.T) {
+	type in struct {
+		storage Storage
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{storage: Storage{UdevRules: []UdevRule{{Name: "nvme-names"}, {Name: "gpu"}}}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{storage: Storage{UdevRules: []UdevRule{{Name: "gpu"}, {Name: "gpu", Priority: intToPtr(60)}}}},
+			out: out{err: errors.ErrUdevRuleDuplicate},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.storage.ValidateUdevRules()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestUdevRuleValidateNameAndPriority(t *testing.T) {
+	type in struct {
+		rule UdevRule
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{rule: UdevRule{Name: "nvme-names"}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{rule: UdevRule{Name: "serial@ttyS0", Priority: intToPtr(0)}},
+			out: out{err: nil},
+		},
+		{
+			in:  in{rule: UdevRule{Name: "60-nvme-names"}},
+			out: out{err: errors.ErrUdevRuleName},
+		},
+		{
+			in:  in{rule: UdevRule{Name: "nvme-names.rules"}},
+			out: out{err: errors.ErrUdevRuleName},
+		},
+		{
+			in:  in{rule: UdevRule{Name: "../nvme"}},
+			out: out{err: errors.ErrUdevRuleName},
+		},
+		{
+			in:  in{rule: UdevRule{Name: ""}},
+			out: out{err: errors.ErrUdevRuleName},
+		},
+		{
+			in:  in{rule: UdevRule{Name: "nvme-names", Priority: intToPtr(100)}},
+			out: out{err: errors.ErrUdevRulePriority},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.rule.ValidateName()
+		r.Merge(test.in.rule.ValidatePriority())
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
+
+func TestUdevRuleValidateContents(t *testing.T) {
+	type in struct {
+		contents string
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{contents: `# stable names for the data disks
+ACTION!="add|change", GOTO="data_end"
+SUBSYSTEM=="block", ENV{DEVTYPE}=="disk", ATTRS{model}=="Data*", \
+  SYMLINK+="data/%k", OWNER="root", GROUP="disk", MODE="0660"
+KERNEL=="nvme[0-9]n[0-9]", ATTR{queue/scheduler}="none", TAG+="systemd"
+SUBSYSTEM=="net", IMPORT{builtin}="net_id", ENV{ID_NET_NAME}=="", RUN{builtin}+="kmod load bonding"
+KERNEL=="sd*", TEST{0644}=="queue/rotational", PROGRAM="/usr/bin/check %k", RESULT=="ssd", SYMLINK-="old/%k"
+CONST{virt}=="kvm", ENV{SEEN}:="1", OPTIONS+="string_escape=replace", NAME=e"data\x20disk"
+LABEL="data_end"
+`},
+			out: out{err: nil},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda", SYMLNK+="data"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", SYMLNK+="data"`, `unknown key "SYMLNK"`)},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda" SYMLINK+="data"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda" SYMLINK+="data"`, `expected a comma after the value of KERNEL at "SYMLINK+=\"data\""`)},
+		},
+		{
+			in:  in{contents: `KERNEL=sda`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=sda`, `expected a key, an operator and a quoted value at "KERNEL=sda"`)},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda, MODE="0660"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda, MODE="0660"`, `expected a comma after the value of KERNEL at "0660\""`)},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda", OWNER="root`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", OWNER="root`, "the value of OWNER isn't closed with a quote")},
+		},
+		{
+			in:  in{contents: `KERNEL="sda", MODE="0660"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL="sda", MODE="0660"`, "KERNEL can only be matched, not assigned with =")},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda", OWNER=="root"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", OWNER=="root"`, "OWNER can only be assigned, not matched with ==")},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda", MODE-="0660"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", MODE-="0660"`, "values can't be removed from MODE")},
+		},
+		{
+			in:  in{contents: `ATTRS=="Data*"`},
+			out: out{err: errors.NewUdevRuleError(`ATTRS=="Data*"`, "ATTRS takes an attribute, like ATTRS{name}")},
+		},
+		{
+			in:  in{contents: `KERNEL{name}=="sda"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL{name}=="sda"`, "KERNEL doesn't take an attribute")},
+		},
+		{
+			in:  in{contents: `IMPORT{shell}="/bin/true"`},
+			out: out{err: errors.NewUdevRuleError(`IMPORT{shell}="/bin/true"`, `unknown attribute "shell" of IMPORT`)},
+		},
+		{
+			in:  in{contents: `KERNEL=="sda", MODE="rw"`},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", MODE="rw"`, `MODE takes an octal mode, like "0660", not "rw"`)},
+		},
+		{
+			in:  in{contents: `LABEL=="end"`},
+			out: out{err: errors.NewUdevRuleError(`LABEL=="end"`, "LABEL can only be assigned, not matched with ==")},
+		},
+		{
+			in:  in{contents: `ACTION=="remove", GOTO="end"`},
+			out: out{err: errors.NewUdevRuleError(`GOTO="end"`, "no LABEL of that name in the file")},
+		},
+		{
+			in:  in{contents: "KERNEL==\"sda\", \\"},
+			out: out{err: errors.NewUdevRuleError(`KERNEL=="sda", `, "the last line ends with a backslash")},
+		},
+	}
+
+	for i, test := range tests {
+		r := UdevRule{Name: "test", Contents: test.in.contents}.ValidateContents()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
@@ -95,6 +95,7 @@ func (u Networkdunit) Validate() report.Report {
 			Kind:    report.EntryError,
 		})
 	}
+	r.Merge(validateLinkFilePath(path.Join("/etc/systemd/network", u.Name)))
 
 	return r
 }
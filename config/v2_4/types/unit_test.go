@@ -138,7 +138,7 @@ func TestNetworkdUnitNameValidate(t *testing.T) {
 			out: out{err: nil},
 		},
 		{
-			in:  in{unit: "test.link"},
+			in:  in{unit: "10-test.link"},
 			out: out{err: nil},
 		},
 		{
@@ -157,6 +157,12 @@ func TestNetworkdUnitNameValidate(t *testing.T) {
 			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
 		}
 	}
+
+	// sorts after 99-default.link, so it never applies
+	r := Networkdunit{Name: "test.link", Contents: "[Foo]\nQux=Bar"}.Validate()
+	if want := report.ReportFromError(errors.ErrNetworkdLinkFileNeverMatches, report.EntryWarning); !reflect.DeepEqual(want, r) {
+		t.Errorf("bad report for test.link: want %v, got %v", want, r)
+	}
 }
 
 func TestNetworkdUnitValidate(t *testing.T) {
@@ -0,0 +1,80 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// updateGroupRegex matches the names of the groups of the update server: the
+// public channels, the UUIDs of custom groups and the names update servers
+// like Nebraska give them.
+var updateGroupRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// rebootWindowStartRegex matches the window starts understood by locksmithd,
+// a time of day optionally preceded by a weekday.
+var rebootWindowStartRegex = regexp.MustCompile(`^(?i:(mon|tue|wed|thu|fri|sat|sun) )?([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func (u Update) ValidateGroup() report.Report {
+	if u.Group != "" && !updateGroupRegex.MatchString(u.Group) {
+		return report.ReportFromError(errors.ErrUpdateGroup, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (u Update) ValidateServer() report.Report {
+	if u.Server == "" {
+		return report.Report{}
+	}
+	p, err := url.Parse(u.Server)
+	if err != nil || strings.ContainsAny(u.Server, " \t") {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch p.Scheme {
+	case "http", "https":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUpdateServer, report.EntryError)
+	}
+}
+
+func (u Update) ValidateRebootStrategy() report.Report {
+	switch u.RebootStrategy {
+	case "", "reboot", "etcd-lock", "best-effort", "off":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUpdateRebootStrategy, report.EntryError)
+	}
+}
+
+func (w UpdateRebootWindow) ValidateStart() report.Report {
+	if !rebootWindowStartRegex.MatchString(w.Start) {
+		return report.ReportFromError(errors.ErrUpdateRebootWindowStart, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (w UpdateRebootWindow) ValidateLength() report.Report {
+	if d, err := time.ParseDuration(w.Length); err != nil || d <= 0 {
+		return report.ReportFromError(errors.ErrUpdateRebootWindowLength, report.EntryError)
+	}
+	return report.Report{}
+}
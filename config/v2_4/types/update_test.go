@@ -0,0 +1,113 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestUpdateValidate(t *testing.T) {
+	type in struct {
+		update Update
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{update: Update{}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{Group: "stable", Server: "https://public.update.flatcar-linux.net/v1/update/"}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{Group: "0b0c1c6e-7f9a-4c1b-9a57-d4c6e5e2f0aa"}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{Group: "prod-canary_2.x"}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{Group: "prod canary"}},
+			out: out{err: errors.ErrUpdateGroup},
+		},
+		{
+			in:  in{update: Update{Group: "-stable"}},
+			out: out{err: errors.ErrUpdateGroup},
+		},
+		{
+			in:  in{update: Update{Group: "stable\nSERVER=http://evil.example.com/"}},
+			out: out{err: errors.ErrUpdateGroup},
+		},
+		{
+			in:  in{update: Update{Server: "ftp://updates.example.com/"}},
+			out: out{err: errors.ErrUpdateServer},
+		},
+		{
+			in:  in{update: Update{Server: "https://updates.example.com/v1 update"}},
+			out: out{err: errors.ErrInvalidUrl},
+		},
+		{
+			in:  in{update: Update{RebootStrategy: "etcd-lock", RebootWindow: &UpdateRebootWindow{Start: "Thu 04:00", Length: "1h30m"}}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{RebootStrategy: "reboot", RebootWindow: &UpdateRebootWindow{Start: "23:30", Length: "45m"}}},
+			out: out{},
+		},
+		{
+			in:  in{update: Update{RebootStrategy: "etcd"}},
+			out: out{err: errors.ErrUpdateRebootStrategy},
+		},
+		{
+			in:  in{update: Update{RebootWindow: &UpdateRebootWindow{Start: "Thursday 4am", Length: "1h"}}},
+			out: out{err: errors.ErrUpdateRebootWindowStart},
+		},
+		{
+			in:  in{update: Update{RebootWindow: &UpdateRebootWindow{Start: "24:00", Length: "1h"}}},
+			out: out{err: errors.ErrUpdateRebootWindowStart},
+		},
+		{
+			in:  in{update: Update{RebootWindow: &UpdateRebootWindow{Start: "04:00", Length: "90"}}},
+			out: out{err: errors.ErrUpdateRebootWindowLength},
+		},
+	}
+
+	for i, test := range tests {
+		u := test.in.update
+		r := u.ValidateGroup()
+		r.Merge(u.ValidateServer())
+		r.Merge(u.ValidateRebootStrategy())
+		if u.RebootWindow != nil {
+			r.Merge(u.RebootWindow.ValidateStart())
+			r.Merge(u.RebootWindow.ValidateLength())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -15,13 +15,21 @@
 package types
 
 import (
+	"io"
+	"io/ioutil"
 	"net/url"
+	"path"
+	"regexp"
+	"strings"
 
-	"github.com/vincent-petithory/dataurl"
-
+	"github.com/flatcar/ignition/config/shared/dataurl"
 	"github.com/flatcar/ignition/config/shared/errors"
 )
 
+// azureAccountRegexp matches the names of storage accounts, which are also
+// their DNS labels.
+var azureAccountRegexp = regexp.MustCompile(`^[a-z0-9]{3,24}$`)
+
 func validateURL(s string) error {
 	// Empty url is valid, indicates an empty file
 	if s == "" {
@@ -42,12 +50,80 @@ func validateURL(s string) error {
 			}
 		}
 		return nil
+	case "gs":
+		if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return errors.ErrInvalidGSUrl
+		}
+		return nil
+	case "azblob":
+		if !azureAccountRegexp.MatchString(u.Host) || len(strings.SplitN(strings.Trim(u.Path, "/"), "/", 2)) != 2 {
+			return errors.ErrInvalidAzblobUrl
+		}
+		return nil
+	case "docker":
+		if u.Host == "" || strings.Trim(u.Path, "/") == "" || !path.IsAbs(u.Fragment) {
+			return errors.ErrInvalidImageUrl
+		}
+		return nil
+	case "oci":
+		if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return errors.ErrInvalidOCIUrl
+		}
+		return nil
+	case "git+https":
+		return validateGitURL(u)
+	case "sftp":
+		return validateSFTPURL(u)
 	case "data":
-		if _, err := dataurl.DecodeString(s); err != nil {
+		r, err := dataurl.NewReader(s)
+		if err != nil {
 			return err
 		}
-		return nil
+		_, err = io.Copy(ioutil.Discard, r)
+		return err
 	default:
 		return errors.ErrInvalidScheme
 	}
 }
+
+// validateGitURL checks a url of a path in a git repository at a ref, e.g.
+// git+https://example.com/org/repo.git?ref=v1.2#path/in/repo.
+func validateGitURL(u *url.URL) error {
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return errors.ErrInvalidGitUrl
+	}
+	for key, values := range u.Query() {
+		if key != "ref" || len(values) != 1 || values[0] == "" {
+			return errors.ErrInvalidGitUrl
+		}
+	}
+	p := strings.Trim(u.Fragment, "/")
+	if p != "" && (path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../")) {
+		return errors.ErrInvalidGitUrl
+	}
+	return nil
+}
+
+// validateSFTPURL checks a url of a file on an sftp server, e.g.
+// sftp://user@example.com:2222/dropbox/config.ign. The user authenticates
+// with the key of the host in ignition.security.sftp, so the url can't
+// carry a password. The path is passed to the sftp command, so it can't
+// contain characters it would interpret.
+func validateSFTPURL(u *url.URL) error {
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return errors.ErrInvalidSFTPUrl
+	}
+	if _, ok := u.User.Password(); ok {
+		return errors.ErrInvalidSFTPUrl
+	}
+	if _, _, ok := splitSFTPHost(u.Host); !ok {
+		return errors.ErrInvalidSFTPUrl
+	}
+	if !path.IsAbs(u.Path) || strings.HasSuffix(u.Path, "/") || strings.ContainsAny(u.Path, "\"\\*?[\n\r") {
+		return errors.ErrInvalidSFTPUrl
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return errors.ErrInvalidSFTPUrl
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// wireGuardKeyLen is the length of WireGuard's Curve25519 keys.
+const wireGuardKeyLen = 32
+
+func (w NetworkdWireGuard) ValidateName() report.Report {
+	return validateNetdevName(w.Name)
+}
+
+func (w NetworkdWireGuard) ValidateListenPort() report.Report {
+	if w.ListenPort != nil && (*w.ListenPort < 1 || *w.ListenPort > 65535) {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardPortInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (w NetworkdWireGuard) ValidateAddresses() report.Report {
+	for _, a := range w.Addresses {
+		if _, _, err := net.ParseCIDR(a); err != nil {
+			return report.ReportFromError(errors.ErrNetworkdWireGuardAddressInvalid, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (w NetworkdWireGuard) ValidatePeers() report.Report {
+	seen := map[string]struct{}{}
+	for _, p := range w.Peers {
+		if _, ok := seen[p.PublicKey]; ok {
+			return report.ReportFromError(errors.ErrNetworkdWireGuardPeerDuplicate, report.EntryError)
+		}
+		seen[p.PublicKey] = struct{}{}
+	}
+	return report.Report{}
+}
+
+func (k NetworkdWireGuardKey) ValidateSource() report.Report {
+	if k.Source == "" {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardKeySourceRequired, report.EntryError)
+	}
+	if err := validateURL(k.Source); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (k NetworkdWireGuardKey) ValidateHTTPHeaders() report.Report {
+	if len(k.HTTPHeaders) < 1 {
+		return report.Report{}
+	}
+	u, err := url.Parse(k.Source)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	switch u.Scheme {
+	case "http", "https", "oci":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
+
+func (p NetworkdWireGuardPeer) ValidatePublicKey() report.Report {
+	if key, err := base64.StdEncoding.DecodeString(p.PublicKey); err != nil || len(key) != wireGuardKeyLen {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardPublicKeyInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p NetworkdWireGuardPeer) ValidateAllowedIPs() report.Report {
+	for _, ip := range p.AllowedIPs {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			return report.ReportFromError(errors.ErrNetworkdWireGuardAllowedIPInvalid, report.EntryError)
+		}
+	}
+	return report.Report{}
+}
+
+func (p NetworkdWireGuardPeer) ValidateEndpoint() report.Report {
+	if p.Endpoint == "" {
+		return report.Report{}
+	}
+	host, port, err := net.SplitHostPort(p.Endpoint)
+	if err != nil || host == "" {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardEndpointInvalid, report.EntryError)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardEndpointInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (p NetworkdWireGuardPeer) ValidatePersistentKeepalive() report.Report {
+	if p.PersistentKeepalive != nil && (*p.PersistentKeepalive < 0 || *p.PersistentKeepalive > 65535) {
+		return report.ReportFromError(errors.ErrNetworkdWireGuardKeepaliveInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
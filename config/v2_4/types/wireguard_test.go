@@ -0,0 +1,111 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestWireGuardValidate(t *testing.T) {
+	type in struct {
+		wg NetworkdWireGuard
+	}
+	type out struct {
+		err error
+	}
+
+	const pub = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	port := 51820
+	badPort := 0
+	keepalive := 25
+	key := NetworkdWireGuardKey{Source: "https://secrets.example.com/wg0.key"}
+	peer := NetworkdWireGuardPeer{PublicKey: pub, AllowedIPs: []string{"10.0.0.0/24"}}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{wg: NetworkdWireGuard{
+				Name:       "wg0",
+				PrivateKey: key,
+				ListenPort: &port,
+				Addresses:  []string{"10.0.0.2/24", "fd00::2/64"},
+				Peers: []NetworkdWireGuardPeer{{
+					PublicKey:           pub,
+					Endpoint:            "[2001:db8::1]:51820",
+					AllowedIPs:          []string{"10.0.0.0/24", "fd00::1"},
+					PersistentKeepalive: &keepalive,
+				}},
+			}},
+			out: out{},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: NetworkdWireGuardKey{}}},
+			out: out{err: errors.ErrNetworkdWireGuardKeySourceRequired},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: NetworkdWireGuardKey{Source: "s3://bucket/wg0.key", HTTPHeaders: HTTPHeaders{{Name: "X-Token", Value: "t"}}}}},
+			out: out{err: errors.ErrUnsupportedSchemeForHTTPHeaders},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, ListenPort: &badPort}},
+			out: out{err: errors.ErrNetworkdWireGuardPortInvalid},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, Addresses: []string{"10.0.0.2"}}},
+			out: out{err: errors.ErrNetworkdWireGuardAddressInvalid},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, Peers: []NetworkdWireGuardPeer{peer, peer}}},
+			out: out{err: errors.ErrNetworkdWireGuardPeerDuplicate},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, Peers: []NetworkdWireGuardPeer{{PublicKey: "AAAA"}}}},
+			out: out{err: errors.ErrNetworkdWireGuardPublicKeyInvalid},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, Peers: []NetworkdWireGuardPeer{{PublicKey: pub, AllowedIPs: []string{"10.0.0.0/33"}}}}},
+			out: out{err: errors.ErrNetworkdWireGuardAllowedIPInvalid},
+		},
+		{
+			in:  in{wg: NetworkdWireGuard{Name: "wg0", PrivateKey: key, Peers: []NetworkdWireGuardPeer{{PublicKey: pub, Endpoint: "vpn.example.com"}}}},
+			out: out{err: errors.ErrNetworkdWireGuardEndpointInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		w := test.in.wg
+		r := w.ValidateName()
+		r.Merge(w.ValidateListenPort())
+		r.Merge(w.ValidateAddresses())
+		r.Merge(w.ValidatePeers())
+		r.Merge(w.PrivateKey.ValidateSource())
+		r.Merge(w.PrivateKey.ValidateHTTPHeaders())
+		for _, p := range w.Peers {
+			r.Merge(p.ValidatePublicKey())
+			r.Merge(p.ValidateAllowedIPs())
+			r.Merge(p.ValidateEndpoint())
+			r.Merge(p.ValidatePersistentKeepalive())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
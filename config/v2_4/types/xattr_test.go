@@ -0,0 +1,102 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+func TestXattrsValidate(t *testing.T) {
+	type in struct {
+		capabilities *FileCapabilities
+		xattrs       []Xattr
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{},
+			out: out{},
+		},
+		{
+			in:  in{capabilities: &FileCapabilities{Permitted: []string{"CAP_NET_RAW", "cap_net_bind_service"}, Effective: true}},
+			out: out{},
+		},
+		{
+			in:  in{capabilities: &FileCapabilities{Inheritable: []string{"CAP_FLY"}}},
+			out: out{err: errors.ErrCapabilityInvalid},
+		},
+		{
+			in:  in{xattrs: []Xattr{{Name: "user.origin", Value: "aWduaXRpb24="}, {Name: "security.ima", Value: "BAQ="}}},
+			out: out{},
+		},
+		{
+			in:  in{xattrs: []Xattr{{Name: "system.posix_acl_access", Value: ""}}},
+			out: out{err: errors.ErrXattrNameInvalid},
+		},
+		{
+			in:  in{xattrs: []Xattr{{Name: "user.", Value: ""}}},
+			out: out{err: errors.ErrXattrNameInvalid},
+		},
+		{
+			in:  in{xattrs: []Xattr{{Name: "user.origin", Value: "not base64"}}},
+			out: out{err: errors.ErrXattrValueInvalid},
+		},
+		{
+			in:  in{xattrs: []Xattr{{Name: "user.origin"}, {Name: "user.origin"}}},
+			out: out{err: errors.ErrXattrDuplicate},
+		},
+		{
+			// revision 2, CAP_NET_RAW permitted and effective
+			in:  in{xattrs: []Xattr{{Name: "security.capability", Value: "AQAAAgAgAAAAAAAAAAAAAAAAAAA="}}},
+			out: out{},
+		},
+		{
+			// the same, big-endian
+			in:  in{xattrs: []Xattr{{Name: "security.capability", Value: "AgAAAQAAIAAAAAAAAAAAAAAAAAA="}}},
+			out: out{err: errors.ErrXattrCapabilityInvalid},
+		},
+		{
+			in: in{
+				capabilities: &FileCapabilities{Permitted: []string{"CAP_NET_RAW"}},
+				xattrs:       []Xattr{{Name: "security.capability", Value: "AQAAAgAgAAAAAAAAAAAAAAAAAAA="}},
+			},
+			out: out{err: errors.ErrXattrCapabilityConflict},
+		},
+	}
+
+	for i, test := range tests {
+		f := File{FileEmbedded1: FileEmbedded1{Capabilities: test.in.capabilities, Xattrs: test.in.xattrs}}
+		r := f.ValidateCapabilities()
+		r.Merge(f.ValidateXattrs())
+		for _, x := range f.Xattrs {
+			r.Merge(x.ValidateName())
+			r.Merge(x.ValidateValue())
+		}
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
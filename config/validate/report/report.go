@@ -127,12 +127,18 @@ type Entry struct {
 	Line      int       `json:"line,omitempty"`
 	Column    int       `json:"column,omitempty"`
 	Highlight string    `json:"-"`
+	// Source names what reported the entry if it isn't about the config
+	// itself, e.g. the provider which supplied the config.
+	Source string `json:"source,omitempty"`
 }
 
 func (e Entry) String() string {
 	if e.Line != 0 {
 		return fmt.Sprintf("%s at line %d, column %d\n%s%v", e.Kind.String(), e.Line, e.Column, e.Highlight, e.Message)
 	}
+	if e.Source != "" {
+		return fmt.Sprintf("%s from %s: %v", e.Kind.String(), e.Source, e.Message)
+	}
 	return fmt.Sprintf("%s: %v", e.Kind.String(), e.Message)
 }
 
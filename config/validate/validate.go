@@ -20,6 +20,7 @@ import (
 	"io"
 	"reflect"
 	"strings"
+	"sync"
 
 	json "github.com/ajeddeloh/go-json"
 	"github.com/flatcar/ignition/config/validate/astjson"
@@ -57,10 +58,7 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 		return
 	}
 
-	line, col, highlight := 0, 0, ""
-	if ast != nil {
-		line, col, highlight = ast.ValueLineCol(source)
-	}
+	pos := &position{node: ast, source: source}
 
 	// See if we A) can call Validate on vObj, and B) should call Validate. Validate should NOT be called
 	// when vObj is nil, as it will panic or when vObj is a pointer to a value with Validate implemented with a
@@ -71,7 +69,7 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 		((vObj.Kind() != reflect.Ptr) ||
 			(!vObj.IsNil() && !vObj.Elem().Type().Implements(reflect.TypeOf((*validator)(nil)).Elem()))) {
 		sub_r := obj.Validate()
-		sub_r.AddPosition(line, col, highlight)
+		pos.addTo(&sub_r, true)
 		r.Merge(sub_r)
 
 		// Dont recurse on invalid inner nodes, it mostly leads to bogus messages
@@ -83,11 +81,11 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 	switch vObj.Kind() {
 	case reflect.Ptr:
 		sub_report := Validate(vObj.Elem(), ast, source, checkUnusedKeys)
-		sub_report.AddPosition(line, col, "")
+		pos.addTo(&sub_report, false)
 		r.Merge(sub_report)
 	case reflect.Struct:
 		sub_report := validateStruct(vObj, ast, source, checkUnusedKeys)
-		sub_report.AddPosition(line, col, "")
+		pos.addTo(&sub_report, false)
 		r.Merge(sub_report)
 	case reflect.Slice:
 		for i := 0; i < vObj.Len(); i++ {
@@ -98,7 +96,7 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 				}
 			}
 			sub_report := Validate(vObj.Index(i), sub_node, source, checkUnusedKeys)
-			sub_report.AddPosition(line, col, "")
+			pos.addTo(&sub_report, false)
 			r.Merge(sub_report)
 		}
 	}
@@ -109,6 +107,45 @@ func ValidateWithoutSource(cfg reflect.Value) (report report.Report) {
 	return Validate(cfg, nil, nil, false)
 }
 
+// position lazily looks up the position of an ast node in the source. Finding
+// it requires scanning the source from its beginning, which would make
+// validating large configs quadratic if done for every node rather than only
+// for those with report entries.
+type position struct {
+	node   astnode.AstNode
+	source io.ReadSeeker
+
+	resolved  bool
+	line, col int
+	highlight string
+}
+
+// addTo sets the position of the entries of r which don't have one yet,
+// optionally including the highlighted source line.
+func (p *position) addTo(r *report.Report, withHighlight bool) {
+	if !needsPosition(*r) {
+		return
+	}
+	if !p.resolved && p.node != nil {
+		p.line, p.col, p.highlight = p.node.ValueLineCol(p.source)
+	}
+	p.resolved = true
+	if withHighlight {
+		r.AddPosition(p.line, p.col, p.highlight)
+	} else {
+		r.AddPosition(p.line, p.col, "")
+	}
+}
+
+func needsPosition(r report.Report) bool {
+	for _, e := range r.Entries {
+		if e.Line == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 type field struct {
 	Type  reflect.StructField
 	Value reflect.Value
@@ -120,20 +157,63 @@ func getFields(vObj reflect.Value) []field {
 	if vObj.Kind() != reflect.Struct {
 		return nil
 	}
-	ret := []field{}
-	for i := 0; i < vObj.Type().NumField(); i++ {
-		if vObj.Type().Field(i).Anonymous {
+	fields := structFields(vObj.Type())
+	ret := make([]field, 0, len(fields))
+	for i := range fields {
+		if fields[i].Anonymous {
 			// in the case of an embedded type that is an alias to interface, extract the
 			// real type contained by the interface
 			realObj := reflect.ValueOf(vObj.Field(i).Interface())
 			ret = append(ret, getFields(realObj)...)
 		} else {
-			ret = append(ret, field{Type: vObj.Type().Field(i), Value: vObj.Field(i)})
+			ret = append(ret, field{Type: fields[i], Value: vObj.Field(i)})
 		}
 	}
 	return ret
 }
 
+// typeInfo caches what validation needs to know about a struct type, since
+// looking it up via reflection for every node dominates validating large
+// configs.
+type typeInfo struct {
+	fields []reflect.StructField
+	// indices of the Validate<Name> methods, by field name
+	validateMethods map[string]int
+}
+
+var typeInfos sync.Map // reflect.Type -> *typeInfo
+
+func getTypeInfo(t reflect.Type) *typeInfo {
+	if info, ok := typeInfos.Load(t); ok {
+		return info.(*typeInfo)
+	}
+	info := &typeInfo{validateMethods: map[string]int{}}
+	for i := 0; i < t.NumField(); i++ {
+		info.fields = append(info.fields, t.Field(i))
+	}
+	for i := 0; i < t.NumMethod(); i++ {
+		if name := t.Method(i).Name; strings.HasPrefix(name, "Validate") {
+			info.validateMethods[strings.TrimPrefix(name, "Validate")] = i
+		}
+	}
+	typeInfos.Store(t, info)
+	return info
+}
+
+func structFields(t reflect.Type) []reflect.StructField {
+	return getTypeInfo(t).fields
+}
+
+// validateMethod returns the Validate<Name> method of vObj for the field
+// name, if there is one.
+func validateMethod(vObj reflect.Value, name string) (reflect.Value, bool) {
+	i, ok := getTypeInfo(vObj.Type()).validateMethods[name]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return vObj.Method(i), true
+}
+
 func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool) report.Report {
 	r := report.Report{}
 
@@ -158,7 +238,10 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 
 		// Try to determine the json.Node that corrosponds with the struct field
 		if isFromObject {
-			tag := strings.SplitN(f.Type.Tag.Get(ast.Tag()), ",", 2)[0]
+			tag := f.Type.Tag.Get(ast.Tag())
+			if i := strings.IndexByte(tag, ','); i >= 0 {
+				tag = tag[:i]
+			}
 			// Save the tag so we have a list of all the tags in the struct
 			tags = append(tags, tag)
 			// mark that this key was used
@@ -173,27 +256,22 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 
 		// Default to deepest node if the node's type isn't an object,
 		// such as when a json string actually unmarshal to structs (like with version)
-		line, col := 0, 0
-		highlight := ""
-		if ast != nil {
-			line, col, highlight = ast.ValueLineCol(src)
-		}
+		pos := &position{node: ast, source: src}
 
 		// If there's a Validate<Name> func for the given field, call it
-		funct := vObj.MethodByName("Validate" + f.Type.Name)
-		if funct.IsValid() {
+		if funct, ok := validateMethod(vObj, f.Type.Name); ok {
 			if sub_node != nil {
 				// if sub_node is non-nil, we can get better line/col info
-				line, col, highlight = sub_node.ValueLineCol(src)
+				pos = &position{node: sub_node, source: src}
 			}
 			res := funct.Call(nil)
 			sub_report := res[0].Interface().(report.Report)
-			sub_report.AddPosition(line, col, highlight)
+			pos.addTo(&sub_report, true)
 			r.Merge(sub_report)
 		}
 
 		sub_report := Validate(f.Value, sub_node, src, checkUnusedKeys)
-		sub_report.AddPosition(line, col, highlight)
+		pos.addTo(&sub_report, true)
 		r.Merge(sub_report)
 	}
 	if !isFromObject || !checkUnusedKeys {
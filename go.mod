@@ -12,6 +12,7 @@ require (
 	github.com/jmespath/go-jmespath v0.0.0-20160202185014-0b12d6b521d8 // indirect
 	github.com/pborman/uuid v0.0.0-20170612153648-e790cca94e6c
 	github.com/pin/tftp v2.1.0+incompatible
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/sigma/bdoor v0.0.0-20160202064022-babf2a4017b0 // indirect
 	github.com/sigma/vmw-guestinfo v0.0.0-20160204083807-95dd4126d6e8
 	github.com/smartystreets/goconvey v1.7.2 // indirect
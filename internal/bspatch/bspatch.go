@@ -0,0 +1,148 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bspatch applies patches in the format of bsdiff 4, as created by
+// the bsdiff tool: a header, followed by the bzip2 compressed control, diff
+// and extra blocks.
+package bspatch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	magic      = "BSDIFF40"
+	headerSize = 32
+
+	// the size of the chunks of the old file read at once
+	chunkSize = 32 * 1024
+)
+
+var (
+	ErrCorruptPatch = errors.New("corrupt bsdiff patch")
+)
+
+// offtin decodes the sign and magnitude encoded integers of patches.
+func offtin(b []byte) int64 {
+	y := int64(binary.LittleEndian.Uint64(b) &^ (1 << 63))
+	if b[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
+
+// Apply writes the result of applying the patch to old, which is oldSize
+// bytes long, to w. The result is written as it's created, so it never is in
+// memory in full.
+func Apply(old io.ReaderAt, oldSize int64, patch []byte, w io.Writer) error {
+	if len(patch) < headerSize || string(patch[:len(magic)]) != magic {
+		return ErrCorruptPatch
+	}
+	ctrlLen := offtin(patch[8:])
+	diffLen := offtin(patch[16:])
+	newSize := offtin(patch[24:])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 ||
+		ctrlLen > int64(len(patch)-headerSize) ||
+		diffLen > int64(len(patch)-headerSize)-ctrlLen {
+		return ErrCorruptPatch
+	}
+	body := patch[headerSize:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	out := bufio.NewWriter(w)
+	buf := make([]byte, chunkSize)
+	oldBuf := make([]byte, chunkSize)
+	var newPos, oldPos int64
+	var c [24]byte
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, c[:]); err != nil {
+			return ErrCorruptPatch
+		}
+		addLen, copyLen, seek := offtin(c[0:]), offtin(c[8:]), offtin(c[16:])
+		if addLen < 0 || copyLen < 0 || addLen > newSize-newPos || copyLen > newSize-newPos-addLen {
+			return ErrCorruptPatch
+		}
+
+		// add the diff block to the old file
+		for n := addLen; n > 0; {
+			chunk := buf[:min(n, chunkSize)]
+			if _, err := io.ReadFull(diff, chunk); err != nil {
+				return ErrCorruptPatch
+			}
+			if err := readOld(old, oldSize, oldPos, oldBuf[:len(chunk)]); err != nil {
+				return err
+			}
+			for i := range chunk {
+				chunk[i] += oldBuf[i]
+			}
+			if _, err := out.Write(chunk); err != nil {
+				return err
+			}
+			n -= int64(len(chunk))
+			oldPos += int64(len(chunk))
+		}
+		newPos += addLen
+
+		// then copy the extra block
+		if _, err := io.CopyN(out, extra, copyLen); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrCorruptPatch
+			}
+			return err
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+	return out.Flush()
+}
+
+// readOld fills b with the old file from off. The parts of b outside of the
+// old file are zeroed, as patches may refer to them.
+func readOld(old io.ReaderAt, oldSize, off int64, b []byte) error {
+	for i := range b {
+		b[i] = 0
+	}
+	start, end := off, off+int64(len(b))
+	if start < 0 {
+		start = 0
+	}
+	if end > oldSize {
+		end = oldSize
+	}
+	if start >= end {
+		return nil
+	}
+	n, err := old.ReadAt(b[start-off:end-off], start)
+	if int64(n) == end-start {
+		return nil
+	}
+	if err == io.EOF {
+		return ErrCorruptPatch
+	}
+	return err
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bspatch
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// testPatch turns testOld into testNew: it keeps "the quick ", adds "green"
+// in place of "brown", keeps the rest of the line with a changed last byte,
+// appends a line and seeks back to the start of the old file.
+const (
+	testOld   = "the quick brown fox jumps over the lazy dog\n"
+	testNew   = "the quick green fox jumps over the lazy dog!\nand a new line\n"
+	testPatch = "425344494646343036000000000000002b000000000000003c00000000000000" +
+		"425a683931415926535901666a0300001070405a10400200044000200031064c" +
+		"40d3434c8d265a005310d4f17724538509001666a030425a6839314159265359" +
+		"5679c4740000007000400000801000200030cc0cf505ce2ee48a70a120acf388" +
+		"e8425a68393141592653597546a0ed00000651800010400026a5108020003100" +
+		"d34d050cdaa1faa7644030f374cb1a6fe2ee48a70a120ea8d41da0"
+)
+
+func TestApply(t *testing.T) {
+	patch, err := hex.DecodeString(testPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Apply(bytes.NewReader([]byte(testOld)), int64(len(testOld)), patch, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != testNew {
+		t.Errorf("bad result: want %q, got %q", testNew, out.String())
+	}
+
+	// truncated and mangled patches are rejected
+	for i, p := range [][]byte{
+		patch[:20],
+		patch[:100],
+		append([]byte("BSDIFF41"), patch[8:]...),
+	} {
+		out.Reset()
+		if err := Apply(bytes.NewReader([]byte(testOld)), int64(len(testOld)), p, &out); err != ErrCorruptPatch {
+			t.Errorf("#%d: expected %v, got %v", i, ErrCorruptPatch, err)
+		}
+	}
+}
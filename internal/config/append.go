@@ -39,7 +39,8 @@ func Append(oldConfig, newConfig types.Config) types.Config {
 // overwriting old values with the new for all other types. Some individual
 // struct fields have alternate merge strategies, determined by the field name.
 // Currently these fields are "ignition.version", which uses the old value, and
-// "ignition.config" which uses the new value.
+// "ignition.config" which uses the new value. Maps are merged, with the values
+// of the new map replacing those of the old for the keys in both.
 func appendStruct(vOld, vNew reflect.Value) reflect.Value {
 	tOld := vOld.Type()
 	vRes := reflect.New(tOld)
@@ -63,6 +64,8 @@ func appendStruct(vOld, vNew reflect.Value) reflect.Value {
 			vfRes.Set(appendStruct(vfOld, vfNew))
 		case reflect.Slice:
 			vfRes.Set(reflect.AppendSlice(vfOld, vfNew))
+		case reflect.Map:
+			vfRes.Set(appendMap(vfOld, vfNew))
 		default:
 			if vfNew.Kind() == reflect.Ptr && vfNew.IsNil() {
 				vfRes.Set(vfOld)
@@ -74,3 +77,19 @@ func appendStruct(vOld, vNew reflect.Value) reflect.Value {
 
 	return vRes.Elem()
 }
+
+// appendMap returns a new map with the entries of vOld and vNew, of which
+// vNew's win, or vOld if vNew is empty.
+func appendMap(vOld, vNew reflect.Value) reflect.Value {
+	if vNew.Len() == 0 {
+		return vOld
+	}
+	vRes := reflect.MakeMapWithSize(vOld.Type(), vOld.Len()+vNew.Len())
+	for _, v := range []reflect.Value{vOld, vNew} {
+		iter := v.MapRange()
+		for iter.Next() {
+			vRes.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	return vRes
+}
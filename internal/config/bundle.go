@@ -0,0 +1,134 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// Bundle is a payload carrying several named configs, of which the one
+// named by the value of the selector on the machine is applied, e.g.
+//
+//	{"bundle": {"selector": "cmdline:flatcar.role", "default": "worker",
+//	  "configs": {"worker": {...}, "control-plane": {...}}}}
+type Bundle struct {
+	// Selector is "cmdline:<argument>", the value of a kernel argument, or
+	// "metadata:<attribute>", the value of a metadata attribute like
+	// IGNITION_PLATFORM.
+	Selector string `json:"selector"`
+	// Default is the config applied if the selector has no value.
+	Default string                     `json:"default,omitempty"`
+	Configs map[string]json.RawMessage `json:"configs"`
+}
+
+// ParseBundle returns the bundle in rawConfig, or nil if it's not a bundle.
+func ParseBundle(rawConfig []byte) (*Bundle, error) {
+	var payload struct {
+		Bundle   *Bundle         `json:"bundle"`
+		Ignition json.RawMessage `json:"ignition"`
+	}
+	// payloads which aren't JSON objects are left to the config parser
+	trimmed := bytes.TrimSpace(rawConfig)
+	if !bytes.HasPrefix(trimmed, []byte("{")) || json.Unmarshal(trimmed, &payload) != nil || payload.Bundle == nil {
+		return nil, nil
+	}
+	if payload.Ignition != nil {
+		return nil, errors.ErrBundleWithConfig
+	}
+	b := payload.Bundle
+	if _, _, ok := splitBundleSelector(b.Selector); !ok {
+		return nil, errors.ErrBundleSelector
+	}
+	if len(b.Configs) == 0 {
+		return nil, errors.ErrBundleEmpty
+	}
+	if _, ok := b.Configs[b.Default]; b.Default != "" && !ok {
+		return nil, errors.ErrBundleDefault
+	}
+	for _, raw := range b.Configs {
+		if nested, err := ParseBundle(raw); err != nil || nested != nil {
+			return nil, errors.ErrBundleNested
+		}
+	}
+	return b, nil
+}
+
+// Select returns the name and contents of the config chosen by the value of
+// the selector, which is looked up on this machine.
+func (b Bundle) Select() (string, []byte, error) {
+	value, found, err := bundleSelectorValue(b.Selector)
+	if err != nil {
+		return "", nil, err
+	}
+	name := value
+	if !found {
+		name = b.Default
+	}
+	raw, ok := b.Configs[name]
+	if !ok || name == "" {
+		return "", nil, errors.ErrBundleNoMatch
+	}
+	return name, raw, nil
+}
+
+func splitBundleSelector(selector string) (string, string, bool) {
+	parts := strings.SplitN(selector, ":", 2)
+	if len(parts) != 2 || parts[1] == "" || strings.ContainsAny(parts[1], " =\t\n") {
+		return "", "", false
+	}
+	switch parts[0] {
+	case "cmdline", "metadata":
+		return parts[0], parts[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// bundleSelectorValue returns the value of a selector on this machine, and
+// whether it has one.
+func bundleSelectorValue(selector string) (string, bool, error) {
+	kind, key, _ := splitBundleSelector(selector)
+	switch kind {
+	case "cmdline":
+		cmdline, err := ioutil.ReadFile(distro.KernelCmdlinePath())
+		if err != nil {
+			return "", false, err
+		}
+		value, found := "", false
+		// the last occurrence wins, like for the kernel's own arguments
+		for _, arg := range strings.Fields(string(cmdline)) {
+			if parts := strings.SplitN(arg, "=", 2); parts[0] == key && len(parts) == 2 {
+				value, found = parts[1], true
+			}
+		}
+		return value, found, nil
+	default:
+		attrs, err := ReadMetadataAttributes(distro.MetadataAttributesPath())
+		if os.IsNotExist(err) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		value, found := attrs[key]
+		return value, found, nil
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	metadata := filepath.Join(dir, "ignition")
+	if err := ioutil.WriteFile(metadata, []byte(FormatMetadataAttributes("gce", map[string]string{"ROLE": ""})), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("IGNITION_METADATA_PATH", metadata)
+	defer os.Unsetenv("IGNITION_METADATA_PATH")
+
+	type out struct {
+		name     string
+		parseErr error
+		err      error
+	}
+
+	tests := []struct {
+		in  string
+		out out
+	}{
+		{
+			in:  `{"ignition": {"version": "2.4.0-experimental"}}`,
+			out: out{},
+		},
+		{
+			in:  `not json`,
+			out: out{},
+		},
+		{
+			in:  `{"bundle": {"selector": "metadata:IGNITION_PLATFORM", "configs": {"gce": {}, "ec2": {}}}}`,
+			out: out{name: "gce"},
+		},
+		{
+			in:  `{"bundle": {"selector": "metadata:IGNITION_ROLE", "configs": {"": {}}}}`,
+			out: out{err: errors.ErrBundleNoMatch},
+		},
+		{
+			in:  `{"bundle": {"selector": "metadata:IGNITION_MISSING", "default": "ec2", "configs": {"gce": {}, "ec2": {}}}}`,
+			out: out{name: "ec2"},
+		},
+		{
+			in:  `{"bundle": {"selector": "metadata:IGNITION_PLATFORM", "configs": {"ec2": {}}}}`,
+			out: out{err: errors.ErrBundleNoMatch},
+		},
+		{
+			in:  `{"bundle": {"selector": "metadata:IGNITION_MISSING", "configs": {"ec2": {}}}}`,
+			out: out{err: errors.ErrBundleNoMatch},
+		},
+		{
+			in:  `{"bundle": {"selector": "dmi:product", "configs": {"ec2": {}}}}`,
+			out: out{parseErr: errors.ErrBundleSelector},
+		},
+		{
+			in:  `{"bundle": {"selector": "cmdline:", "configs": {"ec2": {}}}}`,
+			out: out{parseErr: errors.ErrBundleSelector},
+		},
+		{
+			in:  `{"ignition": {"version": "2.4.0"}, "bundle": {"selector": "cmdline:role", "configs": {"ec2": {}}}}`,
+			out: out{parseErr: errors.ErrBundleWithConfig},
+		},
+		{
+			in:  `{"bundle": {"selector": "cmdline:role", "configs": {}}}`,
+			out: out{parseErr: errors.ErrBundleEmpty},
+		},
+		{
+			in:  `{"bundle": {"selector": "cmdline:role", "default": "gce", "configs": {"ec2": {}}}}`,
+			out: out{parseErr: errors.ErrBundleDefault},
+		},
+		{
+			in:  `{"bundle": {"selector": "cmdline:role", "configs": {"ec2": {"bundle": {"selector": "cmdline:role", "configs": {"a": {}}}}}}}`,
+			out: out{parseErr: errors.ErrBundleNested},
+		},
+	}
+
+	for i, test := range tests {
+		bundle, err := ParseBundle([]byte(test.in))
+		if !reflect.DeepEqual(test.out.parseErr, err) {
+			t.Errorf("#%d: bad parse error: want %v, got %v", i, test.out.parseErr, err)
+			continue
+		}
+		if bundle == nil {
+			if test.out.name != "" || test.out.err != nil {
+				t.Errorf("#%d: payload wasn't parsed as a bundle", i)
+			}
+			continue
+		}
+		name, _, err := bundle.Select()
+		if !reflect.DeepEqual(test.out.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		} else if name != test.out.name {
+			t.Errorf("#%d: bad config: want %q, got %q", i, test.out.name, name)
+		}
+	}
+}
@@ -15,15 +15,50 @@
 package config
 
 import (
+	"fmt"
+
+	"github.com/flatcar/ignition/config/shared/errors"
 	currentExperimental "github.com/flatcar/ignition/config/v2_4"
 	"github.com/flatcar/ignition/config/validate/report"
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/fips"
 )
 
 func Parse(rawConfig []byte) (types.Config, report.Report, error) {
-	cfg, rpt, err := currentExperimental.Parse(rawConfig)
+	if max := distro.MaxConfigSize(); max > 0 && int64(len(rawConfig)) > max {
+		return types.Config{}, report.Report{}, errors.ErrConfigTooLarge
+	}
+	var rpt report.Report
+	bundle, err := ParseBundle(rawConfig)
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+	if bundle != nil {
+		name, raw, err := bundle.Select()
+		if err != nil {
+			return types.Config{}, report.Report{}, err
+		}
+		rpt.Add(report.Entry{
+			Kind:    report.EntryInfo,
+			Message: fmt.Sprintf("selected config %q of the bundle by %s", name, bundle.Selector),
+		})
+		rawConfig = raw
+	}
+	cfg, r, err := currentExperimental.Parse(rawConfig)
+	rpt.Merge(r)
 	if err != nil || rpt.IsFatal() {
 		return types.Config{}, rpt, err
 	}
-	return Translate(cfg), rpt, nil
+	config := Translate(cfg)
+	if distro.RestrictedExec() {
+		rpt.Merge(ValidateRestricted(config))
+	}
+	if fips.Enabled() {
+		rpt.Merge(ValidateFIPS(config))
+	}
+	if rpt.IsFatal() {
+		return types.Config{}, rpt, errors.ErrInvalid
+	}
+	return config, rpt, nil
 }
@@ -0,0 +1,77 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/fips"
+)
+
+var verificationType = reflect.TypeOf(types.Verification{})
+
+// ValidateFIPS reports the parts of cfg requiring algorithms which aren't
+// approved for use in FIPS mode.
+func ValidateFIPS(cfg types.Config) report.Report {
+	r := report.Report{}
+	reject := func(format string, a ...interface{}) {
+		r.Add(report.Entry{
+			Message: fmt.Sprintf(format, a...) + " (not approved in FIPS mode)",
+			Kind:    report.EntryError,
+		})
+	}
+
+	forEachVerification(reflect.ValueOf(cfg), func(v types.Verification) {
+		if v.Hash == nil {
+			return
+		}
+		function := strings.SplitN(*v.Hash, "-", 2)[0]
+		if !fips.ApprovedHash(function) {
+			reject("hash function %q", function)
+		}
+	})
+	for _, k := range cfg.SSH.HostKeys {
+		if !fips.ApprovedSSHHostKeyType(k.Type) {
+			reject("SSH host key type %q", k.Type)
+		}
+	}
+	return r
+}
+
+// forEachVerification calls f with every Verification in v.
+func forEachVerification(v reflect.Value, f func(types.Verification)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == verificationType {
+			f(v.Interface().(types.Verification))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			forEachVerification(v.Field(i), f)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			forEachVerification(v.Index(i), f)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			forEachVerification(v.Elem(), f)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestValidateFIPS(t *testing.T) {
+	type in struct {
+		config types.Config
+	}
+	type out struct {
+		messages []string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{config: types.Config{}},
+			out: out{},
+		},
+		{
+			in: in{config: types.Config{
+				Ignition: types.Ignition{
+					Config: types.IgnitionConfig{
+						Replace: &types.ConfigReference{Verification: types.Verification{Hash: strToPtr("sha512-00")}},
+					},
+				},
+				SSH: types.SSH{
+					HostKeys: []types.SSHHostKey{{Type: "ecdsa"}, {Type: "rsa"}},
+				},
+			}},
+			out: out{},
+		},
+		{
+			in: in{config: types.Config{
+				Ignition: types.Ignition{
+					Config: types.IgnitionConfig{
+						Append: []types.ConfigReference{{Verification: types.Verification{Hash: strToPtr("md5-00")}}},
+					},
+				},
+				Storage: types.Storage{
+					Files: []types.File{{FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Verification: types.Verification{Hash: strToPtr("sha1-00")}}}}},
+				},
+			}},
+			out: out{messages: []string{
+				`hash function "md5" (not approved in FIPS mode)`,
+				`hash function "sha1" (not approved in FIPS mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				SSH: types.SSH{
+					HostKeys: []types.SSHHostKey{{Type: "ed25519"}, {Type: "dsa"}},
+				},
+			}},
+			out: out{messages: []string{
+				`SSH host key type "ed25519" (not approved in FIPS mode)`,
+				`SSH host key type "dsa" (not approved in FIPS mode)`,
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		r := ValidateFIPS(test.in.config)
+		var messages []string
+		for _, e := range r.Entries {
+			messages = append(messages, e.Message)
+		}
+		if !reflect.DeepEqual(test.out.messages, messages) {
+			t.Errorf("#%d: bad messages: want %q, got %q", i, test.out.messages, messages)
+		}
+	}
+}
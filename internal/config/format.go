@@ -0,0 +1,197 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	currentExperimental "github.com/flatcar/ignition/config/v2_4"
+	experimentalTypes "github.com/flatcar/ignition/config/v2_4/types"
+	"github.com/flatcar/ignition/config/validate/report"
+)
+
+// Format returns rawConfig in canonical form: keys sorted, indented by two
+// spaces and with the fields left at their defaults omitted, so that the diff
+// between two revisions of a config only shows what changed. The configs of a
+// bundle are formatted each. Only configs of the current version are
+// formatted, since formatting older ones would upgrade them.
+func Format(rawConfig []byte) ([]byte, report.Report, error) {
+	bundle, err := ParseBundle(rawConfig)
+	if err != nil {
+		return nil, report.Report{}, err
+	}
+	if bundle == nil {
+		return formatConfig(rawConfig)
+	}
+	var rpt report.Report
+	formatted := *bundle
+	formatted.Configs = map[string]json.RawMessage{}
+	for name, raw := range bundle.Configs {
+		out, r, err := formatConfig(raw)
+		rpt.Merge(r)
+		if err != nil {
+			return nil, rpt, fmt.Errorf("config %q of the bundle: %v", name, err)
+		}
+		formatted.Configs[name] = json.RawMessage(out)
+	}
+	out, err := json.Marshal(struct {
+		Bundle Bundle `json:"bundle"`
+	}{formatted})
+	if err != nil {
+		return nil, rpt, err
+	}
+	out, err = canonicalJSON(out, reflect.Value{})
+	return out, rpt, err
+}
+
+func formatConfig(rawConfig []byte) ([]byte, report.Report, error) {
+	var header struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+	// payloads which aren't JSON are left to the parser to report
+	if json.Unmarshal(rawConfig, &header) == nil {
+		version, err := semver.NewVersion(header.Ignition.Version)
+		if err != nil || *version != experimentalTypes.MaxVersion {
+			return nil, report.Report{}, errors.ErrFormatVersion
+		}
+	}
+	cfg, rpt, err := currentExperimental.Parse(rawConfig)
+	if err != nil {
+		return nil, rpt, err
+	}
+	for _, entry := range rpt.Entries {
+		if strings.HasPrefix(entry.Message, "Config has unrecognized key") {
+			return nil, rpt, errors.ErrFormatUnrecognizedKeys
+		}
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, rpt, err
+	}
+	out, err = canonicalJSON(out, reflect.ValueOf(cfg))
+	return out, rpt, err
+}
+
+// canonicalJSON re-encodes raw with sorted keys and two space indentation,
+// keeping numbers as they are and not escaping HTML characters. If raw is the
+// encoding of cfg, the members encoding the struct fields of cfg which are
+// left empty are dropped, as encoding/json writes them as {} when unset.
+func canonicalJSON(raw []byte, cfg reflect.Value) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if cfg.IsValid() {
+		pruneEmptyStructs(cfg, value)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pruneEmptyStructs drops the members of the objects in value, the JSON
+// encoding of v, which encode struct fields whose members are all empty.
+// Pointers to empty structs are kept, as setting them changes what the
+// config means, e.g. "create": {} of a mount.
+func pruneEmptyStructs(v reflect.Value, value interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			pruneEmptyStructs(v.Elem(), value)
+		}
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.Anonymous && name == "" {
+				// the members of embedded structs are the object's
+				pruneEmptyStructs(v.Field(i), object)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			member, ok := object[name]
+			if !ok {
+				continue
+			}
+			if v.Field(i).Kind() == reflect.Struct && isEmptyValue(v.Field(i)) {
+				delete(object, name)
+				continue
+			}
+			pruneEmptyStructs(v.Field(i), member)
+		}
+	case reflect.Slice, reflect.Array:
+		elements, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i := 0; i < v.Len() && i < len(elements); i++ {
+			pruneEmptyStructs(v.Index(i), elements[i])
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if member, ok := object[iter.Key().String()]; ok {
+				pruneEmptyStructs(iter.Value(), member)
+			}
+		}
+	}
+}
+
+// isEmptyValue returns whether v is empty the way omitempty treats values,
+// structs being empty if all their fields are.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isEmptyValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
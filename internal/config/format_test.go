@@ -0,0 +1,152 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	currentExperimental "github.com/flatcar/ignition/config/v2_4"
+)
+
+func TestFormat(t *testing.T) {
+	type out struct {
+		config string
+		err    error
+	}
+
+	tests := []struct {
+		in  string
+		out out
+	}{
+		{
+			in: `{"storage": {"files": [{"path": "/etc/motd", "filesystem": "root", "mode": 420, "contents": {"source": "data:,<hello>"}, "overwrite": null}]},
+			      "passwd": {}, "ignition": {"version": "2.4.0", "config": {}}}`,
+			out: out{config: `{
+  "ignition": {
+    "version": "2.4.0"
+  },
+  "storage": {
+    "files": [
+      {
+        "contents": {
+          "source": "data:,<hello>"
+        },
+        "filesystem": "root",
+        "mode": 420,
+        "path": "/etc/motd"
+      }
+    ]
+  }
+}
+`},
+		},
+		{
+			in: `{"bundle": {"configs": {"b": {"ignition": {"version": "2.4.0"}}, "a": {"ignition": {"version": "2.4.0"}, "passwd": {}}}, "selector": "cmdline:role"}}`,
+			out: out{config: `{
+  "bundle": {
+    "configs": {
+      "a": {
+        "ignition": {
+          "version": "2.4.0"
+        }
+      },
+      "b": {
+        "ignition": {
+          "version": "2.4.0"
+        }
+      }
+    },
+    "selector": "cmdline:role"
+  }
+}
+`},
+		},
+		{
+			in: `{"ignition": {"version": "2.4.0", "security": {"tls": {}}},
+			      "storage": {"filesystems": [{"name": "data", "mount": {"device": "/dev/sdb", "format": "ext4", "create": {}}}],
+			                  "files": [{"path": "/opt/probe", "filesystem": "root", "capabilities": {}, "contents": {}}]},
+			      "passwd": {"users": [{"name": "core", "create": {}}]}}`,
+			out: out{config: `{
+  "ignition": {
+    "version": "2.4.0"
+  },
+  "passwd": {
+    "users": [
+      {
+        "create": {},
+        "name": "core"
+      }
+    ]
+  },
+  "storage": {
+    "files": [
+      {
+        "capabilities": {},
+        "filesystem": "root",
+        "path": "/opt/probe"
+      }
+    ],
+    "filesystems": [
+      {
+        "mount": {
+          "create": {},
+          "device": "/dev/sdb",
+          "format": "ext4"
+        },
+        "name": "data"
+      }
+    ]
+  }
+}
+`},
+		},
+		{
+			in:  `{"ignition": {"version": "2.3.0"}}`,
+			out: out{err: errors.ErrFormatVersion},
+		},
+		{
+			in:  `{"ignition": {"version": "2.4.0"}, "storage": {"filez": []}}`,
+			out: out{err: errors.ErrFormatUnrecognizedKeys},
+		},
+		{
+			in:  `not json`,
+			out: out{err: errors.ErrInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		formatted, _, err := Format([]byte(test.in))
+		if !reflect.DeepEqual(test.out, out{string(formatted), err}) {
+			t.Errorf("#%d: bad result: want %#v, got %#v", i, test.out, out{string(formatted), err})
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		// formatting is idempotent
+		again, _, err := Format(formatted)
+		if err != nil || string(again) != string(formatted) {
+			t.Errorf("#%d: formatting again changed the config: %q, %v", i, again, err)
+		}
+		// formatting doesn't change what the config means
+		before, _, errBefore := currentExperimental.Parse([]byte(test.in))
+		after, _, errAfter := currentExperimental.Parse(formatted)
+		if errBefore == nil && (errAfter != nil || !reflect.DeepEqual(before, after)) {
+			t.Errorf("#%d: formatting changed the config: want %+v, got %+v, %v", i, before, after, errAfter)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+// Graph is the graph of the operations Ignition performs for a config, with
+// an edge from each operation to the operations which have to wait for it.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an operation, such as partitioning a disk or writing a file.
+type GraphNode struct {
+	ID    string `json:"id"`
+	Stage string `json:"stage"`
+	Label string `json:"label"`
+}
+
+// GraphEdge orders operation To after operation From, for the given Reason.
+type GraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+type graphBuilder struct {
+	g     Graph
+	nodes map[string]bool
+	edges map[GraphEdge]bool
+	// devices maps device paths to the operations creating them
+	devices map[string]string
+	// dirs and links map filesystems and paths to the operations creating
+	// directories and links there
+	dirs  map[string]string
+	links map[string]string
+	users []types.PasswdUser
+}
+
+// DependencyGraph returns the operations Ignition performs for cfg and the
+// dependencies between them which make it order them as it does: disks
+// before RAID arrays before filesystems in the disks stage, and filesystems,
+// users and groups, directories and links before the nodes below and owned
+// by them in the files stage. The root filesystem, which Ignition adds to
+// every config, is included.
+func DependencyGraph(cfg types.Config) Graph {
+	b := &graphBuilder{
+		g:       Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}},
+		nodes:   map[string]bool{},
+		edges:   map[GraphEdge]bool{},
+		devices: map[string]string{},
+		dirs:    map[string]string{},
+		links:   map[string]string{},
+	}
+
+	for _, disk := range cfg.Storage.Disks {
+		id := "disk:" + disk.Device
+		b.node(id, "disks", fmt.Sprintf("partition disk %s", disk.Device))
+		b.devices[disk.Device] = id
+		for _, p := range disk.Partitions {
+			name := strconv.Itoa(p.Number)
+			if p.Label != nil && *p.Label != "" {
+				name = *p.Label
+			}
+			pid := fmt.Sprintf("partition:%s:%s", disk.Device, name)
+			b.node(pid, "disks", fmt.Sprintf("partition %s on %s", name, disk.Device))
+			b.edge(id, pid, "partition of the disk")
+			for _, dev := range partitionDevices(disk.Device, p) {
+				b.devices[dev] = pid
+			}
+		}
+	}
+
+	for _, raid := range cfg.Storage.Raid {
+		id := "raid:" + raid.Name
+		b.node(id, "disks", fmt.Sprintf("create %s RAID array %s", raid.Level, raid.Name))
+		for _, dev := range raid.Devices {
+			b.device(string(dev), id, "member device")
+		}
+		b.devices["/dev/md/"+raid.Name] = id
+	}
+
+	filesystems := map[string]string{"root": "filesystem:root"}
+	b.node("filesystem:root", "files", "root filesystem")
+	for _, fs := range cfg.Storage.Filesystems {
+		id := "filesystem:" + fs.Name
+		filesystems[fs.Name] = id
+		switch {
+		case fs.Mount != nil:
+			b.node(id, "disks", fmt.Sprintf("create %s filesystem %s on %s, mounted while writing its files", fs.Mount.Format, fs.Name, fs.Mount.Device))
+			b.device(fs.Mount.Device, id, "on the device")
+		case fs.Path != nil:
+			b.node(id, "files", fmt.Sprintf("filesystem %s at %s", fs.Name, *fs.Path))
+		}
+	}
+
+	if cfg.Passwd.Policy != nil {
+		b.node("policy", "files", "write the password policy")
+		b.edge("filesystem:root", "policy", "on the filesystem")
+	}
+	for _, g := range cfg.Passwd.Groups {
+		b.node("group:"+g.Name, "files", "create group "+g.Name)
+	}
+	for _, u := range cfg.Passwd.Users {
+		id := "user:" + u.Name
+		b.node(id, "files", "create user "+u.Name)
+		if cfg.Passwd.Policy != nil {
+			b.edge("policy", id, "password aging defaults")
+		}
+		b.users = append(b.users, u)
+		if u.PrimaryGroup != "" {
+			b.group(u.PrimaryGroup, id, "primary group")
+		}
+		for _, g := range u.Groups {
+			b.group(string(g), id, "supplementary group")
+		}
+		if u.Create != nil {
+			if u.Create.PrimaryGroup != "" {
+				b.group(u.Create.PrimaryGroup, id, "primary group")
+			}
+			for _, g := range u.Create.Groups {
+				b.group(string(g), id, "supplementary group")
+			}
+		}
+	}
+
+	if len(cfg.Passwd.Sudoers) > 0 {
+		b.node("sudoers", "files", "write sudoers entries")
+		b.edge("filesystem:root", "sudoers", "on the filesystem")
+		for _, s := range cfg.Passwd.Sudoers {
+			if s.User != "" && b.nodes["user:"+s.User] {
+				b.edge("user:"+s.User, "sudoers", "user of an entry")
+			}
+			if s.Group != "" {
+				b.group(s.Group, "sudoers", "group of an entry")
+			}
+		}
+	}
+
+	// index the directories and links first, as they may come after the
+	// nodes below them in the config
+	for _, d := range cfg.Storage.Directories {
+		b.dirs[nodeKey(d.Node)] = "directory:" + nodeKey(d.Node)
+	}
+	for _, l := range cfg.Storage.Links {
+		b.links[nodeKey(l.Node)] = "link:" + nodeKey(l.Node)
+	}
+	for _, d := range cfg.Storage.Directories {
+		b.fsNode(d.Node, "directory", "create directory", filesystems)
+	}
+	for _, f := range cfg.Storage.Files {
+		verb := "write file"
+		if f.Append {
+			verb = "append to file"
+		}
+		b.fsNode(f.Node, "file", verb, filesystems)
+	}
+	for _, a := range cfg.Storage.Archives {
+		b.fsNode(types.Node{Filesystem: a.Filesystem, Path: a.Path}, "archive", "extract archive to", filesystems)
+	}
+	for _, e := range cfg.Storage.Edits {
+		n := types.Node{Filesystem: e.Filesystem, Path: e.Path}
+		id := b.fsNode(n, "edit", "edit", filesystems)
+		// edits apply to what the files of the config wrote
+		for _, f := range cfg.Storage.Files {
+			if nodeKey(f.Node) == nodeKey(n) {
+				b.edge("file:"+nodeKey(n), id, "edited file")
+			}
+		}
+	}
+	for _, m := range cfg.Storage.Manifests {
+		b.fsNode(types.Node{Filesystem: m.Filesystem, Path: m.Path}, "manifest", "write the files listed by manifest into", filesystems)
+	}
+	for _, l := range cfg.Storage.Links {
+		id := b.fsNode(l.Node, "link", "create link", filesystems)
+		if l.Hard {
+			target := nodeKey(types.Node{Filesystem: l.Filesystem, Path: l.Target})
+			for _, f := range cfg.Storage.Files {
+				if nodeKey(f.Node) == target {
+					b.edge("file:"+target, id, "hard link target")
+				}
+			}
+		}
+	}
+
+	for _, e := range cfg.Systemd.Extensions {
+		dir := "/var/lib/extensions"
+		if e.Type == "confext" {
+			dir = "/var/lib/confexts"
+		}
+		b.fsNode(types.Node{Filesystem: "root", Path: path.Join(dir, e.Name)}, "extension", "write system extension", filesystems)
+	}
+	if u := cfg.Update; u.Group != "" || u.Server != "" || u.RebootStrategy != "" || u.RebootWindow != nil {
+		b.fsNode(types.Node{Filesystem: "root", Path: "/etc/flatcar/update.conf"}, "update", "write update config", filesystems)
+	}
+	for _, unit := range cfg.Systemd.Units {
+		b.node("unit:"+unit.Name, "files", "write systemd unit "+unit.Name)
+	}
+	for _, unit := range cfg.Networkd.Units {
+		b.node("networkd:"+unit.Name, "files", "write networkd unit "+unit.Name)
+	}
+	for _, link := range cfg.Networkd.Links {
+		name := link.Name
+		if name == "" && len(link.AlternativeNames) > 0 {
+			name = link.AlternativeNames[0]
+		}
+		b.node("networkd-link:"+name, "files", "write networkd link "+name)
+	}
+	for _, bond := range cfg.Networkd.Bonds {
+		b.node("networkd-netdev:"+bond.Name, "files", "write networkd bond "+bond.Name)
+	}
+	for _, bridge := range cfg.Networkd.Bridges {
+		b.node("networkd-netdev:"+bridge.Name, "files", "write networkd bridge "+bridge.Name)
+	}
+	for _, vlan := range cfg.Networkd.Vlans {
+		b.node("networkd-netdev:"+vlan.Name, "files", "write networkd VLAN "+vlan.Name)
+	}
+	for _, wg := range cfg.Networkd.WireGuard {
+		b.node("networkd-netdev:"+wg.Name, "files", "write networkd WireGuard interface "+wg.Name)
+	}
+	return b.g
+}
+
+func (b *graphBuilder) node(id, stage, label string) {
+	if b.nodes[id] {
+		return
+	}
+	b.nodes[id] = true
+	b.g.Nodes = append(b.g.Nodes, GraphNode{ID: id, Stage: stage, Label: label})
+}
+
+func (b *graphBuilder) edge(from, to, reason string) {
+	e := GraphEdge{From: from, To: to, Reason: reason}
+	if from == to || b.edges[e] {
+		return
+	}
+	b.edges[e] = true
+	b.g.Edges = append(b.g.Edges, e)
+}
+
+// device orders id after the operation creating dev, if the config creates
+// it.
+func (b *graphBuilder) device(dev, id, reason string) {
+	if from, ok := b.devices[dev]; ok {
+		b.edge(from, id, reason)
+	}
+}
+
+// group orders id after the creation of the group name, if the config
+// creates it.
+func (b *graphBuilder) group(name, id, reason string) {
+	if b.nodes["group:"+name] {
+		b.edge("group:"+name, id, reason)
+	}
+}
+
+// fsNode adds the node n of the given kind and orders it after its
+// filesystem, the directories and links above it and its owners.
+func (b *graphBuilder) fsNode(n types.Node, kind, verb string, filesystems map[string]string) string {
+	key := nodeKey(n)
+	id := kind + ":" + key
+	b.node(id, "files", fmt.Sprintf("%s %s on %s", verb, n.Path, n.Filesystem))
+	if fs, ok := filesystems[n.Filesystem]; ok {
+		b.edge(fs, id, "on the filesystem")
+	}
+
+	nearestDir := true
+	for dir := path.Dir(path.Clean("/" + n.Path)); dir != "/"; dir = path.Dir(dir) {
+		parent := nodeKey(types.Node{Filesystem: n.Filesystem, Path: dir})
+		if d, ok := b.dirs[parent]; ok && nearestDir {
+			b.edge(d, id, "below the directory")
+			nearestDir = false
+		}
+		if l, ok := b.links[parent]; ok {
+			b.edge(l, id, "below the link")
+		}
+	}
+	if n.Filesystem == "root" {
+		for _, u := range b.users {
+			if home := homeDir(u); home != "" && strings.HasPrefix(path.Clean("/"+n.Path), home+"/") {
+				b.edge("user:"+u.Name, id, "in the user's home directory")
+			}
+		}
+	}
+
+	if n.User != nil && n.User.Name != "" && b.nodes["user:"+n.User.Name] {
+		b.edge("user:"+n.User.Name, id, "owned by the user")
+	}
+	if n.Group != nil && n.Group.Name != "" {
+		b.group(n.Group.Name, id, "owned by the group")
+	}
+	return id
+}
+
+func nodeKey(n types.Node) string {
+	return n.Filesystem + ":" + path.Clean("/"+n.Path)
+}
+
+// homeDir returns the home directory useradd creates for u, if any.
+func homeDir(u types.PasswdUser) string {
+	home, noCreate := u.HomeDir, u.NoCreateHome
+	if u.Create != nil {
+		home, noCreate = u.Create.HomeDir, u.Create.NoCreateHome
+	}
+	if noCreate {
+		return ""
+	}
+	if home == "" {
+		home = "/home/" + u.Name
+	}
+	return path.Clean(home)
+}
+
+// partitionDevices returns the device paths under which the partition p of
+// the disk dev can be referred to.
+func partitionDevices(dev string, p types.Partition) []string {
+	var devs []string
+	if p.Label != nil && *p.Label != "" {
+		devs = append(devs, "/dev/disk/by-partlabel/"+*p.Label)
+	}
+	if p.GUID != "" {
+		devs = append(devs, "/dev/disk/by-partuuid/"+strings.ToLower(p.GUID))
+	}
+	if p.Number > 0 && dev != "" {
+		n := strconv.Itoa(p.Number)
+		if last := dev[len(dev)-1]; last >= '0' && last <= '9' {
+			devs = append(devs, dev+"p"+n)
+		} else {
+			devs = append(devs, dev+n)
+		}
+	}
+	return devs
+}
+
+// DOT renders g in the DOT language of Graphviz, with the operations
+// grouped by stage.
+func (g Graph) DOT() string {
+	var b bytes.Buffer
+	b.WriteString("digraph ignition {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, stage := range []string{"disks", "files"} {
+		fmt.Fprintf(&b, "\tsubgraph %s {\n\t\tlabel=%s;\n", dotQuote("cluster_"+stage), dotQuote(stage+" stage"))
+		for _, n := range g.Nodes {
+			if n.Stage == stage {
+				fmt.Fprintf(&b, "\t\t%s [label=%s];\n", dotQuote(n.ID), dotQuote(n.Label))
+			}
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Reason))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
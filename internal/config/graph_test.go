@@ -0,0 +1,151 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	type in struct {
+		config types.Config
+	}
+	type out struct {
+		edges []GraphEdge
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{config: types.Config{}},
+			out: out{edges: []GraphEdge{}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Disks: []types.Disk{
+						{Device: "/dev/sda", Partitions: []types.Partition{{Label: strToPtr("DATA"), Number: 1}, {Number: 2}}},
+						{Device: "/dev/nvme0n1", Partitions: []types.Partition{{Number: 1}}},
+					},
+					Raid: []types.Raid{
+						{Name: "md0", Level: "raid1", Devices: []types.Device{"/dev/sda2", "/dev/nvme0n1p1"}},
+					},
+					Filesystems: []types.Filesystem{
+						{Name: "data", Mount: &types.Mount{Device: "/dev/disk/by-partlabel/DATA", Format: "ext4"}},
+						{Name: "array", Mount: &types.Mount{Device: "/dev/md/md0", Format: "xfs"}},
+					},
+				},
+			}},
+			out: out{edges: []GraphEdge{
+				{From: "disk:/dev/sda", To: "partition:/dev/sda:DATA", Reason: "partition of the disk"},
+				{From: "disk:/dev/sda", To: "partition:/dev/sda:2", Reason: "partition of the disk"},
+				{From: "disk:/dev/nvme0n1", To: "partition:/dev/nvme0n1:1", Reason: "partition of the disk"},
+				{From: "partition:/dev/sda:2", To: "raid:md0", Reason: "member device"},
+				{From: "partition:/dev/nvme0n1:1", To: "raid:md0", Reason: "member device"},
+				{From: "partition:/dev/sda:DATA", To: "filesystem:data", Reason: "on the device"},
+				{From: "raid:md0", To: "filesystem:array", Reason: "on the device"},
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Passwd: types.Passwd{
+					Groups: []types.PasswdGroup{{Name: "app"}},
+					Users:  []types.PasswdUser{{Name: "core", Groups: []types.Group{"app", "wheel"}}},
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Filesystem: "root", Path: "/opt/bin/tool", Group: &types.NodeGroup{Name: "app"}}},
+						{Node: types.Node{Filesystem: "root", Path: "/home/core/.profile", User: &types.NodeUser{Name: "core"}}},
+					},
+					Directories: []types.Directory{
+						{Node: types.Node{Filesystem: "root", Path: "/opt/bin"}},
+					},
+					Links: []types.Link{
+						{Node: types.Node{Filesystem: "root", Path: "/opt"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/var/opt"}},
+						{Node: types.Node{Filesystem: "root", Path: "/usr/local/bin/tool"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/opt/bin/tool", Hard: true}},
+					},
+				},
+			}},
+			out: out{edges: []GraphEdge{
+				{From: "group:app", To: "user:core", Reason: "supplementary group"},
+				{From: "filesystem:root", To: "directory:root:/opt/bin", Reason: "on the filesystem"},
+				{From: "link:root:/opt", To: "directory:root:/opt/bin", Reason: "below the link"},
+				{From: "filesystem:root", To: "file:root:/opt/bin/tool", Reason: "on the filesystem"},
+				{From: "directory:root:/opt/bin", To: "file:root:/opt/bin/tool", Reason: "below the directory"},
+				{From: "link:root:/opt", To: "file:root:/opt/bin/tool", Reason: "below the link"},
+				{From: "group:app", To: "file:root:/opt/bin/tool", Reason: "owned by the group"},
+				{From: "filesystem:root", To: "file:root:/home/core/.profile", Reason: "on the filesystem"},
+				{From: "user:core", To: "file:root:/home/core/.profile", Reason: "in the user's home directory"},
+				{From: "user:core", To: "file:root:/home/core/.profile", Reason: "owned by the user"},
+				{From: "filesystem:root", To: "link:root:/opt", Reason: "on the filesystem"},
+				{From: "filesystem:root", To: "link:root:/usr/local/bin/tool", Reason: "on the filesystem"},
+				{From: "file:root:/opt/bin/tool", To: "link:root:/usr/local/bin/tool", Reason: "hard link target"},
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		g := DependencyGraph(test.in.config)
+		if !reflect.DeepEqual(test.out.edges, g.Edges) {
+			t.Errorf("#%d: bad edges: want %v, got %v", i, test.out.edges, g.Edges)
+		}
+		ids := map[string]bool{}
+		for _, n := range g.Nodes {
+			ids[n.ID] = true
+		}
+		for _, e := range g.Edges {
+			if !ids[e.From] || !ids[e.To] {
+				t.Errorf("#%d: edge %v refers to a missing node", i, e)
+			}
+		}
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	g := Graph{
+		Nodes: []GraphNode{
+			{ID: "disk:/dev/sda", Stage: "disks", Label: "partition disk /dev/sda"},
+			{ID: `file:root:/etc/"quoted"`, Stage: "files", Label: `write file /etc/"quoted" on root`},
+		},
+		Edges: []GraphEdge{
+			{From: "disk:/dev/sda", To: `file:root:/etc/"quoted"`, Reason: "example"},
+		},
+	}
+	want := strings.Join([]string{
+		"digraph ignition {",
+		"\trankdir=LR;",
+		"\tnode [shape=box];",
+		"\tsubgraph \"cluster_disks\" {",
+		"\t\tlabel=\"disks stage\";",
+		"\t\t\"disk:/dev/sda\" [label=\"partition disk /dev/sda\"];",
+		"\t}",
+		"\tsubgraph \"cluster_files\" {",
+		"\t\tlabel=\"files stage\";",
+		"\t\t\"file:root:/etc/\\\"quoted\\\"\" [label=\"write file /etc/\\\"quoted\\\" on root\"];",
+		"\t}",
+		"\t\"disk:/dev/sda\" -> \"file:root:/etc/\\\"quoted\\\"\" [label=\"example\"];",
+		"}",
+		"",
+	}, "\n")
+	if got := g.DOT(); got != want {
+		t.Errorf("bad DOT output: want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// Lint severities. Rules reported as errors fail the validation of the
+// config, those turned off aren't checked.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+	LintInfo    = "info"
+	LintOff     = "off"
+)
+
+// maxInlineFileSize is the decoded size of a data url above which the
+// large-inline-file rule suggests serving the contents remotely.
+const maxInlineFileSize = 256 << 10
+
+// LintRules are the rules of Lint, with their default severities.
+var LintRules = map[string]string{
+	// files and directories anyone can write to
+	"world-writable": LintWarning,
+	// password hashes using DES, MD5 or NT, which are cracked easily
+	"weak-password-hash": LintError,
+	// enabled units whose contents have no [Install] section to enable
+	"enabled-without-install": LintWarning,
+	// files inlined as data urls which bloat the config
+	"large-inline-file": LintInfo,
+}
+
+// strongCryptIDs are the ids of the crypt(3) hash methods considered
+// strong: sha256crypt, sha512crypt, yescrypt, gost-yescrypt, scrypt and
+// bcrypt.
+var strongCryptIDs = map[string]bool{
+	"5": true, "6": true, "y": true, "gy": true, "7": true, "2a": true, "2b": true, "2y": true,
+}
+
+// Lint reports the parts of cfg which are valid but likely to be mistakes
+// or bad practice. severities overrides the default severities of
+// LintRules by rule name. Findings suppressed by cfg.Ignition.Lint, for a
+// rule as a whole or for a target, i.e. a path, unit or user name, aren't
+// reported.
+func Lint(cfg types.Config, severities map[string]string) report.Report {
+	r := report.Report{}
+
+	suppressed := map[string]bool{}
+	for _, s := range cfg.Ignition.Lint.Suppress {
+		if _, ok := LintRules[s.Rule]; !ok {
+			r.Add(report.Entry{Message: fmt.Sprintf("suppression of unknown lint rule %q", s.Rule), Kind: report.EntryWarning})
+		}
+		suppressed[s.Rule+"\x00"+s.Target] = true
+	}
+
+	find := func(rule, target, format string, a ...interface{}) {
+		severity, ok := severities[rule]
+		if !ok {
+			severity = LintRules[rule]
+		}
+		if severity == LintOff || suppressed[rule+"\x00"] || suppressed[rule+"\x00"+target] {
+			return
+		}
+		e := report.Entry{Message: fmt.Sprintf(format, a...) + " (lint rule " + rule + ")"}
+		switch severity {
+		case LintError:
+			e.Kind = report.EntryError
+		case LintInfo:
+			e.Kind = report.EntryInfo
+		default:
+			e.Kind = report.EntryWarning
+		}
+		r.Add(e)
+	}
+
+	for _, f := range cfg.Storage.Files {
+		if f.Mode != nil && *f.Mode&02 != 0 {
+			find("world-writable", f.Path, "file %q is world-writable", f.Path)
+		}
+		if strings.HasPrefix(f.Contents.Source, "data:") {
+			if u, err := dataurl.DecodeString(f.Contents.Source); err == nil && len(u.Data) > maxInlineFileSize {
+				find("large-inline-file", f.Path, "file %q inlines %d bytes, consider serving it remotely with a verification hash", f.Path, len(u.Data))
+			}
+		}
+	}
+	for _, d := range cfg.Storage.Directories {
+		// sticky directories like /tmp are meant to be shared
+		if d.Mode != nil && *d.Mode&02 != 0 && *d.Mode&01000 == 0 {
+			find("world-writable", d.Path, "directory %q is world-writable without the sticky bit", d.Path)
+		}
+	}
+
+	for _, u := range cfg.Passwd.Users {
+		if u.PasswordHash == nil || *u.PasswordHash == "" {
+			continue
+		}
+		if h := *u.PasswordHash; !strings.HasPrefix(h, "!") && !strings.HasPrefix(h, "*") && !strongPasswordHash(h) {
+			find("weak-password-hash", u.Name, "password hash of user %q uses a weak algorithm, use sha512crypt or yescrypt", u.Name)
+		}
+	}
+
+	for _, u := range cfg.Systemd.Units {
+		enabled := u.Enable || (u.Enabled != nil && *u.Enabled)
+		if enabled && u.Contents != "" && !hasInstallSection(u.Contents) {
+			find("enabled-without-install", u.Name, "unit %q is enabled but has no [Install] section to enable it with", u.Name)
+		}
+	}
+	return r
+}
+
+// ParseLintSeverities parses comma separated <rule>=<severity> pairs, as
+// given to ignition-validate, into overrides of the default severities.
+func ParseLintSeverities(s string) (map[string]string, error) {
+	severities := map[string]string{}
+	if s == "" {
+		return severities, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("lint severity %q isn't of the form <rule>=<severity>", pair)
+		}
+		if _, ok := LintRules[parts[0]]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q, known rules are %s", parts[0], strings.Join(LintRuleNames(), ", "))
+		}
+		switch parts[1] {
+		case LintError, LintWarning, LintInfo, LintOff:
+		default:
+			return nil, fmt.Errorf("unknown severity %q of lint rule %q", parts[1], parts[0])
+		}
+		severities[parts[0]] = parts[1]
+	}
+	return severities, nil
+}
+
+// LintRuleNames returns the names of LintRules, sorted.
+func LintRuleNames() []string {
+	var names []string
+	for name := range LintRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// strongPasswordHash returns whether the crypt(3) hash h uses one of
+// strongCryptIDs. Hashes without an id are DES.
+func strongPasswordHash(h string) bool {
+	if !strings.HasPrefix(h, "$") {
+		return false
+	}
+	parts := strings.SplitN(h[1:], "$", 2)
+	return len(parts) == 2 && strongCryptIDs[parts[0]]
+}
+
+// hasInstallSection returns whether the unit contents have an [Install]
+// section with a setting systemctl enable acts on.
+func hasInstallSection(contents string) bool {
+	section := ""
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line
+			continue
+		}
+		if section != "[Install]" {
+			continue
+		}
+		key := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+		switch key {
+		case "WantedBy", "RequiredBy", "UpheldBy", "Alias", "Also":
+			return true
+		}
+	}
+	return false
+}
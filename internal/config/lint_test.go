@@ -0,0 +1,139 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestLint(t *testing.T) {
+	mode := func(m int) *int { return &m }
+	enabled := true
+	big := dataurl.EncodeBytes(make([]byte, maxInlineFileSize+1))
+	cfg := types.Config{
+		Passwd: types.Passwd{
+			Users: []types.PasswdUser{
+				{Name: "core", PasswordHash: strToPtr("$6$salt$hash")},
+				{Name: "locked", PasswordHash: strToPtr("!")},
+				{Name: "legacy", PasswordHash: strToPtr("$1$salt$hash")},
+				{Name: "des", PasswordHash: strToPtr("abJnggxhB/yWI")},
+			},
+		},
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/etc/shared"}, FileEmbedded1: types.FileEmbedded1{Mode: mode(0666)}},
+				{Node: types.Node{Path: "/etc/motd"}, FileEmbedded1: types.FileEmbedded1{Mode: mode(0644)}},
+				{Node: types.Node{Path: "/opt/blob"}, FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: big}}},
+			},
+			Directories: []types.Directory{
+				{Node: types.Node{Path: "/var/tmp/drop"}, DirectoryEmbedded1: types.DirectoryEmbedded1{Mode: mode(0777)}},
+				{Node: types.Node{Path: "/var/tmp/shared"}, DirectoryEmbedded1: types.DirectoryEmbedded1{Mode: mode(01777)}},
+			},
+		},
+		Systemd: types.Systemd{
+			Units: []types.Unit{
+				{Name: "a.service", Enable: true, Contents: "[Service]\nExecStart=/bin/true\n"},
+				{Name: "b.service", Enabled: &enabled, Contents: "[Service]\nExecStart=/bin/true\n[Install]\nWantedBy=multi-user.target\n"},
+				{Name: "c.service", Enabled: &enabled},
+				{Name: "d.service", Contents: "[Service]\nExecStart=/bin/true\n"},
+			},
+		},
+	}
+
+	type in struct {
+		severities map[string]string
+		suppress   []types.LintSuppression
+	}
+	type out struct {
+		entries []string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{},
+			out: out{entries: []string{
+				`warning: file "/etc/shared" is world-writable (lint rule world-writable)`,
+				`info: file "/opt/blob" inlines 262145 bytes, consider serving it remotely with a verification hash (lint rule large-inline-file)`,
+				`warning: directory "/var/tmp/drop" is world-writable without the sticky bit (lint rule world-writable)`,
+				`error: password hash of user "legacy" uses a weak algorithm, use sha512crypt or yescrypt (lint rule weak-password-hash)`,
+				`error: password hash of user "des" uses a weak algorithm, use sha512crypt or yescrypt (lint rule weak-password-hash)`,
+				`warning: unit "a.service" is enabled but has no [Install] section to enable it with (lint rule enabled-without-install)`,
+			}},
+		},
+		{
+			in: in{
+				severities: map[string]string{"world-writable": LintError, "large-inline-file": LintOff, "weak-password-hash": LintWarning},
+				suppress: []types.LintSuppression{
+					{Rule: "world-writable", Target: "/var/tmp/drop"},
+					{Rule: "enabled-without-install"},
+					{Rule: "no-such-rule"},
+				},
+			},
+			out: out{entries: []string{
+				`warning: suppression of unknown lint rule "no-such-rule"`,
+				`error: file "/etc/shared" is world-writable (lint rule world-writable)`,
+				`warning: password hash of user "legacy" uses a weak algorithm, use sha512crypt or yescrypt (lint rule weak-password-hash)`,
+				`warning: password hash of user "des" uses a weak algorithm, use sha512crypt or yescrypt (lint rule weak-password-hash)`,
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		cfg.Ignition.Lint.Suppress = test.in.suppress
+		r := Lint(cfg, test.in.severities)
+		var entries []string
+		for _, e := range r.Entries {
+			entries = append(entries, e.String())
+		}
+		if !reflect.DeepEqual(test.out.entries, entries) {
+			t.Errorf("#%d: bad entries: want\n%s\ngot\n%s", i, strings.Join(test.out.entries, "\n"), strings.Join(entries, "\n"))
+		}
+	}
+}
+
+func TestParseLintSeverities(t *testing.T) {
+	tests := []struct {
+		in  string
+		out map[string]string
+		ok  bool
+	}{
+		{in: "", out: map[string]string{}, ok: true},
+		{in: "world-writable=error", out: map[string]string{"world-writable": "error"}, ok: true},
+		{in: "world-writable=off,large-inline-file=warning", out: map[string]string{"world-writable": "off", "large-inline-file": "warning"}, ok: true},
+		{in: "world-writable"},
+		{in: "world-writable=fatal"},
+		{in: "no-such-rule=error"},
+	}
+
+	for i, test := range tests {
+		out, err := ParseLintSeverities(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		if test.ok && !reflect.DeepEqual(test.out, out) {
+			t.Errorf("#%d: bad severities: want %v, got %v", i, test.out, out)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// FormatMetadataAttributes renders the attributes of the instance and the
+// platform as the sorted IGNITION_<NAME>="<value>" lines of the metadata
+// attributes file.
+func FormatMetadataAttributes(platform string, attrs map[string]string) string {
+	lines := []string{"IGNITION_PLATFORM=" + quoteEnvValue(platform)}
+	for name, value := range attrs {
+		lines = append(lines, fmt.Sprintf("IGNITION_%s=%s", name, quoteEnvValue(value)))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ReadMetadataAttributes reads the metadata attributes file at path, as
+// FormatMetadataAttributes renders it, into its variables, e.g.
+// IGNITION_PLATFORM, and their unquoted values.
+func ReadMetadataAttributes(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetadataAttributes(string(contents))
+}
+
+// envValueEscaper escapes the characters which are special in the double
+// quoted values of environment files, for systemd's EnvironmentFile= as for
+// shells sourcing the file.
+var envValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+
+// quoteEnvValue double quotes value for an environment file, so that values
+// with spaces, quotes or newlines are read back as they are.
+func quoteEnvValue(value string) string {
+	return `"` + envValueEscaper.Replace(value) + `"`
+}
+
+// parseMetadataAttributes parses the NAME="<value>" lines of the metadata
+// attributes file. Quoted values may span lines, and the backslashes
+// quoteEnvValue adds are removed; other backslashes are kept, like shells do.
+// Unquoted values, as written by hand, are taken up to the end of the line.
+// Comment lines are skipped, and names with whitespace are rejected.
+func parseMetadataAttributes(contents string) (map[string]string, error) {
+	attrs := map[string]string{}
+	for contents != "" {
+		var line string
+		if i := strings.IndexByte(contents, '\n'); i >= 0 {
+			line, contents = contents[:i], contents[i+1:]
+		} else {
+			line, contents = contents, ""
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(parts[0]), parts[1]
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		if !strings.HasPrefix(value, `"`) {
+			attrs[name] = value
+			continue
+		}
+
+		// the quoted value may continue on the next lines
+		rest := value[1:] + "\n" + contents
+		var b strings.Builder
+		i := 0
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) && strings.IndexByte("\\\"`$", rest[i+1]) >= 0 {
+				i++
+			}
+			b.WriteByte(rest[i])
+		}
+		if i == len(rest) {
+			return nil, fmt.Errorf("value of %s isn't terminated", name)
+		}
+		attrs[name] = b.String()
+		// the remainder of the value's last line is ignored
+		contents = ""
+		if j := strings.IndexByte(rest[i:], '\n'); j >= 0 && i+j+1 < len(rest) {
+			contents = rest[i+j+1:]
+		}
+	}
+	return attrs, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFormatMetadataAttributes(t *testing.T) {
+	type in struct {
+		platform string
+		attrs    map[string]string
+	}
+
+	tests := []struct {
+		in  in
+		out string
+	}{
+		{
+			in:  in{platform: "ec2"},
+			out: "IGNITION_PLATFORM=\"ec2\"\n",
+		},
+		{
+			in: in{platform: "gce", attrs: map[string]string{
+				"REGION":      "us-east1",
+				"INSTANCE_ID": "4711",
+			}},
+			out: "IGNITION_INSTANCE_ID=\"4711\"\nIGNITION_PLATFORM=\"gce\"\nIGNITION_REGION=\"us-east1\"\n",
+		},
+		{
+			in: in{platform: "gce", attrs: map[string]string{
+				"HOSTNAME": "web 1 \"$HOME\" `id` \\n\nIGNITION_REGION=evil",
+			}},
+			out: "IGNITION_HOSTNAME=\"web 1 \\\"\\$HOME\\\" \\`id\\` \\\\n\nIGNITION_REGION=evil\"\nIGNITION_PLATFORM=\"gce\"\n",
+		},
+	}
+
+	for i, test := range tests {
+		if out := FormatMetadataAttributes(test.in.platform, test.in.attrs); out != test.out {
+			t.Errorf("#%d: bad attributes: want %q, got %q", i, test.out, out)
+		}
+	}
+}
+
+func TestMetadataAttributesRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to source the attributes with")
+	}
+	dir, err := ioutil.TempDir("", "ignition-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ignition")
+
+	hostname := "web 1 \"$HOME\" `id` \\n\nIGNITION_REGION=evil"
+	attrs := FormatMetadataAttributes("gce", map[string]string{"HOSTNAME": hostname})
+	if err := ioutil.WriteFile(path, []byte(attrs), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(sh, "-c", `. "$1" && printf '%s|%s' "$IGNITION_HOSTNAME" "$IGNITION_REGION"`, "sh", path).Output()
+	if err != nil {
+		t.Fatalf("sourcing the attributes: %v", err)
+	}
+	if want := hostname + "|"; string(out) != want {
+		t.Errorf("bad attributes read back: want %q, got %q", want, out)
+	}
+}
+
+func TestReadMetadataAttributes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ignition")
+
+	tests := []struct {
+		in  string
+		out map[string]string
+		err bool
+	}{
+		{
+			in:  FormatMetadataAttributes("gce", map[string]string{"REGION": "us-east1", "ROLE": ""}),
+			out: map[string]string{"IGNITION_PLATFORM": "gce", "IGNITION_REGION": "us-east1", "IGNITION_ROLE": ""},
+		},
+		{
+			in:  FormatMetadataAttributes("gce", map[string]string{"HOSTNAME": "web 1 \"$HOME\" `id` \\n\nIGNITION_REGION=evil"}),
+			out: map[string]string{"IGNITION_PLATFORM": "gce", "IGNITION_HOSTNAME": "web 1 \"$HOME\" `id` \\n\nIGNITION_REGION=evil"},
+		},
+		{
+			in:  "IGNITION_PLATFORM=ec2\nIGNITION_ROLE=\"a\\b\"\n# comment\n",
+			out: map[string]string{"IGNITION_PLATFORM": "ec2", "IGNITION_ROLE": "a\\b"},
+		},
+		{
+			in:  "IGNITION_PLATFORM=\"ec2",
+			err: true,
+		},
+		// a comment isn't an attribute, even if it looks like one
+		{
+			in:  "# IGNITION_REGION=us-east-1\n  #IGNITION_ROLE=web\nIGNITION_PLATFORM=ec2\n",
+			out: map[string]string{"IGNITION_PLATFORM": "ec2"},
+		},
+		{
+			in:  "export IGNITION_PLATFORM=ec2\n",
+			err: true,
+		},
+		{
+			in:  "IGNITION_PLATFORM=ec2\n=us-east-1\n",
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		if err := ioutil.WriteFile(path, []byte(test.in), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := ReadMetadataAttributes(path)
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if !reflect.DeepEqual(test.out, out) {
+			t.Errorf("#%d: bad attributes: want %q, got %q", i, test.out, out)
+		}
+	}
+}
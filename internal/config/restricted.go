@@ -0,0 +1,271 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// udevProgramRegexp matches the keys of udev rules which run programs;
+// the builtins of udev are allowed.
+var udevProgramRegexp = regexp.MustCompile(`(^|[\s,])(RUN(\{program\})?|PROGRAM|IMPORT\{program\})\s*[!+:-]?=`)
+
+// unitSuffixes are the suffixes of the names of systemd units.
+var unitSuffixes = []string{".automount", ".device", ".mount", ".path", ".scope", ".service", ".slice", ".socket", ".swap", ".target", ".timer"}
+
+// isUnitPath returns whether p looks like a unit or a dropin of one, e.g.
+// sshd.service or sshd.service.d/override.conf.
+func isUnitPath(p string) bool {
+	name := path.Base(p)
+	if path.Ext(name) == ".conf" {
+		name = strings.TrimSuffix(path.Base(path.Dir(p)), ".d")
+	}
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// InRestrictedDir returns the directory of the distribution's restricted
+// directories p is in, if any.
+func InRestrictedDir(p string) (string, bool) {
+	p = path.Clean("/" + p)
+	for _, dir := range distro.RestrictedDirs() {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// maxLinks is the most symlinks ResolveLinks follows, like the kernel's
+// limit.
+const maxLinks = 40
+
+// ResolveLinks follows the symlinks in the parents of p the way they'd be
+// followed once the system is booted. readlink returns the target of the
+// symlink at the path it's given, or false if there's none.
+func ResolveLinks(p string, readlink func(string) (string, bool, error)) (string, error) {
+	dir, base := path.Split(path.Clean("/" + p))
+	resolved := "/"
+	rest := strings.Split(dir, "/")
+	links := 0
+	for len(rest) > 0 {
+		c := rest[0]
+		rest = rest[1:]
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+		next := path.Join(resolved, c)
+		target, ok, err := readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			resolved = next
+			continue
+		}
+		if links++; links > maxLinks {
+			return "", fmt.Errorf("too many levels of symlinks in %q", p)
+		}
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return path.Join(resolved, base), nil
+}
+
+// ResolveInRoot follows the symlinks in the parents of p, a path in the
+// root, the way they'd be followed once the root is booted: absolute targets
+// are relative to the root. Unlike Util.JoinPath, links to links are
+// followed too.
+func ResolveInRoot(root, p string) (string, error) {
+	return ResolveLinks(p, func(p string) (string, bool, error) {
+		info, err := os.Lstat(filepath.Join(root, p))
+		if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		target, err := os.Readlink(filepath.Join(root, p))
+		return target, true, err
+	})
+}
+
+// ValidateRestricted reports the parts of cfg which would let it run
+// programs of its choosing: hooks, system extensions, grub configs of the
+// OEM partition, setuid and setgid files, files with capabilities or security
+// extended attributes, units and edits of units matching the distribution's denylist, udev rules running
+// programs, and nodes, edits and archives in directories such as the systemd
+// unit directories, or links to them, which would let it sidestep the unit
+// and udev rule checks. Only the config's own links are followed; the files
+// stage checks nodes against the symlinks on disk as it writes them.
+func ValidateRestricted(cfg types.Config) report.Report {
+	r := report.Report{}
+	deny := func(format string, a ...interface{}) {
+		r.Add(report.Entry{
+			Message: fmt.Sprintf(format, a...) + " (not allowed in restricted mode)",
+			Kind:    report.EntryError,
+		})
+	}
+
+	for _, h := range cfg.Ignition.Hooks {
+		deny("hooks of stage %q", h.Stage)
+	}
+
+	for _, f := range cfg.Storage.Files {
+		if f.Mode != nil && *f.Mode&06000 != 0 {
+			deny("file %q is setuid or setgid", f.Path)
+		}
+		// capabilities such as cap_setuid are as good as the setuid bit
+		if f.Capabilities != nil {
+			deny("file %q has capabilities", f.Path)
+		}
+		for _, x := range f.Xattrs {
+			if strings.HasPrefix(x.Name, "security.") || strings.HasPrefix(x.Name, "trusted.") {
+				deny("file %q has extended attribute %q", f.Path, x.Name)
+			}
+		}
+	}
+	// the config's symlinks lead the nodes below them elsewhere, e.g.
+	// /opt/u -> /etc/systemd/system, so nodes are checked once they're
+	// resolved through them as well
+	links := map[string]string{}
+	for _, l := range cfg.Storage.Links {
+		if !l.Hard {
+			links[path.Clean("/"+l.Path)] = l.Target
+		}
+	}
+	readlink := func(p string) (string, bool, error) {
+		target, ok := links[p]
+		return target, ok, nil
+	}
+	checkNode := func(kind string, n types.Node) {
+		if dir, ok := InRestrictedDir(n.Path); ok {
+			deny("%s %q is in %s", kind, n.Path, dir)
+			return
+		}
+		resolved, err := ResolveLinks(n.Path, readlink)
+		if err != nil {
+			deny("%s %q: %v", kind, n.Path, err)
+		} else if dir, ok := InRestrictedDir(resolved); ok {
+			deny("%s %q leads to %q in %s", kind, n.Path, resolved, dir)
+		}
+	}
+	for _, f := range cfg.Storage.Files {
+		checkNode("file", f.Node)
+	}
+	for _, d := range cfg.Storage.Directories {
+		checkNode("directory", d.Node)
+	}
+	for _, l := range cfg.Storage.Links {
+		checkNode("link", l.Node)
+		if l.Target == "" {
+			continue
+		}
+		target := l.Target
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(path.Clean("/"+l.Path)), target)
+		}
+		if dir, ok := InRestrictedDir(target); ok {
+			deny("link %q targets %q in %s", l.Path, l.Target, dir)
+		} else if resolved, err := ResolveLinks(target, readlink); err == nil {
+			if dir, ok := InRestrictedDir(resolved); ok {
+				deny("link %q targets %q in %s", l.Path, l.Target, dir)
+			}
+		}
+	}
+	// the files manifests list are checked once they're fetched, as they
+	// may be below the manifest's path only
+	for _, m := range cfg.Storage.Manifests {
+		checkNode("manifest", types.Node{Path: m.Path})
+	}
+	// likewise, the entries of archives are checked as they're extracted
+	for _, a := range cfg.Storage.Archives {
+		checkNode(a.Format+" archive", types.Node{Path: a.Path})
+	}
+	for _, e := range cfg.Storage.Edits {
+		checkNode("edit", types.Node{Path: e.Path})
+	}
+
+	denylist := regexp.MustCompile(distro.RestrictedUnitDenylist())
+	for _, u := range cfg.Systemd.Units {
+		if denylist.MatchString(u.Contents) {
+			deny("unit %q matches the denylist", u.Name)
+		}
+		for _, d := range u.Dropins {
+			if denylist.MatchString(d.Contents) {
+				deny("dropin %q of unit %q matches the denylist", d.Name, u.Name)
+			}
+		}
+	}
+	// edits of units outside of the directories above, e.g. if the
+	// distribution doesn't list them, are held to the denylist by the lines
+	// they write; what a patch writes isn't known before it's fetched
+	for _, e := range cfg.Storage.Edits {
+		if !isUnitPath(e.Path) {
+			continue
+		}
+		if e.Patch != nil {
+			deny("edit %q patches a unit", e.Path)
+		}
+		var lines []string
+		for _, l := range e.Lines {
+			lines = append(lines, l.Line)
+		}
+		for _, i := range e.Ini {
+			if i.Value != nil {
+				lines = append(lines, i.Key+"="+*i.Value)
+			}
+		}
+		if denylist.MatchString(strings.Join(lines, "\n")) {
+			deny("edit %q of a unit matches the denylist", e.Path)
+		}
+	}
+
+	// extensions overlay /usr with binaries and units of their choosing
+	for _, e := range cfg.Systemd.Extensions {
+		deny("systemd extension %q", e.Name)
+	}
+
+	// the grub.cfg fragment can pass the kernel arguments such as init= or
+	// systemd.run=
+	if cfg.Storage.OEM != nil && cfg.Storage.OEM.GrubConfig != "" {
+		deny("the grub config of the OEM partition")
+	}
+
+	for _, u := range cfg.Storage.UdevRules {
+		if udevProgramRegexp.MatchString(u.Contents) {
+			deny("udev rule %q runs programs", u.Name)
+		}
+	}
+	return r
+}
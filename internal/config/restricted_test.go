@@ -0,0 +1,314 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestValidateRestricted(t *testing.T) {
+	type in struct {
+		config types.Config
+	}
+	type out struct {
+		messages []string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{config: types.Config{}},
+			out: out{},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/usr/local/bin/tool"}, FileEmbedded1: types.FileEmbedded1{Mode: intToPtr(0755)}},
+						{Node: types.Node{Path: "/etc/systemd-like.conf"}},
+					},
+					Directories: []types.Directory{
+						{Node: types.Node{Path: "/srv/shared"}, DirectoryEmbedded1: types.DirectoryEmbedded1{Mode: intToPtr(02775)}},
+					},
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{Name: "sshd.service", Enabled: boolToPtr(true)},
+						{Name: "tmp.mount", Contents: "[Mount]\nWhat=tmpfs\nWhere=/tmp\n", Dropins: []types.SystemdDropin{{Name: "size.conf", Contents: "[Mount]\nOptions=size=1G\n"}}},
+					},
+				},
+			}},
+			out: out{},
+		},
+		{
+			in: in{config: types.Config{
+				Ignition: types.Ignition{Hooks: []types.Hook{{Stage: "disks"}}},
+			}},
+			out: out{messages: []string{`hooks of stage "disks" (not allowed in restricted mode)`}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/usr/local/bin/su"}, FileEmbedded1: types.FileEmbedded1{Mode: intToPtr(04755)}},
+						{Node: types.Node{Path: "/usr/local/bin/wall"}, FileEmbedded1: types.FileEmbedded1{Mode: intToPtr(02755)}},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`file "/usr/local/bin/su" is setuid or setgid (not allowed in restricted mode)`,
+				`file "/usr/local/bin/wall" is setuid or setgid (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/usr/local/bin/ping"}, FileEmbedded1: types.FileEmbedded1{Capabilities: &types.FileCapabilities{Permitted: []string{"cap_net_raw"}}}},
+						{Node: types.Node{Path: "/usr/local/bin/empty"}, FileEmbedded1: types.FileEmbedded1{Capabilities: &types.FileCapabilities{}}},
+						{Node: types.Node{Path: "/usr/local/bin/tool"}, FileEmbedded1: types.FileEmbedded1{Xattrs: []types.Xattr{
+							{Name: "user.origin", Value: "config"},
+							{Name: "security.capability", Value: "AQAAAgAAAAAAAAAAAAAAAAAAAAA="},
+							{Name: "trusted.overlay.redirect", Value: "/etc"},
+						}}},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`file "/usr/local/bin/ping" has capabilities (not allowed in restricted mode)`,
+				`file "/usr/local/bin/empty" has capabilities (not allowed in restricted mode)`,
+				`file "/usr/local/bin/tool" has extended attribute "security.capability" (not allowed in restricted mode)`,
+				`file "/usr/local/bin/tool" has extended attribute "trusted.overlay.redirect" (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Files:       []types.File{{Node: types.Node{Path: "/etc/systemd/system/evil.service"}}},
+					Directories: []types.Directory{{Node: types.Node{Path: "/etc/cron.d"}}},
+					Links:       []types.Link{{Node: types.Node{Path: "etc/../etc/systemd/system/evil.service"}}},
+				},
+			}},
+			out: out{messages: []string{
+				`file "/etc/systemd/system/evil.service" is in /etc/systemd (not allowed in restricted mode)`,
+				`directory "/etc/cron.d" is in /etc/cron.d (not allowed in restricted mode)`,
+				`link "etc/../etc/systemd/system/evil.service" is in /etc/systemd (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Files: []types.File{
+						{Node: types.Node{Path: "/opt/u/evil.service"}},
+						{Node: types.Node{Path: "/opt/e/systemd/system/evil.service"}},
+						{Node: types.Node{Path: "/opt/e/hostname"}},
+						{Node: types.Node{Path: "/opt/r/systemd/user/evil.service"}},
+						{Node: types.Node{Path: "/opt/loop/x"}},
+					},
+					Directories: []types.Directory{{Node: types.Node{Path: "/opt/e/modprobe.d/evil.conf"}}},
+					Links: []types.Link{
+						{Node: types.Node{Path: "/opt/u"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/etc/systemd/system"}},
+						{Node: types.Node{Path: "/opt/e"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/etc"}},
+						{Node: types.Node{Path: "/opt/r"}, LinkEmbedded1: types.LinkEmbedded1{Target: "e"}},
+						{Node: types.Node{Path: "/opt/lib"}, LinkEmbedded1: types.LinkEmbedded1{Target: "../usr/lib/udev/rules.d"}},
+						{Node: types.Node{Path: "/opt/hosts"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/etc/hosts", Hard: true}},
+						{Node: types.Node{Path: "/opt/loop"}, LinkEmbedded1: types.LinkEmbedded1{Target: "loop"}},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`file "/opt/u/evil.service" leads to "/etc/systemd/system/evil.service" in /etc/systemd (not allowed in restricted mode)`,
+				`file "/opt/e/systemd/system/evil.service" leads to "/etc/systemd/system/evil.service" in /etc/systemd (not allowed in restricted mode)`,
+				`file "/opt/r/systemd/user/evil.service" leads to "/etc/systemd/user/evil.service" in /etc/systemd (not allowed in restricted mode)`,
+				`file "/opt/loop/x": too many levels of symlinks in "/opt/loop/x" (not allowed in restricted mode)`,
+				`directory "/opt/e/modprobe.d/evil.conf" leads to "/etc/modprobe.d/evil.conf" in /etc/modprobe.d (not allowed in restricted mode)`,
+				`link "/opt/u" targets "/etc/systemd/system" in /etc/systemd (not allowed in restricted mode)`,
+				`link "/opt/lib" targets "../usr/lib/udev/rules.d" in /usr/lib/udev/rules.d (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Manifests: []types.Manifest{
+						{Path: "/opt/app", Source: "https://example.com/app.json"},
+						{Path: "/etc/systemd/system", Source: "https://example.com/units.json"},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`manifest "/etc/systemd/system" is in /etc/systemd (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Archives: []types.Archive{
+						{Format: "zip", Path: "/opt/app", Source: "https://example.com/app.zip"},
+						{Format: "zip", Path: "/etc/systemd/system", Source: "https://example.com/units.zip"},
+						{Format: "image", Path: "/usr/lib/systemd/system", Source: "oci://example.com/units:latest"},
+						{Format: "git", Path: "/etc/udev/rules.d", Source: "https://example.com/rules.git"},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`zip archive "/etc/systemd/system" is in /etc/systemd (not allowed in restricted mode)`,
+				`image archive "/usr/lib/systemd/system" is in /usr/lib/systemd (not allowed in restricted mode)`,
+				`git archive "/etc/udev/rules.d" is in /etc/udev/rules.d (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					Edits: []types.Edit{
+						{Path: "/etc/ssh/sshd_config", Lines: []types.EditLine{{Line: "ExecStart=ok, not a unit"}}},
+						{Path: "/etc/systemd/system/sshd.service", Ini: []types.EditIni{{Section: "Service", Key: "Nice", Value: strToPtr("5")}}},
+						{Path: "/opt/units/app.service", Ini: []types.EditIni{{Section: "Service", Key: "Nice", Value: strToPtr("5")}}},
+						{Path: "/opt/units/evil.service", Ini: []types.EditIni{{Section: "Service", Key: "ExecStart", Value: strToPtr("/tmp/x")}}},
+						{Path: "/opt/units/app.service.d/10-pre.conf", Lines: []types.EditLine{{Match: strToPtr("^Nice="), Line: "  ExecStartPre=/tmp/x"}}},
+						{Path: "/opt/units/app.timer", Patch: &types.EditPatch{Format: "unified", Source: "https://example.com/timer.patch"}},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`edit "/etc/systemd/system/sshd.service" is in /etc/systemd (not allowed in restricted mode)`,
+				`edit "/opt/units/evil.service" of a unit matches the denylist (not allowed in restricted mode)`,
+				`edit "/opt/units/app.service.d/10-pre.conf" of a unit matches the denylist (not allowed in restricted mode)`,
+				`edit "/opt/units/app.timer" patches a unit (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{Name: "evil.service", Contents: "[Service]\n  ExecStart=/bin/sh -c 'curl | sh'\n"},
+						{Name: "sshd.service", Dropins: []types.SystemdDropin{{Name: "pre.conf", Contents: "[Service]\nExecStartPre = /tmp/x\n"}}},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`unit "evil.service" matches the denylist (not allowed in restricted mode)`,
+				`dropin "pre.conf" of unit "sshd.service" matches the denylist (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Systemd: types.Systemd{
+					Extensions: []types.SystemdExtension{
+						{Name: "docker", Source: "https://example.com/docker.raw"},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`systemd extension "docker" (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					OEM: &types.OEM{Release: &types.OEMRelease{ID: "example"}},
+				},
+			}},
+			out: out{},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					OEM: &types.OEM{GrubConfig: "set linux_append=\"$linux_append systemd.run=/tmp/x\"\n"},
+				},
+			}},
+			out: out{messages: []string{
+				`the grub config of the OEM partition (not allowed in restricted mode)`,
+			}},
+		},
+		{
+			in: in{config: types.Config{
+				Storage: types.Storage{
+					UdevRules: []types.UdevRule{
+						{Name: "disk-owner", Contents: `KERNEL=="sdb", OWNER="core", MODE="0660"`},
+						{Name: "run", Contents: "ACTION==\"add\", RUN+=\"/tmp/x\"\n"},
+						{Name: "builtin", Contents: `SUBSYSTEM=="net",RUN{builtin}+="net_id"`},
+						{Name: "program", Contents: `KERNEL=="sd*", PROGRAM="/tmp/x", RESULT=="1"`},
+						{Name: "import", Contents: `IMPORT{program}="/tmp/x"`},
+						{Name: "import-builtin", Contents: `IMPORT{builtin}="path_id"`},
+					},
+				},
+			}},
+			out: out{messages: []string{
+				`udev rule "run" runs programs (not allowed in restricted mode)`,
+				`udev rule "program" runs programs (not allowed in restricted mode)`,
+				`udev rule "import" runs programs (not allowed in restricted mode)`,
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		r := ValidateRestricted(test.in.config)
+		var messages []string
+		for _, e := range r.Entries {
+			if e.Kind != report.EntryError {
+				t.Errorf("#%d: bad entry kind: want %v, got %v", i, report.EntryError, e.Kind)
+			}
+			messages = append(messages, e.Message)
+		}
+		if !reflect.DeepEqual(test.out.messages, messages) {
+			t.Errorf("#%d: bad messages: want %q, got %q", i, test.out.messages, messages)
+		}
+	}
+}
+
+func TestValidateRestrictedOpenRC(t *testing.T) {
+	config := types.Config{Storage: types.Storage{
+		Files: []types.File{
+			{Node: types.Node{Path: "/etc/init.d/evil"}},
+			{Node: types.Node{Path: "/etc/local.d/evil.start"}},
+			{Node: types.Node{Path: "/etc/conf.d/sshd"}},
+			{Node: types.Node{Path: "/etc/rc.conf"}},
+			{Node: types.Node{Path: "/etc/hostname"}},
+		},
+		Links: []types.Link{
+			{Node: types.Node{Path: "/etc/runlevels/default/evil"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/opt/evil"}},
+		},
+	}}
+
+	// the directories are only restricted for OpenRC targets
+	if r := ValidateRestricted(config); len(r.Entries) != 0 {
+		t.Errorf("bad report for systemd target: %v", r)
+	}
+
+	os.Setenv("IGNITION_INIT_SYSTEM", "openrc")
+	defer os.Unsetenv("IGNITION_INIT_SYSTEM")
+
+	want := []string{
+		`file "/etc/init.d/evil" is in /etc/init.d (not allowed in restricted mode)`,
+		`file "/etc/local.d/evil.start" is in /etc/local.d (not allowed in restricted mode)`,
+		`file "/etc/conf.d/sshd" is in /etc/conf.d (not allowed in restricted mode)`,
+		`file "/etc/rc.conf" is in /etc/rc.conf (not allowed in restricted mode)`,
+		`link "/etc/runlevels/default/evil" is in /etc/runlevels (not allowed in restricted mode)`,
+	}
+	var got []string
+	for _, e := range ValidateRestricted(config).Entries {
+		got = append(got, e.Message)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("bad messages: want %q, got %q", want, got)
+	}
+}
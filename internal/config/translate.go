@@ -45,6 +45,33 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateCondition := func(old *from.Condition) *types.Condition {
+		if old == nil {
+			return nil
+		}
+		return &types.Condition{
+			Metadata: old.Metadata,
+			Platform: old.Platform,
+			Variant:  old.Variant,
+		}
+	}
+	translateConfigMirrorSlice := func(old []from.ConfigMirror) []types.ConfigMirror {
+		var res []types.ConfigMirror
+		for _, x := range old {
+			res = append(res, types.ConfigMirror{
+				Source:  x.Source,
+				Timeout: x.Timeout,
+			})
+		}
+		return res
+	}
+	translateConfigFallbackSlice := func(old []from.ConfigFallback) []types.ConfigFallback {
+		var res []types.ConfigFallback
+		for _, x := range old {
+			res = append(res, types.ConfigFallback(x))
+		}
+		return res
+	}
 	translateConfigReference := func(old *from.ConfigReference) *types.ConfigReference {
 		if old == nil {
 			return nil
@@ -55,6 +82,11 @@ func Translate(old from.Config) types.Config {
 				Hash: old.Verification.Hash,
 			},
 			HTTPHeaders: translateHTTPHeaderSlice(old.HTTPHeaders),
+			Timeout:     old.Timeout,
+			Mirrors:     translateConfigMirrorSlice(old.Mirrors),
+			ETag:        old.ETag,
+			Fallback:    translateConfigFallbackSlice(old.Fallback),
+			Compression: old.Compression,
 		}
 	}
 	translateConfigReferenceSlice := func(old []from.ConfigReference) []types.ConfigReference {
@@ -64,6 +96,13 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateFragmentSlice := func(old []from.Fragment) []types.Fragment {
+		var res []types.Fragment
+		for _, x := range old {
+			res = append(res, types.Fragment(x))
+		}
+		return res
+	}
 	translateCertificateAuthoritySlice := func(old []from.CaReference) []types.CaReference {
 		var res []types.CaReference
 		for _, x := range old {
@@ -77,6 +116,37 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateSFTPHostSlice := func(old []from.SFTPHost) []types.SFTPHost {
+		var res []types.SFTPHost
+		for _, x := range old {
+			res = append(res, types.SFTPHost{
+				Host:     x.Host,
+				HostKeys: x.HostKeys,
+				PrivateKey: types.SFTPPrivateKey{
+					HTTPHeaders: translateHTTPHeaderSlice(x.PrivateKey.HTTPHeaders),
+					Source:      x.PrivateKey.Source,
+					Verification: types.Verification{
+						Hash: x.PrivateKey.Verification.Hash,
+					},
+				},
+			})
+		}
+		return res
+	}
+	translateClientCertificateSlice := func(old []from.ClientCertificate) []types.ClientCertificate {
+		var res []types.ClientCertificate
+		for _, x := range old {
+			res = append(res, types.ClientCertificate(x))
+		}
+		return res
+	}
+	translatePublicKeyPinSlice := func(old []from.PublicKeyPin) []types.PublicKeyPin {
+		var res []types.PublicKeyPin
+		for _, x := range old {
+			res = append(res, types.PublicKeyPin(x))
+		}
+		return res
+	}
 	translateNoProxySlice := func(old []from.NoProxyItem) []types.NoProxyItem {
 		var res []types.NoProxyItem
 		for _, x := range old {
@@ -84,6 +154,34 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateHookProgramSlice := func(old []from.HookProgram) []types.HookProgram {
+		var res []types.HookProgram
+		for _, x := range old {
+			res = append(res, types.HookProgram(x))
+		}
+		return res
+	}
+	translateHookSlice := func(old []from.Hook) []types.Hook {
+		var res []types.Hook
+		for _, x := range old {
+			res = append(res, types.Hook{
+				After:  translateHookProgramSlice(x.After),
+				Before: translateHookProgramSlice(x.Before),
+				Stage:  x.Stage,
+			})
+		}
+		return res
+	}
+	translateLintSuppressionSlice := func(old []from.LintSuppression) []types.LintSuppression {
+		var res []types.LintSuppression
+		for _, x := range old {
+			res = append(res, types.LintSuppression{
+				Rule:   x.Rule,
+				Target: x.Target,
+			})
+		}
+		return res
+	}
 	translateNetworkdDropinSlice := func(old []from.NetworkdDropin) []types.NetworkdDropin {
 		var res []types.NetworkdDropin
 		for _, x := range old {
@@ -94,13 +192,106 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateNetworkdBondSlice := func(old []from.NetworkdBond) []types.NetworkdBond {
+		var res []types.NetworkdBond
+		for _, b := range old {
+			res = append(res, types.NetworkdBond{
+				If:         translateCondition(b.If),
+				Interfaces: b.Interfaces,
+				MIIMonitor: b.MIIMonitor,
+				Mode:       b.Mode,
+				Name:       b.Name,
+			})
+		}
+		return res
+	}
+	translateNetworkdBridgeSlice := func(old []from.NetworkdBridge) []types.NetworkdBridge {
+		var res []types.NetworkdBridge
+		for _, b := range old {
+			res = append(res, types.NetworkdBridge{
+				If:         translateCondition(b.If),
+				Interfaces: b.Interfaces,
+				Name:       b.Name,
+				STP:        b.STP,
+			})
+		}
+		return res
+	}
+	translateNetworkdLinkSlice := func(old []from.NetworkdLink) []types.NetworkdLink {
+		var res []types.NetworkdLink
+		for _, l := range old {
+			res = append(res, types.NetworkdLink{
+				AlternativeNames: l.AlternativeNames,
+				If:               translateCondition(l.If),
+				Match:            types.NetworkdLinkMatch(l.Match),
+				Name:             l.Name,
+			})
+		}
+		return res
+	}
+	translateNetworkdRouteSlice := func(old []from.NetworkdRoute) []types.NetworkdRoute {
+		var res []types.NetworkdRoute
+		for _, r := range old {
+			res = append(res, types.NetworkdRoute(r))
+		}
+		return res
+	}
+	translateNetworkdRoutingPolicyRuleSlice := func(old []from.NetworkdRoutingPolicyRule) []types.NetworkdRoutingPolicyRule {
+		var res []types.NetworkdRoutingPolicyRule
+		for _, r := range old {
+			res = append(res, types.NetworkdRoutingPolicyRule(r))
+		}
+		return res
+	}
 	translateNetworkdUnitSlice := func(old []from.Networkdunit) []types.Networkdunit {
 		var res []types.Networkdunit
 		for _, u := range old {
 			res = append(res, types.Networkdunit{
-				Contents: u.Contents,
-				Name:     u.Name,
-				Dropins:  translateNetworkdDropinSlice(u.Dropins),
+				Contents:           u.Contents,
+				Name:               u.Name,
+				Dropins:            translateNetworkdDropinSlice(u.Dropins),
+				If:                 translateCondition(u.If),
+				Routes:             translateNetworkdRouteSlice(u.Routes),
+				RoutingPolicyRules: translateNetworkdRoutingPolicyRuleSlice(u.RoutingPolicyRules),
+			})
+		}
+		return res
+	}
+	translateNetworkdVLANSlice := func(old []from.NetworkdVLAN) []types.NetworkdVLAN {
+		var res []types.NetworkdVLAN
+		for _, v := range old {
+			res = append(res, types.NetworkdVLAN{
+				ID:        v.ID,
+				If:        translateCondition(v.If),
+				Interface: v.Interface,
+				Name:      v.Name,
+			})
+		}
+		return res
+	}
+	translateNetworkdWireGuardPeerSlice := func(old []from.NetworkdWireGuardPeer) []types.NetworkdWireGuardPeer {
+		var res []types.NetworkdWireGuardPeer
+		for _, p := range old {
+			res = append(res, types.NetworkdWireGuardPeer(p))
+		}
+		return res
+	}
+	translateNetworkdWireGuardSlice := func(old []from.NetworkdWireGuard) []types.NetworkdWireGuard {
+		var res []types.NetworkdWireGuard
+		for _, w := range old {
+			res = append(res, types.NetworkdWireGuard{
+				Addresses:  w.Addresses,
+				If:         translateCondition(w.If),
+				ListenPort: w.ListenPort,
+				Name:       w.Name,
+				Peers:      translateNetworkdWireGuardPeerSlice(w.Peers),
+				PrivateKey: types.NetworkdWireGuardKey{
+					HTTPHeaders: translateHTTPHeaderSlice(w.PrivateKey.HTTPHeaders),
+					Source:      w.PrivateKey.Source,
+					Verification: types.Verification{
+						Hash: w.PrivateKey.Verification.Hash,
+					},
+				},
 			})
 		}
 		return res
@@ -177,6 +368,117 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateSSHHostKeySlice := func(old []from.SSHHostKey) []types.SSHHostKey {
+		var res []types.SSHHostKey
+		for _, x := range old {
+			res = append(res, types.SSHHostKey{
+				HTTPHeaders: translateHTTPHeaderSlice(x.HTTPHeaders),
+				PublicKey:   x.PublicKey,
+				Source:      x.Source,
+				Type:        x.Type,
+				Verification: types.Verification{
+					Hash: x.Verification.Hash,
+				},
+			})
+		}
+		return res
+	}
+	translateSSHPhoneHome := func(old *from.SSHPhoneHome) *types.SSHPhoneHome {
+		if old == nil {
+			return nil
+		}
+		return &types.SSHPhoneHome{
+			HTTPHeaders: translateHTTPHeaderSlice(old.HTTPHeaders),
+			URL:         old.URL,
+		}
+	}
+	translateOEM := func(old *from.OEM) *types.OEM {
+		if old == nil {
+			return nil
+		}
+		res := types.OEM{
+			GrubConfig: old.GrubConfig,
+		}
+		if old.Release != nil {
+			res.Release = &types.OEMRelease{
+				BugReportURL: old.Release.BugReportURL,
+				HomeURL:      old.Release.HomeURL,
+				ID:           old.Release.ID,
+				Name:         old.Release.Name,
+				VersionID:    old.Release.VersionID,
+			}
+		}
+		return &res
+	}
+	translateUpdateRebootWindow := func(old *from.UpdateRebootWindow) *types.UpdateRebootWindow {
+		if old == nil {
+			return nil
+		}
+		return &types.UpdateRebootWindow{
+			Length: old.Length,
+			Start:  old.Start,
+		}
+	}
+	translateTimeMakeStep := func(old *from.TimeMakeStep) *types.TimeMakeStep {
+		if old == nil {
+			return nil
+		}
+		res := types.TimeMakeStep(*old)
+		return &res
+	}
+	translateTimeServerSlice := func(old []from.TimeServer) []types.TimeServer {
+		var res []types.TimeServer
+		for _, x := range old {
+			res = append(res, types.TimeServer(x))
+		}
+		return res
+	}
+	translateKernelDeviceSlice := func(old []from.KernelDevice) []types.KernelDevice {
+		var res []types.KernelDevice
+		for _, x := range old {
+			res = append(res, types.KernelDevice(x))
+		}
+		return res
+	}
+	translateKernelHugepages := func(old *from.KernelHugepages) *types.KernelHugepages {
+		if old == nil {
+			return nil
+		}
+		res := types.KernelHugepages{
+			DefaultSize:   old.DefaultSize,
+			NumaBalancing: old.NumaBalancing,
+			Overcommit:    old.Overcommit,
+			ShmGroup:      old.ShmGroup,
+		}
+		for _, x := range old.Pages {
+			res.Pages = append(res.Pages, types.KernelHugepage(x))
+		}
+		return &res
+	}
+	translateKernelModuleSlice := func(old []from.KernelModule) []types.KernelModule {
+		var res []types.KernelModule
+		for _, x := range old {
+			res = append(res, types.KernelModule(x))
+		}
+		return res
+	}
+	translateAuditRuleSlice := func(old []from.AuditRule) []types.AuditRule {
+		var res []types.AuditRule
+		for _, x := range old {
+			res = append(res, types.AuditRule(x))
+		}
+		return res
+	}
+	translateSSHConfigSnippetSlice := func(old []from.SSHConfigSnippet) []types.SSHConfigSnippet {
+		var res []types.SSHConfigSnippet
+		for _, x := range old {
+			res = append(res, types.SSHConfigSnippet{
+				Contents: x.Contents,
+				Name:     x.Name,
+			})
+		}
+		return res
+	}
 	translateNodeGroup := func(old *from.NodeGroup) *types.NodeGroup {
 		if old == nil {
 			return nil
@@ -202,6 +504,7 @@ func Translate(old from.Config) types.Config {
 			Path:       old.Path,
 			User:       translateNodeUser(old.User),
 			Overwrite:  old.Overwrite,
+			If:         translateCondition(old.If),
 		}
 	}
 	translateDirectorySlice := func(old []from.Directory) []types.Directory {
@@ -241,6 +544,36 @@ func Translate(old from.Config) types.Config {
 				Device:     x.Device,
 				Partitions: translatePartitionSlice(x.Partitions),
 				WipeTable:  x.WipeTable,
+				If:         translateCondition(x.If),
+			})
+		}
+		return res
+	}
+	translateAppendMarkers := func(old *from.AppendMarkers) *types.AppendMarkers {
+		if old == nil {
+			return nil
+		}
+		return &types.AppendMarkers{
+			Begin: old.Begin,
+			End:   old.End,
+		}
+	}
+	translateFileCapabilities := func(old *from.FileCapabilities) *types.FileCapabilities {
+		if old == nil {
+			return nil
+		}
+		return &types.FileCapabilities{
+			Effective:   old.Effective,
+			Inheritable: old.Inheritable,
+			Permitted:   old.Permitted,
+		}
+	}
+	translateXattrSlice := func(old []from.Xattr) []types.Xattr {
+		var res []types.Xattr
+		for _, x := range old {
+			res = append(res, types.Xattr{
+				Name:  x.Name,
+				Value: x.Value,
 			})
 		}
 		return res
@@ -258,9 +591,18 @@ func Translate(old from.Config) types.Config {
 							Hash: x.Contents.Verification.Hash,
 						},
 						HTTPHeaders: translateHTTPHeaderSlice(x.Contents.HTTPHeaders),
+						Encoding:    x.Contents.Encoding,
+						LineEndings: x.Contents.LineEndings,
+						Merge:       x.Contents.Merge,
+						Sparse:      x.Contents.Sparse,
 					},
-					Mode:   x.Mode,
-					Append: x.Append,
+					Mode:          x.Mode,
+					Append:        x.Append,
+					AppendMarkers: translateAppendMarkers(x.AppendMarkers),
+					Capabilities:  translateFileCapabilities(x.Capabilities),
+					Check:         x.Check,
+					Priority:      x.Priority,
+					Xattrs:        translateXattrSlice(x.Xattrs),
 				},
 			})
 		}
@@ -297,6 +639,7 @@ func Translate(old from.Config) types.Config {
 			Create:         translateMountCreate(old.Create),
 			Device:         old.Device,
 			Format:         old.Format,
+			HandOver:       old.HandOver,
 			Label:          old.Label,
 			Options:        translateMountOptionSlice(old.Options),
 			UUID:           old.UUID,
@@ -364,6 +707,22 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateSystemdExtensionSlice := func(old []from.SystemdExtension) []types.SystemdExtension {
+		var res []types.SystemdExtension
+		for _, x := range old {
+			res = append(res, types.SystemdExtension{
+				Compression: x.Compression,
+				HTTPHeaders: translateHTTPHeaderSlice(x.HTTPHeaders),
+				Name:        x.Name,
+				Source:      x.Source,
+				Type:        x.Type,
+				Verification: types.Verification{
+					Hash: x.Verification.Hash,
+				},
+			})
+		}
+		return res
+	}
 	translateSystemdUnitSlice := func(old []from.Unit) []types.Unit {
 		var res []types.Unit
 		for _, x := range old {
@@ -374,24 +733,216 @@ func Translate(old from.Config) types.Config {
 				Enabled:  x.Enabled,
 				Mask:     x.Mask,
 				Name:     x.Name,
+				If:       translateCondition(x.If),
 			})
 		}
 		return res
 	}
+	translateCertificateSlice := func(old []from.Certificate) []types.Certificate {
+		var res []types.Certificate
+		for _, x := range old {
+			res = append(res, types.Certificate{
+				CertificatePath: x.CertificatePath,
+				CommonName:      x.CommonName,
+				DNSNames:        x.DNSNames,
+				KeyPath:         x.KeyPath,
+				Protocol:        x.Protocol,
+				Server:          x.Server,
+				Token:           x.Token,
+			})
+		}
+		return res
+	}
+	translateBootstrapKubeconfig := func(old *from.BootstrapKubeconfig) *types.BootstrapKubeconfig {
+		if old == nil {
+			return nil
+		}
+		return &types.BootstrapKubeconfig{
+			CertificateAuthority: types.CaReference{
+				HTTPHeaders: translateHTTPHeaderSlice(old.CertificateAuthority.HTTPHeaders),
+				Source:      old.CertificateAuthority.Source,
+				Verification: types.Verification{
+					Hash: old.CertificateAuthority.Verification.Hash,
+				},
+			},
+			Path:   old.Path,
+			Server: old.Server,
+			Token: types.TokenReference{
+				HTTPHeaders: translateHTTPHeaderSlice(old.Token.HTTPHeaders),
+				Source:      old.Token.Source,
+				Verification: types.Verification{
+					Hash: old.Token.Verification.Hash,
+				},
+			},
+		}
+	}
+	translateImageSlice := func(old []from.Image) []types.Image {
+		var res []types.Image
+		for _, x := range old {
+			res = append(res, types.Image{
+				Compression: x.Compression,
+				Device:      x.Device,
+				HTTPHeaders: translateHTTPHeaderSlice(x.HTTPHeaders),
+				Source:      x.Source,
+				Verification: types.Verification{
+					Hash: x.Verification.Hash,
+				},
+			})
+		}
+		return res
+	}
+	translateArchiveSlice := func(old []from.Archive) []types.Archive {
+		var res []types.Archive
+		for _, x := range old {
+			res = append(res, types.Archive{
+				Filesystem:  x.Filesystem,
+				Format:      x.Format,
+				Group:       translateNodeGroup(x.Group),
+				HTTPHeaders: translateHTTPHeaderSlice(x.HTTPHeaders),
+				Path:        x.Path,
+				Source:      x.Source,
+				User:        translateNodeUser(x.User),
+				Verification: types.Verification{
+					Hash: x.Verification.Hash,
+				},
+			})
+		}
+		return res
+	}
+	translateUdevRuleSlice := func(old []from.UdevRule) []types.UdevRule {
+		var res []types.UdevRule
+		for _, x := range old {
+			res = append(res, types.UdevRule(x))
+		}
+		return res
+	}
+	translateEditSlice := func(old []from.Edit) []types.Edit {
+		var res []types.Edit
+		for _, x := range old {
+			e := types.Edit{
+				Filesystem: x.Filesystem,
+				Path:       x.Path,
+			}
+			for _, i := range x.Ini {
+				e.Ini = append(e.Ini, types.EditIni{
+					Key:     i.Key,
+					Section: i.Section,
+					Value:   i.Value,
+				})
+			}
+			for _, l := range x.Lines {
+				e.Lines = append(e.Lines, types.EditLine{
+					Absent: l.Absent,
+					Line:   l.Line,
+					Match:  l.Match,
+				})
+			}
+			if x.Patch != nil {
+				e.Patch = &types.EditPatch{
+					Format:      x.Patch.Format,
+					HTTPHeaders: translateHTTPHeaderSlice(x.Patch.HTTPHeaders),
+					Original: types.Verification{
+						Hash: x.Patch.Original.Hash,
+					},
+					Result: types.Verification{
+						Hash: x.Patch.Result.Hash,
+					},
+					Source: x.Patch.Source,
+					Verification: types.Verification{
+						Hash: x.Patch.Verification.Hash,
+					},
+				}
+			}
+			res = append(res, e)
+		}
+		return res
+	}
+	translateManifestSlice := func(old []from.Manifest) []types.Manifest {
+		var res []types.Manifest
+		for _, x := range old {
+			res = append(res, types.Manifest{
+				Filesystem:  x.Filesystem,
+				HTTPHeaders: translateHTTPHeaderSlice(x.HTTPHeaders),
+				Path:        x.Path,
+				Source:      x.Source,
+				Verification: types.Verification{
+					Hash: x.Verification.Hash,
+				},
+			})
+		}
+		return res
+	}
+	translateCloneSlice := func(old []from.Clone) []types.Clone {
+		var res []types.Clone
+		for _, x := range old {
+			res = append(res, types.Clone{
+				Source: x.Source,
+				Target: x.Target,
+			})
+		}
+		return res
+	}
+	translatePasswdSudoerSlice := func(old []from.PasswdSudoer) []types.PasswdSudoer {
+		var res []types.PasswdSudoer
+		for _, x := range old {
+			res = append(res, types.PasswdSudoer(x))
+		}
+		return res
+	}
+	translatePasswdPolicy := func(old *from.PasswdPolicy) *types.PasswdPolicy {
+		if old == nil {
+			return nil
+		}
+		res := &types.PasswdPolicy{}
+		if old.Faillock != nil {
+			faillock := types.PasswdFaillock(*old.Faillock)
+			res.Faillock = &faillock
+		}
+		if old.PasswordAging != nil {
+			aging := types.PasswdPasswordAging(*old.PasswordAging)
+			res.PasswordAging = &aging
+		}
+		return res
+	}
+	translateRebootPathSlice := func(old []from.RebootPath) []types.RebootPath {
+		var res []types.RebootPath
+		for _, x := range old {
+			res = append(res, types.RebootPath(x))
+		}
+		return res
+	}
 	config := types.Config{
+		Audit: types.Audit{
+			Rules: translateAuditRuleSlice(old.Audit.Rules),
+		},
+		Certificates: translateCertificateSlice(old.Certificates),
 		Ignition: types.Ignition{
 			Version: from.MaxVersion.String(),
 			Timeouts: types.Timeouts{
 				HTTPResponseHeaders: old.Ignition.Timeouts.HTTPResponseHeaders,
 				HTTPTotal:           old.Ignition.Timeouts.HTTPTotal,
+				HTTPRetries:         old.Ignition.Timeouts.HTTPRetries,
+				HTTPBackoffBaseMs:   old.Ignition.Timeouts.HTTPBackoffBaseMs,
+				HTTPBackoffMaxMs:    old.Ignition.Timeouts.HTTPBackoffMaxMs,
 			},
 			Config: types.IgnitionConfig{
-				Replace: translateConfigReference(old.Ignition.Config.Replace),
-				Append:  translateConfigReferenceSlice(old.Ignition.Config.Append),
+				Replace:             translateConfigReference(old.Ignition.Config.Replace),
+				Append:              translateConfigReferenceSlice(old.Ignition.Config.Append),
+				Fragments:           translateFragmentSlice(old.Ignition.Config.Fragments),
+				RequireVerification: old.Ignition.Config.RequireVerification,
 			},
 			Security: types.Security{
 				TLS: types.TLS{
 					CertificateAuthorities: translateCertificateAuthoritySlice(old.Ignition.Security.TLS.CertificateAuthorities),
+					ClientCertificates:     translateClientCertificateSlice(old.Ignition.Security.TLS.ClientCertificates),
+					PublicKeyPins:          translatePublicKeyPinSlice(old.Ignition.Security.TLS.PublicKeyPins),
+					RequireOCSPStapling:    old.Ignition.Security.TLS.RequireOCSPStapling,
+				},
+				SFTP: translateSFTPHostSlice(old.Ignition.Security.SFTP),
+				Redirects: types.Redirects{
+					Limit:       old.Ignition.Security.Redirects.Limit,
+					NoDowngrade: old.Ignition.Security.Redirects.NoDowngrade,
+					SameHost:    old.Ignition.Security.Redirects.SameHost,
 				},
 			},
 			Proxy: types.Proxy{
@@ -399,24 +950,76 @@ func Translate(old from.Config) types.Config {
 				HTTPSProxy: old.Ignition.Proxy.HTTPSProxy,
 				NoProxy:    translateNoProxySlice(old.Ignition.Proxy.NoProxy),
 			},
+			Hooks: translateHookSlice(old.Ignition.Hooks),
+			Lint: types.Lint{
+				Suppress: translateLintSuppressionSlice(old.Ignition.Lint.Suppress),
+			},
+			RateLimit:   old.Ignition.RateLimit,
+			Annotations: old.Ignition.Annotations,
+		},
+		Kernel: types.Kernel{
+			Devices:   translateKernelDeviceSlice(old.Kernel.Devices),
+			Hugepages: translateKernelHugepages(old.Kernel.Hugepages),
+			Modules:   translateKernelModuleSlice(old.Kernel.Modules),
+		},
+		Kubernetes: types.Kubernetes{
+			BootstrapKubeconfig: translateBootstrapKubeconfig(old.Kubernetes.BootstrapKubeconfig),
 		},
 		Networkd: types.Networkd{
-			Units: translateNetworkdUnitSlice(old.Networkd.Units),
+			Bonds:     translateNetworkdBondSlice(old.Networkd.Bonds),
+			Bridges:   translateNetworkdBridgeSlice(old.Networkd.Bridges),
+			Links:     translateNetworkdLinkSlice(old.Networkd.Links),
+			Units:     translateNetworkdUnitSlice(old.Networkd.Units),
+			Vlans:     translateNetworkdVLANSlice(old.Networkd.Vlans),
+			WireGuard: translateNetworkdWireGuardSlice(old.Networkd.WireGuard),
 		},
 		Passwd: types.Passwd{
-			Groups: translatePasswdGroupSlice(old.Passwd.Groups),
-			Users:  translatePasswdUserSlice(old.Passwd.Users),
+			Groups:  translatePasswdGroupSlice(old.Passwd.Groups),
+			Policy:  translatePasswdPolicy(old.Passwd.Policy),
+			Sudoers: translatePasswdSudoerSlice(old.Passwd.Sudoers),
+			Users:   translatePasswdUserSlice(old.Passwd.Users),
+		},
+		Reboot: types.Reboot{
+			Method:   old.Reboot.Method,
+			Paths:    translateRebootPathSlice(old.Reboot.Paths),
+			Required: old.Reboot.Required,
+			Trigger:  old.Reboot.Trigger,
+		},
+		SSH: types.SSH{
+			ConfigSnippets:           translateSSHConfigSnippetSlice(old.SSH.ConfigSnippets),
+			DisableHostKeyGeneration: old.SSH.DisableHostKeyGeneration,
+			HostKeys:                 translateSSHHostKeySlice(old.SSH.HostKeys),
+			PhoneHome:                translateSSHPhoneHome(old.SSH.PhoneHome),
+			RequireConfigInclude:     old.SSH.RequireConfigInclude,
 		},
 		Storage: types.Storage{
+			Archives:    translateArchiveSlice(old.Storage.Archives),
+			Clones:      translateCloneSlice(old.Storage.Clones),
 			Directories: translateDirectorySlice(old.Storage.Directories),
 			Disks:       translateDiskSlice(old.Storage.Disks),
+			Edits:       translateEditSlice(old.Storage.Edits),
 			Files:       translateFileSlice(old.Storage.Files),
 			Filesystems: translateFilesystemSlice(old.Storage.Filesystems),
+			Images:      translateImageSlice(old.Storage.Images),
 			Links:       translateLinkSlice(old.Storage.Links),
+			Manifests:   translateManifestSlice(old.Storage.Manifests),
+			OEM:         translateOEM(old.Storage.OEM),
 			Raid:        translateRaidSlice(old.Storage.Raid),
+			UdevRules:   translateUdevRuleSlice(old.Storage.UdevRules),
 		},
 		Systemd: types.Systemd{
-			Units: translateSystemdUnitSlice(old.Systemd.Units),
+			Extensions: translateSystemdExtensionSlice(old.Systemd.Extensions),
+			Units:      translateSystemdUnitSlice(old.Systemd.Units),
+		},
+		Time: types.Time{
+			MakeStep: translateTimeMakeStep(old.Time.MakeStep),
+			Servers:  translateTimeServerSlice(old.Time.Servers),
+		},
+		Update: types.Update{
+			Group:          old.Update.Group,
+			RebootStrategy: old.Update.RebootStrategy,
+			RebootWindow:   translateUpdateRebootWindow(old.Update.RebootWindow),
+			Server:         old.Update.Server,
 		},
 	}
 	return config
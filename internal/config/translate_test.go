@@ -235,6 +235,31 @@ func TestTranslate(t *testing.T) {
 				},
 			}},
 		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{
+					Hooks: []from.Hook{
+						{
+							Stage:  "disks",
+							Before: []from.HookProgram{{Name: "load-firmware", Args: []string{"--wait"}}},
+							After:  []from.HookProgram{{Name: "settle"}},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{
+					Version: types.MaxVersion.String(),
+					Hooks: []types.Hook{
+						{
+							Stage:  "disks",
+							Before: []types.HookProgram{{Name: "load-firmware", Args: []string{"--wait"}}},
+							After:  []types.HookProgram{{Name: "settle"}},
+						},
+					},
+				},
+			}},
+		},
 		{
 			in: in{config: from.Config{
 				Ignition: from.Ignition{
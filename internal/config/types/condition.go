@@ -0,0 +1,36 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// Matches returns true if every predicate set in the condition holds for the
+// given platform, variant and metadata attributes of the instance. A nil
+// condition always matches.
+func (c *Condition) Matches(platform, variant string, metadata map[string]string) bool {
+	if c == nil {
+		return true
+	}
+	if c.Platform != "" && c.Platform != platform {
+		return false
+	}
+	if c.Variant != "" && c.Variant != variant {
+		return false
+	}
+	for key, value := range c.Metadata {
+		if v, ok := metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
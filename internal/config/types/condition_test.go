@@ -0,0 +1,87 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+)
+
+func TestConditionMatches(t *testing.T) {
+	tests := []struct {
+		in       *Condition
+		platform string
+		variant  string
+		metadata map[string]string
+		out      bool
+	}{
+		{
+			in:       nil,
+			platform: "ec2",
+			out:      true,
+		},
+		{
+			in:       &Condition{},
+			platform: "ec2",
+			out:      true,
+		},
+		{
+			in:       &Condition{Platform: "ec2"},
+			platform: "ec2",
+			out:      true,
+		},
+		{
+			in:       &Condition{Platform: "gce"},
+			platform: "ec2",
+			out:      false,
+		},
+		{
+			in:       &Condition{Platform: "ec2", Variant: "gpu"},
+			platform: "ec2",
+			variant:  "gpu",
+			out:      true,
+		},
+		{
+			in:       &Condition{Platform: "ec2", Variant: "gpu"},
+			platform: "ec2",
+			out:      false,
+		},
+		{
+			in:      &Condition{Variant: "gpu"},
+			variant: "gpu",
+			out:     true,
+		},
+		{
+			in:       &Condition{Platform: "gce", Metadata: map[string]string{"REGION": "us-east1"}},
+			platform: "gce",
+			metadata: map[string]string{"REGION": "us-east1", "INSTANCE_ID": "4711"},
+			out:      true,
+		},
+		{
+			in:       &Condition{Metadata: map[string]string{"REGION": "us-east1", "INSTANCE_ID": "4712"}},
+			metadata: map[string]string{"REGION": "us-east1", "INSTANCE_ID": "4711"},
+			out:      false,
+		},
+		{
+			in:  &Condition{Metadata: map[string]string{"REGION": ""}},
+			out: false,
+		},
+	}
+
+	for i, test := range tests {
+		if out := test.in.Matches(test.platform, test.variant, test.metadata); out != test.out {
+			t.Errorf("#%d: bad result: want %v, got %v", i, test.out, out)
+		}
+	}
+}
@@ -2,26 +2,104 @@ package types
 
 // generated by "schematyper --package=types schema/ignition.json -o internal/config/types/schema.go --root-type=Config" -- DO NOT EDIT
 
-type CaReference struct {
+type AppendMarkers struct {
+	Begin string `json:"begin"`
+	End   string `json:"end"`
+}
+
+type Archive struct {
+	Filesystem   string       `json:"filesystem"`
+	Format       string       `json:"format"`
+	Group        *NodeGroup   `json:"group,omitempty"`
 	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Path         string       `json:"path"`
 	Source       string       `json:"source"`
+	User         *NodeUser    `json:"user,omitempty"`
 	Verification Verification `json:"verification,omitempty"`
 }
 
-type Config struct {
-	Ignition Ignition `json:"ignition"`
-	Networkd Networkd `json:"networkd,omitempty"`
-	Passwd   Passwd   `json:"passwd,omitempty"`
-	Storage  Storage  `json:"storage,omitempty"`
-	Systemd  Systemd  `json:"systemd,omitempty"`
+type Audit struct {
+	Rules []AuditRule `json:"rules,omitempty"`
 }
 
-type ConfigReference struct {
+type AuditRule struct {
+	Contents string `json:"contents,omitempty"`
+	Name     string `json:"name"`
+}
+
+type BootstrapKubeconfig struct {
+	CertificateAuthority CaReference    `json:"certificateAuthority"`
+	Path                 string         `json:"path,omitempty"`
+	Server               string         `json:"server"`
+	Token                TokenReference `json:"token"`
+}
+
+type CaReference struct {
 	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
 	Source       string       `json:"source"`
 	Verification Verification `json:"verification,omitempty"`
 }
 
+type Certificate struct {
+	CertificatePath string   `json:"certificatePath"`
+	CommonName      string   `json:"commonName,omitempty"`
+	DNSNames        []string `json:"dnsNames,omitempty"`
+	KeyPath         string   `json:"keyPath"`
+	Protocol        string   `json:"protocol"`
+	Server          string   `json:"server"`
+	Token           string   `json:"token,omitempty"`
+}
+
+type ClientCertificate struct {
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"privateKey"`
+}
+
+type Clone struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type Condition struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Platform string            `json:"platform,omitempty"`
+	Variant  string            `json:"variant,omitempty"`
+}
+
+type Config struct {
+	Audit        Audit         `json:"audit,omitempty"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+	Ignition     Ignition      `json:"ignition"`
+	Kernel       Kernel        `json:"kernel,omitempty"`
+	Kubernetes   Kubernetes    `json:"kubernetes,omitempty"`
+	Networkd     Networkd      `json:"networkd,omitempty"`
+	Passwd       Passwd        `json:"passwd,omitempty"`
+	Reboot       Reboot        `json:"reboot,omitempty"`
+	SSH          SSH           `json:"ssh,omitempty"`
+	Storage      Storage       `json:"storage,omitempty"`
+	Systemd      Systemd       `json:"systemd,omitempty"`
+	Time         Time          `json:"time,omitempty"`
+	Update       Update        `json:"update,omitempty"`
+}
+
+type ConfigFallback string
+
+type ConfigMirror struct {
+	Source  string `json:"source"`
+	Timeout *int   `json:"timeout,omitempty"`
+}
+
+type ConfigReference struct {
+	Compression  string           `json:"compression,omitempty"`
+	ETag         string           `json:"etag,omitempty"`
+	Fallback     []ConfigFallback `json:"fallback,omitempty"`
+	HTTPHeaders  HTTPHeaders      `json:"httpHeaders,omitempty"`
+	Mirrors      []ConfigMirror   `json:"mirrors,omitempty"`
+	Source       string           `json:"source"`
+	Timeout      *int             `json:"timeout,omitempty"`
+	Verification Verification     `json:"verification,omitempty"`
+}
+
 type Create struct {
 	Force   bool           `json:"force,omitempty"`
 	Options []CreateOption `json:"options,omitempty"`
@@ -42,26 +120,71 @@ type DirectoryEmbedded1 struct {
 
 type Disk struct {
 	Device     string      `json:"device"`
+	If         *Condition  `json:"if,omitempty"`
 	Partitions []Partition `json:"partitions,omitempty"`
 	WipeTable  bool        `json:"wipeTable,omitempty"`
 }
 
+type Edit struct {
+	Filesystem string     `json:"filesystem"`
+	Ini        []EditIni  `json:"ini,omitempty"`
+	Lines      []EditLine `json:"lines,omitempty"`
+	Patch      *EditPatch `json:"patch,omitempty"`
+	Path       string     `json:"path"`
+}
+
+type EditIni struct {
+	Key     string  `json:"key"`
+	Section string  `json:"section,omitempty"`
+	Value   *string `json:"value,omitempty"`
+}
+
+type EditLine struct {
+	Absent bool    `json:"absent,omitempty"`
+	Line   string  `json:"line,omitempty"`
+	Match  *string `json:"match,omitempty"`
+}
+
+type EditPatch struct {
+	Format       string       `json:"format"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Original     Verification `json:"original"`
+	Result       Verification `json:"result"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
 type File struct {
 	Node
 	FileEmbedded1
 }
 
+type FileCapabilities struct {
+	Effective   bool     `json:"effective,omitempty"`
+	Inheritable []string `json:"inheritable,omitempty"`
+	Permitted   []string `json:"permitted,omitempty"`
+}
+
 type FileContents struct {
 	Compression  string       `json:"compression,omitempty"`
+	Encoding     string       `json:"encoding,omitempty"`
 	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	LineEndings  string       `json:"lineEndings,omitempty"`
+	Merge        string       `json:"merge,omitempty"`
 	Source       string       `json:"source,omitempty"`
+	Sparse       bool         `json:"sparse,omitempty"`
 	Verification Verification `json:"verification,omitempty"`
 }
 
 type FileEmbedded1 struct {
-	Append   bool         `json:"append,omitempty"`
-	Contents FileContents `json:"contents,omitempty"`
-	Mode     *int         `json:"mode,omitempty"`
+	Append        bool              `json:"append,omitempty"`
+	AppendMarkers *AppendMarkers    `json:"appendMarkers,omitempty"`
+	Capabilities  *FileCapabilities `json:"capabilities,omitempty"`
+	Check         string            `json:"check,omitempty"`
+	Contents      FileContents      `json:"contents,omitempty"`
+	Mode          *int              `json:"mode,omitempty"`
+	Priority      int               `json:"priority,omitempty"`
+	Xattrs        []Xattr           `json:"xattrs,omitempty"`
 }
 
 type Filesystem struct {
@@ -70,6 +193,8 @@ type Filesystem struct {
 	Path  *string `json:"path,omitempty"`
 }
 
+type Fragment string
+
 type Group string
 
 type HTTPHeader struct {
@@ -79,17 +204,81 @@ type HTTPHeader struct {
 
 type HTTPHeaders []HTTPHeader
 
+type Hook struct {
+	After  []HookProgram `json:"after,omitempty"`
+	Before []HookProgram `json:"before,omitempty"`
+	Stage  string        `json:"stage"`
+}
+
+type HookProgram struct {
+	Args []string `json:"args,omitempty"`
+	Name string   `json:"name"`
+}
+
 type Ignition struct {
-	Config   IgnitionConfig `json:"config,omitempty"`
-	Proxy    Proxy          `json:"proxy,omitempty"`
-	Security Security       `json:"security,omitempty"`
-	Timeouts Timeouts       `json:"timeouts,omitempty"`
-	Version  string         `json:"version,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Config      IgnitionConfig    `json:"config,omitempty"`
+	Hooks       []Hook            `json:"hooks,omitempty"`
+	Lint        Lint              `json:"lint,omitempty"`
+	Proxy       Proxy             `json:"proxy,omitempty"`
+	RateLimit   *int              `json:"rateLimit,omitempty"`
+	Security    Security          `json:"security,omitempty"`
+	Timeouts    Timeouts          `json:"timeouts,omitempty"`
+	Version     string            `json:"version,omitempty"`
 }
 
 type IgnitionConfig struct {
-	Append  []ConfigReference `json:"append,omitempty"`
-	Replace *ConfigReference  `json:"replace,omitempty"`
+	Append              []ConfigReference `json:"append,omitempty"`
+	Fragments           []Fragment        `json:"fragments,omitempty"`
+	Replace             *ConfigReference  `json:"replace,omitempty"`
+	RequireVerification bool              `json:"requireVerification,omitempty"`
+}
+
+type Kernel struct {
+	Devices   []KernelDevice   `json:"devices,omitempty"`
+	Hugepages *KernelHugepages `json:"hugepages,omitempty"`
+	Modules   []KernelModule   `json:"modules,omitempty"`
+}
+
+type KernelDevice struct {
+	Group     string `json:"group,omitempty"`
+	Kernel    string `json:"kernel,omitempty"`
+	Mode      *int   `json:"mode,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Vendor    string `json:"vendor,omitempty"`
+}
+
+type KernelHugepage struct {
+	Count int    `json:"count"`
+	Node  *int   `json:"node,omitempty"`
+	Size  string `json:"size"`
+}
+
+type KernelHugepages struct {
+	DefaultSize   string           `json:"defaultSize,omitempty"`
+	NumaBalancing *bool            `json:"numaBalancing,omitempty"`
+	Overcommit    *int             `json:"overcommit,omitempty"`
+	Pages         []KernelHugepage `json:"pages,omitempty"`
+	ShmGroup      *int             `json:"shmGroup,omitempty"`
+}
+
+type KernelModule struct {
+	Blacklist bool     `json:"blacklist,omitempty"`
+	Name      string   `json:"name"`
+	Options   []string `json:"options,omitempty"`
+}
+
+type Kubernetes struct {
+	BootstrapKubeconfig *BootstrapKubeconfig `json:"bootstrapKubeconfig,omitempty"`
+}
+
+type Image struct {
+	Compression  string       `json:"compression,omitempty"`
+	Device       string       `json:"device"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
 }
 
 type Link struct {
@@ -102,10 +291,28 @@ type LinkEmbedded1 struct {
 	Target string `json:"target"`
 }
 
+type Lint struct {
+	Suppress []LintSuppression `json:"suppress,omitempty"`
+}
+
+type LintSuppression struct {
+	Rule   string `json:"rule"`
+	Target string `json:"target,omitempty"`
+}
+
+type Manifest struct {
+	Filesystem   string       `json:"filesystem"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Path         string       `json:"path"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
 type Mount struct {
 	Create         *Create       `json:"create,omitempty"`
 	Device         string        `json:"device"`
 	Format         string        `json:"format"`
+	HandOver       *string       `json:"handOver,omitempty"`
 	Label          *string       `json:"label,omitempty"`
 	Options        []MountOption `json:"options,omitempty"`
 	UUID           *string       `json:"uuid,omitempty"`
@@ -115,7 +322,27 @@ type Mount struct {
 type MountOption string
 
 type Networkd struct {
-	Units []Networkdunit `json:"units,omitempty"`
+	Bonds     []NetworkdBond      `json:"bonds,omitempty"`
+	Bridges   []NetworkdBridge    `json:"bridges,omitempty"`
+	Links     []NetworkdLink      `json:"links,omitempty"`
+	Units     []Networkdunit      `json:"units,omitempty"`
+	Vlans     []NetworkdVLAN      `json:"vlans,omitempty"`
+	WireGuard []NetworkdWireGuard `json:"wireguard,omitempty"`
+}
+
+type NetworkdBond struct {
+	If         *Condition `json:"if,omitempty"`
+	Interfaces []string   `json:"interfaces,omitempty"`
+	MIIMonitor *int       `json:"miimon,omitempty"`
+	Mode       string     `json:"mode,omitempty"`
+	Name       string     `json:"name"`
+}
+
+type NetworkdBridge struct {
+	If         *Condition `json:"if,omitempty"`
+	Interfaces []string   `json:"interfaces,omitempty"`
+	Name       string     `json:"name"`
+	STP        *bool      `json:"stp,omitempty"`
 }
 
 type NetworkdDropin struct {
@@ -123,10 +350,72 @@ type NetworkdDropin struct {
 	Name     string `json:"name"`
 }
 
+type NetworkdLink struct {
+	AlternativeNames []string          `json:"alternativeNames,omitempty"`
+	If               *Condition        `json:"if,omitempty"`
+	Match            NetworkdLinkMatch `json:"match"`
+	Name             string            `json:"name,omitempty"`
+}
+
+type NetworkdLinkMatch struct {
+	Driver              string `json:"driver,omitempty"`
+	MACAddress          string `json:"macAddress,omitempty"`
+	Path                string `json:"path,omitempty"`
+	PermanentMACAddress string `json:"permanentMacAddress,omitempty"`
+}
+
+type NetworkdRoute struct {
+	Destination   string `json:"destination,omitempty"`
+	Gateway       string `json:"gateway,omitempty"`
+	GatewayOnLink bool   `json:"gatewayOnLink,omitempty"`
+	Metric        *int   `json:"metric,omitempty"`
+	Table         *int   `json:"table,omitempty"`
+}
+
+type NetworkdRoutingPolicyRule struct {
+	From              string `json:"from,omitempty"`
+	IncomingInterface string `json:"incomingInterface,omitempty"`
+	Priority          *int   `json:"priority,omitempty"`
+	Table             int    `json:"table"`
+	To                string `json:"to,omitempty"`
+}
+
 type Networkdunit struct {
-	Contents string           `json:"contents,omitempty"`
-	Dropins  []NetworkdDropin `json:"dropins,omitempty"`
-	Name     string           `json:"name"`
+	Contents           string                      `json:"contents,omitempty"`
+	Dropins            []NetworkdDropin            `json:"dropins,omitempty"`
+	If                 *Condition                  `json:"if,omitempty"`
+	Name               string                      `json:"name"`
+	Routes             []NetworkdRoute             `json:"routes,omitempty"`
+	RoutingPolicyRules []NetworkdRoutingPolicyRule `json:"routingPolicyRules,omitempty"`
+}
+
+type NetworkdVLAN struct {
+	ID        int        `json:"id"`
+	If        *Condition `json:"if,omitempty"`
+	Interface string     `json:"interface"`
+	Name      string     `json:"name"`
+}
+
+type NetworkdWireGuard struct {
+	Addresses  []string                `json:"addresses,omitempty"`
+	If         *Condition              `json:"if,omitempty"`
+	ListenPort *int                    `json:"listenPort,omitempty"`
+	Name       string                  `json:"name"`
+	Peers      []NetworkdWireGuardPeer `json:"peers,omitempty"`
+	PrivateKey NetworkdWireGuardKey    `json:"privateKey"`
+}
+
+type NetworkdWireGuardKey struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
+type NetworkdWireGuardPeer struct {
+	AllowedIPs          []string `json:"allowedIPs,omitempty"`
+	Endpoint            string   `json:"endpoint,omitempty"`
+	PersistentKeepalive *int     `json:"persistentKeepalive,omitempty"`
+	PublicKey           string   `json:"publicKey"`
 }
 
 type NoProxyItem string
@@ -134,6 +423,7 @@ type NoProxyItem string
 type Node struct {
 	Filesystem string     `json:"filesystem"`
 	Group      *NodeGroup `json:"group,omitempty"`
+	If         *Condition `json:"if,omitempty"`
 	Overwrite  *bool      `json:"overwrite,omitempty"`
 	Path       string     `json:"path"`
 	User       *NodeUser  `json:"user,omitempty"`
@@ -149,6 +439,19 @@ type NodeUser struct {
 	Name string `json:"name,omitempty"`
 }
 
+type OEM struct {
+	GrubConfig string      `json:"grubConfig,omitempty"`
+	Release    *OEMRelease `json:"release,omitempty"`
+}
+
+type OEMRelease struct {
+	BugReportURL string `json:"bugReportUrl,omitempty"`
+	HomeURL      string `json:"homeUrl,omitempty"`
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	VersionID    string `json:"versionId,omitempty"`
+}
+
 type Partition struct {
 	GUID               string  `json:"guid,omitempty"`
 	Label              *string `json:"label,omitempty"`
@@ -163,8 +466,17 @@ type Partition struct {
 }
 
 type Passwd struct {
-	Groups []PasswdGroup `json:"groups,omitempty"`
-	Users  []PasswdUser  `json:"users,omitempty"`
+	Groups  []PasswdGroup  `json:"groups,omitempty"`
+	Policy  *PasswdPolicy  `json:"policy,omitempty"`
+	Sudoers []PasswdSudoer `json:"sudoers,omitempty"`
+	Users   []PasswdUser   `json:"users,omitempty"`
+}
+
+type PasswdFaillock struct {
+	Deny         *int `json:"deny,omitempty"`
+	EvenDenyRoot bool `json:"evenDenyRoot,omitempty"`
+	FailInterval *int `json:"failInterval,omitempty"`
+	UnlockTime   *int `json:"unlockTime,omitempty"`
 }
 
 type PasswdGroup struct {
@@ -174,6 +486,24 @@ type PasswdGroup struct {
 	System       bool   `json:"system,omitempty"`
 }
 
+type PasswdPasswordAging struct {
+	MaxDays *int `json:"maxDays,omitempty"`
+	MinDays *int `json:"minDays,omitempty"`
+	WarnAge *int `json:"warnAge,omitempty"`
+}
+
+type PasswdPolicy struct {
+	Faillock      *PasswdFaillock      `json:"faillock,omitempty"`
+	PasswordAging *PasswdPasswordAging `json:"passwordAging,omitempty"`
+}
+
+type PasswdSudoer struct {
+	Commands []string `json:"commands,omitempty"`
+	Group    string   `json:"group,omitempty"`
+	NoPasswd bool     `json:"noPasswd,omitempty"`
+	User     string   `json:"user,omitempty"`
+}
+
 type PasswdUser struct {
 	Create            *Usercreate        `json:"create,omitempty"`
 	Gecos             string             `json:"gecos,omitempty"`
@@ -197,6 +527,11 @@ type Proxy struct {
 	NoProxy    []NoProxyItem `json:"noProxy,omitempty"`
 }
 
+type PublicKeyPin struct {
+	Hash string `json:"hash"`
+	Host string `json:"host,omitempty"`
+}
+
 type Raid struct {
 	Devices []Device     `json:"devices"`
 	Level   string       `json:"level"`
@@ -207,23 +542,95 @@ type Raid struct {
 
 type RaidOption string
 
+type Reboot struct {
+	Method   string       `json:"method,omitempty"`
+	Paths    []RebootPath `json:"paths,omitempty"`
+	Required bool         `json:"required,omitempty"`
+	Trigger  bool         `json:"trigger,omitempty"`
+}
+
+type RebootPath string
+
+type Redirects struct {
+	Limit       *int `json:"limit,omitempty"`
+	NoDowngrade bool `json:"noDowngrade,omitempty"`
+	SameHost    bool `json:"sameHost,omitempty"`
+}
+
+type SSH struct {
+	ConfigSnippets           []SSHConfigSnippet `json:"configSnippets,omitempty"`
+	DisableHostKeyGeneration bool               `json:"disableHostKeyGeneration,omitempty"`
+	HostKeys                 []SSHHostKey       `json:"hostKeys,omitempty"`
+	PhoneHome                *SSHPhoneHome      `json:"phoneHome,omitempty"`
+	RequireConfigInclude     bool               `json:"requireConfigInclude,omitempty"`
+}
+
 type SSHAuthorizedKey string
 
+type SSHConfigSnippet struct {
+	Contents string `json:"contents,omitempty"`
+	Name     string `json:"name"`
+}
+
+type SSHPhoneHome struct {
+	HTTPHeaders HTTPHeaders `json:"httpHeaders,omitempty"`
+	URL         string      `json:"url"`
+}
+
+type SSHHostKey struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	PublicKey    string       `json:"publicKey,omitempty"`
+	Source       string       `json:"source"`
+	Type         string       `json:"type"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
 type Security struct {
-	TLS TLS `json:"tls,omitempty"`
+	Redirects Redirects  `json:"redirects,omitempty"`
+	SFTP      []SFTPHost `json:"sftp,omitempty"`
+	TLS       TLS        `json:"tls,omitempty"`
+}
+
+type SFTPHost struct {
+	Host       string         `json:"host"`
+	HostKeys   []string       `json:"hostKeys"`
+	PrivateKey SFTPPrivateKey `json:"privateKey"`
+}
+
+type SFTPPrivateKey struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
 }
 
 type Storage struct {
+	Archives    []Archive    `json:"archives,omitempty"`
+	Clones      []Clone      `json:"clones,omitempty"`
 	Directories []Directory  `json:"directories,omitempty"`
 	Disks       []Disk       `json:"disks,omitempty"`
+	Edits       []Edit       `json:"edits,omitempty"`
 	Files       []File       `json:"files,omitempty"`
 	Filesystems []Filesystem `json:"filesystems,omitempty"`
+	Images      []Image      `json:"images,omitempty"`
 	Links       []Link       `json:"links,omitempty"`
+	Manifests   []Manifest   `json:"manifests,omitempty"`
+	OEM         *OEM         `json:"oem,omitempty"`
 	Raid        []Raid       `json:"raid,omitempty"`
+	UdevRules   []UdevRule   `json:"udevRules,omitempty"`
 }
 
 type Systemd struct {
-	Units []Unit `json:"units,omitempty"`
+	Extensions []SystemdExtension `json:"extensions,omitempty"`
+	Units      []Unit             `json:"units,omitempty"`
+}
+
+type SystemdExtension struct {
+	Compression  string       `json:"compression,omitempty"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Name         string       `json:"name"`
+	Source       string       `json:"source"`
+	Type         string       `json:"type,omitempty"`
+	Verification Verification `json:"verification,omitempty"`
 }
 
 type SystemdDropin struct {
@@ -232,19 +639,54 @@ type SystemdDropin struct {
 }
 
 type TLS struct {
-	CertificateAuthorities []CaReference `json:"certificateAuthorities,omitempty"`
+	CertificateAuthorities []CaReference       `json:"certificateAuthorities,omitempty"`
+	ClientCertificates     []ClientCertificate `json:"clientCertificates,omitempty"`
+	PublicKeyPins          []PublicKeyPin      `json:"publicKeyPins,omitempty"`
+	RequireOCSPStapling    *bool               `json:"requireOCSPStapling,omitempty"`
+}
+
+type Time struct {
+	MakeStep *TimeMakeStep `json:"makeStep,omitempty"`
+	Servers  []TimeServer  `json:"servers,omitempty"`
+}
+
+type TimeMakeStep struct {
+	Limit     int    `json:"limit"`
+	Threshold string `json:"threshold"`
+}
+
+type TimeServer struct {
+	Address string `json:"address"`
+	NTS     bool   `json:"nts,omitempty"`
 }
 
 type Timeouts struct {
+	HTTPBackoffBaseMs   *int `json:"httpBackoffBaseMs,omitempty"`
+	HTTPBackoffMaxMs    *int `json:"httpBackoffMaxMs,omitempty"`
 	HTTPResponseHeaders *int `json:"httpResponseHeaders,omitempty"`
+	HTTPRetries         *int `json:"httpRetries,omitempty"`
 	HTTPTotal           *int `json:"httpTotal,omitempty"`
 }
 
+type TokenReference struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
+type UdevRule struct {
+	Contents string `json:"contents,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Priority *int   `json:"priority,omitempty"`
+	Trigger  bool   `json:"trigger,omitempty"`
+}
+
 type Unit struct {
 	Contents string          `json:"contents,omitempty"`
 	Dropins  []SystemdDropin `json:"dropins,omitempty"`
 	Enable   bool            `json:"enable,omitempty"`
 	Enabled  *bool           `json:"enabled,omitempty"`
+	If       *Condition      `json:"if,omitempty"`
 	Mask     bool            `json:"mask,omitempty"`
 	Name     string          `json:"name"`
 }
@@ -264,6 +706,23 @@ type Usercreate struct {
 
 type UsercreateGroup string
 
+type Update struct {
+	Group          string              `json:"group,omitempty"`
+	RebootStrategy string              `json:"rebootStrategy,omitempty"`
+	RebootWindow   *UpdateRebootWindow `json:"rebootWindow,omitempty"`
+	Server         string              `json:"server,omitempty"`
+}
+
+type UpdateRebootWindow struct {
+	Length string `json:"length"`
+	Start  string `json:"start"`
+}
+
 type Verification struct {
 	Hash *string `json:"hash,omitempty"`
 }
+
+type Xattr struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confine starts child processes confined by Landlock and seccomp,
+// so a process exploited through a malicious config can't write outside of
+// the paths it needs or reconfigure the system.
+//
+// Both Landlock and seccomp apply to single threads, while Go schedules
+// goroutines on many threads. Start therefore confines a dedicated thread,
+// forks the child from it, which inherits the restrictions, and lets the
+// thread exit.
+package confine
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+const prSetNoNewPrivs = 38
+
+// Policy describes what a confined process may access. Everything can be
+// read and executed; only the paths listed here can be written.
+type Policy struct {
+	// WritableDirs are directories in which files, directories, links,
+	// sockets and fifos can be created, written, renamed and removed.
+	// Device nodes can't be created.
+	WritableDirs []string
+	// WritableFiles are files outside of WritableDirs which can be
+	// written, such as /dev/null.
+	WritableFiles []string
+}
+
+// Result describes the restrictions applied to a process.
+type Result struct {
+	// LandlockABI is the Landlock ABI version used to restrict writes,
+	// or 0 if the kernel doesn't support Landlock.
+	LandlockABI int
+	// Seccomp is true if dangerous system calls are denied.
+	Seccomp bool
+}
+
+// Start starts cmd confined by p. It is best effort: the restrictions the
+// kernel doesn't support are skipped, and Result tells which were applied.
+func Start(cmd *exec.Cmd, p Policy) (Result, error) {
+	type started struct {
+		res Result
+		err error
+	}
+	done := make(chan started, 1)
+	go func() {
+		// The thread is never unlocked, so it exits along with this
+		// goroutine instead of running other goroutines confined.
+		runtime.LockOSThread()
+		res, err := restrict(p)
+		if err == nil {
+			err = cmd.Start()
+		}
+		done <- started{res, err}
+	}()
+	s := <-done
+	return s.res, s.err
+}
+
+// restrict confines the current thread.
+func restrict(p Policy) (Result, error) {
+	var res Result
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return res, errno
+	}
+
+	abi, err := restrictLandlock(p)
+	if err != nil {
+		return res, err
+	}
+	res.LandlockABI = abi
+
+	applied, err := restrictSeccomp()
+	if err != nil {
+		return res, err
+	}
+	res.Seccomp = applied
+	return res, nil
+}
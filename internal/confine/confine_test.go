@@ -0,0 +1,91 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confine
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	if landlockABI() == 0 {
+		t.Skip("landlock is not supported")
+	}
+
+	writable, err := ioutil.TempDir("", "confine-writable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(writable)
+	readOnly, err := ioutil.TempDir("", "confine-read-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(readOnly)
+	if err := ioutil.WriteFile(filepath.Join(readOnly, "existing"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		script  string
+		succeed bool
+	}{
+		{"echo ok > " + writable + "/file && mkdir " + writable + "/dir && mv " + writable + "/file " + writable + "/dir/", true},
+		{"cat " + readOnly + "/existing > /dev/null", true},
+		{"echo no > " + readOnly + "/file", false},
+		{"echo no >> " + readOnly + "/existing", false},
+		{"rm " + readOnly + "/existing", false},
+		{"mknod " + writable + "/disk b 8 0", false},
+		// unshare(2) is denied by the seccomp filter
+		{"unshare -m true", !seccompSupported()},
+	}
+
+	for i, test := range tests {
+		cmd := exec.Command("/bin/sh", "-c", test.script)
+		res, err := Start(cmd, Policy{WritableDirs: []string{writable}, WritableFiles: []string{"/dev/null"}})
+		if err != nil {
+			t.Fatalf("#%d: starting: %v", i, err)
+		}
+		if res.LandlockABI == 0 {
+			t.Errorf("#%d: landlock not applied", i)
+		}
+		err = cmd.Wait()
+		if test.succeed && err != nil {
+			t.Errorf("#%d: %q failed: %v", i, test.script, err)
+		} else if !test.succeed && err == nil {
+			t.Errorf("#%d: %q succeeded", i, test.script)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(writable, "dir", "file")); err != nil {
+		t.Errorf("file not written: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(readOnly, "existing")); string(b) != "data" {
+		t.Errorf("read-only file modified: %q", b)
+	}
+	// only the children are confined
+	for i := 0; i < 10; i++ {
+		if err := ioutil.WriteFile(filepath.Join(readOnly, "parent"), nil, 0644); err != nil {
+			t.Fatalf("parent confined: %v", err)
+		}
+	}
+}
+
+func seccompSupported() bool {
+	return auditArch != 0
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confine
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls have the same numbers on all architectures.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1
+	landlockRulePathBeneath      = 1
+
+	oPath = 0x200000
+)
+
+// access rights to files and directories
+const (
+	accessExecute    = 1 << 0
+	accessWriteFile  = 1 << 1
+	accessReadFile   = 1 << 2
+	accessReadDir    = 1 << 3
+	accessRemoveDir  = 1 << 4
+	accessRemoveFile = 1 << 5
+	accessMakeChar   = 1 << 6
+	accessMakeDir    = 1 << 7
+	accessMakeReg    = 1 << 8
+	accessMakeSock   = 1 << 9
+	accessMakeFifo   = 1 << 10
+	accessMakeBlock  = 1 << 11
+	accessMakeSym    = 1 << 12
+	accessRefer      = 1 << 13 // ABI 2
+	accessTruncate   = 1 << 14 // ABI 3
+	accessIoctlDev   = 1 << 15 // ABI 5
+
+	accessRead = accessExecute | accessReadFile | accessReadDir
+	// rights which can be granted on files rather than directories
+	accessFile = accessExecute | accessWriteFile | accessReadFile | accessTruncate | accessIoctlDev
+	// rights never granted, so device nodes giving access to disks
+	// can't be created
+	accessDevices = accessMakeChar | accessMakeBlock
+)
+
+type landlockRulesetAttr struct {
+	handledAccessFS uint64
+}
+
+// landlockPathBeneathAttr is packed in the kernel; only its first 12 bytes
+// are read.
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+// handledAccess returns the rights the given Landlock ABI version knows.
+func handledAccess(abi int) uint64 {
+	access := uint64(accessRefer - 1)
+	if abi >= 2 {
+		access |= accessRefer
+	}
+	if abi >= 3 {
+		access |= accessTruncate
+	}
+	if abi >= 5 {
+		access |= accessIoctlDev
+	}
+	return access
+}
+
+// landlockABI returns the Landlock ABI version of the kernel, or 0 if
+// Landlock isn't supported or enabled.
+func landlockABI() int {
+	abi, _, errno := syscall.RawSyscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// restrictLandlock restricts writes of the current thread to the paths of
+// p and returns the ABI version used.
+func restrictLandlock(p Policy) (int, error) {
+	abi := landlockABI()
+	if abi == 0 {
+		return 0, nil
+	}
+	handled := handledAccess(abi)
+
+	attr := landlockRulesetAttr{handledAccessFS: handled}
+	fd, _, errno := syscall.RawSyscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return 0, fmt.Errorf("creating landlock ruleset: %v", errno)
+	}
+	ruleset := int(fd)
+	defer syscall.Close(ruleset)
+
+	if err := addPathRule(ruleset, "/", accessRead&handled); err != nil {
+		return 0, err
+	}
+	for _, dir := range p.WritableDirs {
+		if err := addPathRule(ruleset, dir, handled&^accessDevices); err != nil {
+			return 0, err
+		}
+	}
+	for _, file := range p.WritableFiles {
+		if err := addPathRule(ruleset, file, (accessReadFile|accessWriteFile|accessTruncate)&handled); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, _, errno := syscall.RawSyscall(sysLandlockRestrictSelf, uintptr(ruleset), 0, 0); errno != 0 {
+		return 0, fmt.Errorf("enforcing landlock ruleset: %v", errno)
+	}
+	return abi, nil
+}
+
+// addPathRule allows access beneath path. Paths which don't exist are
+// skipped, as nothing can be written to them either way.
+func addPathRule(ruleset int, path string, access uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("opening %q: %v", path, err)
+	}
+	defer syscall.Close(fd)
+
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("stat %q: %v", path, err)
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access &= accessFile
+	}
+
+	attr := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(fd)}
+	if _, _, errno := syscall.RawSyscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("adding landlock rule for %q: %v", path, errno)
+	}
+	return nil
+}
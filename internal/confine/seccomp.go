@@ -0,0 +1,81 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confine
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	prSetSeccomp      = 22
+	seccompModeFilter = 2
+
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// offsets in struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+
+	bpfLdWAbs = syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS
+	bpfJeqK   = syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K
+	bpfJgeK   = syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K
+	bpfRetK   = syscall.BPF_RET | syscall.BPF_K
+)
+
+// seccompFilter returns a filter making the system calls in deniedSyscalls
+// fail with EPERM, and killing the process if it uses system calls of another
+// architecture. deniedSyscalls are defined per architecture and have no
+// business in a stage writing files, such as mounting filesystems, loading
+// kernel modules, rebooting or debugging other processes.
+func seccompFilter() []syscall.SockFilter {
+	n := len(deniedSyscalls)
+	filter := []syscall.SockFilter{
+		{Code: bpfLdWAbs, K: seccompDataArch},
+		{Code: bpfJeqK, K: auditArch, Jt: 1},
+		{Code: bpfRetK, K: seccompRetKillProcess},
+		{Code: bpfLdWAbs, K: seccompDataNr},
+	}
+	if syscallNrLimit != 0 {
+		// e.g. x32 system calls on amd64
+		filter = append(filter, syscall.SockFilter{Code: bpfJgeK, K: syscallNrLimit, Jt: uint8(n + 1)})
+	}
+	for i, nr := range deniedSyscalls {
+		filter = append(filter, syscall.SockFilter{Code: bpfJeqK, K: nr, Jt: uint8(n - i)})
+	}
+	return append(filter,
+		syscall.SockFilter{Code: bpfRetK, K: seccompRetAllow},
+		syscall.SockFilter{Code: bpfRetK, K: seccompRetErrno | uint32(syscall.EPERM)},
+	)
+}
+
+// restrictSeccomp installs the filter for the current thread. It returns
+// false if seccomp isn't supported on this architecture.
+func restrictSeccomp() (bool, error) {
+	if auditArch == 0 {
+		return false, nil
+	}
+	filter := seccompFilter()
+	prog := syscall.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog)), 0, 0, 0); errno != 0 {
+		return false, errno
+	}
+	return true, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confine
+
+const (
+	auditArch = 0xc000003e
+	// numbers at or above this belong to the x32 ABI
+	syscallNrLimit = 0x40000000
+)
+
+var deniedSyscalls = []uint32{
+	101, // ptrace
+	155, // pivot_root
+	159, // adjtimex
+	163, // acct
+	164, // settimeofday
+	165, // mount
+	166, // umount2
+	167, // swapon
+	168, // swapoff
+	169, // reboot
+	170, // sethostname
+	171, // setdomainname
+	172, // iopl
+	173, // ioperm
+	175, // init_module
+	176, // delete_module
+	179, // quotactl
+	227, // clock_settime
+	246, // kexec_load
+	248, // add_key
+	249, // request_key
+	250, // keyctl
+	272, // unshare
+	298, // perf_event_open
+	304, // open_by_handle_at
+	305, // clock_adjtime
+	308, // setns
+	310, // process_vm_readv
+	311, // process_vm_writev
+	313, // finit_module
+	320, // kexec_file_load
+	321, // bpf
+	323, // userfaultfd
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	431, // fsconfig
+	432, // fsmount
+	442, // mount_setattr
+}
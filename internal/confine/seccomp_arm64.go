@@ -0,0 +1,60 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confine
+
+const (
+	auditArch      = 0xc00000b7
+	syscallNrLimit = 0
+)
+
+var deniedSyscalls = []uint32{
+	39,  // umount2
+	40,  // mount
+	41,  // pivot_root
+	60,  // quotactl
+	89,  // acct
+	97,  // unshare
+	104, // kexec_load
+	105, // init_module
+	106, // delete_module
+	112, // clock_settime
+	117, // ptrace
+	142, // reboot
+	161, // sethostname
+	162, // setdomainname
+	170, // settimeofday
+	171, // adjtimex
+	217, // add_key
+	218, // request_key
+	219, // keyctl
+	224, // swapon
+	225, // swapoff
+	241, // perf_event_open
+	265, // open_by_handle_at
+	266, // clock_adjtime
+	268, // setns
+	270, // process_vm_readv
+	271, // process_vm_writev
+	273, // finit_module
+	280, // bpf
+	282, // userfaultfd
+	294, // kexec_file_load
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	431, // fsconfig
+	432, // fsmount
+	442, // mount_setattr
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/oem"
+)
+
+// runConverge implements "ignition converge", which applies the storage
+// nodes, units and passwd section of a config to the running system. It
+// only shows the changes unless --apply is given, and returns the exit
+// status.
+func runConverge(args []string) int {
+	flags := flag.NewFlagSet("converge", flag.ContinueOnError)
+	configPath := flags.String("config", "", "config to apply")
+	var oemName oem.Name
+	flags.Var(&oemName, "oem", fmt.Sprintf("current oem. %v", oem.Names()))
+	apply := flags.Bool("apply", false, "apply the changes rather than only showing them")
+	logToStdout := flags.Bool("log-to-stdout", false, "log to stdout instead of the system log when set")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %v\n", flags.Args())
+		return 2
+	}
+	if *configPath == "" {
+		fmt.Fprint(os.Stderr, "'--config' must be provided\n")
+		return 2
+	}
+	if oemName == "" {
+		fmt.Fprint(os.Stderr, "'--oem' must be provided\n")
+		return 2
+	}
+	raw, err := ioutil.ReadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read config: %v\n", err)
+		return 1
+	}
+
+	logger := log.New(*logToStdout)
+	defer logger.Close()
+
+	oemConfig := oem.MustGet(oemName.String())
+	fetcher, err := oemConfig.NewFetcherFunc()(&logger)
+	if err != nil {
+		logger.Crit("failed to generate fetcher: %s", err)
+		return 1
+	}
+	if distro.PrivsepFetch() {
+		if err := fetcher.StartFetchHelper(); err != nil {
+			logger.Crit("failed to start fetch helper: %s", err)
+			return 1
+		}
+		defer fetcher.StopFetchHelper()
+	}
+	engine := exec.Engine{
+		Root:      "/",
+		Logger:    &logger,
+		OEMConfig: oemConfig,
+		Fetcher:   &fetcher,
+	}
+	if err := engine.Converge(raw, *apply, os.Stdout); err != nil {
+		logger.Crit("converging failed: %v", err)
+		fmt.Fprintf(os.Stderr, "converging failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
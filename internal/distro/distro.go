@@ -17,6 +17,9 @@ package distro
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Distro-specific settings that can be overridden at link time with e.g.
@@ -29,10 +32,40 @@ var (
 
 	// File paths
 	kernelCmdlinePath = "/proc/cmdline"
+	// file in which the kernel's IP autoconfiguration reports the boot
+	// server it was given, e.g. by DHCP
+	netPnpPath = "/proc/net/pnp"
+	// file in which the kernel reports whether it is in FIPS mode
+	fipsEnabledPath = "/proc/sys/crypto/fips_enabled"
+	// file ostree-prepare-root creates when booting an ostree deployment
+	ostreeBootedPath = "/run/ostree-booted"
 	// initramfs directory containing distro-provided base config
 	systemConfigDir = "/usr/lib/ignition"
+	// initramfs directory containing the programs config hooks may run
+	hooksDir = "/usr/lib/ignition/hooks"
 	// initramfs directory to check before retrieving file from OEM partition
 	oemLookasideDir = "/usr/share/oem"
+	// file the provider's metadata attributes are written to
+	metadataAttributesPath = "/run/metadata/ignition"
+	// file the paths Ignition wrote are recorded in, relative to the root
+	// filesystem; empty to not record them
+	managedPathsFile = "/var/lib/ignition/managed-paths.json"
+	// file a reboot request is recorded in
+	rebootRequestPath = "/run/ignition/reboot-request.json"
+	// file the report of the fetched config is recorded in
+	configReportPath = "/run/ignition/config-report.json"
+	// file the redacted config is dumped to if requested by the
+	// ignition.config.dump kernel argument
+	configDumpPath = "/run/ignition/config-dump.json"
+	// directory the units of filesystems handed over to the real root are
+	// generated in, surviving the switch to it
+	runtimeUnitsDir = "/run/systemd/system"
+	// directory the config and control sockets of the 802.1X supplicant
+	// authenticating the provisioning interface are created in
+	eapDir = "/run/ignition/eap"
+	// directory the udev rules applied to the devices of the initramfs
+	// are written to, ahead of the stages working on them
+	runtimeUdevRulesDir = "/run/udev/rules.d"
 
 	// Helper programs
 	chrootCmd     = "/usr/bin/chroot"
@@ -41,10 +74,24 @@ var (
 	mdadmCmd      = "/usr/sbin/mdadm"
 	mountCmd      = "/usr/bin/mount"
 	sgdiskCmd     = "/usr/sbin/sgdisk"
+	systemctlCmd  = "/usr/bin/systemctl"
 	udevadmCmd    = "/usr/bin/udevadm"
 	usermodCmd    = "/usr/sbin/usermod"
 	useraddCmd    = "/usr/sbin/useradd"
 	restoreconCmd = "/usr/sbin/restorecon"
+	// run chrooted in the target to generate SSH host keys
+	sshKeygenCmd = "/usr/bin/ssh-keygen"
+	// run chrooted in the target to check the files written
+	visudoCmd = "/usr/sbin/visudo"
+	sshdCmd   = "/usr/sbin/sshd"
+	// run in the initramfs to authenticate the provisioning interface
+	wpaSupplicantCmd = "/usr/sbin/wpa_supplicant"
+	wpaCliCmd        = "/usr/sbin/wpa_cli"
+	// run in the initramfs to fetch sftp resources
+	sftpCmd = "/usr/bin/sftp"
+	// run in the initramfs to decompress zstd and xz resources
+	zstdCmd = "/usr/bin/zstd"
+	xzCmd   = "/usr/bin/xz"
 
 	// Filesystem tools
 	btrfsMkfsCmd = "/usr/sbin/mkfs.btrfs"
@@ -53,29 +100,137 @@ var (
 	vfatMkfsCmd  = "/usr/sbin/mkfs.vfat"
 	xfsMkfsCmd   = "/usr/sbin/mkfs.xfs"
 
+	// Units
+	// the target's init system, "systemd" or "openrc"
+	initSystem = "systemd"
+	// the image's service generating the SSH host keys on first boot
+	sshKeygenUnit = "sshkeygen.service"
+
+	// Limits
+	// maximum decoded size of a data url in bytes, 0 meaning unlimited
+	maxDataURLSize = "0"
+	// maximum size of a config in bytes, 0 meaning unlimited
+	maxConfigSize = "33554432"
+	// maximum size of a compressed config or resource once decompressed,
+	// in bytes, 0 meaning unlimited
+	maxDecompressedSize = "0"
+	// maximum ratio of the decompressed to the compressed size of configs
+	// and resources, 0 meaning unlimited; above gzip's limit of about 1032
+	// by default, so that only the formats compressing better are checked
+	maxCompressionRatio = "1100"
+	// time after which helper programs are killed, 0 meaning never
+	helperTimeout = "1h"
+	// number of remote files the files stage fetches at once ahead of
+	// writing them, 0 meaning they're fetched one by one as they're written
+	concurrentFetches = "4"
+
+	// Time
+	// http(s) or roughtime url the time used to check TLS certificates is
+	// taken from, if the system clock isn't to be trusted
+	timeSource = ""
+
+	// Missing configs
+	// what to do if no config is supplied: "continue" with the base config,
+	// "fail" or "retry" until one is supplied, for all platforms or as
+	// comma separated <platform>=<policy> entries
+	missingConfig = ""
+
+	// Networking
+	// the network stack fetches connect through: "kernel", or another
+	// stack's name followed by ":" and its endpoint, e.g.
+	// "socks5:/run/netstack.sock"
+	networkStack = "kernel"
+	// the interface fetches are bound to, any if empty
+	networkInterface = ""
+	// time name resolutions of fetches may take, separately from the
+	// connection and the fetch, 0 meaning they're only bounded by those
+	dnsTimeout = "10s"
+	// time to wait for the 802.1X authentication of the provisioning
+	// interface
+	eapTimeout = "2m"
+	// directory systemd-networkd records its DHCP leases in, which the
+	// WPAD url of the proxy is looked up in
+	dhcpLeasesDir = "/run/systemd/netif/leases"
+
+	// Privilege separation
+	// user and group the fetch helper process runs as
+	fetchHelperUID = "65534"
+	fetchHelperGID = "65534"
+
+	// Restricted mode
+	// regexp matched against the contents of units and dropins
+	restrictedUnitDenylist = `(?m)^\s*Exec[A-Za-z]*\s*=`
+	// colon separated directories in which configs can't create nodes
+	restrictedDirs = "/etc/systemd:/run/systemd:/usr/lib/systemd:/lib/systemd:/etc/udev/rules.d:/run/udev/rules.d:/usr/lib/udev/rules.d:/lib/udev/rules.d:/etc/modprobe.d:/run/modprobe.d:/usr/lib/modprobe.d:/lib/modprobe.d:/etc/cron.d"
+	// colon separated directories and files in which configs can't create
+	// nodes in addition if the init system is OpenRC, as they hold the init
+	// scripts, their settings and what runs at boot
+	restrictedOpenRCDirs = "/etc/init.d:/etc/conf.d:/etc/local.d:/etc/runlevels:/etc/rc.conf:/etc/inittab"
+
 	// Flags
 	selinuxRelabel  = "false"
 	blackboxTesting = "false"
+	// partition disks without sgdisk even if it is available
+	nativeGPT = "false"
+	// refuse configs which let programs of their choosing run
+	restrictedExec = "false"
+	// run the files stage confined by Landlock and seccomp
+	confineFiles = "false"
+	// fetch network resources in an unprivileged helper process
+	privsepFetch = "false"
+	// remount the filesystems the files stage writes to read-write if they
+	// are mounted read-only, rather than failing
+	remountReadOnly = "false"
+	// refuse to fetch referenced configs without a verification hash
+	requireConfigVerification = "false"
+	// use the proxy of the WPAD url handed out by DHCP for fetches if the
+	// config sets none
+	proxyAutoDetect = "false"
+	// write the metadata attributes of the instance to an environment file
+	writeMetadata = "false"
 )
 
 func DiskByLabelDir() string    { return diskByLabelDir }
 func DiskByPartUUIDDir() string { return diskByPartUUIDDir }
 func OEMDevicePath() string     { return fromEnv("OEM_DEVICE", oemDevicePath) }
 
-func KernelCmdlinePath() string { return kernelCmdlinePath }
+func KernelCmdlinePath() string { return fromEnv("KERNEL_CMDLINE_PATH", kernelCmdlinePath) }
+func NetPnpPath() string        { return fromEnv("NET_PNP_PATH", netPnpPath) }
+func FIPSEnabledPath() string   { return fromEnv("FIPS_ENABLED_PATH", fipsEnabledPath) }
+func OSTreeBootedPath() string  { return fromEnv("OSTREE_BOOTED_PATH", ostreeBootedPath) }
 func SystemConfigDir() string   { return fromEnv("SYSTEM_CONFIG_DIR", systemConfigDir) }
+func HooksDir() string          { return fromEnv("HOOKS_DIR", hooksDir) }
 func OEMLookasideDir() string   { return fromEnv("OEM_LOOKASIDE_DIR", oemLookasideDir) }
 
+func MetadataAttributesPath() string { return fromEnv("METADATA_PATH", metadataAttributesPath) }
+func RebootRequestPath() string      { return fromEnv("REBOOT_REQUEST_PATH", rebootRequestPath) }
+func ConfigReportPath() string       { return fromEnv("CONFIG_REPORT_PATH", configReportPath) }
+func ConfigDumpPath() string         { return fromEnv("CONFIG_DUMP_PATH", configDumpPath) }
+func RuntimeUnitsDir() string        { return fromEnv("RUNTIME_UNITS_DIR", runtimeUnitsDir) }
+func EAPDir() string                 { return fromEnv("EAP_DIR", eapDir) }
+func RuntimeUdevRulesDir() string    { return fromEnv("RUNTIME_UDEV_RULES_DIR", runtimeUdevRulesDir) }
+func ManagedPathsFile() string       { return managedPathsFile }
+
 func ChrootCmd() string     { return chrootCmd }
 func GroupaddCmd() string   { return groupaddCmd }
 func IdCmd() string         { return idCmd }
 func MdadmCmd() string      { return mdadmCmd }
 func MountCmd() string      { return mountCmd }
 func SgdiskCmd() string     { return sgdiskCmd }
+func SystemctlCmd() string  { return systemctlCmd }
 func UdevadmCmd() string    { return udevadmCmd }
 func UsermodCmd() string    { return usermodCmd }
 func UseraddCmd() string    { return useraddCmd }
 func RestoreconCmd() string { return restoreconCmd }
+func SSHKeygenCmd() string  { return sshKeygenCmd }
+func VisudoCmd() string     { return visudoCmd }
+func SshdCmd() string       { return sshdCmd }
+
+func WpaSupplicantCmd() string { return wpaSupplicantCmd }
+func WpaCliCmd() string        { return wpaCliCmd }
+func SFTPCmd() string          { return sftpCmd }
+func ZstdCmd() string          { return zstdCmd }
+func XzCmd() string            { return xzCmd }
 
 func BtrfsMkfsCmd() string { return btrfsMkfsCmd }
 func Ext4MkfsCmd() string  { return ext4MkfsCmd }
@@ -83,8 +238,84 @@ func SwapMkfsCmd() string  { return swapMkfsCmd }
 func VfatMkfsCmd() string  { return vfatMkfsCmd }
 func XfsMkfsCmd() string   { return xfsMkfsCmd }
 
+func InitSystem() string    { return fromEnv("INIT_SYSTEM", initSystem) }
+func SSHKeygenUnit() string { return sshKeygenUnit }
+
+func MaxDataURLSize() int64 { return intFromEnv("MAX_DATA_URL_SIZE", maxDataURLSize) }
+func MaxConfigSize() int64  { return intFromEnv("MAX_CONFIG_SIZE", maxConfigSize) }
+func MaxDecompressedSize() int64 {
+	return intFromEnv("MAX_DECOMPRESSED_SIZE", maxDecompressedSize)
+}
+func MaxCompressionRatio() int64 {
+	return intFromEnv("MAX_COMPRESSION_RATIO", maxCompressionRatio)
+}
+
+func HelperTimeout() time.Duration {
+	return durationFromEnv("HELPER_TIMEOUT", helperTimeout)
+}
+
+func ConcurrentFetches() int {
+	return int(intFromEnv("CONCURRENT_FETCHES", concurrentFetches))
+}
+
+func TimeSource() string { return fromEnv("TIME_SOURCE", timeSource) }
+
+func MissingConfig() string { return fromEnv("MISSING_CONFIG", missingConfig) }
+
+func NetworkStack() string     { return fromEnv("NETWORK_STACK", networkStack) }
+func NetworkInterface() string { return fromEnv("NETWORK_INTERFACE", networkInterface) }
+
+func DNSTimeout() time.Duration { return durationFromEnv("DNS_TIMEOUT", dnsTimeout) }
+
+func EAPTimeout() time.Duration { return durationFromEnv("EAP_TIMEOUT", eapTimeout) }
+func DHCPLeasesDir() string     { return fromEnv("DHCP_LEASES_DIR", dhcpLeasesDir) }
+
+func FetchHelperUID() int { return int(bakedStringToInt(fetchHelperUID)) }
+func FetchHelperGID() int { return int(bakedStringToInt(fetchHelperGID)) }
+
+func RestrictedUnitDenylist() string { return restrictedUnitDenylist }
+
+// RestrictedDirs includes the OpenRC directories if the init system is
+// OpenRC.
+func RestrictedDirs() []string {
+	dirs := strings.Split(restrictedDirs, ":")
+	if InitSystem() == "openrc" {
+		dirs = append(dirs, strings.Split(restrictedOpenRCDirs, ":")...)
+	}
+	return dirs
+}
+
 func SelinuxRelabel() bool  { return bakedStringToBool(selinuxRelabel) }
 func BlackboxTesting() bool { return bakedStringToBool(blackboxTesting) }
+func NativeGPT() bool       { return bakedStringToBool(nativeGPT) }
+func ConfineFiles() bool    { return bakedStringToBool(confineFiles) }
+
+func RemountReadOnly() bool { return boolFromEnv("REMOUNT_READ_ONLY", remountReadOnly) }
+func WriteMetadata() bool   { return boolFromEnv("WRITE_METADATA", writeMetadata) }
+
+// RestrictedExec can be enabled at runtime, but not disabled if it was
+// enabled at link time.
+func RestrictedExec() bool {
+	return bakedStringToBool(restrictedExec) || boolFromEnv("RESTRICTED_EXEC", "false")
+}
+
+// PrivsepFetch can be enabled at runtime, but not disabled if it was
+// enabled at link time.
+func PrivsepFetch() bool {
+	return bakedStringToBool(privsepFetch) || boolFromEnv("PRIVSEP_FETCH", "false")
+}
+
+// RequireConfigVerification can be enabled at runtime, but not disabled if
+// it was enabled at link time.
+func RequireConfigVerification() bool {
+	return bakedStringToBool(requireConfigVerification) || boolFromEnv("REQUIRE_CONFIG_VERIFICATION", "false")
+}
+
+// ProxyAutoDetect can be enabled at runtime, but not disabled if it was
+// enabled at link time.
+func ProxyAutoDetect() bool {
+	return bakedStringToBool(proxyAutoDetect) || boolFromEnv("PROXY_AUTO_DETECT", "false")
+}
 
 func fromEnv(nameSuffix, defaultValue string) string {
 	value := os.Getenv("IGNITION_" + nameSuffix)
@@ -94,14 +325,99 @@ func fromEnv(nameSuffix, defaultValue string) string {
 	return defaultValue
 }
 
+// boolFromEnv, intFromEnv and durationFromEnv return the parsed value of the
+// environment variable, or the baked-in default if it's unset or can't be
+// parsed. Unlike bad link-time values, a bad environment value shouldn't
+// crash Ignition.
+func boolFromEnv(nameSuffix, defaultValue string) bool {
+	if value := os.Getenv("IGNITION_" + nameSuffix); value != "" {
+		b, err := parseBool(value)
+		if err == nil {
+			return b
+		}
+		warnBadEnv(nameSuffix, err)
+	}
+	return bakedStringToBool(defaultValue)
+}
+
+func intFromEnv(nameSuffix, defaultValue string) int64 {
+	if value := os.Getenv("IGNITION_" + nameSuffix); value != "" {
+		i, err := parseInt(value)
+		if err == nil {
+			return i
+		}
+		warnBadEnv(nameSuffix, err)
+	}
+	return bakedStringToInt(defaultValue)
+}
+
+func durationFromEnv(nameSuffix, defaultValue string) time.Duration {
+	if value := os.Getenv("IGNITION_" + nameSuffix); value != "" {
+		d, err := parseDuration(value)
+		if err == nil {
+			return d
+		}
+		warnBadEnv(nameSuffix, err)
+	}
+	return bakedStringToDuration(defaultValue)
+}
+
+// warnBadEnv writes to stderr since the logger depends on this package.
+func warnBadEnv(nameSuffix string, err error) {
+	fmt.Fprintf(os.Stderr, "ignoring IGNITION_%s: %v\n", nameSuffix, err)
+}
+
 func bakedStringToBool(s string) bool {
-	// the linker only supports string args, so do some basic bool sensing
-	if s == "true" || s == "1" {
-		return true
-	} else if s == "false" || s == "0" {
-		return false
-	} else {
+	b, err := parseBool(s)
+	if err != nil {
 		// if we got a bad compile flag, just crash and burn rather than assume
-		panic(fmt.Sprintf("value '%s' cannot be interpreted as a boolean", s))
+		panic(err.Error())
+	}
+	return b
+}
+
+func bakedStringToInt(s string) int64 {
+	i, err := parseInt(s)
+	if err != nil {
+		panic(err.Error())
+	}
+	return i
+}
+
+func bakedStringToDuration(s string) time.Duration {
+	d, err := parseDuration(s)
+	if err != nil {
+		panic(err.Error())
+	}
+	return d
+}
+
+func parseBool(s string) (bool, error) {
+	// the linker only supports string args, so do some basic bool sensing
+	switch s {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("value '%s' cannot be interpreted as a boolean", s)
+}
+
+func parseInt(s string) (int64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' cannot be interpreted as an integer", s)
+	}
+	return i, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' cannot be interpreted as a duration", s)
 	}
+	return d, nil
 }
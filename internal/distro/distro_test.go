@@ -0,0 +1,68 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distro
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	type in struct {
+		env string
+	}
+	type out struct {
+		size    int64
+		timeout time.Duration
+		remount bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{env: ""},
+			out: out{size: 32, timeout: time.Minute, remount: true},
+		},
+		{
+			in:  in{env: "1M"},
+			out: out{size: 32, timeout: time.Minute, remount: true},
+		},
+		{
+			in:  in{env: "0"},
+			out: out{size: 0, timeout: 0, remount: false},
+		},
+	}
+
+	for i, test := range tests {
+		for _, name := range []string{"IGNITION_TEST_SIZE", "IGNITION_TEST_TIMEOUT", "IGNITION_TEST_REMOUNT"} {
+			if err := os.Setenv(name, test.in.env); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Unsetenv(name)
+		}
+		if size := intFromEnv("TEST_SIZE", "32"); size != test.out.size {
+			t.Errorf("#%d: bad size: want %d, got %d", i, test.out.size, size)
+		}
+		if timeout := durationFromEnv("TEST_TIMEOUT", "1m"); timeout != test.out.timeout {
+			t.Errorf("#%d: bad timeout: want %v, got %v", i, test.out.timeout, timeout)
+		}
+		if remount := boolFromEnv("TEST_REMOUNT", "true"); remount != test.out.remount {
+			t.Errorf("#%d: bad remount: want %t, got %t", i, test.out.remount, remount)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// cmdlineValue returns the value of the last flag option of the kernel
+// command line, and whether the option is given at all. An option without
+// "=" has an empty value. A missing command line is treated like one
+// without the option.
+func cmdlineValue(flag string) (string, bool, error) {
+	args, err := ioutil.ReadFile(distro.KernelCmdlinePath())
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("couldn't read cmdline: %v", err)
+	}
+	value, ok := parseCmdlineValue(args, flag)
+	return value, ok, nil
+}
+
+func parseCmdlineValue(cmdline []byte, flag string) (value string, ok bool) {
+	for _, arg := range strings.Fields(string(cmdline)) {
+		parts := strings.SplitN(arg, "=", 2)
+		if parts[0] != flag {
+			continue
+		}
+		value, ok = "", true
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+	}
+	return
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"testing"
+)
+
+func TestParseCmdlineValue(t *testing.T) {
+	tests := []struct {
+		in    string
+		value string
+		ok    bool
+	}{
+		{in: ""},
+		{in: "root=/dev/sda1 console=ttyS0\n"},
+		{in: "root=/dev/sda1 ignition.variant=gpu\n", value: "gpu", ok: true},
+		{in: "ignition.variant=gpu\tignition.variant=storage", value: "storage", ok: true},
+		{in: "ignition.variant.x=gpu"},
+		{in: "ignition.variant=gpu ignition.variant", value: "", ok: true},
+		{in: "ignition.variant=", value: "", ok: true},
+		{in: "ignition.variant=a=b", value: "a=b", ok: true},
+	}
+
+	for i, test := range tests {
+		value, ok := parseCmdlineValue([]byte(test.in), cmdlineVariantFlag)
+		if value != test.value || ok != test.ok {
+			t.Errorf("#%d: bad value: want %q, %t, got %q, %t", i, test.value, test.ok, value, ok)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+const (
+	cmdlineVariantFlag = "ignition.variant"
+)
+
+// readVariant returns the variant given by the "ignition.variant" kernel
+// command line option, or the empty string if it is not set.
+func (e Engine) readVariant() string {
+	variant, _, err := cmdlineValue(cmdlineVariantFlag)
+	if err != nil {
+		e.Logger.Warning("%v, assuming no variant", err)
+		return ""
+	}
+	return variant
+}
+
+// filterConditional drops every disk, file, directory, link, and unit whose
+// "if" condition does not hold on this machine. The platform is the name of
+// the OEM Ignition was started with. The metadata attributes of the instance
+// are only fetched once a condition needs them; if they can't be, conditions
+// on them don't hold.
+func (e Engine) filterConditional(cfg types.Config) types.Config {
+	platform := e.OEMConfig.Name()
+	variant := e.readVariant()
+	var metadata map[string]string
+	fetchedMetadata := false
+	skip := func(kind, name string, c *types.Condition) bool {
+		if c != nil && len(c.Metadata) > 0 && !fetchedMetadata {
+			var err error
+			if metadata, err = e.metadataAttributes(); err != nil {
+				e.Logger.Warning("failed to fetch metadata attributes for conditions: %v", err)
+			}
+			fetchedMetadata = true
+		}
+		if c.Matches(platform, variant, metadata) {
+			return false
+		}
+		e.Logger.Info("skipping %s %q: condition not met on platform %q (variant %q)", kind, name, platform, variant)
+		return true
+	}
+
+	var disks []types.Disk
+	for _, d := range cfg.Storage.Disks {
+		if !skip("disk", d.Device, d.If) {
+			disks = append(disks, d)
+		}
+	}
+	cfg.Storage.Disks = disks
+
+	var files []types.File
+	for _, f := range cfg.Storage.Files {
+		if !skip("file", f.Path, f.If) {
+			files = append(files, f)
+		}
+	}
+	cfg.Storage.Files = files
+
+	var dirs []types.Directory
+	for _, d := range cfg.Storage.Directories {
+		if !skip("directory", d.Path, d.If) {
+			dirs = append(dirs, d)
+		}
+	}
+	cfg.Storage.Directories = dirs
+
+	var links []types.Link
+	for _, l := range cfg.Storage.Links {
+		if !skip("link", l.Path, l.If) {
+			links = append(links, l)
+		}
+	}
+	cfg.Storage.Links = links
+
+	var units []types.Unit
+	for _, u := range cfg.Systemd.Units {
+		if !skip("unit", u.Name, u.If) {
+			units = append(units, u)
+		}
+	}
+	cfg.Systemd.Units = units
+
+	var networkdUnits []types.Networkdunit
+	for _, u := range cfg.Networkd.Units {
+		if !skip("networkd unit", u.Name, u.If) {
+			networkdUnits = append(networkdUnits, u)
+		}
+	}
+	cfg.Networkd.Units = networkdUnits
+
+	var networkdLinks []types.NetworkdLink
+	for _, l := range cfg.Networkd.Links {
+		if !skip("networkd link", l.Name, l.If) {
+			networkdLinks = append(networkdLinks, l)
+		}
+	}
+	cfg.Networkd.Links = networkdLinks
+
+	var bonds []types.NetworkdBond
+	for _, b := range cfg.Networkd.Bonds {
+		if !skip("bond", b.Name, b.If) {
+			bonds = append(bonds, b)
+		}
+	}
+	cfg.Networkd.Bonds = bonds
+
+	var bridges []types.NetworkdBridge
+	for _, b := range cfg.Networkd.Bridges {
+		if !skip("bridge", b.Name, b.If) {
+			bridges = append(bridges, b)
+		}
+	}
+	cfg.Networkd.Bridges = bridges
+
+	var vlans []types.NetworkdVLAN
+	for _, v := range cfg.Networkd.Vlans {
+		if !skip("VLAN", v.Name, v.If) {
+			vlans = append(vlans, v)
+		}
+	}
+	cfg.Networkd.Vlans = vlans
+
+	var wireGuard []types.NetworkdWireGuard
+	for _, w := range cfg.Networkd.WireGuard {
+		if !skip("WireGuard interface", w.Name, w.If) {
+			wireGuard = append(wireGuard, w)
+		}
+	}
+	cfg.Networkd.WireGuard = wireGuard
+
+	return cfg
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestFilterConditional(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-conditions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cmdline := filepath.Join(dir, "cmdline")
+	os.Setenv("IGNITION_KERNEL_CMDLINE_PATH", cmdline)
+	defer os.Unsetenv("IGNITION_KERNEL_CMDLINE_PATH")
+
+	cfg := types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Node: types.Node{Path: "/etc/always"}},
+				{Node: types.Node{Path: "/etc/metal", If: &types.Condition{Platform: "metal"}}},
+				{Node: types.Node{Path: "/etc/ec2", If: &types.Condition{Platform: "ec2"}}},
+			},
+			Directories: []types.Directory{
+				{Node: types.Node{Path: "/var/gpu", If: &types.Condition{Variant: "gpu"}}},
+			},
+		},
+		Systemd: types.Systemd{
+			Units: []types.Unit{
+				{Name: "east.service", If: &types.Condition{Metadata: map[string]string{"REGION": "us-east1"}}},
+				{Name: "west.service", If: &types.Condition{Platform: "metal", Metadata: map[string]string{"REGION": "us-west1"}}},
+			},
+		},
+	}
+
+	type in struct {
+		cmdline  string
+		metadata map[string]string
+		err      error
+	}
+	type out struct {
+		files       []string
+		directories []string
+		units       []string
+		fetches     int
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{cmdline: "root=/dev/sda1"},
+			out: out{files: []string{"/etc/always", "/etc/metal"}, fetches: 1},
+		},
+		{
+			in:  in{cmdline: "root=/dev/sda1 ignition.variant=gpu", metadata: map[string]string{"REGION": "us-east1"}},
+			out: out{files: []string{"/etc/always", "/etc/metal"}, directories: []string{"/var/gpu"}, units: []string{"east.service"}, fetches: 1},
+		},
+		{
+			in:  in{metadata: map[string]string{"REGION": "us-west1"}},
+			out: out{files: []string{"/etc/always", "/etc/metal"}, units: []string{"west.service"}, fetches: 1},
+		},
+		{
+			// conditions on the metadata don't hold if it can't be fetched
+			in:  in{metadata: map[string]string{"REGION": "us-west1"}, err: fmt.Errorf("metadata service unreachable")},
+			out: out{files: []string{"/etc/always", "/etc/metal"}, fetches: 1},
+		},
+	}
+
+	for i, test := range tests {
+		if err := ioutil.WriteFile(cmdline, []byte(test.in.cmdline), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fetches := 0
+		logger := log.New(true)
+		e := Engine{
+			Logger:    &logger,
+			Fetcher:   &resource.Fetcher{Logger: &logger},
+			OEMConfig: oem.MustGet("metal"),
+			fetchMetadata: func(f *resource.Fetcher) (map[string]string, error) {
+				fetches++
+				if test.in.err != nil {
+					return nil, test.in.err
+				}
+				return test.in.metadata, nil
+			},
+		}
+		filtered := e.filterConditional(cfg)
+
+		var files, directories, units []string
+		for _, f := range filtered.Storage.Files {
+			files = append(files, f.Path)
+		}
+		for _, d := range filtered.Storage.Directories {
+			directories = append(directories, d.Path)
+		}
+		for _, u := range filtered.Systemd.Units {
+			units = append(units, u.Name)
+		}
+		got := out{files: files, directories: directories, units: units, fetches: fetches}
+		if !reflect.DeepEqual(test.out, got) {
+			t.Errorf("#%d: bad result: want %+v, got %+v", i, test.out, got)
+		}
+	}
+
+	// the metadata isn't fetched without conditions on it
+	logger := log.New(true)
+	e := Engine{
+		Logger:    &logger,
+		Fetcher:   &resource.Fetcher{Logger: &logger},
+		OEMConfig: oem.MustGet("metal"),
+		fetchMetadata: func(f *resource.Fetcher) (map[string]string, error) {
+			t.Error("metadata fetched without conditions on it")
+			return nil, nil
+		},
+	}
+	e.filterConditional(types.Config{Storage: cfg.Storage})
+}
@@ -0,0 +1,316 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/confine"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+const (
+	// confinedStageEnv names the stage a confined child process runs. The
+	// child reads the config from stdin.
+	confinedStageEnv = "IGNITION_CONFINED_STAGE"
+	// oemMountPathEnv tells the child where the OEM partition is mounted.
+	oemMountPathEnv = "IGNITION_OEM_MOUNT_PATH"
+)
+
+// InConfinedStage reports whether this process is the confined child of
+// another Ignition process.
+func InConfinedStage() bool {
+	return os.Getenv(confinedStageEnv) != ""
+}
+
+// confines reports whether the stage of the given name is run confined.
+func (e Engine) confines(stageName string) bool {
+	return stageName == "files" && distro.ConfineFiles()
+}
+
+// runConfined runs the stage in a child process which may only write to the
+// filesystems the config writes to and can't reconfigure the system. As the
+// child can't mount filesystems, this process mounts the filesystems and the
+// OEM partition for it.
+func (e Engine) runConfined(stageName string, cfg types.Config) error {
+	cfg, cleanup, err := e.mountFilesystems(cfg)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("/proc/self/exe", os.Args[1:]...)
+	cmd.Env = append(os.Environ(), confinedStageEnv+"="+stageName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	oemMountPath, err := e.mountOEMIfNeeded(cfg)
+	if err != nil {
+		return err
+	}
+	if oemMountPath != "" {
+		defer os.Remove(oemMountPath)
+		defer e.Fetcher.UmountOEM(oemMountPath)
+		cmd.Env = append(cmd.Env, oemMountPathEnv+"="+oemMountPath)
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(b)
+
+	// the child gets a temporary directory of its own for its downloads
+	// instead of all of /tmp
+	tmpDir, err := ioutil.TempDir("", "ignition-"+stageName)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	cmd.Env = append(cmd.Env, "TMPDIR="+tmpDir)
+
+	policy := confinedPolicy(cfg, oemMountPath, tmpDir)
+	for _, dir := range runDirs() {
+		// landlock only allows writes beneath directories which exist
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	res, err := confine.Start(cmd, policy)
+	if err != nil {
+		return fmt.Errorf("failed to start confined %s stage: %v", stageName, err)
+	}
+	if res.LandlockABI == 0 {
+		e.Logger.Warning("kernel doesn't support landlock, writes of the %s stage are not restricted", stageName)
+	} else {
+		e.Logger.Info("%s stage may only write to %v (landlock ABI %d)", stageName, policy.WritableDirs, res.LandlockABI)
+	}
+	if !res.Seccomp {
+		e.Logger.Warning("no seccomp filter for %s, system calls of the %s stage are not restricted", runtime.GOARCH, stageName)
+	}
+
+	// pass on the cancellation, so that the child stops its fetches and
+	// helpers itself before it's waited for
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-e.Fetcher.BaseContext().Done():
+			cmd.Process.Signal(syscall.SIGTERM)
+		case <-done:
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("confined %s stage failed: %v", stageName, err)
+	}
+	return nil
+}
+
+// runDirs are the directories under /run the confined stage writes to.
+func runDirs() []string {
+	return []string{
+		filepath.Dir(distro.RebootRequestPath()),
+		filepath.Dir(distro.MetadataAttributesPath()),
+	}
+}
+
+// confinedPolicy is the policy of the confined stage: it may write to the
+// filesystems of the config, the OEM partition if the config writes to it,
+// its own directories under /run and the given temporary directory.
+func confinedPolicy(cfg types.Config, oemMountPath, tmpDir string) confine.Policy {
+	policy := confine.Policy{
+		WritableDirs:  append(runDirs(), tmpDir),
+		WritableFiles: []string{"/dev/null"},
+	}
+	for _, fs := range cfg.Storage.Filesystems {
+		if fs.Path != nil {
+			policy.WritableDirs = append(policy.WritableDirs, *fs.Path)
+		}
+	}
+	if oemMountPath != "" && cfg.Storage.OEM != nil {
+		policy.WritableDirs = append(policy.WritableDirs, oemMountPath)
+	}
+	return policy
+}
+
+// runConfinedChild runs the stage of the given name with the config read from
+// stdin. It is run in the child process started by runConfined.
+func (e Engine) runConfinedChild(stageName string) error {
+	var cfg types.Config
+	if err := json.NewDecoder(os.Stdin).Decode(&cfg); err != nil {
+		e.Logger.Crit("failed to read config: %v", err)
+		return err
+	}
+	err := e.updateFetcher(cfg)
+	if err != nil {
+		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
+		return err
+	}
+	e.Fetcher.OEMMountPath = os.Getenv(oemMountPathEnv)
+
+	e.Logger.PushPrefix(stageName)
+	defer e.Logger.PopPrefix()
+	e.Logger.Info("running confined")
+	return stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher).Run(cfg)
+}
+
+// mountFilesystems mounts the filesystems without a path which the config
+// writes to, and returns a config referring to them by their mount points.
+// The returned function unmounts them again.
+func (e Engine) mountFilesystems(cfg types.Config) (types.Config, func(), error) {
+	var mountPoints []string
+	cleanup := func() {
+		for _, mnt := range mountPoints {
+			e.Logger.LogOp(
+				func() error { return syscall.Unmount(mnt, 0) },
+				"unmounting %q", mnt,
+			)
+			os.Remove(mnt)
+		}
+	}
+
+	used := map[string]bool{}
+	for _, entry := range storageEntries(cfg.Storage) {
+		used[entry.filesystem] = true
+	}
+	// like the files stage, only use the last definition of a filesystem
+	last := map[string]types.Filesystem{}
+	for _, fs := range cfg.Storage.Filesystems {
+		last[fs.Name] = fs
+	}
+
+	filesystems := make([]types.Filesystem, len(cfg.Storage.Filesystems))
+	copy(filesystems, cfg.Storage.Filesystems)
+	u := util.Util{Logger: e.Logger}
+	for name, fs := range last {
+		if !used[name] || fs.Path != nil || fs.Mount == nil {
+			continue
+		}
+		mnt, err := ioutil.TempDir("", "ignition-files")
+		if err != nil {
+			return cfg, cleanup, fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		if err := u.MountAuto(string(fs.Mount.Device), mnt); err != nil {
+			os.Remove(mnt)
+			return cfg, cleanup, err
+		}
+		mountPoints = append(mountPoints, mnt)
+		for i := range filesystems {
+			if filesystems[i].Name == name {
+				filesystems[i] = types.Filesystem{Name: name, Path: &mnt}
+			}
+		}
+	}
+	cfg.Storage.Filesystems = filesystems
+	return cfg, cleanup, nil
+}
+
+// storageEntry is the filesystem and path of an entry of the storage section.
+type storageEntry struct {
+	filesystem string
+	path       string
+}
+
+// storageEntries lists the entries of every storage section which writes to
+// a filesystem: files, directories, links, edits, archives and manifests,
+// whose files are written below the manifest's path.
+func storageEntries(s types.Storage) []storageEntry {
+	var entries []storageEntry
+	for _, n := range s.Files {
+		entries = append(entries, storageEntry{n.Filesystem, n.Path})
+	}
+	for _, n := range s.Directories {
+		entries = append(entries, storageEntry{n.Filesystem, n.Path})
+	}
+	for _, n := range s.Links {
+		entries = append(entries, storageEntry{n.Filesystem, n.Path})
+	}
+	for _, e := range s.Edits {
+		entries = append(entries, storageEntry{e.Filesystem, e.Path})
+	}
+	for _, a := range s.Archives {
+		entries = append(entries, storageEntry{a.Filesystem, a.Path})
+	}
+	for _, m := range s.Manifests {
+		entries = append(entries, storageEntry{m.Filesystem, m.Path})
+	}
+	return entries
+}
+
+// mountOEMIfNeeded mounts the OEM partition if the config writes to it or
+// references files on it which aren't in the OEM lookaside directory, and
+// returns the mount point.
+func (e Engine) mountOEMIfNeeded(cfg types.Config) (string, error) {
+	needed := cfg.Storage.OEM != nil
+	forEachSource(reflect.ValueOf(cfg), func(source string) {
+		u, err := url.Parse(source)
+		if err != nil || u.Scheme != "oem" {
+			return
+		}
+		if _, err := os.Stat(filepath.Join(distro.OEMLookasideDir(), filepath.Clean(u.Path))); err != nil {
+			needed = true
+		}
+	})
+	if !needed {
+		return "", nil
+	}
+
+	mnt, err := ioutil.TempDir("/mnt", "oem")
+	if err != nil {
+		return "", fmt.Errorf("failed to create mount path for oem partition: %v", err)
+	}
+	if err := e.Fetcher.MountOEM(mnt); err != nil {
+		os.Remove(mnt)
+		return "", fmt.Errorf("failed to mount oem partition: %v", err)
+	}
+	return mnt, nil
+}
+
+// forEachSource calls f with the source of every resource in v.
+func forEachSource(v reflect.Value, f func(string)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.Name == "Source" && field.Type.Kind() == reflect.String {
+				f(v.Field(i).String())
+			} else {
+				forEachSource(v.Field(i), f)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			forEachSource(v.Index(i), f)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			forEachSource(v.Elem(), f)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestForEachSource(t *testing.T) {
+	cfg := types.Config{
+		Ignition: types.Ignition{
+			Config: types.IgnitionConfig{
+				Replace: &types.ConfigReference{Source: "https://example.com/config.ign"},
+			},
+		},
+		Storage: types.Storage{
+			Files: []types.File{
+				{FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "oem:///grub.cfg"}}},
+				{FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "data:,"}}},
+			},
+		},
+		Systemd: types.Systemd{
+			Units: []types.Unit{{Name: "oem.service", Contents: "oem:///not-a-source"}},
+		},
+	}
+
+	var sources []string
+	forEachSource(reflect.ValueOf(cfg), func(s string) {
+		sources = append(sources, s)
+	})
+	want := []string{"https://example.com/config.ign", "oem:///grub.cfg", "data:,"}
+	if !reflect.DeepEqual(want, sources) {
+		t.Errorf("bad sources: want %q, got %q", want, sources)
+	}
+}
+
+func TestStorageEntries(t *testing.T) {
+	storage := types.Storage{
+		Files:       []types.File{{Node: types.Node{Filesystem: "root", Path: "/etc/motd"}}},
+		Directories: []types.Directory{{Node: types.Node{Filesystem: "root", Path: "/opt/app"}}},
+		Links:       []types.Link{{Node: types.Node{Filesystem: "root", Path: "/opt/current"}}},
+		Edits:       []types.Edit{{Filesystem: "data", Path: "/app.conf"}},
+		Archives:    []types.Archive{{Filesystem: "var", Path: "/lib/app"}},
+		Manifests:   []types.Manifest{{Filesystem: "oem", Path: "/"}},
+		UdevRules:   []types.UdevRule{{Name: "disk-owner"}},
+	}
+
+	want := []storageEntry{
+		{"root", "/etc/motd"},
+		{"root", "/opt/app"},
+		{"root", "/opt/current"},
+		{"data", "/app.conf"},
+		{"var", "/lib/app"},
+		{"oem", "/"},
+	}
+	if got := storageEntries(storage); !reflect.DeepEqual(want, got) {
+		t.Errorf("bad entries: want %v, got %v", want, got)
+	}
+}
+
+func TestMountOEMIfNeeded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oem-lookaside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "grub.cfg"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("IGNITION_OEM_LOOKASIDE_DIR", dir)
+	defer os.Unsetenv("IGNITION_OEM_LOOKASIDE_DIR")
+
+	// nothing has to be mounted if the files are in the lookaside
+	// directory, or no files are fetched from the OEM partition at all
+	tests := []types.Config{
+		{},
+		{Storage: types.Storage{Files: []types.File{
+			{FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "oem:///grub.cfg"}}},
+			{FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "https://example.com/oem:///missing"}}},
+		}}},
+	}
+
+	logger := log.New(true)
+	e := Engine{Logger: &logger}
+	for i, cfg := range tests {
+		mnt, err := e.mountOEMIfNeeded(cfg)
+		if err != nil || mnt != "" {
+			t.Errorf("#%d: unexpected mount %q: %v", i, mnt, err)
+		}
+	}
+}
+
+func TestConfinedPolicy(t *testing.T) {
+	os.Setenv("IGNITION_REBOOT_REQUEST_PATH", "/run/ignition/reboot-request.json")
+	defer os.Unsetenv("IGNITION_REBOOT_REQUEST_PATH")
+	os.Setenv("IGNITION_METADATA_PATH", "/run/metadata/ignition")
+	defer os.Unsetenv("IGNITION_METADATA_PATH")
+
+	root := "/sysroot"
+	cfg := types.Config{Storage: types.Storage{
+		Filesystems: []types.Filesystem{{Name: "root", Path: &root}, {Name: "data"}},
+		OEM:         &types.OEM{},
+	}}
+	tests := []struct {
+		cfg          types.Config
+		oemMountPath string
+		want         []string
+	}{
+		{
+			cfg:  types.Config{},
+			want: []string{"/run/ignition", "/run/metadata", "/tmp/ignition-files1"},
+		},
+		{
+			cfg:          cfg,
+			oemMountPath: "/oem-mnt",
+			want:         []string{"/run/ignition", "/run/metadata", "/tmp/ignition-files1", "/sysroot", "/oem-mnt"},
+		},
+		// the OEM partition is only writable if the config writes to it
+		{
+			cfg:          types.Config{},
+			oemMountPath: "/oem-mnt",
+			want:         []string{"/run/ignition", "/run/metadata", "/tmp/ignition-files1"},
+		},
+	}
+
+	for i, test := range tests {
+		policy := confinedPolicy(test.cfg, test.oemMountPath, "/tmp/ignition-files1")
+		if !reflect.DeepEqual(test.want, policy.WritableDirs) {
+			t.Errorf("#%d: bad writable dirs: want %v, got %v", i, test.want, policy.WritableDirs)
+		}
+		if !reflect.DeepEqual([]string{"/dev/null"}, policy.WritableFiles) {
+			t.Errorf("#%d: bad writable files: %v", i, policy.WritableFiles)
+		}
+	}
+}
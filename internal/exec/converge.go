@@ -0,0 +1,986 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/verify"
+)
+
+// maxDiffSize bounds the size of the files converge shows diffs of.
+const maxDiffSize = 64 * 1024
+
+// convergeChange is a change converging makes to the system.
+type convergeChange struct {
+	action   string
+	subject  string
+	details  []string
+	diff     string
+	conflict bool
+}
+
+func (c convergeChange) String() string {
+	s := c.action + " " + c.subject
+	if len(c.details) > 0 {
+		s += ": " + strings.Join(c.details, ", ")
+	}
+	if c.diff != "" {
+		s += "\n" + strings.TrimSuffix(c.diff, "\n")
+	}
+	return s
+}
+
+// convergePlan collects the changes converging makes and the parts of the
+// config which have to be applied to make them.
+type convergePlan struct {
+	e       Engine
+	u       util.Util
+	changes []convergeChange
+	// fixups change the metadata of nodes whose contents are unchanged
+	fixups []func() error
+	// enable maps the units whose enablement changes to their new state
+	enable map[string]bool
+	apply  types.Config
+}
+
+// Converge applies the storage nodes, units and passwd section of the
+// config in rawConfig to the running system, changing only what differs
+// from the config. Everything else in the config, like disks, is ignored.
+// The changes are described on out; unless apply is set, they are only
+// described.
+func (e Engine) Converge(rawConfig []byte, apply bool, out io.Writer) error {
+	if e.Fetcher == nil || e.Logger == nil {
+		return errors.ErrEngineConfiguration
+	}
+	cfg, r, err := config.Parse(rawConfig)
+	e.logReport(r)
+	if err != nil {
+		return err
+	}
+	if err := e.updateFetcher(cfg); err != nil {
+		return err
+	}
+	if cfg, err = e.renderConfig(cfg); err != nil {
+		return err
+	}
+	cfg = e.filterConditional(cfg)
+
+	cfg, ignored, err := convergeScope(cfg)
+	if err != nil {
+		return err
+	}
+	for _, section := range ignored {
+		fmt.Fprintf(out, "ignoring %s\n", section)
+	}
+
+	plan, err := e.planConvergence(cfg)
+	if err != nil {
+		return err
+	}
+	conflict := false
+	for _, c := range plan.changes {
+		fmt.Fprintln(out, c)
+		conflict = conflict || c.conflict
+	}
+	if conflict {
+		return errors.ErrConvergeConflict
+	}
+	if len(plan.changes) == 0 {
+		fmt.Fprintln(out, "nothing to change")
+		return nil
+	}
+	if !apply {
+		return nil
+	}
+	return plan.run()
+}
+
+// convergeApplied lists the sections of a config converge applies, or which
+// configure how it fetches, by their JSON paths. Any other section set in a
+// config is reported as ignored.
+var convergeApplied = map[string]bool{
+	"ignition.annotations": true,
+	"ignition.config":      true,
+	"ignition.lint":        true,
+	"ignition.proxy":       true,
+	"ignition.rateLimit":   true,
+	"ignition.security":    true,
+	"ignition.timeouts":    true,
+	"ignition.version":     true,
+	"networkd":             true,
+	"passwd":               true,
+	"storage.directories":  true,
+	"storage.files":        true,
+	"storage.links":        true,
+	"systemd.units":        true,
+}
+
+// convergeScope returns the part of cfg converge applies, along with the
+// sections it ignores. Nodes on filesystems other than the root filesystem
+// are refused.
+func convergeScope(cfg types.Config) (types.Config, []string, error) {
+	ignored := convergeIgnored(reflect.ValueOf(cfg), "")
+	sort.Strings(ignored)
+
+	var foreign []string
+	check := func(n types.Node) {
+		if n.Filesystem != "root" {
+			foreign = append(foreign, n.Path)
+		}
+	}
+	for _, f := range cfg.Storage.Files {
+		check(f.Node)
+	}
+	for _, d := range cfg.Storage.Directories {
+		check(d.Node)
+	}
+	for _, l := range cfg.Storage.Links {
+		check(l.Node)
+	}
+	if len(foreign) > 0 {
+		return types.Config{}, nil, fmt.Errorf("converge only writes to the root filesystem, not %s", strings.Join(foreign, ", "))
+	}
+
+	return types.Config{
+		Ignition: types.Ignition{Version: cfg.Ignition.Version},
+		Storage: types.Storage{
+			Files:       cfg.Storage.Files,
+			Directories: cfg.Storage.Directories,
+			Links:       cfg.Storage.Links,
+		},
+		Systemd:  cfg.Systemd,
+		Networkd: cfg.Networkd,
+		Passwd:   cfg.Passwd,
+	}, ignored, nil
+}
+
+// convergeIgnored returns the JSON paths of the fields of the struct v which
+// are set, but neither listed in convergeApplied nor parents of fields which
+// are. prefix is the path of v.
+func convergeIgnored(v reflect.Value, prefix string) []string {
+	var ignored []string
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		section := prefix + name
+		if convergeApplied[section] {
+			continue
+		}
+		partial := false
+		for applied := range convergeApplied {
+			partial = partial || strings.HasPrefix(applied, section+".")
+		}
+		field := v.Field(i)
+		switch {
+		case partial:
+			ignored = append(ignored, convergeIgnored(field, section+".")...)
+		case !field.IsZero() && !((field.Kind() == reflect.Slice || field.Kind() == reflect.Map) && field.Len() == 0):
+			ignored = append(ignored, section)
+		}
+	}
+	return ignored
+}
+
+// planConvergence compares cfg against the system at e.Root.
+func (e Engine) planConvergence(cfg types.Config) (*convergePlan, error) {
+	p := &convergePlan{
+		e: e,
+		u: util.Util{
+			DestDir: e.Root,
+			Fetcher: *e.Fetcher,
+			Logger:  e.Logger,
+		},
+		enable: map[string]bool{},
+		apply:  types.Config{Ignition: cfg.Ignition},
+	}
+	if err := p.planPasswd(cfg.Passwd); err != nil {
+		return nil, err
+	}
+	for _, d := range cfg.Storage.Directories {
+		if err := p.planDirectory(d); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range cfg.Storage.Files {
+		op := p.u.PrepareFetch(e.Logger, f)
+		if op == nil {
+			return nil, fmt.Errorf("failed to resolve file %q", f.Path)
+		}
+		changed, err := p.planFile(op, fmt.Sprintf("file %q", f.Path))
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			p.apply.Storage.Files = append(p.apply.Storage.Files, f)
+		}
+	}
+	for _, l := range cfg.Storage.Links {
+		if err := p.planLink(l); err != nil {
+			return nil, err
+		}
+	}
+	for _, unit := range cfg.Systemd.Units {
+		if err := p.planUnit(unit); err != nil {
+			return nil, err
+		}
+	}
+	for _, unit := range cfg.Networkd.Units {
+		if err := p.planNetworkdUnit(util.NetworkdUnitWithRoutes(unit)); err != nil {
+			return nil, err
+		}
+	}
+	for _, link := range cfg.Networkd.Links {
+		if err := p.planNetworkdUnit(util.NetworkdLinkUnit(link)); err != nil {
+			return nil, err
+		}
+	}
+	for _, wg := range cfg.Networkd.WireGuard {
+		f := util.WireGuardKeyFile(wg)
+		op := p.u.PrepareFetch(e.Logger, f)
+		if op == nil {
+			return nil, fmt.Errorf("failed to resolve the private key of %q", wg.Name)
+		}
+		changed, err := p.planFile(op, fmt.Sprintf("private key of %q", wg.Name))
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			p.apply.Storage.Files = append(p.apply.Storage.Files, f)
+		}
+	}
+	for _, unit := range util.NetworkdNetdevUnits(cfg.Networkd) {
+		if err := p.planNetworkdUnit(unit); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *convergePlan) add(c convergeChange) {
+	p.changes = append(p.changes, c)
+}
+
+// planFile compares the file described by op against the system and
+// returns whether it has to be written. Changes to only its metadata are
+// made by a fixup instead.
+func (p *convergePlan) planFile(op *util.FetchOp, subject string) (bool, error) {
+	path, err := p.u.JoinPath(op.Path)
+	if err != nil {
+		return false, err
+	}
+	tmp, err := ioutil.TempFile("", "ignition-converge")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := p.u.FetchContents(op, tmp); err != nil {
+		return false, err
+	}
+	if op.Merge != "" {
+		if err := p.u.MergeInto(op, tmp); err != nil {
+			return false, err
+		}
+	}
+
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		p.add(convergeChange{action: "create", subject: subject})
+		return true, nil
+	case err != nil:
+		return false, err
+	case !info.Mode().IsRegular():
+		if op.Append || (op.Overwrite != nil && !*op.Overwrite) {
+			p.add(convergeChange{action: "refuse to write", subject: subject, details: []string{"something else exists at that path"}, conflict: true})
+			return false, nil
+		}
+		p.add(convergeChange{action: "replace", subject: subject, details: []string{"not a file"}})
+		return true, nil
+	}
+
+	uid, gid, mode := fileOwnerAndMode(info)
+	var details []string
+	var diff string
+	if op.Append && op.AppendMarkers != nil {
+		contains, block, err := fileContainsBlock(path, tmp.Name(), op.Path, *op.AppendMarkers)
+		if err != nil {
+			return false, err
+		}
+		if !contains {
+			p.add(convergeChange{action: "append to", subject: subject, diff: block})
+			// appending sets the metadata too
+			return true, nil
+		}
+	} else if op.Append {
+		contains, appended, err := fileContains(path, tmp.Name())
+		if err != nil {
+			return false, err
+		}
+		if !contains {
+			p.add(convergeChange{action: "append to", subject: subject, diff: appended})
+			// appending sets the metadata too
+			return true, nil
+		}
+	} else {
+		same, d, err := compareFiles(path, tmp.Name(), op.Path)
+		if err != nil {
+			return false, err
+		}
+		if !same {
+			if op.Overwrite != nil && !*op.Overwrite {
+				p.add(convergeChange{action: "refuse to write", subject: subject, details: []string{"its contents differ and overwrite is false"}, conflict: true})
+				return false, nil
+			}
+			details = append(details, "contents")
+			diff = d
+		}
+		// files are written with the given mode and owned by root by
+		// default, merged files keep theirs
+		if op.Merge == "" {
+			if op.Mode == nil {
+				op.Mode = configUtil.IntToPtr(0)
+			}
+			uid, gid = 0, 0
+		}
+	}
+
+	if op.Mode != nil && os.FileMode(*op.Mode).Perm() != mode {
+		details = append(details, fmt.Sprintf("mode %04o -> %04o", mode, os.FileMode(*op.Mode).Perm()))
+	}
+	details = append(details, p.ownerChanges(op.Node, path, uid, gid)...)
+	changedXattrs, err := util.XattrsChanged(path, op.Xattrs)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range changedXattrs {
+		details = append(details, fmt.Sprintf("xattr %s", name))
+	}
+	if len(details) == 0 {
+		return false, nil
+	}
+	p.add(convergeChange{action: "update", subject: subject, details: details, diff: diff})
+	if diff != "" {
+		return true, nil
+	}
+	node, wantMode, defaultUID, defaultGID, xattrs := op.Node, op.Mode, uid, gid, op.Xattrs
+	p.fixups = append(p.fixups, func() error {
+		if err := p.setMetadata(node, path, wantMode, defaultUID, defaultGID); err != nil {
+			return err
+		}
+		// changing the owner drops capabilities, so they're set again
+		return util.SetXattrs(path, xattrs)
+	})
+	return false, nil
+}
+
+func (p *convergePlan) planDirectory(d types.Directory) error {
+	subject := fmt.Sprintf("directory %q", d.Path)
+	path, err := p.u.JoinPath(d.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		p.add(convergeChange{action: "create", subject: subject})
+		p.apply.Storage.Directories = append(p.apply.Storage.Directories, d)
+		return nil
+	case err != nil:
+		return err
+	case !info.IsDir():
+		if d.Overwrite == nil || !*d.Overwrite {
+			p.add(convergeChange{action: "refuse to write", subject: subject, details: []string{"something else exists at that path"}, conflict: true})
+			return nil
+		}
+		p.add(convergeChange{action: "replace", subject: subject, details: []string{"not a directory"}})
+		p.apply.Storage.Directories = append(p.apply.Storage.Directories, d)
+		return nil
+	}
+
+	uid, gid, mode := fileOwnerAndMode(info)
+	want := configUtil.IntToPtr(0)
+	if d.Mode != nil {
+		want = d.Mode
+	}
+	var details []string
+	if os.FileMode(*want).Perm() != mode {
+		details = append(details, fmt.Sprintf("mode %04o -> %04o", mode, os.FileMode(*want).Perm()))
+	}
+	details = append(details, p.ownerChanges(d.Node, path, uid, gid)...)
+	if len(details) > 0 {
+		p.add(convergeChange{action: "update", subject: subject, details: details})
+		p.fixups = append(p.fixups, func() error {
+			return p.setMetadata(d.Node, path, want, 0, 0)
+		})
+	}
+	return nil
+}
+
+func (p *convergePlan) planLink(l types.Link) error {
+	subject := fmt.Sprintf("link %q", l.Path)
+	path, err := p.u.JoinPath(l.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		p.add(convergeChange{action: "create", subject: subject, details: []string{"-> " + l.Target}})
+		p.apply.Storage.Links = append(p.apply.Storage.Links, l)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	var current string
+	if l.Hard {
+		target, err := p.u.JoinPath(l.Target)
+		if err != nil {
+			return err
+		}
+		if targetInfo, err := os.Stat(target); err == nil && os.SameFile(info, targetInfo) {
+			return nil
+		}
+		current = "another file"
+	} else if info.Mode()&os.ModeSymlink != 0 {
+		if current, err = os.Readlink(path); err != nil {
+			return err
+		}
+		if current == l.Target {
+			return nil
+		}
+	} else {
+		current = "not a link"
+	}
+
+	if l.Overwrite == nil || !*l.Overwrite {
+		p.add(convergeChange{action: "refuse to write", subject: subject, details: []string{fmt.Sprintf("it points to %s and overwrite is false", current)}, conflict: true})
+		return nil
+	}
+	p.add(convergeChange{action: "replace", subject: subject, details: []string{fmt.Sprintf("%s -> %s", current, l.Target)}})
+	p.apply.Storage.Links = append(p.apply.Storage.Links, l)
+	return nil
+}
+
+func (p *convergePlan) planUnit(unit types.Unit) error {
+	subject := fmt.Sprintf("unit %q", unit.Name)
+	if distro.InitSystem() != "systemd" {
+		// the init scripts are translated, so there's nothing to compare
+		p.add(convergeChange{action: "write", subject: subject, details: []string{"translated for " + distro.InitSystem()}})
+		p.apply.Systemd.Units = append(p.apply.Systemd.Units, unit)
+		return nil
+	}
+
+	apply := types.Unit{Name: unit.Name}
+	if unit.Contents != "" {
+		op, err := util.FileFromSystemdUnit(unit, false)
+		if err != nil {
+			return err
+		}
+		changed, err := p.planFile(op, subject)
+		if err != nil {
+			return err
+		}
+		if changed {
+			apply.Contents = unit.Contents
+		}
+	}
+	for _, dropin := range unit.Dropins {
+		if dropin.Contents == "" {
+			continue
+		}
+		op, err := util.FileFromSystemdUnitDropin(unit, dropin, false)
+		if err != nil {
+			return err
+		}
+		changed, err := p.planFile(op, fmt.Sprintf("drop-in %q of unit %q", dropin.Name, unit.Name))
+		if err != nil {
+			return err
+		}
+		if changed {
+			apply.Dropins = append(apply.Dropins, dropin)
+		}
+	}
+
+	if unit.Mask {
+		path, err := p.u.JoinPath(util.SystemdUnitsPath(), unit.Name)
+		if err != nil {
+			return err
+		}
+		if target, err := os.Readlink(path); err != nil || target != "/dev/null" {
+			p.add(convergeChange{action: "mask", subject: subject})
+			apply.Mask = true
+		}
+	}
+
+	if unit.Enable || unit.Enabled != nil {
+		want := unit.Enable || (unit.Enabled != nil && *unit.Enabled)
+		enabled, err := p.e.unitEnabled(unit.Name)
+		if err != nil {
+			return err
+		}
+		if enabled != want {
+			action := "enable"
+			if !want {
+				action = "disable"
+			}
+			p.add(convergeChange{action: action, subject: subject})
+			apply.Enabled = &want
+			p.enable[unit.Name] = want
+		}
+	}
+
+	if apply.Contents != "" || len(apply.Dropins) > 0 || apply.Mask || apply.Enabled != nil {
+		p.apply.Systemd.Units = append(p.apply.Systemd.Units, apply)
+	}
+	return nil
+}
+
+func (p *convergePlan) planNetworkdUnit(unit types.Networkdunit) error {
+	apply := types.Networkdunit{Name: unit.Name}
+	if unit.Contents != "" {
+		op, err := util.FileFromNetworkdUnit(unit)
+		if err != nil {
+			return err
+		}
+		changed, err := p.planFile(op, fmt.Sprintf("networkd unit %q", unit.Name))
+		if err != nil {
+			return err
+		}
+		if changed {
+			apply.Contents = unit.Contents
+		}
+	}
+	for _, dropin := range unit.Dropins {
+		if dropin.Contents == "" {
+			continue
+		}
+		op, err := util.FileFromNetworkdUnitDropin(unit, dropin)
+		if err != nil {
+			return err
+		}
+		changed, err := p.planFile(op, fmt.Sprintf("drop-in %q of networkd unit %q", dropin.Name, unit.Name))
+		if err != nil {
+			return err
+		}
+		if changed {
+			apply.Dropins = append(apply.Dropins, dropin)
+		}
+	}
+	if apply.Contents != "" || len(apply.Dropins) > 0 {
+		p.apply.Networkd.Units = append(p.apply.Networkd.Units, apply)
+	}
+	return nil
+}
+
+func (p *convergePlan) planPasswd(passwd types.Passwd) error {
+	if len(passwd.Sudoers) > 0 {
+		f := util.SudoersFile(passwd.Sudoers)
+		op := p.u.PrepareFetch(p.e.Logger, f)
+		if op == nil {
+			return fmt.Errorf("failed to resolve %q", f.Path)
+		}
+		changed, err := p.planFile(op, "sudoers entries")
+		if err != nil {
+			return err
+		}
+		if changed {
+			p.apply.Passwd.Sudoers = passwd.Sudoers
+		}
+	}
+	if passwd.Policy != nil {
+		apply := types.PasswdPolicy{}
+		for _, f := range util.PolicyFiles(*passwd.Policy) {
+			op := p.u.PrepareFetch(p.e.Logger, f)
+			if op == nil {
+				return fmt.Errorf("failed to resolve %q", f.Path)
+			}
+			changed, err := p.planFile(op, fmt.Sprintf("password policy in %q", f.Path))
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			switch f.Path {
+			case util.FaillockPath:
+				apply.Faillock = passwd.Policy.Faillock
+			case util.LoginDefsPath:
+				apply.PasswordAging = passwd.Policy.PasswordAging
+			}
+		}
+		if apply.Faillock != nil || apply.PasswordAging != nil {
+			p.apply.Passwd.Policy = &apply
+		}
+	}
+	if len(passwd.Users) == 0 && len(passwd.Groups) == 0 {
+		return nil
+	}
+	drift, err := verify.Passwd(p.e.Root, passwd)
+	if err != nil {
+		return err
+	}
+	groupDrift := map[string][]verify.Drift{}
+	userDrift := map[string][]verify.Drift{}
+	for _, d := range drift {
+		if d.Group != "" {
+			groupDrift[d.Group] = append(groupDrift[d.Group], d)
+		} else {
+			userDrift[d.User] = append(userDrift[d.User], d)
+		}
+	}
+
+	for _, g := range passwd.Groups {
+		subject := fmt.Sprintf("group %q", g.Name)
+		for _, d := range groupDrift[g.Name] {
+			if d.Kind == "missing" {
+				p.add(convergeChange{action: "create", subject: subject})
+				p.apply.Passwd.Groups = append(p.apply.Passwd.Groups, g)
+			} else {
+				p.add(convergeChange{action: "refuse to change", subject: subject, details: []string{fmt.Sprintf("%s %s -> %s of an existing group", d.Kind, d.Got, d.Want)}, conflict: true})
+			}
+		}
+	}
+
+	users, err := verify.ReadDatabase(filepath.Join(p.e.Root, "etc/passwd"), 7)
+	if err != nil {
+		return err
+	}
+	shadow, err := verify.ReadDatabase(filepath.Join(p.e.Root, "etc/shadow"), 3)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, u := range passwd.Users {
+		subject := fmt.Sprintf("user %q", u.Name)
+		var details []string
+		missing := false
+		for _, d := range userDrift[u.Name] {
+			if d.Kind == "missing" {
+				missing = true
+				break
+			}
+			details = append(details, fmt.Sprintf("%s %s -> %s", d.Kind, d.Got, d.Want))
+		}
+		if missing {
+			p.add(convergeChange{action: "create", subject: subject})
+			p.apply.Passwd.Users = append(p.apply.Passwd.Users, u)
+			continue
+		}
+		if u.PasswordHash != nil {
+			want := *u.PasswordHash
+			if want == "" {
+				want = "*"
+			}
+			if fields, ok := shadow[u.Name]; !ok || fields[1] != want {
+				details = append(details, "password hash")
+			}
+		}
+		if len(u.SSHAuthorizedKeys) > 0 {
+			changed, err := p.sshKeysChanged(u, users[u.Name][5])
+			if err != nil {
+				return err
+			}
+			if changed {
+				details = append(details, "SSH keys")
+			}
+		}
+		if len(details) > 0 {
+			p.add(convergeChange{action: "update", subject: subject, details: details})
+			p.apply.Passwd.Users = append(p.apply.Passwd.Users, u)
+		}
+	}
+	return nil
+}
+
+// sshKeysChanged returns whether the keys Ignition manages for u differ
+// from the ones in the config.
+func (p *convergePlan) sshKeysChanged(u types.PasswdUser, home string) (bool, error) {
+	var keys []string
+	for _, k := range u.SSHAuthorizedKeys {
+		keys = append(keys, string(k))
+	}
+	want := strings.Join(keys, "\n")
+	if !strings.HasSuffix(want, "\n") {
+		want += "\n"
+	}
+	path, err := p.u.JoinPath(home, ".ssh", "authorized_keys.d", "flatcar-ignition")
+	if err != nil {
+		return false, err
+	}
+	got, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(got) != want, nil
+}
+
+// ownerChanges describes how the owner of the node at path changes. Owners
+// which can't be resolved yet, e.g. because the user is only created by the
+// config, are assumed to change.
+func (p *convergePlan) ownerChanges(n types.Node, path string, defaultUID, defaultGID int) []string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	uid, gid, _ := fileOwnerAndMode(info)
+	wantUID, wantGID, err := p.u.ResolveNodeUidAndGid(n, defaultUID, defaultGID)
+	if err != nil {
+		return []string{"owner"}
+	}
+	if uid != wantUID || gid != wantGID {
+		return []string{fmt.Sprintf("owner %d:%d -> %d:%d", uid, gid, wantUID, wantGID)}
+	}
+	return nil
+}
+
+// setMetadata sets the owner and mode of the node at path.
+func (p *convergePlan) setMetadata(n types.Node, path string, mode *int, defaultUID, defaultGID int) error {
+	uid, gid, err := p.u.ResolveNodeUidAndGid(n, defaultUID, defaultGID)
+	if err != nil {
+		return err
+	}
+	if err := os.Chown(path, uid, gid); err != nil {
+		return err
+	}
+	if mode != nil {
+		return os.Chmod(path, os.FileMode(*mode))
+	}
+	return nil
+}
+
+// run applies the plan.
+func (p *convergePlan) run() error {
+	files := stages.Get("files")
+	if files == nil {
+		return fmt.Errorf("the files stage isn't built in")
+	}
+	p.apply = config.Append(types.Config{
+		Ignition: types.Ignition{Version: types.MaxVersion.String()},
+		Storage: types.Storage{
+			Filesystems: []types.Filesystem{{
+				Name: "root",
+				Path: configUtil.StrToPtr(p.e.Root),
+			}},
+		},
+	}, p.apply)
+	if err := files.Create(p.e.Logger, p.e.Root, *p.e.Fetcher).Run(p.apply); err != nil {
+		return err
+	}
+	for _, fixup := range p.fixups {
+		if err := fixup(); err != nil {
+			return err
+		}
+	}
+	if err := p.relabel(); err != nil {
+		return err
+	}
+	if distro.InitSystem() != "systemd" || (len(p.apply.Systemd.Units) == 0 && len(p.apply.Networkd.Units) == 0) {
+		return nil
+	}
+	if _, err := p.u.LogCmd(exec.Command(distro.SystemctlCmd(), "daemon-reload"), "reloading systemd"); err != nil {
+		return err
+	}
+	for name, enable := range p.enable {
+		verb, msg := "enable", "enabling unit %q"
+		if !enable {
+			verb, msg = "disable", "disabling unit %q"
+		}
+		if _, err := p.u.LogCmd(exec.Command(distro.SystemctlCmd(), verb, name), msg, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relabel relabels the files listed by the files stage right away, as the
+// unit it added for relabeling only runs at boot.
+func (p *convergePlan) relabel() error {
+	path, err := p.u.JoinPath("etc/selinux/ignition.relabel")
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := p.u.LogCmd(exec.Command(distro.RestoreconCmd(), "-0vRif", path), "relabeling files"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// unitEnabled returns whether systemd enables the unit of the given name.
+func (e Engine) unitEnabled(name string) (bool, error) {
+	cmd := exec.Command(distro.SystemctlCmd(), "--root", e.Root, "is-enabled", name)
+	out, err := cmd.Output()
+	state := strings.TrimSpace(string(out))
+	if state == "" && err != nil {
+		return false, fmt.Errorf("failed to check whether %q is enabled: %v", name, err)
+	}
+	return state == "enabled", nil
+}
+
+func fileOwnerAndMode(info os.FileInfo) (int, int, os.FileMode) {
+	uid, gid := 0, 0
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		uid, gid = int(st.Uid), int(st.Gid)
+	}
+	return uid, gid, info.Mode().Perm()
+}
+
+// compareFiles returns whether the files at current and want have the same
+// contents, and a diff of them if they're small text files.
+func compareFiles(current, want, name string) (bool, string, error) {
+	currentSum, currentSize, err := fileDigest(current)
+	if err != nil {
+		return false, "", err
+	}
+	wantSum, wantSize, err := fileDigest(want)
+	if err != nil {
+		return false, "", err
+	}
+	if currentSum == wantSum {
+		return true, "", nil
+	}
+	if currentSize > maxDiffSize || wantSize > maxDiffSize {
+		return false, "", nil
+	}
+	a, err := ioutil.ReadFile(current)
+	if err != nil {
+		return false, "", err
+	}
+	b, err := ioutil.ReadFile(want)
+	if err != nil {
+		return false, "", err
+	}
+	if !isText(a) || !isText(b) {
+		return false, "", nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(a)),
+		B:        splitLines(string(b)),
+		FromFile: "/" + strings.TrimPrefix(name, "/"),
+		ToFile:   "/" + strings.TrimPrefix(name, "/") + " (config)",
+		Context:  3,
+	})
+	return false, diff, err
+}
+
+// fileContains returns whether the file at path already contains the
+// contents of the file at want, and otherwise the contents as added lines.
+func fileContains(path, want string) (bool, string, error) {
+	a, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, "", err
+	}
+	b, err := ioutil.ReadFile(want)
+	if err != nil {
+		return false, "", err
+	}
+	if bytes.Contains(a, b) {
+		return true, "", nil
+	}
+	if len(b) > maxDiffSize || !isText(b) {
+		return false, "", nil
+	}
+	var added strings.Builder
+	for _, line := range splitLines(string(b)) {
+		added.WriteString("+" + line)
+	}
+	return false, added.String(), nil
+}
+
+// fileContainsBlock returns whether the file at path already contains the
+// contents of the file at want between the markers, and otherwise the diff
+// of appending or replacing the block.
+func fileContainsBlock(path, want, name string, markers types.AppendMarkers) (bool, string, error) {
+	a, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, "", err
+	}
+	b, err := ioutil.ReadFile(want)
+	if err != nil {
+		return false, "", err
+	}
+	res, changed := util.AppendBlock(string(a), string(b), markers.Begin, markers.End)
+	if !changed {
+		return true, "", nil
+	}
+	if len(res) > maxDiffSize || !isText(a) || !isText(b) {
+		return false, "", nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(a)),
+		B:        splitLines(res),
+		FromFile: "/" + strings.TrimPrefix(name, "/"),
+		ToFile:   "/" + strings.TrimPrefix(name, "/") + " (config)",
+		Context:  3,
+	})
+	return false, diff, err
+}
+
+// splitLines splits s into lines, keeping their line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+	return lines
+}
+
+func fileDigest(path string) ([sha256.Size]byte, int64, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return sum, 0, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, n, nil
+}
+
+func isText(b []byte) bool {
+	return utf8.Valid(b) && bytes.IndexByte(b, 0) < 0
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestPlanConvergence(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-converge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	write := func(path, contents string, mode os.FileMode) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("etc/same", "same\n", 0644)
+	write("etc/changed", "a\nb\n", 0644)
+	write("etc/mode", "mode\n", 0644)
+	write("etc/append", "first\nx\n", 0644)
+	write("etc/keep", "local\n", 0644)
+	write("etc/block", "first\n# BEGIN x\nold\n# END x\n", 0644)
+	write("etc/block-same", "# BEGIN x\nsame\n# END x\nlast\n", 0644)
+	if err := os.MkdirAll(filepath.Join(root, "var/same"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/same", filepath.Join(root, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/other", filepath.Join(root, "etc/relink")); err != nil {
+		t.Fatal(err)
+	}
+
+	node := func(path string) types.Node {
+		return types.Node{
+			Filesystem: "root",
+			Path:       path,
+			User:       &types.NodeUser{ID: util.IntToPtr(os.Getuid())},
+			Group:      &types.NodeGroup{ID: util.IntToPtr(os.Getgid())},
+		}
+	}
+	file := func(path, contents string, mode int) types.File {
+		return types.File{
+			Node: node(path),
+			FileEmbedded1: types.FileEmbedded1{
+				Contents: types.FileContents{Source: "data:," + url.PathEscape(contents)},
+				Mode:     util.IntToPtr(mode),
+			},
+		}
+	}
+	appended := file("/etc/append", "x\n", 0644)
+	appended.Append = true
+	block := func(path, contents string) types.File {
+		f := file(path, contents, 0644)
+		f.Append = true
+		f.AppendMarkers = &types.AppendMarkers{Begin: "# BEGIN x", End: "# END x"}
+		return f
+	}
+	keep := file("/etc/keep", "config\n", 0644)
+	keep.Overwrite = util.BoolToPtr(false)
+	relink := types.Link{Node: node("/etc/relink"), LinkEmbedded1: types.LinkEmbedded1{Target: "/etc/same"}}
+	relink.Overwrite = util.BoolToPtr(true)
+	cfg := types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				file("/etc/new", "new\n", 0644),
+				file("/etc/same", "same\n", 0644),
+				file("/etc/changed", "a\nc\n", 0644),
+				file("/etc/mode", "mode\n", 0600),
+				appended,
+				block("/etc/block", "new\n"),
+				block("/etc/block-same", "same\n"),
+				keep,
+			},
+			Directories: []types.Directory{
+				{Node: node("/var/new"), DirectoryEmbedded1: types.DirectoryEmbedded1{Mode: util.IntToPtr(0755)}},
+				{Node: node("/var/same"), DirectoryEmbedded1: types.DirectoryEmbedded1{Mode: util.IntToPtr(0755)}},
+			},
+			Links: []types.Link{
+				{Node: node("/etc/link"), LinkEmbedded1: types.LinkEmbedded1{Target: "/etc/same"}},
+				relink,
+			},
+		},
+	}
+
+	logger := log.New(true)
+	e := Engine{Root: root, Logger: &logger, Fetcher: &resource.Fetcher{Logger: &logger}}
+	plan, err := e.planConvergence(cfg)
+	if err != nil {
+		t.Fatalf("planning failed: %v", err)
+	}
+
+	want := []convergeChange{
+		{action: "create", subject: `directory "/var/new"`},
+		{action: "create", subject: `file "/etc/new"`},
+		{action: "update", subject: `file "/etc/changed"`, details: []string{"contents"},
+			diff: "--- /etc/changed\n+++ /etc/changed (config)\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{action: "update", subject: `file "/etc/mode"`, details: []string{"mode 0644 -> 0600"}},
+		{action: "append to", subject: `file "/etc/block"`,
+			diff: "--- /etc/block\n+++ /etc/block (config)\n@@ -1,4 +1,4 @@\n first\n # BEGIN x\n-old\n+new\n # END x\n"},
+		{action: "refuse to write", subject: `file "/etc/keep"`, details: []string{"its contents differ and overwrite is false"}, conflict: true},
+		{action: "replace", subject: `link "/etc/relink"`, details: []string{"/etc/other -> /etc/same"}},
+	}
+	if !reflect.DeepEqual(plan.changes, want) {
+		t.Errorf("bad changes:\nwant %+v\ngot  %+v", want, plan.changes)
+	}
+
+	var applied []string
+	for _, f := range plan.apply.Storage.Files {
+		applied = append(applied, f.Path)
+	}
+	for _, d := range plan.apply.Storage.Directories {
+		applied = append(applied, d.Path)
+	}
+	for _, l := range plan.apply.Storage.Links {
+		applied = append(applied, l.Path)
+	}
+	if want := []string{"/etc/new", "/etc/changed", "/etc/block", "/var/new", "/etc/relink"}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("bad applied nodes: want %v, got %v", want, applied)
+	}
+
+	if len(plan.fixups) != 1 {
+		t.Fatalf("bad fixups: want 1, got %d", len(plan.fixups))
+	}
+	if err := plan.fixups[0](); err != nil {
+		t.Fatalf("fixup failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(root, "etc/mode")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("fixup didn't change the mode: %v, %v", info.Mode(), err)
+	}
+}
+
+func TestConvergeScope(t *testing.T) {
+	tests := []struct {
+		in      types.Config
+		ignored []string
+		err     bool
+	}{
+		{
+			in: types.Config{},
+		},
+		{
+			in: types.Config{
+				Storage: types.Storage{
+					Disks:       []types.Disk{{Device: "/dev/sda"}},
+					Filesystems: []types.Filesystem{{Name: "data"}},
+				},
+				Reboot: types.Reboot{Method: "reboot"},
+			},
+			ignored: []string{"reboot", "storage.disks", "storage.filesystems"},
+		},
+		{
+			// the sections converge applies or fetches with
+			in: types.Config{
+				Ignition: types.Ignition{
+					Version:  "2.4.0",
+					Timeouts: types.Timeouts{HTTPTotal: util.IntToPtr(10)},
+				},
+				Storage: types.Storage{
+					Files:       []types.File{{Node: types.Node{Filesystem: "root", Path: "/foo"}}},
+					Directories: []types.Directory{{Node: types.Node{Filesystem: "root", Path: "/bar"}}},
+				},
+				Systemd:  types.Systemd{Units: []types.Unit{{Name: "foo.service"}}},
+				Networkd: types.Networkd{Units: []types.Networkdunit{{Name: "00-eth0.network"}}},
+				Passwd:   types.Passwd{Users: []types.PasswdUser{{Name: "core"}}},
+			},
+		},
+		{
+			in: types.Config{
+				Ignition: types.Ignition{Hooks: []types.Hook{{Stage: "files"}}},
+				Storage: types.Storage{
+					Archives:  []types.Archive{{Path: "/opt"}},
+					Clones:    []types.Clone{{Source: "/dev/sda1", Target: "/dev/sdb1"}},
+					Edits:     []types.Edit{{Path: "/etc/foo"}},
+					Images:    []types.Image{{Device: "/dev/sdc"}},
+					Manifests: []types.Manifest{{Path: "/opt"}},
+					OEM:       &types.OEM{},
+					Raid:      []types.Raid{{Name: "md0"}},
+					UdevRules: []types.UdevRule{{Name: "foo"}},
+				},
+				Systemd:      types.Systemd{Extensions: []types.SystemdExtension{{Name: "docker.raw"}}},
+				Audit:        types.Audit{Rules: []types.AuditRule{{Name: "foo"}}},
+				Certificates: []types.Certificate{{Server: "https://est.example.com"}},
+				Kernel:       types.Kernel{Modules: []types.KernelModule{{Name: "foo"}}},
+				Kubernetes:   types.Kubernetes{BootstrapKubeconfig: &types.BootstrapKubeconfig{}},
+				SSH:          types.SSH{DisableHostKeyGeneration: true},
+				Time:         types.Time{Servers: []types.TimeServer{{Address: "pool.ntp.org"}}},
+				Update:       types.Update{Group: "stable"},
+			},
+			ignored: []string{
+				"audit",
+				"certificates",
+				"ignition.hooks",
+				"kernel",
+				"kubernetes",
+				"ssh",
+				"storage.archives",
+				"storage.clones",
+				"storage.edits",
+				"storage.images",
+				"storage.manifests",
+				"storage.oem",
+				"storage.raid",
+				"storage.udevRules",
+				"systemd.extensions",
+				"time",
+				"update",
+			},
+		},
+		{
+			in: types.Config{
+				Storage: types.Storage{
+					Files: []types.File{{Node: types.Node{Filesystem: "data", Path: "/foo"}}},
+				},
+			},
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, ignored, err := convergeScope(test.in)
+		if (err != nil) != test.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		}
+		if !reflect.DeepEqual(ignored, test.ignored) {
+			t.Errorf("#%d: bad ignored sections: want %v, got %v", i, test.ignored, ignored)
+		}
+	}
+}
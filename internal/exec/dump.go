@@ -0,0 +1,204 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	cmdlineDumpFlag = "ignition.config.dump"
+
+	// dumpNone doesn't dump the config.
+	dumpNone = 0
+	// dumpRedacted dumps it without secrets and the contents of data urls.
+	dumpRedacted = 1
+	// dumpContents dumps it without secrets, but with the contents of data
+	// urls.
+	dumpContents = 2
+
+	redacted = "<redacted>"
+)
+
+// secretKeys are the keys of the config whose values are secrets, whatever
+// their type.
+var secretKeys = map[string]struct{}{
+	"passwordHash": {},
+	"privateKey":   {},
+	"token":        {},
+}
+
+// secretSourceKeys are the keys of the config whose objects have a source
+// holding key material, which is a secret even as a data url.
+var secretSourceKeys = map[string]struct{}{
+	// ssh.hostKeys; the hostKeys of sftp hosts are public keys
+	"hostKeys": {},
+}
+
+// readDumpLevel returns the level of detail of the config dump requested by
+// the "ignition.config.dump" kernel command line option.
+func (e Engine) readDumpLevel() int {
+	value, ok, err := cmdlineValue(cmdlineDumpFlag)
+	if err != nil {
+		e.Logger.Warning("%v, not dumping the config", err)
+		return dumpNone
+	}
+	return parseCmdlineDump(value, ok)
+}
+
+func parseCmdlineDump(value string, ok bool) int {
+	switch {
+	case !ok:
+		return dumpNone
+	case value == "" || value == "1":
+		return dumpRedacted
+	case value == "2":
+		return dumpContents
+	default:
+		return dumpNone
+	}
+}
+
+// dumpConfig records the config, once merged with the configs it references,
+// in distro.ConfigDumpPath() for inspecting what a machine applied after the
+// fact. The cached config can't be used for that, as it keeps its secrets.
+// Failing to dump it doesn't fail the stage.
+func (e *Engine) dumpConfig(cfg types.Config) {
+	level := e.readDumpLevel()
+	if level == dumpNone {
+		return
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		e.Logger.Warning("failed to marshal config dump: %v", err)
+		return
+	}
+	var tree interface{}
+	if err := json.Unmarshal(b, &tree); err != nil {
+		e.Logger.Warning("failed to marshal config dump: %v", err)
+		return
+	}
+	if b, err = json.MarshalIndent(redactConfig("", tree, level), "", "  "); err != nil {
+		e.Logger.Warning("failed to marshal config dump: %v", err)
+		return
+	}
+
+	path := distro.ConfigDumpPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.Logger.Warning("failed to create directory for config dump: %v", err)
+		return
+	}
+	// secrets might still hide in contents, so keep it to root
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0600); err != nil {
+		e.Logger.Warning("failed to write config dump: %v", err)
+		return
+	}
+	e.Logger.Info("dumped the config to %s", path)
+}
+
+// redactConfig returns the value of key in the config, decoded into a tree
+// of maps, slices and strings, without its secrets: password hashes, keys
+// including the sources of SSH host keys, tokens, the values of HTTP headers and the passwords and query values of
+// urls, which carry the signatures of presigned urls. With dumpRedacted, the
+// contents of data urls are replaced by their size.
+func redactConfig(key string, v interface{}, level int) interface{} {
+	if _, ok := secretKeys[key]; ok {
+		return redactAll(v)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactConfig(k, child, level)
+		}
+		if _, ok := v["value"]; ok && key == "httpHeaders" {
+			v["value"] = redacted
+		}
+		if _, ok := secretSourceKeys[key]; ok {
+			if source, ok := v["source"]; ok {
+				v["source"] = redactAll(source)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactConfig(key, child, level)
+		}
+	case string:
+		return redactURL(v, level)
+	}
+	return v
+}
+
+// redactAll replaces every string of v.
+func redactAll(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactAll(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactAll(child)
+		}
+	case string:
+		if v != "" {
+			return redacted
+		}
+	}
+	return v
+}
+
+// redactURL returns s without the secrets it has if it's a url.
+func redactURL(s string, level int) string {
+	if strings.HasPrefix(s, "data:") {
+		if level == dumpContents {
+			return s
+		}
+		du, err := dataurl.DecodeString(s)
+		if err != nil {
+			return redacted
+		}
+		return fmt.Sprintf("<redacted data url of %d bytes>", len(du.Data))
+	}
+	// only whole strings are urls, not e.g. commands of units
+	if !strings.Contains(s, "://") || strings.ContainsAny(s, " \t\n") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return s
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k := range q {
+			q[k] = []string{redacted}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,278 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// Provisioning networks may only let machines in after authenticating their
+// switch port with 802.1X. The credentials can't come with the config, which
+// is fetched through that port, so they're handed to the machine by the
+// hypervisor or firmware, and Ignition runs wpa_supplicant on the interface
+// before the first remote fetch.
+
+const (
+	// the prefix of the SMBIOS OEM string holding the credentials, as
+	// base64 encoded JSON
+	eapOEMStringPrefix = "io.flatcar.ignition.eap="
+
+	// how often the state of the supplicant is checked
+	eapPollInterval = time.Second
+)
+
+var (
+	eapFirmwareConfigPath = "/sys/firmware/qemu_fw_cfg/by_name/opt/org.flatcar-linux/eap/raw"
+	smbiosOEMStringsGlob  = "/sys/firmware/dmi/entries/11-*/raw"
+)
+
+// eapCredentials are the 802.1X credentials of the provisioning interface.
+type eapCredentials struct {
+	Interface          string `json:"interface,omitempty"`
+	Method             string `json:"method"`
+	Identity           string `json:"identity,omitempty"`
+	AnonymousIdentity  string `json:"anonymousIdentity,omitempty"`
+	Password           string `json:"password,omitempty"`
+	Phase2             string `json:"phase2,omitempty"`
+	CACert             string `json:"caCert,omitempty"`
+	ClientCert         string `json:"clientCert,omitempty"`
+	PrivateKey         string `json:"privateKey,omitempty"`
+	PrivateKeyPassword string `json:"privateKeyPassword,omitempty"`
+}
+
+// authenticateNetwork authenticates the provisioning interface with 802.1X
+// if the machine was given credentials for it, and waits until the switch
+// authorized the port.
+func (e *Engine) authenticateNetwork() error {
+	if e.OEMConfig.Name() == "qemu" {
+		if _, err := e.Logger.LogCmd(exec.Command("modprobe", "qemu_fw_cfg"), "loading QEMU firmware config module"); err != nil {
+			return err
+		}
+	}
+	data, source, err := findEAPCredentials()
+	if err != nil || data == nil {
+		return err
+	}
+	creds, err := parseEAPCredentials(data)
+	if err != nil {
+		return fmt.Errorf("EAP credentials from %s: %v", source, err)
+	}
+	iface := creds.Interface
+	if iface == "" {
+		iface = distro.NetworkInterface()
+	}
+	if iface == "" {
+		return errors.ErrEAPInterfaceRequired
+	}
+	e.Logger.Info("authenticating %q with EAP-%s, using the credentials from %s", iface, creds.Method, source)
+
+	dir := distro.EAPDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	config, err := creds.writeFiles(dir, iface)
+	if err != nil {
+		return err
+	}
+
+	pidFile := filepath.Join(dir, "wpa_supplicant-"+iface+".pid")
+	if !processRunning(pidFile) {
+		if _, err := e.Logger.LogCmd(exec.Command(distro.WpaSupplicantCmd(), "-B", "-D", "wired", "-i", iface, "-c", config, "-P", pidFile),
+			"starting 802.1X supplicant on %q", iface); err != nil {
+			return err
+		}
+	}
+	return e.Logger.LogOp(func() error { return e.waitForEAP(dir, iface) }, "waiting for 802.1X authentication of %q", iface)
+}
+
+// findEAPCredentials returns the credentials handed to the machine through
+// the QEMU firmware config or an SMBIOS OEM string, and where they were
+// found, or nil if there are none.
+func findEAPCredentials() ([]byte, string, error) {
+	data, err := ioutil.ReadFile(eapFirmwareConfigPath)
+	if err == nil {
+		return data, "the QEMU firmware config", nil
+	} else if !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	entries, err := filepath.Glob(smbiosOEMStringsGlob)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, entry := range entries {
+		raw, err := ioutil.ReadFile(entry)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, s := range smbiosStrings(raw) {
+			if !strings.HasPrefix(s, eapOEMStringPrefix) {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, eapOEMStringPrefix))
+			if err != nil {
+				return nil, "", fmt.Errorf("decoding the EAP credentials of the SMBIOS OEM strings: %v", err)
+			}
+			return data, "the SMBIOS OEM strings", nil
+		}
+	}
+	return nil, "", nil
+}
+
+// smbiosStrings returns the strings of the raw SMBIOS structure raw, which
+// follow its formatted area, each terminated by a NUL, ending with an empty
+// string.
+func smbiosStrings(raw []byte) []string {
+	if len(raw) < 2 || int(raw[1]) > len(raw) {
+		return nil
+	}
+	var res []string
+	for _, s := range bytes.Split(raw[raw[1]:], []byte{0}) {
+		if len(s) == 0 {
+			break
+		}
+		res = append(res, string(s))
+	}
+	return res
+}
+
+func parseEAPCredentials(data []byte) (eapCredentials, error) {
+	var creds eapCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return creds, err
+	}
+	creds.Method = strings.ToUpper(creds.Method)
+	switch creds.Method {
+	case "TLS":
+		if creds.ClientCert == "" || creds.PrivateKey == "" {
+			return creds, errors.ErrEAPCredentialsIncomplete
+		}
+	case "PEAP", "TTLS":
+		if creds.Identity == "" || creds.Password == "" {
+			return creds, errors.ErrEAPCredentialsIncomplete
+		}
+	default:
+		return creds, errors.ErrEAPMethod
+	}
+	return creds, nil
+}
+
+// writeFiles writes the config of wpa_supplicant for iface, and the
+// certificates and key it refers to, to dir, and returns the path of the
+// config. The files are only readable by root.
+func (c eapCredentials) writeFiles(dir, iface string) (string, error) {
+	write := func(name, contents string) (string, error) {
+		path := filepath.Join(dir, name)
+		return path, ioutil.WriteFile(path, []byte(contents), 0600)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ctrl_interface=%s\nap_scan=0\nnetwork={\n\tkey_mgmt=IEEE8021X\n\teap=%s\n\teapol_flags=0\n", dir, c.Method)
+	// values from the credentials are written hex encoded, which
+	// wpa_supplicant accepts for all strings, so they need no quoting
+	setting := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "\t%s=%s\n", key, hex.EncodeToString([]byte(value)))
+		}
+	}
+	setting("identity", c.Identity)
+	setting("anonymous_identity", c.AnonymousIdentity)
+	setting("password", c.Password)
+	setting("phase2", c.Phase2)
+	setting("private_key_passwd", c.PrivateKeyPassword)
+	for _, f := range []struct{ key, name, contents string }{
+		{"ca_cert", "ca.pem", c.CACert},
+		{"client_cert", "client.pem", c.ClientCert},
+		{"private_key", "key.pem", c.PrivateKey},
+	} {
+		if f.contents == "" {
+			continue
+		}
+		path, err := write(iface+"-"+f.name, f.contents)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\t%s=%s\n", f.key, strconv.Quote(path))
+	}
+	b.WriteString("}\n")
+	return write("wpa_supplicant-"+iface+".conf", b.String())
+}
+
+// waitForEAP waits until the supplicant controlled through the sockets in
+// dir reports iface as authorized.
+func (e *Engine) waitForEAP(dir, iface string) error {
+	ctx := e.Fetcher.BaseContext()
+	deadline := time.After(distro.EAPTimeout())
+	var state string
+	for {
+		out, err := exec.CommandContext(ctx, distro.WpaCliCmd(), "-p", dir, "-i", iface, "status").Output()
+		if err == nil {
+			status := parseWpaStatus(out)
+			if status["suppPortStatus"] == "Authorized" {
+				return nil
+			}
+			state = status["Supplicant PAE state"]
+		}
+		select {
+		case <-time.After(eapPollInterval):
+		case <-deadline:
+			return fmt.Errorf("%v, supplicant state %q", errors.ErrEAPTimeout, state)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// parseWpaStatus parses the key=value lines printed by "wpa_cli status".
+func parseWpaStatus(out []byte) map[string]string {
+	status := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if i := strings.Index(scanner.Text(), "="); i > 0 {
+			status[scanner.Text()[:i]] = scanner.Text()[i+1:]
+		}
+	}
+	return status
+}
+
+// processRunning returns whether the process whose pid is in pidFile runs,
+// e.g. because an earlier stage started the supplicant.
+func processRunning(pidFile string) bool {
+	b, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
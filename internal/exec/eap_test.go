@@ -0,0 +1,144 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+)
+
+func TestFindEAPCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-eap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(fwCfg, smbios string) {
+		eapFirmwareConfigPath, smbiosOEMStringsGlob = fwCfg, smbios
+	}(eapFirmwareConfigPath, smbiosOEMStringsGlob)
+	eapFirmwareConfigPath = filepath.Join(dir, "fw_cfg")
+	smbiosOEMStringsGlob = filepath.Join(dir, "11-*", "raw")
+
+	if data, _, err := findEAPCredentials(); err != nil || data != nil {
+		t.Fatalf("found credentials %q (%v) where there are none", data, err)
+	}
+
+	// a type 11 structure: type, length, handle and string count, followed
+	// by the strings
+	creds := `{"method": "PEAP", "identity": "node1", "password": "secret"}`
+	raw := []byte{11, 5, 0x2a, 0, 2}
+	raw = append(raw, "io.systemd.credential:foo=bar\x00"...)
+	raw = append(raw, eapOEMStringPrefix+base64.StdEncoding.EncodeToString([]byte(creds))+"\x00\x00"...)
+	if err := os.Mkdir(filepath.Join(dir, "11-0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "11-0", "raw"), raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, source, err := findEAPCredentials()
+	if err != nil || string(data) != creds || source != "the SMBIOS OEM strings" {
+		t.Errorf("got %q from %s (%v), want the SMBIOS OEM strings' credentials", data, source, err)
+	}
+
+	// the firmware config comes first
+	if err := ioutil.WriteFile(eapFirmwareConfigPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, source, err := findEAPCredentials(); err != nil || string(data) != "{}" || source != "the QEMU firmware config" {
+		t.Errorf("got %q from %s (%v), want the firmware config's credentials", data, source, err)
+	}
+}
+
+func TestParseEAPCredentials(t *testing.T) {
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{in: `{"method": "peap", "identity": "node1", "password": "secret"}`},
+		{in: `{"method": "TLS", "clientCert": "cert", "privateKey": "key"}`},
+		{in: `{"method": "TLS", "identity": "node1", "password": "secret"}`, err: errors.ErrEAPCredentialsIncomplete},
+		{in: `{"method": "TTLS", "identity": "node1"}`, err: errors.ErrEAPCredentialsIncomplete},
+		{in: `{"method": "MD5", "identity": "node1", "password": "secret"}`, err: errors.ErrEAPMethod},
+	}
+
+	for i, test := range tests {
+		if _, err := parseEAPCredentials([]byte(test.in)); err != test.err {
+			t.Errorf("#%d: want %v, got %v", i, test.err, err)
+		}
+	}
+}
+
+func TestEAPCredentialsWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-eap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	creds := eapCredentials{
+		Method:   "PEAP",
+		Identity: "node1",
+		Password: `se"cret`,
+		Phase2:   "auth=MSCHAPV2",
+		CACert:   "-----BEGIN CERTIFICATE-----\n",
+	}
+	path, err := creds.writeFiles(dir, "eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"ctrl_interface=" + dir,
+		"ap_scan=0",
+		"network={",
+		"\tkey_mgmt=IEEE8021X",
+		"\teap=PEAP",
+		"\teapol_flags=0",
+		"\tidentity=6e6f646531",
+		"\tpassword=73652263726574",
+		"\tphase2=617574683d4d53434841505632",
+		"\tca_cert=\"" + filepath.Join(dir, "eth0-ca.pem") + "\"",
+		"}",
+		"",
+	}, "\n")
+	if string(config) != want {
+		t.Errorf("bad config:\n%s\nwant:\n%s", config, want)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("config isn't only readable by root: %v", err)
+	}
+}
+
+func TestParseWpaStatus(t *testing.T) {
+	out := "Supplicant PAE state=AUTHENTICATED\nsuppPortStatus=Authorized\nEAP state=SUCCESS\n"
+	want := map[string]string{
+		"Supplicant PAE state": "AUTHENTICATED",
+		"suppPortStatus":       "Authorized",
+		"EAP state":            "SUCCESS",
+	}
+	if got := parseWpaStatus([]byte(out)); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
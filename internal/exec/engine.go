@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/flatcar/ignition/config/shared/errors"
@@ -30,9 +31,10 @@ import (
 	"github.com/flatcar/ignition/config/validate/report"
 	"github.com/flatcar/ignition/internal/config"
 	"github.com/flatcar/ignition/internal/config/types"
-	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/ostree"
 	"github.com/flatcar/ignition/internal/providers"
 	"github.com/flatcar/ignition/internal/providers/cmdline"
 	"github.com/flatcar/ignition/internal/providers/system"
@@ -44,6 +46,12 @@ const (
 	DefaultFetchTimeout = 2 * time.Minute
 )
 
+// delays between fetches of missing configs
+var (
+	missingConfigInitialBackoff = 5 * time.Second
+	missingConfigMaxBackoff     = time.Minute
+)
+
 // Engine represents the entity that fetches and executes a configuration.
 type Engine struct {
 	ConfigCache  string
@@ -52,6 +60,16 @@ type Engine struct {
 	Root         string
 	OEMConfig    oem.Config
 	Fetcher      *resource.Fetcher
+
+	// the configs fetched while rendering the config, and whether the
+	// configs rendered so far require references to be verified
+	fetchedConfigs      []fetchedConfig
+	requireVerification bool
+
+	// resolveFragment and fetchMetadata replace the OEM's fragment resolver
+	// and metadata in tests.
+	resolveFragment providers.FuncResolveFragment
+	fetchMetadata   providers.FuncFetchMetadata
 }
 
 // Run executes the stage of the given name. It returns true if the stage
@@ -61,6 +79,22 @@ func (e Engine) Run(stageName string) error {
 		fmt.Fprintf(os.Stderr, "engine incorrectly configured\n")
 		return errors.ErrEngineConfiguration
 	}
+	// provision the deployment rather than the physical root filesystem
+	// on machines booting ostree
+	deployment, err := ostree.Find(e.Root)
+	if err != nil {
+		e.Logger.Crit("failed to find ostree deployment: %v", err)
+		return err
+	}
+	if deployment != nil {
+		e.Logger.Info("provisioning ostree deployment at %q (stateroot %q)", deployment.Root, deployment.Stateroot)
+		e.Root = deployment.Root
+	}
+
+	if os.Getenv(confinedStageEnv) == stageName {
+		return e.runConfinedChild(stageName)
+	}
+
 	baseConfig := types.Config{
 		Ignition: types.Ignition{Version: types.MaxVersion.String()},
 		Storage: types.Storage{
@@ -98,7 +132,19 @@ func (e Engine) Run(stageName string) error {
 	defer e.Logger.PopPrefix()
 
 	fullConfig := config.Append(baseConfig, config.Append(systemBaseConfig, cfg))
-	if err = stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher).Run(fullConfig); err != nil {
+	if len(fullConfig.Ignition.Annotations) > 0 {
+		e.Logger.Info("config is annotated with %s", annotationsForLog(fullConfig.Ignition.Annotations))
+	}
+	fullConfig = e.filterConditional(fullConfig)
+	if deployment != nil && stageName == "files" {
+		cleanup, err := e.prepareOSTree(deployment, fullConfig)
+		defer cleanup()
+		if err != nil {
+			e.Logger.Crit("config can't be applied to ostree deployment: %v", err)
+			return err
+		}
+	}
+	if err = e.runStage(stageName, fullConfig); err != nil {
 		// e.Logger could be nil
 		fmt.Fprintf(os.Stderr, "%s failed", stageName)
 		tmp, jsonerr := json.MarshalIndent(fullConfig, "", "  ")
@@ -126,7 +172,7 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 		}
 		// Create an http client and fetcher with the timeouts from the cached
 		// config
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+		err = e.updateFetcher(cfg)
 		if err != nil {
 			e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 			return
@@ -138,14 +184,40 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 	// since we don't have a config with timeout values we can use
 	timeout := int(e.FetchTimeout.Seconds())
 	emptyProxy := types.Proxy{}
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, nil, emptyProxy)
+	redirects, err := e.redirectPolicy(types.Redirects{})
+	if err != nil {
+		e.Logger.Crit("failed to read the redirect policy: %v", err)
+		return
+	}
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{}, redirects, emptyProxy)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
 	}
+	rate, err := e.rateLimit(nil)
+	if err != nil {
+		e.Logger.Crit("failed to read the rate limit: %v", err)
+		return
+	}
+	if err = e.Fetcher.SetRateLimit(rate); err != nil {
+		e.Logger.Crit("failed to limit the rate of fetches: %v", err)
+		return
+	}
+
+	// the first remote fetch, of the metadata attributes, may need the
+	// provisioning interface to be authenticated
+	if err = e.authenticateNetwork(); err != nil {
+		e.Logger.Crit("failed to authenticate the network: %v", err)
+		return
+	}
+
+	e.writeMetadataAttributes()
 
 	// (Re)Fetch the config if the cache is unreadable.
 	cfg, err = e.fetchProviderConfig()
+	if err == errors.ErrEmpty {
+		cfg, err = e.missingConfig()
+	}
 	if err != nil {
 		e.Logger.Warning("failed to fetch config: %s", err)
 		return
@@ -153,7 +225,7 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 
 	// Update the http client to use the timeouts and CAs from the newly fetched
 	// config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	err = e.updateFetcher(cfg)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
@@ -175,10 +247,65 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 		e.Logger.Crit("failed to write cached config: %v", err)
 		return
 	}
+	e.dumpConfig(cfg)
 
 	return
 }
 
+// updateFetcher configures the fetcher with the timeouts, TLS settings,
+// redirect policy, proxy, rate limit and sftp keys of cfg.
+func (e *Engine) updateFetcher(cfg types.Config) error {
+	redirects, err := e.redirectPolicy(cfg.Ignition.Security.Redirects)
+	if err != nil {
+		return err
+	}
+	if err := e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, redirects, cfg.Ignition.Proxy); err != nil {
+		return err
+	}
+	rate, err := e.rateLimit(cfg.Ignition.RateLimit)
+	if err != nil {
+		return err
+	}
+	if err := e.Fetcher.SetRateLimit(rate); err != nil {
+		return err
+	}
+	return e.Fetcher.UpdateSFTPHosts(cfg.Ignition.Security.SFTP)
+}
+
+// missingConfig applies the platform's policy for when no config is supplied.
+// It returns ErrEmpty to continue with the base config, fails, or fetches the
+// config again until one is supplied.
+func (e *Engine) missingConfig() (types.Config, error) {
+	policy, err := e.OEMConfig.MissingConfig()
+	if err != nil {
+		return types.Config{}, err
+	}
+	switch policy {
+	case oem.MissingConfigFail:
+		return types.Config{}, providers.ErrMissingConfig
+	case oem.MissingConfigRetry:
+		delay := missingConfigInitialBackoff
+		for {
+			e.Logger.Info("no config supplied, fetching it again in %v", delay)
+			select {
+			case <-time.After(delay):
+			case <-e.Fetcher.BaseContext().Done():
+				return types.Config{}, e.Fetcher.BaseContext().Err()
+			}
+			cfg, err := e.fetchProviderConfig()
+			if err != errors.ErrEmpty {
+				return cfg, err
+			}
+			if delay *= 2; delay > missingConfigMaxBackoff {
+				delay = missingConfigMaxBackoff
+			}
+		}
+	default:
+		e.Logger.Warning("no config supplied, continuing with the base config")
+		return types.Config{}, errors.ErrEmpty
+	}
+}
+
 // fetchProviderConfig returns the externally-provided configuration. It first
 // checks to see if the command-line option is present. If so, it uses that
 // source for the configuration. If the command-line option is not present, it
@@ -197,7 +324,11 @@ func (e *Engine) fetchProviderConfig() (types.Config, error) {
 	var r report.Report
 	var err error
 	for _, fetcher := range fetchers {
-		cfg, r, err = fetcher(e.Fetcher)
+		var fr report.Report
+		cfg, fr, err = fetcher(e.Fetcher)
+		// keep the warnings of the providers which had no config, e.g.
+		// about ignored kernel options
+		r.Merge(fr)
 		if err != providers.ErrNoProvider {
 			// successful, or failed on another error
 			break
@@ -205,37 +336,55 @@ func (e *Engine) fetchProviderConfig() (types.Config, error) {
 	}
 
 	e.logReport(r)
+	// written once the config is rendered, to record the configs it
+	// references and the annotations they add up to
+	var rendered types.Config
+	defer func() { e.writeConfigReport(r, rendered.Ignition.Annotations) }()
 	if err != nil {
 		return types.Config{}, err
 	}
 
 	// Replace the HTTP client in the fetcher to be configured with the
 	// timeouts of the config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	err = e.updateFetcher(cfg)
 	if err != nil {
 		return types.Config{}, err
 	}
 
-	return e.renderConfig(cfg)
+	rendered, err = e.renderConfig(cfg)
+	return rendered, err
 }
 
-// renderConfig evaluates "ignition.config.replace" and "ignition.config.append"
-// in the given config and returns the result. If "ignition.config.replace" is
-// set, the referenced and evaluted config will be returned. Otherwise, if
-// "ignition.config.append" is set, each of the referenced configs will be
-// evaluated and appended to the provided config. If neither option is set, the
+// renderConfig evaluates "ignition.config.replace", "ignition.config.append",
+// and "ignition.config.fragments" in the given config and returns the result.
+// If "ignition.config.replace" is set, the referenced and evaluted config will
+// be returned. Otherwise, each of the configs referenced by
+// "ignition.config.append" and then each of the fragments will be evaluated and
+// appended to the provided config. If none of these options are set, the
 // provided config will be returned unmodified. An updated fetcher will be
 // returned with any new timeouts set.
 func (e *Engine) renderConfig(cfg types.Config) (types.Config, error) {
+	if cfg.Ignition.Config.RequireVerification || distro.RequireConfigVerification() {
+		// configs fetched later can't opt out again
+		e.requireVerification = true
+	}
+
 	if cfgRef := cfg.Ignition.Config.Replace; cfgRef != nil {
 		newCfg, err := e.fetchReferencedConfig(*cfgRef)
+		if err != nil && canFallBack(*cfgRef, err) {
+			// keep the config referencing the replacement, e.g. one
+			// embedded in the image, and render it without the reference
+			e.Logger.Warning("falling back to the config replaced by %s: %v", cfgRef.Source, err)
+			cfg.Ignition.Config.Replace = nil
+			return e.renderConfig(cfg)
+		}
 		if err != nil {
 			return types.Config{}, err
 		}
 
 		// Replace the HTTP client in the fetcher to be configured with the
 		// timeouts of the new config
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(newCfg.Ignition.Timeouts, newCfg.Ignition.Security.TLS.CertificateAuthorities, newCfg.Ignition.Proxy)
+		err = e.updateFetcher(newCfg)
 		if err != nil {
 			return types.Config{}, err
 		}
@@ -244,34 +393,73 @@ func (e *Engine) renderConfig(cfg types.Config) (types.Config, error) {
 	}
 
 	appendedCfg := cfg
+	var err error
 	for _, cfgRef := range cfg.Ignition.Config.Append {
-		newCfg, err := e.fetchReferencedConfig(cfgRef)
+		appendedCfg, err = e.appendReferencedConfig(appendedCfg, cfgRef)
 		if err != nil {
 			return types.Config{}, err
 		}
+	}
 
-		// Append the old config with the new config before the new config has
-		// been rendered, so we can use the new config's timeouts and CAs when
-		// fetching more configs.
-		cfgForFetcherSettings := config.Append(appendedCfg, newCfg)
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfgForFetcherSettings.Ignition.Timeouts, cfgForFetcherSettings.Ignition.Security.TLS.CertificateAuthorities, cfgForFetcherSettings.Ignition.Proxy)
+	// Fragments are resolved to URLs by the provider and then appended just
+	// like the configs referenced in "ignition.config.append".
+	for _, name := range cfg.Ignition.Config.Fragments {
+		if e.requireVerification {
+			e.Logger.Crit("refusing to resolve config fragment %q: %v", name, errors.ErrConfigFragmentsUnverified)
+			return types.Config{}, errors.ErrConfigFragmentsUnverified
+		}
+		resolve := e.OEMConfig.ResolveFragment
+		if e.resolveFragment != nil {
+			resolve = e.resolveFragment
+		}
+		u, err := resolve(e.Fetcher, string(name))
 		if err != nil {
+			e.Logger.Crit("failed to resolve config fragment %q: %v", name, err)
 			return types.Config{}, err
 		}
+		e.Logger.Info("resolved config fragment %q", name)
 
-		newCfg, err = e.renderConfig(newCfg)
+		appendedCfg, err = e.appendReferencedConfig(appendedCfg, types.ConfigReference{Source: u.String()})
 		if err != nil {
 			return types.Config{}, err
 		}
-
-		appendedCfg = config.Append(appendedCfg, newCfg)
 	}
 	return appendedCfg, nil
 }
 
+// appendReferencedConfig fetches and renders the config referenced by cfgRef
+// and appends it to cfg.
+func (e *Engine) appendReferencedConfig(cfg types.Config, cfgRef types.ConfigReference) (types.Config, error) {
+	newCfg, err := e.fetchReferencedConfig(cfgRef)
+	if err != nil {
+		return types.Config{}, err
+	}
+
+	// Append the old config with the new config before the new config has
+	// been rendered, so we can use the new config's timeouts and CAs when
+	// fetching more configs.
+	cfgForFetcherSettings := config.Append(cfg, newCfg)
+	err = e.updateFetcher(cfgForFetcherSettings)
+	if err != nil {
+		return types.Config{}, err
+	}
+
+	newCfg, err = e.renderConfig(newCfg)
+	if err != nil {
+		return types.Config{}, err
+	}
+
+	return config.Append(cfg, newCfg), nil
+}
+
 // fetchReferencedConfig fetches and parses the requested config.
 func (e *Engine) fetchReferencedConfig(cfgRef types.ConfigReference) (types.Config, error) {
-	u, err := url.Parse(cfgRef.Source)
+	if e.requireVerification && cfgRef.Verification.Hash == nil {
+		e.Logger.Crit("refusing to fetch config %s: %v", configSourceForLog(cfgRef.Source), errors.ErrConfigVerificationRequired)
+		return types.Config{}, errors.ErrConfigVerificationRequired
+	}
+
+	mirrors, err := configMirrors(cfgRef)
 	if err != nil {
 		return types.Config{}, err
 	}
@@ -299,36 +487,118 @@ func (e *Engine) fetchReferencedConfig(cfgRef types.ConfigReference) (types.Conf
 			}
 		}
 	}
-	rawCfg, err := e.Fetcher.FetchToBuffer(*u, resource.FetchOptions{
-		Headers: headers,
-		// Default headers that will be used in case of redirection
-		HeadersRedirect: resource.ConfigHeaders,
+
+	// Default headers that will be used in case of redirection
+	headersRedirect := resource.ConfigHeaders
+	if cfgRef.ETag != "" {
+		// ask for the config only if it differs from the one the
+		// referencing config was built from
+		headersRedirect = http.Header{}
+		for headerName, headerValue := range resource.ConfigHeaders {
+			headersRedirect[headerName] = headerValue
+		}
+		headers.Set("If-None-Match", cfgRef.ETag)
+		headersRedirect.Set("If-None-Match", cfgRef.ETag)
+	}
+
+	// A mirror serving a config not matching the hash is failed over like
+	// an unreachable one.
+	rawCfg, served, err := e.Fetcher.FetchToBufferFromMirrors(mirrors, resource.FetchOptions{
+		Headers:         headers,
+		HeadersRedirect: headersRedirect,
+		Compression:     cfgRef.Compression,
+		MaxSize:         distro.MaxConfigSize(),
+		RejectHTML:      true,
+	}, func(rawCfg []byte) error {
+		return util.AssertValid(cfgRef.Verification, rawCfg)
 	})
 	if err != nil {
 		return types.Config{}, err
 	}
 
+	// log and record the digest of every fetched config, so that it can be
+	// proven which configs provisioned the machine
 	hash := sha512.Sum512(rawCfg)
-	if u.Scheme != "data" {
-		e.Logger.Debug("fetched referenced config at %s with SHA512: %s", cfgRef.Source, hex.EncodeToString(hash[:]))
-	} else {
-		// data url's might contain secrets
-		e.Logger.Debug("fetched referenced config from data url with SHA512: %s", hex.EncodeToString(hash[:]))
-	}
-
-	if err := util.AssertValid(cfgRef.Verification, rawCfg); err != nil {
-		return types.Config{}, err
+	sum := hex.EncodeToString(hash[:])
+	e.Logger.Info("fetched referenced config %s with SHA512: %s", configSourceForLog(served.String()), sum)
+	fetched := fetchedConfig{SHA512: sum, Verified: cfgRef.Verification.Hash != nil}
+	if served.Scheme != "data" {
+		// record the mirror which served the config
+		fetched.Source = resource.RedactURL(served)
 	}
+	e.fetchedConfigs = append(e.fetchedConfigs, fetched)
 
 	cfg, r, err := config.Parse(rawCfg)
 	e.logReport(r)
 	if err != nil {
 		return types.Config{}, err
 	}
+	if len(cfg.Ignition.Annotations) > 0 {
+		e.Logger.Info("referenced config %s is annotated with %s", configSourceForLog(cfgRef.Source), annotationsForLog(cfg.Ignition.Annotations))
+		e.fetchedConfigs[len(e.fetchedConfigs)-1].Annotations = cfg.Ignition.Annotations
+	}
 
 	return cfg, nil
 }
 
+// configSourceForLog returns the source of a config to be logged, without the
+// secrets it might contain.
+func configSourceForLog(source string) string {
+	if strings.HasPrefix(source, "data:") {
+		return "from data url"
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return "at an invalid url"
+	}
+	return "at " + resource.RedactURL(*u)
+}
+
+// canFallBack reports whether the config replaced by cfgRef is to be kept
+// after fetching the replacement failed with err: always if the
+// replacement wasn't modified since the etag, and if it wasn't found or
+// timed out as far as cfgRef's fallback allows.
+func canFallBack(cfgRef types.ConfigReference, err error) bool {
+	var fallback types.ConfigFallback
+	switch err {
+	case resource.ErrNotModified:
+		return cfgRef.ETag != ""
+	case resource.ErrNotFound:
+		fallback = "notFound"
+	case resource.ErrTimeout:
+		fallback = "timeout"
+	default:
+		return false
+	}
+	for _, f := range cfgRef.Fallback {
+		if f == fallback {
+			return true
+		}
+	}
+	return false
+}
+
+// configMirrors returns the source of cfgRef followed by its mirrors, in
+// the order they are tried.
+func configMirrors(cfgRef types.ConfigReference) ([]resource.Mirror, error) {
+	sources := []types.ConfigMirror{{Source: cfgRef.Source, Timeout: cfgRef.Timeout}}
+	sources = append(sources, cfgRef.Mirrors...)
+
+	var mirrors []resource.Mirror
+	for _, s := range sources {
+		u, err := url.Parse(s.Source)
+		if err != nil {
+			return nil, err
+		}
+		m := resource.Mirror{URL: *u}
+		if s.Timeout != nil {
+			m.Timeout = time.Duration(*s.Timeout) * time.Second
+		}
+		mirrors = append(mirrors, m)
+	}
+	return mirrors, nil
+}
+
 func (e Engine) logReport(r report.Report) {
 	for _, entry := range r.Entries {
 		entry.Highlight = "" // might contain secrets, don't log when Ignition runs
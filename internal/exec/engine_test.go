@@ -0,0 +1,428 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/providers"
+	providerUtil "github.com/flatcar/ignition/internal/providers/util"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestRenderConfigFallback(t *testing.T) {
+	const etag = `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.ign":
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v2"`)
+			w.Write([]byte(`{"ignition":{"version":"2.3.0"},"systemd":{"units":[{"name":"remote.service"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	embedded := func(ref types.ConfigReference) types.Config {
+		return types.Config{
+			Ignition: types.Ignition{Config: types.IgnitionConfig{Replace: &ref}},
+			Systemd:  types.Systemd{Units: []types.Unit{{Name: "embedded.service"}}},
+		}
+	}
+
+	type in struct {
+		ref types.ConfigReference
+	}
+	type out struct {
+		unit string
+		err  error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/config.ign"}},
+			out: out{unit: "remote.service"},
+		},
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/config.ign", ETag: etag}},
+			out: out{unit: "embedded.service"},
+		},
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/config.ign", ETag: `"v0"`}},
+			out: out{unit: "remote.service"},
+		},
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/missing.ign"}},
+			out: out{err: resource.ErrNotFound},
+		},
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/missing.ign", Fallback: []types.ConfigFallback{"timeout"}}},
+			out: out{err: resource.ErrNotFound},
+		},
+		{
+			in:  in{ref: types.ConfigReference{Source: srv.URL + "/missing.ign", Fallback: []types.ConfigFallback{"notFound"}}},
+			out: out{unit: "embedded.service"},
+		},
+	}
+
+	for i, test := range tests {
+		logger := log.New(true)
+		e := Engine{Logger: &logger, Fetcher: &resource.Fetcher{Logger: &logger}}
+		cfg, err := e.renderConfig(embedded(test.in.ref))
+		if !reflect.DeepEqual(test.out.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if cfg.Ignition.Config.Replace != nil {
+			t.Errorf("#%d: replace reference wasn't rendered", i)
+		}
+		if len(cfg.Systemd.Units) != 1 || cfg.Systemd.Units[0].Name != test.out.unit {
+			t.Errorf("#%d: bad units: want %q, got %v", i, test.out.unit, cfg.Systemd.Units)
+		}
+	}
+}
+
+func TestRenderConfigFragments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/worker.ign":
+			w.Write([]byte(`{"ignition":{"version":"2.3.0"},"systemd":{"units":[{"name":"worker.service"}]}}`))
+		case "/gpu.ign":
+			w.Write([]byte(`{"ignition":{"version":"2.3.0"},"systemd":{"units":[{"name":"gpu.service"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// the metadata of the instance, by key
+	metadata := map[string]string{
+		providerUtil.FragmentKey("role:worker"): srv.URL + "/worker.ign",
+		providerUtil.FragmentKey("role-worker"): srv.URL + "/gpu.ign",
+	}
+	resolve := func(f *resource.Fetcher, name string) (*url.URL, error) {
+		v, ok := metadata[providerUtil.FragmentKey(name)]
+		if !ok {
+			return nil, providers.ErrFragmentNotFound
+		}
+		return url.Parse(v)
+	}
+
+	type in struct {
+		fragments []types.Fragment
+		verify    bool
+		noResolve bool
+	}
+	type out struct {
+		units []string
+		err   error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{},
+			out: out{units: []string{"base.service"}},
+		},
+		{
+			in:  in{fragments: []types.Fragment{"role:worker"}},
+			out: out{units: []string{"base.service", "worker.service"}},
+		},
+		{
+			in:  in{fragments: []types.Fragment{"role-worker", "role:worker"}},
+			out: out{units: []string{"base.service", "gpu.service", "worker.service"}},
+		},
+		{
+			in:  in{fragments: []types.Fragment{"role.worker"}},
+			out: out{err: providers.ErrFragmentNotFound},
+		},
+		{
+			in:  in{fragments: []types.Fragment{"role:worker"}, verify: true},
+			out: out{err: errors.ErrConfigFragmentsUnverified},
+		},
+		{
+			in:  in{fragments: []types.Fragment{"role:worker"}, noResolve: true},
+			out: out{err: providers.ErrNoFragmentResolver},
+		},
+	}
+
+	for i, test := range tests {
+		logger := log.New(true)
+		e := Engine{
+			Logger:    &logger,
+			Fetcher:   &resource.Fetcher{Logger: &logger},
+			OEMConfig: oem.MustGet("metal"),
+		}
+		if !test.in.noResolve {
+			e.resolveFragment = resolve
+		}
+		cfg, err := e.renderConfig(types.Config{
+			Ignition: types.Ignition{Config: types.IgnitionConfig{
+				Fragments:           test.in.fragments,
+				RequireVerification: test.in.verify,
+			}},
+			Systemd: types.Systemd{Units: []types.Unit{{Name: "base.service"}}},
+		})
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			continue
+		}
+		var units []string
+		for _, u := range cfg.Systemd.Units {
+			units = append(units, u.Name)
+		}
+		if !reflect.DeepEqual(test.out.units, units) {
+			t.Errorf("#%d: bad units: want %v, got %v", i, test.out.units, units)
+		}
+	}
+}
+
+func TestMissingConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-missing-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("IGNITION_SYSTEM_CONFIG_DIR")
+	defer os.Unsetenv("IGNITION_MISSING_CONFIG")
+	defer os.Unsetenv("IGNITION_CONFIG_REPORT_PATH")
+	os.Setenv("IGNITION_SYSTEM_CONFIG_DIR", dir)
+	os.Setenv("IGNITION_CONFIG_REPORT_PATH", filepath.Join(dir, "config-report.json"))
+	defer func(backoff time.Duration) { missingConfigInitialBackoff = backoff }(missingConfigInitialBackoff)
+	missingConfigInitialBackoff = 10 * time.Millisecond
+
+	type in struct {
+		policy string
+		// config supplied after the first fetch, if any
+		supplied string
+	}
+	type out struct {
+		unit string
+		err  error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{policy: ""},
+			out: out{err: errors.ErrEmpty},
+		},
+		{
+			in:  in{policy: "metal=continue,fail"},
+			out: out{err: errors.ErrEmpty},
+		},
+		{
+			in:  in{policy: "metal=fail"},
+			out: out{err: providers.ErrMissingConfig},
+		},
+		{
+			in:  in{policy: "retry", supplied: `{"ignition":{"version":"2.3.0"},"systemd":{"units":[{"name":"late.service"}]}}`},
+			out: out{unit: "late.service"},
+		},
+	}
+
+	for i, test := range tests {
+		os.Setenv("IGNITION_MISSING_CONFIG", test.in.policy)
+		os.Remove(filepath.Join(dir, "user.ign"))
+		if test.in.supplied != "" {
+			go func(config string) {
+				time.Sleep(50 * time.Millisecond)
+				ioutil.WriteFile(filepath.Join(dir, "user.ign"), []byte(config), 0644)
+			}(test.in.supplied)
+		}
+
+		logger := log.New(true)
+		e := Engine{
+			Logger:    &logger,
+			Fetcher:   &resource.Fetcher{Logger: &logger},
+			OEMConfig: oem.MustGet("metal"),
+		}
+		cfg, err := e.missingConfig()
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			continue
+		}
+		var unit string
+		if len(cfg.Systemd.Units) > 0 {
+			unit = cfg.Systemd.Units[0].Name
+		}
+		if unit != test.out.unit {
+			t.Errorf("#%d: bad unit: want %q, got %q", i, test.out.unit, unit)
+		}
+	}
+}
+
+func TestConfigReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-config-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("IGNITION_SYSTEM_CONFIG_DIR")
+	defer os.Unsetenv("IGNITION_CONFIG_REPORT_PATH")
+	os.Setenv("IGNITION_SYSTEM_CONFIG_DIR", dir)
+	path := filepath.Join(dir, "run/config-report.json")
+	os.Setenv("IGNITION_CONFIG_REPORT_PATH", path)
+
+	// a 2.3.0 config with an unknown key is valid, with a warning
+	if err := ioutil.WriteFile(filepath.Join(dir, "user.ign"), []byte(`{"ignition":{"version":"2.3.0"},"unknown":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	e := Engine{
+		Logger:    &logger,
+		Fetcher:   &resource.Fetcher{Logger: &logger},
+		OEMConfig: oem.MustGet("metal"),
+	}
+	if _, err := e.fetchProviderConfig(); err != nil {
+		t.Fatalf("fetching config: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading config report: %v", err)
+	}
+	var rep struct {
+		Platform string
+		Entries  []struct {
+			Kind    string
+			Message string
+		}
+	}
+	if err := json.Unmarshal(b, &rep); err != nil {
+		t.Fatalf("parsing config report %q: %v", b, err)
+	}
+	if rep.Platform != "metal" {
+		t.Errorf("bad platform: want %q, got %q", "metal", rep.Platform)
+	}
+	if len(rep.Entries) != 1 || rep.Entries[0].Kind != "warning" {
+		t.Errorf("bad entries: want one warning, got %s", b)
+	}
+}
+
+func TestConfigAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-config-annotations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("IGNITION_SYSTEM_CONFIG_DIR")
+	defer os.Unsetenv("IGNITION_CONFIG_REPORT_PATH")
+	os.Setenv("IGNITION_SYSTEM_CONFIG_DIR", dir)
+	path := filepath.Join(dir, "run/config-report.json")
+	os.Setenv("IGNITION_CONFIG_REPORT_PATH", path)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ignition":{"version":"2.4.0","annotations":{"run":"4712","component":"network"}}}`))
+	}))
+	defer srv.Close()
+	user := `{"ignition":{"version":"2.4.0","annotations":{"pipeline":"deploy","run":"4711"},` +
+		`"config":{"append":[{"source":"` + srv.URL + `/network.ign"}]}}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "user.ign"), []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	e := Engine{
+		Logger:    &logger,
+		Fetcher:   &resource.Fetcher{Logger: &logger},
+		OEMConfig: oem.MustGet("metal"),
+	}
+	cfg, err := e.fetchProviderConfig()
+	if err != nil {
+		t.Fatalf("fetching config: %v", err)
+	}
+	// the appended config's annotations win
+	merged := map[string]string{"pipeline": "deploy", "run": "4712", "component": "network"}
+	if !reflect.DeepEqual(cfg.Ignition.Annotations, merged) {
+		t.Errorf("bad annotations: want %v, got %v", merged, cfg.Ignition.Annotations)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading config report: %v", err)
+	}
+	var rep struct {
+		Annotations map[string]string
+		Configs     []struct {
+			Source      string
+			Annotations map[string]string
+		}
+	}
+	if err := json.Unmarshal(b, &rep); err != nil {
+		t.Fatalf("parsing config report %q: %v", b, err)
+	}
+	if !reflect.DeepEqual(rep.Annotations, merged) {
+		t.Errorf("bad annotations in the report: want %v, got %s", merged, b)
+	}
+	if len(rep.Configs) != 1 || !reflect.DeepEqual(rep.Configs[0].Annotations, map[string]string{"run": "4712", "component": "network"}) {
+		t.Errorf("bad annotations of the referenced config: %s", b)
+	}
+}
+
+func TestFetchReferencedConfigMirrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mirror.ign":
+			w.Write([]byte(`{"ignition":{"version":"2.4.0"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	e := Engine{Logger: &logger, Fetcher: &resource.Fetcher{Logger: &logger}}
+	ref := types.ConfigReference{
+		Source:  srv.URL + "/config.ign",
+		Mirrors: []types.ConfigMirror{{Source: strings.Replace(srv.URL, "://", "://user:secret@", 1) + "/mirror.ign?signature=secret"}},
+	}
+	if _, err := e.fetchReferencedConfig(ref); err != nil {
+		t.Fatalf("fetching config: %v", err)
+	}
+	// the mirror which served the config is recorded, not the primary
+	// source, without its credentials
+	if len(e.fetchedConfigs) != 1 || e.fetchedConfigs[0].Source != srv.URL+"/mirror.ign" {
+		t.Errorf("bad fetched configs: want the source %q, got %+v", srv.URL+"/mirror.ign", e.fetchedConfigs)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+const (
+	cmdlineHandOverFlag = "ignition.handover"
+)
+
+// readHandOver returns whether the filesystem which is to be handed over at
+// the given path may be, according to the "ignition.handover" kernel command
+// line option: "none" hands over no filesystem, a comma separated list of
+// paths only the filesystems handed over at these. Without the option, all
+// of them are handed over.
+func (e Engine) readHandOver() func(path string) bool {
+	value, ok, err := cmdlineValue(cmdlineHandOverFlag)
+	if err != nil {
+		e.Logger.Warning("%v, handing over filesystems as configured", err)
+		return func(string) bool { return true }
+	}
+	return parseCmdlineHandOver(value, ok)
+}
+
+func parseCmdlineHandOver(value string, ok bool) func(path string) bool {
+	if !ok {
+		return func(string) bool { return true }
+	}
+	paths := map[string]bool{}
+	for _, p := range strings.Split(value, ",") {
+		if p != "" && p != "none" {
+			paths[filepath.Clean(p)] = true
+		}
+	}
+	return func(path string) bool { return paths[filepath.Clean(path)] }
+}
+
+// handOverFilesystems mounts the filesystems of the config which are to be
+// handed over to the real root at their place in it and leaves them
+// mounted, generating runtime units for them so that the real root takes
+// them over without mounting them again. It returns a config referring to
+// them by their mount points. Filesystems which aren't handed over are
+// mounted by the stage itself and unmounted again when it's done.
+func (e Engine) handOverFilesystems(cfg types.Config) (types.Config, error) {
+	allowed := e.readHandOver()
+	filesystems := make([]types.Filesystem, len(cfg.Storage.Filesystems))
+	copy(filesystems, cfg.Storage.Filesystems)
+	u := util.Util{Logger: e.Logger}
+	for i, fs := range filesystems {
+		if fs.Mount == nil || fs.Mount.HandOver == nil {
+			continue
+		}
+		where := filepath.Clean(*fs.Mount.HandOver)
+		if !allowed(where) {
+			e.Logger.Info("not handing over filesystem %q at %q: disabled by the %s kernel option", fs.Name, where, cmdlineHandOverFlag)
+			continue
+		}
+		mnt := filepath.Join(e.Root, where)
+		mounted, err := util.IsMountPoint(mnt)
+		if err != nil {
+			return cfg, err
+		}
+		// the filesystem is still mounted if the stage is run again
+		if !mounted {
+			if err := os.MkdirAll(mnt, 0755); err != nil {
+				return cfg, fmt.Errorf("failed to create mount point %q: %v", mnt, err)
+			}
+			// the real root uses the mount as it is, so it isn't
+			// hardened like the filesystems Ignition unmounts again
+			if err := u.MountAutoFlags(fs.Mount.Device, mnt, 0); err != nil {
+				return cfg, err
+			}
+		}
+		if err := e.Logger.LogOp(
+			func() error { return writeHandOverUnit(*fs.Mount, where) },
+			"generating unit handing over %q at %q", fs.Mount.Device, where,
+		); err != nil {
+			return cfg, err
+		}
+		filesystems[i] = types.Filesystem{Name: fs.Name, Path: &mnt}
+	}
+	cfg.Storage.Filesystems = filesystems
+	return cfg, nil
+}
+
+// writeHandOverUnit generates the mount unit of a filesystem handed over at
+// where in distro.RuntimeUnitsDir(). The unit is ignored in the initramfs.
+func writeHandOverUnit(m types.Mount, where string) error {
+	name := unitNameForPath(where) + ".mount"
+	contents := fmt.Sprintf(`# Generated by Ignition, which mounted the filesystem in the initramfs
+[Unit]
+ConditionPathExists=!/etc/initrd-release
+Before=local-fs.target
+
+[Mount]
+What=%s
+Where=%s
+Type=%s
+`, m.Device, where, m.Format)
+
+	dir := distro.RuntimeUnitsDir()
+	wants := filepath.Join(dir, "local-fs.target.wants")
+	if err := os.MkdirAll(wants, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+		return err
+	}
+	link := filepath.Join(wants, name)
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(filepath.Join(dir, name), link)
+}
+
+// unitNameForPath escapes path like `systemd-escape --path`.
+func unitNameForPath(path string) string {
+	path = strings.Trim(filepath.Clean(path), "/")
+	if path == "" {
+		return "-"
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0,
+			!(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == ':' || c == '_' || c == '.'):
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestParseCmdlineHandOver(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		allowed []string
+		denied  []string
+	}{
+		{
+			cmdline: "root=/dev/sda1 ro",
+			allowed: []string{"/var", "/srv"},
+		},
+		{
+			cmdline: "ignition.handover=none",
+			denied:  []string{"/var", "/srv"},
+		},
+		{
+			cmdline: "ignition.handover=/var,/srv/data/ quiet",
+			allowed: []string{"/var", "/srv/data"},
+			denied:  []string{"/srv", "/home"},
+		},
+		{
+			cmdline: "ignition.handover=none ignition.handover=/home",
+			allowed: []string{"/home"},
+			denied:  []string{"/var"},
+		},
+	}
+
+	for i, test := range tests {
+		allowed := parseCmdlineHandOver(parseCmdlineValue([]byte(test.cmdline), cmdlineHandOverFlag))
+		for _, p := range test.allowed {
+			if !allowed(p) {
+				t.Errorf("#%d: %q not handed over", i, p)
+			}
+		}
+		for _, p := range test.denied {
+			if allowed(p) {
+				t.Errorf("#%d: %q handed over", i, p)
+			}
+		}
+	}
+}
+
+func TestUnitNameForPath(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "/", out: "-"},
+		{in: "/var", out: "var"},
+		{in: "/var/lib/docker/", out: "var-lib-docker"},
+		{in: "/srv/my-data", out: `srv-my\x2ddata`},
+		{in: "/.hidden/a b", out: `\x2ehidden-a\x20b`},
+	}
+
+	for i, test := range tests {
+		if out := unitNameForPath(test.in); out != test.out {
+			t.Errorf("#%d: bad unit name: want %q, got %q", i, test.out, out)
+		}
+	}
+}
+
+func TestWriteHandOverUnit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "handover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("IGNITION_RUNTIME_UNITS_DIR", dir)
+	defer os.Unsetenv("IGNITION_RUNTIME_UNITS_DIR")
+
+	m := types.Mount{Device: "/dev/disk/by-label/VAR", Format: "xfs"}
+	// generating the unit again, e.g. when the stage is run again, replaces it
+	for i := 0; i < 2; i++ {
+		if err := writeHandOverUnit(m, "/var/lib"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "var-lib.mount"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"What=/dev/disk/by-label/VAR", "Where=/var/lib", "Type=xfs", "ConditionPathExists=!/etc/initrd-release"} {
+		if !strings.Contains(string(contents), line+"\n") {
+			t.Errorf("unit lacks %q:\n%s", line, contents)
+		}
+	}
+	target, err := os.Readlink(filepath.Join(dir, "local-fs.target.wants", "var-lib.mount"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Join(dir, "var-lib.mount") {
+		t.Errorf("bad wants link: %q", target)
+	}
+}
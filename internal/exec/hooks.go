@@ -0,0 +1,116 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/supervisor"
+)
+
+// hookPath is the PATH hooks are run with.
+const hookPath = "/usr/sbin:/usr/bin:/sbin:/bin"
+
+// runStage runs the stage of the given name along with the hooks the config
+// declares for it. If a hook before the stage fails, the stage isn't run.
+func (e Engine) runStage(stageName string, cfg types.Config) error {
+	for _, h := range cfg.Ignition.Hooks {
+		if stages.Get(h.Stage) == nil {
+			e.Logger.Warning("ignoring hooks of unknown stage %q", h.Stage)
+		}
+	}
+
+	// don't start the stage once Ignition is being stopped
+	if err := e.Fetcher.BaseContext().Err(); err != nil {
+		return err
+	}
+	if err := e.runHooks(stageName, "before", cfg); err != nil {
+		return err
+	}
+	if writesFilesystems(stageName) {
+		var err error
+		if cfg, err = e.handOverFilesystems(cfg); err != nil {
+			return err
+		}
+		if err := e.ensureWritable(cfg); err != nil {
+			return err
+		}
+	}
+	var err error
+	if e.confines(stageName) {
+		err = e.runConfined(stageName, cfg)
+	} else {
+		err = stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher).Run(cfg)
+	}
+	if err != nil {
+		if writesFilesystems(stageName) {
+			return e.explainReadOnly(cfg, err)
+		}
+		return err
+	}
+	return e.runHooks(stageName, "after", cfg)
+}
+
+// runHooks runs the programs declared to run when ("before" or "after") the
+// stage of the given name, in the order they appear in the config.
+func (e Engine) runHooks(stageName, when string, cfg types.Config) error {
+	for _, h := range cfg.Ignition.Hooks {
+		if h.Stage != stageName {
+			continue
+		}
+		programs := h.Before
+		if when == "after" {
+			programs = h.After
+		}
+		for _, p := range programs {
+			if err := e.runHook(stageName, when, p); err != nil {
+				return fmt.Errorf("%s hook %q failed: %v", when, p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runHook runs a program from the hooks directory. Hooks only get a minimal
+// environment describing the stage they run for, and have to succeed.
+func (e Engine) runHook(stageName, when string, p types.HookProgram) error {
+	return e.Logger.LogOp(func() error {
+		var output bytes.Buffer
+		cmd := exec.Command(filepath.Join(distro.HooksDir(), p.Name), p.Args...)
+		cmd.Dir = "/"
+		cmd.Env = []string{
+			"PATH=" + hookPath,
+			"IGNITION_STAGE=" + stageName,
+			"IGNITION_HOOK=" + when,
+			"IGNITION_ROOT=" + e.Root,
+		}
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := supervisor.Run(cmd, distro.HelperTimeout())
+		for _, line := range strings.Split(strings.TrimRight(output.String(), "\n"), "\n") {
+			if line != "" {
+				e.Logger.Info("%s: %s", p.Name, line)
+			}
+		}
+		return err
+	}, "running %s hook %q", when, p.Name)
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestRunHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("IGNITION_HOOKS_DIR", dir)
+	defer os.Unsetenv("IGNITION_HOOKS_DIR")
+
+	record := filepath.Join(dir, "record.log")
+	scripts := map[string]string{
+		"record": "#!/bin/sh\necho $IGNITION_HOOK $IGNITION_STAGE $IGNITION_ROOT $* ${HOME:-nohome} >> " + record + "\n",
+		"fail":   "#!/bin/sh\necho failing\nexit 1\n",
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type in struct {
+		hooks []types.Hook
+		when  string
+	}
+	type out struct {
+		records []string
+		err     string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{
+				hooks: []types.Hook{
+					{Stage: "files", Before: []types.HookProgram{{Name: "fail"}}},
+					{Stage: "disks", Before: []types.HookProgram{{Name: "record", Args: []string{"a", "b"}}}, After: []types.HookProgram{{Name: "fail"}}},
+					{Stage: "disks", Before: []types.HookProgram{{Name: "record"}}},
+				},
+				when: "before",
+			},
+			out: out{records: []string{"before disks /sysroot a b nohome", "before disks /sysroot nohome"}},
+		},
+		{
+			in: in{
+				hooks: []types.Hook{
+					{Stage: "disks", After: []types.HookProgram{{Name: "record"}, {Name: "fail"}, {Name: "record"}}},
+				},
+				when: "after",
+			},
+			out: out{records: []string{"after disks /sysroot nohome"}, err: `after hook "fail" failed`},
+		},
+		{
+			in: in{
+				hooks: []types.Hook{
+					{Stage: "disks", Before: []types.HookProgram{{Name: "missing"}}},
+				},
+				when: "before",
+			},
+			out: out{err: `before hook "missing" failed`},
+		},
+	}
+
+	logger := log.New(true)
+	e := Engine{Logger: &logger, Root: "/sysroot"}
+	for i, test := range tests {
+		os.Remove(record)
+		err := e.runHooks("disks", test.in.when, types.Config{Ignition: types.Ignition{Hooks: test.in.hooks}})
+		if test.out.err == "" && err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if test.out.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.out.err)) {
+			t.Errorf("#%d: bad error: want %s, got %v", i, test.out.err, err)
+		}
+
+		var records []string
+		if b, err := ioutil.ReadFile(record); err == nil {
+			records = strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+		}
+		if !reflect.DeepEqual(test.out.records, records) {
+			t.Errorf("#%d: bad records: want %q, got %q", i, test.out.records, records)
+		}
+	}
+}
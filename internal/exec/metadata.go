@@ -0,0 +1,63 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// writeMetadataAttributes asks the provider for the core attributes of the
+// instance and writes them as an environment file, so units from the config
+// can consume them without a separate metadata agent. The file is only
+// written if the distribution asks for it, and providers that do not expose
+// any attributes leave no file behind.
+func (e *Engine) writeMetadataAttributes() {
+	if !distro.WriteMetadata() {
+		return
+	}
+	attrs, err := e.metadataAttributes()
+	if err != nil {
+		e.Logger.Warning("failed to fetch metadata attributes: %v", err)
+		return
+	}
+	if attrs == nil {
+		return
+	}
+
+	path := distro.MetadataAttributesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.Logger.Warning("failed to create directory for metadata attributes: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(path, []byte(config.FormatMetadataAttributes(e.OEMConfig.Name(), attrs)), 0644); err != nil {
+		e.Logger.Warning("failed to write metadata attributes: %v", err)
+		return
+	}
+	e.Logger.Info("wrote metadata attributes to %q", path)
+}
+
+// metadataAttributes returns the attributes of the instance the provider
+// reports, or nil if it doesn't report any.
+func (e Engine) metadataAttributes() (map[string]string, error) {
+	if e.fetchMetadata != nil {
+		return e.fetchMetadata(e.Fetcher)
+	}
+	return e.OEMConfig.FetchMetadata(e.Fetcher)
+}
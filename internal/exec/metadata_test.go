@@ -0,0 +1,76 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/providers"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestWriteMetadataAttributes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metadata", "ignition")
+	os.Setenv("IGNITION_METADATA_PATH", path)
+	defer os.Unsetenv("IGNITION_METADATA_PATH")
+	defer os.Unsetenv("IGNITION_WRITE_METADATA")
+
+	// out is empty if no file is written
+	tests := []struct {
+		enabled string
+		attrs   map[string]string
+		out     string
+	}{
+		{enabled: "", attrs: map[string]string{providers.MetadataRegion: "us-east1"}},
+		{enabled: "false", attrs: map[string]string{providers.MetadataRegion: "us-east1"}},
+		{enabled: "true"},
+		{enabled: "true", attrs: map[string]string{providers.MetadataRegion: "us-east1"}, out: "IGNITION_PLATFORM=\"metal\"\nIGNITION_REGION=\"us-east1\"\n"},
+	}
+
+	for i, test := range tests {
+		os.Remove(path)
+		os.Setenv("IGNITION_WRITE_METADATA", test.enabled)
+		logger := log.New(true)
+		e := Engine{
+			Logger:    &logger,
+			Fetcher:   &resource.Fetcher{Logger: &logger},
+			OEMConfig: oem.MustGet("metal"),
+			fetchMetadata: func(f *resource.Fetcher) (map[string]string, error) {
+				return test.attrs, nil
+			},
+		}
+		e.writeMetadataAttributes()
+
+		got, err := ioutil.ReadFile(path)
+		switch {
+		case test.out == "" && !os.IsNotExist(err):
+			t.Errorf("#%d: attributes written: %q, %v", i, got, err)
+		case test.out != "" && err != nil:
+			t.Errorf("#%d: reading attributes: %v", i, err)
+		case test.out != "" && string(got) != test.out:
+			t.Errorf("#%d: bad attributes: want %q, got %q", i, test.out, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/ostree"
+)
+
+// prepareOSTree checks that the config only writes to the paths of the
+// deployment which persist across updates, and mounts the stateroot's /var
+// at the deployment's /var if it isn't mounted yet, so that files written
+// there are found after booting. The returned function unmounts it again.
+func (e Engine) prepareOSTree(d *ostree.Deployment, cfg types.Config) (func(), error) {
+	cleanup := func() {}
+
+	// like the files stage, only use the last definition of a filesystem
+	onRoot := map[string]bool{}
+	for _, fs := range cfg.Storage.Filesystems {
+		onRoot[fs.Name] = fs.Path != nil && filepath.Clean(*fs.Path) == filepath.Clean(d.Root)
+	}
+	var paths []string
+	for _, entry := range storageEntries(cfg.Storage) {
+		if onRoot[entry.filesystem] {
+			paths = append(paths, entry.path)
+		}
+	}
+	refused := 0
+	for _, path := range paths {
+		if err := d.CheckPath(path); err != nil {
+			e.Logger.Crit("%v", err)
+			refused++
+		}
+	}
+	if refused != 0 {
+		return cleanup, fmt.Errorf("config writes to %d paths outside of /etc and /var of the ostree deployment", refused)
+	}
+
+	varPath := filepath.Join(d.Root, "var")
+	mounted, err := util.IsMountPoint(varPath)
+	if err != nil {
+		return cleanup, err
+	}
+	if mounted {
+		return cleanup, nil
+	}
+	if _, err := os.Stat(d.Var); err != nil {
+		return cleanup, fmt.Errorf("stateroot %q has no /var: %v", d.Stateroot, err)
+	}
+	if err := e.Logger.LogOp(
+		func() error { return syscall.Mount(d.Var, varPath, "", syscall.MS_BIND, "") },
+		"mounting %q at %q", d.Var, varPath,
+	); err != nil {
+		return cleanup, err
+	}
+	return func() {
+		e.Logger.LogOp(
+			func() error { return syscall.Unmount(varPath, 0) },
+			"unmounting %q", varPath,
+		)
+	}, nil
+}
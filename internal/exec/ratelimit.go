@@ -0,0 +1,71 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const cmdlineRateLimitFlag = "ignition.ratelimit"
+
+// rateLimit returns the rate in bytes per second fetches are limited to,
+// the lower of cfg and the "ignition.ratelimit" kernel command line option,
+// so that a config can't raise the limit of the machine it's applied to. 0
+// means unlimited.
+func (e Engine) rateLimit(cfg *int) (int64, error) {
+	var limit int64
+	if cfg != nil {
+		limit = int64(*cfg)
+	}
+	value, _, err := cmdlineValue(cmdlineRateLimitFlag)
+	if err != nil {
+		return 0, err
+	}
+	cmdline, err := parseCmdlineRateLimit(value)
+	if err != nil {
+		return 0, err
+	}
+	if cmdline > 0 && (limit == 0 || cmdline < limit) {
+		limit = cmdline
+	}
+	return limit, nil
+}
+
+// parseCmdlineRateLimit parses the value of the "ignition.ratelimit"
+// option, a number of bytes per second optionally followed by K, M or G for
+// multiples of 1024.
+func parseCmdlineRateLimit(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	digits, shift := value, uint(0)
+	switch value[len(value)-1] {
+	case 'K', 'k':
+		shift = 10
+	case 'M', 'm':
+		shift = 20
+	case 'G', 'g':
+		shift = 30
+	}
+	if shift != 0 {
+		digits = value[:len(value)-1]
+	}
+	limit, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || limit < 0 || limit > (1<<62)>>shift {
+		return 0, fmt.Errorf("invalid %s value %q", cmdlineRateLimitFlag, value)
+	}
+	return limit << shift, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"testing"
+)
+
+func TestParseCmdlineRateLimit(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		limit   int64
+		ok      bool
+	}{
+		{cmdline: "root=/dev/sda1 ro", ok: true},
+		{cmdline: "ignition.ratelimit=1000000", limit: 1000000, ok: true},
+		{cmdline: "ignition.ratelimit=10M ro", limit: 10 << 20, ok: true},
+		{cmdline: "ignition.ratelimit=512k", limit: 512 << 10, ok: true},
+		{cmdline: "ignition.ratelimit=1G ignition.ratelimit=0", limit: 0, ok: true},
+		{cmdline: "ignition.ratelimit=-1"},
+		{cmdline: "ignition.ratelimit=10MB"},
+		{cmdline: "ignition.ratelimit=M"},
+		{cmdline: "ignition.ratelimit=99999999999999999G"},
+	}
+
+	for i, test := range tests {
+		value, _ := parseCmdlineValue([]byte(test.cmdline), cmdlineRateLimitFlag)
+		limit, err := parseCmdlineRateLimit(value)
+		if (err == nil) != test.ok {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		if limit != test.limit {
+			t.Errorf("#%d: bad limit: want %d, got %d", i, test.limit, limit)
+		}
+	}
+}
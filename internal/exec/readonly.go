@@ -0,0 +1,64 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// writesFilesystems reports whether the stage of the given name writes to
+// the filesystems of the config.
+func writesFilesystems(stageName string) bool {
+	return stageName == "files"
+}
+
+// ensureWritable makes sure the filesystems of the config which are already
+// mounted, like the root filesystem, are mounted read-write, remounting
+// them if the distro allows it. Otherwise the stage would fail on the first
+// write with an error which doesn't name the mount.
+func (e Engine) ensureWritable(cfg types.Config) error {
+	u := util.Util{Logger: e.Logger}
+	for _, fs := range cfg.Storage.Filesystems {
+		if fs.Path == nil {
+			continue
+		}
+		// the stage reports missing mount points of the filesystems it uses
+		if err := u.EnsureWritable(*fs.Path, distro.RemountReadOnly()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// explainReadOnly replaces the error of a failed stage with one naming the
+// mount if one of the filesystems of the config became read-only while the
+// stage ran, e.g. as the filesystem ran into an error.
+func (e Engine) explainReadOnly(cfg types.Config, err error) error {
+	u := util.Util{Logger: e.Logger}
+	for _, fs := range cfg.Storage.Filesystems {
+		if fs.Path == nil {
+			continue
+		}
+		if roErr, ok := u.EnsureWritable(*fs.Path, false).(util.ReadOnlyError); ok {
+			return fmt.Errorf("%v, probably after an error of the filesystem: %v", roErr, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+const cmdlineRedirectsFlag = "ignition.redirects"
+
+// redirectPolicy returns the policy for following redirects of cfg, made
+// at least as strict as the one given by the "ignition.redirects" kernel
+// command line option, so that a config can't loosen the policy of the
+// machine it's applied to.
+func (e Engine) redirectPolicy(cfg types.Redirects) (types.Redirects, error) {
+	value, _, err := cmdlineValue(cmdlineRedirectsFlag)
+	if err != nil {
+		return types.Redirects{}, err
+	}
+	cmdline, err := parseCmdlineRedirects(value)
+	if err != nil {
+		return types.Redirects{}, err
+	}
+	return stricterRedirects(cfg, cmdline), nil
+}
+
+// parseCmdlineRedirects parses the value of the "ignition.redirects"
+// option, a comma separated list of the number of redirects to follow,
+// "same-host" and "no-downgrade".
+func parseCmdlineRedirects(value string) (types.Redirects, error) {
+	var policy types.Redirects
+	if value == "" {
+		return policy, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		switch item {
+		case "same-host":
+			policy.SameHost = true
+		case "no-downgrade":
+			policy.NoDowngrade = true
+		default:
+			limit, err := strconv.Atoi(item)
+			if err != nil || limit < 0 {
+				return types.Redirects{}, fmt.Errorf("invalid %s entry %q", cmdlineRedirectsFlag, item)
+			}
+			policy.Limit = &limit
+		}
+	}
+	return policy, nil
+}
+
+// stricterRedirects returns the policy following only the redirects both a
+// and b follow.
+func stricterRedirects(a, b types.Redirects) types.Redirects {
+	policy := types.Redirects{
+		Limit:       a.Limit,
+		SameHost:    a.SameHost || b.SameHost,
+		NoDowngrade: a.NoDowngrade || b.NoDowngrade,
+	}
+	if b.Limit != nil && (a.Limit == nil || *b.Limit < *a.Limit) {
+		policy.Limit = b.Limit
+	}
+	return policy
+}
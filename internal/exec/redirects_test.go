@@ -0,0 +1,82 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestParseCmdlineRedirects(t *testing.T) {
+	intp := func(i int) *int { return &i }
+	tests := []struct {
+		cmdline string
+		policy  types.Redirects
+		ok      bool
+	}{
+		{cmdline: "root=/dev/sda1 ro", ok: true},
+		{cmdline: "ignition.redirects=same-host", policy: types.Redirects{SameHost: true}, ok: true},
+		{cmdline: "ignition.redirects=3,no-downgrade ro", policy: types.Redirects{Limit: intp(3), NoDowngrade: true}, ok: true},
+		{cmdline: "ignition.redirects=0", policy: types.Redirects{Limit: intp(0)}, ok: true},
+		{cmdline: "ignition.redirects=same-host ignition.redirects=2", policy: types.Redirects{Limit: intp(2)}, ok: true},
+		{cmdline: "ignition.redirects=-1"},
+		{cmdline: "ignition.redirects=same-origin"},
+	}
+
+	for i, test := range tests {
+		value, _ := parseCmdlineValue([]byte(test.cmdline), cmdlineRedirectsFlag)
+		policy, err := parseCmdlineRedirects(value)
+		if (err == nil) != test.ok {
+			t.Errorf("#%d: unexpected error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(policy, test.policy) {
+			t.Errorf("#%d: bad policy: want %+v, got %+v", i, test.policy, policy)
+		}
+	}
+}
+
+func TestStricterRedirects(t *testing.T) {
+	intp := func(i int) *int { return &i }
+	tests := []struct {
+		a, b types.Redirects
+		out  types.Redirects
+	}{
+		{},
+		{
+			a:   types.Redirects{Limit: intp(5), SameHost: true},
+			b:   types.Redirects{NoDowngrade: true},
+			out: types.Redirects{Limit: intp(5), SameHost: true, NoDowngrade: true},
+		},
+		{
+			a:   types.Redirects{Limit: intp(5)},
+			b:   types.Redirects{Limit: intp(1)},
+			out: types.Redirects{Limit: intp(1)},
+		},
+		{
+			a:   types.Redirects{Limit: intp(0)},
+			b:   types.Redirects{Limit: intp(3)},
+			out: types.Redirects{Limit: intp(0)},
+		},
+	}
+
+	for i, test := range tests {
+		if out := stricterRedirects(test.a, test.b); !reflect.DeepEqual(out, test.out) {
+			t.Errorf("#%d: want %+v, got %+v", i, test.out, out)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// configReport is recorded in distro.ConfigReportPath() when the config is
+// fetched, so that automation can react to the warnings of the config and
+// its provider without parsing the journal, tell which configs the config
+// referenced, and trace the config back to what produced it through its
+// annotations.
+type configReport struct {
+	Platform    string            `json:"platform"`
+	Entries     []report.Entry    `json:"entries"`
+	Configs     []fetchedConfig   `json:"configs"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// fetchedConfig records the digest of a config referenced by another. The
+// sources of configs in data urls aren't recorded, as they might contain
+// secrets.
+type fetchedConfig struct {
+	Source      string            `json:"source,omitempty"`
+	SHA512      string            `json:"sha512"`
+	Verified    bool              `json:"verified"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// writeConfigReport records the report of the fetched config and the
+// annotations of the config rendered from it. Failing to record it doesn't
+// fail the stage, as the entries were logged already.
+func (e *Engine) writeConfigReport(r report.Report, annotations map[string]string) {
+	rep := configReport{Platform: e.OEMConfig.Name(), Entries: r.Entries, Configs: e.fetchedConfigs, Annotations: annotations}
+	if rep.Entries == nil {
+		rep.Entries = []report.Entry{}
+	}
+	if rep.Configs == nil {
+		rep.Configs = []fetchedConfig{}
+	}
+	b, err := json.Marshal(rep)
+	if err != nil {
+		e.Logger.Warning("failed to marshal config report: %v", err)
+		return
+	}
+
+	path := distro.ConfigReportPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.Logger.Warning("failed to create directory for config report: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		e.Logger.Warning("failed to write config report: %v", err)
+	}
+}
+
+// annotationsForLog returns the annotations of a config as a single line of
+// sorted key="value" pairs, for tracing the config in the journal.
+func annotationsForLog(annotations map[string]string) string {
+	pairs := make([]string, 0, len(annotations))
+	for key, value := range annotations {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
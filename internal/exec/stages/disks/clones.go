@@ -0,0 +1,106 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The storage stage is responsible for partitioning disks, creating RAID
+// arrays, formatting partitions, writing files, writing systemd units, and
+// writing network units.
+
+package disks
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// clonePartitions copies the contents of the partitions described in
+// config.Storage.Clones onto their targets.
+func (s stage) clonePartitions(config types.Config) error {
+	if len(config.Storage.Clones) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("clonePartitions")
+	defer s.Logger.PopPrefix()
+
+	devs := []string{}
+	for _, clone := range config.Storage.Clones {
+		devs = append(devs, clone.Source, clone.Target)
+	}
+
+	if err := s.waitOnDevicesAndCreateAliases(devs, "clones"); err != nil {
+		return err
+	}
+
+	for _, clone := range config.Storage.Clones {
+		srcAlias := util.DeviceAlias(clone.Source)
+		dstAlias := util.DeviceAlias(clone.Target)
+
+		err := s.Logger.LogOp(func() error {
+			progress := &progressLogger{logger: s.Logger, name: dstAlias}
+			return clonePartition(srcAlias, dstAlias, progress)
+		}, "cloning %q to %q", srcAlias, dstAlias)
+		if err != nil {
+			return err
+		}
+
+		// the filesystem signatures of the target changed
+		if err := s.waitForUdev(dstAlias, "clone"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clonePartition copies the whole of src onto dst, which must be at least as
+// large as src, writing the copied data to progress as well.
+func clonePartition(src, dst string, progress io.Writer) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	srcSize, err := in.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	dstSize, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if dstSize < srcSize {
+		return fmt.Errorf("target is smaller than the source (%d < %d bytes)", dstSize, srcSize)
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, io.TeeReader(in, progress)); err != nil {
+		return err
+	}
+	return out.Sync()
+}
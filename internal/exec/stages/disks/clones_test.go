@@ -0,0 +1,91 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClonePartition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-clone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+	tests := []struct {
+		source *[]byte
+		target []byte
+		fail   bool
+	}{
+		// target as large as the source
+		{source: &source, target: make([]byte, len(source))},
+		// larger target, whose tail is kept
+		{source: &source, target: bytes.Repeat([]byte("x"), len(source)+512)},
+		// smaller target
+		{source: &source, target: make([]byte, len(source)-1), fail: true},
+		// missing source
+		{target: make([]byte, len(source)), fail: true},
+	}
+
+	for i, test := range tests {
+		src := filepath.Join(dir, "source")
+		dst := filepath.Join(dir, "target")
+		os.Remove(src)
+		if test.source != nil {
+			if err := ioutil.WriteFile(src, *test.source, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(dst, test.target, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var progress bytes.Buffer
+		err := clonePartition(src, dst, &progress)
+		got, rerr := ioutil.ReadFile(dst)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if test.fail {
+			if err == nil {
+				t.Errorf("#%d: expected error", i)
+			} else if test.source == nil && !os.IsNotExist(err) {
+				t.Errorf("#%d: bad error: want a missing source, got %v", i, err)
+			}
+			if !bytes.Equal(got, test.target) {
+				t.Errorf("#%d: the target was modified", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		want := append(append([]byte{}, source...), test.target[len(source):]...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("#%d: bad target contents", i)
+		}
+		if !bytes.Equal(progress.Bytes(), source) {
+			t.Errorf("#%d: bad progress: want %d bytes, got %d bytes", i, len(source), progress.Len())
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/distro"
@@ -34,6 +35,10 @@ import (
 
 const (
 	name = "disks"
+
+	// heartbeatInterval is how often the progress of long running commands
+	// such as mkfs is logged
+	heartbeatInterval = 30 * time.Second
 )
 
 func init() {
@@ -56,6 +61,10 @@ func (creator) Name() string {
 	return name
 }
 
+func (creator) After() []string {
+	return []string{"fetch"}
+}
+
 type stage struct {
 	util.Util
 
@@ -67,21 +76,38 @@ func (stage) Name() string {
 }
 
 func (s stage) Run(config types.Config) error {
+	// The udev rules to trigger may name or own the devices used below or
+	// by the later stages, so they're applied even if there's nothing
+	// else to do.
+	if err := s.applyUdevRules(config); err != nil {
+		return fmt.Errorf("failed to apply udev rules: %v", err)
+	}
+
 	// Interacting with disks/partitions/raids/filesystems in general can cause
 	// udev races. If we do not need to  do anything, we also do not need to
 	// do the udevadm settle and can just return here. There is always an implicit
 	// root filesystem defined in the base config, so the lowest number of
 	// filesystems is 1.
-	if len(config.Storage.Disks) == 0 &&
+	if len(config.Storage.Images) == 0 &&
+		len(config.Storage.Disks) == 0 &&
+		len(config.Storage.Clones) == 0 &&
 		len(config.Storage.Raid) == 0 &&
 		len(config.Storage.Filesystems) == 1 {
 		return nil
 	}
 
+	if err := s.writeImages(config); err != nil {
+		return fmt.Errorf("failed to write images: %v", err)
+	}
+
 	if err := s.createPartitions(config); err != nil {
 		return fmt.Errorf("create partitions failed: %v", err)
 	}
 
+	if err := s.clonePartitions(config); err != nil {
+		return fmt.Errorf("failed to clone partitions: %v", err)
+	}
+
 	if err := s.createRaids(config); err != nil {
 		return fmt.Errorf("failed to create raids: %v", err)
 	}
@@ -28,6 +28,8 @@ import (
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/probe"
 )
 
 var (
@@ -64,11 +66,12 @@ func (s stage) createFilesystems(config types.Config) error {
 	results := make(chan error)
 
 	for i := 0; i < concurrency; i++ {
-		go func() {
+		go func(s stage, logger *log.Logger) {
+			s.Logger = logger
 			for fs := range work {
 				results <- s.createFilesystem(fs)
 			}
-		}()
+		}(s, s.Logger.Fork())
 	}
 
 	for _, fs := range fss {
@@ -107,7 +110,13 @@ func (s stage) createFilesystem(fs types.Mount) error {
 	} else if !fs.WipeFilesystem {
 		// If the filesystem isn't forcefully being created, then we need
 		// to check if it is of the correct type or that no filesystem exists.
+		// Data in a format the prober doesn't know must not be
+		// mistaken for an empty device.
 
+		if info.format == probe.Unknown {
+			s.Logger.Err("%q contains data matching no known signature and a filesystem wipe was not requested", fs.Device)
+			return ErrBadFilesystem
+		}
 		if (info.format == fs.Format || info.label == "OEM") &&
 			(fs.Label == nil || info.label == *fs.Label) &&
 			(fs.UUID == nil || canonicalizeFilesystemUUID(info.format, info.uuid) == canonicalizeFilesystemUUID(fs.Format, *fs.UUID)) {
@@ -179,8 +188,8 @@ func (s stage) createFilesystem(fs types.Mount) error {
 
 	devAlias := util.DeviceAlias(string(fs.Device))
 	args = append(args, devAlias)
-	if _, err := s.Logger.LogCmd(
-		exec.Command(mkfs, args...),
+	if _, err := s.Logger.LogLongCmd(
+		exec.Command(mkfs, args...), heartbeatInterval,
 		"creating %q filesystem on %q",
 		fs.Format, devAlias,
 	); err != nil {
@@ -241,20 +250,12 @@ func (s stage) readFilesystemInfo(fs types.Mount) (filesystemInfo, error) {
 	res := filesystemInfo{}
 	err := s.Logger.LogOp(
 		func() error {
-			var err error
-			res.format, err = util.FilesystemType(fs.Device)
-			if err != nil {
-				return err
-			}
-			res.uuid, err = util.FilesystemUUID(fs.Device)
-			if err != nil {
-				return err
-			}
-			res.label, err = util.FilesystemLabel(fs.Device)
+			found, err := probe.Device(fs.Device)
 			if err != nil {
 				return err
 			}
-			s.Logger.Info("found %s filesystem at %q with uuid %q and label %q", res.format, fs.Device, res.uuid, res.label)
+			res = filesystemInfo{format: found.Type, uuid: found.UUID, label: found.Label}
+			s.Logger.Info("probed %q: found %s", fs.Device, found)
 			return nil
 		},
 		"determining filesystem type of %q", fs.Device,
@@ -0,0 +1,125 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Disk images of storage.images are written onto whole devices before the
+// disks are partitioned. Images are usually larger than the memory of the
+// initramfs, so they are streamed onto the device as they are fetched
+// rather than downloaded first.
+
+package disks
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+const (
+	// log the progress of image writes every progressInterval bytes
+	progressInterval = 256 * 1024 * 1024
+)
+
+// writeImages writes the disk images described in config.Storage.Images
+// onto their devices.
+func (s stage) writeImages(config types.Config) error {
+	if len(config.Storage.Images) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("writeImages")
+	defer s.Logger.PopPrefix()
+
+	devs := []string{}
+	for _, image := range config.Storage.Images {
+		devs = append(devs, image.Device)
+	}
+
+	if err := s.waitOnDevicesAndCreateAliases(devs, "images"); err != nil {
+		return err
+	}
+
+	for _, image := range config.Storage.Images {
+		devAlias := util.DeviceAlias(image.Device)
+
+		err := s.Logger.LogOp(func() error {
+			return s.writeImage(image, devAlias)
+		}, "writing image %q to %q", image.Source, devAlias)
+		if err != nil {
+			return err
+		}
+
+		// the image most likely contains a new partition table
+		if err := s.waitForUdev(devAlias, "image"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeImage streams the image onto the device, decompressing it on the way.
+// The hash of the verification is computed along the way and only checked
+// once the whole image is on the device, so an image failing verification
+// has already overwritten the device when the stage fails.
+func (s stage) writeImage(image types.Image, devAlias string) error {
+	// reuse PrepareFetch for parsing the verification and headers, the image
+	// is then written to the device directly rather than to a file
+	op := s.PrepareFetch(s.Logger, types.File{
+		Node: types.Node{Path: image.Device},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Compression:  image.Compression,
+				HTTPHeaders:  image.HTTPHeaders,
+				Source:       image.Source,
+				Verification: image.Verification,
+			},
+		},
+	})
+	if op == nil {
+		return fmt.Errorf("failed to resolve image %q", image.Source)
+	}
+
+	// S3 fetches read the device back to verify the hash
+	dev, err := os.OpenFile(devAlias, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	opts := op.FetchOptions
+	opts.Progress = &progressLogger{logger: s.Logger, name: devAlias}
+	if err := s.Fetcher.Fetch(op.Url, dev, opts); err != nil {
+		return err
+	}
+	return dev.Sync()
+}
+
+// progressLogger counts the bytes written to it and periodically logs the
+// total.
+type progressLogger struct {
+	logger  *log.Logger
+	name    string
+	written int64
+}
+
+func (p *progressLogger) Write(b []byte) (int, error) {
+	before := p.written / progressInterval
+	p.written += int64(len(b))
+	if p.written/progressInterval != before {
+		p.logger.Info("wrote %d MiB to %q", p.written/(1024*1024), p.name)
+	}
+	return len(b), nil
+}
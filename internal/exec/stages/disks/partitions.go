@@ -21,13 +21,19 @@ package disks
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/gpt"
+	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/sgdisk"
 )
 
@@ -52,7 +58,144 @@ func (s stage) createPartitions(config types.Config) error {
 		return err
 	}
 
-	for _, dev := range config.Storage.Disks {
+	groups, err := groupDisksByDevice(config.Storage.Disks)
+	if err != nil {
+		return err
+	}
+
+	// Partition independent disks concurrently. Entries referring to the same
+	// device, or to devices stacked on each other, are partitioned in order
+	// by the same goroutine.
+	results := make(chan error)
+	for _, group := range groups {
+		go func(group []types.Disk, logger *log.Logger) {
+			results <- s.partitionDisks(group, logger)
+		}(group, s.Logger.Fork())
+	}
+
+	// Return combined errors
+	var errs []string
+	for range groups {
+		if err := <-results; err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+// sysClassBlock lists the block devices of the kernel; replaced in tests.
+var sysClassBlock = "/sys/class/block"
+
+// groupDisksByDevice groups the disks which must be partitioned one after the
+// other, see groupDisks.
+func groupDisksByDevice(disks []types.Disk) ([][]types.Disk, error) {
+	devs := make([]string, len(disks))
+	for i, disk := range disks {
+		dev, err := filepath.EvalSymlinks(util.DeviceAlias(string(disk.Device)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve device alias for %q: %v", disk.Device, err)
+		}
+		devs[i] = dev
+	}
+	return groupDisks(disks, devs), nil
+}
+
+// groupDisks groups the disks, which are at the given devices, that must be
+// partitioned one after the other: those at the same device, and those
+// stacked on each other, like a RAID array and the disks of its members.
+// Groups keep the order of the config, except that the disks an array is
+// built from are partitioned before the array.
+func groupDisks(disks []types.Disk, devs []string) [][]types.Disk {
+	// the disks are joined into groups through the devices they're at or
+	// stacked on
+	group := make([]int, len(disks))
+	depth := make([]int, len(disks))
+	owner := map[string]int{}
+	for i, dev := range devs {
+		group[i] = i
+		var lower []string
+		depth[i] = lowerDisks(filepath.Base(dev), &lower)
+		for _, name := range append(lower, filepath.Base(dev)) {
+			j, ok := owner[name]
+			if !ok {
+				owner[name] = i
+				continue
+			}
+			// merge the group of j into the group of i
+			from, to := group[j], group[i]
+			if from == to {
+				continue
+			}
+			if from < to {
+				from, to = to, from
+			}
+			for k := range group[:i+1] {
+				if group[k] == from {
+					group[k] = to
+				}
+			}
+		}
+	}
+
+	var groups [][]types.Disk
+	index := map[int]int{}
+	var order [][]int
+	for i := range disks {
+		n, ok := index[group[i]]
+		if !ok {
+			n = len(order)
+			index[group[i]] = n
+			order = append(order, nil)
+		}
+		order[n] = append(order[n], i)
+	}
+	for _, members := range order {
+		sort.SliceStable(members, func(a, b int) bool {
+			return depth[members[a]] < depth[members[b]]
+		})
+		var g []types.Disk
+		for _, i := range members {
+			g = append(g, disks[i])
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// lowerDisks adds the disks the named block device is stacked on, like the
+// disks of the members of an array, to lower and returns how many layers
+// deep the device is stacked. Partitions count as their disk.
+func lowerDisks(name string, lower *[]string) int {
+	slaves, err := ioutil.ReadDir(filepath.Join(sysClassBlock, name, "slaves"))
+	if err != nil {
+		return 0
+	}
+	depth := 0
+	for _, slave := range slaves {
+		disk := slave.Name()
+		if _, err := os.Stat(filepath.Join(sysClassBlock, disk, "partition")); err == nil {
+			if real, err := filepath.EvalSymlinks(filepath.Join(sysClassBlock, disk)); err == nil {
+				disk = filepath.Base(filepath.Dir(real))
+			}
+		}
+		*lower = append(*lower, disk)
+		if d := lowerDisks(disk, lower) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// partitionDisks partitions disks in order, using its own logger so it can
+// run concurrently with other calls.
+func (s stage) partitionDisks(disks []types.Disk, logger *log.Logger) error {
+	s.Logger = logger
+	for _, dev := range disks {
 		devAlias := util.DeviceAlias(string(dev.Device))
 
 		err := s.Logger.LogOp(func() error {
@@ -106,7 +249,7 @@ func partitionShouldBeInspected(part types.Partition) bool {
 // and end sector should be. It runs sgdisk --pretend to determine what the partitions would look like if
 // everything specified were to be (re)created.
 func (s stage) getRealStartAndSize(dev types.Disk, devAlias string, existanceMap map[int]types.Partition) ([]types.Partition, error) {
-	op := sgdisk.Begin(s.Logger, devAlias)
+	op := s.beginPartitioning(devAlias)
 	for _, part := range dev.Partitions {
 		info, exists := existanceMap[part.Number]
 		if exists {
@@ -133,17 +276,11 @@ func (s stage) getRealStartAndSize(dev types.Disk, devAlias string, existanceMap
 	partitionsToInspect := []int{}
 	for _, part := range dev.Partitions {
 		if partitionShouldBeInspected(part) {
-			op.Info(part.Number)
 			partitionsToInspect = append(partitionsToInspect, part.Number)
 		}
 	}
 
-	output, err := op.Pretend()
-	if err != nil {
-		return nil, err
-	}
-
-	realDimensions, err := parseSgdiskPretend(output, partitionsToInspect)
+	realDimensions, err := op.resolve(partitionsToInspect)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +307,67 @@ type sgdiskOutput struct {
 	size  int
 }
 
+// partitionOperation is a set of changes to the partition table of a disk,
+// carried out either by sgdisk or natively.
+type partitionOperation interface {
+	CreatePartition(types.Partition)
+	DeletePartition(int)
+	WipeTable(bool)
+	Commit() error
+
+	// resolve returns the start and size the partitions with the given
+	// numbers would have if the operation was committed.
+	resolve(partitionNumbers []int) (map[int]sgdiskOutput, error)
+}
+
+// beginPartitioning begins an operation on the partition table of devAlias.
+// The native GPT implementation is used if the distro asks for it or sgdisk
+// isn't available.
+func (s stage) beginPartitioning(devAlias string) partitionOperation {
+	if _, err := os.Stat(distro.SgdiskCmd()); distro.NativeGPT() || err != nil {
+		return gptOperation{gpt.Begin(s.Logger, devAlias)}
+	}
+	return sgdiskOperation{sgdisk.Begin(s.Logger, devAlias)}
+}
+
+type sgdiskOperation struct {
+	*sgdisk.Operation
+}
+
+func (op sgdiskOperation) resolve(partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	for _, num := range partitionNumbers {
+		op.Info(num)
+	}
+	output, err := op.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	return parseSgdiskPretend(output, partitionNumbers)
+}
+
+type gptOperation struct {
+	*gpt.Operation
+}
+
+func (op gptOperation) resolve(partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	parts, err := op.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	if len(partitionNumbers) == 0 {
+		return nil, nil
+	}
+	output := map[int]sgdiskOutput{}
+	for _, num := range partitionNumbers {
+		p, ok := parts[num]
+		if !ok {
+			return nil, fmt.Errorf("partition %d would not exist", num)
+		}
+		output[num] = sgdiskOutput{start: int(p.Start), size: int(p.Size())}
+	}
+	return output, nil
+}
+
 // parseLine takes a regexp that captures an int and a string to match on. On success it returns
 // the captured int and nil. If the regexp does not match it returns -1 and nil. If it encountered
 // an error it returns 0 and the error.
@@ -301,7 +499,7 @@ func (p PartitionList) Swap(i, j int) {
 // partitionDisk partitions devAlias according to the spec given by dev
 func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 	if dev.WipeTable {
-		op := sgdisk.Begin(s.Logger, devAlias)
+		op := s.beginPartitioning(devAlias)
 		s.Logger.Info("wiping partition table requested on %q", devAlias)
 		op.WipeTable(true)
 		op.Commit()
@@ -310,7 +508,7 @@ func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 	// Ensure all partitions with number 0 are last
 	sort.Stable(PartitionList(dev.Partitions))
 
-	op := sgdisk.Begin(s.Logger, devAlias)
+	op := s.beginPartitioning(devAlias)
 
 	originalParts, err := s.getPartitionMap(devAlias)
 	if err != nil {
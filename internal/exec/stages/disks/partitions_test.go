@@ -0,0 +1,99 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestGroupDisks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// sda1 and sdb1 are the members of md127, which is the member of
+	// md126, like in /sys/class/block
+	devices := filepath.Join(dir, "devices")
+	class := filepath.Join(dir, "class")
+	for _, p := range []string{"sda/sda1", "sdb/sdb1", "sdc", "md127/slaves", "md126/slaves"} {
+		if err := os.MkdirAll(filepath.Join(devices, p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []string{"sda/sda1/partition", "sdb/sdb1/partition", "md127/slaves/sda1", "md127/slaves/sdb1", "md126/slaves/md127"} {
+		if err := ioutil.WriteFile(filepath.Join(devices, p), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(class, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, p := range map[string]string{"sda": "sda", "sda1": "sda/sda1", "sdb": "sdb", "sdb1": "sdb/sdb1", "sdc": "sdc", "md127": "md127", "md126": "md126"} {
+		if err := os.Symlink(filepath.Join(devices, p), filepath.Join(class, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sysClassBlock = class
+	defer func() { sysClassBlock = "/sys/class/block" }()
+
+	tests := []struct {
+		in  []string
+		out [][]string
+	}{
+		{
+			in:  []string{"/dev/sda", "/dev/sdb", "/dev/sdc"},
+			out: [][]string{{"/dev/sda"}, {"/dev/sdb"}, {"/dev/sdc"}},
+		},
+		{
+			in:  []string{"/dev/sda", "/dev/sdc", "/dev/sda"},
+			out: [][]string{{"/dev/sda", "/dev/sda"}, {"/dev/sdc"}},
+		},
+		{
+			// arrays are partitioned after the disks of their members
+			in:  []string{"/dev/md127", "/dev/sdc", "/dev/sdb", "/dev/sda"},
+			out: [][]string{{"/dev/sdb", "/dev/sda", "/dev/md127"}, {"/dev/sdc"}},
+		},
+		{
+			in:  []string{"/dev/sda", "/dev/md126", "/dev/sdc", "/dev/md127"},
+			out: [][]string{{"/dev/sda", "/dev/md127", "/dev/md126"}, {"/dev/sdc"}},
+		},
+	}
+
+	for i, test := range tests {
+		var disks []types.Disk
+		for _, dev := range test.in {
+			disks = append(disks, types.Disk{Device: dev})
+		}
+		var out [][]string
+		for _, group := range groupDisks(disks, test.in) {
+			var devs []string
+			for _, disk := range group {
+				devs = append(devs, disk.Device)
+			}
+			out = append(out, devs)
+		}
+		if !reflect.DeepEqual(test.out, out) {
+			t.Errorf("#%d: bad groups: want %v, got %v", i, test.out, out)
+		}
+	}
+}
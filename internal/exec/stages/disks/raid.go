@@ -69,8 +69,8 @@ func (s stage) createRaids(config types.Config) error {
 			args = append(args, util.DeviceAlias(string(dev)))
 		}
 
-		if _, err := s.Logger.LogCmd(
-			exec.Command(distro.MdadmCmd(), args...),
+		if _, err := s.Logger.LogLongCmd(
+			exec.Command(distro.MdadmCmd(), args...), heartbeatInterval,
 			"creating %q", md.Name,
 		); err != nil {
 			return fmt.Errorf("mdadm failed: %v", err)
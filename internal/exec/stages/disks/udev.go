@@ -0,0 +1,70 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// applyUdevRules installs the udev rules marked with trigger into the
+// runtime rules directory of the initramfs and replays the events of the
+// existing devices, so the devices the later stages use are set up by
+// them. The files stage writes all rules into the real root.
+func (s stage) applyUdevRules(config types.Config) error {
+	var rules []types.UdevRule
+	for _, r := range config.Storage.UdevRules {
+		if r.Trigger {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("applyUdevRules")
+	defer s.Logger.PopPrefix()
+
+	dir := distro.RuntimeUdevRulesDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, r := range rules {
+		path := filepath.Join(dir, util.UdevRuleFileName(r))
+		if err := s.Logger.LogOp(func() error {
+			return ioutil.WriteFile(path, []byte(util.UdevRuleContents(r)), 0644)
+		}, "writing udev rule %q", path); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.Logger.LogCmd(
+		exec.Command(distro.UdevadmCmd(), "control", "--reload"),
+		"reloading udev rules"); err != nil {
+		return fmt.Errorf("udevadm control failed: %v", err)
+	}
+	if _, err := s.Logger.LogCmd(
+		exec.Command(distro.UdevadmCmd(), "trigger", "--settle"),
+		"triggering uevents for the udev rules"); err != nil {
+		return fmt.Errorf("udevadm trigger failed: %v", err)
+	}
+	return nil
+}
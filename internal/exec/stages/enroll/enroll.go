@@ -0,0 +1,160 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The enroll stage is responsible for requesting the machine certificates
+// listed in the config and writing them, along with their keys, to the root
+// filesystem.
+
+package enroll
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	name = "enroll"
+)
+
+var (
+	ErrNoHostname = errors.New("no commonName given and the root filesystem has no hostname in /etc/hostname")
+)
+
+func init() {
+	stages.Register(creator{})
+}
+
+type creator struct{}
+
+func (creator) Create(logger *log.Logger, root string, f resource.Fetcher) stages.Stage {
+	return &stage{
+		Util: util.Util{
+			DestDir: root,
+			Root:    root,
+			IsRoot:  true,
+			Logger:  logger,
+			Fetcher: f,
+		},
+	}
+}
+
+func (creator) Name() string {
+	return name
+}
+
+func (creator) After() []string {
+	return []string{"files"}
+}
+
+type stage struct {
+	util.Util
+}
+
+func (stage) Name() string {
+	return name
+}
+
+func (s stage) Run(config types.Config) error {
+	for _, c := range config.Certificates {
+		if err := s.enroll(c); err != nil {
+			return fmt.Errorf("failed to enroll certificate %q: %v", c.CertificatePath, err)
+		}
+	}
+	return nil
+}
+
+// enroll requests the certificate and writes the key and the certificate
+// chain to their paths.
+func (s stage) enroll(c types.Certificate) error {
+	commonName := c.CommonName
+	if commonName == "" {
+		var err error
+		if commonName, err = s.hostname(); err != nil {
+			return err
+		}
+	}
+
+	var key, chain []byte
+	err := s.Logger.LogOp(func() error {
+		var err error
+		switch c.Protocol {
+		case "est":
+			key, chain, err = s.enrollEST(c, commonName)
+		default:
+			err = fmt.Errorf("unsupported protocol %q", c.Protocol)
+		}
+		return err
+	}, "requesting certificate for %q from %q", commonName, c.Server)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeFile(c.KeyPath, key, 0600); err != nil {
+		return err
+	}
+	return s.writeFile(c.CertificatePath, chain, 0644)
+}
+
+// hostname returns the hostname the machine boots with, from /etc/hostname
+// of the root filesystem, which the files stage may have written. The
+// hostname of the initramfs is usually localhost or a temporary name.
+func (s stage) hostname() (string, error) {
+	path, err := s.FollowPath("/etc/hostname")
+	if err != nil {
+		return "", err
+	}
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", ErrNoHostname
+	} else if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	return "", ErrNoHostname
+}
+
+func (s stage) writeFile(path string, contents []byte, mode int) error {
+	u, err := url.Parse(dataurl.EncodeBytes(contents))
+	if err != nil {
+		return err
+	}
+	op := &util.FetchOp{
+		Path:      path,
+		Url:       *u,
+		Mode:      configUtil.IntToPtr(mode),
+		Overwrite: configUtil.BoolToPtr(true),
+	}
+	return s.Logger.LogOp(
+		func() error { return s.PerformFetch(op) },
+		"writing file %q", path,
+	)
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enroll
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		contents *string
+		hostname string
+		err      error
+	}{
+		{contents: strp("node1\n"), hostname: "node1"},
+		{contents: strp("# set by the installer\n\n  node2  \n"), hostname: "node2"},
+		{contents: strp("\n"), err: ErrNoHostname},
+		{err: ErrNoHostname},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "ignition-enroll")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if test.contents != nil {
+				if err := os.MkdirAll(filepath.Join(dir, "etc"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, "etc/hostname"), []byte(*test.contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := stage{Util: util.Util{DestDir: dir, IsRoot: true}}
+			hostname, err := s.hostname()
+			if err != test.err {
+				t.Fatalf("bad error: want %v, got %v", test.err, err)
+			}
+			if hostname != test.hostname {
+				t.Errorf("bad hostname: want %q, got %q", test.hostname, hostname)
+			}
+		})
+	}
+}
+
+func strp(s string) *string {
+	return &s
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enroll
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+var (
+	ErrNoCertificate = errors.New("the response does not contain a certificate for the requested key")
+
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// enrollEST generates a key and requests a certificate for it using the EST
+// simpleenroll operation (RFC 7030). It returns the PEM-encoded key and
+// certificate chain, leaf first.
+func (s stage) enrollEST(c types.Certificate, commonName string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: c.DNSNames,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.Path = path.Join(u.Path, "simpleenroll")
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/pkcs10")
+	headers.Set("Content-Transfer-Encoding", "base64")
+	if c.Token != "" {
+		headers.Set("Authorization", "Bearer "+c.Token)
+	}
+	body := []byte(base64.StdEncoding.EncodeToString(csr))
+	resp, err := s.Fetcher.PostToBuffer(*u, body, resource.FetchOptions{
+		Headers: headers,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs, err := parseCertsOnly(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var chain bytes.Buffer
+	leaf := -1
+	for i, cert := range certs {
+		if pub, ok := cert.PublicKey.(*ecdsa.PublicKey); ok && pub.X.Cmp(key.X) == 0 && pub.Y.Cmp(key.Y) == 0 {
+			leaf = i
+		}
+	}
+	if leaf < 0 {
+		return nil, nil, ErrNoCertificate
+	}
+	certs[0], certs[leaf] = certs[leaf], certs[0]
+	for _, cert := range certs {
+		pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), chain.Bytes(), nil
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+// parseCertsOnly returns the certificates of a base64-encoded, degenerate
+// PKCS#7 SignedData structure, as returned by EST servers.
+func parseCertsOnly(b64 []byte) ([]*x509.Certificate, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(b64)), ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("the response is not a PKCS#7 SignedData structure")
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificates(sd.Certificates.Bytes)
+}
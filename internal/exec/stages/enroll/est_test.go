@@ -0,0 +1,257 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enroll
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestParseCertsOnly(t *testing.T) {
+	var certs [][]byte
+	var raw []byte
+	for i := 0; i < 2; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 1)),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, der)
+		raw = append(raw, der...)
+	}
+
+	der, err := certsOnly(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// EST servers may wrap the base64 encoding
+	b64 := base64.StdEncoding.EncodeToString(der)
+	b64 = b64[:10] + "\r\n" + b64[10:]
+
+	parsed, err := parseCertsOnly([]byte(b64))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(parsed) != len(certs) {
+		t.Fatalf("bad number of certificates: want %d, got %d", len(certs), len(parsed))
+	}
+	for i := range certs {
+		if string(parsed[i].Raw) != string(certs[i]) {
+			t.Errorf("#%d: bad certificate", i)
+		}
+	}
+}
+
+// certsOnly returns a degenerate PKCS#7 SignedData structure carrying the
+// concatenated DER certificates raw.
+func certsOnly(raw []byte) ([]byte, error) {
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	data, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+	}{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      asn1.RawValue{FullBytes: data},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      emptySet,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// asn1.Marshal ignores the tags of RawValue fields, so wrap the content
+	// in its explicit tag by hand
+	return asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+func TestEnrollEST(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "est ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, &caTmpl, &caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/.well-known/est/simpleenroll" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/pkcs10" {
+			http.Error(w, "bad headers", http.StatusUnauthorized)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		der, err := base64.StdEncoding.DecodeString(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err == nil {
+			err = csr.CheckSignature()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		leafTmpl := x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		leafDER, err := x509.CreateCertificate(rand.Reader, &leafTmpl, &caTmpl, csr.PublicKey, caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// the leaf doesn't have to come first
+		resp, err := certsOnly(append(caDER, leafDER...))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pkcs7-mime; smime-type=certs-only")
+		w.Write([]byte(base64.StdEncoding.EncodeToString(resp)))
+	}))
+	defer srv.Close()
+
+	root, err := ioutil.TempDir("", "ignition-enroll-est")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		IsRoot:  true,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{Certificates: []types.Certificate{{
+		CertificatePath: "/etc/pki/machine.crt",
+		CommonName:      "node1.example.com",
+		DNSNames:        []string{"node1.example.com", "node1"},
+		KeyPath:         "/etc/pki/machine.key",
+		Protocol:        "est",
+		Server:          srv.URL + "/.well-known/est",
+		Token:           "secret",
+	}}}
+	if err := s.Run(config); err != nil {
+		t.Fatalf("enrolling: %v", err)
+	}
+
+	read := func(path string, mode os.FileMode) *pem.Block {
+		p := filepath.Join(root, path)
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("bad mode of %s: want %o, got %o", path, mode, info.Mode().Perm())
+		}
+		contents, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(contents)
+		if block == nil {
+			t.Fatalf("%s isn't PEM-encoded", path)
+		}
+		return block
+	}
+
+	keyBlock := read("etc/pki/machine.key", 0600)
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		t.Fatalf("parsing key: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(read("etc/pki/machine.crt", 0644).Bytes)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+	if leaf.Subject.CommonName != "node1.example.com" || !reflect.DeepEqual(leaf.DNSNames, []string{"node1.example.com", "node1"}) {
+		t.Errorf("bad certificate: common name %q, dns names %v", leaf.Subject.CommonName, leaf.DNSNames)
+	}
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !pub.Equal(key.(*ecdsa.PrivateKey).Public()) {
+		t.Errorf("the certificate isn't for the written key")
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("the certificate isn't signed by the CA: %v", err)
+	}
+}
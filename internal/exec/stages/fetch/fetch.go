@@ -49,6 +49,10 @@ func (creator) Name() string {
 	return name
 }
 
+func (creator) After() []string {
+	return nil
+}
+
 type stage struct {
 	util.Util
 }
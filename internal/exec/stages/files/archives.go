@@ -0,0 +1,335 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	ignConfig "github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+const (
+	// the modes of entries of archives which weren't created on unix, and
+	// thus don't carry unix modes
+	archiveFileMode = 0644
+	archiveDirMode  = 0755
+
+	// the longest symlink target read from an archive
+	archiveMaxLinkTarget = 4096
+)
+
+type archiveEntry types.Archive
+
+func (tmp archiveEntry) getPath() string {
+	return types.Archive(tmp).Path
+}
+
+func (tmp archiveEntry) create(l *log.Logger, u util.Util) error {
+	a := types.Archive(tmp)
+
+	extract := extractArchive
+	switch a.Format {
+	case "image":
+		extract = extractImage
+	case "git":
+		extract = extractGitTree
+	}
+	if err := l.LogOp(
+		func() error { return extract(l, u, a) },
+		"extracting %s archive %q to %q", a.Format, a.Source, a.Path,
+	); err != nil {
+		return fmt.Errorf("failed to extract archive to %q: %v", a.Path, err)
+	}
+	return nil
+}
+
+// extractArchive fetches the archive into its destination, verifying it
+// as it's fetched, and extracts it there. The archive's entries are owned by
+// the archive's user and group, root by default.
+func extractArchive(l *log.Logger, u util.Util, a types.Archive) error {
+	op := u.PrepareFetch(l, types.File{
+		Node: types.Node{Path: a.Path},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Source:       a.Source,
+				HTTPHeaders:  a.HTTPHeaders,
+				Verification: a.Verification,
+			},
+		},
+	})
+	if op == nil {
+		return fmt.Errorf("failed to resolve archive %q", a.Source)
+	}
+	uid, gid, err := u.ResolveNodeUidAndGid(types.Node{User: a.User, Group: a.Group}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	dest, err := u.JoinPath(a.Path)
+	if err != nil {
+		return err
+	}
+	if err := createArchiveDirs(dest, archiveDirMode, uid, gid); err != nil {
+		return err
+	}
+	u.RecordManaged(a.Path)
+
+	// fetch to the destination's filesystem rather than to memory, as
+	// archives may be large
+	tmp, err := ioutil.TempFile(dest, "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := u.Fetcher.Fetch(op.Url, tmp, op.FetchOptions); err != nil {
+		return err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	r, err := zip.NewReader(tmp, info.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range r.File {
+		name, err := archiveEntryName(zf.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		var linkTarget string
+		if zf.Mode()&os.ModeSymlink != 0 {
+			if linkTarget, err = readZipLink(zf); err != nil {
+				return fmt.Errorf("failed to read %q: %v", zf.Name, err)
+			}
+		}
+		if err := checkRestrictedEntry(u, a, name, zf.Mode(), linkTarget, nil); err != nil {
+			return err
+		}
+		target, err := u.JoinPath(a.Path, name)
+		if err != nil {
+			return err
+		}
+		if err := extractZipEntry(zf, target, linkTarget, uid, gid); err != nil {
+			return fmt.Errorf("failed to extract %q: %v", zf.Name, err)
+		}
+		u.RecordManaged(path.Join(a.Path, name))
+	}
+	return nil
+}
+
+// archiveEntryName returns the name of an archive entry as a clean relative
+// path, or an error if it would leave the directory the archive is
+// extracted to. Some Windows tools separate names with backslashes.
+func archiveEntryName(name string) (string, error) {
+	name = strings.TrimSuffix(strings.Replace(name, "\\", "/", -1), "/")
+	if name == "" || name == "." {
+		return "", nil
+	}
+	if path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("archive entry %q isn't a clean relative path", name)
+	}
+	return name, nil
+}
+
+// checkRestrictedEntry holds the archive's entry to restricted mode like the
+// config's files, as entries of archives extracted to a parent of a
+// restricted directory, such as /, would otherwise end up in it; this
+// includes the extended attributes entries carry, which are refused although
+// they aren't written. As symlinks
+// created by earlier entries may lead elsewhere, the path the entry is
+// written to once they're followed is checked as well, and symlinks into
+// restricted directories aren't created at all.
+func checkRestrictedEntry(u util.Util, a types.Archive, name string, mode os.FileMode, linkTarget string, xattrs []types.Xattr) error {
+	if !distro.RestrictedExec() {
+		return nil
+	}
+	perm := int(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		perm |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		perm |= 02000
+	}
+	f := types.File{Node: types.Node{Path: path.Join(a.Path, name)}, FileEmbedded1: types.FileEmbedded1{Mode: &perm, Xattrs: xattrs}}
+	if r := ignConfig.ValidateRestricted(types.Config{Storage: types.Storage{Files: []types.File{f}}}); r.IsFatal() {
+		return fmt.Errorf("archive entry %q is not allowed in restricted mode:\n%s", name, r)
+	}
+
+	return checkRestrictedOnDisk(u, fmt.Sprintf("archive entry %q", name), path.Join(a.Path, name), linkTarget)
+}
+
+// checkRestrictedOnDisk checks that what, about to be written to p, doesn't
+// end up in a restricted directory once the symlinks on disk are followed,
+// e.g. the stock /var/run -> ../run link. If what is a symlink to
+// linkTarget, the target is checked likewise.
+func checkRestrictedOnDisk(u util.Util, what, p, linkTarget string) error {
+	resolved, err := ignConfig.ResolveInRoot(u.DestDir, p)
+	if err != nil {
+		return err
+	}
+	if dir, ok := ignConfig.InRestrictedDir(resolved); ok {
+		return fmt.Errorf("%s leads to %q in %s, which is not allowed in restricted mode", what, resolved, dir)
+	}
+	if linkTarget == "" {
+		return nil
+	}
+	if !path.IsAbs(linkTarget) {
+		linkTarget = path.Join(path.Dir(resolved), linkTarget)
+	}
+	resolved, err = ignConfig.ResolveInRoot(u.DestDir, linkTarget)
+	if err != nil {
+		return err
+	}
+	if dir, ok := ignConfig.InRestrictedDir(resolved); ok {
+		return fmt.Errorf("%s links to %q in %s, which is not allowed in restricted mode", what, resolved, dir)
+	}
+	return nil
+}
+
+// extractZipEntry writes the zip entry to the target path. Entries of
+// archives created on unix keep their permissions, other entries get the
+// default modes.
+func extractZipEntry(zf *zip.File, target, linkTarget string, uid, gid int) error {
+	mode := zf.Mode()
+	unix := zf.CreatorVersion>>8 == 3
+	switch {
+	case mode.IsDir():
+		perm := os.FileMode(archiveDirMode)
+		if unix {
+			perm = mode.Perm()
+		}
+		return createArchiveDirs(target, perm, uid, gid)
+
+	case mode&os.ModeSymlink != 0:
+		if err := createArchiveDirs(filepath.Dir(target), archiveDirMode, uid, gid); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Symlink(linkTarget, target); err != nil {
+			return err
+		}
+		return os.Lchown(target, uid, gid)
+
+	case mode.IsRegular():
+		perm := os.FileMode(archiveFileMode)
+		if unix {
+			perm = mode.Perm()
+		}
+		if err := createArchiveDirs(filepath.Dir(target), archiveDirMode, uid, gid); err != nil {
+			return err
+		}
+		return writeZipFile(zf, target, perm, uid, gid)
+
+	default:
+		return fmt.Errorf("unsupported file type %v", mode.Type())
+	}
+}
+
+// readZipLink reads the target of the zip's symlink entry.
+func readZipLink(zf *zip.File) (string, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	linkTarget, err := ioutil.ReadAll(io.LimitReader(rc, archiveMaxLinkTarget+1))
+	if err != nil {
+		return "", err
+	}
+	if len(linkTarget) > archiveMaxLinkTarget {
+		return "", fmt.Errorf("symlink target is too long")
+	}
+	return string(linkTarget), nil
+}
+
+// writeZipFile writes the contents of the zip entry to a temporary file next
+// to the target and renames it into place. Reading the entry to its end
+// verifies its checksum.
+func writeZipFile(zf *zip.File, target string, perm os.FileMode, uid, gid int) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, rc); err != nil {
+		return err
+	}
+	if err := tmp.Chown(uid, gid); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// createArchiveDirs creates the directory and its missing parents with the
+// given mode and owner. Like for the config's directories, existing
+// directories are left alone.
+func createArchiveDirs(dir string, perm os.FileMode, uid, gid int) error {
+	var newPaths []string
+	for p := dir; p != "/"; p = filepath.Dir(p) {
+		_, err := os.Stat(p)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		newPaths = append(newPaths, p)
+	}
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	for _, p := range newPaths {
+		if err := os.Chmod(p, perm); err != nil {
+			return err
+		}
+		if err := os.Chown(p, uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
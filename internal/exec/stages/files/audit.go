@@ -0,0 +1,61 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"path/filepath"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// auditRulesDir is read by augenrules, which loads its rules at boot.
+const auditRulesDir = "/etc/audit/rules.d"
+
+// createAuditRules writes the rule files listed under audit.rules. Their
+// syntax was checked when the config was validated.
+func (s *stage) createAuditRules(config types.Config) error {
+	if len(config.Audit.Rules) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createAuditRules")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	for _, r := range config.Audit.Rules {
+		f := types.File{
+			Node: types.Node{
+				Filesystem: "root",
+				Path:       filepath.Join(auditRulesDir, r.Name),
+				Overwrite:  configUtil.BoolToPtr(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: configUtil.IntToPtr(0640),
+				Contents: types.FileContents{
+					Source: dataurl.EncodeBytes([]byte(r.Contents)),
+				},
+			},
+		}
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/flatcar/ignition/internal/bspatch"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+	ignUtil "github.com/flatcar/ignition/internal/util"
+)
+
+type editEntry types.Edit
+
+func (tmp editEntry) getPath() string {
+	return types.Edit(tmp).Path
+}
+
+func (tmp editEntry) create(l *log.Logger, u util.Util) error {
+	e := types.Edit(tmp)
+
+	what := "editing"
+	apply := applyEdits
+	if e.Patch != nil {
+		what = "patching"
+		apply = applyPatch
+	}
+	if err := l.LogOp(
+		func() error { return apply(l, u, e) },
+		"%s %q", what, e.Path,
+	); err != nil {
+		return fmt.Errorf("failed to edit %q: %v", e.Path, err)
+	}
+	return nil
+}
+
+// openEdited opens the file to edit, which must exist. If it is a symlink,
+// e.g. to a file of a read-only /usr, the file it links to is read and the
+// edited file replaces the symlink. Symlinks are resolved within the
+// filesystem, not the initramfs.
+func openEdited(u util.Util, e types.Edit) (string, *os.File, os.FileInfo, error) {
+	path, err := u.JoinPath(e.Path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	target, err := u.FollowPath(e.Path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return "", nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return "", nil, nil, fmt.Errorf("%q is not a regular file", e.Path)
+	}
+	return path, f, info, nil
+}
+
+// replaceEdited atomically replaces the file at path with the result of
+// write, keeping the mode and owner of the original file.
+func replaceEdited(path string, info os.FileInfo, write func(io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".edit")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := tmp.Chown(int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// applyEdits applies the line edits and then the ini edits to the file.
+// The file is only written if they change it.
+func applyEdits(l *log.Logger, u util.Util, e types.Edit) error {
+	path, f, info, err := openEdited(u, e)
+	if err != nil {
+		return err
+	}
+	contents, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	edited, err := util.EditLines(string(contents), e.Lines)
+	if err != nil {
+		return err
+	}
+	edited = util.EditIni(edited, e.Ini)
+	u.RecordManaged(e.Path)
+	if edited == string(contents) {
+		l.Info("%q is up to date", e.Path)
+		return nil
+	}
+	return replaceEdited(path, info, func(w io.Writer) error {
+		_, err := io.WriteString(w, edited)
+		return err
+	})
+}
+
+// fileSum returns the hex encoded sum of the file of the given hash
+// function.
+func fileSum(f *os.File, verify types.Verification) (string, error) {
+	hasher, err := ignUtil.GetHasher(verify)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// applyPatch patches the file if it is the original the patch applies to,
+// and verifies the result. A file which is the result of the patch already
+// is left alone, any other file is an error.
+func applyPatch(l *log.Logger, u util.Util, e types.Edit) error {
+	p := *e.Patch
+	path, f, info, err := openEdited(u, e)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, originalSum, err := ignUtil.HashParts(p.Original)
+	if err != nil {
+		return err
+	}
+	_, resultSum, err := ignUtil.HashParts(p.Result)
+	if err != nil {
+		return err
+	}
+	sum, err := fileSum(f, p.Result)
+	if err != nil {
+		return err
+	}
+	if sum == resultSum {
+		u.RecordManaged(e.Path)
+		l.Info("%q is patched already", e.Path)
+		return nil
+	}
+	if sum, err = fileSum(f, p.Original); err != nil {
+		return err
+	}
+	if sum != originalSum {
+		return fmt.Errorf("%q is neither the original the patch applies to nor its result", e.Path)
+	}
+
+	op := u.PrepareFetch(l, types.File{
+		Node: types.Node{Path: e.Path},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Source:       p.Source,
+				HTTPHeaders:  p.HTTPHeaders,
+				Verification: p.Verification,
+			},
+		},
+	})
+	if op == nil {
+		return fmt.Errorf("failed to resolve patch %q", p.Source)
+	}
+	patch, err := u.Fetcher.FetchToBuffer(op.Url, op.FetchOptions)
+	if err != nil {
+		return err
+	}
+
+	u.RecordManaged(e.Path)
+	return replaceEdited(path, info, func(w io.Writer) error {
+		hasher, err := ignUtil.GetHasher(p.Result)
+		if err != nil {
+			return err
+		}
+		if err := bspatch.Apply(f, info.Size(), patch, io.MultiWriter(w, hasher)); err != nil {
+			return err
+		}
+		if sum := hex.EncodeToString(hasher.Sum(nil)); sum != resultSum {
+			return ignUtil.ErrHashMismatch{Calculated: sum, Expected: resultSum}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"path/filepath"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// the directories systemd-sysext and systemd-confext merge images from, and
+// the services merging them on boot, by extension type
+var (
+	extensionDirs = map[string]string{
+		"sysext":  "/var/lib/extensions",
+		"confext": "/var/lib/confexts",
+	}
+	extensionUnits = map[string]string{
+		"sysext":  "systemd-sysext.service",
+		"confext": "systemd-confext.service",
+	}
+)
+
+// extensionType returns the type of the extension, "sysext" if it isn't set.
+func extensionType(e types.SystemdExtension) string {
+	if e.Type == "" {
+		return "sysext"
+	}
+	return e.Type
+}
+
+// createExtensions writes the extension images listed under
+// systemd.extensions and enables the services merging them, so that they're
+// merged on first boot.
+func (s *stage) createExtensions(config types.Config) error {
+	if len(config.Systemd.Extensions) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createExtensions")
+	defer s.Logger.PopPrefix()
+
+	if distro.InitSystem() != "systemd" {
+		s.Logger.Warning("writing system extensions, which have no effect without systemd")
+	}
+
+	u := s.Util
+	u.IsRoot = true
+
+	enable := map[string]bool{}
+	for _, e := range config.Systemd.Extensions {
+		typ := extensionType(e)
+		f := types.File{
+			Node: types.Node{
+				Filesystem: "root",
+				Path:       filepath.Join(extensionDirs[typ], e.Name),
+				Overwrite:  configUtil.BoolToPtr(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: configUtil.IntToPtr(0644),
+				Contents: types.FileContents{
+					Compression:  e.Compression,
+					Source:       e.Source,
+					HTTPHeaders:  e.HTTPHeaders,
+					Verification: e.Verification,
+				},
+			},
+		}
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+		enable[typ] = true
+	}
+
+	if distro.InitSystem() != "systemd" {
+		return nil
+	}
+	for _, typ := range []string{"sysext", "confext"} {
+		if !enable[typ] {
+			continue
+		}
+		unit := types.Unit{Name: extensionUnits[typ]}
+		if err := s.Logger.LogOp(
+			func() error { return s.EnableUnit(unit) },
+			"enabling unit %q", unit.Name,
+		); err != nil {
+			return err
+		}
+	}
+	s.relabel(util.PresetPath)
+	return nil
+}
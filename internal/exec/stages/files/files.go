@@ -43,7 +43,7 @@ func init() {
 type creator struct{}
 
 func (creator) Create(logger *log.Logger, root string, f resource.Fetcher) stages.Stage {
-	return &stage{
+	s := &stage{
 		Util: util.Util{
 			DestDir: root,
 			Root:    root,
@@ -51,12 +51,20 @@ func (creator) Create(logger *log.Logger, root string, f resource.Fetcher) stage
 			Fetcher: f,
 		},
 	}
+	if distro.ManagedPathsFile() != "" {
+		s.Managed = &util.ManagedPaths{}
+	}
+	return s
 }
 
 func (creator) Name() string {
 	return name
 }
 
+func (creator) After() []string {
+	return []string{"disks"}
+}
+
 type stage struct {
 	util.Util
 	toRelabel []string
@@ -71,6 +79,14 @@ func (s stage) Run(config types.Config) error {
 		return fmt.Errorf("failed to check if SELinux labeling required: %v", err)
 	}
 
+	config, err := s.expandManifests(config)
+	if err != nil {
+		return fmt.Errorf("failed to expand manifests: %v", err)
+	}
+
+	s.prefetchFiles(config)
+	defer s.Prefetched.Close()
+
 	if err := s.createPasswd(config); err != nil {
 		return fmt.Errorf("failed to create users/groups: %v", err)
 	}
@@ -79,10 +95,64 @@ func (s stage) Run(config types.Config) error {
 		return fmt.Errorf("failed to create files: %v", err)
 	}
 
+	if err := s.createSSHHostKeys(config); err != nil {
+		return fmt.Errorf("failed to create ssh host keys: %v", err)
+	}
+
+	if err := s.createSSHConfigSnippets(config); err != nil {
+		return fmt.Errorf("failed to create sshd config snippets: %v", err)
+	}
+
+	if err := s.createAuditRules(config); err != nil {
+		return fmt.Errorf("failed to create audit rules: %v", err)
+	}
+
+	if err := s.createKernelConfig(config); err != nil {
+		return fmt.Errorf("failed to create kernel config: %v", err)
+	}
+
+	if err := s.createUdevRules(config); err != nil {
+		return fmt.Errorf("failed to create udev rules: %v", err)
+	}
+
+	if err := s.createTimeConfig(config); err != nil {
+		return fmt.Errorf("failed to create time config: %v", err)
+	}
+
+	if err := s.createBootstrapKubeconfig(config); err != nil {
+		return fmt.Errorf("failed to create bootstrap kubeconfig: %v", err)
+	}
+
+	if err := s.createWireGuardKeys(config); err != nil {
+		return fmt.Errorf("failed to create wireguard keys: %v", err)
+	}
+
 	if err := s.createUnits(config); err != nil {
 		return fmt.Errorf("failed to create units: %v", err)
 	}
 
+	if err := s.createExtensions(config); err != nil {
+		return fmt.Errorf("failed to create system extensions: %v", err)
+	}
+
+	if err := s.createUpdateConfig(config); err != nil {
+		return fmt.Errorf("failed to create update config: %v", err)
+	}
+
+	if err := s.createOEM(config); err != nil {
+		return fmt.Errorf("failed to write oem partition: %v", err)
+	}
+
+	if err := s.requestReboot(config); err != nil {
+		return fmt.Errorf("failed to request reboot: %v", err)
+	}
+
+	s.recordManagedPaths()
+
+	if err := s.phoneHome(config); err != nil {
+		return fmt.Errorf("failed to phone home: %v", err)
+	}
+
 	// add systemd unit to relabel files
 	if err := s.addRelabelUnit(config); err != nil {
 		return fmt.Errorf("failed to add relabel unit: %v", err)
@@ -91,6 +161,24 @@ func (s stage) Run(config types.Config) error {
 	return nil
 }
 
+// recordManagedPaths records the paths written by the stage, along with
+// their digests, in the managed paths file. The record is informational, so
+// failing to write it doesn't fail the stage.
+func (s *stage) recordManagedPaths() {
+	if s.Managed == nil {
+		return
+	}
+	path := distro.ManagedPathsFile()
+	if err := s.Logger.LogOp(
+		func() error { return s.WriteManagedPaths(path) },
+		"recording managed paths in %q", path,
+	); err != nil {
+		s.Logger.Warning("failed to record managed paths: %v", err)
+		return
+	}
+	s.relabel(path)
+}
+
 // checkRelabeling determines whether relabeling is supported/requested so that
 // we only collect filenames if we need to.
 func (s *stage) checkRelabeling() error {
@@ -15,13 +15,29 @@
 package files
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
+	"syscall"
 	"testing"
 
+	ignConfig "github.com/flatcar/ignition/internal/config"
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/exec/util"
 	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
 )
 
 func TestMapEntriesToFilesystems(t *testing.T) {
@@ -89,6 +105,27 @@ func TestMapEntriesToFilesystems(t *testing.T) {
 				},
 			}},
 		},
+		{
+			in: in{config: types.Config{Storage: types.Storage{
+				Filesystems: []types.Filesystem{{Name: "fs1", Path: &fs1}},
+				Files: []types.File{
+					{Node: types.Node{Filesystem: "fs1", Path: "/bulk"}},
+					{Node: types.Node{Filesystem: "fs1", Path: "/conf"}},
+					{Node: types.Node{Filesystem: "fs1", Path: "/kubelet"}, FileEmbedded1: types.FileEmbedded1{Priority: 10}},
+					{Node: types.Node{Filesystem: "fs1", Path: "/conf"}, FileEmbedded1: types.FileEmbedded1{Append: true, Priority: 20}},
+					{Node: types.Node{Filesystem: "fs1", Path: "/later"}, FileEmbedded1: types.FileEmbedded1{Priority: -1}},
+				},
+			}}},
+			out: out{files: map[types.Filesystem][]filesystemEntry{
+				{Name: "fs1", Path: &fs1}: {
+					fileEntry(types.File{Node: types.Node{Filesystem: "fs1", Path: "/conf"}}),
+					fileEntry(types.File{Node: types.Node{Filesystem: "fs1", Path: "/conf"}, FileEmbedded1: types.FileEmbedded1{Append: true, Priority: 20}}),
+					fileEntry(types.File{Node: types.Node{Filesystem: "fs1", Path: "/kubelet"}, FileEmbedded1: types.FileEmbedded1{Priority: 10}}),
+					fileEntry(types.File{Node: types.Node{Filesystem: "fs1", Path: "/bulk"}}),
+					fileEntry(types.File{Node: types.Node{Filesystem: "fs1", Path: "/later"}, FileEmbedded1: types.FileEmbedded1{Priority: -1}}),
+				},
+			}},
+		},
 	}
 
 	for i, test := range tests {
@@ -145,3 +182,1516 @@ func TestDirectorySort(t *testing.T) {
 		}
 	}
 }
+
+func TestRebootReasons(t *testing.T) {
+	type in struct {
+		config types.Config
+	}
+	type out struct {
+		reasons []string
+	}
+
+	firmware := types.File{Node: types.Node{Filesystem: "root", Path: "/lib/firmware/nic.bin"}}
+	oemFirmware := types.File{Node: types.Node{Filesystem: "oem", Path: "/lib/firmware/nic.bin"}}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{config: types.Config{}},
+			out: out{},
+		},
+		{
+			in:  in{config: types.Config{Reboot: types.Reboot{Required: true}}},
+			out: out{reasons: []string{"config"}},
+		},
+		{
+			in: in{config: types.Config{
+				Reboot:  types.Reboot{Paths: []types.RebootPath{"/lib/firmware/nic.bin", "/etc/modprobe.d/nic.conf"}},
+				Storage: types.Storage{Files: []types.File{firmware}},
+			}},
+			out: out{reasons: []string{"/lib/firmware/nic.bin"}},
+		},
+		{
+			in: in{config: types.Config{
+				Reboot:  types.Reboot{Paths: []types.RebootPath{"/lib/firmware/nic.bin"}},
+				Storage: types.Storage{Files: []types.File{oemFirmware}},
+			}},
+			out: out{},
+		},
+	}
+
+	for i, test := range tests {
+		reasons := rebootReasons(test.in.config)
+		if !reflect.DeepEqual(test.out.reasons, reasons) {
+			t.Errorf("#%d: bad reasons: want %v, got %v", i, test.out.reasons, reasons)
+		}
+	}
+}
+
+func TestPhoneHome(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-phone-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for path, contents := range map[string]string{
+		"etc/ssh/ssh_host_ed25519_key":     "private",
+		"etc/ssh/ssh_host_ed25519_key.pub": "ssh-ed25519 AAAAC3Nz root@localhost\n",
+		"etc/ssh/ssh_host_rsa_key.pub":     "ssh-rsa AAAAB3Nz root@localhost\n",
+		"etc/hostname":                     "node1\n",
+		"etc/machine-id":                   "uninitialized\n",
+		"run/metadata/ignition":            ignConfig.FormatMetadataAttributes("ec2", map[string]string{"INSTANCE_ID": "i-0123", "NAME": "web \"1\""}),
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(root, path), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Unsetenv("IGNITION_METADATA_PATH")
+	os.Setenv("IGNITION_METADATA_PATH", filepath.Join(root, "run/metadata/ignition"))
+
+	var got phoneHomeReport
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{SSH: types.SSH{
+		// keep ssh-keygen from running
+		DisableHostKeyGeneration: true,
+		PhoneHome: &types.SSHPhoneHome{
+			URL:         srv.URL + "/nodes",
+			HTTPHeaders: types.HTTPHeaders{{Name: "Authorization", Value: "Bearer secret"}},
+		},
+	}}
+	if err := s.phoneHome(config); err != nil {
+		t.Fatalf("phoning home: %v", err)
+	}
+
+	want := phoneHomeReport{
+		Hostname: "node1",
+		Metadata: map[string]string{"INSTANCE_ID": "i-0123", "NAME": "web \"1\"", "PLATFORM": "ec2"},
+		HostKeys: []phoneHomeHostKey{
+			{Type: "ed25519", PublicKey: "ssh-ed25519 AAAAC3Nz root@localhost"},
+			{Type: "rsa", PublicKey: "ssh-rsa AAAAB3Nz root@localhost"},
+		},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("bad report: want %+v, got %+v", want, got)
+	}
+	if token != "Bearer secret" {
+		t.Errorf("bad authorization header: want %q, got %q", "Bearer secret", token)
+	}
+}
+
+func TestCreateSSHHostKeys(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-ssh-host-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := func(private, public string) types.Config {
+		return types.Config{SSH: types.SSH{HostKeys: []types.SSHHostKey{
+			{Type: "ed25519", Source: "data:," + private, PublicKey: public},
+			{Type: "rsa", Source: "data:,rsa"},
+		}}}
+	}
+
+	check := func(run int, want map[string]string) {
+		for path, contents := range want {
+			p := filepath.Join(root, "etc/ssh", path)
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				t.Errorf("run %d: reading %s: %v", run, path, err)
+				continue
+			}
+			if string(b) != contents {
+				t.Errorf("run %d: bad contents of %s: want %q, got %q", run, path, contents, b)
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mode := os.FileMode(0600)
+			if strings.HasSuffix(path, ".pub") {
+				mode = 0644
+			}
+			if info.Mode().Perm() != mode {
+				t.Errorf("run %d: bad mode of %s: want %o, got %o", run, path, mode, info.Mode().Perm())
+			}
+			if st := info.Sys().(*syscall.Stat_t); st.Uid != 0 || st.Gid != 0 {
+				t.Errorf("run %d: bad owner of %s: want 0:0, got %d:%d", run, path, st.Uid, st.Gid)
+			}
+		}
+	}
+
+	if err := s.createSSHHostKeys(config("private", "ssh-ed25519 AAAAC3Nz")); err != nil {
+		t.Fatalf("creating host keys: %v", err)
+	}
+	check(1, map[string]string{
+		"ssh_host_ed25519_key":     "private",
+		"ssh_host_ed25519_key.pub": "ssh-ed25519 AAAAC3Nz\n",
+		"ssh_host_rsa_key":         "rsa",
+	})
+	if _, err := os.Stat(filepath.Join(root, "etc/ssh/ssh_host_rsa_key.pub")); !os.IsNotExist(err) {
+		t.Errorf("public key without publicKey was written: %v", err)
+	}
+
+	// a rerun replaces the keys, resetting their mode and owner
+	key := filepath.Join(root, "etc/ssh/ssh_host_ed25519_key")
+	if err := os.Chmod(key, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(key, 1000, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.createSSHHostKeys(config("rotated", "ssh-ed25519 AAAAC3Nz rotated")); err != nil {
+		t.Fatalf("creating host keys again: %v", err)
+	}
+	check(2, map[string]string{
+		"ssh_host_ed25519_key":     "rotated",
+		"ssh_host_ed25519_key.pub": "ssh-ed25519 AAAAC3Nz rotated\n",
+		"ssh_host_rsa_key":         "rsa",
+	})
+}
+
+func TestCreateSSHConfigSnippets(t *testing.T) {
+	snippets := []types.SSHConfigSnippet{
+		{Name: "10-auth.conf", Contents: "PasswordAuthentication no\nPermitRootLogin prohibit-password\n"},
+		{Name: "20-port.conf", Contents: "Port 2222\n"},
+	}
+
+	tests := []struct {
+		sshdConfig string
+		require    bool
+		written    bool
+		fail       bool
+	}{
+		// absolute include
+		{sshdConfig: "Include /etc/ssh/sshd_config.d/*.conf\nUsePAM yes\n", written: true},
+		// include relative to /etc/ssh, with an equals sign
+		{sshdConfig: "Include=sshd_config.d/*.conf\n", require: true, written: true},
+		// not included, the snippets are written anyway
+		{sshdConfig: "UsePAM yes\n", written: true},
+		// not included, but the include is required
+		{sshdConfig: "UsePAM yes\n", require: true, fail: true},
+		// no sshd_config at all
+		{require: true, fail: true},
+	}
+
+	for i, test := range tests {
+		root, err := ioutil.TempDir("", "ignition-sshd-snippets")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+		if test.sshdConfig != "" {
+			if err := os.MkdirAll(filepath.Join(root, "etc/ssh"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(root, "etc/ssh/sshd_config"), []byte(test.sshdConfig), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		logger := log.New(true)
+		s := stage{Util: util.Util{
+			DestDir: root,
+			Logger:  &logger,
+			Fetcher: resource.Fetcher{Logger: &logger},
+		}}
+		config := types.Config{SSH: types.SSH{ConfigSnippets: snippets, RequireConfigInclude: test.require}}
+		err = s.createSSHConfigSnippets(config)
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+
+		for _, snippet := range snippets {
+			path := filepath.Join(root, "etc/ssh/sshd_config.d", snippet.Name)
+			contents, err := ioutil.ReadFile(path)
+			if !test.written {
+				if !os.IsNotExist(err) {
+					t.Errorf("#%d: %s was written", i, snippet.Name)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("#%d: reading %s: %v", i, snippet.Name, err)
+				continue
+			}
+			if string(contents) != snippet.Contents {
+				t.Errorf("#%d: bad contents of %s: want %q, got %q", i, snippet.Name, snippet.Contents, contents)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0644 {
+				t.Errorf("#%d: bad mode of %s: want %o, got %o", i, snippet.Name, 0644, mode)
+			}
+		}
+	}
+}
+
+func TestCreateExtensions(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-extensions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{Systemd: types.Systemd{Extensions: []types.SystemdExtension{
+		{Name: "docker.raw", Source: "data:,sysext"},
+		{Name: "motd.raw", Type: "confext", Source: "data:,confext"},
+	}}}
+	if err := s.createExtensions(config); err != nil {
+		t.Fatalf("creating extensions: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"var/lib/extensions/docker.raw": "sysext",
+		"var/lib/confexts/motd.raw":     "confext",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Errorf("reading %s: %v", path, err)
+		} else if string(got) != want {
+			t.Errorf("bad contents of %s: want %q, got %q", path, want, got)
+		}
+	}
+	presets, err := ioutil.ReadFile(filepath.Join(root, util.PresetPath))
+	if err != nil {
+		t.Fatalf("reading presets: %v", err)
+	}
+	if want := "enable systemd-sysext.service\nenable systemd-confext.service\n"; string(presets) != want {
+		t.Errorf("bad presets: want %q, got %q", want, presets)
+	}
+}
+
+func TestUpdateGrubConfig(t *testing.T) {
+	type in struct {
+		old      string
+		fragment string
+	}
+	type out struct {
+		contents string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{old: "", fragment: "set oem_id=\"example\""},
+			out: out{contents: "# BEGIN IGNITION\nset oem_id=\"example\"\n# END IGNITION\n"},
+		},
+		{
+			in:  in{old: "set linux_console=\"console=ttyS0\"", fragment: "set oem_id=\"example\"\n"},
+			out: out{contents: "set linux_console=\"console=ttyS0\"\n# BEGIN IGNITION\nset oem_id=\"example\"\n# END IGNITION\n"},
+		},
+		{
+			in:  in{old: "a\n# BEGIN IGNITION\nold\n# END IGNITION\nb\n", fragment: "new\n"},
+			out: out{contents: "a\n# BEGIN IGNITION\nnew\n# END IGNITION\nb\n"},
+		},
+		{
+			in:  in{old: "a\n# BEGIN IGNITION\nold\n", fragment: "new\n"},
+			out: out{contents: "a\n# BEGIN IGNITION\nnew\n# END IGNITION\n"},
+		},
+	}
+
+	for i, test := range tests {
+		contents := updateGrubConfig(test.in.old, test.in.fragment)
+		if contents != test.out.contents {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out.contents, contents)
+		}
+	}
+}
+
+func TestUpdateOEMRelease(t *testing.T) {
+	type in struct {
+		old     string
+		release types.OEMRelease
+	}
+	type out struct {
+		contents string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{old: "", release: types.OEMRelease{ID: "example", Name: "Example Cloud"}},
+			out: out{contents: "ID=\"example\"\nNAME=\"Example Cloud\"\n"},
+		},
+		{
+			in:  in{old: "ID=qemu\nNAME=\"QEMU\"\nVERSION_ID=0.0.1\n", release: types.OEMRelease{Name: "Example $HOME `x` \"y\""}},
+			out: out{contents: "ID=qemu\nNAME=\"Example \\$HOME \\`x\\` \\\"y\\\"\"\nVERSION_ID=0.0.1\n"},
+		},
+		{
+			in:  in{old: "ID=qemu\n", release: types.OEMRelease{VersionID: "1.2", HomeURL: "https://example.com/"}},
+			out: out{contents: "ID=qemu\nVERSION_ID=\"1.2\"\nHOME_URL=\"https://example.com/\"\n"},
+		},
+	}
+
+	for i, test := range tests {
+		contents := updateOEMRelease(test.in.old, test.in.release)
+		if contents != test.out.contents {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out.contents, contents)
+		}
+	}
+}
+
+func TestCreateOEM(t *testing.T) {
+	mnt, err := ioutil.TempDir("", "ignition-oem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(mnt)
+	if err := ioutil.WriteFile(filepath.Join(mnt, "oem-release"), []byte("ID=qemu\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger, OEMMountPath: mnt},
+	}}
+	config := types.Config{Storage: types.Storage{OEM: &types.OEM{
+		GrubConfig: "set oem_id=\"example\"\n",
+		Release:    &types.OEMRelease{ID: "example"},
+	}}}
+	if err := s.createOEM(config); err != nil {
+		t.Fatalf("writing oem partition: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"grub.cfg":    "# BEGIN IGNITION\nset oem_id=\"example\"\n# END IGNITION\n",
+		"oem-release": "ID=\"example\"\n",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(mnt, path))
+		if err != nil {
+			t.Errorf("reading %s: %v", path, err)
+		} else if string(got) != want {
+			t.Errorf("bad contents of %s: want %q, got %q", path, want, got)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(mnt, "oem-release")); err != nil {
+		t.Errorf("stat oem-release: %v", err)
+	} else if fi.Mode().Perm() != 0600 {
+		t.Errorf("bad mode of oem-release: want %v, got %v", os.FileMode(0600), fi.Mode().Perm())
+	}
+}
+
+func TestCreateUpdateConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "etc/flatcar/update.conf")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte("GROUP=beta\nREBOOT_STRATEGY=off\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{Update: types.Update{
+		Group:          "stable",
+		Server:         "https://updates.example.com/v1/update/",
+		RebootStrategy: "etcd-lock",
+		RebootWindow:   &types.UpdateRebootWindow{Start: "Thu 04:00", Length: "1h"},
+	}}
+	if err := s.createUpdateConfig(config); err != nil {
+		t.Fatalf("creating update config: %v", err)
+	}
+
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading update.conf: %v", err)
+	}
+	if want := "GROUP=stable\nREBOOT_STRATEGY=etcd-lock\nSERVER=https://updates.example.com/v1/update/\nLOCKSMITHD_REBOOT_WINDOW_START=Thu 04:00\nLOCKSMITHD_REBOOT_WINDOW_LENGTH=1h\n"; string(got) != want {
+		t.Errorf("bad update.conf: want %q, got %q", want, got)
+	}
+}
+
+func TestCreateTimeConfig(t *testing.T) {
+	config := types.Config{Time: types.Time{
+		Servers: []types.TimeServer{
+			{Address: "time.cloudflare.com", NTS: true},
+			{Address: "192.0.2.1"},
+		},
+		MakeStep: &types.TimeMakeStep{Threshold: "100ms", Limit: 3},
+	}}
+	plain := types.Config{Time: types.Time{
+		Servers:  []types.TimeServer{{Address: "192.0.2.1"}, {Address: "192.0.2.2"}},
+		MakeStep: config.Time.MakeStep,
+	}}
+
+	tests := []struct {
+		files  map[string]string
+		config types.Config
+		path   string
+		want   string
+		err    bool
+	}{
+		// chrony, reading the confdir of chrony.conf
+		{
+			files:  map[string]string{"usr/sbin/chronyd": "", "etc/chrony/chrony.conf": "pool 2.debian.pool.ntp.org iburst\nconfdir /etc/chrony/conf.d\n"},
+			config: config,
+			path:   "etc/chrony/conf.d/50-ignition.conf",
+			want:   "# Written by Ignition from the time section\nserver time.cloudflare.com iburst nts\nserver 192.0.2.1 iburst\nmakestep 0.1 3\n",
+		},
+		// chrony, without a confdir
+		{
+			files:  map[string]string{"usr/sbin/chronyd": "", "usr/lib/systemd/systemd-timesyncd": ""},
+			config: plain,
+			path:   "etc/chrony.d/50-ignition.conf",
+			want:   "# Written by Ignition from the time section\nserver 192.0.2.1 iburst\nserver 192.0.2.2 iburst\nmakestep 0.1 3\n",
+		},
+		// timesyncd
+		{
+			files:  map[string]string{"usr/lib/systemd/systemd-timesyncd": ""},
+			config: plain,
+			path:   "etc/systemd/timesyncd.conf.d/50-ignition.conf",
+			want:   "# Written by Ignition from the time section\n[Time]\nNTP=192.0.2.1 192.0.2.2\n",
+		},
+		// timesyncd can't do NTS
+		{
+			files:  map[string]string{"usr/lib/systemd/systemd-timesyncd": ""},
+			config: config,
+			err:    true,
+		},
+		// no time daemon
+		{
+			config: plain,
+			err:    true,
+		},
+	}
+
+	for i, test := range tests {
+		root, err := ioutil.TempDir("", "ignition-time")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+		for name, contents := range test.files {
+			path := filepath.Join(root, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		logger := log.New(true)
+		s := stage{Util: util.Util{
+			DestDir: root,
+			Root:    root,
+			Logger:  &logger,
+			Fetcher: resource.Fetcher{Logger: &logger},
+		}}
+		err = s.createTimeConfig(test.config)
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: creating time config: %v", i, err)
+			continue
+		}
+		got, err := ioutil.ReadFile(filepath.Join(root, test.path))
+		if err != nil {
+			t.Errorf("#%d: reading %s: %v", i, test.path, err)
+		} else if string(got) != test.want {
+			t.Errorf("#%d: bad %s: want %q, got %q", i, test.path, test.want, got)
+		}
+	}
+}
+
+func TestCreateKernelConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-kernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	mode := 0660
+	node0, node1, gid := 0, 1, 1001
+	balancing := false
+	config := types.Config{Kernel: types.Kernel{
+		Modules: []types.KernelModule{
+			{Name: "nouveau", Blacklist: true, Options: []string{"modeset=0"}},
+			{Name: "nvidia-drm", Options: []string{"modeset=1", "fbdev=1"}},
+		},
+		Devices: []types.KernelDevice{
+			{Subsystem: "drm", Kernel: "renderD*", Vendor: "0x10DE", Group: "render", Mode: &mode},
+			{Kernel: "nvidia*", Owner: "root", Group: "video"},
+		},
+		Hugepages: &types.KernelHugepages{
+			DefaultSize: "1G",
+			Pages: []types.KernelHugepage{
+				{Size: "1G", Count: 8, Node: &node0},
+				{Size: "1G", Count: 4, Node: &node1},
+				{Size: "2M", Count: 512},
+			},
+			ShmGroup:      &gid,
+			NumaBalancing: &balancing,
+		},
+	}}
+	if err := s.createKernelConfig(config); err != nil {
+		t.Fatalf("creating kernel config: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		modprobeConfigPath:  "# Written by Ignition from kernel.modules\nblacklist nouveau\ninstall nouveau /bin/false\noptions nouveau modeset=0\noptions nvidia-drm modeset=1 fbdev=1\n",
+		deviceRulesPath:     "# Written by Ignition from kernel.devices\nSUBSYSTEM==\"drm\", KERNEL==\"renderD*\", ATTRS{vendor}==\"0x10de\", GROUP=\"render\", MODE=\"0660\"\nKERNEL==\"nvidia*\", OWNER=\"root\", GROUP=\"video\"\n",
+		hugepagesSysctlPath: "# Written by Ignition from kernel.hugepages\nvm.hugetlb_shm_group = 1001\nkernel.numa_balancing = 0\n",
+		hugepagesTmpfilesPath: "# Written by Ignition from kernel.hugepages\n" +
+			"w /sys/devices/system/node/node0/hugepages/hugepages-1048576kB/nr_hugepages - - - - 8\n" +
+			"w /sys/devices/system/node/node1/hugepages/hugepages-1048576kB/nr_hugepages - - - - 4\n" +
+			"w /sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages - - - - 512\n",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Errorf("reading %s: %v", path, err)
+		} else if string(got) != want {
+			t.Errorf("bad %s: want %q, got %q", path, want, got)
+		}
+	}
+
+	if args, want := hugepagesKernelArgs(config.Kernel.Hugepages), "default_hugepagesz=1G hugepagesz=1G hugepages=0:8,1:4 hugepagesz=2M hugepages=512"; args != want {
+		t.Errorf("bad kernel arguments: want %q, got %q", want, args)
+	}
+}
+
+func TestCreateUdevRules(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-udev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	priority := 10
+	config := types.Config{Storage: types.Storage{
+		UdevRules: []types.UdevRule{
+			{Name: "net-names", Priority: &priority, Contents: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="52:54:00:12:34:56", NAME="lan0"`},
+			{Name: "disk-owner", Contents: "KERNEL==\"sdb\", OWNER=\"core\"\n", Trigger: true},
+		},
+	}}
+	if err := s.createUdevRules(config); err != nil {
+		t.Fatalf("creating udev rules: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"/etc/udev/rules.d/10-net-names.rules":  "SUBSYSTEM==\"net\", ACTION==\"add\", ATTR{address}==\"52:54:00:12:34:56\", NAME=\"lan0\"\n",
+		"/etc/udev/rules.d/90-disk-owner.rules": "KERNEL==\"sdb\", OWNER=\"core\"\n",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Errorf("reading %s: %v", path, err)
+		} else if string(got) != want {
+			t.Errorf("bad %s: want %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestCreateBootstrapKubeconfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte("abcdef.0123456789abcdef\n"))
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{Kubernetes: types.Kubernetes{BootstrapKubeconfig: &types.BootstrapKubeconfig{
+		Server:               "https://k8s.example.com:6443",
+		CertificateAuthority: types.CaReference{Source: "data:,CA"},
+		Token: types.TokenReference{
+			Source:      srv.URL + "/token",
+			HTTPHeaders: types.HTTPHeaders{{Name: "Authorization", Value: "Bearer secret"}},
+		},
+	}}}
+	if err := s.createBootstrapKubeconfig(config); err != nil {
+		t.Fatalf("creating kubeconfig: %v", err)
+	}
+
+	path := filepath.Join(root, defaultBootstrapKubeconfigPath)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+kind: Config
+clusters:
+- name: default
+  cluster:
+    server: "https://k8s.example.com:6443"
+    certificate-authority-data: Q0E=
+users:
+- name: kubelet-bootstrap
+  user:
+    token: "abcdef.0123456789abcdef"
+contexts:
+- name: default
+  context:
+    cluster: default
+    user: kubelet-bootstrap
+current-context: default
+`
+	if string(contents) != want {
+		t.Errorf("bad kubeconfig: want %q, got %q", want, contents)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("bad mode: want %o, got %o", 0600, mode)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("bad authorization header: want %q, got %q", "Bearer secret", auth)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	sum := "sha512-" + strings.Repeat("0", 128)
+	m := types.Manifest{
+		Filesystem:  "root",
+		Path:        "/opt/tools",
+		Source:      "https://example.com/v1/manifest.json",
+		HTTPHeaders: types.HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+	}
+
+	type in struct {
+		data string
+	}
+	type out struct {
+		files []types.File
+		err   bool
+	}
+	mode := func(m int) *int { return &m }
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{data: `{"files": [
+				{"path": "bin/tool", "source": "tool", "mode": 493, "verification": {"hash": "` + sum + `"}},
+				{"path": "share/README", "source": "https://mirror.example.org/README", "verification": {"hash": "` + sum + `"}}
+			]}`},
+			out: out{files: []types.File{
+				{
+					Node: types.Node{Filesystem: "root", Path: "/opt/tools/bin/tool"},
+					FileEmbedded1: types.FileEmbedded1{
+						Mode: mode(0755),
+						Contents: types.FileContents{
+							HTTPHeaders:  m.HTTPHeaders,
+							Source:       "https://example.com/v1/tool",
+							Verification: types.Verification{Hash: &sum},
+						},
+					},
+				},
+				{
+					Node: types.Node{Filesystem: "root", Path: "/opt/tools/share/README"},
+					FileEmbedded1: types.FileEmbedded1{
+						Mode: mode(0644),
+						Contents: types.FileContents{
+							Source:       "https://mirror.example.org/README",
+							Verification: types.Verification{Hash: &sum},
+						},
+					},
+				},
+			}},
+		},
+		{
+			in:  in{data: `{"files": [{"path": "../etc/shadow", "source": "shadow", "verification": {"hash": "` + sum + `"}}]}`},
+			out: out{err: true},
+		},
+		{
+			in:  in{data: `{"files": [{"path": "/etc/shadow", "source": "shadow", "verification": {"hash": "` + sum + `"}}]}`},
+			out: out{err: true},
+		},
+		{
+			in:  in{data: `{"files": [{"path": "tool", "source": "tool"}]}`},
+			out: out{err: true},
+		},
+		{
+			in:  in{data: `{"files": [{"path": "tool", "source": "tool", "verification": {"hash": "sha512-abc"}}]}`},
+			out: out{err: true},
+		},
+		{
+			in: in{data: `{"files": [
+				{"path": "tool", "source": "tool", "verification": {"hash": "` + sum + `"}},
+				{"path": "tool", "source": "tool2", "verification": {"hash": "` + sum + `"}}
+			]}`},
+			out: out{err: true},
+		},
+		{
+			in:  in{data: `{"files": [{"path": "tool", "source": "tool", "mode": 65536, "verification": {"hash": "` + sum + `"}}]}`},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		files, err := parseManifest(m, []byte(test.in.data))
+		if test.out.err {
+			if err == nil {
+				t.Errorf("#%d: expected error, got files %v", i, files)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out.files, files) {
+			t.Errorf("#%d: bad files: want %+v, got %+v", i, test.out.files, files)
+		}
+	}
+}
+
+func TestExpandManifests(t *testing.T) {
+	contents := "#!/bin/sh\necho hello\n"
+	sum := sha512.Sum512([]byte(contents))
+	hash := "sha512-" + hex.EncodeToString(sum[:])
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"files": [{"path": "bin/hello", "source": "hello", "mode": 493, "verification": {"hash": "` + hash + `"}}]}`))
+	})
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(contents))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	root, err := ioutil.TempDir("", "ignition-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	config := types.Config{Storage: types.Storage{
+		Filesystems: []types.Filesystem{{Name: "root", Path: &root}},
+		Manifests: []types.Manifest{{
+			Filesystem: "root",
+			Path:       "/opt",
+			Source:     server.URL + "/manifest.json",
+		}},
+	}}
+	config, err = s.expandManifests(config)
+	if err != nil {
+		t.Fatalf("expanding manifests: %v", err)
+	}
+	if err := s.createFilesystemsEntries(config); err != nil {
+		t.Fatalf("creating files: %v", err)
+	}
+
+	path := filepath.Join(root, "opt/bin/hello")
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+	if string(got) != contents {
+		t.Errorf("bad contents: want %q, got %q", contents, got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("bad mode: want %#o, got %#o", 0755, info.Mode().Perm())
+	}
+}
+
+func TestExpandManifestsRestricted(t *testing.T) {
+	var listing string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(listing))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	os.Setenv("IGNITION_RESTRICTED_EXEC", "true")
+	defer os.Unsetenv("IGNITION_RESTRICTED_EXEC")
+
+	hash := "sha512-" + strings.Repeat("0", 128)
+	tests := []struct {
+		listing string
+		links   []types.Link
+		fail    bool
+	}{
+		{listing: `{"files": [{"path": "opt/bin/tool", "source": "tool", "mode": 493, "verification": {"hash": "` + hash + `"}}]}`},
+		{listing: `{"files": [{"path": "usr/local/bin/su", "source": "su", "mode": 2541, "verification": {"hash": "` + hash + `"}}]}`, fail: true},
+		{listing: `{"files": [{"path": "etc/systemd/system/evil.service", "source": "evil", "verification": {"hash": "` + hash + `"}}]}`, fail: true},
+		// the config's links are followed
+		{
+			listing: `{"files": [{"path": "opt/u/evil.service", "source": "evil", "verification": {"hash": "` + hash + `"}}]}`,
+			links:   []types.Link{{Node: types.Node{Filesystem: "root", Path: "/opt/u"}, LinkEmbedded1: types.LinkEmbedded1{Target: "/etc/systemd/system"}}},
+			fail:    true,
+		},
+	}
+
+	for i, test := range tests {
+		listing = test.listing
+		logger := log.New(true)
+		s := stage{Util: util.Util{
+			Logger:  &logger,
+			Fetcher: resource.Fetcher{Logger: &logger},
+		}}
+		config := types.Config{Storage: types.Storage{
+			Manifests: []types.Manifest{{
+				Filesystem: "root",
+				Path:       "/",
+				Source:     server.URL + "/manifest.json",
+			}},
+			Links: test.links,
+		}}
+		_, err := s.expandManifests(config)
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+	}
+}
+
+func TestCreateEntriesRestricted(t *testing.T) {
+	os.Setenv("IGNITION_RESTRICTED_EXEC", "true")
+	defer os.Unsetenv("IGNITION_RESTRICTED_EXEC")
+
+	root, err := ioutil.TempDir("", "ignition-restricted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "var"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// the stock link of most distributions
+	if err := os.Symlink("../run", filepath.Join(root, "var/run")); err != nil {
+		t.Fatal(err)
+	}
+
+	node := func(p string) types.Node {
+		return types.Node{Filesystem: "root", Path: p}
+	}
+	tests := []struct {
+		entry filesystemEntry
+		fail  bool
+	}{
+		{entry: fileEntry{Node: node("/var/lib/app.conf"), FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "data:,ok"}}}},
+		{entry: fileEntry{Node: node("/var/run/systemd/system/evil.service"), FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "data:,evil"}}}, fail: true},
+		{entry: dirEntry{Node: node("/var/run/systemd/system/evil.service.d")}, fail: true},
+		{entry: linkEntry{Node: node("/var/run/udev/rules.d/99-evil.rules"), LinkEmbedded1: types.LinkEmbedded1{Target: "/opt/evil.rules"}}, fail: true},
+		{entry: linkEntry{Node: node("/opt/units"), LinkEmbedded1: types.LinkEmbedded1{Target: "../var/run/systemd"}}, fail: true},
+	}
+
+	logger := log.New(true)
+	s := stage{Util: util.Util{
+		DestDir: root,
+		Root:    root,
+		Logger:  &logger,
+		Fetcher: resource.Fetcher{Logger: &logger},
+	}}
+	fs := types.Filesystem{Name: "root", Path: &root}
+	for i, test := range tests {
+		err := s.createEntries(fs, []filesystemEntry{test.entry})
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(root, "run/systemd")); !os.IsNotExist(err) {
+		t.Errorf("restricted directory was created: %v", err)
+	}
+}
+
+func TestCheckRestrictedEntry(t *testing.T) {
+	os.Setenv("IGNITION_RESTRICTED_EXEC", "true")
+	defer os.Unsetenv("IGNITION_RESTRICTED_EXEC")
+
+	root, err := ioutil.TempDir("", "ignition-restricted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "opt/app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range map[string]string{
+		"opt/app/units": "/etc/systemd/system",
+		"opt/app/etc":   "/etc",
+		"opt/app/cfg":   "etc",
+		"opt/app/loop":  "loop",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		name   string
+		mode   os.FileMode
+		link   string
+		xattrs []types.Xattr
+		fail   bool
+	}{
+		{path: "/opt/app", name: "bin/tool", mode: 0755},
+		{path: "/opt/app", name: "bin/tool", mode: 0755, xattrs: []types.Xattr{{Name: "user.origin", Value: "image"}}},
+		{path: "/opt/app", name: "bin/tool", mode: 0755, xattrs: []types.Xattr{{Name: "security.capability", Value: "\x01"}}, fail: true},
+		{path: "/opt/app", name: "bin/tool", mode: 0755, xattrs: []types.Xattr{{Name: "trusted.overlay.opaque", Value: "y"}}, fail: true},
+		{path: "/", name: "opt/app/bin/tool", mode: 0755},
+		{path: "/", name: "usr/local/bin/su", mode: 0755 | os.ModeSetuid, fail: true},
+		{path: "/opt", name: "app/bin", mode: 0755 | os.ModeDir | os.ModeSetgid, fail: true},
+		{path: "/", name: "etc/systemd/system/evil.service", mode: 0644, fail: true},
+		{path: "/etc", name: "systemd", mode: 0755 | os.ModeDir, fail: true},
+		// symlinks on the way are followed
+		{path: "/opt/app", name: "units/evil.service", mode: 0644, fail: true},
+		{path: "/opt/app", name: "etc/systemd/system/evil.service", mode: 0644, fail: true},
+		{path: "/opt/app", name: "cfg/systemd/system/evil.service", mode: 0644, fail: true},
+		{path: "/opt/app", name: "etc/app.conf", mode: 0644},
+		{path: "/opt/app", name: "loop/evil.service", mode: 0644, fail: true},
+		// and symlinks into restricted directories aren't created
+		{path: "/opt/app", name: "bin/current", mode: os.ModeSymlink | 0777, link: "tool"},
+		{path: "/opt/app", name: "lib", mode: os.ModeSymlink | 0777, link: "/usr/lib"},
+		{path: "/opt/app", name: "u", mode: os.ModeSymlink | 0777, link: "/etc/systemd/system", fail: true},
+		{path: "/opt/app", name: "u", mode: os.ModeSymlink | 0777, link: "../../etc/systemd", fail: true},
+		{path: "/opt/app", name: "u", mode: os.ModeSymlink | 0777, link: "etc/systemd/user", fail: true},
+	}
+
+	u := util.Util{DestDir: root, Root: root}
+	for i, test := range tests {
+		err := checkRestrictedEntry(u, types.Archive{Path: test.path}, test.name, test.mode, test.link, test.xattrs)
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+	}
+}
+
+func TestCreateArchivesRestricted(t *testing.T) {
+	os.Setenv("IGNITION_RESTRICTED_EXEC", "true")
+	defer os.Unsetenv("IGNITION_RESTRICTED_EXEC")
+
+	type entry struct {
+		name     string
+		contents string
+		mode     os.FileMode
+	}
+	tests := []struct {
+		entries []entry
+		fail    bool
+	}{
+		{
+			entries: []entry{
+				{name: "lib", contents: "/usr/lib", mode: os.ModeSymlink | 0777},
+				{name: "bin/tool", contents: "#!/bin/sh\n", mode: 0755},
+			},
+		},
+		{
+			entries: []entry{
+				{name: "u", contents: "/etc/systemd/system", mode: os.ModeSymlink | 0777},
+				{name: "u/evil.service", contents: "[Service]\n", mode: 0644},
+			},
+			fail: true,
+		},
+		{
+			entries: []entry{
+				{name: "u", contents: "/etc", mode: os.ModeSymlink | 0777},
+				{name: "u/systemd/system/evil.service", contents: "[Service]\n", mode: 0644},
+			},
+			fail: true,
+		},
+	}
+
+	for i, test := range tests {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for _, e := range test.entries {
+			h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+			h.SetMode(e.mode)
+			f, err := w.CreateHeader(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte(e.contents)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(buf.Bytes())
+		}))
+		root, err := ioutil.TempDir("", "ignition-archive")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logger := log.New(true)
+		u := util.Util{
+			DestDir: root,
+			Root:    root,
+			Logger:  &logger,
+			Fetcher: resource.Fetcher{Logger: &logger},
+		}
+		uid, gid := os.Getuid(), os.Getgid()
+		err = extractArchive(&logger, u, types.Archive{
+			Path:   "/opt/app",
+			Format: "zip",
+			Source: server.URL + "/app.zip",
+			User:   &types.NodeUser{ID: &uid},
+			Group:  &types.NodeGroup{ID: &gid},
+		})
+		server.Close()
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+		if _, err := os.Lstat(filepath.Join(root, "etc/systemd/system/evil.service")); !os.IsNotExist(err) {
+			t.Errorf("#%d: unit was written to the unit directory: %v", i, err)
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestCreateArchives(t *testing.T) {
+	type entry struct {
+		name     string
+		contents string
+		mode     os.FileMode
+		fat      bool
+	}
+	zipArchive := func(entries []entry) []byte {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for _, e := range entries {
+			h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+			if !e.fat {
+				h.SetMode(e.mode)
+			}
+			f, err := w.CreateHeader(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte(e.contents)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	type in struct {
+		entries []entry
+	}
+	type out struct {
+		files map[string]string
+		modes map[string]os.FileMode
+		links map[string]string
+		err   bool
+	}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{entries: []entry{
+				{name: "bin/", mode: os.ModeDir | 0750},
+				{name: "bin/app", contents: "#!/bin/sh\n", mode: 0755},
+				{name: "bin/current", contents: "app", mode: os.ModeSymlink | 0777},
+				{name: "conf\\app.ini", contents: "[app]\n", fat: true},
+			}},
+			out: out{
+				files: map[string]string{"bin/app": "#!/bin/sh\n", "conf/app.ini": "[app]\n"},
+				modes: map[string]os.FileMode{"bin": os.ModeDir | 0750, "bin/app": 0755, "conf": os.ModeDir | 0755, "conf/app.ini": 0644},
+				links: map[string]string{"bin/current": "app"},
+			},
+		},
+		{
+			in:  in{entries: []entry{{name: "../escape", contents: "x", mode: 0644}}},
+			out: out{err: true},
+		},
+		{
+			in:  in{entries: []entry{{name: "/etc/passwd", contents: "x", mode: 0644}}},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		data := zipArchive(test.in.entries)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		}))
+		root, err := ioutil.TempDir("", "ignition-archive")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logger := log.New(true)
+		s := stage{Util: util.Util{
+			DestDir: root,
+			Root:    root,
+			Logger:  &logger,
+			Fetcher: resource.Fetcher{Logger: &logger},
+		}}
+		uid, gid := os.Getuid(), os.Getgid()
+		config := types.Config{Storage: types.Storage{
+			Filesystems: []types.Filesystem{{Name: "root", Path: &root}},
+			Archives: []types.Archive{{
+				Filesystem: "root",
+				Path:       "/opt/app",
+				Format:     "zip",
+				Source:     server.URL + "/app.zip",
+				User:       &types.NodeUser{ID: &uid},
+				Group:      &types.NodeGroup{ID: &gid},
+			}},
+		}}
+		err = s.createFilesystemsEntries(config)
+		server.Close()
+		if test.out.err {
+			if err == nil {
+				t.Errorf("#%d: expected error", i)
+			}
+			os.RemoveAll(root)
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			os.RemoveAll(root)
+			continue
+		}
+
+		dir := filepath.Join(root, "opt/app")
+		for name, want := range test.out.files {
+			got, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("#%d: reading %q: %v", i, name, err)
+			} else if string(got) != want {
+				t.Errorf("#%d: bad contents of %q: want %q, got %q", i, name, want, got)
+			}
+		}
+		for name, want := range test.out.modes {
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("#%d: stat %q: %v", i, name, err)
+			} else if got := info.Mode() & (os.ModeDir | os.ModePerm); got != want {
+				t.Errorf("#%d: bad mode of %q: want %v, got %v", i, name, want, got)
+			}
+		}
+		for name, want := range test.out.links {
+			got, err := os.Readlink(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("#%d: reading link %q: %v", i, name, err)
+			} else if got != want {
+				t.Errorf("#%d: bad target of %q: want %q, got %q", i, name, want, got)
+			}
+		}
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), "tmp") {
+				t.Errorf("#%d: temporary file %q left behind", i, e.Name())
+			}
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestImageTree(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	uid, gid := os.Getuid(), os.Getgid()
+	tree := imageTree{
+		u:      util.Util{DestDir: root, Root: root, Logger: &logger},
+		a:      types.Archive{Path: "/opt/app"},
+		uid:    uid,
+		gid:    gid,
+		tmpDir: filepath.Join(root, "opt/app/.ignition-image123"),
+	}
+	if err := os.MkdirAll(tree.tmpDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name string, hdr tar.Header, contents string) {
+		if err := tree.Write(name, &hdr, strings.NewReader(contents)); err != nil {
+			t.Fatalf("writing %q: %v", name, err)
+		}
+	}
+	// the lower layer
+	write("", tar.Header{Typeflag: tar.TypeDir, Mode: 0750}, "")
+	write("bin/app", tar.Header{Typeflag: tar.TypeReg, Mode: 0755}, "v1")
+	write("share/old", tar.Header{Typeflag: tar.TypeReg, Mode: 0644}, "old")
+	write("bin/gone", tar.Header{Typeflag: tar.TypeReg, Mode: 0644}, "gone")
+	// the upper layer
+	for _, err := range []error{tree.Clear("share"), tree.Remove("bin/gone")} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("bin/app", tar.Header{Typeflag: tar.TypeReg, Mode: 0700}, "v2")
+	write("bin/app-link", tar.Header{Typeflag: tar.TypeLink, Linkname: "bin/app"}, "")
+	write("bin/current", tar.Header{Typeflag: tar.TypeSymlink, Linkname: "app"}, "")
+	if err := tree.Write("../escape", &tar.Header{Typeflag: tar.TypeReg, Mode: 0644}, strings.NewReader("x")); err == nil {
+		t.Errorf("entry escaping the destination was written")
+	}
+
+	dir := filepath.Join(root, "opt/app")
+	for name, want := range map[string]string{"bin/app": "v2", "bin/app-link": "v2"} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("reading %q: %v", name, err)
+		} else if string(got) != want {
+			t.Errorf("bad contents of %q: want %q, got %q", name, want, got)
+		}
+	}
+	for name, want := range map[string]os.FileMode{"": os.ModeDir | 0750, "bin/app": 0700, "share": os.ModeDir | 0755} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("stat %q: %v", name, err)
+		} else if got := info.Mode() & (os.ModeDir | os.ModePerm); got != want {
+			t.Errorf("bad mode of %q: want %v, got %v", name, want, got)
+		}
+	}
+	for _, name := range []string{"share/old", "bin/gone"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%q wasn't deleted: %v", name, err)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "bin/current")); err != nil || target != "app" {
+		t.Errorf("bad link: %q, %v", target, err)
+	}
+	if _, err := os.Stat(tree.tmpDir); err != nil {
+		t.Errorf("directory of the layers was deleted: %v", err)
+	}
+}
+
+func TestGitTree(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	tree := gitTree{
+		u:   util.Util{DestDir: root, Root: root, Logger: &logger},
+		a:   types.Archive{Path: "/etc/app"},
+		uid: os.Getuid(),
+		gid: os.Getgid(),
+	}
+	dir := filepath.Join(root, "etc/app")
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.conf"), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range []error{
+		tree.WriteFile("app.conf", false, []byte("new")),
+		tree.Mkdir("bin"),
+		tree.WriteFile("bin/run", true, []byte("#!/bin/sh\n")),
+		tree.Mkdir("conf.d"),
+		tree.Symlink("conf.d/default", "../app.conf"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.WriteFile("../escape", false, []byte("x")); err == nil {
+		t.Errorf("entry escaping the destination was written")
+	}
+
+	if got, err := ioutil.ReadFile(filepath.Join(dir, "conf.d/default")); err != nil || string(got) != "new" {
+		t.Errorf("bad contents of the link: %q, %v", got, err)
+	}
+	for name, want := range map[string]os.FileMode{"app.conf": 0644, "bin/run": 0755, "bin": os.ModeDir | 0700, "conf.d": os.ModeDir | 0755} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("stat %q: %v", name, err)
+		} else if got := info.Mode() & (os.ModeDir | os.ModePerm); got != want {
+			t.Errorf("bad mode of %q: want %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestEditEntry(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	u := util.Util{DestDir: root, Root: root, Logger: &logger, Fetcher: resource.Fetcher{Logger: &logger}}
+	write := func(path, contents string, mode os.FileMode) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(path, contents string, mode os.FileMode) {
+		info, err := os.Lstat(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != mode {
+			t.Errorf("%q: bad mode: want %v, got %v", path, mode, info.Mode())
+		}
+		b, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents {
+			t.Errorf("%q: bad contents: want %q, got %q", path, contents, b)
+		}
+	}
+	sum := func(s string) *string {
+		h := sha512.Sum512([]byte(s))
+		res := "sha512-" + hex.EncodeToString(h[:])
+		return &res
+	}
+
+	match := "^#?PermitRootLogin "
+	value := "1"
+	edit := editEntry{
+		Path:  "/etc/ssh/sshd_config",
+		Lines: []types.EditLine{{Line: "PermitRootLogin no", Match: &match}},
+		Ini:   []types.EditIni{{Section: "main", Key: "x", Value: &value}},
+	}
+	write("etc/ssh/sshd_config", "#PermitRootLogin yes\n", 0600)
+	for i := 0; i < 2; i++ {
+		if err := edit.create(&logger, u); err != nil {
+			t.Fatal(err)
+		}
+		check("etc/ssh/sshd_config", "PermitRootLogin no\n\n[main]\nx=1\n", 0600)
+	}
+
+	// the patch of the bspatch tests, applied to a link to a vendor file,
+	// which replaces the link
+	const (
+		original = "the quick brown fox jumps over the lazy dog\n"
+		result   = "the quick green fox jumps over the lazy dog!\nand a new line\n"
+		patch    = "425344494646343036000000000000002b000000000000003c00000000000000" +
+			"425a683931415926535901666a0300001070405a10400200044000200031064c" +
+			"40d3434c8d265a005310d4f17724538509001666a030425a6839314159265359" +
+			"5679c4740000007000400000801000200030cc0cf505ce2ee48a70a120acf388" +
+			"e8425a68393141592653597546a0ed00000651800010400026a5108020003100" +
+			"d34d050cdaa1faa7644030f374cb1a6fe2ee48a70a120ea8d41da0"
+	)
+	raw, err := hex.DecodeString(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("usr/share/vendor/fox", original, 0644)
+	if err := os.Symlink("/usr/share/vendor/fox", filepath.Join(root, "etc/fox")); err != nil {
+		t.Fatal(err)
+	}
+	patchEdit := editEntry{
+		Path: "/etc/fox",
+		Patch: &types.EditPatch{
+			Format:   "bsdiff",
+			Source:   "data:;base64," + base64.StdEncoding.EncodeToString(raw),
+			Original: types.Verification{Hash: sum(original)},
+			Result:   types.Verification{Hash: sum(result)},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := patchEdit.create(&logger, u); err != nil {
+			t.Fatal(err)
+		}
+		check("etc/fox", result, 0644)
+	}
+	check("usr/share/vendor/fox", original, 0644)
+
+	// files which are neither the original nor the result are left alone
+	write("etc/fox", "something else\n", 0644)
+	if err := patchEdit.create(&logger, u); err == nil {
+		t.Errorf("patched a file which isn't the original")
+	}
+	check("etc/fox", "something else\n", 0644)
+}
@@ -24,6 +24,7 @@ import (
 
 	configUtil "github.com/flatcar/ignition/config/util"
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/exec/util"
 	"github.com/flatcar/ignition/internal/log"
 )
@@ -73,13 +74,18 @@ func (tmp fileEntry) create(l *log.Logger, u util.Util) error {
 	msg := "writing file %q"
 	if f.Append {
 		msg = "appending to file %q"
+	} else if f.Contents.Merge != "" {
+		msg = "merging into file %q"
 	}
 
 	if err := l.LogOp(
 		func() error {
-			err := u.DeletePathOnOverwrite(f.Node)
-			if err != nil {
-				return err
+			// merged files are replaced by the merge itself
+			if f.Contents.Merge == "" {
+				err := u.DeletePathOnOverwrite(f.Node)
+				if err != nil {
+					return err
+				}
 			}
 
 			return u.PerformFetch(fetchOp)
@@ -134,6 +140,7 @@ func (tmp dirEntry) create(l *log.Logger, u util.Util) error {
 			d.Mode = configUtil.IntToPtr(0)
 		}
 
+		u.RecordManaged(d.Path)
 		if err := os.MkdirAll(path, os.FileMode(*d.Mode)); err != nil {
 			return err
 		}
@@ -202,9 +209,31 @@ func depth(n types.Node) uint {
 	return count
 }
 
+// sortFilesByPriority returns the files ordered by descending priority,
+// keeping the order of the config among files of the same priority. Entries
+// for the same path, e.g. a file and an append to it, all take the highest
+// priority among them, so that they stay in order.
+func sortFilesByPriority(files []types.File) []types.File {
+	key := func(f types.File) string {
+		return f.Filesystem + ":" + filepath.Clean(f.Path)
+	}
+	priorities := map[string]int{}
+	for _, f := range files {
+		if p, ok := priorities[key(f)]; !ok || f.Priority > p {
+			priorities[key(f)] = f.Priority
+		}
+	}
+	sorted := append([]types.File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return priorities[key(sorted[i])] > priorities[key(sorted[j])]
+	})
+	return sorted
+}
+
 // mapEntriesToFilesystems builds a map of filesystems to files. If multiple
 // definitions of the same filesystem are present, only the final definition is
-// used. The directories are sorted to ensure /foo gets created before /foo/bar.
+// used. The directories are sorted to ensure /foo gets created before /foo/bar,
+// and the files by their priority.
 func (s stage) mapEntriesToFilesystems(config types.Config) (map[types.Filesystem][]filesystemEntry, error) {
 	filesystems := map[string]types.Filesystem{}
 	for _, fs := range config.Storage.Filesystems {
@@ -227,7 +256,7 @@ func (s stage) mapEntriesToFilesystems(config types.Config) (map[types.Filesyste
 		}
 	}
 
-	for _, f := range config.Storage.Files {
+	for _, f := range sortFilesByPriority(config.Storage.Files) {
 		if fs, ok := filesystems[f.Filesystem]; ok {
 			entryMap[fs] = append(entryMap[fs], fileEntry(f))
 		} else {
@@ -236,6 +265,24 @@ func (s stage) mapEntriesToFilesystems(config types.Config) (map[types.Filesyste
 		}
 	}
 
+	for _, a := range config.Storage.Archives {
+		if fs, ok := filesystems[a.Filesystem]; ok {
+			entryMap[fs] = append(entryMap[fs], archiveEntry(a))
+		} else {
+			s.Logger.Crit("the filesystem (%q), was not defined", a.Filesystem)
+			return nil, ErrFilesystemUndefined
+		}
+	}
+
+	for _, e := range config.Storage.Edits {
+		if fs, ok := filesystems[e.Filesystem]; ok {
+			entryMap[fs] = append(entryMap[fs], editEntry(e))
+		} else {
+			s.Logger.Crit("the filesystem (%q), was not defined", e.Filesystem)
+			return nil, ErrFilesystemUndefined
+		}
+	}
+
 	for _, sy := range config.Storage.Links {
 		if fs, ok := filesystems[sy.Filesystem]; ok {
 			entryMap[fs] = append(entryMap[fs], linkEntry(sy))
@@ -248,21 +295,6 @@ func (s stage) mapEntriesToFilesystems(config types.Config) (map[types.Filesyste
 	return entryMap, nil
 }
 
-func (s *stage) mountAuto(dev, mnt string) error {
-	var err error
-	// try to mount all possible formats from config/v2_*/types/filesystem.go (without "swap")
-	formats := []string{"ext4", "btrfs", "xfs", "vfat"}
-	for _, tryFormat := range formats {
-		if err = s.Logger.LogOp(
-			func() error { return syscall.Mount(dev, mnt, tryFormat, 0, "") },
-			"mounting %q at %q (trying %q)", dev, mnt, tryFormat,
-		); err == nil {
-			return nil
-		}
-	}
-	return fmt.Errorf("failed to mount device %q at %q (tried %v): %v", dev, mnt, formats, err)
-}
-
 // createEntries creates any files or directories listed for the filesystem in Storage.{Files,Directories}.
 func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) error {
 	s.Logger.PushPrefix("createFiles")
@@ -279,7 +311,7 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 
 		dev := string(fs.Mount.Device)
 
-		if err := s.mountAuto(dev, mnt); err != nil {
+		if err := s.MountAuto(dev, mnt); err != nil {
 			return err
 		}
 		defer s.Logger.LogOp(
@@ -297,6 +329,11 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 		Fetcher: s.Util.Fetcher,
 		Logger:  s.Logger,
 	}
+	// only paths on the root filesystem can be recorded
+	if fs.Name == "root" {
+		u.Managed = s.Util.Managed
+	}
+	u.Prefetched = s.Util.Prefetched
 
 	for _, e := range files {
 		path := e.getPath()
@@ -323,9 +360,36 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 			}
 			s.relabel(relabelFrom)
 		}
+		if distro.RestrictedExec() {
+			if err := checkRestrictedEntryOnDisk(u, e); err != nil {
+				return err
+			}
+		}
 		if err := e.create(s.Logger, u); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// checkRestrictedEntryOnDisk holds e to restricted mode against the symlinks
+// already on disk, which the config was validated without. The nodes of
+// archives are checked likewise as they're extracted.
+func checkRestrictedEntryOnDisk(u util.Util, e filesystemEntry) error {
+	what := fmt.Sprintf("%q", e.getPath())
+	linkTarget := ""
+	switch e := e.(type) {
+	case fileEntry:
+		what = "file " + what
+	case dirEntry:
+		what = "directory " + what
+	case linkEntry:
+		what = "link " + what
+		linkTarget = e.Target
+	case editEntry:
+		what = "edit " + what
+	case archiveEntry:
+		what = e.Format + " archive " + what
+	}
+	return checkRestrictedOnDisk(u, what, e.getPath(), linkTarget)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// extractGitTree writes the directory of a git repository named by the
+// archive's source to its destination. Files get mode 0644, or 0755 if
+// they're executable in the repository, and the entries are owned by the
+// archive's user and group, root by default.
+func extractGitTree(l *log.Logger, u util.Util, a types.Archive) error {
+	op := u.PrepareFetch(l, types.File{
+		Node: types.Node{Path: a.Path},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Source:      a.Source,
+				HTTPHeaders: a.HTTPHeaders,
+			},
+		},
+	})
+	if op == nil {
+		return fmt.Errorf("failed to resolve repository %q", a.Source)
+	}
+	uid, gid, err := u.ResolveNodeUidAndGid(types.Node{User: a.User, Group: a.Group}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	dest, err := u.JoinPath(a.Path)
+	if err != nil {
+		return err
+	}
+	if err := createArchiveDirs(dest, archiveDirMode, uid, gid); err != nil {
+		return err
+	}
+	u.RecordManaged(a.Path)
+
+	return u.Fetcher.ExtractGitTree(op.Url, op.FetchOptions, gitTree{u: u, a: a, uid: uid, gid: gid})
+}
+
+// gitTree writes the directory of a repository to the archive's
+// destination.
+type gitTree struct {
+	u        util.Util
+	a        types.Archive
+	uid, gid int
+}
+
+func (t gitTree) target(name, linkTarget string) (string, error) {
+	if _, err := archiveEntryName(name); err != nil {
+		return "", err
+	}
+	// the trees of repositories only carry the executable bit
+	if err := checkRestrictedEntry(t.u, t.a, name, 0755, linkTarget, nil); err != nil {
+		return "", err
+	}
+	return t.u.JoinPath(t.a.Path, name)
+}
+
+func (t gitTree) Mkdir(name string) error {
+	target, err := t.target(name, "")
+	if err != nil {
+		return err
+	}
+	if err := createArchiveDirs(target, archiveDirMode, t.uid, t.gid); err != nil {
+		return err
+	}
+	t.u.RecordManaged(path.Join(t.a.Path, name))
+	return nil
+}
+
+func (t gitTree) WriteFile(name string, executable bool, contents []byte) error {
+	target, err := t.target(name, "")
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(archiveFileMode)
+	if executable {
+		perm = 0755
+	}
+	if err := writeImageFile(bytes.NewReader(contents), target, perm, t.uid, t.gid); err != nil {
+		return err
+	}
+	t.u.RecordManaged(path.Join(t.a.Path, name))
+	return nil
+}
+
+func (t gitTree) Symlink(name, linkTarget string) error {
+	target, err := t.target(name, linkTarget)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := os.Symlink(linkTarget, target); err != nil {
+		return err
+	}
+	if err := os.Lchown(target, t.uid, t.gid); err != nil {
+		return err
+	}
+	t.u.RecordManaged(path.Join(t.a.Path, name))
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// tarXattrPrefix prefixes the PAX records of extended attributes.
+const tarXattrPrefix = "SCHILY.xattr."
+
+// extractImage extracts the directory of a container image named by the
+// archive's source into its destination. The entries keep the modes they
+// have in the image, and are owned by the archive's user and group, root by
+// default.
+func extractImage(l *log.Logger, u util.Util, a types.Archive) error {
+	src, err := url.Parse(a.Source)
+	if err != nil {
+		return err
+	}
+	uid, gid, err := u.ResolveNodeUidAndGid(types.Node{User: a.User, Group: a.Group}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	dest, err := u.JoinPath(a.Path)
+	if err != nil {
+		return err
+	}
+	if err := createArchiveDirs(dest, archiveDirMode, uid, gid); err != nil {
+		return err
+	}
+	u.RecordManaged(a.Path)
+
+	// fetch the layers to the destination's filesystem rather than to
+	// memory, as they may be large
+	tmpDir, err := ioutil.TempDir(dest, ".ignition-image")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	return u.Fetcher.ExtractImageTree(*src, tmpDir, imageTree{u: u, a: a, uid: uid, gid: gid, tmpDir: tmpDir})
+}
+
+// imageTree writes the directory of a container image to the archive's
+// destination.
+type imageTree struct {
+	u        util.Util
+	a        types.Archive
+	uid, gid int
+	tmpDir   string
+}
+
+func (t imageTree) target(name string) (string, error) {
+	if name == "" {
+		return t.u.JoinPath(t.a.Path)
+	}
+	if _, err := archiveEntryName(name); err != nil {
+		return "", err
+	}
+	return t.u.JoinPath(t.a.Path, name)
+}
+
+func (t imageTree) Remove(name string) error {
+	target, err := t.target(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(target)
+}
+
+func (t imageTree) Clear(name string) error {
+	target, err := t.target(name)
+	if err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(target)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		// the directory the layers are fetched to is kept
+		if name == "" && e.Name() == filepath.Base(t.tmpDir) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(target, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tarXattrs returns the extended attributes of the entry, which GNU tar and
+// other tools store as PAX records.
+func tarXattrs(hdr *tar.Header) []types.Xattr {
+	var xattrs []types.Xattr
+	for k, v := range hdr.PAXRecords {
+		if strings.HasPrefix(k, tarXattrPrefix) {
+			xattrs = append(xattrs, types.Xattr{Name: strings.TrimPrefix(k, tarXattrPrefix), Value: v})
+		}
+	}
+	sort.Slice(xattrs, func(i, j int) bool { return xattrs[i].Name < xattrs[j].Name })
+	return xattrs
+}
+
+func (t imageTree) Write(name string, hdr *tar.Header, r io.Reader) error {
+	target, err := t.target(name)
+	if err != nil {
+		return err
+	}
+	var linkTarget string
+	if hdr.Typeflag == tar.TypeSymlink {
+		linkTarget = hdr.Linkname
+	}
+	if err := checkRestrictedEntry(t.u, t.a, name, hdr.FileInfo().Mode(), linkTarget, tarXattrs(hdr)); err != nil {
+		return err
+	}
+	if name != "" {
+		if err := createArchiveDirs(filepath.Dir(target), archiveDirMode, t.uid, t.gid); err != nil {
+			return err
+		}
+	}
+	perm := os.FileMode(hdr.Mode).Perm()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := createArchiveDirs(target, perm, t.uid, t.gid); err != nil {
+			return err
+		}
+		// the directory may exist from a lower layer, or have been
+		// created as the parent of an earlier entry
+		if err := os.Chmod(target, perm); err != nil {
+			return err
+		}
+	case tar.TypeReg, tar.TypeRegA:
+		if err := writeImageFile(r, target, perm, t.uid, t.gid); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return err
+		}
+		if err := os.Lchown(target, t.uid, t.gid); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linked, err := t.target(hdr.Linkname)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Link(linked, target); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported file type %q", string(hdr.Typeflag))
+	}
+	if name != "" {
+		t.u.RecordManaged(path.Join(t.a.Path, name))
+	}
+	return nil
+}
+
+// writeImageFile writes the contents of the layer's entry to a temporary
+// file next to the target and renames it into place.
+func writeImageFile(r io.Reader, target string, perm os.FileMode, uid, gid int) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		return err
+	}
+	if err := tmp.Chown(uid, gid); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
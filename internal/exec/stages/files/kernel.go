@@ -0,0 +1,223 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	modprobeConfigPath = "/etc/modprobe.d/ignition.conf"
+	// deviceRulesPath sorts after the rules of the image, e.g.
+	// 50-udev-default.rules, so that its assignments win.
+	deviceRulesPath = "/etc/udev/rules.d/70-ignition-devices.rules"
+	// hugepages are allocated at boot through both files, so that they're
+	// there on the first boot, before the kernel arguments apply
+	hugepagesSysctlPath   = "/etc/sysctl.d/70-ignition-hugepages.conf"
+	hugepagesTmpfilesPath = "/etc/tmpfiles.d/ignition-hugepages.conf"
+)
+
+// createKernelConfig writes the module settings, device rules and hugepage
+// settings of the kernel section. The kernel arguments of the hugepages are
+// written by createOEM.
+func (s *stage) createKernelConfig(config types.Config) error {
+	k := config.Kernel
+	if len(k.Modules) == 0 && len(k.Devices) == 0 && k.Hugepages == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("createKernelConfig")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	var files []types.File
+	if len(k.Modules) > 0 {
+		files = append(files, kernelConfigFile(modprobeConfigPath, modprobeConfig(k.Modules)))
+	}
+	if len(k.Devices) > 0 {
+		files = append(files, kernelConfigFile(deviceRulesPath, deviceRules(k.Devices)))
+	}
+	if h := k.Hugepages; h != nil {
+		if sysctl := hugepagesSysctl(*h); sysctl != "" {
+			files = append(files, kernelConfigFile(hugepagesSysctlPath, sysctl))
+		}
+		if len(h.Pages) > 0 {
+			files = append(files, kernelConfigFile(hugepagesTmpfilesPath, hugepagesTmpfiles(h.Pages)))
+		}
+	}
+	for _, f := range files {
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
+
+func kernelConfigFile(path, contents string) types.File {
+	return types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       path,
+			Overwrite:  configUtil.BoolToPtr(true),
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0644),
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(contents)),
+			},
+		},
+	}
+}
+
+// modprobeConfig returns the modprobe.d(5) lines of the modules. Blacklisted
+// modules are also kept from being loaded explicitly or as a dependency of
+// another module, which blacklist alone doesn't prevent.
+func modprobeConfig(modules []types.KernelModule) string {
+	var b strings.Builder
+	b.WriteString("# Written by Ignition from kernel.modules\n")
+	for _, m := range modules {
+		if m.Blacklist {
+			fmt.Fprintf(&b, "blacklist %s\ninstall %s /bin/false\n", m.Name, m.Name)
+		}
+		if len(m.Options) > 0 {
+			fmt.Fprintf(&b, "options %s %s\n", m.Name, strings.Join(m.Options, " "))
+		}
+	}
+	return b.String()
+}
+
+// deviceRules returns the udev rules assigning the owner, group and mode of
+// the devices.
+func deviceRules(devices []types.KernelDevice) string {
+	var b strings.Builder
+	b.WriteString("# Written by Ignition from kernel.devices\n")
+	for _, d := range devices {
+		var keys []string
+		if d.Subsystem != "" {
+			keys = append(keys, fmt.Sprintf("SUBSYSTEM==%q", d.Subsystem))
+		}
+		if d.Kernel != "" {
+			keys = append(keys, fmt.Sprintf("KERNEL==%q", d.Kernel))
+		}
+		if d.Vendor != "" {
+			keys = append(keys, fmt.Sprintf("ATTRS{vendor}==%q", strings.ToLower(d.Vendor)))
+		}
+		if d.Owner != "" {
+			keys = append(keys, fmt.Sprintf("OWNER=%q", d.Owner))
+		}
+		if d.Group != "" {
+			keys = append(keys, fmt.Sprintf("GROUP=%q", d.Group))
+		}
+		if d.Mode != nil {
+			keys = append(keys, fmt.Sprintf("MODE=\"%04o\"", *d.Mode))
+		}
+		b.WriteString(strings.Join(keys, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// hugepagesSysctl returns the sysctl.d(5) lines of the hugepage settings,
+// or "" if there are none.
+func hugepagesSysctl(h types.KernelHugepages) string {
+	var lines []string
+	if h.Overcommit != nil {
+		lines = append(lines, fmt.Sprintf("vm.nr_overcommit_hugepages = %d", *h.Overcommit))
+	}
+	if h.ShmGroup != nil {
+		lines = append(lines, fmt.Sprintf("vm.hugetlb_shm_group = %d", *h.ShmGroup))
+	}
+	if h.NumaBalancing != nil {
+		v := 0
+		if *h.NumaBalancing {
+			v = 1
+		}
+		lines = append(lines, fmt.Sprintf("kernel.numa_balancing = %d", v))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "# Written by Ignition from kernel.hugepages\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// hugepagesTmpfiles returns the tmpfiles.d(5) lines allocating the pages
+// through sysfs, per NUMA node if one is given.
+func hugepagesTmpfiles(pages []types.KernelHugepage) string {
+	var b strings.Builder
+	b.WriteString("# Written by Ignition from kernel.hugepages\n")
+	for _, p := range pages {
+		dir := "/sys/kernel/mm/hugepages"
+		if p.Node != nil {
+			dir = fmt.Sprintf("/sys/devices/system/node/node%d/hugepages", *p.Node)
+		}
+		file := path.Join(dir, fmt.Sprintf("hugepages-%dkB", hugepageSizeKB(p.Size)), "nr_hugepages")
+		fmt.Fprintf(&b, "w %s - - - - %d\n", file, p.Count)
+	}
+	return b.String()
+}
+
+// hugepagesKernelArgs returns the kernel arguments allocating the pages at
+// boot, the pages of each size in the order the sizes are first listed, or
+// "" if there are none.
+func hugepagesKernelArgs(h *types.KernelHugepages) string {
+	if h == nil {
+		return ""
+	}
+	var args []string
+	if h.DefaultSize != "" {
+		args = append(args, "default_hugepagesz="+h.DefaultSize)
+	}
+	var sizes []int
+	counts := map[int][]string{}
+	names := map[int]string{}
+	for _, p := range h.Pages {
+		size := hugepageSizeKB(p.Size)
+		if _, ok := counts[size]; !ok {
+			sizes = append(sizes, size)
+			names[size] = p.Size
+		}
+		count := strconv.Itoa(p.Count)
+		if p.Node != nil {
+			count = fmt.Sprintf("%d:%d", *p.Node, p.Count)
+		}
+		counts[size] = append(counts[size], count)
+	}
+	for _, size := range sizes {
+		args = append(args, "hugepagesz="+names[size], "hugepages="+strings.Join(counts[size], ","))
+	}
+	return strings.Join(args, " ")
+}
+
+// hugepageSizeKB returns the size in KiB of a validated hugepage size like
+// "2M".
+func hugepageSizeKB(size string) int {
+	n, _ := strconv.Atoi(size[:len(size)-1])
+	switch size[len(size)-1] {
+	case 'M':
+		n <<= 10
+	case 'G':
+		n <<= 20
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	defaultBootstrapKubeconfigPath = "/var/lib/kubelet/bootstrap-kubeconfig"
+)
+
+// createBootstrapKubeconfig renders the kubelet bootstrap kubeconfig described
+// in kubernetes.bootstrapKubeconfig.
+func (s *stage) createBootstrapKubeconfig(config types.Config) error {
+	k := config.Kubernetes.BootstrapKubeconfig
+	if k == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("createBootstrapKubeconfig")
+	defer s.Logger.PopPrefix()
+
+	ca, err := s.fetchReference("certificate authority", k.CertificateAuthority.Source, k.CertificateAuthority.HTTPHeaders, k.CertificateAuthority.Verification)
+	if err != nil {
+		return err
+	}
+	token, err := s.fetchReference("bootstrap token", k.Token.Source, k.Token.HTTPHeaders, k.Token.Verification)
+	if err != nil {
+		return err
+	}
+
+	path := k.Path
+	if path == "" {
+		path = defaultBootstrapKubeconfigPath
+	}
+
+	u := s.Util
+	u.IsRoot = true
+	f := types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       path,
+			Overwrite:  configUtil.BoolToPtr(true),
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0600),
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(renderBootstrapKubeconfig(k.Server, ca, strings.TrimSpace(string(token))))),
+			},
+		},
+	}
+	if err := fileEntry(f).create(s.Logger, u); err != nil {
+		return err
+	}
+	s.relabel(path)
+	return nil
+}
+
+// fetchReference fetches and verifies the given resource into memory.
+func (s *stage) fetchReference(what, source string, headers types.HTTPHeaders, verification types.Verification) ([]byte, error) {
+	op := s.PrepareFetch(s.Logger, types.File{
+		Node: types.Node{Path: what},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Source:       source,
+				HTTPHeaders:  headers,
+				Verification: verification,
+			},
+		},
+	})
+	if op == nil {
+		return nil, fmt.Errorf("failed to resolve %s", what)
+	}
+	var data []byte
+	err := s.Logger.LogOp(func() error {
+		var err error
+		data, err = s.Fetcher.FetchToBuffer(op.Url, op.FetchOptions)
+		return err
+	}, "fetching %s", what)
+	return data, err
+}
+
+// renderBootstrapKubeconfig returns a kubeconfig authenticating to server
+// with the bootstrap token and trusting the PEM-encoded CA.
+func renderBootstrapKubeconfig(server string, ca []byte, token string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: default
+  cluster:
+    server: %q
+    certificate-authority-data: %s
+users:
+- name: kubelet-bootstrap
+  user:
+    token: %q
+contexts:
+- name: default
+  context:
+    cluster: default
+    user: kubelet-bootstrap
+current-context: default
+`, server, base64.StdEncoding.EncodeToString(ca), token)
+}
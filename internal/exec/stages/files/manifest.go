@@ -0,0 +1,178 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	ignConfig "github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	ignUtil "github.com/flatcar/ignition/internal/util"
+)
+
+// manifest is the format of the manifests listed under storage.manifests.
+type manifest struct {
+	Files []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Compression  string             `json:"compression,omitempty"`
+	Mode         *int               `json:"mode,omitempty"`
+	Path         string             `json:"path"`
+	Source       string             `json:"source"`
+	Verification types.Verification `json:"verification"`
+}
+
+// expandManifests fetches the manifests listed under storage.manifests and
+// returns the config with the files they list added to storage.files, so
+// that they're fetched, verified and written like the config's own files.
+func (s *stage) expandManifests(config types.Config) (types.Config, error) {
+	if len(config.Storage.Manifests) == 0 {
+		return config, nil
+	}
+	s.Logger.PushPrefix("expandManifests")
+	defer s.Logger.PopPrefix()
+
+	// don't append to the backing array of the caller's config
+	files := append([]types.File(nil), config.Storage.Files...)
+	for _, m := range config.Storage.Manifests {
+		data, err := s.fetchReference(fmt.Sprintf("manifest %q", m.Source), m.Source, m.HTTPHeaders, m.Verification)
+		if err != nil {
+			return config, err
+		}
+		listed, err := parseManifest(m, data)
+		if err != nil {
+			return config, fmt.Errorf("bad manifest %q: %v", m.Source, err)
+		}
+		// the listed files weren't part of the config when it was
+		// checked, so they're held to restricted mode here, along with
+		// the config's links they may lead through
+		if distro.RestrictedExec() {
+			restricted := types.Config{Storage: types.Storage{
+				Files:       listed,
+				Directories: config.Storage.Directories,
+				Links:       config.Storage.Links,
+			}}
+			if r := ignConfig.ValidateRestricted(restricted); r.IsFatal() {
+				return config, fmt.Errorf("manifest %q lists files not allowed in restricted mode:\n%s", m.Source, r)
+			}
+		}
+		s.Logger.Info("manifest %q lists %d files below %q", m.Source, len(listed), m.Path)
+		files = append(files, listed...)
+	}
+	config.Storage.Files = files
+	return config, nil
+}
+
+// parseManifest returns the files listed by the manifest. Their paths are
+// relative to the manifest's path and may not leave it, their sources are
+// relative to the manifest's source, and each needs a hash to verify it
+// against. The manifest's HTTP headers are only sent along with sources on
+// the same host as the manifest.
+func parseManifest(m types.Manifest, data []byte) ([]types.File, error) {
+	var mf manifest
+	if err := json.Unmarshal(data, &mf); err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(m.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []types.File
+	seen := map[string]bool{}
+	for _, e := range mf.Files {
+		if e.Path == "" || path.IsAbs(e.Path) || path.Clean(e.Path) != e.Path ||
+			e.Path == ".." || strings.HasPrefix(e.Path, "../") {
+			return nil, fmt.Errorf("path %q isn't a clean path relative to the manifest's", e.Path)
+		}
+		if seen[e.Path] {
+			return nil, fmt.Errorf("%q is listed twice", e.Path)
+		}
+		seen[e.Path] = true
+
+		if e.Source == "" {
+			return nil, fmt.Errorf("%q has no source", e.Path)
+		}
+		ref, err := url.Parse(e.Source)
+		if err != nil {
+			return nil, fmt.Errorf("%q has an invalid source: %v", e.Path, err)
+		}
+		source := base.ResolveReference(ref)
+		if err := validateManifestHash(e.Verification); err != nil {
+			return nil, fmt.Errorf("%q has an invalid hash: %v", e.Path, err)
+		}
+		switch e.Compression {
+		case "", "gzip", "xz", "zstd":
+		default:
+			return nil, fmt.Errorf("%q has an unsupported compression %q", e.Path, e.Compression)
+		}
+		mode := 0644
+		if e.Mode != nil {
+			mode = *e.Mode
+		}
+		if mode < 0 || mode > 07777 {
+			return nil, fmt.Errorf("%q has an illegal mode %#o", e.Path, mode)
+		}
+
+		var headers types.HTTPHeaders
+		if source.Scheme == base.Scheme && source.Host == base.Host {
+			headers = m.HTTPHeaders
+		}
+		files = append(files, types.File{
+			Node: types.Node{
+				Filesystem: m.Filesystem,
+				Path:       path.Join(m.Path, e.Path),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: &mode,
+				Contents: types.FileContents{
+					Compression:  e.Compression,
+					HTTPHeaders:  headers,
+					Source:       source.String(),
+					Verification: e.Verification,
+				},
+			},
+		})
+	}
+	return files, nil
+}
+
+// validateManifestHash checks that the hash of a manifest entry is set and
+// names a known function along with a sum of the right length.
+func validateManifestHash(v types.Verification) error {
+	if v.Hash == nil {
+		return errors.New("missing")
+	}
+	hasher, err := ignUtil.GetHasher(v)
+	if err != nil {
+		return err
+	}
+	_, sum, err := ignUtil.HashParts(v)
+	if err != nil {
+		return err
+	}
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != hasher.Size() {
+		return ignUtil.ErrHashMalformed
+	}
+	return nil
+}
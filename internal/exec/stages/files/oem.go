@@ -0,0 +1,199 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+const (
+	// the files on the OEM partition written from storage.oem
+	oemGrubConfig  = "grub.cfg"
+	oemReleaseFile = "oem-release"
+
+	// the grub.cfg fragment is kept between these markers, so that it's
+	// replaced rather than appended to when Ignition runs again
+	oemGrubBeginMarker = "# BEGIN IGNITION"
+	oemGrubEndMarker   = "# END IGNITION"
+)
+
+// createOEM writes the grub.cfg fragment and the oem-release fields of
+// storage.oem to the OEM partition, the fragment followed by the kernel
+// arguments of the hugepages. The partition is mounted for the duration of
+// the writes unless it already is.
+func (s *stage) createOEM(config types.Config) error {
+	var oem types.OEM
+	if config.Storage.OEM != nil {
+		oem = *config.Storage.OEM
+	}
+	if args := hugepagesKernelArgs(config.Kernel.Hugepages); args != "" {
+		if oem.GrubConfig != "" && !strings.HasSuffix(oem.GrubConfig, "\n") {
+			oem.GrubConfig += "\n"
+		}
+		oem.GrubConfig += fmt.Sprintf("set linux_append=\"$linux_append %s\"\n", args)
+	}
+	if oem.GrubConfig == "" && oem.Release == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("createOEM")
+	defer s.Logger.PopPrefix()
+
+	mnt := s.Fetcher.OEMMountPath
+	if mnt == "" {
+		var err error
+		mnt, err = ioutil.TempDir("/mnt", "oem")
+		if err != nil {
+			return fmt.Errorf("failed to create mount path for oem partition: %v", err)
+		}
+		defer os.Remove(mnt)
+		if err := s.Fetcher.MountOEM(mnt); err != nil {
+			return fmt.Errorf("failed to mount oem partition: %v", err)
+		}
+		defer s.Fetcher.UmountOEM(mnt)
+	}
+
+	return writeOEM(mnt, oem)
+}
+
+// writeOEM updates the files of the OEM partition mounted at mnt.
+func writeOEM(mnt string, oem types.OEM) error {
+	if oem.GrubConfig != "" {
+		path := filepath.Join(mnt, oemGrubConfig)
+		old, err := readOptional(path)
+		if err != nil {
+			return err
+		}
+		if err := writeAtomic(path, updateGrubConfig(old, oem.GrubConfig)); err != nil {
+			return fmt.Errorf("failed to write %q: %v", path, err)
+		}
+	}
+	if oem.Release != nil {
+		path := filepath.Join(mnt, oemReleaseFile)
+		old, err := readOptional(path)
+		if err != nil {
+			return err
+		}
+		if err := writeAtomic(path, updateOEMRelease(old, *oem.Release)); err != nil {
+			return fmt.Errorf("failed to write %q: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// updateGrubConfig replaces the Ignition block of the grub.cfg contents old
+// with fragment, appending the block if there is none yet.
+func updateGrubConfig(old, fragment string) string {
+	if !strings.HasSuffix(fragment, "\n") {
+		fragment += "\n"
+	}
+	block := oemGrubBeginMarker + "\n" + fragment + oemGrubEndMarker + "\n"
+
+	var out []string
+	lines := strings.SplitAfter(old, "\n")
+	replaced := false
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != oemGrubBeginMarker || replaced {
+			out = append(out, lines[i])
+			continue
+		}
+		// skip to the end marker; an unterminated block runs to the end
+		for i < len(lines) && strings.TrimSpace(lines[i]) != oemGrubEndMarker {
+			i++
+		}
+		out = append(out, block)
+		replaced = true
+	}
+	res := strings.Join(out, "")
+	if replaced {
+		return res
+	}
+	if res != "" && !strings.HasSuffix(res, "\n") {
+		res += "\n"
+	}
+	return res + block
+}
+
+// updateOEMRelease sets the fields of rel in the oem-release contents old,
+// keeping the other fields.
+func updateOEMRelease(old string, rel types.OEMRelease) string {
+	var fields []keyValue
+	for _, f := range []keyValue{
+		{"ID", rel.ID},
+		{"NAME", rel.Name},
+		{"VERSION_ID", rel.VersionID},
+		{"HOME_URL", rel.HomeURL},
+		{"BUG_REPORT_URL", rel.BugReportURL},
+	} {
+		if f.value != "" {
+			fields = append(fields, keyValue{f.key, quoteOSRelease(f.value)})
+		}
+	}
+	return setKeyValues(old, fields)
+}
+
+// quoteOSRelease quotes s as a value of an os-release(5) style file.
+func quoteOSRelease(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// readOptional returns the contents of path, or "" if it doesn't exist.
+func readOptional(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+// writeAtomic replaces path with contents, so that the file is either intact
+// or updated if the machine loses power during the write.
+func writeAtomic(path, contents string) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := tmp.WriteString(contents); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
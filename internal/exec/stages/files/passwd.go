@@ -18,10 +18,16 @@ import (
 	"fmt"
 
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
 )
 
 // createPasswd creates the users and groups as described in config.Passwd.
 func (s *stage) createPasswd(config types.Config) error {
+	// before the users, so that they get the aging defaults
+	if err := s.createPolicy(config); err != nil {
+		return fmt.Errorf("failed to write the password policy: %v", err)
+	}
+
 	if err := s.createGroups(config); err != nil {
 		return fmt.Errorf("failed to create groups: %v", err)
 	}
@@ -30,9 +36,14 @@ func (s *stage) createPasswd(config types.Config) error {
 		return fmt.Errorf("failed to create users: %v", err)
 	}
 
+	if err := s.createSudoers(config); err != nil {
+		return fmt.Errorf("failed to create sudoers entries: %v", err)
+	}
+
 	// to be safe, just blanket mark all passwd-related files rather than
 	// trying to make it more granular based on which executables we ran
 	if len(config.Passwd.Groups) != 0 || len(config.Passwd.Users) != 0 {
+		s.RecordManaged("/etc/passwd", "/etc/group", "/etc/shadow", "/etc/gshadow")
 		s.relabel(
 			"/etc/passwd*",
 			"/etc/group*",
@@ -80,6 +91,47 @@ func (s stage) createUsers(config types.Config) error {
 	return nil
 }
 
+// createSudoers writes the entries of config.Passwd.Sudoers to the sudoers
+// fragment of Ignition.
+func (s *stage) createSudoers(config types.Config) error {
+	if len(config.Passwd.Sudoers) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createSudoers")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	f := util.SudoersFile(config.Passwd.Sudoers)
+	if err := fileEntry(f).create(s.Logger, u); err != nil {
+		return err
+	}
+	s.relabel(f.Path)
+	return nil
+}
+
+// createPolicy merges the settings of config.Passwd.Policy into the files
+// of the PAM modules and login tools reading them.
+func (s *stage) createPolicy(config types.Config) error {
+	if config.Passwd.Policy == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("createPolicy")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	for _, f := range util.PolicyFiles(*config.Passwd.Policy) {
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
+
 // createGroups creates the users as described in config.Passwd.Groups.
 func (s stage) createGroups(config types.Config) error {
 	if len(config.Passwd.Groups) == 0 {
@@ -0,0 +1,206 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ignConfig "github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/fips"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+// phoneHomeReport is posted to ssh.phoneHome.url.
+type phoneHomeReport struct {
+	Hostname  string             `json:"hostname,omitempty"`
+	MachineID string             `json:"machineId,omitempty"`
+	Metadata  map[string]string  `json:"metadata,omitempty"`
+	HostKeys  []phoneHomeHostKey `json:"hostKeys"`
+}
+
+type phoneHomeHostKey struct {
+	Type      string `json:"type"`
+	PublicKey string `json:"publicKey"`
+}
+
+// phoneHome generates the missing SSH host keys, unless the image's host key
+// generation is disabled, and posts the public keys along with the identity
+// of the machine to ssh.phoneHome.url, so that inventory systems can trust
+// the machine before it's first reached.
+func (s *stage) phoneHome(config types.Config) error {
+	p := config.SSH.PhoneHome
+	if p == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("phoneHome")
+	defer s.Logger.PopPrefix()
+
+	if !config.SSH.DisableHostKeyGeneration {
+		if err := s.generateSSHHostKeys(); err != nil {
+			return err
+		}
+	}
+
+	report, err := s.phoneHomeReport()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return err
+	}
+	headers, err := p.HTTPHeaders.Parse()
+	if err != nil {
+		return err
+	}
+	headers.Set("Content-Type", "application/json")
+	return s.Logger.LogOp(func() error {
+		_, err := s.Fetcher.PostToBuffer(*u, body, resource.FetchOptions{Headers: headers})
+		return err
+	}, "posting %d ssh host keys to %q", len(report.HostKeys), u.Host)
+}
+
+// generateSSHHostKeys runs ssh-keygen in the target to generate the host
+// keys of the types missing, as the image's host key generation would on
+// first boot. In FIPS mode, only the missing keys of approved types are
+// generated, since ssh-keygen -A would also generate ed25519 keys.
+func (s *stage) generateSSHHostKeys() error {
+	if fips.Enabled() {
+		for _, typ := range fips.ApprovedSSHHostKeyTypes() {
+			path := filepath.Join(sshConfigDir, fmt.Sprintf("ssh_host_%s_key", typ))
+			target, err := s.JoinPath(path)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Lstat(target); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+			if _, err := s.Logger.LogCmd(
+				exec.Command(distro.ChrootCmd(), s.DestDir, distro.SSHKeygenCmd(), "-q", "-t", typ, "-N", "", "-f", path),
+				"generating ssh host key %q", path,
+			); err != nil {
+				return err
+			}
+		}
+	} else if _, err := s.Logger.LogCmd(
+		exec.Command(distro.ChrootCmd(), s.DestDir, distro.SSHKeygenCmd(), "-A"),
+		"generating missing ssh host keys",
+	); err != nil {
+		return err
+	}
+	keys, err := s.sshHostKeyPaths("ssh_host_*_key*")
+	if err != nil {
+		return err
+	}
+	s.relabel(keys...)
+	return nil
+}
+
+// sshHostKeyPaths returns the paths in the target of the files in the ssh
+// config directory matching pattern.
+func (s *stage) sshHostKeyPaths(pattern string) ([]string, error) {
+	dir, err := s.JoinPath(sshConfigDir)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = filepath.Join(sshConfigDir, filepath.Base(m))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// phoneHomeReport collects the public host keys and the identity of the
+// machine: its hostname, its machine id if it already has one, and the
+// metadata attributes of the provider.
+func (s *stage) phoneHomeReport() (phoneHomeReport, error) {
+	var report phoneHomeReport
+
+	keys, err := s.sshHostKeyPaths("ssh_host_*_key.pub")
+	if err != nil {
+		return report, err
+	}
+	for _, k := range keys {
+		contents, err := s.readTargetFile(k)
+		if err != nil {
+			return report, err
+		}
+		typ := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(k), "ssh_host_"), "_key.pub")
+		report.HostKeys = append(report.HostKeys, phoneHomeHostKey{
+			Type:      typ,
+			PublicKey: contents,
+		})
+	}
+
+	if report.Hostname, err = s.readTargetFile("/etc/hostname"); err != nil && !os.IsNotExist(err) {
+		return report, err
+	}
+	if report.Hostname == "" {
+		if report.Hostname, err = os.Hostname(); err != nil {
+			return report, err
+		}
+	}
+	if report.MachineID, err = s.readTargetFile("/etc/machine-id"); err != nil && !os.IsNotExist(err) {
+		return report, err
+	}
+	if report.MachineID == "uninitialized" {
+		report.MachineID = ""
+	}
+
+	attrs, err := ignConfig.ReadMetadataAttributes(distro.MetadataAttributesPath())
+	if err != nil && !os.IsNotExist(err) {
+		return report, err
+	}
+	if len(attrs) > 0 {
+		report.Metadata = map[string]string{}
+	}
+	for name, value := range attrs {
+		report.Metadata[strings.TrimPrefix(name, "IGNITION_")] = value
+	}
+	return report, nil
+}
+
+// readTargetFile returns the trimmed contents of the file at path in the
+// target.
+func (s *stage) readTargetFile(path string) (string, error) {
+	path, err := s.JoinPath(path)
+	if err != nil {
+		return "", err
+	}
+	contents, err := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(contents)), err
+}
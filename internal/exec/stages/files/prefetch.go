@@ -0,0 +1,54 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// prefetchFiles starts fetching the contents of the config's remote files
+// in the background, so that slow fetches overlap with each other and with
+// creating the users and groups. Only the fetching is done ahead: users and
+// groups are still created before the directories, files and links, in
+// order, as nodes may be owned by users the config creates and placed below
+// directories and links it creates. Files of higher priority are fetched
+// first, as they're written first.
+func (s *stage) prefetchFiles(config types.Config) {
+	workers := distro.ConcurrentFetches()
+	if workers <= 0 {
+		return
+	}
+	var ops []*util.FetchOp
+	for _, f := range sortFilesByPriority(config.Storage.Files) {
+		if op := s.PrepareFetch(s.Logger, f); op != nil && util.Prefetchable(op) {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return
+	}
+
+	prefetched, err := s.Prefetch(ops, workers)
+	if err != nil {
+		// e.g. read-only roots; the files are then fetched as they're
+		// written
+		s.Logger.Info("not prefetching files: %v", err)
+		return
+	}
+	s.Logger.Info("prefetching the contents of %d files, %d at once", len(ops), workers)
+	s.Prefetched = prefetched
+}
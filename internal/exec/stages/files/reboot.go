@@ -0,0 +1,118 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// rebootRequest is recorded in distro.RebootRequestPath() if the config
+// requires a reboot.
+type rebootRequest struct {
+	Method  string   `json:"method"`
+	Reasons []string `json:"reasons"`
+}
+
+// requestReboot records a reboot request if the config requires one, and
+// adds a runtime unit performing it once the system is up if requested.
+func (s *stage) requestReboot(config types.Config) error {
+	reasons := rebootReasons(config)
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	req := rebootRequest{
+		Method:  config.Reboot.Method,
+		Reasons: reasons,
+	}
+	if req.Method == "" {
+		req.Method = "reboot"
+	}
+
+	path := distro.RebootRequestPath()
+	if err := s.Logger.LogOp(func() error {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, b, 0644)
+	}, "recording %s request in %q", req.Method, path); err != nil {
+		return err
+	}
+
+	if !config.Reboot.Trigger {
+		return nil
+	}
+
+	unit := types.Unit{
+		Name: "ignition-reboot.service",
+		Contents: `[Unit]
+Description=Reboot requested by Ignition
+After=multi-user.target
+ConditionPathExists=` + path + `
+
+[Service]
+Type=oneshot
+ExecStart=` + distro.SystemctlCmd() + ` --no-block ` + req.Method,
+	}
+
+	if err := s.writeSystemdUnit(unit, true); err != nil {
+		return err
+	}
+
+	return s.EnableRuntimeUnit(unit, "multi-user.target")
+}
+
+// rebootReasons returns why the config requires a reboot: "config" if it
+// requires one unconditionally, and the paths listed in reboot.paths that are
+// written to the root filesystem.
+func rebootReasons(config types.Config) []string {
+	var reasons []string
+	if config.Reboot.Required {
+		reasons = append(reasons, "config")
+	}
+
+	written := map[string]bool{}
+	for _, f := range config.Storage.Files {
+		if f.Filesystem == "root" {
+			written[filepath.Clean(f.Path)] = true
+		}
+	}
+	for _, d := range config.Storage.Directories {
+		if d.Filesystem == "root" {
+			written[filepath.Clean(d.Path)] = true
+		}
+	}
+	for _, l := range config.Storage.Links {
+		if l.Filesystem == "root" {
+			written[filepath.Clean(l.Path)] = true
+		}
+	}
+	for _, p := range config.Reboot.Paths {
+		if written[filepath.Clean(string(p))] {
+			reasons = append(reasons, string(p))
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/util"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	sshConfigDir         = "/etc/ssh"
+	sshdConfigPath       = "/etc/ssh/sshd_config"
+	sshdConfigSnippetDir = "/etc/ssh/sshd_config.d"
+)
+
+// createSSHHostKeys writes the host keys listed under ssh.hostKeys and masks
+// the image's host key generation service if requested.
+func (s *stage) createSSHHostKeys(config types.Config) error {
+	if len(config.SSH.HostKeys) == 0 && !config.SSH.DisableHostKeyGeneration {
+		return nil
+	}
+	s.Logger.PushPrefix("createSSHHostKeys")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	for _, k := range config.SSH.HostKeys {
+		path := filepath.Join(sshConfigDir, fmt.Sprintf("ssh_host_%s_key", k.Type))
+		files := []types.File{{
+			Node: types.Node{
+				Filesystem: "root",
+				Path:       path,
+				Overwrite:  configUtil.BoolToPtr(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: configUtil.IntToPtr(0600),
+				Contents: types.FileContents{
+					Source:       k.Source,
+					HTTPHeaders:  k.HTTPHeaders,
+					Verification: k.Verification,
+				},
+			},
+		}}
+		if k.PublicKey != "" {
+			files = append(files, types.File{
+				Node: types.Node{
+					Filesystem: "root",
+					Path:       path + ".pub",
+					Overwrite:  configUtil.BoolToPtr(true),
+				},
+				FileEmbedded1: types.FileEmbedded1{
+					Mode: configUtil.IntToPtr(0644),
+					Contents: types.FileContents{
+						Source: dataurl.EncodeBytes([]byte(k.PublicKey + "\n")),
+					},
+				},
+			})
+		}
+
+		for _, f := range files {
+			if err := fileEntry(f).create(s.Logger, u); err != nil {
+				return err
+			}
+			s.relabel(f.Path)
+		}
+	}
+
+	if config.SSH.DisableHostKeyGeneration {
+		unit := types.Unit{Name: distro.SSHKeygenUnit()}
+		if err := s.Logger.LogOp(
+			func() error { return s.MaskUnit(unit) },
+			"masking unit %q", unit.Name,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSSHConfigSnippets writes the sshd configuration snippets listed under
+// ssh.configSnippets and checks that sshd_config includes them.
+func (s *stage) createSSHConfigSnippets(config types.Config) error {
+	if len(config.SSH.ConfigSnippets) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createSSHConfigSnippets")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	includes, err := s.sshdConfigIncludes(u)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range config.SSH.ConfigSnippets {
+		path := filepath.Join(sshdConfigSnippetDir, c.Name)
+		if !matchesAny(includes, path) {
+			if config.SSH.RequireConfigInclude {
+				return fmt.Errorf("%s does not include %q", sshdConfigPath, path)
+			}
+			s.Logger.Warning("%s does not include %q, the snippet will be ignored by sshd", sshdConfigPath, path)
+		}
+
+		f := types.File{
+			Node: types.Node{
+				Filesystem: "root",
+				Path:       path,
+				Overwrite:  configUtil.BoolToPtr(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: configUtil.IntToPtr(0644),
+				Contents: types.FileContents{
+					Source: dataurl.EncodeBytes([]byte(c.Contents)),
+				},
+			},
+		}
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
+
+// sshdConfigIncludes returns the patterns of the Include directives of the
+// target's sshd_config, made absolute the way sshd does.
+func (s *stage) sshdConfigIncludes(u util.Util) ([]string, error) {
+	path, err := u.JoinPath(sshdConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var includes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.Replace(scanner.Text(), "=", " ", 1))
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "Include") {
+			continue
+		}
+		for _, pattern := range fields[1:] {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(sshConfigDir, pattern)
+			}
+			includes = append(includes, pattern)
+		}
+	}
+	return includes, scanner.Err()
+}
+
+func matchesAny(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	timeConfigName       = "50-ignition.conf"
+	defaultChronyConfDir = "/etc/chrony.d"
+	timesyncdConfigDir   = "/etc/systemd/timesyncd.conf.d"
+)
+
+var (
+	chronydPaths    = []string{"/usr/sbin/chronyd", "/usr/bin/chronyd"}
+	chronyConfPaths = []string{"/etc/chrony.conf", "/etc/chrony/chrony.conf"}
+	timesyncdPaths  = []string{"/usr/lib/systemd/systemd-timesyncd", "/lib/systemd/systemd-timesyncd"}
+)
+
+// createTimeConfig writes the time section for the time daemon of the
+// target, chrony if it has it and systemd-timesyncd otherwise.
+func (s *stage) createTimeConfig(config types.Config) error {
+	t := config.Time
+	if len(t.Servers) == 0 && t.MakeStep == nil {
+		return nil
+	}
+	s.Logger.PushPrefix("createTimeConfig")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	var path, contents string
+	if ok, err := existsAny(u, chronydPaths); err != nil {
+		return err
+	} else if ok {
+		dir, err := s.chronyConfDir(u)
+		if err != nil {
+			return err
+		}
+		path, contents = filepath.Join(dir, timeConfigName), chronyConfig(t)
+	} else if ok, err := existsAny(u, timesyncdPaths); err != nil {
+		return err
+	} else if ok {
+		for _, server := range t.Servers {
+			if server.NTS {
+				return fmt.Errorf("systemd-timesyncd doesn't support NTS, which server %q requires", server.Address)
+			}
+		}
+		if t.MakeStep != nil {
+			s.Logger.Warning("systemd-timesyncd steps the clock by offsets over 0.4s, makeStep is ignored")
+		}
+		if len(t.Servers) == 0 {
+			return nil
+		}
+		path, contents = filepath.Join(timesyncdConfigDir, timeConfigName), timesyncdConfig(t)
+	} else {
+		return fmt.Errorf("neither chrony nor systemd-timesyncd found")
+	}
+
+	f := types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       path,
+			Overwrite:  configUtil.BoolToPtr(true),
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0644),
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(contents)),
+			},
+		},
+	}
+	if err := fileEntry(f).create(s.Logger, u); err != nil {
+		return err
+	}
+	s.relabel(f.Path)
+	return nil
+}
+
+// chronyConfDir returns the directory chrony reads the configuration of
+// Ignition from: the first confdir of the target's chrony.conf, or
+// /etc/chrony.d if it has none, which is then only read if chrony.conf
+// includes it.
+func (s *stage) chronyConfDir(u util.Util) (string, error) {
+	var includes []string
+	for _, conf := range chronyConfPaths {
+		path, err := u.JoinPath(conf)
+		if err != nil {
+			return "", err
+		}
+		contents, err := readOptional(path)
+		if err != nil {
+			return "", err
+		}
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			switch strings.ToLower(fields[0]) {
+			case "confdir":
+				return fields[1], nil
+			case "include":
+				includes = append(includes, fields[1])
+			}
+		}
+	}
+	path := filepath.Join(defaultChronyConfDir, timeConfigName)
+	if !matchesAny(includes, path) {
+		s.Logger.Warning("chrony.conf does not include %q, the settings will be ignored by chronyd", path)
+	}
+	return defaultChronyConfDir, nil
+}
+
+// chronyConfig returns the directives of chrony.conf(5) for t.
+func chronyConfig(t types.Time) string {
+	var b strings.Builder
+	b.WriteString("# Written by Ignition from the time section\n")
+	for _, server := range t.Servers {
+		b.WriteString("server " + server.Address + " iburst")
+		if server.NTS {
+			b.WriteString(" nts")
+		}
+		b.WriteString("\n")
+	}
+	if m := t.MakeStep; m != nil {
+		// validated to parse
+		threshold, _ := time.ParseDuration(m.Threshold)
+		fmt.Fprintf(&b, "makestep %s %d\n", strconv.FormatFloat(threshold.Seconds(), 'f', -1, 64), m.Limit)
+	}
+	return b.String()
+}
+
+// timesyncdConfig returns the settings of timesyncd.conf(5) for t.
+func timesyncdConfig(t types.Time) string {
+	var servers []string
+	for _, server := range t.Servers {
+		servers = append(servers, server.Address)
+	}
+	return "# Written by Ignition from the time section\n[Time]\nNTP=" + strings.Join(servers, " ") + "\n"
+}
+
+// existsAny returns whether any of paths exists in the target.
+func existsAny(u util.Util, paths []string) (bool, error) {
+	for _, p := range paths {
+		path, err := u.JoinPath(p)
+		if err != nil {
+			return false, err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true, nil
+		} else if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
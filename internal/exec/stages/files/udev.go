@@ -0,0 +1,60 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"path/filepath"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// createUdevRules writes the rules listed under storage.udevRules, named
+// with their priority. Their syntax was checked when the config was
+// validated.
+func (s *stage) createUdevRules(config types.Config) error {
+	if len(config.Storage.UdevRules) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createUdevRules")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	for _, r := range config.Storage.UdevRules {
+		f := types.File{
+			Node: types.Node{
+				Filesystem: "root",
+				Path:       filepath.Join(util.UdevRulesDir, util.UdevRuleFileName(r)),
+				Overwrite:  configUtil.BoolToPtr(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: configUtil.IntToPtr(0644),
+				Contents: types.FileContents{
+					Source: dataurl.EncodeBytes([]byte(util.UdevRuleContents(r))),
+				},
+			},
+		}
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
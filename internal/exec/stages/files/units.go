@@ -15,14 +15,13 @@
 package files
 
 import (
-	"path/filepath"
-
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/exec/util"
 )
 
-// createUnits creates the units listed under systemd.units and networkd.units.
+// createUnits creates the units listed under systemd.units and networkd.units,
+// and the files of networkd.links, bonds, bridges, vlans and wireguard.
 func (s *stage) createUnits(config types.Config) error {
 	enabledOneUnit := false
 	for _, unit := range config.Systemd.Units {
@@ -71,6 +70,26 @@ func (s *stage) createUnits(config types.Config) error {
 		s.relabel(util.PresetPath)
 	}
 	for _, unit := range config.Networkd.Units {
+		if distro.InitSystem() != "systemd" {
+			s.Logger.Warning("writing networkd unit %q, which has no effect without systemd-networkd", unit.Name)
+		}
+		if err := s.writeNetworkdUnit(util.NetworkdUnitWithRoutes(unit)); err != nil {
+			return err
+		}
+	}
+	for _, link := range config.Networkd.Links {
+		unit := util.NetworkdLinkUnit(link)
+		if distro.InitSystem() != "systemd" {
+			s.Logger.Warning("writing networkd link %q, which has no effect without systemd-udevd", unit.Name)
+		}
+		if err := s.writeNetworkdUnit(unit); err != nil {
+			return err
+		}
+	}
+	for _, unit := range util.NetworkdNetdevUnits(config.Networkd) {
+		if distro.InitSystem() != "systemd" {
+			s.Logger.Warning("writing networkd unit %q, which has no effect without systemd-networkd", unit.Name)
+		}
 		if err := s.writeNetworkdUnit(unit); err != nil {
 			return err
 		}
@@ -82,54 +101,10 @@ func (s *stage) createUnits(config types.Config) error {
 // If the contents of the unit or are empty, the unit is not created. The same
 // applies to the unit's dropins.
 func (s *stage) writeSystemdUnit(unit types.Unit, runtime bool) error {
-	// use a different DestDir if it's runtime so it affects our /run (but not
-	// if we're running locally through blackbox tests)
-	u := s.Util
-	if runtime && !distro.BlackboxTesting() {
-		u.DestDir = "/"
-	}
-
 	return s.Logger.LogOp(func() error {
-		relabeledDropinDir := false
-		for _, dropin := range unit.Dropins {
-			if dropin.Contents == "" {
-				continue
-			}
-			f, err := util.FileFromSystemdUnitDropin(unit, dropin, runtime)
-			if err != nil {
-				s.Logger.Crit("error converting systemd dropin: %v", err)
-				return err
-			}
-			if err := s.Logger.LogOp(
-				func() error { return u.PerformFetch(f) },
-				"writing systemd drop-in %q at %q", dropin.Name, f.Path,
-			); err != nil {
-				return err
-			}
-			if !relabeledDropinDir {
-				s.relabel(filepath.Dir("/" + f.Path))
-				relabeledDropinDir = true
-			}
-		}
-
-		if unit.Contents == "" {
-			return nil
-		}
-
-		f, err := util.FileFromSystemdUnit(unit, runtime)
-		if err != nil {
-			s.Logger.Crit("error converting unit: %v", err)
-			return err
-		}
-		if err := s.Logger.LogOp(
-			func() error { return u.PerformFetch(f) },
-			"writing unit %q at %q", unit.Name, f.Path,
-		); err != nil {
-			return err
-		}
-		s.relabel("/" + f.Path)
-
-		return nil
+		relabel, err := s.WriteUnit(unit, runtime)
+		s.relabel(relabel...)
+		return err
 	}, "processing unit %q", unit.Name)
 }
 
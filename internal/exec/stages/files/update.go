@@ -0,0 +1,128 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const updateConfPath = "/etc/flatcar/update.conf"
+
+// keyValue is a line of a KEY=value file like update.conf or oem-release,
+// with the value already quoted as needed.
+type keyValue struct {
+	key   string
+	value string
+}
+
+// updateConfFields returns the update.conf settings of the update section,
+// read by update_engine and locksmithd.
+func updateConfFields(update types.Update) []keyValue {
+	var fields []keyValue
+	if update.Group != "" {
+		fields = append(fields, keyValue{"GROUP", update.Group})
+	}
+	if update.Server != "" {
+		fields = append(fields, keyValue{"SERVER", update.Server})
+	}
+	if update.RebootStrategy != "" {
+		fields = append(fields, keyValue{"REBOOT_STRATEGY", update.RebootStrategy})
+	}
+	if w := update.RebootWindow; w != nil {
+		fields = append(fields,
+			keyValue{"LOCKSMITHD_REBOOT_WINDOW_START", w.Start},
+			keyValue{"LOCKSMITHD_REBOOT_WINDOW_LENGTH", w.Length},
+		)
+	}
+	return fields
+}
+
+// createUpdateConfig sets the settings of the update section in the target's
+// update.conf, keeping the settings written by the image or file entries.
+func (s *stage) createUpdateConfig(config types.Config) error {
+	fields := updateConfFields(config.Update)
+	if len(fields) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createUpdateConfig")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	path, err := u.JoinPath(updateConfPath)
+	if err != nil {
+		return err
+	}
+	old, err := readOptional(path)
+	if err != nil {
+		return err
+	}
+
+	f := types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       updateConfPath,
+			Overwrite:  configUtil.BoolToPtr(true),
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0644),
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(setKeyValues(old, fields))),
+			},
+		},
+	}
+	if err := fileEntry(f).create(s.Logger, u); err != nil {
+		return err
+	}
+	s.relabel(f.Path)
+	return nil
+}
+
+// setKeyValues sets fields in the KEY=value file contents old, replacing the
+// lines of the keys in place and appending the keys not set yet. Other lines
+// are kept.
+func setKeyValues(old string, fields []keyValue) string {
+	set := map[string]string{}
+	for _, f := range fields {
+		set[f.key] = f.key + "=" + f.value
+	}
+
+	var out []string
+	for _, line := range strings.Split(strings.TrimSuffix(old, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		key := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+		if l, ok := set[key]; ok {
+			out = append(out, l)
+			delete(set, key)
+			continue
+		}
+		out = append(out, line)
+	}
+	for _, f := range fields {
+		if l, ok := set[f.key]; ok {
+			out = append(out, l)
+			delete(set, f.key)
+		}
+	}
+	return strings.Join(out, "\n") + "\n"
+}
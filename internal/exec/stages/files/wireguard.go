@@ -0,0 +1,42 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// createWireGuardKeys writes the private keys of the WireGuard interfaces
+// listed under networkd.wireguard, which their .netdev files refer to.
+func (s *stage) createWireGuardKeys(config types.Config) error {
+	if len(config.Networkd.WireGuard) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createWireGuardKeys")
+	defer s.Logger.PopPrefix()
+
+	u := s.Util
+	u.IsRoot = true
+
+	for _, wg := range config.Networkd.WireGuard {
+		f := util.WireGuardKeyFile(wg)
+		if err := fileEntry(f).create(s.Logger, u); err != nil {
+			return err
+		}
+		s.relabel(f.Path)
+	}
+	return nil
+}
@@ -15,6 +15,10 @@
 package stages
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/registry"
@@ -28,10 +32,15 @@ type Stage interface {
 }
 
 // StageCreator is responsible for instantiating a particular stage given a
-// logger and root path under the root partition.
+// logger and root path under the root partition. After returns the names of
+// the stages which have to run before this one if they are run at all. The
+// ordering is informational: each stage is run by its own invocation of
+// Ignition, which the init system orders, and Ignition doesn't check that
+// the earlier stages ran.
 type StageCreator interface {
 	Create(logger *log.Logger, root string, f resource.Fetcher) Stage
 	Name() string
+	After() []string
 }
 
 var stages = registry.Create("stages")
@@ -50,3 +59,62 @@ func Get(name string) StageCreator {
 func Names() (names []string) {
 	return stages.Names()
 }
+
+// Ordered returns the names of the registered stages in the order in which
+// they have to be run, for listing them. Stages which don't depend on each
+// other are sorted by name.
+func Ordered() ([]string, error) {
+	var creators []StageCreator
+	for _, name := range stages.Names() {
+		creators = append(creators, Get(name))
+	}
+	return order(creators)
+}
+
+func order(creators []StageCreator) ([]string, error) {
+	// number of unordered predecessors and successors of each stage
+	pending := map[string]int{}
+	successors := map[string][]string{}
+	for _, c := range creators {
+		pending[c.Name()] = 0
+	}
+	for _, c := range creators {
+		for _, dep := range c.After() {
+			if _, ok := pending[dep]; !ok {
+				return nil, fmt.Errorf("stage %q must run after unknown stage %q", c.Name(), dep)
+			}
+			pending[c.Name()]++
+			successors[dep] = append(successors[dep], c.Name())
+		}
+	}
+
+	var ready, ordered []string
+	for name, n := range pending {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, name)
+		for _, succ := range successors[name] {
+			if pending[succ]--; pending[succ] == 0 {
+				ready = append(ready, succ)
+			}
+		}
+	}
+
+	if len(ordered) < len(pending) {
+		var cycle []string
+		for name, n := range pending {
+			if n > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("stages %s depend on each other", strings.Join(cycle, ", "))
+	}
+	return ordered, nil
+}
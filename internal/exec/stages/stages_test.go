@@ -0,0 +1,96 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+type creator struct {
+	name  string
+	after []string
+}
+
+func (c creator) Create(*log.Logger, string, resource.Fetcher) Stage { return nil }
+func (c creator) Name() string                                       { return c.name }
+func (c creator) After() []string                                    { return c.after }
+
+func TestOrder(t *testing.T) {
+	type in struct {
+		creators []StageCreator
+	}
+	type out struct {
+		names []string
+		err   error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{creators: nil},
+			out: out{names: nil},
+		},
+		{
+			in: in{creators: []StageCreator{
+				creator{name: "enroll", after: []string{"files"}},
+				creator{name: "files", after: []string{"disks"}},
+				creator{name: "disks", after: []string{"fetch"}},
+				creator{name: "fetch"},
+			}},
+			out: out{names: []string{"fetch", "disks", "files", "enroll"}},
+		},
+		{
+			// independent stages are sorted by name
+			in: in{creators: []StageCreator{
+				creator{name: "files", after: []string{"disks"}},
+				creator{name: "firmware", after: []string{"disks"}},
+				creator{name: "disks"},
+				creator{name: "audit"},
+			}},
+			out: out{names: []string{"audit", "disks", "files", "firmware"}},
+		},
+		{
+			in: in{creators: []StageCreator{
+				creator{name: "firmware", after: []string{"mount"}},
+			}},
+			out: out{err: errors.New(`stage "firmware" must run after unknown stage "mount"`)},
+		},
+		{
+			in: in{creators: []StageCreator{
+				creator{name: "fetch"},
+				creator{name: "disks", after: []string{"fetch", "files"}},
+				creator{name: "files", after: []string{"disks"}},
+			}},
+			out: out{err: errors.New("stages disks, files depend on each other")},
+		},
+	}
+
+	for i, test := range tests {
+		names, err := order(test.in.creators)
+		if !reflect.DeepEqual(test.out.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+		if !reflect.DeepEqual(test.out.names, names) {
+			t.Errorf("#%d: bad order: want %v, got %v", i, test.out.names, names)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package util
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/gpt"
+	"github.com/flatcar/ignition/internal/probe"
+)
+
+// DumpPartitionTable returns a list of all partitions on device (e.g. /dev/vda). The list
+// of partitions returned is unordered. A device without a partition table has no partitions.
+func DumpPartitionTable(device string) ([]types.Partition, error) {
+	t, err := gpt.ReadDevice(device)
+	if err == gpt.ErrNoTable {
+		return []types.Partition{}, nil
+	} else if err != nil {
+		return []types.Partition{}, err
+	}
+
+	output := []types.Partition{}
+	for _, p := range t.Partitions() {
+		output = append(output, types.Partition{
+			Label:    util.StrToPtrStrict(p.Name),
+			GUID:     p.GUID,
+			TypeGUID: p.TypeGUID,
+			Number:   p.Number,
+			Start:    util.IntToPtr(int(p.Start)),
+			Size:     util.IntToPtr(int(p.Size())),
+		})
+	}
+	return output, nil
+}
+
+// GetBlockDevices returns a slice of block devices with the given filesystem
+func GetBlockDevices(fstype string) ([]string, error) {
+	entries, err := ioutil.ReadDir("/sys/class/block")
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, entry := range entries {
+		dev := filepath.Join("/dev", entry.Name())
+		// devices without media, such as empty CD-ROM drives, can't
+		// be opened and are skipped
+		res, err := probe.Device(dev)
+		if err != nil || res.Type != fstype {
+			continue
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// fileChecks are the checks files can be given, as the command line of the
+// program checking the file at path, which is run chrooted in the target
+// so that it checks the file against the target's version of the program.
+// Only these programs can be run, configs merely pick one by name.
+var fileChecks = map[string]func(path string) []string{
+	"sudoers": func(path string) []string {
+		return []string{distro.VisudoCmd(), "-c", "-q", "-f", path}
+	},
+	// -G parses the config like -t, but doesn't need the host keys, which
+	// may only be generated on first boot
+	"sshd": func(path string) []string {
+		return []string{distro.SshdCmd(), "-G", "-f", path}
+	},
+}
+
+// CheckFile runs the check of the given name on the file at path, which is
+// below u.DestDir, failing if the file doesn't pass. name is the path the
+// file is written to, for the logs.
+func (u Util) CheckFile(check, path, name string) error {
+	args, err := fileCheckArgs(check, u.DestDir, path)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(distro.ChrootCmd(), append([]string{u.DestDir}, args...)...)
+	if _, err := u.LogCmd(cmd, "checking %q with the %s check", name, check); err != nil {
+		return fmt.Errorf("%q failed the %s check: %v", name, check, err)
+	}
+	return nil
+}
+
+// fileCheckArgs returns the command line of the check of the given name
+// for the file at path, given relative to the root of the target at
+// destDir.
+func fileCheckArgs(check, destDir, path string) ([]string, error) {
+	args, ok := fileChecks[check]
+	if !ok {
+		return nil, fmt.Errorf("unknown check %q", check)
+	}
+	rel, err := filepath.Rel(destDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return nil, fmt.Errorf("%q is outside of %q", path, destDir)
+	}
+	return args(filepath.Join("/", rel)), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileCheckArgs(t *testing.T) {
+	tests := []struct {
+		check   string
+		destDir string
+		path    string
+		out     []string
+	}{
+		{
+			check:   "sudoers",
+			destDir: "/sysroot",
+			path:    "/sysroot/etc/sudoers.d/tmp123",
+			out:     []string{"/usr/sbin/visudo", "-c", "-q", "-f", "/etc/sudoers.d/tmp123"},
+		},
+		{
+			check:   "sshd",
+			destDir: "/",
+			path:    "/etc/ssh/sshd_config",
+			out:     []string{"/usr/sbin/sshd", "-G", "-f", "/etc/ssh/sshd_config"},
+		},
+		{
+			check:   "sshd",
+			destDir: "/sysroot",
+			path:    "/sysroot-other/etc/ssh/sshd_config",
+		},
+		{
+			check:   "nginx",
+			destDir: "/sysroot",
+			path:    "/sysroot/etc/nginx/nginx.conf",
+		},
+	}
+
+	for i, test := range tests {
+		out, err := fileCheckArgs(test.check, test.destDir, test.path)
+		if (err == nil) != (test.out != nil) {
+			t.Errorf("#%d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("#%d: want %q, got %q", i, test.out, out)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+// splitLines splits contents into its lines, without their newlines.
+func splitLines(contents string) []string {
+	if contents == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+}
+
+// joinLines joins lines, ending every line with a newline.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// EditLines applies the line edits to contents, in order. A present line
+// replaces the last line its match matches, or is appended if none does and
+// the line isn't there yet. An absent line removes every line its match
+// matches, or which equals it if it has no match. Applying the edits to
+// their own result doesn't change it.
+func EditLines(contents string, edits []types.EditLine) (string, error) {
+	lines := splitLines(contents)
+	for _, e := range edits {
+		matches := func(l string) bool { return l == e.Line }
+		if e.Match != nil {
+			re, err := regexp.Compile(*e.Match)
+			if err != nil {
+				return "", err
+			}
+			matches = re.MatchString
+		}
+
+		if e.Absent {
+			kept := lines[:0]
+			for _, l := range lines {
+				if !matches(l) {
+					kept = append(kept, l)
+				}
+			}
+			lines = kept
+			continue
+		}
+
+		last, exists := -1, false
+		for i, l := range lines {
+			if matches(l) {
+				last = i
+			}
+			exists = exists || l == e.Line
+		}
+		switch {
+		case last >= 0:
+			lines[last] = e.Line
+		case !exists:
+			lines = append(lines, e.Line)
+		}
+	}
+	return joinLines(lines), nil
+}
+
+// iniSection returns the name of the section the line starts, if it does.
+func iniSection(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// iniKey returns the key the line sets, if it does.
+func iniKey(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' || line[0] == ';' {
+		return "", false
+	}
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(line[:i]), true
+}
+
+// EditIni applies the ini edits to contents, in order. Keys outside of any
+// section have an empty section. A key with a value is set to it where it's
+// set already, and other settings of it in the section are removed; a new
+// key goes to the end of its section, a new section to the end of contents.
+// A key without a value is removed from the section. Comments and the
+// formatting of the other lines are kept, so that applying the edits to
+// their own result doesn't change it.
+func EditIni(contents string, edits []types.EditIni) string {
+	return editIni(contents, edits, "=")
+}
+
+// editIni is EditIni, separating the keys of new settings from their values
+// with sep.
+func editIni(contents string, edits []types.EditIni, sep string) string {
+	lines := splitLines(contents)
+	for _, e := range edits {
+		key := strings.TrimSpace(e.Key)
+		section := strings.TrimSpace(e.Section)
+
+		// find the lines of the section, [start, end)
+		start, end, found := 0, len(lines), section == ""
+		for i, l := range lines {
+			name, ok := iniSection(l)
+			if !ok {
+				continue
+			}
+			if found {
+				end = i
+				break
+			}
+			if name == section {
+				start, found = i+1, true
+			}
+		}
+
+		var rest []string
+		set := false
+		if found {
+			rest = append(rest, lines[end:]...)
+			kept := lines[:start]
+			for _, l := range lines[start:end] {
+				if k, ok := iniKey(l); ok && k == key {
+					if e.Value == nil || set {
+						continue
+					}
+					l = key + sep + *e.Value
+					set = true
+				}
+				kept = append(kept, l)
+			}
+			lines = kept
+		}
+
+		if e.Value != nil && !set {
+			line := key + sep + *e.Value
+			if !found {
+				if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+					lines = append(lines, "")
+				}
+				lines = append(lines, "["+section+"]")
+				start = len(lines)
+			}
+			// insert behind the last line of the section which isn't
+			// blank, so the blank lines separating sections stay
+			at := len(lines)
+			for at > start && strings.TrimSpace(lines[at-1]) == "" {
+				at--
+			}
+			lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+		}
+		lines = append(lines, rest...)
+	}
+	return joinLines(lines)
+}
+
+// AppendBlock returns contents with the block between the begin and end
+// marker lines, and whether that changes them. A block with the same markers
+// which is there already is replaced in place, so that appending the block
+// again changes nothing; otherwise the block is appended.
+func AppendBlock(contents, block, begin, end string) (string, bool) {
+	if block != "" && !strings.HasSuffix(block, "\n") {
+		block += "\n"
+	}
+	wrapped := begin + "\n" + block + end + "\n"
+
+	lines := strings.SplitAfter(contents, "\n")
+	start := -1
+	offset := 0
+	for _, l := range lines {
+		trimmed := strings.TrimRight(l, "\r\n")
+		switch {
+		case start < 0 && trimmed == begin:
+			start = offset
+		case start >= 0 && trimmed == end:
+			res := contents[:start] + wrapped + contents[offset+len(l):]
+			return res, res != contents
+		}
+		offset += len(l)
+	}
+
+	if contents != "" && !strings.HasSuffix(contents, "\n") {
+		contents += "\n"
+	}
+	return contents + wrapped, true
+}
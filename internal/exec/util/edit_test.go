@@ -0,0 +1,153 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func strP(s string) *string {
+	return &s
+}
+
+func TestEditLines(t *testing.T) {
+	tests := []struct {
+		in    string
+		edits []types.EditLine
+		out   string
+	}{
+		{
+			in:    "Port 22\n#PermitRootLogin yes\nPermitRootLogin prohibit-password\n",
+			edits: []types.EditLine{{Line: "PermitRootLogin no", Match: strP("^#?PermitRootLogin ")}},
+			out:   "Port 22\n#PermitRootLogin yes\nPermitRootLogin no\n",
+		},
+		{
+			in:    "Port 22",
+			edits: []types.EditLine{{Line: "UseDNS no"}, {Line: "Port 22"}},
+			out:   "Port 22\nUseDNS no\n",
+		},
+		{
+			in:    "a\nb\na\nc\n",
+			edits: []types.EditLine{{Line: "a", Absent: true}, {Absent: true, Match: strP("^c")}},
+			out:   "b\n",
+		},
+		{
+			in:    "",
+			edits: []types.EditLine{{Line: "only"}},
+			out:   "only\n",
+		},
+	}
+
+	for i, test := range tests {
+		out, err := EditLines(test.in, test.edits)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if out != test.out {
+			t.Errorf("#%d: bad result: want %q, got %q", i, test.out, out)
+		}
+		if again, _ := EditLines(out, test.edits); again != out {
+			t.Errorf("#%d: not idempotent: %q became %q", i, out, again)
+		}
+	}
+}
+
+func TestEditIni(t *testing.T) {
+	tests := []struct {
+		in    string
+		edits []types.EditIni
+		out   string
+	}{
+		{
+			in:    "[main]\ngpgcheck = 0\n# keep me\nbest=True\n\n[other]\ngpgcheck=0\n",
+			edits: []types.EditIni{{Section: "main", Key: "gpgcheck", Value: strP("1")}},
+			out:   "[main]\ngpgcheck=1\n# keep me\nbest=True\n\n[other]\ngpgcheck=0\n",
+		},
+		{
+			in:    "top=1\n[main]\nbest=True\n\n[other]\nx=y\n",
+			edits: []types.EditIni{{Section: "main", Key: "installonly_limit", Value: strP("2")}, {Key: "top"}},
+			out:   "[main]\nbest=True\ninstallonly_limit=2\n\n[other]\nx=y\n",
+		},
+		{
+			in:    "[main]\na=1\na=2\n",
+			edits: []types.EditIni{{Section: "main", Key: "a", Value: strP("3")}, {Section: "new", Key: "b", Value: strP("")}},
+			out:   "[main]\na=3\n\n[new]\nb=\n",
+		},
+		{
+			in:    "[main]\n;a=1\na=1\n",
+			edits: []types.EditIni{{Section: "main", Key: "a"}},
+			out:   "[main]\n;a=1\n",
+		},
+	}
+
+	for i, test := range tests {
+		out := EditIni(test.in, test.edits)
+		if out != test.out {
+			t.Errorf("#%d: bad result: want %q, got %q", i, test.out, out)
+		}
+		if again := EditIni(out, test.edits); again != out {
+			t.Errorf("#%d: not idempotent: %q became %q", i, out, again)
+		}
+	}
+}
+
+func TestAppendBlock(t *testing.T) {
+	tests := []struct {
+		in      string
+		block   string
+		out     string
+		changed bool
+	}{
+		{
+			in:      "",
+			block:   "a",
+			out:     "# BEGIN\na\n# END\n",
+			changed: true,
+		},
+		{
+			in:      "first",
+			block:   "a\n",
+			out:     "first\n# BEGIN\na\n# END\n",
+			changed: true,
+		},
+		{
+			in:    "first\n# BEGIN\na\n# END\nlast\n",
+			block: "a\n",
+			out:   "first\n# BEGIN\na\n# END\nlast\n",
+		},
+		{
+			in:      "first\n# BEGIN\nold\nlines\n# END\nlast\n",
+			block:   "a\n",
+			out:     "first\n# BEGIN\na\n# END\nlast\n",
+			changed: true,
+		},
+		{
+			// a block missing its end marker isn't one
+			in:      "# BEGIN\nold\n",
+			block:   "a\n",
+			out:     "# BEGIN\nold\n# BEGIN\na\n# END\n",
+			changed: true,
+		},
+	}
+
+	for i, test := range tests {
+		out, changed := AppendBlock(test.in, test.block, "# BEGIN", "# END")
+		if out != test.out || changed != test.changed {
+			t.Errorf("#%d: bad result: want %q (%v), got %q (%v)", i, test.out, test.changed, out, changed)
+		}
+	}
+}
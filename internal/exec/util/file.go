@@ -15,7 +15,9 @@
 package util
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -26,6 +28,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"syscall"
+	"unicode/utf8"
 
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/log"
@@ -39,14 +42,20 @@ const (
 )
 
 type FetchOp struct {
-	Hash         hash.Hash
-	Path         string
-	Url          url.URL
-	Mode         *int
-	FetchOptions resource.FetchOptions
-	Overwrite    *bool
-	Append       bool
-	Node         types.Node
+	Hash          hash.Hash
+	Path          string
+	Url           url.URL
+	Mode          *int
+	FetchOptions  resource.FetchOptions
+	Overwrite     *bool
+	Append        bool
+	AppendMarkers *types.AppendMarkers
+	Node          types.Node
+	Encoding      string
+	LineEndings   string
+	Merge         string
+	Xattrs        []Xattr
+	Check         string
 }
 
 // newHashedReader returns a new ReadCloser that also writes to the provided hash.
@@ -98,19 +107,32 @@ func (u Util) PrepareFetch(l *log.Logger, f types.File) *FetchOp {
 		}
 	}
 
+	xattrs, err := FileXattrs(f)
+	if err != nil {
+		l.Crit("Error encoding the xattrs of file %q: %v", f.Path, err)
+		return nil
+	}
+
 	return &FetchOp{
-		Path:      f.Path,
-		Hash:      hasher,
-		Node:      f.Node,
-		Url:       *uri,
-		Mode:      f.Mode,
-		Overwrite: f.Overwrite,
-		Append:    f.Append,
+		Path:          f.Path,
+		Hash:          hasher,
+		Node:          f.Node,
+		Url:           *uri,
+		Mode:          f.Mode,
+		Overwrite:     f.Overwrite,
+		Append:        f.Append,
+		AppendMarkers: f.AppendMarkers,
+		Encoding:      f.Contents.Encoding,
+		LineEndings:   f.Contents.LineEndings,
+		Merge:         f.Contents.Merge,
+		Xattrs:        xattrs,
+		Check:         f.Check,
 		FetchOptions: resource.FetchOptions{
 			Hash:        hasher,
 			Compression: f.Contents.Compression,
 			ExpectedSum: expectedSum,
 			Headers:     headers,
+			Sparse:      f.Contents.Sparse,
 		},
 	}
 }
@@ -120,6 +142,7 @@ func (u Util) WriteLink(s types.Link) error {
 	if err != nil {
 		return err
 	}
+	u.RecordManaged(s.Path)
 
 	if err := MkdirForFile(path); err != nil {
 		return err
@@ -156,6 +179,7 @@ func (u Util) PerformFetch(f *FetchOp) error {
 	if err != nil {
 		return err
 	}
+	u.RecordManaged(f.Path)
 
 	if f.Overwrite != nil && *f.Overwrite == false {
 		// Both directories and links will fail to be created if the target path
@@ -174,7 +198,7 @@ func (u Util) PerformFetch(f *FetchOp) error {
 			return fmt.Errorf("error creating %q: something else exists at that path", f.Path)
 		}
 	}
-	if f.Overwrite == nil && !f.Append {
+	if f.Overwrite == nil && !f.Append && f.Merge == "" {
 		// For files, overwrite defaults to true if append is false. If
 		// overwrite wasn't specified, delete the path.
 		err := os.RemoveAll(path)
@@ -198,11 +222,16 @@ func (u Util) PerformFetch(f *FetchOp) error {
 	// but that's ok (we wanted to keep the file in that case).
 	defer os.Remove(tmp.Name())
 
-	err = u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions)
-	if err != nil {
-		u.Crit("Error fetching file %q: %v", f.Path, err)
+	if tmp, err = u.fetchInto(f, tmp); err != nil {
 		return err
 	}
+	defer tmp.Close()
+
+	if f.Merge != "" {
+		if err := u.MergeInto(f, tmp); err != nil {
+			return fmt.Errorf("error merging into %q: %v", f.Path, err)
+		}
+	}
 
 	if f.Append {
 		// Make sure that we're appending to a file
@@ -229,24 +258,39 @@ func (u Util) PerformFetch(f *FetchOp) error {
 			mode = os.FileMode(*f.Mode)
 		}
 
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
-		if err != nil {
-			return err
+		if f.AppendMarkers != nil {
+			if err := u.appendBlock(f, path, tmp); err != nil {
+				return err
+			}
+		} else {
+			targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
+			if err != nil {
+				return err
+			}
+			defer targetFile.Close()
+
+			if _, err = tmp.Seek(0, os.SEEK_SET); err != nil {
+				return err
+			}
+			if _, err = io.Copy(targetFile, tmp); err != nil {
+				return err
+			}
 		}
-		defer targetFile.Close()
 
-		if _, err = tmp.Seek(0, os.SEEK_SET); err != nil {
+		if err = os.Chown(path, uid, gid); err != nil {
 			return err
 		}
-		if _, err = io.Copy(targetFile, tmp); err != nil {
+		if err = os.Chmod(path, mode); err != nil {
 			return err
 		}
-
-		if err = os.Chown(targetFile.Name(), uid, gid); err != nil {
+		if err = SetXattrs(path, f.Xattrs); err != nil {
 			return err
 		}
-		if err = os.Chmod(targetFile.Name(), mode); err != nil {
-			return err
+		// the appended file is only complete in place
+		if f.Check != "" {
+			if err := u.CheckFile(f.Check, path, f.Path); err != nil {
+				return err
+			}
 		}
 	} else {
 		// XXX(vc): Note that we assume to be operating on the file we just wrote, this is only guaranteed
@@ -255,11 +299,20 @@ func (u Util) PerformFetch(f *FetchOp) error {
 		// Ensure the ownership and mode are as requested (since WriteFile can be affected by sticky bit)
 
 		mode := os.FileMode(0)
+		defaultUid, defaultGid := 0, 0
+		if f.Merge != "" {
+			// Default to the merged file's owner and mode
+			existingPath, err := u.FollowPath(f.Path)
+			if err != nil {
+				return err
+			}
+			defaultUid, defaultGid, mode = getFileOwnerAndMode(existingPath)
+		}
 		if f.Mode != nil {
 			mode = os.FileMode(*f.Mode)
 		}
 
-		uid, gid, err := u.ResolveNodeUidAndGid(f.Node, 0, 0)
+		uid, gid, err := u.ResolveNodeUidAndGid(f.Node, defaultUid, defaultGid)
 		if err != nil {
 			return err
 		}
@@ -272,6 +325,17 @@ func (u Util) PerformFetch(f *FetchOp) error {
 			return err
 		}
 
+		if err = SetXattrs(tmp.Name(), f.Xattrs); err != nil {
+			return err
+		}
+
+		// files failing their check never replace the file at path
+		if f.Check != "" {
+			if err := u.CheckFile(f.Check, tmp.Name(), f.Path); err != nil {
+				return err
+			}
+		}
+
 		if err = os.Rename(tmp.Name(), path); err != nil {
 			return err
 		}
@@ -280,6 +344,106 @@ func (u Util) PerformFetch(f *FetchOp) error {
 	return nil
 }
 
+// appendBlock appends the fetched contents in tmp to the file at path as a
+// block between the file's append markers, unless the block is there
+// already. A block between the same markers with other contents is replaced.
+// The file is replaced rather than written in place if it changes.
+func (u Util) appendBlock(f *FetchOp, path string, tmp *os.File) error {
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	block, err := ioutil.ReadAll(tmp)
+	if err != nil {
+		return err
+	}
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	res, changed := AppendBlock(string(existing), string(block), f.AppendMarkers.Begin, f.AppendMarkers.End)
+	if !changed {
+		u.Info("%q already contains the block between %q and %q", f.Path, f.AppendMarkers.Begin, f.AppendMarkers.End)
+		return nil
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(path), "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	if _, err := out.WriteString(res); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), path)
+}
+
+// MergeInto replaces the fetched contents of the file described by f in
+// tmp with the existing file, if there is one, merged with them. If the file
+// is a symlink, e.g. to a default of the OS image, the file it points to is
+// merged with.
+func (u Util) MergeInto(f *FetchOp, tmp *os.File) error {
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	provided, err := ioutil.ReadAll(tmp)
+	if err != nil {
+		return err
+	}
+	existingPath, err := u.FollowPath(f.Path)
+	if err != nil {
+		return err
+	}
+	existing, err := ioutil.ReadFile(existingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	merged, err := MergeContents(f.Merge, existing, provided)
+	if err != nil {
+		return err
+	}
+	if err := tmp.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := tmp.WriteAt(merged, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FetchContents fetches the contents of the file described by f into dest,
+// normalizing text as requested. It leaves the file's metadata alone.
+func (u Util) FetchContents(f *FetchOp, dest *os.File) error {
+	if err := u.Fetcher.Fetch(f.Url, dest, f.FetchOptions); err != nil {
+		u.Crit("Error fetching file %q: %v", f.Path, err)
+		return err
+	}
+	return normalize(f, dest)
+}
+
+// fetchInto fetches the contents of the file described by f into tmp,
+// taking the prefetched contents if there are any. It returns the file
+// holding the contents, which replaces tmp if the contents were moved.
+func (u Util) fetchInto(f *FetchOp, tmp *os.File) (*os.File, error) {
+	tmp, prefetched, err := u.takePrefetched(f, tmp)
+	if err != nil {
+		return tmp, err
+	}
+	if !prefetched {
+		return tmp, u.FetchContents(f, tmp)
+	}
+	return tmp, normalize(f, tmp)
+}
+
+func normalize(f *FetchOp, dest *os.File) error {
+	if f.Encoding != "" || f.LineEndings != "" {
+		if err := normalizeText(dest, f.Encoding, f.LineEndings); err != nil {
+			return fmt.Errorf("error normalizing file %q: %v", f.Path, err)
+		}
+	}
+	return nil
+}
+
 // MkdirForFile helper creates the directory components of path.
 func MkdirForFile(path string) error {
 	return os.MkdirAll(filepath.Dir(path), DefaultDirectoryPermissions)
@@ -380,3 +544,74 @@ func (u Util) DeletePathOnOverwrite(n types.Node) error {
 	}
 	return os.RemoveAll(path)
 }
+
+// normalizeText checks that the contents of f are in the given encoding and
+// converts their line endings, rewriting f in place. Since the conversion never
+// grows the contents, f is processed in chunks without holding it in memory.
+// The cursor is left at the end of f.
+func normalizeText(f *os.File, encoding, lineEndings string) error {
+	buf := make([]byte, normalizeChunkSize+utf8.UTFMax)
+	var carry int
+	var rOff, wOff int64
+	for {
+		n, err := f.ReadAt(buf[carry:normalizeChunkSize+carry], rOff)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		rOff += int64(n)
+		eof := err == io.EOF
+		data := buf[:carry+n]
+
+		// hold back bytes whose meaning depends on the next chunk
+		held := 0
+		if !eof {
+			held = incompleteSuffix(data, encoding, lineEndings)
+			data = data[:len(data)-held]
+		}
+
+		if encoding == "utf-8" && !utf8.Valid(data) {
+			return errors.New("contents are not valid UTF-8")
+		}
+		if lineEndings == "lf" {
+			data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+		}
+		if _, err := f.WriteAt(data, wOff); err != nil {
+			return err
+		}
+		wOff += int64(len(data))
+
+		if eof {
+			break
+		}
+		carry = copy(buf, buf[n+carry-held:n+carry])
+	}
+
+	if err := f.Truncate(wOff); err != nil {
+		return err
+	}
+	_, err := f.Seek(wOff, io.SeekStart)
+	return err
+}
+
+// normalizeChunkSize is the amount of data normalizeText reads at a time.
+var normalizeChunkSize = 32 * 1024
+
+// incompleteSuffix returns the number of bytes at the end of data that cannot
+// be normalized without knowing the following bytes: a partial UTF-8 sequence
+// or a trailing carriage return.
+func incompleteSuffix(data []byte, encoding, lineEndings string) int {
+	if lineEndings == "lf" && len(data) > 0 && data[len(data)-1] == '\r' {
+		return 1
+	}
+	if encoding == "utf-8" {
+		for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+			if utf8.RuneStart(data[len(data)-i]) {
+				if !utf8.FullRune(data[len(data)-i:]) {
+					return i
+				}
+				break
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	type in struct {
+		data        string
+		encoding    string
+		lineEndings string
+	}
+	type out struct {
+		data string
+		err  bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{data: "[Unit]\r\nDescription=x\r\n", lineEndings: "lf"},
+			out: out{data: "[Unit]\nDescription=x\n"},
+		},
+		{
+			in:  in{data: "[Unit]\r\nDescription=x\r\n", encoding: "utf-8"},
+			out: out{data: "[Unit]\r\nDescription=x\r\n"},
+		},
+		{
+			in:  in{data: "gr\xfc\xdfe\r\n", encoding: "utf-8", lineEndings: "lf"},
+			out: out{err: true},
+		},
+		{
+			in:  in{data: "grüße\r\n", encoding: "utf-8", lineEndings: "lf"},
+			out: out{data: "grüße\n"},
+		},
+		{
+			in:  in{data: "a\r\r\nb\r", lineEndings: "lf"},
+			out: out{data: "a\r\nb\r"},
+		},
+		{
+			in:  in{data: "gr\xc3", encoding: "utf-8"},
+			out: out{err: true},
+		},
+	}
+
+	// exercise chunk boundaries falling within line endings and runes
+	defer func(size int) { normalizeChunkSize = size }(normalizeChunkSize)
+	for _, size := range []int{1, 2, 3, 5, 32 * 1024} {
+		normalizeChunkSize = size
+		for i, test := range tests {
+			f, err := ioutil.TempFile("", "ignition-normalize")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			if _, err := f.WriteString(test.in.data); err != nil {
+				t.Fatal(err)
+			}
+
+			err = normalizeText(f, test.in.encoding, test.in.lineEndings)
+			if (err != nil) != test.out.err {
+				t.Errorf("#%d (chunk size %d): bad error: want %v, got %v", i, size, test.out.err, err)
+				continue
+			}
+			if test.out.err {
+				continue
+			}
+			data, err := ioutil.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.out.data {
+				t.Errorf("#%d (chunk size %d): bad data: want %q, got %q", i, size, test.out.data, data)
+			}
+		}
+	}
+}
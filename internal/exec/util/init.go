@@ -0,0 +1,77 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// InitSystem installs the units of the systemd section on the target. Init
+// systems other than systemd translate the units as far as they can, and
+// fail for the units they can't express.
+type InitSystem interface {
+	// WriteUnit writes the unit and its dropins, and returns the paths
+	// which need to be relabeled.
+	WriteUnit(u Util, unit types.Unit, runtime bool) ([]string, error)
+	EnableUnit(u Util, unit types.Unit) error
+	EnableRuntimeUnit(u Util, unit types.Unit, target string) error
+	DisableUnit(u Util, unit types.Unit) error
+	MaskUnit(u Util, unit types.Unit) error
+}
+
+var initSystems = map[string]InitSystem{
+	"systemd": systemd{},
+	"openrc":  openRC{},
+}
+
+// CheckInitSystem returns an error if the distribution's init system isn't
+// supported.
+func CheckInitSystem() error {
+	if _, ok := initSystems[distro.InitSystem()]; !ok {
+		return fmt.Errorf("unsupported init system %q", distro.InitSystem())
+	}
+	return nil
+}
+
+// InitSystem returns the target's init system.
+func (u Util) InitSystem() InitSystem {
+	if s, ok := initSystems[distro.InitSystem()]; ok {
+		return s
+	}
+	return systemd{}
+}
+
+func (u Util) WriteUnit(unit types.Unit, runtime bool) ([]string, error) {
+	return u.InitSystem().WriteUnit(u, unit, runtime)
+}
+
+func (u Util) EnableUnit(unit types.Unit) error {
+	return u.InitSystem().EnableUnit(u, unit)
+}
+
+func (u Util) EnableRuntimeUnit(unit types.Unit, target string) error {
+	return u.InitSystem().EnableRuntimeUnit(u, unit, target)
+}
+
+func (u Util) DisableUnit(unit types.Unit) error {
+	return u.InitSystem().DisableUnit(u, unit)
+}
+
+func (u Util) MaskUnit(unit types.Unit) error {
+	return u.InitSystem().MaskUnit(u, unit)
+}
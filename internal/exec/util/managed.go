@@ -0,0 +1,225 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// managedPathsVersion is the version of the format of the managed paths
+// record.
+const managedPathsVersion = 1
+
+// ManagedPaths collects the paths Ignition created or modified on the root
+// filesystem, so that config management and update tooling can tell the
+// files owned by provisioning from local edits.
+type ManagedPaths struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// ManagedPath describes a path of the record as Ignition left it.
+type ManagedPath struct {
+	Path string `json:"path"`
+	// Type is "file", "directory", "link" or "other".
+	Type string `json:"type"`
+	// Mode is the octal mode of files and directories.
+	Mode string `json:"mode,omitempty"`
+	UID  int    `json:"uid"`
+	GID  int    `json:"gid"`
+	// SHA256 is the digest of the contents of files.
+	SHA256 string `json:"sha256,omitempty"`
+	// Target is the target of links.
+	Target string `json:"target,omitempty"`
+}
+
+type managedPathsRecord struct {
+	Version int           `json:"version"`
+	Paths   []ManagedPath `json:"paths"`
+}
+
+// RecordManaged adds paths relative to u.DestDir to the managed paths, if
+// they are collected. Paths in /run don't persist and aren't recorded.
+func (u Util) RecordManaged(paths ...string) {
+	if u.Managed == nil {
+		return
+	}
+	u.Managed.mu.Lock()
+	defer u.Managed.mu.Unlock()
+	if u.Managed.paths == nil {
+		u.Managed.paths = map[string]bool{}
+	}
+	for _, path := range paths {
+		path = filepath.Join("/", path)
+		if path == "/run" || strings.HasPrefix(path, "/run/") {
+			continue
+		}
+		u.Managed.paths[path] = true
+	}
+}
+
+// WriteManagedPaths writes the record of the managed paths to path,
+// merged with the record left by earlier runs. The paths are described as
+// they are now; paths which don't exist anymore are dropped.
+func (u Util) WriteManagedPaths(path string) error {
+	full, err := u.JoinPath(path)
+	if err != nil {
+		return err
+	}
+	paths, err := u.ReadManagedPaths(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	entries := map[string]ManagedPath{}
+	for _, entry := range paths {
+		entries[entry.Path] = entry
+	}
+	u.Managed.mu.Lock()
+	defer u.Managed.mu.Unlock()
+	for p := range u.Managed.paths {
+		entry, err := u.DescribeManaged(p)
+		if os.IsNotExist(err) {
+			delete(entries, p)
+			continue
+		} else if err != nil {
+			return err
+		}
+		entries[p] = entry
+	}
+
+	record := managedPathsRecord{Version: managedPathsVersion, Paths: []ManagedPath{}}
+	for _, entry := range entries {
+		record.Paths = append(record.Paths, entry)
+	}
+	sort.Slice(record.Paths, func(i, j int) bool { return record.Paths[i].Path < record.Paths[j].Path })
+	b, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := MkdirForFile(full); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(full), "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(DefaultFilePermissions); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), full)
+}
+
+// ReadManagedPaths reads the record of the managed paths from path. Errors
+// reading the file are returned unwrapped, so that a missing record can be
+// told apart.
+func (u Util) ReadManagedPaths(path string) ([]ManagedPath, error) {
+	full, err := u.JoinPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(full)
+	if err != nil {
+		return nil, err
+	}
+	record := managedPathsRecord{}
+	if err := json.Unmarshal(b, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %v", path, err)
+	}
+	if record.Version != managedPathsVersion {
+		return nil, fmt.Errorf("unsupported version %d of %q", record.Version, path)
+	}
+	return record.Paths, nil
+}
+
+// DescribeManaged describes path, relative to u.DestDir, as it is now.
+func (u Util) DescribeManaged(path string) (ManagedPath, error) {
+	entry := ManagedPath{Path: path}
+	full, err := u.JoinPath(path)
+	if err != nil {
+		return entry, err
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return entry, err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		entry.UID = int(st.Uid)
+		entry.GID = int(st.Gid)
+	}
+
+	mode := fmt.Sprintf("%04o", unixMode(info.Mode()))
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Type = "link"
+		entry.Target, err = os.Readlink(full)
+	case info.IsDir():
+		entry.Type = "directory"
+		entry.Mode = mode
+	case info.Mode().IsRegular():
+		entry.Type = "file"
+		entry.Mode = mode
+		entry.SHA256, err = fileSHA256(full)
+	default:
+		entry.Type = "other"
+	}
+	return entry, err
+}
+
+// unixMode returns the permission bits of m along with the setuid, setgid
+// and sticky bits.
+func unixMode(m os.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		mode |= syscall.S_ISUID
+	}
+	if m&os.ModeSetgid != 0 {
+		mode |= syscall.S_ISGID
+	}
+	if m&os.ModeSticky != 0 {
+		mode |= syscall.S_ISVTX
+	}
+	return mode
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteManagedPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-managed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	uid, gid := os.Getuid(), os.Getgid()
+
+	write := func(path, contents string, mode os.FileMode) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func() []ManagedPath {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "var/lib/ignition/managed-paths.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record managedPathsRecord
+		if err := json.Unmarshal(b, &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Version != managedPathsVersion {
+			t.Errorf("bad version: want %d, got %d", managedPathsVersion, record.Version)
+		}
+		return record.Paths
+	}
+
+	write("etc/hostname", "hello\n", 0644)
+	write("etc/old", "old", 0600)
+	if err := os.Symlink("/etc/hostname", filepath.Join(dir, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "var/lib/app"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	u := Util{DestDir: dir, Managed: &ManagedPaths{}}
+	u.RecordManaged("etc/hostname", "/etc/old", "/etc/link", "/var/lib/app", "/etc/missing", "/run/ignition.json")
+	if err := u.WriteManagedPaths("/var/lib/ignition/managed-paths.json"); err != nil {
+		t.Fatalf("writing managed paths: %v", err)
+	}
+	want := []ManagedPath{
+		{Path: "/etc/hostname", Type: "file", Mode: "0644", UID: uid, GID: gid, SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{Path: "/etc/link", Type: "link", UID: uid, GID: gid, Target: "/etc/hostname"},
+		{Path: "/etc/old", Type: "file", Mode: "0600", UID: uid, GID: gid, SHA256: "cba06b5736faf67e54b07b561eae94395e774c517a7d910a54369e1263ccfbd4"},
+		{Path: "/var/lib/app", Type: "directory", Mode: "0750", UID: uid, GID: gid},
+	}
+	if got := read(); !reflect.DeepEqual(got, want) {
+		t.Errorf("bad record:\nwant %+v\ngot  %+v", want, got)
+	}
+
+	// a later run updates the paths it wrote and keeps the others
+	if err := os.Remove(filepath.Join(dir, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	write("etc/hostname", "world\n", 0640)
+	u = Util{DestDir: dir, Managed: &ManagedPaths{}}
+	u.RecordManaged("/etc/hostname", "/etc/link")
+	if err := u.WriteManagedPaths("/var/lib/ignition/managed-paths.json"); err != nil {
+		t.Fatalf("writing managed paths: %v", err)
+	}
+	sum, _ := fileSHA256(filepath.Join(dir, "etc/hostname"))
+	want = []ManagedPath{
+		{Path: "/etc/hostname", Type: "file", Mode: "0640", UID: uid, GID: gid, SHA256: sum},
+		want[2],
+		want[3],
+	}
+	if got := read(); !reflect.DeepEqual(got, want) {
+		t.Errorf("bad merged record:\nwant %+v\ngot  %+v", want, got)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+var (
+	ErrMergeNotObject = errors.New("merged JSON must be an object")
+)
+
+// MergeContents merges the provided contents into the existing contents of a
+// file in the given format, "json", "ini", "toml" or "keywords". Existing
+// contents may be empty, e.g. if the file doesn't exist yet. The keywords
+// format is only used for the files rendered from the config, like
+// login.defs, whose settings don't fit ini.
+func MergeContents(format string, existing, provided []byte) ([]byte, error) {
+	switch format {
+	case "json":
+		return mergeJSON(existing, provided)
+	case "ini":
+		edits, err := parseKeys(string(provided), false)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(editIni(string(existing), edits, "=")), nil
+	case "toml":
+		edits, err := parseKeys(string(provided), true)
+		if err != nil {
+			return nil, err
+		}
+		// the existing settings are replaced line by line as well, so the
+		// existing file is held to the same rules
+		if _, err := parseKeys(string(existing), true); err != nil {
+			return nil, fmt.Errorf("the existing file can't be merged into: %v", err)
+		}
+		return []byte(editIni(string(existing), edits, " = ")), nil
+	case "keywords":
+		return []byte(mergeKeywords(string(existing), string(provided))), nil
+	default:
+		return nil, fmt.Errorf("unsupported merge format %q", format)
+	}
+}
+
+// mergeJSON applies provided to existing as a JSON merge patch (RFC 7396):
+// objects are merged recursively, null removes a key and other values
+// replace what's there. Both must be objects.
+func mergeJSON(existing, provided []byte) ([]byte, error) {
+	decode := func(b []byte) (map[string]interface{}, error) {
+		if len(bytes.TrimSpace(b)) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		d := json.NewDecoder(bytes.NewReader(b))
+		// keep numbers as they're written
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, ErrMergeNotObject
+		}
+		return obj, nil
+	}
+	target, err := decode(existing)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the existing file: %v", err)
+	}
+	patch, err := decode(provided)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the merged contents: %v", err)
+	}
+	res, err := json.MarshalIndent(mergePatch(target, patch), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(res, '\n'), nil
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// keyword returns the keyword the line sets, which is its first word, up
+// to a blank or '='. Blank lines and comments set none.
+func keyword(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return ""
+	}
+	if i := strings.IndexAny(line, " \t="); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// mergeKeywords sets the keywords of the provided lines in existing, where
+// every line is a keyword optionally followed by its value, e.g. "deny = 5"
+// or "PASS_MAX_DAYS 90". A provided line replaces the first line setting its
+// keyword, and other lines setting it are removed; lines setting new
+// keywords are appended. Comments and other lines are kept.
+func mergeKeywords(existing, provided string) string {
+	lines := splitLines(existing)
+	for _, p := range splitLines(provided) {
+		key := keyword(p)
+		if key == "" {
+			continue
+		}
+		set := false
+		kept := lines[:0]
+		for _, l := range lines {
+			if keyword(l) == key {
+				if set {
+					continue
+				}
+				l = p
+				set = true
+			}
+			kept = append(kept, l)
+		}
+		lines = kept
+		if !set {
+			lines = append(lines, p)
+		}
+	}
+	return joinLines(lines)
+}
+
+// parseKeys returns the settings of ini or TOML contents as ini edits
+// setting them. Only the single line values of TOML are supported: array
+// tables and values continued on the next lines are rejected, as they can't
+// be merged line by line, neither from the provided contents nor into the
+// existing ones.
+func parseKeys(contents string, toml bool) ([]types.EditIni, error) {
+	var edits []types.EditIni
+	section := ""
+	for i, l := range splitLines(contents) {
+		trimmed := strings.TrimSpace(l)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+			continue
+		}
+		if name, ok := iniSection(l); ok {
+			if toml && strings.HasPrefix(trimmed, "[[") {
+				return nil, fmt.Errorf("line %d: array tables can't be merged", i+1)
+			}
+			section = name
+			continue
+		}
+		key, ok := iniKey(l)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: neither a section nor a setting", i+1)
+		}
+		value := strings.TrimSpace(trimmed[strings.Index(trimmed, "=")+1:])
+		if toml && !tomlValueComplete(value) {
+			return nil, fmt.Errorf("line %d: values spanning lines can't be merged", i+1)
+		}
+		edits = append(edits, types.EditIni{Section: section, Key: key, Value: &value})
+	}
+	return edits, nil
+}
+
+// tomlValueComplete returns whether the TOML value ends on its line: its
+// strings are closed, including multi-line ones anywhere in it, and its
+// brackets and braces balanced. A '#' outside of strings starts a comment,
+// also inside brackets, where the value then continues on the next line.
+func tomlValueComplete(value string) bool {
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case strings.HasPrefix(value[i:], `"""`) || strings.HasPrefix(value[i:], `'''`):
+			end := tomlStringEnd(value, i+3, value[i:i+3])
+			if end < 0 {
+				return false
+			}
+			// up to two quotes before the closing ones are part of the
+			// string
+			for n := 0; n < 2 && end+3 < len(value) && value[end+3] == c; n++ {
+				end++
+			}
+			i = end + 2
+		case c == '"' || c == '\'':
+			end := tomlStringEnd(value, i+1, string(c))
+			if end < 0 {
+				return false
+			}
+			i = end
+		case c == '#':
+			return depth == 0
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// tomlStringEnd returns the index of the quote closing the string whose
+// contents start at start in value, or -1 if it isn't closed. Backslashes
+// escape quotes in basic strings, which are quoted by '"'.
+func tomlStringEnd(value string, start int, quote string) int {
+	for j := start; j < len(value); j++ {
+		if value[j] == '\\' && quote[0] == '"' {
+			j++
+			continue
+		}
+		if strings.HasPrefix(value[j:], quote) {
+			return j
+		}
+	}
+	return -1
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestMergeContents(t *testing.T) {
+	tests := []struct {
+		format   string
+		existing string
+		provided string
+		out      string
+		err      bool
+	}{
+		{
+			format:   "json",
+			existing: `{"log-driver": "json-file", "log-opts": {"max-size": "10m", "max-file": 3}, "debug": true}`,
+			provided: `{"log-opts": {"max-size": "50m"}, "debug": null, "mtu": 1400}`,
+			out:      "{\n  \"log-driver\": \"json-file\",\n  \"log-opts\": {\n    \"max-file\": 3,\n    \"max-size\": \"50m\"\n  },\n  \"mtu\": 1400\n}\n",
+		},
+		{
+			format:   "json",
+			provided: `{"a": {"b": 1}}`,
+			out:      "{\n  \"a\": {\n    \"b\": 1\n  }\n}\n",
+		},
+		{
+			format:   "json",
+			existing: `[1, 2]`,
+			provided: `{"a": 1}`,
+			err:      true,
+		},
+		{
+			format:   "ini",
+			existing: "[main]\ngpgcheck=0\n\n[updates]\nenabled=1\n",
+			provided: "; ours\n[main]\ngpgcheck=1\ninstallonly_limit = 2\n[new]\nx=y\n",
+			out:      "[main]\ngpgcheck=1\ninstallonly_limit=2\n\n[updates]\nenabled=1\n\n[new]\nx=y\n",
+		},
+		{
+			format: "toml",
+			existing: "version = 2\n\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\"]\n  sandbox_image = \"pause:3.6\"\n\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\".containerd]\n  snapshotter = \"overlayfs\"\n",
+			provided: "root = \"/var/lib/containerd\"\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\".containerd]\nsnapshotter = \"native\" # for tests\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\".registry]\nconfig_path = \"/etc/containerd/certs.d\"\n",
+			out: "version = 2\nroot = \"/var/lib/containerd\"\n\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\"]\n  sandbox_image = \"pause:3.6\"\n\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\".containerd]\nsnapshotter = \"native\" # for tests\n\n" +
+				"[plugins.\"io.containerd.grpc.v1.cri\".registry]\nconfig_path = \"/etc/containerd/certs.d\"\n",
+		},
+		{
+			format:   "toml",
+			provided: "a = [\n  1,\n]\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			provided: "[[plugins]]\na = 1\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			provided: "a = \"\"\"\nb\n\"\"\"\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			existing: "[hosts]\nhosts = [\n  \"a\",\n]\n",
+			provided: "[hosts]\nhosts = [\"c\"]\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			existing: "motd = '''\n[not a table]\n'''\n",
+			provided: "motd = \"hi\"\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			existing: "[[plugins]]\na = 1\n",
+			provided: "b = 1\n",
+			err:      true,
+		},
+		{
+			format:   "toml",
+			existing: "a = 1\n",
+			provided: "b = ['''it's # no comment''', \"\"\"a \"quoted\" #\"\"\"\"] # ]\nc = { d = '#' }\n",
+			out:      "a = 1\nb = ['''it's # no comment''', \"\"\"a \"quoted\" #\"\"\"\"] # ]\nc = { d = '#' }\n",
+		},
+		{
+			format:   "toml",
+			provided: "a = [\"x\", # [\"y\"]\n  \"z\"]\n",
+			err:      true,
+		},
+		{
+			format:   "keywords",
+			existing: "# Password aging controls:\nPASS_MAX_DAYS\t99999\nPASS_MIN_DAYS\t0\n#PASS_WARN_AGE\t7\nUMASK\t\t022\nPASS_MIN_DAYS 2\n",
+			provided: "PASS_MAX_DAYS 365\nPASS_MIN_DAYS 1\nPASS_WARN_AGE 14\n",
+			out:      "# Password aging controls:\nPASS_MAX_DAYS 365\nPASS_MIN_DAYS 1\n#PASS_WARN_AGE\t7\nUMASK\t\t022\nPASS_WARN_AGE 14\n",
+		},
+		{
+			format:   "keywords",
+			existing: "# deny = 3\ndeny=4\naudit\n",
+			provided: "deny = 5\neven_deny_root\n",
+			out:      "# deny = 3\ndeny = 5\naudit\neven_deny_root\n",
+		},
+	}
+
+	for i, test := range tests {
+		out, err := MergeContents(test.format, []byte(test.existing), []byte(test.provided))
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %q", i, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if string(out) != test.out {
+			t.Errorf("#%d: bad result:\nwant %q\ngot  %q", i, test.out, out)
+		}
+		again, err := MergeContents(test.format, out, []byte(test.provided))
+		if err != nil || string(again) != string(out) {
+			t.Errorf("#%d: merging again changed %q to %q (%v)", i, out, again, err)
+		}
+	}
+}
+
+func TestPerformFetchMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a default of the OS image, which the config links to
+	if err := os.MkdirAll(filepath.Join(dir, "usr/share/app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "usr/share/app/config.json"), []byte(`{"a": 1, "b": {"c": 2}}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "etc/app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/usr/share/app/config.json", filepath.Join(dir, "etc/app/config.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	u := Util{DestDir: dir, IsRoot: true, Logger: &logger, Fetcher: resource.Fetcher{Logger: &logger}}
+	op := u.PrepareFetch(&logger, types.File{
+		Node: types.Node{Path: "/etc/app/config.json"},
+		FileEmbedded1: types.FileEmbedded1{
+			Contents: types.FileContents{
+				Source: "data:," + url.PathEscape(`{"b": {"d": 3}}`),
+				Merge:  "json",
+			},
+		},
+	})
+	if op == nil {
+		t.Fatal("failed to prepare the fetch")
+	}
+	if err := u.PerformFetch(op); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "etc/app/config.json")
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm() != 0640 {
+		t.Errorf("bad merged file: %v", info.Mode())
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"a\": 1,\n  \"b\": {\n    \"c\": 2,\n    \"d\": 3\n  }\n}\n"
+	if string(contents) != expected {
+		t.Errorf("bad merged contents: want %q, got %q", expected, contents)
+	}
+	contents, err = ioutil.ReadFile(filepath.Join(dir, "usr/share/app/config.json"))
+	if err != nil || string(contents) != `{"a": 1, "b": {"c": 2}}` {
+		t.Errorf("the default was changed: %q (%v)", contents, err)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// unescapeMountinfo undoes the escaping of paths in /proc/self/mountinfo.
+var unescapeMountinfo = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+// HardenedMountFlags are the flags of the filesystems Ignition mounts to
+// write files to. Nothing on them is run or opened as a device while
+// Ignition has them mounted.
+const HardenedMountFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC
+
+// MountAuto mounts the filesystem on dev at mnt with HardenedMountFlags,
+// trying all formats files can be written to.
+func (u Util) MountAuto(dev, mnt string) error {
+	return u.MountAutoFlags(dev, mnt, HardenedMountFlags)
+}
+
+// MountAutoFlags mounts the filesystem on dev at mnt with the given flags,
+// trying all formats files can be written to. The flags the filesystem
+// ended up mounted with are logged.
+func (u Util) MountAutoFlags(dev, mnt string, flags uintptr) error {
+	var err error
+	// try to mount all possible formats from config/v2_*/types/filesystem.go (without "swap")
+	formats := []string{"ext4", "btrfs", "xfs", "vfat"}
+	for _, tryFormat := range formats {
+		if err = u.LogOp(
+			func() error { return syscall.Mount(dev, mnt, tryFormat, flags, "") },
+			"mounting %q at %q (trying %q, %s)", dev, mnt, tryFormat, MountFlagsString(flags),
+		); err == nil {
+			var st syscall.Statfs_t
+			if err := syscall.Statfs(mnt, &st); err != nil {
+				u.Warning("couldn't check the flags %q is mounted with: %v", mnt, err)
+				return nil
+			}
+			// the ST_* flags of statfs match the MS_* flags of mount
+			u.Info("mounted %q at %q with %s", dev, mnt, MountFlagsString(uintptr(st.Flags)))
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to mount device %q at %q (tried %v): %v", dev, mnt, formats, err)
+}
+
+// MountFlagsString describes the security relevant flags of a mount like the
+// options of mount(8), e.g. "rw,nosuid,nodev,noexec".
+func MountFlagsString(flags uintptr) string {
+	options := []string{"rw"}
+	if flags&syscall.MS_RDONLY != 0 {
+		options[0] = "ro"
+	}
+	for _, o := range []struct {
+		flag uintptr
+		name string
+	}{
+		{syscall.MS_NOSUID, "nosuid"},
+		{syscall.MS_NODEV, "nodev"},
+		{syscall.MS_NOEXEC, "noexec"},
+	} {
+		if flags&o.flag != 0 {
+			options = append(options, o.name)
+		}
+	}
+	return strings.Join(options, ",")
+}
+
+// IsMountPoint reports whether a filesystem is mounted at path.
+func IsMountPoint(path string) (bool, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isMountPoint(f, filepath.Clean(path))
+}
+
+func isMountPoint(mountinfo io.Reader, path string) (bool, error) {
+	scanner := bufio.NewScanner(mountinfo)
+	for scanner.Scan() {
+		// the fifth field is the mount point
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 4 && unescapeMountinfo.Replace(fields[4]) == path {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// MountInfo describes a mounted filesystem.
+type MountInfo struct {
+	Path   string
+	Source string
+	// ReadOnly is set if the mount point is read-only, e.g. a read-only
+	// bind mount.
+	ReadOnly bool
+	// SuperReadOnly is set if the filesystem itself is mounted read-only.
+	SuperReadOnly bool
+}
+
+// FindMount returns the mount path is on.
+func FindMount(path string) (MountInfo, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return MountInfo{}, err
+	}
+	defer f.Close()
+	return findMount(f, filepath.Clean(path))
+}
+
+func findMount(mountinfo io.Reader, path string) (MountInfo, error) {
+	var found MountInfo
+	scanner := bufio.NewScanner(mountinfo)
+	for scanner.Scan() {
+		// the fifth field is the mount point, the sixth its options, and
+		// the source and options of the filesystem follow the optional
+		// fields after the "-"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		mnt := unescapeMountinfo.Replace(fields[4])
+		if mnt != path && mnt != "/" && !strings.HasPrefix(path, mnt+"/") {
+			continue
+		}
+		// later mounts over the same path hide the earlier ones
+		if len(mnt) < len(found.Path) {
+			continue
+		}
+		m := MountInfo{Path: mnt, ReadOnly: hasOption(fields[5], "ro")}
+		for i, f := range fields {
+			if f == "-" && i+3 < len(fields) {
+				m.Source = unescapeMountinfo.Replace(fields[i+2])
+				m.SuperReadOnly = hasOption(fields[i+3], "ro")
+				break
+			}
+		}
+		found = m
+	}
+	if err := scanner.Err(); err != nil {
+		return MountInfo{}, err
+	}
+	if found.Path == "" {
+		return MountInfo{}, fmt.Errorf("no filesystem mounted at %q", path)
+	}
+	return found, nil
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnlyError is returned for paths on read-only filesystems.
+type ReadOnlyError struct {
+	Path  string
+	Mount MountInfo
+}
+
+func (e ReadOnlyError) Error() string {
+	return fmt.Sprintf("%q is on the read-only filesystem %q mounted at %q", e.Path, e.Mount.Source, e.Mount.Path)
+}
+
+// EnsureWritable makes sure path is on a filesystem mounted read-write. If
+// it's read-only, it is remounted read-write if remount is set, otherwise a
+// ReadOnlyError is returned.
+func (u Util) EnsureWritable(path string, remount bool) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return err
+	}
+	// the ST_* flags of statfs match the MS_* flags of mount
+	if st.Flags&syscall.MS_RDONLY == 0 {
+		return nil
+	}
+	m, err := FindMount(path)
+	if err != nil {
+		return err
+	}
+	if !remount {
+		return ReadOnlyError{Path: path, Mount: m}
+	}
+
+	// keep the other flags of the mount point
+	keep := uintptr(st.Flags) & (syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+		syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME)
+	if m.SuperReadOnly {
+		if err := u.LogOp(
+			func() error { return syscall.Mount("", m.Path, "", syscall.MS_REMOUNT|keep, "") },
+			"remounting %q at %q read-write", m.Source, m.Path,
+		); err != nil {
+			return fmt.Errorf("%v, and remounting it failed: %v", ReadOnlyError{Path: path, Mount: m}, err)
+		}
+	}
+	if m.ReadOnly {
+		if err := u.LogOp(
+			func() error { return syscall.Mount("", m.Path, "", syscall.MS_REMOUNT|syscall.MS_BIND|keep, "") },
+			"remounting mount point %q read-write", m.Path,
+		); err != nil {
+			return fmt.Errorf("%v, and remounting it failed: %v", ReadOnlyError{Path: path, Mount: m}, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestIsMountPoint(t *testing.T) {
+	mountinfo := `22 1 253:0 / / rw,relatime shared:1 - ext4 /dev/vda1 rw
+23 22 253:0 /ostree/deploy/fedora/var /sysroot/var rw,relatime shared:2 - ext4 /dev/vda1 rw
+24 22 0:21 / /mnt/with\040space rw - tmpfs tmpfs rw
+`
+	tests := []struct {
+		in  string
+		out bool
+	}{
+		{in: "/", out: true},
+		{in: "/sysroot/var", out: true},
+		{in: "/sysroot", out: false},
+		{in: "/mnt/with space", out: true},
+		{in: `/mnt/with\040space`, out: false},
+	}
+
+	for i, test := range tests {
+		mounted, err := isMountPoint(strings.NewReader(mountinfo), test.in)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if mounted != test.out {
+			t.Errorf("#%d: bad result for %q: want %t, got %t", i, test.in, test.out, mounted)
+		}
+	}
+}
+
+func TestFindMount(t *testing.T) {
+	mountinfo := `22 1 253:0 / / rw,relatime shared:1 - ext4 /dev/vda1 rw
+30 22 253:3 / /sysroot ro,relatime shared:3 - xfs /dev/vda4 rw
+31 30 253:3 / /sysroot rw,relatime shared:4 - xfs /dev/vda4 ro
+32 31 253:5 / /sysroot/var rw,relatime - ext4 /dev/vda5 rw
+24 22 0:21 / /mnt/with\040space ro - tmpfs tmp\040fs rw
+`
+	tests := []struct {
+		in  string
+		out MountInfo
+	}{
+		{in: "/", out: MountInfo{Path: "/", Source: "/dev/vda1"}},
+		{in: "/etc/hostname", out: MountInfo{Path: "/", Source: "/dev/vda1"}},
+		{in: "/sysroot/etc", out: MountInfo{Path: "/sysroot", Source: "/dev/vda4", SuperReadOnly: true}},
+		{in: "/sysroot/var/lib", out: MountInfo{Path: "/sysroot/var", Source: "/dev/vda5"}},
+		{in: "/sysroot/variable", out: MountInfo{Path: "/sysroot", Source: "/dev/vda4", SuperReadOnly: true}},
+		{in: "/mnt/with space/file", out: MountInfo{Path: "/mnt/with space", Source: "tmp fs", ReadOnly: true}},
+	}
+
+	for i, test := range tests {
+		m, err := findMount(strings.NewReader(mountinfo), test.in)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if m != test.out {
+			t.Errorf("#%d: bad mount for %q: want %+v, got %+v", i, test.in, test.out, m)
+		}
+	}
+}
+
+func TestMountFlagsString(t *testing.T) {
+	tests := []struct {
+		in  uintptr
+		out string
+	}{
+		{in: 0, out: "rw"},
+		{in: HardenedMountFlags, out: "rw,nosuid,nodev,noexec"},
+		{in: syscall.MS_RDONLY | syscall.MS_NODEV | syscall.MS_RELATIME, out: "ro,nodev"},
+	}
+
+	for i, test := range tests {
+		if out := MountFlagsString(test.in); out != test.out {
+			t.Errorf("#%d: bad options: want %q, got %q", i, test.out, out)
+		}
+	}
+}
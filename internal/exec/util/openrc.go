@@ -0,0 +1,606 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	// openRCRunlevel is the runlevel enabled services are added to.
+	openRCRunlevel = "default"
+
+	openRCScriptPermissions os.FileMode = 0755
+)
+
+// openRC translates service units into OpenRC init scripts and mount units
+// into /etc/fstab entries, and enables services by adding them to the
+// default runlevel.
+type openRC struct{}
+
+var (
+	openRCServiceKeys = map[string]map[string]bool{
+		"Unit": {
+			"Description":         true,
+			"Documentation":       true,
+			"DefaultDependencies": true,
+			"After":               true,
+			"Before":              true,
+			"Requires":            true,
+			"BindsTo":             true,
+			"Wants":               true,
+			"ConditionPathExists": true,
+		},
+		"Service": {
+			"Type":             true,
+			"ExecStart":        true,
+			"ExecStartPre":     true,
+			"ExecStartPost":    true,
+			"ExecStop":         true,
+			"ExecStopPost":     true,
+			"User":             true,
+			"Group":            true,
+			"WorkingDirectory": true,
+			"Environment":      true,
+			"EnvironmentFile":  true,
+			"PIDFile":          true,
+			"Restart":          true,
+			"RemainAfterExit":  true,
+		},
+		// services are enabled by adding them to the default runlevel
+		"Install": nil,
+	}
+
+	openRCMountKeys = map[string]map[string]bool{
+		// fstab entries are mounted in order of their mount points
+		"Unit": nil,
+		"Mount": {
+			"What":    true,
+			"Where":   true,
+			"Type":    true,
+			"Options": true,
+		},
+		"Install": nil,
+	}
+
+	envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// openRCServices are the OpenRC services standing in for systemd's
+	// targets. Other targets are left out of the dependencies.
+	openRCServices = map[string]string{
+		"network.target":        "net",
+		"network-online.target": "net",
+		"local-fs.target":       "localmount",
+		"remote-fs.target":      "netmount",
+	}
+)
+
+func unitError(unit types.Unit, format string, a ...interface{}) error {
+	return fmt.Errorf("unit %q: %s", unit.Name, fmt.Sprintf(format, a...))
+}
+
+// openRCServiceName returns the name of the OpenRC service standing in for
+// a service unit.
+func openRCServiceName(unit types.Unit) (string, error) {
+	name := string(unit.Name)
+	if strings.Contains(name, "@") {
+		return "", unitError(unit, "template units aren't supported by OpenRC")
+	}
+	return strings.TrimSuffix(name, ".service"), nil
+}
+
+func (openRC) WriteUnit(u Util, unit types.Unit, runtime bool) ([]string, error) {
+	if runtime {
+		return nil, unitError(unit, "runtime units aren't supported by OpenRC")
+	}
+
+	dropins := append([]types.SystemdDropin{}, unit.Dropins...)
+	sort.Slice(dropins, func(i, j int) bool { return dropins[i].Name < dropins[j].Name })
+	var contents []string
+	for _, dropin := range dropins {
+		if dropin.Contents != "" {
+			contents = append(contents, dropin.Contents)
+		}
+	}
+	if unit.Contents == "" {
+		if len(contents) != 0 {
+			return nil, unitError(unit, "drop-ins can only be translated for OpenRC along with the unit")
+		}
+		return nil, nil
+	}
+	f, err := parseUnit(append([]string{unit.Contents}, contents...)...)
+	if err != nil {
+		return nil, unitError(unit, "%v", err)
+	}
+
+	switch filepath.Ext(string(unit.Name)) {
+	case ".service":
+		name, err := openRCServiceName(unit)
+		if err != nil {
+			return nil, err
+		}
+		script, warnings, err := openRCScript(unit, f)
+		if err != nil {
+			return nil, err
+		}
+		u.logUnitWarnings(unit, warnings)
+		uri, err := url.Parse(dataurl.EncodeBytes([]byte(script)))
+		if err != nil {
+			return nil, err
+		}
+		op := &FetchOp{
+			Path: filepath.Join(OpenRCInitScriptsPath(), name),
+			Url:  *uri,
+			Mode: configUtil.IntToPtr(int(openRCScriptPermissions)),
+		}
+		if err := u.LogOp(
+			func() error { return u.PerformFetch(op) },
+			"writing OpenRC init script for unit %q at %q", unit.Name, op.Path,
+		); err != nil {
+			return nil, err
+		}
+		return []string{"/" + op.Path}, nil
+	case ".mount":
+		line, warnings, err := fstabLine(unit, f)
+		if err != nil {
+			return nil, err
+		}
+		u.logUnitWarnings(unit, warnings)
+		if err := u.LogOp(
+			func() error { return u.appendUniqueLine(FstabPath(), line) },
+			"adding unit %q to %q", unit.Name, FstabPath(),
+		); err != nil {
+			return nil, err
+		}
+		return []string{"/" + FstabPath()}, nil
+	default:
+		return nil, unitError(unit, "%s units aren't supported by OpenRC", filepath.Ext(string(unit.Name)))
+	}
+}
+
+func (openRC) EnableUnit(u Util, unit types.Unit) error {
+	switch filepath.Ext(string(unit.Name)) {
+	case ".service":
+	case ".mount":
+		// fstab entries are mounted at boot
+		return nil
+	default:
+		return unitError(unit, "%s units aren't supported by OpenRC", filepath.Ext(string(unit.Name)))
+	}
+	name, err := openRCServiceName(unit)
+	if err != nil {
+		return err
+	}
+	path, err := u.JoinPath(OpenRCRunlevelPath(openRCRunlevel), name)
+	if err != nil {
+		return err
+	}
+	u.RecordManaged(filepath.Join(OpenRCRunlevelPath(openRCRunlevel), name))
+	if err := MkdirForFile(path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(filepath.Join("/", OpenRCInitScriptsPath(), name), path)
+}
+
+func (openRC) EnableRuntimeUnit(u Util, unit types.Unit, target string) error {
+	return unitError(unit, "runtime units aren't supported by OpenRC")
+}
+
+func (openRC) DisableUnit(u Util, unit types.Unit) error {
+	if filepath.Ext(string(unit.Name)) != ".service" {
+		return unitError(unit, "only services can be disabled with OpenRC")
+	}
+	name, err := openRCServiceName(unit)
+	if err != nil {
+		return err
+	}
+	path, err := u.JoinPath(OpenRCRunlevelPath(openRCRunlevel), name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (openRC) MaskUnit(u Util, unit types.Unit) error {
+	return unitError(unit, "masking isn't supported by OpenRC")
+}
+
+func (u Util) logUnitWarnings(unit types.Unit, warnings []string) {
+	for _, w := range warnings {
+		u.Warning("unit %q: %s", unit.Name, w)
+	}
+}
+
+// appendUniqueLine appends line to the file at path, unless the file
+// already contains it.
+func (u Util) appendUniqueLine(path, line string) error {
+	u.RecordManaged(path)
+	path, err := u.JoinPath(path)
+	if err != nil {
+		return err
+	}
+	if err := MkdirForFile(path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, DefaultFilePermissions)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if scanner.Text() == line {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	_, err = file.WriteString(line + "\n")
+	return err
+}
+
+// openRCScript translates a service unit into an OpenRC init script. It
+// returns warnings for the settings it leaves out, and fails for units
+// which can't be translated.
+func openRCScript(unit types.Unit, f unitFile) (string, []string, error) {
+	var warnings []string
+	for _, key := range f.unknownKeys(openRCServiceKeys) {
+		warnings = append(warnings, fmt.Sprintf("ignoring %s, which isn't supported by OpenRC", key))
+	}
+
+	typ := f.last("Service", "Type")
+	switch typ {
+	case "", "simple", "exec", "forking", "oneshot":
+	case "notify":
+		warnings = append(warnings, "Type=notify is treated as Type=simple")
+	default:
+		return "", nil, unitError(unit, "Type=%s isn't supported by OpenRC", typ)
+	}
+	oneshot := typ == "oneshot"
+
+	execStart, err := execLines(unit, f, "ExecStart")
+	if err != nil {
+		return "", nil, err
+	}
+	if len(execStart) == 0 {
+		return "", nil, unitError(unit, "no ExecStart")
+	}
+	if !oneshot && len(execStart) > 1 {
+		return "", nil, unitError(unit, "more than one ExecStart is only supported for Type=oneshot")
+	}
+
+	// b holds the variables and fns the functions of the script
+	var b, fns strings.Builder
+	if d := f.last("Unit", "Description"); d != "" {
+		fmt.Fprintf(&b, "description=%s\n", shellQuote(d))
+	}
+
+	for _, env := range f.all("Service", "Environment") {
+		for _, assignment := range splitQuoted(env) {
+			parts := strings.SplitN(assignment, "=", 2)
+			if len(parts) != 2 || !envNameRegexp.MatchString(parts[0]) {
+				warnings = append(warnings, fmt.Sprintf("ignoring invalid environment assignment %q", assignment))
+				continue
+			}
+			fmt.Fprintf(&b, "export %s=%s\n", parts[0], shellQuote(parts[1]))
+		}
+	}
+	if files := f.all("Service", "EnvironmentFile"); len(files) != 0 {
+		b.WriteString("set -a\n")
+		for _, file := range files {
+			if strings.HasPrefix(file, "-") {
+				file = shellQuote(strings.TrimPrefix(file, "-"))
+				fmt.Fprintf(&b, "[ ! -f %s ] || . %s\n", file, file)
+			} else {
+				fmt.Fprintf(&b, ". %s\n", shellQuote(file))
+			}
+		}
+		b.WriteString("set +a\n")
+	}
+
+	user := f.last("Service", "User")
+	if group := f.last("Service", "Group"); group != "" {
+		user += ":" + group
+	}
+	dir := f.last("Service", "WorkingDirectory")
+
+	if oneshot {
+		if user != "" {
+			warnings = append(warnings, "ignoring User and Group, which OpenRC only supports for daemons")
+		}
+		if restart := f.last("Service", "Restart"); restart != "" && restart != "no" {
+			warnings = append(warnings, "ignoring Restart, which OpenRC only supports for daemons")
+		}
+	} else {
+		if len(execStart[0].line) == 0 || execStart[0].line[0] == '"' || execStart[0].line[0] == '\'' {
+			return "", nil, unitError(unit, "ExecStart must start with an unquoted path for OpenRC")
+		}
+		if execStart[0].ignoreFailure {
+			warnings = append(warnings, "ignoring the - prefix of ExecStart")
+		}
+		command := strings.TrimSpace(execStart[0].line)
+		args := ""
+		if i := strings.IndexAny(command, " \t"); i >= 0 {
+			command, args = command[:i], strings.TrimSpace(command[i:])
+		}
+		fmt.Fprintf(&b, "command=%s\n", shellQuote(command))
+		if args != "" {
+			// OpenRC evaluates the arguments, so quotes work as they
+			// do for systemd
+			fmt.Fprintf(&b, "command_args=%s\n", shellQuote(args))
+		}
+		if user != "" {
+			fmt.Fprintf(&b, "command_user=%s\n", shellQuote(user))
+		}
+		if dir != "" {
+			fmt.Fprintf(&b, "directory=%s\n", shellQuote(dir))
+		}
+
+		restart := f.last("Service", "Restart")
+		supervised := restart != "" && restart != "no"
+		switch {
+		case typ == "forking":
+			if supervised {
+				warnings = append(warnings, "ignoring Restart, which OpenRC doesn't support for Type=forking")
+			}
+			if pidfile := f.last("Service", "PIDFile"); pidfile != "" {
+				fmt.Fprintf(&b, "pidfile=%s\n", shellQuote(pidfile))
+			}
+		case supervised:
+			b.WriteString("supervisor=supervise-daemon\n")
+		default:
+			b.WriteString("command_background=yes\n")
+			b.WriteString("pidfile=\"/run/${RC_SVCNAME}.pid\"\n")
+		}
+		if len(f.all("Service", "ExecStop")) != 0 {
+			warnings = append(warnings, "ignoring ExecStop, OpenRC stops daemons by signalling them")
+		}
+	}
+
+	depend, depWarnings := openRCDepend(f)
+	warnings = append(warnings, depWarnings...)
+	if depend != "" {
+		fmt.Fprintf(&fns, "\ndepend() {\n%s}\n", depend)
+	}
+
+	var conditions []string
+	for _, path := range f.all("Unit", "ConditionPathExists") {
+		if strings.HasPrefix(path, "|") {
+			return "", nil, unitError(unit, "triggering conditions aren't supported by OpenRC")
+		}
+		if strings.HasPrefix(path, "!") {
+			conditions = append(conditions, fmt.Sprintf("[ ! -e %s ] || return 0", shellQuote(path[1:])))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("[ -e %s ] || return 0", shellQuote(path)))
+		}
+	}
+	if len(conditions) != 0 && !oneshot {
+		warnings = append(warnings, "ignoring ConditionPathExists, which OpenRC can only check for Type=oneshot")
+	}
+
+	if oneshot {
+		fns.WriteString("\nstart() {\n")
+		for _, c := range conditions {
+			fmt.Fprintf(&fns, "\t%s\n", c)
+		}
+		fns.WriteString("\tebegin \"Starting ${RC_SVCNAME}\"\n")
+		if dir != "" {
+			fmt.Fprintf(&fns, "\tcd %s &&\n", shellQuote(dir))
+		}
+		fns.WriteString(commandChain(execStart))
+		fns.WriteString("\teend $?\n}\n")
+
+		execStop, err := execLines(unit, f, "ExecStop")
+		if err != nil {
+			return "", nil, err
+		}
+		if len(execStop) != 0 {
+			fns.WriteString("\nstop() {\n\tebegin \"Stopping ${RC_SVCNAME}\"\n")
+			fns.WriteString(commandChain(execStop))
+			fns.WriteString("\teend $?\n}\n")
+		}
+	}
+
+	for _, hook := range []struct{ key, function string }{
+		{"ExecStartPre", "start_pre"},
+		{"ExecStartPost", "start_post"},
+		{"ExecStopPost", "stop_post"},
+	} {
+		lines, err := execLines(unit, f, hook.key)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(lines) != 0 {
+			fmt.Fprintf(&fns, "\n%s() {\n%s}\n", hook.function, commandChain(lines))
+		}
+	}
+
+	script := fmt.Sprintf("#!/sbin/openrc-run\n# Translated by Ignition from the systemd unit %s.\n", unit.Name)
+	if b.Len() != 0 {
+		script += "\n" + b.String()
+	}
+	return script + fns.String(), warnings, nil
+}
+
+// openRCDepend returns the body of the depend function for the unit's
+// dependencies.
+func openRCDepend(f unitFile) (string, []string) {
+	var b strings.Builder
+	var warnings []string
+	for _, dep := range []struct {
+		keyword string
+		keys    []string
+	}{
+		{"need", []string{"Requires", "BindsTo"}},
+		{"want", []string{"Wants"}},
+		{"after", []string{"After"}},
+		{"before", []string{"Before"}},
+	} {
+		seen := map[string]bool{}
+		var services []string
+		for _, key := range dep.keys {
+			for _, name := range f.list("Unit", key) {
+				service := openRCServices[name]
+				switch {
+				case service != "":
+				case strings.HasSuffix(name, ".service"):
+					service = strings.TrimSuffix(name, ".service")
+				case strings.HasSuffix(name, ".target"):
+					continue
+				default:
+					warnings = append(warnings, fmt.Sprintf("ignoring dependency on %s, which OpenRC can't express", name))
+					continue
+				}
+				if !seen[service] {
+					seen[service] = true
+					services = append(services, service)
+				}
+			}
+		}
+		if len(services) != 0 {
+			fmt.Fprintf(&b, "\t%s %s\n", dep.keyword, strings.Join(services, " "))
+		}
+	}
+	return b.String(), warnings
+}
+
+// execLine is a command of an Exec setting.
+type execLine struct {
+	line          string
+	ignoreFailure bool
+}
+
+// execLines returns the commands of the Exec setting key. Prefixes other
+// than "-" and specifiers other than "%%" aren't supported.
+func execLines(unit types.Unit, f unitFile, key string) ([]execLine, error) {
+	var lines []execLine
+	for _, value := range f.all("Service", key) {
+		var l execLine
+		for len(value) > 0 && strings.ContainsRune("-@:+!", rune(value[0])) {
+			if value[0] != '-' {
+				return nil, unitError(unit, "the %c prefix of %s isn't supported by OpenRC", value[0], key)
+			}
+			l.ignoreFailure = true
+			value = value[1:]
+		}
+		if i := strings.Index(strings.Replace(value, "%%", "", -1), "%"); i >= 0 {
+			return nil, unitError(unit, "specifiers in %s aren't supported by OpenRC", key)
+		}
+		l.line = strings.Replace(value, "%%", "%", -1)
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// commandChain renders commands to run one after the other until one of
+// them fails.
+func commandChain(lines []execLine) string {
+	var b strings.Builder
+	for i, l := range lines {
+		cmd := l.line
+		if l.ignoreFailure {
+			cmd = "{ " + cmd + " || :; }"
+		}
+		if i < len(lines)-1 {
+			cmd += " &&"
+		}
+		fmt.Fprintf(&b, "\t%s\n", cmd)
+	}
+	return b.String()
+}
+
+// fstabLine translates a mount unit into an fstab entry.
+func fstabLine(unit types.Unit, f unitFile) (string, []string, error) {
+	var warnings []string
+	for _, key := range f.unknownKeys(openRCMountKeys) {
+		warnings = append(warnings, fmt.Sprintf("ignoring %s, which can't be expressed in fstab", key))
+	}
+	what := f.last("Mount", "What")
+	where := f.last("Mount", "Where")
+	if what == "" || where == "" {
+		return "", nil, unitError(unit, "What and Where are required")
+	}
+	typ := f.last("Mount", "Type")
+	if typ == "" {
+		typ = "auto"
+	}
+	options := f.last("Mount", "Options")
+	if options == "" {
+		options = "defaults"
+	}
+	return fmt.Sprintf("%s %s %s %s 0 0", fstabEscape(what), fstabEscape(where), fstabEscape(typ), fstabEscape(options)), warnings, nil
+}
+
+func fstabEscape(s string) string {
+	return strings.NewReplacer(" ", `\040`, "\t", `\011`).Replace(s)
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// splitQuoted splits s at spaces outside of double or single quotes, and
+// removes the quotes.
+func splitQuoted(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
@@ -0,0 +1,258 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestOpenRCScript(t *testing.T) {
+	type out struct {
+		script   string
+		warnings []string
+		err      bool
+	}
+
+	tests := []struct {
+		in  types.Unit
+		out out
+	}{
+		{
+			in: types.Unit{
+				Name: "hello.service",
+				Contents: `[Unit]
+Description=Say "hello"
+After=network-online.target foo.service multi-user.target
+Wants=network-online.target
+
+[Service]
+Environment="GREETING=hello world" LANG=C
+ExecStart=/usr/bin/hello --greeting "$GREETING" \
+  --verbose
+User=core
+WorkingDirectory=/var/lib/hello
+
+[Install]
+WantedBy=multi-user.target`,
+				Dropins: []types.SystemdDropin{{
+					Name:     "10-restart.conf",
+					Contents: "[Service]\nRestart=always\nProtectSystem=strict",
+				}},
+			},
+			out: out{
+				script: `#!/sbin/openrc-run
+# Translated by Ignition from the systemd unit hello.service.
+
+description='Say "hello"'
+export GREETING='hello world'
+export LANG='C'
+command='/usr/bin/hello'
+command_args='--greeting "$GREETING" --verbose'
+command_user='core'
+directory='/var/lib/hello'
+supervisor=supervise-daemon
+
+depend() {
+	want net
+	after net foo
+}
+`,
+				warnings: []string{"ignoring [Service] ProtectSystem, which isn't supported by OpenRC"},
+			},
+		},
+		{
+			in: types.Unit{
+				Name: "setup.service",
+				Contents: `[Unit]
+ConditionPathExists=!/etc/setup-done
+Requires=var.mount
+
+[Service]
+Type=oneshot
+ExecStartPre=-/usr/bin/rm /tmp/setup
+ExecStart=/usr/bin/setup
+ExecStart=-/usr/bin/touch /etc/setup-done
+ExecStart=/usr/bin/printf 100%%`,
+			},
+			out: out{
+				script: `#!/sbin/openrc-run
+# Translated by Ignition from the systemd unit setup.service.
+
+start() {
+	[ ! -e '/etc/setup-done' ] || return 0
+	ebegin "Starting ${RC_SVCNAME}"
+	/usr/bin/setup &&
+	{ /usr/bin/touch /etc/setup-done || :; } &&
+	/usr/bin/printf 100%
+	eend $?
+}
+
+start_pre() {
+	{ /usr/bin/rm /tmp/setup || :; }
+}
+`,
+				warnings: []string{"ignoring dependency on var.mount, which OpenRC can't express"},
+			},
+		},
+		{
+			in: types.Unit{
+				Name:     "forking.service",
+				Contents: "[Service]\nType=forking\nPIDFile=/run/forking.pid\nExecStart=/usr/sbin/forking\nExecStart=\nExecStart=/usr/sbin/forking -d",
+			},
+			out: out{
+				script: `#!/sbin/openrc-run
+# Translated by Ignition from the systemd unit forking.service.
+
+command='/usr/sbin/forking'
+command_args='-d'
+pidfile='/run/forking.pid'
+`,
+			},
+		},
+		{
+			in: types.Unit{
+				Name:     "simple.service",
+				Contents: "[Service]\nEnvironmentFile=-/etc/default/simple\nExecStart=/usr/bin/simple",
+			},
+			out: out{
+				script: `#!/sbin/openrc-run
+# Translated by Ignition from the systemd unit simple.service.
+
+set -a
+[ ! -f '/etc/default/simple' ] || . '/etc/default/simple'
+set +a
+command='/usr/bin/simple'
+command_background=yes
+pidfile="/run/${RC_SVCNAME}.pid"
+`,
+			},
+		},
+		{
+			in:  types.Unit{Name: "two.service", Contents: "[Service]\nExecStart=/bin/a\nExecStart=/bin/b"},
+			out: out{err: true},
+		},
+		{
+			in:  types.Unit{Name: "none.service", Contents: "[Service]\nType=oneshot"},
+			out: out{err: true},
+		},
+		{
+			in:  types.Unit{Name: "dbus.service", Contents: "[Service]\nType=dbus\nExecStart=/bin/a"},
+			out: out{err: true},
+		},
+		{
+			in:  types.Unit{Name: "prefix.service", Contents: "[Service]\nExecStart=+/bin/a"},
+			out: out{err: true},
+		},
+		{
+			in:  types.Unit{Name: "specifier.service", Contents: "[Service]\nExecStart=/bin/a %n"},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		contents := []string{test.in.Contents}
+		for _, dropin := range test.in.Dropins {
+			contents = append(contents, dropin.Contents)
+		}
+		f, err := parseUnit(contents...)
+		if err != nil {
+			t.Errorf("#%d: parsing unit: %v", i, err)
+			continue
+		}
+		script, warnings, err := openRCScript(test.in, f)
+		if test.out.err {
+			if err == nil {
+				t.Errorf("#%d: expected error, got script:\n%s", i, script)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if script != test.out.script {
+			t.Errorf("#%d: bad script: want:\n%s\ngot:\n%s", i, test.out.script, script)
+		}
+		if !reflect.DeepEqual(warnings, test.out.warnings) {
+			t.Errorf("#%d: bad warnings: want %q, got %q", i, test.out.warnings, warnings)
+		}
+	}
+}
+
+func TestOpenRCInstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-openrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logger := log.New(true)
+	u := Util{DestDir: dir, Logger: &logger}
+	s := openRC{}
+
+	service := types.Unit{Name: "hello.service", Contents: "[Service]\nExecStart=/usr/bin/hello"}
+	mount := types.Unit{Name: "var-data.mount", Contents: "[Mount]\nWhat=/dev/disk/by-label/DATA\nWhere=/var/data dir\nType=ext4"}
+	for _, unit := range []types.Unit{service, mount, mount} {
+		if _, err := s.WriteUnit(u, unit, false); err != nil {
+			t.Fatalf("writing %q: %v", unit.Name, err)
+		}
+		if err := s.EnableUnit(u, unit); err != nil {
+			t.Fatalf("enabling %q: %v", unit.Name, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "etc/init.d/hello"))
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("bad init script: %v %v", info, err)
+	}
+	target, err := os.Readlink(filepath.Join(dir, "etc/runlevels/default/hello"))
+	if err != nil || target != "/etc/init.d/hello" {
+		t.Errorf("bad runlevel link: want %q, got %q (%v)", "/etc/init.d/hello", target, err)
+	}
+	fstab, err := ioutil.ReadFile(filepath.Join(dir, "etc/fstab"))
+	want := "/dev/disk/by-label/DATA /var/data\\040dir ext4 defaults 0 0\n"
+	if err != nil || string(fstab) != want {
+		t.Errorf("bad fstab: want %q, got %q (%v)", want, fstab, err)
+	}
+
+	if err := s.DisableUnit(u, service); err != nil {
+		t.Errorf("disabling %q: %v", service.Name, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "etc/runlevels/default/hello")); !os.IsNotExist(err) {
+		t.Errorf("runlevel link still exists after disabling: %v", err)
+	}
+
+	for _, unit := range []types.Unit{
+		{Name: "hello.socket", Contents: "[Socket]\nListenStream=80"},
+		{Name: "getty@tty1.service", Contents: "[Service]\nExecStart=/sbin/agetty"},
+		{Name: "sshd.service", Dropins: []types.SystemdDropin{{Name: "10.conf", Contents: "[Service]\nUser=x"}}},
+	} {
+		if _, err := s.WriteUnit(u, unit, false); err == nil {
+			t.Errorf("writing %q succeeded", unit.Name)
+		}
+	}
+	if err := s.MaskUnit(u, service); err == nil {
+		t.Errorf("masking %q succeeded", service.Name)
+	}
+	if _, err := s.WriteUnit(u, service, true); err == nil {
+		t.Errorf("writing runtime unit %q succeeded", service.Name)
+	}
+}
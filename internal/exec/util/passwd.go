@@ -15,8 +15,10 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -25,6 +27,7 @@ import (
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/supervisor"
 )
 
 // EnsureUser ensures that the user exists as described. If the user does not
@@ -136,7 +139,11 @@ func translateV2_1UsercreateGroupSliceToPasswdUserGroupSlice(groups []types.User
 func (u Util) CheckIfUserExists(c types.PasswdUser) (bool, error) {
 	code := -1
 	cmd := exec.Command(distro.ChrootCmd(), u.DestDir, distro.IdCmd(), c.Name)
-	stdout, err := cmd.CombinedOutput()
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := supervisor.Run(cmd, distro.HelperTimeout())
+	stdout := output.Bytes()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			code = exitErr.Sys().(syscall.WaitStatus).ExitStatus()
@@ -196,6 +203,11 @@ func (u Util) AuthorizeSSHKeys(c types.PasswdUser) error {
 		if err := akd.Sync(); err != nil {
 			return err
 		}
+		home := strings.TrimPrefix(usr.HomeDir, u.DestDir)
+		u.RecordManaged(
+			filepath.Join(home, ".ssh", keys.AuthorizedKeysDir, "flatcar-ignition"),
+			filepath.Join(home, ".ssh", keys.AuthorizedKeysFile),
+		)
 
 		return nil
 	}, "adding ssh keys to user %q", c.Name)
@@ -45,3 +45,15 @@ func SystemdRuntimeDropinsPath(unitName string) string {
 func NetworkdDropinsPath(unitName string) string {
 	return filepath.Join("etc", "systemd", "network", unitName+".d")
 }
+
+func OpenRCInitScriptsPath() string {
+	return filepath.Join("etc", "init.d")
+}
+
+func OpenRCRunlevelPath(runlevel string) string {
+	return filepath.Join("etc", "runlevels", runlevel)
+}
+
+func FstabPath() string {
+	return filepath.Join("etc", "fstab")
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	// FaillockPath is read by pam_faillock and the faillock command.
+	FaillockPath = "/etc/security/faillock.conf"
+	// LoginDefsPath holds the password aging defaults of new users.
+	LoginDefsPath = "/etc/login.defs"
+)
+
+// PolicyFiles returns the files the password policy is written to. The
+// settings are merged into the files the OS ships, so that their other
+// settings and comments are kept.
+func PolicyFiles(policy types.PasswdPolicy) []types.File {
+	var files []types.File
+	if policy.Faillock != nil {
+		files = append(files, policyFile(FaillockPath, faillockSettings(*policy.Faillock)))
+	}
+	if policy.PasswordAging != nil {
+		files = append(files, policyFile(LoginDefsPath, passwordAgingSettings(*policy.PasswordAging)))
+	}
+	return files
+}
+
+func policyFile(path string, settings []string) types.File {
+	return types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       path,
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0644),
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(joinLines(settings))),
+				Merge:  "keywords",
+			},
+		},
+	}
+}
+
+// faillockSettings returns the lines of faillock.conf, in the format of
+// faillock.conf(5).
+func faillockSettings(f types.PasswdFaillock) []string {
+	var settings []string
+	add := func(key string, value *int) {
+		if value != nil {
+			settings = append(settings, fmt.Sprintf("%s = %d", key, *value))
+		}
+	}
+	add("deny", f.Deny)
+	add("fail_interval", f.FailInterval)
+	add("unlock_time", f.UnlockTime)
+	if f.EvenDenyRoot {
+		settings = append(settings, "even_deny_root")
+	}
+	return settings
+}
+
+// passwordAgingSettings returns the lines of login.defs, which separates
+// keywords from their values with blanks.
+func passwordAgingSettings(a types.PasswdPasswordAging) []string {
+	var settings []string
+	add := func(key string, value *int) {
+		if value != nil {
+			settings = append(settings, fmt.Sprintf("%s\t%d", key, *value))
+		}
+	}
+	add("PASS_MAX_DAYS", a.MaxDays)
+	add("PASS_MIN_DAYS", a.MinDays)
+	add("PASS_WARN_AGE", a.WarnAge)
+	return settings
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestPolicyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	loginDefs := "MAIL_DIR\t/var/spool/mail\nPASS_MAX_DAYS\t99999\nPASS_MIN_DAYS\t0\nPASS_WARN_AGE\t7\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, LoginDefsPath), []byte(loginDefs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := types.PasswdPolicy{
+		Faillock: &types.PasswdFaillock{
+			Deny:         configUtil.IntToPtr(5),
+			UnlockTime:   configUtil.IntToPtr(900),
+			EvenDenyRoot: true,
+		},
+		PasswordAging: &types.PasswdPasswordAging{
+			MaxDays: configUtil.IntToPtr(365),
+			MinDays: configUtil.IntToPtr(1),
+		},
+	}
+	want := map[string]string{
+		FaillockPath:  "deny = 5\nunlock_time = 900\neven_deny_root\n",
+		LoginDefsPath: "MAIL_DIR\t/var/spool/mail\nPASS_MAX_DAYS\t365\nPASS_MIN_DAYS\t1\nPASS_WARN_AGE\t7\n",
+	}
+
+	logger := log.New(true)
+	u := Util{DestDir: dir, IsRoot: true, Logger: &logger, Fetcher: resource.Fetcher{Logger: &logger}}
+	// writing the policy again changes nothing
+	for i := 0; i < 2; i++ {
+		for _, f := range PolicyFiles(policy) {
+			op := u.PrepareFetch(&logger, f)
+			if op == nil {
+				t.Fatalf("failed to prepare %q", f.Path)
+			}
+			if err := u.PerformFetch(op); err != nil {
+				t.Fatalf("writing %q: %v", f.Path, err)
+			}
+		}
+		for path, contents := range want {
+			got, err := ioutil.ReadFile(filepath.Join(dir, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != contents {
+				t.Errorf("#%d: %s: want %q, got %q", i, path, contents, got)
+			}
+		}
+	}
+}
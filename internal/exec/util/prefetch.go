@@ -0,0 +1,207 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+// Prefetcher fetches the contents of remote files ahead of writing them, so
+// that slow fetches overlap with each other and with unrelated work. Only
+// the fetching is done ahead; the files are still written in order by
+// PerformFetch, which takes the prefetched contents.
+type Prefetcher struct {
+	dir     string
+	mu      sync.Mutex
+	pending map[string][]*prefetch
+	wg      sync.WaitGroup
+}
+
+type prefetch struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// Prefetchable returns whether the contents of f are fetched from the
+// network, and are therefore worth fetching ahead.
+func Prefetchable(f *FetchOp) bool {
+	switch f.Url.Scheme {
+	case "http", "https", "tftp", "s3", "gs", "azblob", "docker", "oci", "git+https", "sftp":
+		return true
+	default:
+		return false
+	}
+}
+
+// Prefetch starts fetching the contents of ops, with at most workers
+// fetches at once. The contents are kept in a directory below u.DestDir, so
+// that they can usually be moved into place rather than copied. Failed
+// fetches are reported by PerformFetch.
+func (u Util) Prefetch(ops []*FetchOp, workers int) (*Prefetcher, error) {
+	dir, err := ioutil.TempDir(u.DestDir, ".ignition-prefetch")
+	if err != nil {
+		return nil, err
+	}
+	p := &Prefetcher{
+		dir:     dir,
+		pending: map[string][]*prefetch{},
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	work := make(chan *FetchOp, len(ops))
+	fetches := map[*FetchOp]*prefetch{}
+	for _, op := range ops {
+		pf := &prefetch{done: make(chan struct{})}
+		fetches[op] = pf
+		key := prefetchKey(op)
+		p.pending[key] = append(p.pending[key], pf)
+		work <- op
+	}
+	close(work)
+
+	for i := 0; i < workers && i < len(ops); i++ {
+		p.wg.Add(1)
+		go func(u Util, logger *log.Logger) {
+			defer p.wg.Done()
+			u.Logger = logger
+			for op := range work {
+				pf := fetches[op]
+				pf.path, pf.err = u.prefetch(op, dir)
+				close(pf.done)
+			}
+		}(u, u.Logger.Fork())
+	}
+	return p, nil
+}
+
+func (u Util) prefetch(f *FetchOp, dir string) (string, error) {
+	tmp, err := ioutil.TempFile(dir, "prefetch")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+	u.Debug("prefetching contents of %q", f.Path)
+	if err := u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// take returns the prefetched contents of f, if they were prefetched,
+// waiting for the fetch to finish.
+func (p *Prefetcher) take(f *FetchOp) *prefetch {
+	if p == nil {
+		return nil
+	}
+	key := prefetchKey(f)
+	p.mu.Lock()
+	queue := p.pending[key]
+	if len(queue) == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	pf := queue[0]
+	p.pending[key] = queue[1:]
+	p.mu.Unlock()
+
+	<-pf.done
+	return pf
+}
+
+// Close waits for the fetches still running and removes the contents which
+// weren't taken, along with their directory.
+func (p *Prefetcher) Close() {
+	if p == nil {
+		return
+	}
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, queue := range p.pending {
+		for _, pf := range queue {
+			if pf.path != "" {
+				os.Remove(pf.path)
+			}
+		}
+	}
+	p.pending = nil
+	os.RemoveAll(p.dir)
+}
+
+func prefetchKey(f *FetchOp) string {
+	return f.Node.Filesystem + "\x00" + f.Path + "\x00" + f.Url.String() + "\x00" + strconv.FormatBool(f.Append)
+}
+
+// takePrefetched moves the prefetched contents of f into dest, which must
+// be empty, and returns the file to continue with. It returns dest as is
+// if f wasn't prefetched, along with false.
+func (u Util) takePrefetched(f *FetchOp, dest *os.File) (*os.File, bool, error) {
+	pf := u.Prefetched.take(f)
+	if pf == nil {
+		return dest, false, nil
+	}
+	if pf.err != nil {
+		u.Crit("Error fetching file %q: %v", f.Path, pf.err)
+		return dest, true, pf.err
+	}
+	defer os.Remove(pf.path)
+
+	// move the contents if they're on the same filesystem and copy them
+	// otherwise
+	err := os.Rename(pf.path, dest.Name())
+	if err == nil {
+		moved, err := os.OpenFile(dest.Name(), os.O_RDWR, 0)
+		if err != nil {
+			return dest, true, err
+		}
+		dest.Close()
+		if _, err := moved.Seek(0, io.SeekEnd); err != nil {
+			moved.Close()
+			return dest, true, err
+		}
+		return moved, true, nil
+	}
+	if linkErr, ok := err.(*os.LinkError); !ok || linkErr.Err != syscall.EXDEV {
+		return dest, true, err
+	}
+	src, err := os.Open(pf.path)
+	if err != nil {
+		return dest, true, err
+	}
+	defer src.Close()
+	if !f.FetchOptions.Sparse {
+		_, err = io.Copy(dest, src)
+		return dest, true, err
+	}
+	w, err := resource.NewSparseWriter(dest)
+	if err != nil {
+		return dest, true, err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return dest, true, err
+	}
+	return dest, true, w.Close()
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestPrefetch(t *testing.T) {
+	// every request waits for the others, so the test only finishes if
+	// the files are fetched concurrently
+	var inFlight sync.WaitGroup
+	inFlight.Add(3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		inFlight.Done()
+		done := make(chan struct{})
+		go func() {
+			inFlight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	root, err := ioutil.TempDir("", "ignition-prefetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	logger := log.New(true)
+	u := Util{DestDir: root, Fetcher: resource.Fetcher{Logger: &logger}, Logger: &logger}
+	op := func(path, source string) *FetchOp {
+		src, err := url.Parse(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &FetchOp{
+			Path: path,
+			Url:  *src,
+			Node: types.Node{Filesystem: "root", Path: path},
+		}
+	}
+	ops := []*FetchOp{
+		op("/etc/a", server.URL+"/a"),
+		op("/etc/b", server.URL+"/b"),
+		op("/etc/c", server.URL+"/c"),
+		op("/etc/missing", server.URL+"/missing"),
+	}
+	if !Prefetchable(ops[0]) || Prefetchable(op("/etc/d", "data:,d")) {
+		t.Errorf("bad prefetchable schemes")
+	}
+
+	u.Prefetched, err = u.Prefetch(ops, 3)
+	if err != nil {
+		t.Fatalf("prefetching failed: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if err := u.PerformFetch(op("/etc/"+name, server.URL+"/"+name)); err != nil {
+			t.Errorf("%s: writing the file failed: %v", name, err)
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(root, "etc", name))
+		if err != nil {
+			t.Errorf("%s: reading the file failed: %v", name, err)
+		} else if string(b) != "/"+name {
+			t.Errorf("%s: bad contents: want %q, got %q", name, "/"+name, string(b))
+		}
+	}
+	if err := u.PerformFetch(op("/etc/missing", server.URL+"/missing")); err == nil {
+		t.Errorf("missing: expected an error")
+	}
+
+	u.Prefetched.Close()
+	if entries, err := ioutil.ReadDir(root); err != nil || len(entries) != 1 {
+		t.Errorf("prefetched contents weren't removed: %v, %v", entries, err)
+	}
+}
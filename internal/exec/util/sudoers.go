@@ -0,0 +1,79 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+
+	configUtil "github.com/flatcar/ignition/config/util"
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// SudoersPath is the sudoers fragment the entries of passwd.sudoers are
+// written to. sudo skips the files of sudoers.d with a '.' in their name,
+// so the entries share one file rather than one per user.
+const SudoersPath = "/etc/sudoers.d/90-ignition"
+
+// SudoersFile returns the file the entries are written as, which is
+// checked with visudo before it's put in place.
+func SudoersFile(sudoers []types.PasswdSudoer) types.File {
+	return types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       SudoersPath,
+			Overwrite:  configUtil.BoolToPtr(true),
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode:  configUtil.IntToPtr(0440),
+			Check: "sudoers",
+			Contents: types.FileContents{
+				Source: dataurl.EncodeBytes([]byte(sudoersContents(sudoers))),
+			},
+		},
+	}
+}
+
+func sudoersContents(sudoers []types.PasswdSudoer) string {
+	var b strings.Builder
+	b.WriteString("# Written by Ignition from passwd.sudoers\n")
+	for _, s := range sudoers {
+		who := s.User
+		if s.Group != "" {
+			who = "%" + s.Group
+		}
+		b.WriteString(who + " ALL=(ALL) ")
+		if s.NoPasswd {
+			b.WriteString("NOPASSWD: ")
+		}
+		if len(s.Commands) == 0 {
+			b.WriteString("ALL\n")
+			continue
+		}
+		var commands []string
+		for _, c := range s.Commands {
+			commands = append(commands, escapeSudoersCommand(c))
+		}
+		b.WriteString(strings.Join(commands, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// escapeSudoersCommand escapes the characters sudoers gives a meaning in
+// command lines, so that they're taken literally.
+func escapeSudoersCommand(c string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`, `:`, `\:`, `=`, `\=`).Replace(c)
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestSudoersContents(t *testing.T) {
+	sudoers := []types.PasswdSudoer{
+		{Group: "admins"},
+		{User: "core", NoPasswd: true},
+		{
+			User:     "deploy",
+			NoPasswd: true,
+			Commands: []string{
+				"/usr/bin/systemctl restart app.service",
+				"/usr/bin/env FOO=a,b:c\\d /opt/bin/tool",
+			},
+		},
+	}
+	want := `# Written by Ignition from passwd.sudoers
+%admins ALL=(ALL) ALL
+core ALL=(ALL) NOPASSWD: ALL
+deploy ALL=(ALL) NOPASSWD: /usr/bin/systemctl restart app.service, /usr/bin/env FOO\=a\,b\:c\\d /opt/bin/tool
+`
+	if got := sudoersContents(sudoers); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	f := SudoersFile(sudoers)
+	if f.Path != SudoersPath || f.Check != "sudoers" || f.Mode == nil || *f.Mode != 0440 {
+		t.Errorf("unexpected file %+v", f)
+	}
+}
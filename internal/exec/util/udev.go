@@ -0,0 +1,52 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+const (
+	// UdevRulesDir is the directory of the target the rules of
+	// storage.udevRules are written to.
+	UdevRulesDir = "/etc/udev/rules.d"
+
+	// DefaultUdevRulePriority sorts rules after those of systemd and the
+	// image, so that they see the properties those set and their
+	// assignments win.
+	DefaultUdevRulePriority = 90
+)
+
+// UdevRuleFileName returns the name of the file of the rule, which udev
+// orders the rules of all its directories by.
+func UdevRuleFileName(r types.UdevRule) string {
+	priority := DefaultUdevRulePriority
+	if r.Priority != nil {
+		priority = *r.Priority
+	}
+	return fmt.Sprintf("%02d-%s.rules", priority, r.Name)
+}
+
+// UdevRuleContents returns the contents of the rule's file, ending in a
+// line feed.
+func UdevRuleContents(r types.UdevRule) string {
+	if r.Contents == "" || strings.HasSuffix(r.Contents, "\n") {
+		return r.Contents
+	}
+	return r.Contents + "\n"
+}
@@ -16,9 +16,11 @@ package util
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	configUtil "github.com/flatcar/ignition/config/util"
 	"github.com/flatcar/ignition/internal/config/types"
@@ -30,6 +32,11 @@ import (
 const (
 	PresetPath               string      = "/etc/systemd/system-preset/20-ignition.preset"
 	DefaultPresetPermissions os.FileMode = 0644
+
+	networkdRoutesDropin = "10-ignition-routes.conf"
+
+	wireGuardKeyDir   = "/etc/wireguard"
+	wireGuardKeyGroup = "systemd-network"
 )
 
 func FileFromSystemdUnit(unit types.Unit, runtime bool) (*FetchOp, error) {
@@ -52,6 +59,250 @@ func FileFromSystemdUnit(unit types.Unit, runtime bool) (*FetchOp, error) {
 	}, nil
 }
 
+// NetworkdLinkUnit renders the link as a .link file. udevd only applies the
+// first .link file matching an interface, in the order of their names, so
+// the file is named to come before the default one, which matches every
+// interface.
+func NetworkdLinkUnit(link types.NetworkdLink) types.Networkdunit {
+	name := link.Name
+	if name == "" {
+		name = link.AlternativeNames[0]
+	}
+
+	var b strings.Builder
+	b.WriteString("[Match]\n")
+	for _, m := range []struct{ key, value string }{
+		{"MACAddress", formatMAC(link.Match.MACAddress)},
+		{"PermanentMACAddress", formatMAC(link.Match.PermanentMACAddress)},
+		{"Path", link.Match.Path},
+		{"Driver", link.Match.Driver},
+	} {
+		if m.value != "" {
+			fmt.Fprintf(&b, "%s=%s\n", m.key, m.value)
+		}
+	}
+	b.WriteString("\n[Link]\n")
+	if link.Name != "" {
+		fmt.Fprintf(&b, "Name=%s\n", link.Name)
+	} else {
+		// keep the name the default would give, as it doesn't apply
+		b.WriteString("NamePolicy=keep kernel database onboard slot path\n")
+	}
+	for _, alt := range link.AlternativeNames {
+		fmt.Fprintf(&b, "AlternativeName=%s\n", alt)
+	}
+
+	return types.Networkdunit{
+		Name:     fmt.Sprintf("10-ignition-%s.link", name),
+		Contents: b.String(),
+	}
+}
+
+// NetworkdNetdevUnits renders the bonds, bridges and VLANs of networkd as a
+// .netdev file each, and the interfaces they're built on as a .network file
+// each, which adds the interface to its bond or bridge and its VLANs. Like
+// the .link files, they're named to come before the files of the image, as
+// networkd only applies the first .network file matching an interface.
+func NetworkdNetdevUnits(networkd types.Networkd) []types.Networkdunit {
+	var units []types.Networkdunit
+	netdev := func(name, kind, section string, settings []string) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "[NetDev]\nName=%s\nKind=%s\n", name, kind)
+		if len(settings) > 0 {
+			fmt.Fprintf(&b, "\n[%s]\n", section)
+			for _, s := range settings {
+				b.WriteString(s + "\n")
+			}
+		}
+		units = append(units, types.Networkdunit{
+			Name:     fmt.Sprintf("10-ignition-%s.netdev", name),
+			Contents: b.String(),
+		})
+	}
+
+	// the settings of the .network files, in the order the interfaces are
+	// first named
+	var ifaces []string
+	network := map[string][]string{}
+	add := func(iface, setting string) {
+		if _, ok := network[iface]; !ok {
+			ifaces = append(ifaces, iface)
+		}
+		network[iface] = append(network[iface], setting)
+	}
+
+	for _, bond := range networkd.Bonds {
+		var settings []string
+		if bond.Mode != "" {
+			settings = append(settings, "Mode="+bond.Mode)
+		}
+		if bond.MIIMonitor != nil {
+			settings = append(settings, fmt.Sprintf("MIIMonitorSec=%dms", *bond.MIIMonitor))
+		}
+		netdev(bond.Name, "bond", "Bond", settings)
+		for _, iface := range bond.Interfaces {
+			add(iface, "Bond="+bond.Name)
+		}
+	}
+	for _, bridge := range networkd.Bridges {
+		var settings []string
+		if bridge.STP != nil {
+			settings = append(settings, fmt.Sprintf("STP=%t", *bridge.STP))
+		}
+		netdev(bridge.Name, "bridge", "Bridge", settings)
+		for _, iface := range bridge.Interfaces {
+			add(iface, "Bridge="+bridge.Name)
+		}
+	}
+	for _, vlan := range networkd.Vlans {
+		netdev(vlan.Name, "vlan", "VLAN", []string{fmt.Sprintf("Id=%d", vlan.ID)})
+		add(vlan.Interface, "VLAN="+vlan.Name)
+	}
+
+	for _, iface := range ifaces {
+		units = append(units, types.Networkdunit{
+			Name:     fmt.Sprintf("10-ignition-%s.network", iface),
+			Contents: fmt.Sprintf("[Match]\nName=%s\n\n[Network]\n%s\n", iface, strings.Join(network[iface], "\n")),
+		})
+	}
+	for _, wg := range networkd.WireGuard {
+		units = append(units, wireGuardUnits(wg)...)
+	}
+	return units
+}
+
+// wireGuardUnits renders the WireGuard interface wg as a .netdev file, and a
+// .network file giving it its addresses. Routes to the allowed IPs of the
+// peers are added to the main routing table, so that traffic to them goes
+// through the tunnel.
+func wireGuardUnits(wg types.NetworkdWireGuard) []types.Networkdunit {
+	var netdev strings.Builder
+	fmt.Fprintf(&netdev, "[NetDev]\nName=%s\nKind=wireguard\n\n[WireGuard]\nPrivateKeyFile=%s\nRouteTable=main\n", wg.Name, WireGuardKeyPath(wg))
+	if wg.ListenPort != nil {
+		fmt.Fprintf(&netdev, "ListenPort=%d\n", *wg.ListenPort)
+	}
+	for _, peer := range wg.Peers {
+		fmt.Fprintf(&netdev, "\n[WireGuardPeer]\nPublicKey=%s\n", peer.PublicKey)
+		if len(peer.AllowedIPs) > 0 {
+			fmt.Fprintf(&netdev, "AllowedIPs=%s\n", strings.Join(peer.AllowedIPs, ","))
+		}
+		if peer.Endpoint != "" {
+			fmt.Fprintf(&netdev, "Endpoint=%s\n", peer.Endpoint)
+		}
+		if peer.PersistentKeepalive != nil {
+			fmt.Fprintf(&netdev, "PersistentKeepalive=%d\n", *peer.PersistentKeepalive)
+		}
+	}
+
+	network := fmt.Sprintf("[Match]\nName=%s\n", wg.Name)
+	if len(wg.Addresses) > 0 {
+		network += "\n[Network]\n"
+		for _, a := range wg.Addresses {
+			network += "Address=" + a + "\n"
+		}
+	}
+
+	return []types.Networkdunit{
+		{Name: fmt.Sprintf("10-ignition-%s.netdev", wg.Name), Contents: netdev.String()},
+		{Name: fmt.Sprintf("10-ignition-%s.network", wg.Name), Contents: network},
+	}
+}
+
+// WireGuardKeyPath returns the path of the private key of the WireGuard
+// interface wg.
+func WireGuardKeyPath(wg types.NetworkdWireGuard) string {
+	return filepath.Join(wireGuardKeyDir, wg.Name+".key")
+}
+
+// WireGuardKeyFile returns the file holding the private key of the WireGuard
+// interface wg. Only root and systemd-networkd, which reads the key when
+// creating the interface, can read it.
+func WireGuardKeyFile(wg types.NetworkdWireGuard) types.File {
+	return types.File{
+		Node: types.Node{
+			Filesystem: "root",
+			Path:       WireGuardKeyPath(wg),
+			Overwrite:  configUtil.BoolToPtr(true),
+			Group:      &types.NodeGroup{Name: wireGuardKeyGroup},
+		},
+		FileEmbedded1: types.FileEmbedded1{
+			Mode: configUtil.IntToPtr(0640),
+			Contents: types.FileContents{
+				Source:       wg.PrivateKey.Source,
+				HTTPHeaders:  wg.PrivateKey.HTTPHeaders,
+				Verification: wg.PrivateKey.Verification,
+			},
+		},
+	}
+}
+
+// NetworkdUnitWithRoutes returns unit with its routes and routing policy
+// rules rendered as a drop-in, which adds them to the [Route] and
+// [RoutingPolicyRule] sections of the unit's own contents or of the file of
+// the same name the image or a generated file provides.
+func NetworkdUnitWithRoutes(unit types.Networkdunit) types.Networkdunit {
+	if len(unit.Routes) == 0 && len(unit.RoutingPolicyRules) == 0 {
+		return unit
+	}
+
+	var sections []string
+	for _, r := range unit.Routes {
+		var b strings.Builder
+		b.WriteString("[Route]\n")
+		if r.Destination != "" {
+			fmt.Fprintf(&b, "Destination=%s\n", r.Destination)
+		}
+		if r.Gateway != "" {
+			fmt.Fprintf(&b, "Gateway=%s\n", r.Gateway)
+		}
+		if r.GatewayOnLink {
+			b.WriteString("GatewayOnLink=yes\n")
+		}
+		if r.Metric != nil {
+			fmt.Fprintf(&b, "Metric=%d\n", *r.Metric)
+		}
+		if r.Table != nil {
+			fmt.Fprintf(&b, "Table=%d\n", *r.Table)
+		}
+		sections = append(sections, b.String())
+	}
+	for _, r := range unit.RoutingPolicyRules {
+		var b strings.Builder
+		b.WriteString("[RoutingPolicyRule]\n")
+		if r.From != "" {
+			fmt.Fprintf(&b, "From=%s\n", r.From)
+		}
+		if r.To != "" {
+			fmt.Fprintf(&b, "To=%s\n", r.To)
+		}
+		if r.IncomingInterface != "" {
+			fmt.Fprintf(&b, "IncomingInterface=%s\n", r.IncomingInterface)
+		}
+		if r.Priority != nil {
+			fmt.Fprintf(&b, "Priority=%d\n", *r.Priority)
+		}
+		fmt.Fprintf(&b, "Table=%d\n", r.Table)
+		sections = append(sections, b.String())
+	}
+
+	dropins := make([]types.NetworkdDropin, len(unit.Dropins), len(unit.Dropins)+1)
+	copy(dropins, unit.Dropins)
+	unit.Dropins = append(dropins, types.NetworkdDropin{
+		Name:     networkdRoutesDropin,
+		Contents: strings.Join(sections, "\n"),
+	})
+	return unit
+}
+
+// formatMAC writes the MAC address mac, which may be written with colons,
+// hyphens or dots, in the form of systemd.
+func formatMAC(mac string) string {
+	if hw, err := net.ParseMAC(mac); err == nil {
+		return hw.String()
+	}
+	return mac
+}
+
 func FileFromNetworkdUnit(unit types.Networkdunit) (*FetchOp, error) {
 	u, err := url.Parse(dataurl.EncodeBytes([]byte(unit.Contents)))
 	if err != nil {
@@ -96,11 +347,62 @@ func FileFromNetworkdUnitDropin(unit types.Networkdunit, dropin types.NetworkdDr
 	}, nil
 }
 
-func (u Util) MaskUnit(unit types.Unit) error {
+// systemd installs units as systemd unit files and enables them with a
+// preset file.
+type systemd struct{}
+
+func (systemd) WriteUnit(u Util, unit types.Unit, runtime bool) ([]string, error) {
+	// use a different DestDir if it's runtime so it affects our /run (but not
+	// if we're running locally through blackbox tests)
+	if runtime && !distro.BlackboxTesting() {
+		u.DestDir = "/"
+	}
+
+	var relabel []string
+	for _, dropin := range unit.Dropins {
+		if dropin.Contents == "" {
+			continue
+		}
+		f, err := FileFromSystemdUnitDropin(unit, dropin, runtime)
+		if err != nil {
+			u.Crit("error converting systemd dropin: %v", err)
+			return relabel, err
+		}
+		if err := u.LogOp(
+			func() error { return u.PerformFetch(f) },
+			"writing systemd drop-in %q at %q", dropin.Name, f.Path,
+		); err != nil {
+			return relabel, err
+		}
+		if len(relabel) == 0 {
+			relabel = append(relabel, filepath.Dir("/"+f.Path))
+		}
+	}
+
+	if unit.Contents == "" {
+		return relabel, nil
+	}
+
+	f, err := FileFromSystemdUnit(unit, runtime)
+	if err != nil {
+		u.Crit("error converting unit: %v", err)
+		return relabel, err
+	}
+	if err := u.LogOp(
+		func() error { return u.PerformFetch(f) },
+		"writing unit %q at %q", unit.Name, f.Path,
+	); err != nil {
+		return relabel, err
+	}
+	return append(relabel, "/"+f.Path), nil
+}
+
+func (systemd) MaskUnit(u Util, unit types.Unit) error {
 	path, err := u.JoinPath(SystemdUnitsPath(), string(unit.Name))
 	if err != nil {
 		return err
 	}
+	u.RecordManaged(filepath.Join(SystemdUnitsPath(), string(unit.Name)))
 
 	if err := MkdirForFile(path); err != nil {
 		return err
@@ -111,13 +413,13 @@ func (u Util) MaskUnit(unit types.Unit) error {
 	return os.Symlink("/dev/null", path)
 }
 
-func (u Util) EnableUnit(unit types.Unit) error {
+func (systemd) EnableUnit(u Util, unit types.Unit) error {
 	return u.appendLineToPreset(fmt.Sprintf("enable %s", unit.Name))
 }
 
 // presets link in /etc, which doesn't make sense for runtime units
 // Related: https://github.com/flatcar/ignition/issues/588
-func (u Util) EnableRuntimeUnit(unit types.Unit, target string) error {
+func (systemd) EnableRuntimeUnit(u Util, unit types.Unit, target string) error {
 	// unless we're running tests locally, we want to affect /run, which will
 	// be carried into the pivot, not a directory named /$DestDir/run
 	if !distro.BlackboxTesting() {
@@ -138,7 +440,7 @@ func (u Util) EnableRuntimeUnit(unit types.Unit, target string) error {
 	return u.WriteLink(link)
 }
 
-func (u Util) DisableUnit(unit types.Unit) error {
+func (systemd) DisableUnit(u Util, unit types.Unit) error {
 	return u.appendLineToPreset(fmt.Sprintf("disable %s", unit.Name))
 }
 
@@ -147,6 +449,7 @@ func (u Util) appendLineToPreset(data string) error {
 	if err != nil {
 		return err
 	}
+	u.RecordManaged(PresetPath)
 
 	if err := MkdirForFile(path); err != nil {
 		return err
@@ -0,0 +1,154 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+func TestNetworkdLinkUnit(t *testing.T) {
+	tests := []struct {
+		in  types.NetworkdLink
+		out types.Networkdunit
+	}{
+		{
+			in: types.NetworkdLink{
+				Name:             "uplink",
+				AlternativeNames: []string{"provisioning"},
+				Match:            types.NetworkdLinkMatch{MACAddress: "52-54-00-AB-CD-EF"},
+			},
+			out: types.Networkdunit{
+				Name:     "10-ignition-uplink.link",
+				Contents: "[Match]\nMACAddress=52:54:00:ab:cd:ef\n\n[Link]\nName=uplink\nAlternativeName=provisioning\n",
+			},
+		},
+		{
+			// without a name, the kernel's or udev's is kept
+			in: types.NetworkdLink{
+				AlternativeNames: []string{"storage", "san"},
+				Match:            types.NetworkdLinkMatch{Path: "pci-0000:00:03.0", Driver: "virtio_net"},
+			},
+			out: types.Networkdunit{
+				Name:     "10-ignition-storage.link",
+				Contents: "[Match]\nPath=pci-0000:00:03.0\nDriver=virtio_net\n\n[Link]\nNamePolicy=keep kernel database onboard slot path\nAlternativeName=storage\nAlternativeName=san\n",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		if got := NetworkdLinkUnit(test.in); !reflect.DeepEqual(test.out, got) {
+			t.Errorf("#%d: want %+v, got %+v", i, test.out, got)
+		}
+	}
+}
+
+func TestNetworkdNetdevUnits(t *testing.T) {
+	miimon := 100
+	stp := true
+	in := types.Networkd{
+		Bonds: []types.NetworkdBond{
+			{Name: "bond0", Mode: "802.3ad", MIIMonitor: &miimon, Interfaces: []string{"eth0", "eth1"}},
+		},
+		Bridges: []types.NetworkdBridge{
+			{Name: "br0", STP: &stp, Interfaces: []string{"vlan10"}},
+			{Name: "br1"},
+		},
+		Vlans: []types.NetworkdVLAN{
+			{Name: "vlan10", ID: 10, Interface: "bond0"},
+			{Name: "vlan20", ID: 20, Interface: "bond0"},
+		},
+	}
+	out := []types.Networkdunit{
+		{Name: "10-ignition-bond0.netdev", Contents: "[NetDev]\nName=bond0\nKind=bond\n\n[Bond]\nMode=802.3ad\nMIIMonitorSec=100ms\n"},
+		{Name: "10-ignition-br0.netdev", Contents: "[NetDev]\nName=br0\nKind=bridge\n\n[Bridge]\nSTP=true\n"},
+		{Name: "10-ignition-br1.netdev", Contents: "[NetDev]\nName=br1\nKind=bridge\n"},
+		{Name: "10-ignition-vlan10.netdev", Contents: "[NetDev]\nName=vlan10\nKind=vlan\n\n[VLAN]\nId=10\n"},
+		{Name: "10-ignition-vlan20.netdev", Contents: "[NetDev]\nName=vlan20\nKind=vlan\n\n[VLAN]\nId=20\n"},
+		{Name: "10-ignition-eth0.network", Contents: "[Match]\nName=eth0\n\n[Network]\nBond=bond0\n"},
+		{Name: "10-ignition-eth1.network", Contents: "[Match]\nName=eth1\n\n[Network]\nBond=bond0\n"},
+		{Name: "10-ignition-vlan10.network", Contents: "[Match]\nName=vlan10\n\n[Network]\nBridge=br0\n"},
+		{Name: "10-ignition-bond0.network", Contents: "[Match]\nName=bond0\n\n[Network]\nVLAN=vlan10\nVLAN=vlan20\n"},
+	}
+
+	if got := NetworkdNetdevUnits(in); !reflect.DeepEqual(out, got) {
+		t.Errorf("want %+v, got %+v", out, got)
+	}
+}
+
+func TestWireGuardUnits(t *testing.T) {
+	port := 51820
+	keepalive := 25
+	in := types.Networkd{WireGuard: []types.NetworkdWireGuard{{
+		Name:       "wg0",
+		ListenPort: &port,
+		Addresses:  []string{"10.0.0.2/24"},
+		Peers: []types.NetworkdWireGuardPeer{
+			{PublicKey: "peer1", Endpoint: "vpn.example.com:51820", AllowedIPs: []string{"10.0.0.0/24", "10.1.0.0/16"}, PersistentKeepalive: &keepalive},
+			{PublicKey: "peer2"},
+		},
+	}}}
+	out := []types.Networkdunit{
+		{
+			Name:     "10-ignition-wg0.netdev",
+			Contents: "[NetDev]\nName=wg0\nKind=wireguard\n\n[WireGuard]\nPrivateKeyFile=/etc/wireguard/wg0.key\nRouteTable=main\nListenPort=51820\n\n[WireGuardPeer]\nPublicKey=peer1\nAllowedIPs=10.0.0.0/24,10.1.0.0/16\nEndpoint=vpn.example.com:51820\nPersistentKeepalive=25\n\n[WireGuardPeer]\nPublicKey=peer2\n",
+		},
+		{
+			Name:     "10-ignition-wg0.network",
+			Contents: "[Match]\nName=wg0\n\n[Network]\nAddress=10.0.0.2/24\n",
+		},
+	}
+
+	if got := NetworkdNetdevUnits(in); !reflect.DeepEqual(out, got) {
+		t.Errorf("want %+v, got %+v", out, got)
+	}
+	if f := WireGuardKeyFile(in.WireGuard[0]); f.Path != "/etc/wireguard/wg0.key" || *f.Mode != 0640 || f.Group.Name != "systemd-network" {
+		t.Errorf("bad key file %+v", f)
+	}
+}
+
+func TestNetworkdUnitWithRoutes(t *testing.T) {
+	table := 100
+	metric := 50
+	priority := 1000
+	in := types.Networkdunit{
+		Name:    "10-eth1.network",
+		Dropins: []types.NetworkdDropin{{Name: "mtu.conf", Contents: "[Link]\nMTUBytes=9000\n"}},
+		Routes: []types.NetworkdRoute{
+			{Destination: "10.1.0.0/16", Gateway: "10.0.0.1", Metric: &metric},
+			{Gateway: "192.0.2.1", GatewayOnLink: true, Table: &table},
+		},
+		RoutingPolicyRules: []types.NetworkdRoutingPolicyRule{
+			{From: "192.0.2.0/24", Priority: &priority, Table: 100},
+		},
+	}
+	out := []types.NetworkdDropin{
+		{Name: "mtu.conf", Contents: "[Link]\nMTUBytes=9000\n"},
+		{
+			Name:     "10-ignition-routes.conf",
+			Contents: "[Route]\nDestination=10.1.0.0/16\nGateway=10.0.0.1\nMetric=50\n\n[Route]\nGateway=192.0.2.1\nGatewayOnLink=yes\nTable=100\n\n[RoutingPolicyRule]\nFrom=192.0.2.0/24\nPriority=1000\nTable=100\n",
+		},
+	}
+
+	got := NetworkdUnitWithRoutes(in)
+	if !reflect.DeepEqual(out, got.Dropins) {
+		t.Errorf("want %+v, got %+v", out, got.Dropins)
+	}
+	if len(in.Dropins) != 1 {
+		t.Errorf("the drop-ins of the unit were changed")
+	}
+}
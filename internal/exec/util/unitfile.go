@@ -0,0 +1,117 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// unitFile holds the settings of a systemd unit and its dropins by section
+// and key, in the order they were assigned. Assigning an empty value
+// clears the values assigned before, as it does for systemd's list
+// settings.
+type unitFile map[string]map[string][]string
+
+// parseUnit parses the contents of a unit followed by the contents of its
+// dropins.
+func parseUnit(contents ...string) (unitFile, error) {
+	f := unitFile{}
+	for _, c := range contents {
+		section := ""
+		lines := strings.Split(c, "\n")
+		for i := 0; i < len(lines); i++ {
+			n := i + 1
+			line := strings.TrimSpace(lines[i])
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+			for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+				i++
+				line = strings.TrimSpace(strings.TrimSuffix(line, "\\")) + " " + strings.TrimSpace(lines[i])
+			}
+
+			if line[0] == '[' {
+				if !strings.HasSuffix(line, "]") {
+					return nil, fmt.Errorf("line %d: invalid section header %q", n, line)
+				}
+				section = line[1 : len(line)-1]
+				if f[section] == nil {
+					f[section] = map[string][]string{}
+				}
+				continue
+			}
+			if section == "" {
+				return nil, fmt.Errorf("line %d: assignment outside of a section", n)
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: expected an assignment, got %q", n, line)
+			}
+			key := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			if value == "" {
+				f[section][key] = []string{}
+			} else {
+				f[section][key] = append(f[section][key], value)
+			}
+		}
+	}
+	return f, nil
+}
+
+// last returns the value last assigned to key, which is the one systemd
+// uses for settings taking a single value.
+func (f unitFile) last(section, key string) string {
+	values := f[section][key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// all returns all values assigned to key.
+func (f unitFile) all(section, key string) []string {
+	return f[section][key]
+}
+
+// list returns the space separated words of all values assigned to key.
+func (f unitFile) list(section, key string) []string {
+	var words []string
+	for _, value := range f[section][key] {
+		words = append(words, strings.Fields(value)...)
+	}
+	return words
+}
+
+// unknownKeys returns the settings which aren't listed in known, as
+// "[Section] Key". A section listed with a nil map is ignored as a whole.
+func (f unitFile) unknownKeys(known map[string]map[string]bool) []string {
+	var unknown []string
+	for section, keys := range f {
+		knownKeys, ok := known[section]
+		if ok && knownKeys == nil {
+			continue
+		}
+		for key := range keys {
+			if !knownKeys[key] {
+				unknown = append(unknown, fmt.Sprintf("[%s] %s", section, key))
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
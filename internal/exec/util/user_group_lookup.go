@@ -16,7 +16,8 @@
 
 package util
 
-// See blkid.go for compiler warning comment.
+// We want at least this warning, since the default C behavior of
+// assuming int foo(int) is totally broken.
 
 // #cgo CFLAGS: -Werror=implicit-function-declaration
 // #include "user_group_lookup.h"
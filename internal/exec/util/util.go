@@ -16,6 +16,7 @@ package util
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -33,6 +34,9 @@ type Util struct {
 	Root    string // path to rootfs for resolving uids and gids
 	IsRoot  bool   // whether or not DestDir is the root filesystem
 	Fetcher resource.Fetcher
+	Managed *ManagedPaths // collects the paths written, if set
+	// Prefetched holds the contents of files fetched ahead, if set
+	Prefetched *Prefetcher
 	*log.Logger
 }
 
@@ -100,3 +104,41 @@ func (u Util) JoinPath(path ...string) (string, error) {
 
 	return filepath.Join(u.DestDir, realpath, last), nil
 }
+
+// maxFollowedLinks is the most symlinks FollowPath follows.
+const maxFollowedLinks = 40
+
+// FollowPath returns a path into the context like JoinPath, following the
+// last element of the path too while it's a symlink, so that it refers to
+// what the symlink points to within the context.
+func (u Util) FollowPath(path string) (string, error) {
+	target, err := u.JoinPath(path)
+	if err != nil {
+		return "", err
+	}
+	for i := 0; ; i++ {
+		info, err := os.Lstat(target)
+		if os.IsNotExist(err) {
+			return target, nil
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return target, nil
+		}
+		if i == maxFollowedLinks {
+			return "", fmt.Errorf("too many levels of symlinks at %q", path)
+		}
+		dest, err := os.Readlink(target)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(filepath.Dir(path), dest)
+		}
+		if target, err = u.JoinPath(dest); err != nil {
+			return "", err
+		}
+		path = dest
+	}
+}
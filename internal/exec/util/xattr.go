@@ -0,0 +1,101 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"syscall"
+
+	"github.com/flatcar/ignition/config/shared/capabilities"
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+// Xattr is an extended attribute of a file, with its value decoded.
+type Xattr struct {
+	Name  string
+	Value []byte
+}
+
+// FileXattrs returns the extended attributes of the file, including the
+// security.capability xattr granting its capabilities. Their values are
+// bytes as the kernel stores them, independent of the architecture.
+func FileXattrs(f types.File) ([]Xattr, error) {
+	var res []Xattr
+	for _, x := range f.Xattrs {
+		value, err := base64.StdEncoding.DecodeString(x.Value)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Xattr{Name: x.Name, Value: value})
+	}
+	if c := f.Capabilities; c != nil {
+		value, err := capabilities.Encode(c.Effective, c.Permitted, c.Inheritable)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Xattr{Name: capabilities.Xattr, Value: value})
+	}
+	return res, nil
+}
+
+// SetXattrs sets the extended attributes of the file at path. Changing the
+// owner of a file drops its capabilities, so they have to be set after it.
+func SetXattrs(path string, xattrs []Xattr) error {
+	for _, x := range xattrs {
+		if err := syscall.Setxattr(path, x.Name, x.Value, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// XattrsChanged returns the names of the extended attributes of the file at
+// path which are missing or have other values.
+func XattrsChanged(path string, xattrs []Xattr) ([]string, error) {
+	var changed []string
+	for _, x := range xattrs {
+		value, err := getXattr(path, x.Name)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil || !bytes.Equal(value, x.Value) {
+			changed = append(changed, x.Name)
+		}
+	}
+	return changed, nil
+}
+
+// getXattr returns the value of the extended attribute of the file at path,
+// or nil if it doesn't have it.
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, name, nil)
+		if err == syscall.ENODATA || err == syscall.ENOTSUP {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size)
+		n, err := syscall.Getxattr(path, name, value)
+		if err == syscall.ERANGE {
+			// it grew in between
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	v1 "github.com/flatcar/ignition/config/v1/types"
+	v2_0 "github.com/flatcar/ignition/config/v2_0/types"
+	v2_1 "github.com/flatcar/ignition/config/v2_1/types"
+	v2_2 "github.com/flatcar/ignition/config/v2_2/types"
+	v2_3 "github.com/flatcar/ignition/config/v2_3/types"
+	v2_4 "github.com/flatcar/ignition/config/v2_4/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/exec/stages"
+	"github.com/flatcar/ignition/internal/fips"
+	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/resource"
+	"github.com/flatcar/ignition/internal/version"
+)
+
+// features describes what this build of Ignition supports, so that whoever
+// is about to send it a config can check that it will be understood.
+type features struct {
+	Version       string          `json:"version"`
+	SpecVersions  []string        `json:"specVersions"`
+	Providers     []string        `json:"providers"`
+	Stages        []string        `json:"stages"`
+	Schemes       []string        `json:"schemes"`
+	Compressions  []string        `json:"compressions"`
+	Hashes        []string        `json:"hashes"`
+	NetworkStacks []string        `json:"networkStacks"`
+	Flags         map[string]bool `json:"flags"`
+}
+
+func getFeatures() (features, error) {
+	stageOrder, err := stages.Ordered()
+	if err != nil {
+		return features{}, err
+	}
+	return features{
+		Version: version.Raw,
+		SpecVersions: []string{
+			v1.MaxVersion.String(),
+			v2_0.MaxVersion.String(),
+			v2_1.MaxVersion.String(),
+			v2_2.MaxVersion.String(),
+			v2_3.MaxVersion.String(),
+			v2_4.MaxVersion.String(),
+		},
+		Providers:     oem.Names(),
+		Stages:        stageOrder,
+		Schemes:       resource.Schemes,
+		Compressions:  resource.Compressions,
+		Hashes:        []string{"sha512"},
+		NetworkStacks: resource.NetworkStacks(),
+		// the settings of the distro which change what configs do, as in
+		// effect in this environment
+		Flags: map[string]bool{
+			"confineFiles":              distro.ConfineFiles(),
+			"fips":                      fips.Enabled(),
+			"nativeGPT":                 distro.NativeGPT(),
+			"privsepFetch":              distro.PrivsepFetch(),
+			"proxyAutoDetect":           distro.ProxyAutoDetect(),
+			"remountReadOnly":           distro.RemountReadOnly(),
+			"requireConfigVerification": distro.RequireConfigVerification(),
+			"restrictedExec":            distro.RestrictedExec(),
+			"selinuxRelabel":            distro.SelinuxRelabel(),
+		},
+	}, nil
+}
+
+// runVersion implements "ignition version", which prints the version or,
+// with --features, what this build supports as JSON. It returns the exit
+// status.
+func runVersion(args []string) int {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	withFeatures := flags.Bool("features", false, "print the supported features as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %v\n", flags.Args())
+		return 2
+	}
+	if !*withFeatures {
+		fmt.Printf("%s\n", version.String)
+		return 0
+	}
+
+	f, err := getFeatures()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 3
+	}
+	if err := writeFeatures(os.Stdout, f); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 3
+	}
+	return 0
+}
+
+// writeFeatures writes f as JSON indented by two spaces, the format external
+// tooling parses.
+func writeFeatures(w io.Writer, f features) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestWriteFeatures checks the format of "ignition version --features",
+// which external tooling parses.
+func TestWriteFeatures(t *testing.T) {
+	f := features{
+		Version:       "v2.4.0",
+		SpecVersions:  []string{"1.0.0", "2.4.0"},
+		Providers:     []string{"ec2", "gce"},
+		Stages:        []string{"fetch", "disks", "files"},
+		Schemes:       []string{"http", "https"},
+		Compressions:  []string{"gzip"},
+		Hashes:        []string{"sha512"},
+		NetworkStacks: []string{"kernel"},
+		Flags:         map[string]bool{"restrictedExec": true, "fips": false},
+	}
+	want := `{
+  "version": "v2.4.0",
+  "specVersions": [
+    "1.0.0",
+    "2.4.0"
+  ],
+  "providers": [
+    "ec2",
+    "gce"
+  ],
+  "stages": [
+    "fetch",
+    "disks",
+    "files"
+  ],
+  "schemes": [
+    "http",
+    "https"
+  ],
+  "compressions": [
+    "gzip"
+  ],
+  "hashes": [
+    "sha512"
+  ],
+  "networkStacks": [
+    "kernel"
+  ],
+  "flags": {
+    "fips": false,
+    "restrictedExec": true
+  }
+}
+`
+	var buf bytes.Buffer
+	if err := writeFeatures(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("bad output: want %q, got %q", want, buf.String())
+	}
+}
+
+func TestGetFeatures(t *testing.T) {
+	os.Setenv("IGNITION_RESTRICTED_EXEC", "true")
+	defer os.Unsetenv("IGNITION_RESTRICTED_EXEC")
+
+	f, err := getFeatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeFeatures(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys are in a fixed order, and every list is there even if
+	// it's empty
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &top); err != nil {
+		t.Fatalf("output isn't JSON: %v", err)
+	}
+	var keys []string
+	dec := json.NewDecoder(&buf)
+	dec.Token()
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+		if string(value) == "null" {
+			t.Errorf("%q is null", key)
+		}
+	}
+	if want := []string{"version", "specVersions", "providers", "stages", "schemes", "compressions", "hashes", "networkStacks", "flags"}; !reflect.DeepEqual(want, keys) {
+		t.Errorf("bad keys: want %v, got %v", want, keys)
+	}
+
+	var flags map[string]bool
+	if err := json.Unmarshal(top["flags"], &flags); err != nil {
+		t.Fatalf("flags aren't booleans: %v", err)
+	}
+	var names []string
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"confineFiles", "fips", "nativeGPT", "privsepFetch", "proxyAutoDetect", "remountReadOnly", "requireConfigVerification", "restrictedExec", "selinuxRelabel"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("bad flags: want %v, got %v", want, names)
+	}
+	if !flags["restrictedExec"] {
+		t.Errorf("restrictedExec doesn't reflect the environment")
+	}
+	if want := []string{"1.0.0", "2.0.0", "2.1.0", "2.2.0", "2.3.0", "2.4.0"}; !reflect.DeepEqual(want, f.SpecVersions) {
+		t.Errorf("bad spec versions: want %v, got %v", want, f.SpecVersions)
+	}
+	// the stages are listed in the order they run in
+	if len(f.Stages) == 0 || f.Stages[0] != "fetch" {
+		t.Errorf("bad stages: %v", f.Stages)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fips restricts the cryptography Ignition uses to algorithms
+// approved by FIPS 140 when the kernel is in FIPS mode.
+package fips
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// approvedHashes are the hash functions configs may use for verification.
+var approvedHashes = map[string]bool{
+	"sha256": true,
+	"sha384": true,
+	"sha512": true,
+}
+
+// approvedSSHHostKeyTypes are the host key types sshd accepts in FIPS mode.
+var approvedSSHHostKeyTypes = map[string]bool{
+	"ecdsa": true,
+	"rsa":   true,
+}
+
+// sshAlgorithms are the approved algorithms of OpenSSH, by the options
+// listing them.
+var sshAlgorithms = []struct {
+	option     string
+	algorithms []string
+}{
+	{"Ciphers", []string{"aes256-gcm@openssh.com", "aes128-gcm@openssh.com", "aes256-ctr", "aes192-ctr", "aes128-ctr"}},
+	{"KexAlgorithms", []string{"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521", "diffie-hellman-group-exchange-sha256", "diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512", "diffie-hellman-group18-sha512"}},
+	{"MACs", []string{"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256", "hmac-sha2-512"}},
+	{"HostKeyAlgorithms", []string{"ecdsa-sha2-nistp256", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521", "rsa-sha2-512", "rsa-sha2-256"}},
+}
+
+// Enabled reports whether the kernel is in FIPS mode.
+func Enabled() bool {
+	b, err := ioutil.ReadFile(distro.FIPSEnabledPath())
+	return err == nil && strings.TrimSpace(string(b)) == "1"
+}
+
+// ApprovedHash reports whether the hash function of the given name is
+// approved.
+func ApprovedHash(name string) bool {
+	return approvedHashes[name]
+}
+
+// ApprovedSSHHostKeyType reports whether SSH host keys of the given type
+// can be used in FIPS mode.
+func ApprovedSSHHostKeyType(typ string) bool {
+	return approvedSSHHostKeyTypes[typ]
+}
+
+// ApprovedSSHHostKeyTypes returns the SSH host key types which can be used in
+// FIPS mode, sorted.
+func ApprovedSSHHostKeyTypes() []string {
+	var types []string
+	for typ := range approvedSSHHostKeyTypes {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RestrictTLS limits c to approved versions, cipher suites and curves. TLS
+// 1.3 is disabled, as Go doesn't allow restricting its cipher suites.
+func RestrictTLS(c *tls.Config) {
+	c.MinVersion = tls.VersionTLS12
+	c.MaxVersion = tls.VersionTLS12
+	c.CipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	}
+	c.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+}
+
+// RestrictSSH returns the -o options limiting the ssh or sftp command of
+// OpenSSH to approved ciphers, key exchanges, MACs and host key algorithms.
+func RestrictSSH() []string {
+	var args []string
+	for _, a := range sshAlgorithms {
+		args = append(args, "-o", a.option+"="+strings.Join(a.algorithms, ","))
+	}
+	return args
+}
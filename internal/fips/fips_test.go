@@ -0,0 +1,84 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fips
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fips_enabled")
+	os.Setenv("IGNITION_FIPS_ENABLED_PATH", path)
+	defer os.Unsetenv("IGNITION_FIPS_ENABLED_PATH")
+
+	tests := []struct {
+		contents *string
+		enabled  bool
+	}{
+		{nil, false},
+		{strPtr("0\n"), false},
+		{strPtr("1\n"), true},
+	}
+
+	for i, test := range tests {
+		os.Remove(path)
+		if test.contents != nil {
+			if err := ioutil.WriteFile(path, []byte(*test.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if enabled := Enabled(); enabled != test.enabled {
+			t.Errorf("#%d: bad result: want %v, got %v", i, test.enabled, enabled)
+		}
+	}
+}
+
+func TestRestrictTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := server.Client().Transport.(*http.Transport)
+	RestrictTLS(transport.TLSClientConfig)
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.TLS.Version != tls.VersionTLS12 {
+		t.Errorf("bad TLS version: want %x, got %x", tls.VersionTLS12, resp.TLS.Version)
+	}
+	approved := false
+	for _, suite := range transport.TLSClientConfig.CipherSuites {
+		approved = approved || suite == resp.TLS.CipherSuite
+	}
+	if !approved {
+		t.Errorf("unapproved cipher suite %s", tls.CipherSuiteName(resp.TLS.CipherSuite))
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/flatcar/ignition/internal/config"
+)
+
+// runFmt implements "ignition fmt", which rewrites configs in canonical form
+// so that diffs between their revisions reflect real changes. It returns the
+// exit status: 0 on success, 1 if -l listed configs which aren't formatted,
+// 2 for bad usage and 3 if a config couldn't be formatted.
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result to the config files instead of stdout")
+	list := flags.Bool("l", false, "list the config files which aren't formatted instead of printing them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ignition fmt [-w] [-l] [config ...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		if *write {
+			fmt.Fprint(os.Stderr, "-w needs config files to write to\n")
+			return 2
+		}
+		paths = []string{"-"}
+	}
+
+	status := 0
+	for _, path := range paths {
+		var raw []byte
+		var err error
+		if path == "-" {
+			raw, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			raw, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s: %v\n", path, err)
+			status = 3
+			continue
+		}
+		out, rpt, err := config.Format(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to format %s: %v\n%s", path, err, rpt)
+			status = 3
+			continue
+		}
+		changed := !bytes.Equal(raw, out)
+		switch {
+		case *list:
+			if changed {
+				fmt.Println(path)
+				if status == 0 {
+					status = 1
+				}
+			}
+		case *write && path != "-":
+			if !changed {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+				status = 3
+				continue
+			}
+			if err := ioutil.WriteFile(path, out, info.Mode().Perm()); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+				status = 3
+			}
+		default:
+			os.Stdout.Write(out)
+		}
+	}
+	return status
+}
@@ -0,0 +1,516 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gpt reads and writes GUID partition tables, so disks can be
+// partitioned without sgdisk. It implements the subset of sgdisk's behavior
+// Ignition relies on: deleting and creating partitions, resolving default
+// starts, sizes and numbers, and wiping the table.
+package gpt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	headerSize    = 92
+	entrySize     = 128
+	defaultNumber = 128
+	nameLength    = 36
+
+	// sgdisk's default type code 8300
+	linuxFilesystemGUID = "0FC63DAF-8483-4772-8E79-3D69D8477DE4"
+)
+
+var (
+	ErrNoTable       = errors.New("no valid GUID partition table found")
+	ErrMBR           = errors.New("disk has an MBR partition table, which can only be converted by sgdisk")
+	ErrNoSpace       = errors.New("no free space for the partition")
+	ErrNoFreeNumber  = errors.New("no free partition number")
+	ErrBadGUID       = errors.New("invalid GUID")
+	ErrNameTooLong   = errors.New("partition name is longer than 36 UTF-16 code units")
+	ErrNumberInUse   = errors.New("partition number is already in use")
+	ErrNumberInvalid = errors.New("partition number is out of range")
+
+	signature = []byte("EFI PART")
+)
+
+// Partition is an entry of a partition table. Start and End are inclusive
+// sector numbers.
+type Partition struct {
+	Number     int
+	Start      uint64
+	End        uint64
+	TypeGUID   string
+	GUID       string
+	Name       string
+	Attributes uint64
+}
+
+// Size returns the size of p in sectors.
+func (p Partition) Size() uint64 {
+	return p.End - p.Start + 1
+}
+
+// Table is a GUID partition table of a disk.
+type Table struct {
+	SectorSize  uint64
+	Sectors     uint64
+	DiskGUID    string
+	FirstUsable uint64
+	LastUsable  uint64
+
+	numEntries uint32
+	entriesLBA uint64
+	bootCode   []byte
+	partitions map[int]Partition
+}
+
+// New returns an empty table for a disk of the given size in bytes.
+func New(diskSize, sectorSize uint64) (*Table, error) {
+	guid, err := randomGUID()
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{
+		SectorSize: sectorSize,
+		Sectors:    diskSize / sectorSize,
+		DiskGUID:   guid,
+		numEntries: defaultNumber,
+		entriesLBA: 2,
+		partitions: map[int]Partition{},
+	}
+	t.FirstUsable = t.entriesLBA + t.entriesSectors()
+	t.LastUsable = t.Sectors - 2 - t.entriesSectors()
+	if t.Sectors <= t.FirstUsable+t.entriesSectors()+1 {
+		return nil, fmt.Errorf("disk of %d bytes is too small for a partition table", diskSize)
+	}
+	return t, nil
+}
+
+// Read reads the partition table of a disk of the given size in bytes. If the
+// primary table is damaged, the backup table is used. If the disk grew since
+// the table was written, the usable area is extended to its end, moving the
+// backup table there on the next Write.
+func Read(r io.ReaderAt, diskSize, sectorSize uint64) (*Table, error) {
+	sectors := diskSize / sectorSize
+	if sectors < 3 {
+		return nil, ErrNoTable
+	}
+
+	mbr := make([]byte, sectorSize)
+	if _, err := r.ReadAt(mbr, 0); err != nil {
+		return nil, err
+	}
+
+	t, err := readHeader(r, 1, sectorSize)
+	if err != nil {
+		t, err = readHeader(r, sectors-1, sectorSize)
+	}
+	if err != nil {
+		if hasMBRPartitions(mbr) {
+			return nil, ErrMBR
+		}
+		return nil, ErrNoTable
+	}
+
+	t.Sectors = sectors
+	t.bootCode = mbr[:440]
+	// the primary entries might have been read from the backup header
+	t.entriesLBA = 2
+	if last := sectors - 2 - t.entriesSectors(); t.LastUsable < last {
+		t.LastUsable = last
+	} else if t.LastUsable > last {
+		return nil, fmt.Errorf("partition table extends beyond the end of the disk")
+	}
+	return t, nil
+}
+
+func readHeader(r io.ReaderAt, lba, sectorSize uint64) (*Table, error) {
+	header := make([]byte, sectorSize)
+	if _, err := r.ReadAt(header, int64(lba*sectorSize)); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], signature) {
+		return nil, ErrNoTable
+	}
+	size := binary.LittleEndian.Uint32(header[12:])
+	if size < headerSize || uint64(size) > sectorSize {
+		return nil, ErrNoTable
+	}
+	crc := binary.LittleEndian.Uint32(header[16:])
+	binary.LittleEndian.PutUint32(header[16:], 0)
+	if crc32.ChecksumIEEE(header[:size]) != crc {
+		return nil, ErrNoTable
+	}
+
+	t := &Table{
+		SectorSize:  sectorSize,
+		FirstUsable: binary.LittleEndian.Uint64(header[40:]),
+		LastUsable:  binary.LittleEndian.Uint64(header[48:]),
+		DiskGUID:    decodeGUID(header[56:72]),
+		entriesLBA:  binary.LittleEndian.Uint64(header[72:]),
+		numEntries:  binary.LittleEndian.Uint32(header[80:]),
+		partitions:  map[int]Partition{},
+	}
+	if binary.LittleEndian.Uint32(header[84:]) != entrySize || t.numEntries == 0 || t.numEntries > 1024 {
+		return nil, ErrNoTable
+	}
+
+	entries := make([]byte, t.entriesSectors()*sectorSize)
+	if _, err := r.ReadAt(entries, int64(t.entriesLBA*sectorSize)); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(entries[:t.numEntries*entrySize]) != binary.LittleEndian.Uint32(header[88:]) {
+		return nil, ErrNoTable
+	}
+	for i := 0; i < int(t.numEntries); i++ {
+		e := entries[i*entrySize : (i+1)*entrySize]
+		if isZero(e[:16]) {
+			continue
+		}
+		t.partitions[i+1] = Partition{
+			Number:     i + 1,
+			TypeGUID:   decodeGUID(e[0:16]),
+			GUID:       decodeGUID(e[16:32]),
+			Start:      binary.LittleEndian.Uint64(e[32:]),
+			End:        binary.LittleEndian.Uint64(e[40:]),
+			Attributes: binary.LittleEndian.Uint64(e[48:]),
+			Name:       decodeName(e[56:128]),
+		}
+	}
+	return t, nil
+}
+
+// Partitions returns the partitions of t, ordered by number.
+func (t *Table) Partitions() []Partition {
+	parts := make([]Partition, 0, len(t.partitions))
+	for _, p := range t.partitions {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts
+}
+
+// Partition returns the partition with the given number, if it exists.
+func (t *Table) Partition(number int) (Partition, bool) {
+	p, ok := t.partitions[number]
+	return p, ok
+}
+
+// Delete deletes the partition with the given number, if it exists.
+func (t *Table) Delete(number int) {
+	delete(t.partitions, number)
+}
+
+// Create adds p to the table and returns it as created. Like with sgdisk, a
+// zero Number uses the lowest free number, a zero Start uses the first
+// sector of the largest free block, and a zero size extends the partition to
+// the end of the free block its start is in. Starts are aligned to 1 MiB.
+func (t *Table) Create(p Partition, size uint64) (Partition, error) {
+	if p.Number == 0 {
+		for n := 1; n <= int(t.numEntries); n++ {
+			if _, ok := t.partitions[n]; !ok {
+				p.Number = n
+				break
+			}
+		}
+		if p.Number == 0 {
+			return Partition{}, ErrNoFreeNumber
+		}
+	} else if p.Number < 0 || p.Number > int(t.numEntries) {
+		return Partition{}, ErrNumberInvalid
+	} else if _, ok := t.partitions[p.Number]; ok {
+		return Partition{}, ErrNumberInUse
+	}
+
+	free := t.freeBlocks()
+	if len(free) == 0 {
+		return Partition{}, ErrNoSpace
+	}
+	largest := free[0]
+	for _, b := range free[1:] {
+		if b.Size() > largest.Size() {
+			largest = b
+		}
+	}
+
+	if p.Start == 0 {
+		p.Start = largest.Start
+	}
+	p.Start = t.align(p.Start)
+	block, ok := findBlock(free, p.Start)
+	if !ok {
+		return Partition{}, fmt.Errorf("partition %d: start sector %d is not free", p.Number, p.Start)
+	}
+	if size == 0 {
+		p.End = block.End
+	} else {
+		p.End = p.Start + size - 1
+		if p.End > block.End {
+			return Partition{}, fmt.Errorf("partition %d: %d sectors starting at sector %d don't fit in the free space", p.Number, size, p.Start)
+		}
+	}
+
+	if p.TypeGUID == "" {
+		p.TypeGUID = linuxFilesystemGUID
+	}
+	if p.GUID == "" {
+		guid, err := randomGUID()
+		if err != nil {
+			return Partition{}, err
+		}
+		p.GUID = guid
+	}
+	if _, err := encodeGUID(p.TypeGUID); err != nil {
+		return Partition{}, err
+	}
+	if _, err := encodeGUID(p.GUID); err != nil {
+		return Partition{}, err
+	}
+	if len(utf16.Encode([]rune(p.Name))) > nameLength {
+		return Partition{}, ErrNameTooLong
+	}
+
+	t.partitions[p.Number] = p
+	return p, nil
+}
+
+// SectorsForMiB returns the number of sectors in n MiB.
+func (t *Table) SectorsForMiB(n uint64) uint64 {
+	return n * 1024 * 1024 / t.SectorSize
+}
+
+// align rounds sector up to the next 1 MiB boundary, as sgdisk does by default.
+func (t *Table) align(sector uint64) uint64 {
+	alignment := t.SectorsForMiB(1)
+	if alignment <= 1 {
+		return sector
+	}
+	return (sector + alignment - 1) / alignment * alignment
+}
+
+func (t *Table) freeBlocks() []Partition {
+	parts := t.Partitions()
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Start < parts[j].Start })
+
+	var free []Partition
+	next := t.FirstUsable
+	for _, p := range parts {
+		if p.Start > next {
+			free = append(free, Partition{Start: next, End: p.Start - 1})
+		}
+		if p.End+1 > next {
+			next = p.End + 1
+		}
+	}
+	if next <= t.LastUsable {
+		free = append(free, Partition{Start: next, End: t.LastUsable})
+	}
+	return free
+}
+
+func findBlock(free []Partition, sector uint64) (Partition, bool) {
+	for _, b := range free {
+		if b.Start <= sector && sector <= b.End {
+			return b, true
+		}
+	}
+	return Partition{}, false
+}
+
+// Write writes the protective MBR and the primary and backup tables.
+func (t *Table) Write(w io.WriterAt) error {
+	entries := make([]byte, t.entriesSectors()*t.SectorSize)
+	for n, p := range t.partitions {
+		e := entries[(n-1)*entrySize : n*entrySize]
+		typeGUID, err := encodeGUID(p.TypeGUID)
+		if err != nil {
+			return err
+		}
+		guid, err := encodeGUID(p.GUID)
+		if err != nil {
+			return err
+		}
+		copy(e[0:], typeGUID)
+		copy(e[16:], guid)
+		binary.LittleEndian.PutUint64(e[32:], p.Start)
+		binary.LittleEndian.PutUint64(e[40:], p.End)
+		binary.LittleEndian.PutUint64(e[48:], p.Attributes)
+		copy(e[56:], encodeName(p.Name))
+	}
+	entriesCRC := crc32.ChecksumIEEE(entries[:t.numEntries*entrySize])
+
+	last := t.Sectors - 1
+	backupEntriesLBA := last - t.entriesSectors()
+	primary, err := t.header(1, last, t.entriesLBA, entriesCRC)
+	if err != nil {
+		return err
+	}
+	backup, err := t.header(last, 1, backupEntriesLBA, entriesCRC)
+	if err != nil {
+		return err
+	}
+
+	writes := []struct {
+		lba  uint64
+		data []byte
+	}{
+		{0, t.protectiveMBR()},
+		{t.entriesLBA, entries},
+		{1, primary},
+		{backupEntriesLBA, entries},
+		{last, backup},
+	}
+	for _, write := range writes {
+		if _, err := w.WriteAt(write.data, int64(write.lba*t.SectorSize)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Table) header(lba, alternate, entriesLBA uint64, entriesCRC uint32) ([]byte, error) {
+	h := make([]byte, t.SectorSize)
+	copy(h, signature)
+	binary.LittleEndian.PutUint32(h[8:], 0x00010000)
+	binary.LittleEndian.PutUint32(h[12:], headerSize)
+	binary.LittleEndian.PutUint64(h[24:], lba)
+	binary.LittleEndian.PutUint64(h[32:], alternate)
+	binary.LittleEndian.PutUint64(h[40:], t.FirstUsable)
+	binary.LittleEndian.PutUint64(h[48:], t.LastUsable)
+	guid, err := encodeGUID(t.DiskGUID)
+	if err != nil {
+		return nil, err
+	}
+	copy(h[56:], guid)
+	binary.LittleEndian.PutUint64(h[72:], entriesLBA)
+	binary.LittleEndian.PutUint32(h[80:], t.numEntries)
+	binary.LittleEndian.PutUint32(h[84:], entrySize)
+	binary.LittleEndian.PutUint32(h[88:], entriesCRC)
+	binary.LittleEndian.PutUint32(h[16:], crc32.ChecksumIEEE(h[:headerSize]))
+	return h, nil
+}
+
+// protectiveMBR returns an MBR with a single partition of type 0xEE spanning
+// the disk, keeping any existing boot code.
+func (t *Table) protectiveMBR() []byte {
+	mbr := make([]byte, t.SectorSize)
+	copy(mbr, t.bootCode)
+	e := mbr[446:462]
+	e[1], e[2], e[3] = 0x00, 0x02, 0x00 // CHS of sector 1
+	e[4] = 0xee
+	e[5], e[6], e[7] = 0xff, 0xff, 0xff
+	binary.LittleEndian.PutUint32(e[8:], 1)
+	size := t.Sectors - 1
+	if size > 0xffffffff {
+		size = 0xffffffff
+	}
+	binary.LittleEndian.PutUint32(e[12:], uint32(size))
+	mbr[510], mbr[511] = 0x55, 0xaa
+	return mbr
+}
+
+func (t *Table) entriesSectors() uint64 {
+	return (uint64(t.numEntries)*entrySize + t.SectorSize - 1) / t.SectorSize
+}
+
+// hasMBRPartitions returns whether mbr is a valid MBR with partitions other
+// than a protective one.
+func hasMBRPartitions(mbr []byte) bool {
+	if len(mbr) < 512 || mbr[510] != 0x55 || mbr[511] != 0xaa {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		if typ := mbr[446+i*16+4]; typ != 0 && typ != 0xee {
+			return true
+		}
+	}
+	return false
+}
+
+// GUIDs are stored with their first three fields little endian.
+func encodeGUID(s string) ([]byte, error) {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return nil, ErrBadGUID
+	}
+	raw, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil {
+		return nil, ErrBadGUID
+	}
+	b := make([]byte, 16)
+	b[0], b[1], b[2], b[3] = raw[3], raw[2], raw[1], raw[0]
+	b[4], b[5] = raw[5], raw[4]
+	b[6], b[7] = raw[7], raw[6]
+	copy(b[8:], raw[8:])
+	return b, nil
+}
+
+func decodeGUID(b []byte) string {
+	return fmt.Sprintf("%02X%02X%02X%02X-%02X%02X-%02X%02X-%02X%02X-%02X%02X%02X%02X%02X%02X",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6],
+		b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15])
+}
+
+func randomGUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	// version 4, variant 1
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func encodeName(name string) []byte {
+	b := make([]byte, nameLength*2)
+	for i, u := range utf16.Encode([]rune(name)) {
+		if i == nameLength {
+			break
+		}
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func decodeName(b []byte) string {
+	units := make([]uint16, 0, nameLength)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := binary.LittleEndian.Uint16(b[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpt
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const mib = 1024 * 1024
+
+func tempDisk(t *testing.T, size int64) *os.File {
+	f, err := ioutil.TempFile("", "ignition-gpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCreate(t *testing.T) {
+	type part struct {
+		number      int
+		start, size uint64
+	}
+	type in struct {
+		parts []part
+	}
+	type out struct {
+		parts []Partition
+		err   bool
+	}
+
+	// a 100 MiB disk has sectors 34 to 204766 usable
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{parts: []part{{number: 1}}},
+			out: out{parts: []Partition{{Number: 1, Start: 2048, End: 204766}}},
+		},
+		{
+			in: in{parts: []part{{number: 1, size: 20480}, {}}},
+			out: out{parts: []Partition{
+				{Number: 1, Start: 2048, End: 22527},
+				{Number: 2, Start: 22528, End: 204766},
+			}},
+		},
+		{
+			// starts are aligned to 1 MiB
+			in:  in{parts: []part{{number: 3, start: 34, size: 100}}},
+			out: out{parts: []Partition{{Number: 3, Start: 2048, End: 2147}}},
+		},
+		{
+			// size 0 fills the block the start is in, whichever it is
+			in: in{parts: []part{{number: 1, start: 4096, size: 2048}, {number: 2, start: 2048}, {number: 3}}},
+			out: out{parts: []Partition{
+				{Number: 1, Start: 4096, End: 6143},
+				{Number: 2, Start: 2048, End: 4095},
+				{Number: 3, Start: 6144, End: 204766},
+			}},
+		},
+		{
+			in:  in{parts: []part{{number: 1, size: 20480}, {number: 1}}},
+			out: out{err: true},
+		},
+		{
+			in:  in{parts: []part{{number: 1, size: 204800}}},
+			out: out{err: true},
+		},
+		{
+			in:  in{parts: []part{{number: 129}}},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		table, err := New(100*mib, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range test.in.parts {
+			_, err = table.Create(Partition{Number: p.number, Start: p.start}, p.size)
+			if err != nil {
+				break
+			}
+		}
+		if (err != nil) != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			continue
+		}
+		if test.out.err {
+			continue
+		}
+		got := table.Partitions()
+		for j := range got {
+			got[j].TypeGUID, got[j].GUID = "", ""
+		}
+		if !reflect.DeepEqual(test.out.parts, got) {
+			t.Errorf("#%d: bad partitions: want %+v, got %+v", i, test.out.parts, got)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	f := tempDisk(t, 100*mib)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	table, err := New(100*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := []Partition{
+		{Number: 1, TypeGUID: "C12A7328-F81F-11D2-BA4B-00A0C93EC3B8", GUID: "7130C94A-213A-4E5A-8E26-6CCE9662F132", Name: "EFI-SYSTEM"},
+		{Number: 9, Name: "ROOT ÄÖÜ"},
+	}
+	sizes := []uint64{20480, 0}
+	for i, p := range parts {
+		if parts[i], err = table.Create(p, sizes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(f, 100*mib, 512)
+	if err != nil {
+		t.Fatalf("reading the table failed: %v", err)
+	}
+	if !reflect.DeepEqual(parts, read.Partitions()) {
+		t.Errorf("bad partitions: want %+v, got %+v", parts, read.Partitions())
+	}
+	if read.DiskGUID != table.DiskGUID {
+		t.Errorf("bad disk GUID: want %q, got %q", table.DiskGUID, read.DiskGUID)
+	}
+
+	// a damaged primary table is recovered from the backup
+	if _, err := f.WriteAt(make([]byte, 512), 512); err != nil {
+		t.Fatal(err)
+	}
+	read, err = Read(f, 100*mib, 512)
+	if err != nil {
+		t.Fatalf("reading the backup table failed: %v", err)
+	}
+	if !reflect.DeepEqual(parts, read.Partitions()) {
+		t.Errorf("bad partitions from backup: want %+v, got %+v", parts, read.Partitions())
+	}
+
+	// the usable area grows with the disk
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(200 * mib); err != nil {
+		t.Fatal(err)
+	}
+	read, err = Read(f, 200*mib, 512)
+	if err != nil {
+		t.Fatalf("reading the table of the grown disk failed: %v", err)
+	}
+	if want := uint64(200*mib/512 - 34); read.LastUsable != want {
+		t.Errorf("bad last usable sector: want %d, got %d", want, read.LastUsable)
+	}
+}
+
+func TestReadNoTable(t *testing.T) {
+	f := tempDisk(t, 10*mib)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := Read(f, 10*mib, 512); err != ErrNoTable {
+		t.Errorf("bad error for an empty disk: want %v, got %v", ErrNoTable, err)
+	}
+
+	mbr := make([]byte, 512)
+	mbr[446+4] = 0x83
+	mbr[510], mbr[511] = 0x55, 0xaa
+	if _, err := f.WriteAt(mbr, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(f, 10*mib, 512); err != ErrMBR {
+		t.Errorf("bad error for an MBR disk: want %v, got %v", ErrMBR, err)
+	}
+}
+
+func TestGUID(t *testing.T) {
+	guid := "0FC63DAF-8483-4772-8E79-3D69D8477DE4"
+	b, err := encodeGUID(guid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first three fields are stored little endian
+	if want := []byte{0xaf, 0x3d, 0xc6, 0x0f, 0x83, 0x84, 0x72, 0x47, 0x8e, 0x79}; !reflect.DeepEqual(b[:10], want) {
+		t.Errorf("bad encoding: want % x, got % x", want, b[:10])
+	}
+	if got := decodeGUID(b); got != guid {
+		t.Errorf("bad decoding: want %q, got %q", guid, got)
+	}
+	if _, err := encodeGUID("0FC63DAF84834772-8E79-3D69D8477DE4x"); err != ErrBadGUID {
+		t.Errorf("bad error for an invalid GUID: %v", err)
+	}
+}
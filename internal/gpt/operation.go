@@ -0,0 +1,199 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpt
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+const (
+	// from linux/fs.h
+	blkRRPart  = 0x125f
+	blkSSZGet  = 0x1268
+	sectorSize = 512
+)
+
+// Operation is a set of changes to the partition table of a device, carried
+// out like the equivalent sgdisk invocation would.
+type Operation struct {
+	logger    *log.Logger
+	dev       string
+	wipe      bool
+	parts     []types.Partition
+	deletions []int
+}
+
+// Begin begins an operation on dev.
+func Begin(logger *log.Logger, dev string) *Operation {
+	return &Operation{logger: logger, dev: dev}
+}
+
+// CreatePartition adds the supplied partition to the list of partitions to be created as part of an operation.
+func (op *Operation) CreatePartition(p types.Partition) {
+	op.parts = append(op.parts, p)
+}
+
+func (op *Operation) DeletePartition(num int) {
+	op.deletions = append(op.deletions, num)
+}
+
+// WipeTable toggles if the table is to be wiped first when commiting this operation.
+func (op *Operation) WipeTable(wipe bool) {
+	op.wipe = wipe
+}
+
+// Pretend carries out the operation without writing the result, returning the
+// partitions of the resulting table by number.
+func (op *Operation) Pretend() (map[int]Partition, error) {
+	f, err := os.Open(op.dev)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := op.apply(f)
+	if err != nil {
+		return nil, err
+	}
+	result := map[int]Partition{}
+	for _, p := range t.Partitions() {
+		result[p.Number] = p
+	}
+	return result, nil
+}
+
+// Commit commits the operation and has the kernel reread the partition table.
+func (op *Operation) Commit() error {
+	if !op.wipe && len(op.deletions) == 0 && len(op.parts) == 0 {
+		return nil
+	}
+	return op.logger.LogOp(func() error {
+		f, err := os.OpenFile(op.dev, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		t, err := op.apply(f)
+		if err != nil {
+			return err
+		}
+		if err := t.Write(f); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		if err := ioctl(f, blkRRPart, 0); err != nil {
+			// like sgdisk, leave it to the caller to wait for the
+			// kernel to pick up the changes
+			op.logger.Warning("kernel did not reread the partition table of %q: %v", op.dev, err)
+		}
+		return nil
+	}, "deleting %d partitions and creating %d partitions on %q", len(op.deletions), len(op.parts), op.dev)
+}
+
+// apply reads the partition table of f and applies the operation to it.
+func (op *Operation) apply(f *os.File) (*Table, error) {
+	diskSize, ssz, err := geometry(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var t *Table
+	if !op.wipe {
+		t, err = Read(f, diskSize, ssz)
+	}
+	if op.wipe || err == ErrNoTable {
+		t, err = New(diskSize, ssz)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading partition table of %q: %v", op.dev, err)
+	}
+
+	for _, num := range op.deletions {
+		t.Delete(num)
+	}
+	for _, p := range op.parts {
+		spec := Partition{
+			Number:   p.Number,
+			TypeGUID: p.TypeGUID,
+			GUID:     p.GUID,
+		}
+		if p.Label != nil {
+			spec.Name = *p.Label
+		}
+		var size uint64
+		switch {
+		case p.Start != nil:
+			spec.Start = uint64(*p.Start)
+		case p.StartMiB != nil:
+			spec.Start = t.SectorsForMiB(uint64(*p.StartMiB))
+		}
+		switch {
+		case p.Size != nil:
+			size = uint64(*p.Size)
+		case p.SizeMiB != nil:
+			size = t.SectorsForMiB(uint64(*p.SizeMiB))
+		}
+		if _, err := t.Create(spec, size); err != nil {
+			return nil, fmt.Errorf("creating partition %d on %q: %v", p.Number, op.dev, err)
+		}
+	}
+	return t, nil
+}
+
+// ReadDevice reads the partition table of the device at path.
+func ReadDevice(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	diskSize, ssz, err := geometry(f)
+	if err != nil {
+		return nil, err
+	}
+	return Read(f, diskSize, ssz)
+}
+
+// geometry returns the size in bytes and the logical sector size of the
+// device f. Regular files are assumed to have 512 byte sectors.
+func geometry(f *os.File) (uint64, uint64, error) {
+	diskSize, err := f.Seek(0, os.SEEK_END)
+	if err != nil {
+		return 0, 0, err
+	}
+	ssz := uint64(sectorSize)
+	var blockSSZ int32
+	if err := ioctl(f, blkSSZGet, uintptr(unsafe.Pointer(&blockSSZ))); err == nil && blockSSZ > 0 {
+		ssz = uint64(blockSSZ)
+	}
+	return uint64(diskSize), ssz, nil
+}
+
+func ioctl(f *os.File, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -20,7 +20,12 @@ import (
 	"log/syslog"
 	"os/exec"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/supervisor"
 )
 
 type LoggerOps interface {
@@ -40,6 +45,11 @@ type Logger struct {
 	ops           LoggerOps
 	prefixStack   []string
 	opSequenceNum int
+
+	// opPrefix is prepended to the numbers of the ops of a fork, which
+	// has its own sequence, e.g. "2." for the second fork of a Logger
+	opPrefix string
+	forks    int
 }
 
 // New creates a new logger.
@@ -121,6 +131,22 @@ func (l *Logger) PopPrefix() {
 	l.prefixStack = l.prefixStack[:len(l.prefixStack)-1]
 }
 
+// Fork returns a copy of the Logger for use by a concurrently running
+// goroutine. The copy starts out with the Logger's prefix stack but pushes and
+// pops its own, and numbers its ops below a number of its own, so that the
+// ops of forks can be told apart: the ops of the second fork are op(2.1),
+// op(2.2) and so on. Fork must be called before the goroutine is started,
+// as it isn't safe to call concurrently.
+func (l *Logger) Fork() *Logger {
+	l.forks++
+	fork := *l
+	fork.prefixStack = append([]string(nil), l.prefixStack...)
+	fork.opPrefix = fmt.Sprintf("%s%x.", l.opPrefix, l.forks)
+	fork.opSequenceNum = 0
+	fork.forks = 0
+	return &fork
+}
+
 // QuotedCmd returns a concatenated, quoted form of cmd's cmdline
 func QuotedCmd(cmd *exec.Cmd) string {
 	if len(cmd.Args) == 0 {
@@ -138,16 +164,30 @@ func QuotedCmd(cmd *exec.Cmd) string {
 // LogCmd runs and logs the supplied cmd as an operation with distinct start/finish/fail log messages uniformly combined with the supplied format string.
 // The exact command path and arguments being executed are also logged for debugging assistance.
 func (l *Logger) LogCmd(cmd *exec.Cmd, format string, a ...interface{}) (int, error) {
+	return l.logCmd(cmd, 0, format, a...)
+}
+
+// LogLongCmd is like LogCmd, but for commands which may run for a long time. Every interval it logs that cmd is still
+// running along with the latest line of output cmd produced since, so watchdogs and humans can tell progress from a hang.
+func (l *Logger) LogLongCmd(cmd *exec.Cmd, interval time.Duration, format string, a ...interface{}) (int, error) {
+	return l.logCmd(cmd, interval, format, a...)
+}
+
+func (l *Logger) logCmd(cmd *exec.Cmd, interval time.Duration, format string, a ...interface{}) (int, error) {
 	code := -1
 	f := func() error {
 		cmdLine := QuotedCmd(cmd)
 		l.Debug("executing: %s", cmdLine)
 
-		stdout := &bytes.Buffer{}
-		stderr := &bytes.Buffer{}
+		stdout := &outputBuffer{}
+		stderr := &outputBuffer{}
 		cmd.Stdout = stdout
 		cmd.Stderr = stderr
-		if err := cmd.Run(); err != nil {
+		p, err := supervisor.Start(cmd, distro.HelperTimeout())
+		if err == nil {
+			err = l.waitWithHeartbeat(p.Wait, interval, stdout, stderr)
+		}
+		if err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				code = exitErr.Sys().(syscall.WaitStatus).ExitStatus()
 			}
@@ -159,10 +199,97 @@ func (l *Logger) LogCmd(cmd *exec.Cmd, format string, a ...interface{}) (int, er
 	return code, err
 }
 
+// now and newTicker are the clock of the heartbeats, which tests replace.
+var (
+	now       = time.Now
+	newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		t := time.NewTicker(d)
+		return t.C, t.Stop
+	}
+)
+
+// waitWithHeartbeat calls wait, which waits for a process to exit. If
+// interval is non-zero, it logs every interval that the process is still
+// running.
+func (l *Logger) waitWithHeartbeat(wait func() error, interval time.Duration, stdout, stderr *outputBuffer) error {
+	if interval == 0 {
+		return wait()
+	}
+
+	start := now()
+	done := make(chan error, 1)
+	go func() {
+		done <- wait()
+	}()
+
+	ticks, stop := newTicker(interval)
+	defer stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticks:
+			elapsed := now().Sub(start).Round(time.Second)
+			line := stderr.takeLatest()
+			if out := stdout.takeLatest(); out != "" {
+				line = out
+			}
+			if line != "" {
+				l.Info("still running after %s: %s", elapsed, line)
+			} else {
+				l.Info("still running after %s", elapsed)
+			}
+		}
+	}
+}
+
+// outputBuffer collects the output of a command and keeps track of the latest
+// line written to it. Progress meters commonly redraw their line using
+// carriage returns or backspaces, so these end lines too.
+type outputBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	line   []byte
+	latest string
+}
+
+func (o *outputBuffer) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, c := range p {
+		switch c {
+		case '\n', '\r', '\b':
+			if line := strings.TrimSpace(string(o.line)); line != "" {
+				o.latest = line
+			}
+			o.line = o.line[:0]
+		default:
+			o.line = append(o.line, c)
+		}
+	}
+	return o.buf.Write(p)
+}
+
+// takeLatest returns the latest complete line written since the last call,
+// or "" if there is none.
+func (o *outputBuffer) takeLatest() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	latest := o.latest
+	o.latest = ""
+	return latest
+}
+
+func (o *outputBuffer) Bytes() []byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Bytes()
+}
+
 // LogOp calls and logs the supplied function as an operation with distinct start/finish/fail log messages uniformly combined with the supplied format string.
 func (l *Logger) LogOp(op func() error, format string, a ...interface{}) error {
 	l.opSequenceNum++
-	l.PushPrefix("op(%x)", l.opSequenceNum)
+	l.PushPrefix("op(%s%x)", l.opPrefix, l.opSequenceNum)
 	defer l.PopPrefix()
 
 	l.logStart(format, a...)
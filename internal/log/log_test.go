@@ -0,0 +1,188 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	tests := []struct {
+		in  []string
+		out string
+	}{
+		{
+			in:  []string{"partial"},
+			out: "",
+		},
+		{
+			in:  []string{"first\nsec", "ond\n"},
+			out: "second",
+		},
+		{
+			in:  []string{"Writing inode tables:  1/8\b\b\b", "2/8\b\b\b"},
+			out: "2/8",
+		},
+		{
+			in:  []string{"10%\r20%\r  \r"},
+			out: "20%",
+		},
+	}
+
+	for i, test := range tests {
+		var o outputBuffer
+		for _, w := range test.in {
+			o.Write([]byte(w))
+		}
+		if latest := o.takeLatest(); latest != test.out {
+			t.Errorf("#%d: bad latest line: want %q, got %q", i, test.out, latest)
+		}
+		if latest := o.takeLatest(); latest != "" {
+			t.Errorf("#%d: latest line returned twice: %q", i, latest)
+		}
+		if got := string(o.Bytes()); got != strings.Join(test.in, "") {
+			t.Errorf("#%d: bad output: want %q, got %q", i, strings.Join(test.in, ""), got)
+		}
+	}
+}
+
+type recordingOps struct {
+	Stdout
+	mu   sync.Mutex
+	info []string
+}
+
+func (r *recordingOps) Info(msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info = append(r.info, msg)
+	return nil
+}
+
+func TestLogLongCmd(t *testing.T) {
+	ops := &recordingOps{}
+	l := Logger{ops: ops}
+
+	cmd := exec.Command("sh", "-c", "echo formatting")
+	if _, err := l.LogLongCmd(cmd, time.Hour, "testing"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(strings.Join(ops.info, "\n"), "[finished] testing") {
+		t.Errorf("command not logged: %q", ops.info)
+	}
+}
+
+func TestWaitWithHeartbeat(t *testing.T) {
+	// the clock advances by 30s every time it's read
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func(n func() time.Time, nt func(time.Duration) (<-chan time.Time, func())) {
+		now, newTicker = n, nt
+	}(now, newTicker)
+	now = func() time.Time {
+		clock = clock.Add(30 * time.Second)
+		return clock
+	}
+	ticks := make(chan time.Time)
+	newTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+
+	ops := &recordingOps{}
+	l := Logger{ops: ops}
+	stdout := &outputBuffer{}
+	stderr := &outputBuffer{}
+	stdout.Write([]byte("formatting\n"))
+	stderr.Write([]byte("warning\n"))
+
+	exit := make(chan struct{})
+	result := make(chan error)
+	go func() {
+		result <- l.waitWithHeartbeat(func() error {
+			<-exit
+			return nil
+		}, time.Minute, stdout, stderr)
+	}()
+	// a tick is only received once the previous one was logged
+	ticks <- time.Time{}
+	ticks <- time.Time{}
+	ticks <- time.Time{}
+	close(exit)
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"still running after 30s: formatting",
+		"still running after 1m0s",
+	}
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+	if len(ops.info) < len(want) {
+		t.Fatalf("bad heartbeats: want %q, got %q", want, ops.info)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(ops.info[i], w) {
+			t.Errorf("#%d: bad heartbeat: want %q, got %q", i, w, ops.info[i])
+		}
+	}
+}
+
+func TestFork(t *testing.T) {
+	ops := &recordingOps{}
+	l := &Logger{ops: ops}
+	l.PushPrefix("disks")
+	op := func(l *Logger, name string) {
+		l.LogOp(func() error { return nil }, "%s", name)
+	}
+
+	op(l, "parent")
+	first, second := l.Fork(), l.Fork()
+	var wg sync.WaitGroup
+	for _, fork := range []*Logger{first, second} {
+		wg.Add(1)
+		go func(fork *Logger) {
+			defer wg.Done()
+			op(fork, "fork")
+			op(fork, "fork")
+		}(fork)
+	}
+	wg.Wait()
+	op(l, "parent")
+	op(first.Fork(), "nested")
+
+	started := map[string]bool{}
+	for _, msg := range ops.info {
+		if strings.Contains(msg, "[started]") {
+			started[msg] = true
+		}
+	}
+	want := map[string]bool{
+		"disks: op(1): [started]  parent":     true,
+		"disks: op(1.1): [started]  fork":     true,
+		"disks: op(1.2): [started]  fork":     true,
+		"disks: op(2.1): [started]  fork":     true,
+		"disks: op(2.2): [started]  fork":     true,
+		"disks: op(2): [started]  parent":     true,
+		"disks: op(1.1.1): [started]  nested": true,
+	}
+	if !reflect.DeepEqual(want, started) {
+		t.Errorf("bad ops: want %v, got %v", want, started)
+	}
+}
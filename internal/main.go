@@ -15,22 +15,48 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/exec"
 	"github.com/flatcar/ignition/internal/exec/stages"
-	_ "github.com/flatcar/ignition/internal/exec/stages/disks"
-	_ "github.com/flatcar/ignition/internal/exec/stages/fetch"
-	_ "github.com/flatcar/ignition/internal/exec/stages/files"
+	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/fips"
 	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/oem"
+	"github.com/flatcar/ignition/internal/resource"
+	"github.com/flatcar/ignition/internal/supervisor"
 	"github.com/flatcar/ignition/internal/version"
 )
 
+// abortGracePeriod is how long a stopped Ignition waits for the stage to
+// wind down before exiting.
+const abortGracePeriod = 5 * time.Second
+
 func main() {
+	if resource.InFetchHelper() {
+		serveFetchHelper()
+		return
+	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "converge":
+			os.Exit(runConverge(os.Args[2:]))
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:]))
+		case "verify":
+			os.Exit(runVerify(os.Args[2:]))
+		case "version":
+			os.Exit(runVersion(os.Args[2:]))
+		}
+	}
+
 	flags := struct {
 		clearCache   bool
 		configCache  string
@@ -42,12 +68,26 @@ func main() {
 		logToStdout  bool
 	}{}
 
+	// Stages declare their ordering themselves, so a downstream stage
+	// depending on a stage which isn't built in is only noticed here. The
+	// order is only listed; the init system runs the stages in it.
+	stageOrder, err := stages.Ordered()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid stages: %v\n", err)
+		os.Exit(2)
+	}
+
+	if err := util.CheckInitSystem(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
 	flag.BoolVar(&flags.clearCache, "clear-cache", false, "clear any cached config")
 	flag.StringVar(&flags.configCache, "config-cache", "/run/ignition.json", "where to cache the config")
 	flag.DurationVar(&flags.fetchTimeout, "fetch-timeout", exec.DefaultFetchTimeout, "initial duration for which to wait for config")
 	flag.Var(&flags.oem, "oem", fmt.Sprintf("current oem. %v", oem.Names()))
 	flag.StringVar(&flags.root, "root", "/", "root of the filesystem")
-	flag.Var(&flags.stage, "stage", fmt.Sprintf("execution stage. %v", stages.Names()))
+	flag.Var(&flags.stage, "stage", fmt.Sprintf("execution stage. %v", stageOrder))
 	flag.BoolVar(&flags.version, "version", false, "print the version and exit")
 	flag.BoolVar(&flags.logToStdout, "log-to-stdout", false, "log to stdout instead of the system log when set")
 
@@ -73,6 +113,26 @@ func main() {
 
 	logger.Info(version.String)
 	logger.Info("Stage: %v", flags.stage)
+	if fips.Enabled() {
+		logger.Info("kernel is in FIPS mode, restricting hashes, SSH host keys and TLS to approved algorithms")
+	}
+
+	// Cancel the fetches in flight and kill any helper programs still
+	// running if Ignition is stopped, e.g. by the initrd moving on, rather
+	// than leaving them to modify disks behind its back. The stage is given
+	// a moment to wind down and stop its children before exiting.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Crit("Ignition aborted by %v", sig)
+		cancel()
+		supervisor.Abort()
+		time.Sleep(abortGracePeriod)
+		os.Exit(1)
+	}()
 
 	if flags.clearCache {
 		if err := os.Remove(flags.configCache); err != nil {
@@ -86,6 +146,13 @@ func main() {
 		logger.Crit("failed to generate fetcher: %s", err)
 		os.Exit(3)
 	}
+	fetcher.Context = ctx
+	if distro.PrivsepFetch() {
+		if err := fetcher.StartFetchHelper(); err != nil {
+			logger.Crit("failed to start fetch helper: %s", err)
+			os.Exit(3)
+		}
+	}
 	engine := exec.Engine{
 		Root:         flags.root,
 		FetchTimeout: flags.fetchTimeout,
@@ -96,8 +163,14 @@ func main() {
 	}
 
 	err = engine.Run(flags.stage.String())
-	if statusErr := engine.OEMConfig.Status(flags.stage.String(), *engine.Fetcher, err); statusErr != nil {
-		logger.Err("POST Status error: %v", statusErr.Error())
+	// the parent of a confined stage reports its status
+	if !exec.InConfinedStage() {
+		if statusErr := engine.OEMConfig.Status(flags.stage.String(), *engine.Fetcher, err); statusErr != nil {
+			logger.Err("POST Status error: %v", statusErr.Error())
+		}
+	}
+	if stopErr := fetcher.StopFetchHelper(); stopErr != nil {
+		logger.Err("fetch helper failed: %v", stopErr)
 	}
 	if err != nil {
 		logger.Crit("Ignition failed: %v", err.Error())
@@ -105,3 +178,17 @@ func main() {
 	}
 	logger.Info("Ignition finished successfully")
 }
+
+// serveFetchHelper runs this process as the fetch helper of its parent.
+// Stdout carries the responses, so nothing else may be written to it.
+func serveFetchHelper() {
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	logger := log.New(false)
+	defer logger.Close()
+
+	if err := resource.ServeFetchHelper(&logger, os.Stdin, out); err != nil {
+		logger.Crit("fetch helper failed: %v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocsp verifies OCSP responses (RFC 6960), as stapled by TLS
+// servers to prove that their certificate hasn't been revoked.
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	// registered for the hashes of certificate ids
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var (
+	ErrMalformed    = errors.New("malformed OCSP response")
+	ErrUnsuccessful = errors.New("OCSP responder didn't answer successfully")
+	ErrNoResponse   = errors.New("OCSP response doesn't cover the certificate")
+	ErrRevoked      = errors.New("certificate has been revoked")
+	ErrUnknown      = errors.New("certificate is unknown to the OCSP responder")
+	ErrExpired      = errors.New("OCSP response isn't current")
+	ErrSigner       = errors.New("OCSP response isn't signed by the issuer or a responder it authorized")
+
+	oidBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+
+	hashes = map[string]crypto.Hash{
+		"1.3.14.3.2.26":          crypto.SHA1,
+		"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+		"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+		"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	}
+	signatureAlgorithms = map[string]x509.SignatureAlgorithm{
+		"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+		"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+		"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+		"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+		"1.2.840.10045.4.1":     x509.ECDSAWithSHA1,
+		"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+		"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+		"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+		"1.3.101.112":           x509.PureEd25519,
+	}
+)
+
+type response struct {
+	Status        asn1.Enumerated
+	ResponseBytes responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicResponse struct {
+	TBSResponseData    responseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type responseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []singleResponse
+}
+
+type singleResponse struct {
+	CertID     certID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag   `asn1:"tag:2,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type certID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+// Verify checks that raw is a current OCSP response, signed by issuer or a
+// responder it authorized, stating that cert is good.
+func Verify(raw []byte, cert, issuer *x509.Certificate, now time.Time) error {
+	var resp response
+	if rest, err := asn1.Unmarshal(raw, &resp); err != nil || len(rest) > 0 {
+		return ErrMalformed
+	}
+	if resp.Status != 0 {
+		return ErrUnsuccessful
+	}
+	if !resp.ResponseBytes.ResponseType.Equal(oidBasicResponse) {
+		return ErrMalformed
+	}
+	var basic basicResponse
+	if rest, err := asn1.Unmarshal(resp.ResponseBytes.Response, &basic); err != nil || len(rest) > 0 {
+		return ErrMalformed
+	}
+
+	if err := checkSignature(basic, issuer, now); err != nil {
+		return err
+	}
+
+	single, err := findResponse(basic.TBSResponseData.Responses, cert, issuer)
+	if err != nil {
+		return err
+	}
+	switch {
+	case bool(single.Good):
+	case bool(single.Unknown):
+		return ErrUnknown
+	default:
+		return ErrRevoked
+	}
+	if now.Before(single.ThisUpdate) || (!single.NextUpdate.IsZero() && now.After(single.NextUpdate)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// checkSignature checks that the response is signed by the issuer, or by a
+// responder certificate included in the response which the issuer signed
+// for OCSP signing and which is valid at now.
+func checkSignature(basic basicResponse, issuer *x509.Certificate, now time.Time) error {
+	algo, ok := signatureAlgorithms[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("unsupported OCSP signature algorithm %s", basic.SignatureAlgorithm.Algorithm)
+	}
+	signed, signature := basic.TBSResponseData.Raw, basic.Signature.RightAlign()
+
+	if issuer.CheckSignature(algo, signed, signature) == nil {
+		return nil
+	}
+	for _, raw := range basic.Certificates {
+		responder, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return ErrMalformed
+		}
+		if responder.CheckSignatureFrom(issuer) != nil || !hasOCSPSigning(responder) {
+			continue
+		}
+		if now.Before(responder.NotBefore) || now.After(responder.NotAfter) {
+			continue
+		}
+		if responder.CheckSignature(algo, signed, signature) == nil {
+			return nil
+		}
+	}
+	return ErrSigner
+}
+
+func hasOCSPSigning(cert *x509.Certificate) bool {
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageOCSPSigning {
+			return true
+		}
+	}
+	return false
+}
+
+// findResponse returns the response about cert.
+func findResponse(responses []singleResponse, cert, issuer *x509.Certificate) (singleResponse, error) {
+	var publicKey struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKey); err != nil {
+		return singleResponse{}, err
+	}
+
+	for _, r := range responses {
+		h, ok := hashes[r.CertID.HashAlgorithm.Algorithm.String()]
+		if !ok || r.CertID.SerialNumber == nil || r.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+		nameHash := h.New()
+		nameHash.Write(issuer.RawSubject)
+		keyHash := h.New()
+		keyHash.Write(publicKey.PublicKey.RightAlign())
+		if bytes.Equal(r.CertID.NameHash, nameHash.Sum(nil)) && bytes.Equal(r.CertID.IssuerKeyHash, keyHash.Sum(nil)) {
+			return r, nil
+		}
+	}
+	return singleResponse{}, ErrNoResponse
+}
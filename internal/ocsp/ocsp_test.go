@@ -0,0 +1,146 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/ocsp/ocsptest"
+)
+
+func newCert(t *testing.T, serial int64, parent *x509.Certificate, parentKey crypto.Signer, usage []x509.ExtKeyUsage) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: big.NewInt(serial).String()},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           usage,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestVerify(t *testing.T) {
+	ca, caKey := newCert(t, 1, nil, nil, nil)
+	rogue, rogueKey := newCert(t, 2, nil, nil, nil)
+	leaf, _ := newCert(t, 3, ca, caKey, nil)
+	other, _ := newCert(t, 4, ca, caKey, nil)
+	responder, responderKey := newCert(t, 5, ca, caKey, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+	unauthorized, unauthorizedKey := newCert(t, 6, ca, caKey, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+
+	now := time.Now()
+	type in struct {
+		cert      *x509.Certificate
+		responder *x509.Certificate
+		key       crypto.Signer
+		status    ocsptest.Status
+		this      time.Time
+		next      time.Time
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{cert: leaf, key: caKey, status: ocsptest.Good, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{},
+		},
+		{
+			in:  in{cert: leaf, responder: responder, key: responderKey, status: ocsptest.Good, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{},
+		},
+		{
+			in:  in{cert: leaf, key: caKey, status: ocsptest.Revoked, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{err: ErrRevoked},
+		},
+		{
+			in:  in{cert: leaf, key: caKey, status: ocsptest.Unknown, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{err: ErrUnknown},
+		},
+		{
+			in:  in{cert: leaf, key: caKey, status: ocsptest.Good, this: now.Add(-2 * time.Hour), next: now.Add(-time.Hour)},
+			out: out{err: ErrExpired},
+		},
+		{
+			in:  in{cert: leaf, key: caKey, status: ocsptest.Good, this: now.Add(time.Hour), next: now.Add(2 * time.Hour)},
+			out: out{err: ErrExpired},
+		},
+		{
+			in:  in{cert: other, key: caKey, status: ocsptest.Good, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{err: ErrNoResponse},
+		},
+		{
+			in:  in{cert: leaf, responder: rogue, key: rogueKey, status: ocsptest.Good, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{err: ErrSigner},
+		},
+		{
+			in:  in{cert: leaf, responder: unauthorized, key: unauthorizedKey, status: ocsptest.Good, this: now.Add(-time.Hour), next: now.Add(time.Hour)},
+			out: out{err: ErrSigner},
+		},
+	}
+
+	for i, test := range tests {
+		raw, err := ocsptest.Create(test.in.cert, ca, test.in.responder, test.in.key, test.in.status, test.in.this, test.in.next)
+		if err != nil {
+			t.Fatalf("#%d: creating response: %v", i, err)
+		}
+		err = Verify(raw, leaf, ca, now)
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+	}
+
+	// the response is current but the responder certificate has expired
+	raw, err := ocsptest.Create(leaf, ca, responder, responderKey, ocsptest.Good, now.Add(time.Hour), now.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("creating response: %v", err)
+	}
+	if err := Verify(raw, leaf, ca, now.Add(2*time.Hour)); err != ErrSigner {
+		t.Errorf("bad error for expired responder: want %v, got %v", ErrSigner, err)
+	}
+
+	if err := Verify([]byte("not a response"), leaf, ca, now); err != ErrMalformed {
+		t.Errorf("bad error for garbage: want %v, got %v", ErrMalformed, err)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocsptest creates OCSP responses for tests.
+package ocsptest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Status is the revocation status of a certificate.
+type Status int
+
+const (
+	Good Status = iota
+	Revoked
+	Unknown
+)
+
+var (
+	oidBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+
+	signatureOIDs = map[x509.SignatureAlgorithm]asn1.ObjectIdentifier{
+		x509.SHA256WithRSA:   {1, 2, 840, 113549, 1, 1, 11},
+		x509.ECDSAWithSHA256: {1, 2, 840, 10045, 4, 3, 2},
+		x509.PureEd25519:     {1, 3, 101, 112},
+	}
+)
+
+type response struct {
+	Status        asn1.Enumerated
+	ResponseBytes responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type responseData struct {
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []singleResponse
+}
+
+type singleResponse struct {
+	CertID     certID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag   `asn1:"tag:2,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type certID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type responderByName struct {
+	Name asn1.RawValue `asn1:"explicit,tag:1"`
+}
+
+// Create returns a response stating status for cert, signed by the
+// issuer's key or by responder, a certificate the issuer authorized for OCSP
+// signing and which is then included in the response.
+func Create(cert, issuer, responder *x509.Certificate, key crypto.Signer, status Status, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	var publicKey struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKey); err != nil {
+		return nil, err
+	}
+	nameHash := sha1.Sum(issuer.RawSubject)
+	keyHash := sha1.Sum(publicKey.PublicKey.RightAlign())
+
+	single := singleResponse{
+		CertID: certID{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Parameters: asn1.NullRawValue},
+			NameHash:      nameHash[:],
+			IssuerKeyHash: keyHash[:],
+			SerialNumber:  cert.SerialNumber,
+		},
+		ThisUpdate: thisUpdate.UTC(),
+		NextUpdate: nextUpdate.UTC(),
+	}
+	switch status {
+	case Good:
+		single.Good = true
+	case Unknown:
+		single.Unknown = true
+	case Revoked:
+		single.Revoked = revokedInfo{RevocationTime: thisUpdate.UTC()}
+	}
+
+	signer := issuer
+	if responder != nil {
+		signer = responder
+	}
+	responderID, err := asn1.Marshal(responderByName{Name: asn1.RawValue{FullBytes: signer.RawSubject}})
+	if err != nil {
+		return nil, err
+	}
+	tbs := responseData{
+		RawResponderID: asn1.RawValue{FullBytes: responderID},
+		ProducedAt:     thisUpdate.UTC(),
+		Responses:      []singleResponse{single},
+	}
+	tbsBytes, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	var algo x509.SignatureAlgorithm
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		algo = x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		algo = x509.ECDSAWithSHA256
+	case ed25519.PublicKey:
+		algo = x509.PureEd25519
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Public())
+	}
+	digest, opts := tbsBytes, crypto.SignerOpts(crypto.Hash(0))
+	if algo != x509.PureEd25519 {
+		h := crypto.SHA256.New()
+		h.Write(tbsBytes)
+		digest, opts = h.Sum(nil), crypto.SHA256
+	}
+	signature, err := key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	basic := basicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbsBytes},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureOIDs[algo]},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	}
+	if responder != nil {
+		basic.Certificates = []asn1.RawValue{{FullBytes: responder.Raw}}
+	}
+	basicBytes, err := asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(response{
+		ResponseBytes: responseBytes{ResponseType: oidBasicResponse, Response: basicBytes},
+	})
+}
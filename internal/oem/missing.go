@@ -0,0 +1,71 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oem
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// MissingConfig is what Ignition does if no config is supplied on a platform.
+type MissingConfig string
+
+const (
+	// MissingConfigContinue provisions the machine with the base config,
+	// for platforms on which machines are commonly installed interactively.
+	MissingConfigContinue MissingConfig = "continue"
+	// MissingConfigFail fails the boot, for platforms on which an absent
+	// config means that provisioning went wrong.
+	MissingConfigFail MissingConfig = "fail"
+	// MissingConfigRetry fetches the config again until one is supplied.
+	MissingConfigRetry MissingConfig = "retry"
+)
+
+// MissingConfig returns what to do if no config is supplied on the platform,
+// as set by the distribution. Ignition continues with the base config unless
+// told otherwise.
+func (c Config) MissingConfig() (MissingConfig, error) {
+	return parseMissingConfig(distro.MissingConfig(), c.name)
+}
+
+// parseMissingConfig returns the policy for platform from setting, a comma
+// separated list of policies for all platforms and of
+// <platform>=<policy> entries. The platform's entry takes precedence.
+func parseMissingConfig(setting, platform string) (MissingConfig, error) {
+	policy := MissingConfigContinue
+	found := false
+	for _, entry := range strings.Split(setting, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value := "", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			name, value = entry[:i], entry[i+1:]
+		}
+		switch MissingConfig(value) {
+		case MissingConfigContinue, MissingConfigFail, MissingConfigRetry:
+		default:
+			return "", fmt.Errorf("invalid missing config policy %q", entry)
+		}
+		if name == platform || (name == "" && !found) {
+			policy = MissingConfig(value)
+			found = found || name == platform
+		}
+	}
+	return policy, nil
+}
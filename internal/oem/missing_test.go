@@ -0,0 +1,79 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oem
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMissingConfig(t *testing.T) {
+	type in struct {
+		setting  string
+		platform string
+	}
+	type out struct {
+		policy MissingConfig
+		err    error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{setting: "", platform: "ec2"},
+			out: out{policy: MissingConfigContinue},
+		},
+		{
+			in:  in{setting: "fail", platform: "ec2"},
+			out: out{policy: MissingConfigFail},
+		},
+		{
+			in:  in{setting: "metal=continue,fail", platform: "metal"},
+			out: out{policy: MissingConfigContinue},
+		},
+		{
+			in:  in{setting: "fail, metal=continue", platform: "metal"},
+			out: out{policy: MissingConfigContinue},
+		},
+		{
+			in:  in{setting: "fail,metal=continue", platform: "ec2"},
+			out: out{policy: MissingConfigFail},
+		},
+		{
+			in:  in{setting: "ec2=fail,qemu=retry", platform: "qemu"},
+			out: out{policy: MissingConfigRetry},
+		},
+		{
+			in:  in{setting: "ec2=fail", platform: "gce"},
+			out: out{policy: MissingConfigContinue},
+		},
+		{
+			in:  in{setting: "ec2=wait", platform: "gce"},
+			out: out{err: errors.New(`invalid missing config policy "ec2=wait"`)},
+		},
+	}
+
+	for i, test := range tests {
+		policy, err := parseMissingConfig(test.in.setting, test.in.platform)
+		if (err == nil) != (test.out.err == nil) || (err != nil && err.Error() != test.out.err.Error()) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+		if policy != test.out.policy {
+			t.Errorf("#%d: bad policy: want %q, got %q", i, test.out.policy, policy)
+		}
+	}
+}
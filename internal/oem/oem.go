@@ -16,6 +16,7 @@ package oem
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/providers"
@@ -43,6 +44,8 @@ type Config struct {
 	fetch      providers.FuncFetchConfig
 	newFetcher providers.FuncNewFetcher
 	status     providers.FuncPostStatus
+	resolve    providers.FuncResolveFragment
+	metadata   providers.FuncFetchMetadata
 }
 
 func (c Config) Name() string {
@@ -72,6 +75,24 @@ func (c Config) Status(stageName string, f resource.Fetcher, statusErr error) er
 	return nil
 }
 
+// ResolveFragment maps the name of a config fragment to the URL it should be
+// fetched from, using the provider's metadata.
+func (c Config) ResolveFragment(f *resource.Fetcher, name string) (*url.URL, error) {
+	if c.resolve == nil {
+		return nil, providers.ErrNoFragmentResolver
+	}
+	return c.resolve(f, name)
+}
+
+// FetchMetadata returns the metadata attributes of the instance, keyed by the
+// providers.Metadata* names. Providers without metadata support return nil.
+func (c Config) FetchMetadata(f *resource.Fetcher) (map[string]string, error) {
+	if c.metadata == nil {
+		return nil, nil
+	}
+	return c.metadata(f)
+}
+
 var configs = registry.Create("oem configs")
 
 func init() {
@@ -80,8 +101,9 @@ func init() {
 		fetch: aliyun.FetchConfig,
 	})
 	configs.Register(Config{
-		name:  "azure",
-		fetch: azure.FetchConfig,
+		name:       "azure",
+		fetch:      azure.FetchConfig,
+		newFetcher: azure.NewFetcher,
 	})
 	configs.Register(Config{
 		name:  "cloudsigma",
@@ -107,14 +129,19 @@ func init() {
 		name:       "ec2",
 		fetch:      ec2.FetchConfig,
 		newFetcher: ec2.NewFetcher,
+		resolve:    ec2.ResolveFragment,
+		metadata:   ec2.FetchMetadata,
 	})
 	configs.Register(Config{
 		name:  "exoscale",
 		fetch: noop.FetchConfig,
 	})
 	configs.Register(Config{
-		name:  "gce",
-		fetch: gce.FetchConfig,
+		name:       "gce",
+		fetch:      gce.FetchConfig,
+		newFetcher: gce.NewFetcher,
+		resolve:    gce.ResolveFragment,
+		metadata:   gce.FetchMetadata,
 	})
 	configs.Register(Config{
 		name:  "hyperv",
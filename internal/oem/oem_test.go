@@ -0,0 +1,31 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oem
+
+import (
+	"testing"
+
+	"github.com/flatcar/ignition/config/v2_4/types"
+)
+
+// TestConditionPlatforms checks that configs can match every OEM in their
+// conditions.
+func TestConditionPlatforms(t *testing.T) {
+	for _, name := range Names() {
+		if r := (types.Condition{Platform: name}).ValidatePlatform(); r.IsFatal() {
+			t.Errorf("%s isn't a valid condition platform: %v", name, r)
+		}
+	}
+}
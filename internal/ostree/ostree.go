@@ -0,0 +1,138 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ostree finds the ostree deployment Ignition provisions on
+// machines booting one, and tells which of its paths configs may write to.
+//
+// A deployment's /usr is read-only, and everything but /etc and /var is
+// replaced by the next update. ostree carries /etc over to new deployments
+// by merging local changes, and shares /var between all deployments of a
+// stateroot.
+package ostree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+const cmdlineOSTreeFlag = "ostree"
+
+// Deployment is an ostree deployment.
+type Deployment struct {
+	// Root is the deployment's root directory.
+	Root string
+	// Stateroot is the name of the stateroot the deployment belongs to.
+	Stateroot string
+	// Var is the stateroot's /var, which is mounted at the deployment's
+	// /var at boot.
+	Var string
+}
+
+// Find returns the deployment booted with the ostree= kernel argument, or
+// nil if the machine doesn't boot an ostree deployment. root is either the
+// physical root filesystem, or the deployment with the physical root
+// filesystem at root/sysroot, as set up by ostree-prepare-root.
+func Find(root string) (*Deployment, error) {
+	if _, err := os.Stat(distro.OSTreeBootedPath()); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cmdline, err := ioutil.ReadFile(distro.KernelCmdlinePath())
+	if err != nil {
+		return nil, err
+	}
+	return find(root, parseCmdline(cmdline))
+}
+
+func find(root, karg string) (*Deployment, error) {
+	if karg == "" {
+		return nil, fmt.Errorf("%s exists, but the kernel command line has no %s= argument", distro.OSTreeBootedPath(), cmdlineOSTreeFlag)
+	}
+	for _, sysroot := range []string{filepath.Join(root, "sysroot"), root} {
+		if _, err := os.Stat(filepath.Join(sysroot, "ostree", "deploy")); err != nil {
+			continue
+		}
+		path, err := resolve(sysroot, karg)
+		if err != nil {
+			return nil, err
+		}
+		// ostree/deploy/<stateroot>/deploy/<checksum>.<serial>
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(parts) != 5 || parts[0] != "ostree" || parts[1] != "deploy" || parts[3] != "deploy" {
+			return nil, fmt.Errorf("%s=%s doesn't point to a deployment, but to %q", cmdlineOSTreeFlag, karg, path)
+		}
+		d := Deployment{
+			Root:      filepath.Join(sysroot, path),
+			Stateroot: parts[2],
+			Var:       filepath.Join(sysroot, "ostree", "deploy", parts[2], "var"),
+		}
+		if sysroot != root {
+			// the deployment is already set up at root
+			d.Root = root
+		}
+		if _, err := os.Stat(d.Root); err != nil {
+			return nil, err
+		}
+		return &d, nil
+	}
+	return nil, fmt.Errorf("no ostree repository in %q or %q", filepath.Join(root, "sysroot"), root)
+}
+
+func parseCmdline(cmdline []byte) (karg string) {
+	for _, arg := range strings.Fields(string(cmdline)) {
+		parts := strings.SplitN(arg, "=", 2)
+		if parts[0] == cmdlineOSTreeFlag && len(parts) == 2 {
+			karg = parts[1]
+		}
+	}
+	return
+}
+
+// CheckPath returns an error if a config can't write to path in the
+// deployment, which is the case for all paths outside of /etc and /var
+// after following symlinks.
+func (d Deployment) CheckPath(path string) error {
+	resolved, err := resolve(d.Root, path)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{"/etc", "/var"} {
+		if resolved == dir || strings.HasPrefix(resolved, dir+"/") {
+			return nil
+		}
+	}
+	if resolved == "/usr" || strings.HasPrefix(resolved, "/usr/") {
+		return fmt.Errorf("%q is in /usr, which is read-only in ostree deployments", path)
+	}
+	return fmt.Errorf("%q isn't in /etc or /var, so it would be lost on the next update of the ostree deployment", path)
+}
+
+// resolve resolves the symlinks in path, including its last component, as if
+// root was the root directory. Components which don't exist are kept as they
+// are.
+func resolve(root, path string) (string, error) {
+	// ResolveInRoot only follows the links in the parents of the path
+	resolved, err := config.ResolveInRoot(root, filepath.Join(path, "_"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(resolved), nil
+}
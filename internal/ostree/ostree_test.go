@@ -0,0 +1,157 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ostree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sysroot creates a physical root filesystem with a deployment of the
+// stateroot "fedora" linked from boot.1 as ostree-boot does.
+func sysroot(t *testing.T) string {
+	root, err := ioutil.TempDir("", "ignition-ostree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := filepath.Join(root, "ostree/deploy/fedora/deploy/abc.0")
+	for _, dir := range []string{"etc", "usr/bin", "var", "sysroot"} {
+		if err := os.MkdirAll(filepath.Join(deployment, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"ostree/boot.1/fedora/xyz/0":             "../../../deploy/fedora/deploy/abc.0",
+		"ostree/deploy/fedora/deploy/abc.0/home": "var/home",
+		"ostree/deploy/fedora/deploy/abc.0/bin":  "usr/bin",
+		"ostree/deploy/fedora/deploy/abc.0/opt":  "/var/opt",
+		"ostree/deploy/fedora/deploy/abc.0/loop": "loop",
+	} {
+		path := filepath.Join(root, link)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "ostree/deploy/fedora/var"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestFind(t *testing.T) {
+	root := sysroot(t)
+	defer os.RemoveAll(root)
+	deployment := filepath.Join(root, "ostree/deploy/fedora/deploy/abc.0")
+	stateVar := filepath.Join(root, "ostree/deploy/fedora/var")
+
+	// after ostree-prepare-root, the physical root is at /sysroot of the
+	// deployment
+	prepared, err := ioutil.TempDir("", "ignition-ostree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(prepared)
+	if err := os.Symlink(root, filepath.Join(prepared, "sysroot")); err != nil {
+		t.Fatal(err)
+	}
+
+	type in struct {
+		root string
+		karg string
+	}
+	type out struct {
+		d   *Deployment
+		err bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{root: root, karg: "/ostree/boot.1/fedora/xyz/0"},
+			out: out{d: &Deployment{Root: deployment, Stateroot: "fedora", Var: stateVar}},
+		},
+		{
+			in:  in{root: prepared, karg: "/ostree/boot.1/fedora/xyz/0"},
+			out: out{d: &Deployment{Root: prepared, Stateroot: "fedora", Var: filepath.Join(prepared, "sysroot/ostree/deploy/fedora/var")}},
+		},
+		{
+			in:  in{root: root, karg: ""},
+			out: out{err: true},
+		},
+		{
+			in:  in{root: root, karg: "/ostree/boot.1/fedora"},
+			out: out{err: true},
+		},
+		{
+			in:  in{root: deployment, karg: "/ostree/boot.1/fedora/xyz/0"},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		d, err := find(test.in.root, test.in.karg)
+		if test.out.err {
+			if err == nil {
+				t.Errorf("#%d: expected error, got %+v", i, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if *d != *test.out.d {
+			t.Errorf("#%d: bad deployment: want %+v, got %+v", i, *test.out.d, *d)
+		}
+	}
+}
+
+func TestCheckPath(t *testing.T) {
+	root := sysroot(t)
+	defer os.RemoveAll(root)
+	d := Deployment{Root: filepath.Join(root, "ostree/deploy/fedora/deploy/abc.0")}
+
+	tests := []struct {
+		in  string
+		out bool
+	}{
+		{in: "/etc/hostname", out: true},
+		{in: "/etc", out: true},
+		{in: "/var/lib/foo", out: true},
+		{in: "/home/core/.bashrc", out: true},
+		{in: "/opt/foo/bar", out: true},
+		{in: "/etc/../var/x", out: true},
+		{in: "/usr/bin/foo", out: false},
+		{in: "/bin/foo", out: false},
+		{in: "/etc/../usr/x", out: false},
+		{in: "/srv/www", out: false},
+		{in: "/etcetera", out: false},
+		{in: "/loop/x", out: false},
+	}
+
+	for i, test := range tests {
+		err := d.CheckPath(test.in)
+		if (err == nil) != test.out {
+			t.Errorf("#%d: bad result for %q: want writable %t, got %v", i, test.in, test.out, err)
+		}
+	}
+}
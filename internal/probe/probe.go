@@ -0,0 +1,383 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package probe detects filesystems and other on-disk signatures by their
+// superblocks, so the disks stage can decide whether a device may be reused
+// or needs to be wiped without depending on libblkid.
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Unknown is the Type of a device which carries data matching no known
+// signature. Such a device must not be treated as empty, as it may hold a
+// format this package doesn't recognize.
+const Unknown = "unknown"
+
+// unknownProbeSize is the number of bytes at the start of a device which
+// are checked for data if no known signature was found. It covers the
+// superblock locations of all formats probed for.
+const unknownProbeSize = 512 * 1024
+
+// Result describes what was found on a device. Type uses the names blkid
+// reports; it is empty if the device is blank and Unknown if it carries data
+// matching no known signature.
+type Result struct {
+	Type  string
+	UUID  string
+	Label string
+	// Offset is the byte offset of the signature identifying Type.
+	Offset int64
+}
+
+func (r Result) String() string {
+	switch r.Type {
+	case "":
+		return "no signature"
+	case Unknown:
+		return "data matching no known signature"
+	}
+	return fmt.Sprintf("%s signature at offset %d with uuid %q and label %q", r.Type, r.Offset, r.UUID, r.Label)
+}
+
+type prober func(r io.ReaderAt, size int64) (Result, bool)
+
+// probers are tried in order. Containers such as RAID members, LVM physical
+// volumes and LUKS devices come first, as they may carry leftover filesystem
+// superblocks of their contents.
+// Partition tables come last, as filesystems such as vfat share their boot
+// sector signature.
+var probers = []prober{
+	probeRAID,
+	probeLVM,
+	probeLUKS,
+	probeBcache,
+	probeZFS,
+	probeXFS,
+	probeExt,
+	probeBtrfs,
+	probeF2FS,
+	probeUDF,
+	probeISO9660,
+	probeNTFS,
+	probeVFAT,
+	probeSwap,
+	probeGPT,
+	probeDOS,
+}
+
+// Device probes the device or file at path.
+func Device(path string) (Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Result{}, err
+	}
+	return Probe(f, size), nil
+}
+
+// Probe returns the first known signature found in r, which is size bytes
+// large. If there is none, but the start of r isn't zeroed, the result has
+// Type Unknown.
+func Probe(r io.ReaderAt, size int64) Result {
+	for _, p := range probers {
+		if res, ok := p(r, size); ok {
+			return res
+		}
+	}
+	n := int64(unknownProbeSize)
+	if size < n {
+		n = size
+	}
+	b := read(r, 0, int(n))
+	if b == nil || !isZero(b) {
+		return Result{Type: Unknown}
+	}
+	return Result{}
+}
+
+// read returns n bytes at off, or nil if they can't be read.
+func read(r io.ReaderAt, off int64, n int) []byte {
+	if off < 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	if _, err := r.ReadAt(b, off); err != nil {
+		return nil
+	}
+	return b
+}
+
+func probeExt(r io.ReaderAt, size int64) (Result, bool) {
+	const off = 1024
+	sb := read(r, off, 1024)
+	if sb == nil || binary.LittleEndian.Uint16(sb[56:]) != 0xef53 {
+		return Result{}, false
+	}
+	const (
+		compatHasJournal   = 0x4
+		incompatJournalDev = 0x8
+		// features supported by ext2 and ext3 as far as blkid is concerned
+		incompatExt3 = 0x2 | 0x4 | 0x10
+		roCompatExt3 = 0x1 | 0x2 | 0x4
+	)
+	compat := binary.LittleEndian.Uint32(sb[92:])
+	incompat := binary.LittleEndian.Uint32(sb[96:])
+	roCompat := binary.LittleEndian.Uint32(sb[100:])
+
+	typ := "ext2"
+	switch {
+	case incompat&incompatJournalDev != 0:
+		typ = "jbd"
+	case incompat&^incompatExt3 != 0 || roCompat&^roCompatExt3 != 0:
+		typ = "ext4"
+	case compat&compatHasJournal != 0:
+		typ = "ext3"
+	}
+	return Result{Type: typ, UUID: uuid(sb[104:120]), Label: cString(sb[120:136]), Offset: off + 56}, true
+}
+
+func probeXFS(r io.ReaderAt, size int64) (Result, bool) {
+	sb := read(r, 0, 512)
+	if sb == nil || !bytes.Equal(sb[:4], []byte("XFSB")) {
+		return Result{}, false
+	}
+	return Result{Type: "xfs", UUID: uuid(sb[32:48]), Label: cString(sb[108:120])}, true
+}
+
+func probeBtrfs(r io.ReaderAt, size int64) (Result, bool) {
+	const off = 64 * 1024
+	sb := read(r, off, 4096)
+	if sb == nil || !bytes.Equal(sb[64:72], []byte("_BHRfS_M")) {
+		return Result{}, false
+	}
+	return Result{Type: "btrfs", UUID: uuid(sb[32:48]), Label: cString(sb[299:555]), Offset: off + 64}, true
+}
+
+func probeVFAT(r io.ReaderAt, size int64) (Result, bool) {
+	bs := read(r, 0, 512)
+	if bs == nil || bs[510] != 0x55 || bs[511] != 0xaa {
+		return Result{}, false
+	}
+	var idOff, labelOff int64
+	switch {
+	case bytes.HasPrefix(bs[82:], []byte("FAT32   ")):
+		idOff, labelOff = 67, 71
+	case bytes.HasPrefix(bs[54:], []byte("FAT12   ")), bytes.HasPrefix(bs[54:], []byte("FAT16   ")):
+		idOff, labelOff = 39, 43
+	default:
+		return Result{}, false
+	}
+	id := bs[idOff : idOff+4]
+	label := strings.TrimRight(string(bs[labelOff:labelOff+11]), " ")
+	if label == "NO NAME" {
+		label = ""
+	}
+	return Result{
+		Type:   "vfat",
+		UUID:   fmt.Sprintf("%02X%02X-%02X%02X", id[3], id[2], id[1], id[0]),
+		Label:  label,
+		Offset: 510,
+	}, true
+}
+
+func probeSwap(r io.ReaderAt, size int64) (Result, bool) {
+	for _, pageSize := range []int64{4096, 8192, 16384, 65536} {
+		magic := read(r, pageSize-10, 10)
+		switch string(magic) {
+		case "SWAPSPACE2":
+			header := read(r, 1024, 44)
+			if header == nil {
+				return Result{}, false
+			}
+			return Result{Type: "swap", UUID: uuid(header[12:28]), Label: cString(header[28:44]), Offset: pageSize - 10}, true
+		case "SWAP-SPACE":
+			return Result{Type: "swap", Offset: pageSize - 10}, true
+		}
+	}
+	return Result{}, false
+}
+
+func probeLUKS(r io.ReaderAt, size int64) (Result, bool) {
+	h := read(r, 0, 512)
+	if h == nil || !bytes.Equal(h[:6], []byte("LUKS\xba\xbe")) {
+		return Result{}, false
+	}
+	res := Result{Type: "crypto_LUKS", UUID: cString(h[168:208])}
+	if binary.BigEndian.Uint16(h[6:]) == 2 {
+		res.Label = cString(h[24:72])
+	}
+	return res, true
+}
+
+func probeRAID(r io.ReaderAt, size int64) (Result, bool) {
+	const magic = 0xa92b4efc
+	// metadata 1.1 and 1.2 at the start, 1.0 at the end
+	offsets := []int64{0, 4096, ((size / 512) - 16) &^ 7 * 512}
+	for _, off := range offsets {
+		sb := read(r, off, 256)
+		if sb != nil && binary.LittleEndian.Uint32(sb) == magic && binary.LittleEndian.Uint32(sb[4:]) == 1 {
+			return Result{Type: "linux_raid_member", UUID: uuid(sb[16:32]), Label: cString(sb[32:64]), Offset: off}, true
+		}
+	}
+	// metadata 0.90 in the last 64 KiB aligned block
+	if off := size&^(64*1024-1) - 64*1024; off >= 0 {
+		sb := read(r, off, 4)
+		if sb != nil && binary.LittleEndian.Uint32(sb) == magic {
+			return Result{Type: "linux_raid_member", Offset: off}, true
+		}
+	}
+	return Result{}, false
+}
+
+func probeLVM(r io.ReaderAt, size int64) (Result, bool) {
+	for sector := int64(0); sector < 4; sector++ {
+		label := read(r, sector*512, 64)
+		if label == nil || !bytes.Equal(label[:8], []byte("LABELONE")) || !bytes.Equal(label[24:32], []byte("LVM2 001")) {
+			continue
+		}
+		id := string(label[32:64])
+		return Result{
+			Type:   "LVM2_member",
+			UUID:   strings.Join([]string{id[0:6], id[6:10], id[10:14], id[14:18], id[18:22], id[22:26], id[26:32]}, "-"),
+			Offset: sector * 512,
+		}, true
+	}
+	return Result{}, false
+}
+
+func probeISO9660(r io.ReaderAt, size int64) (Result, bool) {
+	const off = 32768
+	vd := read(r, off, 2048)
+	if vd == nil || !bytes.Equal(vd[1:6], []byte("CD001")) {
+		return Result{}, false
+	}
+	return Result{Type: "iso9660", Label: strings.TrimRight(string(vd[40:72]), " \x00"), Offset: off + 1}, true
+}
+
+func probeNTFS(r io.ReaderAt, size int64) (Result, bool) {
+	bs := read(r, 0, 512)
+	if bs == nil || !bytes.Equal(bs[3:11], []byte("NTFS    ")) {
+		return Result{}, false
+	}
+	serial := binary.LittleEndian.Uint64(bs[72:])
+	return Result{Type: "ntfs", UUID: fmt.Sprintf("%016X", serial), Offset: 3}, true
+}
+
+func probeBcache(r io.ReaderAt, size int64) (Result, bool) {
+	const off = 4096
+	sb := read(r, off, 72)
+	magic := []byte{0xc6, 0x85, 0x73, 0xf6, 0x4e, 0x1a, 0x45, 0xca, 0x82, 0x65, 0xf5, 0x7f, 0x48, 0xba, 0x6d, 0x81}
+	if sb == nil || !bytes.Equal(sb[24:40], magic) {
+		return Result{}, false
+	}
+	return Result{Type: "bcache", UUID: uuid(sb[40:56]), Offset: off + 24}, true
+}
+
+func probeZFS(r io.ReaderAt, size int64) (Result, bool) {
+	const magic = 0x00bab10c
+	// the uberblock arrays of the first two labels
+	for _, off := range []int64{128 * 1024, 384 * 1024} {
+		ub := read(r, off, 8)
+		if ub != nil && (binary.LittleEndian.Uint64(ub) == magic || binary.BigEndian.Uint64(ub) == magic) {
+			return Result{Type: "zfs_member", Offset: off}, true
+		}
+	}
+	return Result{}, false
+}
+
+func probeF2FS(r io.ReaderAt, size int64) (Result, bool) {
+	const off = 1024
+	sb := read(r, off, 124)
+	if sb == nil || binary.LittleEndian.Uint32(sb) != 0xf2f52010 {
+		return Result{}, false
+	}
+	return Result{Type: "f2fs", UUID: uuid(sb[108:124]), Offset: off}, true
+}
+
+func probeUDF(r io.ReaderAt, size int64) (Result, bool) {
+	// the volume recognition sequence follows the ISO 9660 descriptors of
+	// hybrid images
+	const off = 32768
+	for i := int64(0); i < 64; i++ {
+		vsd := read(r, off+i*2048, 6)
+		if vsd == nil {
+			break
+		}
+		switch string(vsd[1:6]) {
+		case "NSR02", "NSR03":
+			return Result{Type: "udf", Offset: off + i*2048 + 1}, true
+		case "BEA01", "CD001", "CDW02", "BOOT2", "TEA01":
+			continue
+		}
+		break
+	}
+	return Result{}, false
+}
+
+func probeGPT(r io.ReaderAt, size int64) (Result, bool) {
+	for _, off := range []int64{512, 4096} {
+		h := read(r, off, 72)
+		if h != nil && bytes.Equal(h[:8], []byte("EFI PART")) {
+			return Result{Type: "gpt", UUID: guid(h[56:72]), Offset: off}, true
+		}
+	}
+	return Result{}, false
+}
+
+func probeDOS(r io.ReaderAt, size int64) (Result, bool) {
+	mbr := read(r, 0, 512)
+	if mbr == nil || mbr[510] != 0x55 || mbr[511] != 0xaa {
+		return Result{}, false
+	}
+	return Result{Type: "dos", UUID: fmt.Sprintf("%08x", binary.LittleEndian.Uint32(mbr[440:])), Offset: 510}, true
+}
+
+// uuid formats a UUID stored in big endian byte order.
+func uuid(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// cString returns the NUL terminated string at the start of b.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// guid formats a GUID stored in mixed endian byte order.
+func guid(b []byte) string {
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%x-%x",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8:10], b[10:16])
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+type image map[int64][]byte
+
+func (i image) build(size int64) []byte {
+	b := make([]byte, size)
+	for off, data := range i {
+		copy(b[off:], data)
+	}
+	return b
+}
+
+func le16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return b
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+var testUUID = []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+
+const testUUIDString = "01234567-89ab-cdef-0123-456789abcdef"
+
+func TestProbe(t *testing.T) {
+	type in struct {
+		image image
+	}
+	type out struct {
+		res Result
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{image: image{}},
+			out: out{res: Result{}},
+		},
+		{
+			in: in{image: image{
+				1024 + 56:  le16(0xef53),
+				1024 + 104: testUUID,
+				1024 + 120: []byte("data"),
+			}},
+			out: out{res: Result{Type: "ext2", UUID: testUUIDString, Label: "data", Offset: 1080}},
+		},
+		{
+			in: in{image: image{
+				1024 + 56: le16(0xef53),
+				1024 + 92: le32(0x4),
+			}},
+			out: out{res: Result{Type: "ext3", UUID: "00000000-0000-0000-0000-000000000000", Offset: 1080}},
+		},
+		{
+			in: in{image: image{
+				1024 + 56: le16(0xef53),
+				1024 + 92: le32(0x4),
+				1024 + 96: le32(0x40),
+			}},
+			out: out{res: Result{Type: "ext4", UUID: "00000000-0000-0000-0000-000000000000", Offset: 1080}},
+		},
+		{
+			in: in{image: image{
+				0:   []byte("XFSB"),
+				32:  testUUID,
+				108: []byte("root"),
+			}},
+			out: out{res: Result{Type: "xfs", UUID: testUUIDString, Label: "root"}},
+		},
+		{
+			in: in{image: image{
+				65536 + 32:  testUUID,
+				65536 + 64:  []byte("_BHRfS_M"),
+				65536 + 299: []byte("var"),
+			}},
+			out: out{res: Result{Type: "btrfs", UUID: testUUIDString, Label: "var", Offset: 65600}},
+		},
+		{
+			in: in{image: image{
+				67:  []byte{0xd4, 0xc3, 0xb2, 0xa1},
+				71:  []byte("EFI-SYSTEM "),
+				82:  []byte("FAT32   "),
+				510: []byte{0x55, 0xaa},
+			}},
+			out: out{res: Result{Type: "vfat", UUID: "A1B2-C3D4", Label: "EFI-SYSTEM", Offset: 510}},
+		},
+		{
+			in: in{image: image{
+				39:  []byte{0xd4, 0xc3, 0xb2, 0xa1},
+				43:  []byte("NO NAME    "),
+				54:  []byte("FAT16   "),
+				510: []byte{0x55, 0xaa},
+			}},
+			out: out{res: Result{Type: "vfat", UUID: "A1B2-C3D4", Offset: 510}},
+		},
+		{
+			// a DOS partition table is not a filesystem
+			in:  in{image: image{440: le32(0x89abcdef), 510: []byte{0x55, 0xaa}}},
+			out: out{res: Result{Type: "dos", UUID: "89abcdef", Offset: 510}},
+		},
+		{
+			in: in{image: image{
+				510:      []byte{0x55, 0xaa},
+				512:      []byte("EFI PART"),
+				512 + 56: testUUID,
+			}},
+			out: out{res: Result{Type: "gpt", UUID: "67452301-ab89-efcd-0123-456789abcdef", Offset: 512}},
+		},
+		{
+			in: in{image: image{
+				4096 + 24: []byte{0xc6, 0x85, 0x73, 0xf6, 0x4e, 0x1a, 0x45, 0xca, 0x82, 0x65, 0xf5, 0x7f, 0x48, 0xba, 0x6d, 0x81},
+				4096 + 40: testUUID,
+				// a leftover filesystem superblock is ignored
+				1024 + 56: le16(0xef53),
+			}},
+			out: out{res: Result{Type: "bcache", UUID: testUUIDString, Offset: 4120}},
+		},
+		{
+			in:  in{image: image{384 * 1024: []byte{0x0c, 0xb1, 0xba, 0x00}}},
+			out: out{res: Result{Type: "zfs_member", Offset: 384 * 1024}},
+		},
+		{
+			in: in{image: image{
+				1024:       le32(0xf2f52010),
+				1024 + 108: testUUID,
+			}},
+			out: out{res: Result{Type: "f2fs", UUID: testUUIDString, Offset: 1024}},
+		},
+		{
+			in: in{image: image{
+				32769:        []byte("BEA01"),
+				32769 + 2048: []byte("NSR02"),
+			}},
+			out: out{res: Result{Type: "udf", Offset: 32769 + 2048}},
+		},
+		{
+			// data without a known signature isn't mistaken for a
+			// blank device
+			in:  in{image: image{200 * 1024: []byte("data")}},
+			out: out{res: Result{Type: Unknown}},
+		},
+		{
+			// but data beyond the probed area is
+			in:  in{image: image{768 * 1024: []byte("data")}},
+			out: out{res: Result{}},
+		},
+		{
+			in: in{image: image{
+				1024 + 12: testUUID,
+				1024 + 28: []byte("swap"),
+				4096 - 10: []byte("SWAPSPACE2"),
+			}},
+			out: out{res: Result{Type: "swap", UUID: testUUIDString, Label: "swap", Offset: 4086}},
+		},
+		{
+			in: in{image: image{
+				0:   []byte("LUKS\xba\xbe\x00\x02"),
+				24:  []byte("secret"),
+				168: []byte(testUUIDString),
+				// a leftover filesystem superblock is ignored
+				1024 + 56: le16(0xef53),
+			}},
+			out: out{res: Result{Type: "crypto_LUKS", UUID: testUUIDString, Label: "secret"}},
+		},
+		{
+			in: in{image: image{
+				4096:      le32(0xa92b4efc),
+				4096 + 4:  le32(1),
+				4096 + 16: testUUID,
+				4096 + 32: []byte("host:md0"),
+			}},
+			out: out{res: Result{Type: "linux_raid_member", UUID: testUUIDString, Label: "host:md0", Offset: 4096}},
+		},
+		{
+			in: in{image: image{
+				1024*1024 - 64*1024: le32(0xa92b4efc),
+			}},
+			out: out{res: Result{Type: "linux_raid_member", Offset: 1024*1024 - 64*1024}},
+		},
+		{
+			in: in{image: image{
+				512:      []byte("LABELONE"),
+				512 + 24: []byte("LVM2 001abcdefghijklmnopqrstuvwxyz012345"),
+			}},
+			out: out{res: Result{Type: "LVM2_member", UUID: "abcdef-ghij-klmn-opqr-stuv-wxyz-012345", Offset: 512}},
+		},
+		{
+			in: in{image: image{
+				32769: []byte("CD001"),
+				32808: []byte("INSTALL   "),
+			}},
+			out: out{res: Result{Type: "iso9660", Label: "INSTALL", Offset: 32769}},
+		},
+		{
+			in: in{image: image{
+				3:  []byte("NTFS    "),
+				72: []byte{0xef, 0xcd, 0xab, 0x89, 0x67, 0x45, 0x23, 0x01},
+			}},
+			out: out{res: Result{Type: "ntfs", UUID: "0123456789ABCDEF", Offset: 3}},
+		},
+	}
+
+	for i, test := range tests {
+		const size = 1024 * 1024
+		res := Probe(bytes.NewReader(test.in.image.build(size)), size)
+		if res != test.out.res {
+			t.Errorf("#%d: bad result: want %+v, got %+v", i, test.out.res, res)
+		}
+	}
+}
+
+func TestProbeSmallDevice(t *testing.T) {
+	res := Probe(bytes.NewReader([]byte("tiny")), 4)
+	if res != (Result{Type: Unknown}) {
+		t.Errorf("bad result: want %+v, got %+v", Result{Type: Unknown}, res)
+	}
+	res = Probe(bytes.NewReader(make([]byte, 4)), 4)
+	if res != (Result{}) {
+		t.Errorf("bad result: want %+v, got %+v", Result{}, res)
+	}
+}
+
+// TestDeviceMkfs checks the prober against images created by the real tools,
+// if they are installed.
+func TestDeviceMkfs(t *testing.T) {
+	tests := []struct {
+		format string
+		args   []string
+	}{
+		{"ext4", []string{"mkfs.ext4", "-q", "-F", "-L", "ROOT", "-U", testUUIDString}},
+		{"swap", []string{"mkswap", "-L", "SWAP", "-U", testUUIDString}},
+	}
+
+	dir, err := ioutil.TempDir("", "probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range tests {
+		if _, err := exec.LookPath(test.args[0]); err != nil {
+			t.Logf("skipping %s: %v", test.format, err)
+			continue
+		}
+		path := filepath.Join(dir, test.format)
+		if err := ioutil.WriteFile(path, make([]byte, 16*1024*1024), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(test.args[0], append(test.args[1:], path)...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v: %s", test.format, err, out)
+		}
+		res, err := Device(path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if res.Type != test.format || res.UUID != testUUIDString {
+			t.Errorf("%s: bad result: %+v", test.format, res)
+		}
+	}
+}
@@ -88,7 +88,11 @@ func FetchFromOvfDevice(f *resource.Fetcher, ovfFsTypes []string) (types.Config,
 		}
 		// wait for the actual config drive to appear
 		// if it's not shown up yet
-		time.Sleep(time.Second)
+		select {
+		case <-time.After(time.Second):
+		case <-f.BaseContext().Done():
+			return types.Config{}, report.Report{}, f.BaseContext().Err()
+		}
 	}
 }
 
@@ -103,7 +107,9 @@ func getRawConfig(f *resource.Fetcher, devicePath string, fstype string) ([]byte
 
 	logger.Debug("mounting config device")
 	if err := logger.LogOp(
-		func() error { return syscall.Mount(devicePath, mnt, fstype, syscall.MS_RDONLY, "") },
+		func() error {
+			return syscall.Mount(devicePath, mnt, fstype, syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "")
+		},
 		"mounting %q at %q", devicePath, mnt,
 	); err != nil {
 		return nil, fmt.Errorf("failed to mount device %q at %q: %v", devicePath, mnt, err)
@@ -162,3 +168,12 @@ func isCdromPresent(logger *log.Logger, devicePath string) bool {
 
 	return (status == CDS_DISC_OK)
 }
+
+// NewFetcher returns a fetcher authenticating azblob fetches with the VM's
+// managed identity.
+func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
+	return resource.Fetcher{
+		Logger:           l,
+		AzureCredentials: resource.NewAzureCredentials(l),
+	}, nil
+}
@@ -13,7 +13,8 @@
 // limitations under the License.
 
 // The cmdline provider fetches a remote configuration from the URL specified
-// in the kernel boot option "flatcar.config.url".
+// in the kernel boot option "flatcar.config.url", failing over to the URLs
+// given by "ignition.config.mirror" options in order.
 
 package cmdline
 
@@ -35,61 +36,83 @@ const (
 	cmdlineUrlFlagLegacyCoreOS = "coreos.config.url"
 	cmdlineUrlFlagLegacy       = "flatcar.config.url"
 	cmdlineUrlFlag             = "ignition.config.url"
+	cmdlineMirrorFlag          = "ignition.config.mirror"
 )
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
-	url, err := readCmdline(f.Logger)
+	mirrors, r, err := readCmdline(f.Logger)
 	if err != nil {
-		return types.Config{}, report.Report{}, err
+		return types.Config{}, r, err
 	}
 
-	if url == nil {
-		return types.Config{}, report.Report{}, providers.ErrNoProvider
+	if mirrors == nil {
+		return types.Config{}, r, providers.ErrNoProvider
 	}
 
-	data, err := f.FetchToBuffer(*url, resource.FetchOptions{
-		Headers: resource.ConfigHeaders,
-	})
+	data, _, err := f.FetchToBufferFromMirrors(mirrors, resource.FetchOptions{
+		Headers:    resource.ConfigHeaders,
+		MaxSize:    distro.MaxConfigSize(),
+		RejectHTML: true,
+	}, nil)
 	if err != nil {
-		return types.Config{}, report.Report{}, err
+		return types.Config{}, r, err
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	cfg, pr, err := util.ParseConfig(f.Logger, data)
+	r.Merge(pr)
+	return cfg, r, err
 }
 
-func readCmdline(logger *log.Logger) (*url.URL, error) {
+func readCmdline(logger *log.Logger) ([]resource.Mirror, report.Report, error) {
+	var r report.Report
 	args, err := ioutil.ReadFile(distro.KernelCmdlinePath())
 	if err != nil {
 		logger.Err("couldn't read cmdline: %v", err)
-		return nil, err
+		return nil, r, err
 	}
 
-	rawUrl := parseCmdline(args)
+	flag, rawUrl, rawMirrors := parseCmdline(args)
 	logger.Debug("parsed url from cmdline: %q", rawUrl)
 	if rawUrl == "" {
+		if len(rawMirrors) > 0 {
+			r.Merge(providers.Warning("cmdline", "ignoring config mirrors without a config URL"))
+		}
 		logger.Info("no config URL provided")
-		return nil, nil
+		return nil, r, nil
+	}
+	if flag != cmdlineUrlFlag {
+		r.Merge(providers.Warning("cmdline", "the %s kernel option is deprecated, use %s", flag, cmdlineUrlFlag))
 	}
 
-	url, err := url.Parse(rawUrl)
-	if err != nil {
-		logger.Err("failed to parse url: %v", err)
-		return nil, err
+	var mirrors []resource.Mirror
+	for _, raw := range append([]string{rawUrl}, rawMirrors...) {
+		url, err := url.Parse(raw)
+		if err != nil {
+			logger.Err("failed to parse url: %v", err)
+			return nil, r, err
+		}
+		mirrors = append(mirrors, resource.Mirror{URL: *url})
 	}
 
-	return url, err
+	return mirrors, r, nil
 }
 
-func parseCmdline(cmdline []byte) (url string) {
+// parseCmdline returns the config URL, the kernel option it was given by, and
+// the mirrors of the config.
+func parseCmdline(cmdline []byte) (flag, url string, mirrors []string) {
 	for _, arg := range strings.Split(string(cmdline), " ") {
 		parts := strings.SplitN(strings.TrimSpace(arg), "=", 2)
 		key := parts[0]
 
 		if key == cmdlineUrlFlagLegacy || key == cmdlineUrlFlagLegacyCoreOS || key == cmdlineUrlFlag {
 			if len(parts) == 2 {
+				flag = key
 				url = parts[1]
 			}
 		}
+		if key == cmdlineMirrorFlag && len(parts) == 2 && parts[1] != "" {
+			mirrors = append(mirrors, parts[1])
+		}
 	}
 
 	return
@@ -0,0 +1,70 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCmdline(t *testing.T) {
+	type in struct {
+		cmdline string
+	}
+	type out struct {
+		flag    string
+		url     string
+		mirrors []string
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{cmdline: "console=ttyS0 root=LABEL=ROOT"},
+			out: out{},
+		},
+		{
+			in:  in{cmdline: "ignition.config.url=http://example.com/config.ign ignition.config.mirror=http://mirror.example.com/config.ign"},
+			out: out{flag: "ignition.config.url", url: "http://example.com/config.ign", mirrors: []string{"http://mirror.example.com/config.ign"}},
+		},
+		{
+			in:  in{cmdline: "flatcar.config.url=http://example.com/config.ign\n"},
+			out: out{flag: "flatcar.config.url", url: "http://example.com/config.ign"},
+		},
+		{
+			in:  in{cmdline: "coreos.config.url=http://example.com/config.ign"},
+			out: out{flag: "coreos.config.url", url: "http://example.com/config.ign"},
+		},
+		{
+			in:  in{cmdline: "ignition.config.mirror=http://mirror.example.com/config.ign"},
+			out: out{mirrors: []string{"http://mirror.example.com/config.ign"}},
+		},
+	}
+
+	for i, test := range tests {
+		flag, url, mirrors := parseCmdline([]byte(test.in.cmdline))
+		if flag != test.out.flag {
+			t.Errorf("#%d: bad flag: want %q, got %q", i, test.out.flag, flag)
+		}
+		if url != test.out.url {
+			t.Errorf("#%d: bad url: want %q, got %q", i, test.out.url, url)
+		}
+		if !reflect.DeepEqual(mirrors, test.out.mirrors) {
+			t.Errorf("#%d: bad mirrors: want %v, got %v", i, test.out.mirrors, mirrors)
+		}
+	}
+}
@@ -23,21 +23,37 @@ import (
 	"github.com/flatcar/ignition/config/validate/report"
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/providers"
 	"github.com/flatcar/ignition/internal/providers/util"
 	"github.com/flatcar/ignition/internal/resource"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
+// userdataLimit is the size EC2 limits user data to. Tools creating instances
+// cut off longer user data.
+const userdataLimit = 16 * 1024
+
 var (
 	userdataUrl = url.URL{
 		Scheme: "http",
 		Host:   "169.254.169.254",
 		Path:   "2009-04-04/user-data",
 	}
+	instanceTagsUrl = url.URL{
+		Scheme: "http",
+		Host:   "169.254.169.254",
+		Path:   "latest/meta-data/tags/instance/",
+	}
+	metadataUrls = map[string]url.URL{
+		providers.MetadataInstanceID: {Scheme: "http", Host: "169.254.169.254", Path: "latest/meta-data/instance-id"},
+		providers.MetadataRegion:     {Scheme: "http", Host: "169.254.169.254", Path: "latest/meta-data/placement/region"},
+		providers.MetadataHostname:   {Scheme: "http", Host: "169.254.169.254", Path: "latest/meta-data/local-hostname"},
+		providers.MetadataLocalIPv4:  {Scheme: "http", Host: "169.254.169.254", Path: "latest/meta-data/local-ipv4"},
+		providers.MetadataPublicIPv4: {Scheme: "http", Host: "169.254.169.254", Path: "latest/meta-data/public-ipv4"},
+	}
 )
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
@@ -55,7 +71,28 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	}
 	f.S3RegionHint = regionHint
 
-	return util.ParseConfig(f.Logger, data)
+	var r report.Report
+	if len(data) >= userdataLimit {
+		r.Merge(providers.Warning("ec2", "user data is at the limit of %d bytes and may have been truncated; supply larger configs through ignition.config.replace", userdataLimit))
+	}
+	cfg, pr, err := util.ParseConfig(f.Logger, data)
+	r.Merge(pr)
+	return cfg, r, err
+}
+
+// ResolveFragment looks up the URL of the named config fragment in the
+// instance's tags. Access to tags in instance metadata must be enabled for the
+// instance.
+func ResolveFragment(f *resource.Fetcher, name string) (*url.URL, error) {
+	tagUrl := instanceTagsUrl
+	tagUrl.Path += util.FragmentKey(name)
+	return util.ResolveFragmentFromMetadata(f, tagUrl, nil)
+}
+
+// FetchMetadata returns the instance ID, region, hostname, and IP addresses of
+// the instance.
+func FetchMetadata(f *resource.Fetcher) (map[string]string, error) {
+	return util.FetchMetadataAttributes(f, metadataUrls, nil)
 }
 
 func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
@@ -63,7 +100,8 @@ func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
 	if err != nil {
 		return resource.Fetcher{}, err
 	}
-	sess.Config.Credentials = ec2rolecreds.NewCredentials(sess)
+	// the SDK's provider only speaks IMDSv1, which instances may disable
+	sess.Config.Credentials = resource.NewIMDSCredentials(l)
 
 	return resource.Fetcher{
 		Logger:     l,
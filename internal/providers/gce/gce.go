@@ -12,39 +12,177 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// The gce provider fetches a remote configuration from the gce user-data
-// metadata service URL.
+// The gce provider fetches a remote configuration from the gce metadata
+// service. The instance config is read from the first instance attribute that
+// is set out of "ignition" and "user-data". A project-wide base config is read
+// the same way from the project attributes, and the instance config is
+// appended to it. Project attributes holding a cloud-config or a script
+// rather than a config are ignored in favor of the instance config.
 
 package gce
 
 import (
+	"net/http"
 	"net/url"
+	"path"
+	"strings"
 
+	"github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/config/validate/report"
+	"github.com/flatcar/ignition/internal/config"
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/providers"
 	"github.com/flatcar/ignition/internal/providers/util"
 	"github.com/flatcar/ignition/internal/resource"
 )
 
 var (
-	userdataUrl = url.URL{
+	attributesUrl = url.URL{
 		Scheme: "http",
 		Host:   "metadata.google.internal",
-		Path:   "computeMetadata/v1/instance/attributes/user-data",
+		Path:   "computeMetadata/v1/instance/attributes/",
+	}
+	projectAttributesUrl = url.URL{
+		Scheme: "http",
+		Host:   "metadata.google.internal",
+		Path:   "computeMetadata/v1/project/attributes/",
+	}
+	metadataUrls = map[string]url.URL{
+		providers.MetadataInstanceID: {Scheme: "http", Host: "metadata.google.internal", Path: "computeMetadata/v1/instance/id"},
+		providers.MetadataHostname:   {Scheme: "http", Host: "metadata.google.internal", Path: "computeMetadata/v1/instance/hostname"},
+		providers.MetadataLocalIPv4:  {Scheme: "http", Host: "metadata.google.internal", Path: "computeMetadata/v1/instance/network-interfaces/0/ip"},
+		providers.MetadataPublicIPv4: {Scheme: "http", Host: "metadata.google.internal", Path: "computeMetadata/v1/instance/network-interfaces/0/access-configs/0/external-ip"},
+	}
+	zoneUrl = url.URL{
+		Scheme: "http",
+		Host:   "metadata.google.internal",
+		Path:   "computeMetadata/v1/instance/zone",
 	}
 	metadataHeaderKey = "Metadata-Flavor"
 	metadataHeaderVal = "Google"
+
+	// configKeys are the metadata attributes that may hold the config, in
+	// order of precedence.
+	configKeys = []string{"ignition", "user-data"}
 )
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
-	headers := resource.ConfigHeaders
-	headers.Set(metadataHeaderKey, metadataHeaderVal)
-	data, err := f.FetchToBuffer(userdataUrl, resource.FetchOptions{
-		Headers: headers,
-	})
-	if err != nil && err != resource.ErrNotFound {
+	projectData, err := fetchFirstAttribute(f, projectAttributesUrl)
+	if err != nil {
 		return types.Config{}, report.Report{}, err
 	}
+	instanceData, err := fetchFirstAttribute(f, attributesUrl)
+	if err != nil {
+		return types.Config{}, report.Report{}, err
+	}
+
+	if len(projectData) == 0 {
+		return util.ParseConfig(f.Logger, instanceData)
+	}
+
+	projectCfg, r, err := util.ParseConfig(f.Logger, projectData)
+	switch err {
+	case nil:
+	case errors.ErrCloudConfig, errors.ErrScript, errors.ErrEmpty:
+		if len(instanceData) == 0 {
+			return types.Config{}, r, err
+		}
+		f.Logger.Info("%v: using instance config only", err)
+		instanceCfg, instanceR, err := util.ParseConfig(f.Logger, instanceData)
+		r.Merge(instanceR)
+		return instanceCfg, r, err
+	default:
+		f.Logger.Err("failed to parse project-level config: %v", err)
+		return types.Config{}, r, err
+	}
+	if len(instanceData) == 0 {
+		f.Logger.Info("no instance config found, using project-level config")
+		return projectCfg, r, nil
+	}
+
+	instanceCfg, instanceR, err := util.ParseConfig(f.Logger, instanceData)
+	r.Merge(instanceR)
+	switch err {
+	case nil:
+	case errors.ErrCloudConfig, errors.ErrScript, errors.ErrEmpty:
+		f.Logger.Info("%v: using project-level config only", err)
+		return projectCfg, r, nil
+	default:
+		return types.Config{}, r, err
+	}
+
+	f.Logger.Info("appending instance config to project-level config")
+	return config.Append(projectCfg, instanceCfg), r, nil
+}
+
+// fetchFirstAttribute returns the contents of the first attribute out of
+// configKeys that is set below base. If none of them are set, nil is returned.
+func fetchFirstAttribute(f *resource.Fetcher, base url.URL) ([]byte, error) {
+	headers := http.Header{}
+	for name, values := range resource.ConfigHeaders {
+		headers[name] = values
+	}
+	headers.Set(metadataHeaderKey, metadataHeaderVal)
+
+	for _, key := range configKeys {
+		u := base
+		u.Path += key
+		data, err := f.FetchToBuffer(u, resource.FetchOptions{
+			Headers: headers,
+		})
+		if err == resource.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			return data, nil
+		}
+	}
+	return nil, nil
+}
+
+// FetchMetadata returns the instance ID, region, hostname, and IP addresses of
+// the instance.
+func FetchMetadata(f *resource.Fetcher) (map[string]string, error) {
+	headers := http.Header{}
+	headers.Set(metadataHeaderKey, metadataHeaderVal)
+	attrs, err := util.FetchMetadataAttributes(f, metadataUrls, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// The zone is reported as "projects/<number>/zones/<region>-<zone>"
+	zone, err := util.FetchMetadataAttributes(f, map[string]url.URL{"zone": zoneUrl}, headers)
+	if err != nil {
+		return nil, err
+	}
+	if z, ok := zone["zone"]; ok {
+		z = path.Base(z)
+		if i := strings.LastIndex(z, "-"); i > 0 {
+			attrs[providers.MetadataRegion] = z[:i]
+		}
+	}
+	return attrs, nil
+}
+
+// ResolveFragment looks up the URL of the named config fragment in the
+// instance's custom metadata attributes.
+func ResolveFragment(f *resource.Fetcher, name string) (*url.URL, error) {
+	attrUrl := attributesUrl
+	attrUrl.Path += util.FragmentKey(name)
+	return util.ResolveFragmentFromMetadata(f, attrUrl, map[string][]string{
+		metadataHeaderKey: {metadataHeaderVal},
+	})
+}
 
-	return util.ParseConfig(f.Logger, data)
+// NewFetcher returns a fetcher authenticating gs fetches with the instance's
+// service account.
+func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
+	return resource.Fetcher{
+		Logger:         l,
+		GCSCredentials: resource.NewGCSCredentials(l),
+	}, nil
 }
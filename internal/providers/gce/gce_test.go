@@ -0,0 +1,138 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gce
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func unitConfig(name string) string {
+	return `{"ignition": {"version": "2.4.0"}, "systemd": {"units": [{"name": "` + name + `"}]}}`
+}
+
+func TestFetchConfig(t *testing.T) {
+	var attributes map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(metadataHeaderKey) != metadataHeaderVal {
+			http.Error(w, "missing metadata header", http.StatusForbidden)
+			return
+		}
+		value, ok := attributes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(value))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(instance, project url.URL) {
+		attributesUrl, projectAttributesUrl = instance, project
+	}(attributesUrl, projectAttributesUrl)
+	attributesUrl.Host = u.Host
+	projectAttributesUrl.Host = u.Host
+
+	const (
+		instance = "/computeMetadata/v1/instance/attributes/"
+		project  = "/computeMetadata/v1/project/attributes/"
+	)
+
+	type out struct {
+		units []string
+		err   error
+	}
+
+	tests := []struct {
+		in  map[string]string
+		out out
+	}{
+		{
+			in:  map[string]string{},
+			out: out{err: errors.ErrEmpty},
+		},
+		{
+			// project only
+			in:  map[string]string{project + "ignition": unitConfig("project.service")},
+			out: out{units: []string{"project.service"}},
+		},
+		{
+			// instance only, from user-data
+			in:  map[string]string{instance + "user-data": unitConfig("instance.service")},
+			out: out{units: []string{"instance.service"}},
+		},
+		{
+			// the instance config is appended to the project's
+			in: map[string]string{
+				project + "user-data": unitConfig("project.service"),
+				instance + "ignition": unitConfig("instance.service"),
+			},
+			out: out{units: []string{"project.service", "instance.service"}},
+		},
+		{
+			in: map[string]string{
+				project + "ignition":   unitConfig("project.service"),
+				instance + "user-data": "#cloud-config\nhostname: example\n",
+			},
+			out: out{units: []string{"project.service"}},
+		},
+		{
+			in: map[string]string{
+				project + "user-data": "#cloud-config\nhostname: example\n",
+				instance + "ignition": unitConfig("instance.service"),
+			},
+			out: out{units: []string{"instance.service"}},
+		},
+		{
+			in: map[string]string{
+				project + "user-data":  "#!/bin/sh\necho project\n",
+				instance + "user-data": unitConfig("instance.service"),
+			},
+			out: out{units: []string{"instance.service"}},
+		},
+		{
+			in:  map[string]string{project + "user-data": "#!/bin/sh\necho project\n"},
+			out: out{err: errors.ErrScript},
+		},
+	}
+
+	for i, test := range tests {
+		attributes = test.in
+		logger := log.New(true)
+		f := resource.Fetcher{Logger: &logger}
+		cfg, _, err := FetchConfig(&f)
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			continue
+		}
+		var units []string
+		for _, unit := range cfg.Systemd.Units {
+			units = append(units, unit.Name)
+		}
+		if !reflect.DeepEqual(units, test.out.units) {
+			t.Errorf("#%d: bad units: want %v, got %v", i, test.out.units, units)
+		}
+	}
+}
@@ -52,7 +52,7 @@ var (
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	var data []byte
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(f.BaseContext(), 30*time.Second)
 
 	dispatch := func(name string, fn func() ([]byte, error)) {
 		raw, err := fn()
@@ -84,6 +84,9 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	})
 
 	<-ctx.Done()
+	if err := f.BaseContext().Err(); err != nil {
+		return types.Config{}, report.Report{}, err
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		f.Logger.Info("neither config drive nor metadata service were available in time. Continuing without a config...")
 	}
@@ -113,7 +116,7 @@ func fetchConfigFromDevice(logger *log.Logger, ctx context.Context, path string)
 	}
 	defer os.Remove(mnt)
 
-	cmd := exec.Command(distro.MountCmd(), "-o", "ro", "-t", "auto", path, mnt)
+	cmd := exec.Command(distro.MountCmd(), "-o", "ro,nosuid,nodev,noexec", "-t", "auto", path, mnt)
 	if _, err := logger.LogCmd(cmd, "mounting config drive"); err != nil {
 		return nil, err
 	}
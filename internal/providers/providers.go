@@ -16,6 +16,8 @@ package providers
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 
 	"github.com/flatcar/ignition/config/validate/report"
 	"github.com/flatcar/ignition/internal/config/types"
@@ -24,9 +26,35 @@ import (
 )
 
 var (
-	ErrNoProvider = errors.New("config provider was not online")
+	ErrNoProvider         = errors.New("config provider was not online")
+	ErrNoFragmentResolver = errors.New("config provider cannot resolve config fragments")
+	ErrFragmentNotFound   = errors.New("config fragment not found")
+	ErrMissingConfig      = errors.New("no config supplied, but the platform requires one")
 )
 
+// Metadata attributes that providers can report about the instance. They are
+// written to the metadata attributes file, prefixed with "IGNITION_".
+const (
+	MetadataInstanceID = "INSTANCE_ID"
+	MetadataRegion     = "REGION"
+	MetadataHostname   = "HOSTNAME"
+	MetadataLocalIPv4  = "LOCAL_IPV4"
+	MetadataPublicIPv4 = "PUBLIC_IPV4"
+)
+
+// Warning returns a report with a warning of the named provider, e.g. about
+// the way the config was supplied, so that it's reported along with the
+// warnings about the config rather than only logged.
+func Warning(provider, format string, a ...interface{}) report.Report {
+	return report.Report{Entries: []report.Entry{{
+		Kind:    report.EntryWarning,
+		Message: fmt.Sprintf(format, a...),
+		Source:  provider,
+	}}}
+}
+
 type FuncFetchConfig func(f *resource.Fetcher) (types.Config, report.Report, error)
 type FuncNewFetcher func(logger *log.Logger) (resource.Fetcher, error)
 type FuncPostStatus func(stageName string, f resource.Fetcher, e error) error
+type FuncResolveFragment func(f *resource.Fetcher, name string) (*url.URL, error)
+type FuncFetchMetadata func(f *resource.Fetcher) (map[string]string, error)
@@ -0,0 +1,66 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/providers"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+const (
+	fragmentKeyPrefix = "ignition-fragment-"
+)
+
+// FragmentKey returns the name of the metadata key (instance tag, metadata
+// attribute, ...) holding the URL of the named config fragment. Characters
+// other than letters, digits and dashes, which are commonly not allowed in
+// metadata keys, are escaped as an underscore followed by their two hex
+// digits, so that every name has a key of its own: "role:worker" maps to
+// "ignition-fragment-role_3aworker" and "role-worker" to
+// "ignition-fragment-role-worker". Fragment names are ASCII.
+func FragmentKey(name string) string {
+	var key strings.Builder
+	key.WriteString(fragmentKeyPrefix)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			key.WriteByte(c)
+		default:
+			fmt.Fprintf(&key, "_%02x", c)
+		}
+	}
+	return key.String()
+}
+
+// ResolveFragmentFromMetadata fetches the metadata value at u and parses it as
+// the URL of a config fragment. A missing value is reported as
+// providers.ErrFragmentNotFound.
+func ResolveFragmentFromMetadata(f *resource.Fetcher, u url.URL, headers map[string][]string) (*url.URL, error) {
+	data, err := f.FetchToBuffer(u, resource.FetchOptions{
+		Headers: headers,
+	})
+	if err == resource.ErrNotFound || (err == nil && len(strings.TrimSpace(string(data))) == 0) {
+		return nil, providers.ErrFragmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(strings.TrimSpace(string(data)))
+}
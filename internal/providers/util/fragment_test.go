@@ -0,0 +1,101 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/providers"
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+func TestFragmentKey(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "network", out: "ignition-fragment-network"},
+		{in: "role-worker", out: "ignition-fragment-role-worker"},
+		{in: "role:worker", out: "ignition-fragment-role_3aworker"},
+		{in: "role.worker", out: "ignition-fragment-role_2eworker"},
+		{in: "role_worker", out: "ignition-fragment-role_5fworker"},
+		{in: "role_3aworker", out: "ignition-fragment-role_5f3aworker"},
+		{in: "pool_2.gpu-large", out: "ignition-fragment-pool_5f2_2egpu-large"},
+	}
+
+	keys := map[string]string{}
+	for i, test := range tests {
+		key := FragmentKey(test.in)
+		if key != test.out {
+			t.Errorf("#%d: bad key: want %q, got %q", i, test.out, key)
+		}
+		if name, ok := keys[key]; ok {
+			t.Errorf("#%d: %q has the same key as %q", i, test.in, name)
+		}
+		keys[key] = test.in
+	}
+}
+
+func TestResolveFragmentFromMetadata(t *testing.T) {
+	values := map[string]string{
+		"/ignition-fragment-role_3aworker": " https://configs.example.com/worker.ign\n",
+		"/ignition-fragment-empty":         "\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		v, ok := values[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(v))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{in: "role:worker", out: "https://configs.example.com/worker.ign"},
+		{in: "role-worker", err: providers.ErrFragmentNotFound},
+		{in: "empty", err: providers.ErrFragmentNotFound},
+	}
+
+	logger := log.New(true)
+	f := resource.Fetcher{Logger: &logger}
+	for i, test := range tests {
+		u, err := url.Parse(server.URL + "/" + FragmentKey(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ResolveFragmentFromMetadata(&f, *u, map[string][]string{"Metadata-Flavor": {"Google"}})
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+			continue
+		}
+		if err == nil && got.String() != test.out {
+			t.Errorf("#%d: bad url: want %q, got %q", i, test.out, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/resource"
+)
+
+// FetchMetadataAttributes fetches each of the given metadata URLs and returns
+// the values keyed by attribute name. Attributes that the metadata service
+// does not know about (e.g. the public IP of an instance without one) are
+// left out.
+func FetchMetadataAttributes(f *resource.Fetcher, attrs map[string]url.URL, headers http.Header) (map[string]string, error) {
+	res := map[string]string{}
+	for name, u := range attrs {
+		data, err := f.FetchToBuffer(u, resource.FetchOptions{
+			Headers: headers,
+		})
+		if err == resource.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if value := strings.TrimSpace(string(data)); value != "" {
+			res[name] = value
+		}
+	}
+	return res, nil
+}
@@ -19,6 +19,7 @@ package vmware
 
 import (
 	"net/url"
+	"strings"
 
 	"github.com/flatcar/ignition/config/validate/report"
 	"github.com/flatcar/ignition/internal/config/types"
@@ -36,19 +37,28 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, providers.ErrNoProvider
 	}
 
-	config, err := fetchDataConfig(f)
+	var r report.Report
+	config, err := fetchDataConfig(f, &r)
 	if err == nil && len(config) == 0 {
-		config, err = fetchUrlConfig(f)
+		config, err = fetchUrlConfig(f, &r)
 	}
 	if err != nil {
-		return types.Config{}, report.Report{}, err
+		return types.Config{}, r, err
 	}
 
 	f.Logger.Debug("config successfully fetched")
-	return util.ParseConfig(f.Logger, config)
+	cfg, pr, err := util.ParseConfig(f.Logger, config)
+	r.Merge(pr)
+	return cfg, r, err
 }
 
-func fetchDataConfig(f *resource.Fetcher) ([]byte, error) {
+// deprecatedVariable reports the use of a guestinfo variable with the legacy
+// "coreos." prefix.
+func deprecatedVariable(r *report.Report, key string) {
+	r.Merge(providers.Warning("vmware", "the guestinfo.%s variable is deprecated, use guestinfo.%s", key, strings.Replace(key, "coreos.", "ignition.", 1)))
+}
+
+func fetchDataConfig(f *resource.Fetcher, r *report.Report) ([]byte, error) {
 	var data string
 	var encoding string
 	var err error
@@ -59,6 +69,7 @@ func fetchDataConfig(f *resource.Fetcher) ([]byte, error) {
 	} else {
 		data, err = getVariable(f, "coreos.config.data")
 		if err == nil && data != "" {
+			deprecatedVariable(r, "coreos.config.data")
 			encoding, err = getVariable(f, "coreos.config.data.encoding")
 		}
 	}
@@ -80,10 +91,13 @@ func fetchDataConfig(f *resource.Fetcher) ([]byte, error) {
 	return decodedData, nil
 }
 
-func fetchUrlConfig(f *resource.Fetcher) ([]byte, error) {
+func fetchUrlConfig(f *resource.Fetcher, r *report.Report) ([]byte, error) {
 	rawUrl, err := getVariable(f, "ignition.config.url")
 	if err != nil || rawUrl == "" {
 		rawUrl, err = getVariable(f, "coreos.config.url")
+		if err == nil && rawUrl != "" {
+			deprecatedVariable(r, "coreos.config.url")
+		}
 	}
 	if err != nil || rawUrl == "" {
 		f.Logger.Info("no config URL provided")
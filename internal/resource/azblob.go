@@ -0,0 +1,180 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+const (
+	// azureBlobDomain is the domain of the blob endpoints of storage
+	// accounts, served at https://<account>.blob.core.windows.net.
+	azureBlobDomain = "blob.core.windows.net"
+	// azureStorageVersion is the version of the Blob service API requested.
+	// Bearer tokens are accepted from 2017-11-09 on.
+	azureStorageVersion = "2020-04-08"
+
+	azureIMDSEndpoint = "http://169.254.169.254"
+	azureTokenPath    = "/metadata/identity/oauth2/token"
+	azureAPIVersion   = "2018-02-01"
+	azureStorage      = "https://storage.azure.com/"
+
+	// azureRefreshWindow is the time before it expires that a token is
+	// renewed, so that no fetch uses it expired.
+	azureRefreshWindow = time.Minute
+)
+
+// AzureCredentials are the access tokens of the managed identity of the
+// Azure VM, taken from the instance metadata service and renewed as they
+// expire.
+type AzureCredentials struct {
+	// Endpoint is the metadata service. It defaults to the one of Azure.
+	Endpoint string
+
+	// Client makes the requests. If left nil, one dialing through the
+	// network stack and interface configured for the distro is created.
+	Client *http.Client
+
+	// CurrentTime returns the time tokens expire against. It defaults to
+	// time.Now.
+	CurrentTime func() time.Time
+
+	Logger *log.Logger
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewAzureCredentials returns the credentials of the VM's managed identity.
+func NewAzureCredentials(l *log.Logger) *AzureCredentials {
+	return &AzureCredentials{Logger: l}
+}
+
+// azureToken is the document the metadata service describes a token with.
+// It gives the lifetime of the token as a string.
+type azureToken struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresIn   json.Number `json:"expires_in"`
+	TokenType   string      `json:"token_type"`
+}
+
+func (c *AzureCredentials) now() time.Time {
+	if c.CurrentTime != nil {
+		return c.CurrentTime()
+	}
+	return time.Now()
+}
+
+// Token returns an access token of the managed identity for Azure Storage,
+// requesting a new one if there's none or it's about to expire.
+func (c *AzureCredentials) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	if c.Client == nil {
+		client, err := metadataHTTPClient(c.Logger)
+		if err != nil {
+			return "", err
+		}
+		c.Client = client
+	}
+	endpoint := azureIMDSEndpoint
+	if c.Endpoint != "" {
+		endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	}
+	query := url.Values{"api-version": {azureAPIVersion}, "resource": {azureStorage}}
+	req, err := http.NewRequest(http.MethodGet, endpoint+azureTokenPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	start := c.now()
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("managed identity token: %s", resp.Status)
+	}
+	var token azureToken
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding the managed identity token: %v", err)
+	}
+	expiresIn, err := token.ExpiresIn.Int64()
+	if err != nil {
+		return "", fmt.Errorf("managed identity token expiring in %q", token.ExpiresIn)
+	}
+	if token.AccessToken == "" || !strings.EqualFold(token.TokenType, "Bearer") {
+		return "", fmt.Errorf("managed identity token of type %q", token.TokenType)
+	}
+	c.token = token.AccessToken
+	c.expiry = start.Add(time.Duration(expiresIn)*time.Second - azureRefreshWindow)
+	return c.token, nil
+}
+
+// FetchFromAzblob fetches the blob of u, azblob://account/container/blob,
+// into dest over https. The fetch is authenticated with the token of the
+// VM's managed identity if the fetcher has AzureCredentials; without them,
+// or if no token can be had, the blob is fetched anonymously, which works
+// for containers with public access only.
+func (f *Fetcher) FetchFromAzblob(u url.URL, dest io.Writer, opts FetchOptions) error {
+	blob := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || len(strings.SplitN(blob, "/", 2)) != 2 {
+		return configErrors.ErrInvalidAzblobUrl
+	}
+	api := &url.URL{
+		Scheme: "https",
+		Host:   u.Host + "." + azureBlobDomain,
+		Path:   "/" + blob,
+	}
+	if f.azblobEndpoint != "" {
+		var err error
+		if api, err = url.Parse(strings.TrimSuffix(f.azblobEndpoint, "/")); err != nil {
+			return err
+		}
+		api.Path += "/" + u.Host + "/" + blob
+	}
+
+	headers := http.Header{}
+	for name, values := range opts.Headers {
+		headers[name] = values
+	}
+	if f.AzureCredentials != nil {
+		token, err := f.AzureCredentials.Token()
+		if err != nil {
+			f.Logger.Warning("fetching %q anonymously, failed to get the token of the VM's managed identity: %v", u.String(), err)
+		} else {
+			headers.Set("Authorization", "Bearer "+token)
+			headers.Set("x-ms-version", azureStorageVersion)
+		}
+	}
+	opts.Headers = headers
+	return f.fetch(*api, dest, opts)
+}
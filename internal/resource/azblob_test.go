@@ -0,0 +1,105 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestFetchFromAzblob(t *testing.T) {
+	tokens := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != azureTokenPath || r.Header.Get("Metadata") != "true" || q.Get("resource") != azureStorage || q.Get("api-version") == "" {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		tokens++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": "3599", "expires_on": "1700000000", "resource": %q, "token_type": "Bearer"}`, tokens, azureStorage)
+	}))
+	defer metadata.Close()
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized := r.Header.Get("Authorization") == fmt.Sprintf("Bearer token-%d", tokens) && r.Header.Get("x-ms-version") != ""
+		switch {
+		case r.URL.Path == "/account/public/file":
+			fmt.Fprint(w, "public")
+		case r.URL.Path == "/account/private/dir/file" && authorized:
+			fmt.Fprint(w, "private")
+		case r.URL.Path == "/account/private/dir/file":
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer storage.Close()
+
+	now := time.Now()
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, azblobEndpoint: storage.URL}
+	fetch := func(s string) (string, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.FetchToBuffer(*u, FetchOptions{})
+		return string(data), err
+	}
+
+	// anonymous
+	if data, err := fetch("azblob://account/public/file"); err != nil || data != "public" {
+		t.Errorf("public blob: got %q, %v", data, err)
+	}
+	if _, err := fetch("azblob://account/private/dir/file"); err == nil {
+		t.Errorf("private blob fetched anonymously")
+	}
+	if _, err := fetch("azblob://account/public/missing"); err != ErrNotFound {
+		t.Errorf("missing blob: want %v, got %v", ErrNotFound, err)
+	}
+
+	f.AzureCredentials = &AzureCredentials{
+		Endpoint:    metadata.URL,
+		Client:      metadata.Client(),
+		CurrentTime: func() time.Time { return now },
+		Logger:      &logger,
+	}
+	for i := 0; i < 2; i++ {
+		if data, err := fetch("azblob://account/private/dir/file"); err != nil || data != "private" {
+			t.Errorf("#%d: private blob: got %q, %v", i, data, err)
+		}
+	}
+	if tokens != 1 {
+		t.Errorf("%d tokens requested, want the first reused", tokens)
+	}
+
+	// tokens are renewed as they expire
+	now = now.Add(time.Hour - azureRefreshWindow)
+	if data, err := fetch("azblob://account/private/dir/file"); err != nil || data != "private" || tokens != 2 {
+		t.Errorf("expired token: got %q, %v with %d tokens", data, err, tokens)
+	}
+
+	// without a token the blob is fetched anonymously
+	f.AzureCredentials = &AzureCredentials{Endpoint: storage.URL, Client: storage.Client(), Logger: &logger}
+	if data, err := fetch("azblob://account/public/file"); err != nil || data != "public" {
+		t.Errorf("public blob without a token: got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+
+	"github.com/flatcar/ignition/internal/config/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// clientCertificates loads the certificates presented to https servers
+// asking for one. Of several, the first one issued by a CA the server
+// accepts is presented.
+func (f *Fetcher) clientCertificates(certs []types.ClientCertificate) ([]tls.Certificate, error) {
+	var res []tls.Certificate
+	for _, c := range certs {
+		certPEM, keyPEM, err := f.clientCertificateBlobs(c)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			f.Logger.Err("Unable to load client certificate (%s): %v", clientCertificateSourceForLog(c.Certificate), err)
+			return nil, err
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+		f.Logger.Info("Presenting client certificate %q to servers asking for one", leaf.Subject.CommonName)
+		res = append(res, pair)
+	}
+	return res, nil
+}
+
+// clientCertificateBlobs fetches the PEM encoded certificate chain and
+// private key of c from their data or oem urls.
+func (f *Fetcher) clientCertificateBlobs(c types.ClientCertificate) ([]byte, []byte, error) {
+	var blobs [2][]byte
+	for i, source := range []string{c.Certificate, c.PrivateKey} {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		blobs[i], err = f.FetchToBuffer(*u, FetchOptions{})
+		if err != nil {
+			f.Logger.Err("Unable to fetch client certificate (%s): %v", clientCertificateSourceForLog(source), err)
+			return nil, nil, err
+		}
+	}
+	return blobs[0], blobs[1], nil
+}
+
+// rewriteClientCertificatesWithDataUrls returns certs with their
+// certificates and keys fetched into data urls, e.g. to pass them to the
+// fetch helper, which can't read the OEM partition.
+func (f *Fetcher) rewriteClientCertificatesWithDataUrls(certs []types.ClientCertificate) ([]types.ClientCertificate, error) {
+	var res []types.ClientCertificate
+	for _, c := range certs {
+		certPEM, keyPEM, err := f.clientCertificateBlobs(c)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, types.ClientCertificate{
+			Certificate: dataurl.EncodeBytes(certPEM),
+			PrivateKey:  dataurl.EncodeBytes(keyPEM),
+		})
+	}
+	return res, nil
+}
+
+// clientCertificateSourceForLog returns the source of a client certificate
+// or key to be logged. Data urls contain the key itself.
+func clientCertificateSourceForLog(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme == "data" {
+		return "data url"
+	}
+	return source
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/earlyrand"
+	"github.com/flatcar/ignition/internal/fips"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/roughtime"
+)
+
+const (
+	// clockTimeout bounds a single query of the time source.
+	clockTimeout = 10 * time.Second
+	// clockRetryInterval is the time after which a failed query is
+	// retried by the next TLS handshake.
+	clockRetryInterval = 10 * time.Second
+)
+
+var (
+	ErrTimeSourceScheme = errors.New("time source must be an http, https or roughtime url")
+
+	// tlsClock is shared by all clients, so the time source is queried
+	// once per process.
+	tlsClock = &clock{}
+)
+
+// clock gives the time TLS certificates are checked against. If a time
+// source is configured, the time is taken from it, so that machines whose
+// real time clock is wildly wrong, e.g. because its battery is dead, can
+// still fetch over https. Otherwise, it's the system time.
+type clock struct {
+	mu          sync.Mutex
+	synced      bool
+	lastAttempt time.Time
+	// offset is the difference of the time source to the system clock.
+	offset time.Duration
+}
+
+// Now returns the current time, querying the time source on first use or
+// if querying it failed before. The source is queried with the proxy, dialer
+// and TLS settings of transport, the Fetcher's, if set.
+func (c *clock) Now(logger *log.Logger, transport *http.Transport) time.Time {
+	source := distro.TimeSource()
+	if source == "" {
+		return time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.synced && (c.lastAttempt.IsZero() || time.Since(c.lastAttempt) > clockRetryInterval) {
+		c.lastAttempt = time.Now()
+		t, err := queryTimeSource(source, transport)
+		if err != nil {
+			logger.Warning("couldn't get the time from %s, checking certificates against the system clock: %v", source, err)
+		} else {
+			c.offset = time.Until(t)
+			c.synced = true
+			logger.Info("checking certificates against the time from %s: %v (system clock off by %v)", source, t.UTC().Format(time.RFC3339), c.offset.Round(time.Second))
+		}
+	}
+	return time.Now().Add(c.offset)
+}
+
+// queryTimeSource returns the time given by source: the Date header of the
+// response to a HEAD request for http(s) urls, or the time signed by the
+// server for roughtime://host:port/<base64 public key> urls.
+func queryTimeSource(source string, transport *http.Transport) (time.Time, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return queryDate(*u, transport)
+	case "roughtime":
+		encoded := strings.TrimPrefix(u.Path, "/")
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			key, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		}
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return time.Time{}, fmt.Errorf("invalid roughtime public key %q", encoded)
+		}
+		urand, err := earlyrand.UrandomReader()
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, _, err := roughtime.Query(u.Host, ed25519.PublicKey(key), urand, clockTimeout)
+		return t, err
+	default:
+		return time.Time{}, ErrTimeSourceScheme
+	}
+}
+
+// queryDate returns the Date header of u's response, requested through a
+// copy of base, the Fetcher's transport, so the config's proxy and the TLS
+// policy apply. https servers have to present a certificate of an authority
+// in base's roots, or the system's if there are none, for their name, but
+// it's checked at the time it became valid rather than the current time,
+// which is unknown.
+func queryDate(u url.URL, base *http.Transport) (time.Time, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	tlsConfig := &tls.Config{}
+	if base != nil {
+		transport = base.Clone()
+		if base.TLSClientConfig != nil {
+			tlsConfig = base.TLSClientConfig.Clone()
+		}
+	}
+	// the clock isn't known yet, so the checks against it are left out
+	tlsConfig.Time = nil
+	tlsConfig.VerifyConnection = nil
+	// verified below, without the time
+	tlsConfig.InsecureSkipVerify = true
+	roots := tlsConfig.RootCAs
+	tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		return verifyIgnoringTime(rawCerts, u.Hostname(), roots)
+	}
+	if fips.Enabled() {
+		fips.RestrictTLS(tlsConfig)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := http.Client{
+		Timeout:   clockTimeout,
+		Transport: transport,
+	}
+	resp, err := client.Head(u.String())
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, fmt.Errorf("no Date header in the response")
+	}
+	return http.ParseTime(date)
+}
+
+// verifyIgnoringTime verifies the certificate chain rawCerts for host
+// against roots at the latest time at which all of them became valid.
+func verifyIgnoringTime(rawCerts [][]byte, host string, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no certificate presented")
+	}
+	var certs []*x509.Certificate
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       host,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range certs {
+		if cert.NotBefore.After(opts.CurrentTime) {
+			opts.CurrentTime = cert.NotBefore
+		}
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
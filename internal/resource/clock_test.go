@@ -0,0 +1,210 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestClock(t *testing.T) {
+	date := time.Date(2034, time.March, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+	defer os.Unsetenv("IGNITION_TIME_SOURCE")
+
+	logger := log.New(true)
+	type in struct {
+		source string
+	}
+	type out struct {
+		synced bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{source: ""},
+			out: out{},
+		},
+		{
+			in:  in{source: srv.URL},
+			out: out{synced: true},
+		},
+		{
+			in:  in{source: "ftp://time.example.com"},
+			out: out{},
+		},
+		{
+			in:  in{source: "roughtime://127.0.0.1:2002/not-a-key"},
+			out: out{},
+		},
+	}
+
+	for i, test := range tests {
+		os.Setenv("IGNITION_TIME_SOURCE", test.in.source)
+		c := &clock{}
+		now := c.Now(&logger, nil)
+		want := time.Now()
+		if test.out.synced {
+			want = date
+		}
+		if d := now.Sub(want); d < -5*time.Second || d > 5*time.Second {
+			t.Errorf("#%d: bad time: want about %v, got %v", i, want, now)
+		}
+		if c.synced != test.out.synced {
+			t.Errorf("#%d: bad synced: want %v, got %v", i, test.out.synced, c.synced)
+		}
+	}
+
+	// the time is kept once the source is down
+	os.Setenv("IGNITION_TIME_SOURCE", srv.URL)
+	c := &clock{}
+	c.Now(&logger, nil)
+	srv.Close()
+	if d := c.Now(&logger, nil).Sub(date); d < -5*time.Second || d > 5*time.Second {
+		t.Errorf("bad time after the source went down: want about %v, got %v", date, c.Now(&logger, nil))
+	}
+}
+
+func TestClockProxy(t *testing.T) {
+	date := time.Date(2034, time.March, 1, 12, 0, 0, 0, time.UTC)
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+	}))
+	defer proxy.Close()
+	defer os.Unsetenv("IGNITION_TIME_SOURCE")
+	os.Setenv("IGNITION_TIME_SOURCE", "http://time.example.com/")
+
+	// the source is queried through the proxy of the Fetcher's transport
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	logger := log.New(true)
+	c := &clock{}
+	now := c.Now(&logger, transport)
+	if !c.synced {
+		t.Fatalf("clock not synced")
+	}
+	if d := now.Sub(date); d < -5*time.Second || d > 5*time.Second {
+		t.Errorf("bad time: want about %v, got %v", date, now)
+	}
+	if proxied != "http://time.example.com/" {
+		t.Errorf("bad proxied request: want %q, got %q", "http://time.example.com/", proxied)
+	}
+}
+
+func TestVerifyIgnoringTime(t *testing.T) {
+	newCert := func(tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+	caTemplate := func(name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:              time.Date(2120, time.January, 1, 0, 0, 0, 0, time.UTC),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+	}
+	ca, caKey := newCert(caTemplate("ca"), nil, nil)
+	other, _ := newCert(caTemplate("other"), nil, nil)
+	// only valid in the future, as seen from a machine whose clock is
+	// stuck in the past
+	leaf, _ := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "time.example.com"},
+		DNSNames:     []string{"time.example.com"},
+		NotBefore:    time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2101, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+
+	pool := func(cert *x509.Certificate) *x509.CertPool {
+		p := x509.NewCertPool()
+		p.AddCert(cert)
+		return p
+	}
+
+	type in struct {
+		host  string
+		roots *x509.CertPool
+	}
+	type out struct {
+		err bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{host: "time.example.com", roots: pool(ca)},
+			out: out{},
+		},
+		{
+			in:  in{host: "evil.example.com", roots: pool(ca)},
+			out: out{err: true},
+		},
+		{
+			in:  in{host: "time.example.com", roots: pool(other)},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		err := verifyIgnoringTime([][]byte{leaf.Raw}, test.in.host, test.in.roots)
+		if test.out.err != (err != nil) {
+			t.Errorf("#%d: bad error: want error %v, got %v", i, test.out.err, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+var (
+	ErrDecompressedTooLarge = errors.New("resource exceeds the maximum size once decompressed")
+	ErrCompressionRatio     = errors.New("resource exceeds the maximum compression ratio, it might be a decompression bomb")
+)
+
+// ratioGrace is the decompressed size up to which the compression ratio
+// isn't checked, as small resources like files of zeros compress to next to
+// nothing without being a threat.
+const ratioGrace = 16 << 20
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// bombGuard fails reading the decompressed stream once it's larger than
+// maxSize, or larger than maxRatio times the compressed stream read so far,
+// so that a tiny compressed resource can't fill the memory or disks of the
+// initramfs.
+type bombGuard struct {
+	io.ReadCloser
+	compressed *countingReader
+	n          int64
+	maxSize    int64
+	maxRatio   int64
+}
+
+// guardDecompression guards reading the stream decompressed from compressed
+// with the limits of the distribution.
+func guardDecompression(decompressed io.ReadCloser, compressed *countingReader) io.ReadCloser {
+	return &bombGuard{
+		ReadCloser: decompressed,
+		compressed: compressed,
+		maxSize:    distro.MaxDecompressedSize(),
+		maxRatio:   distro.MaxCompressionRatio(),
+	}
+}
+
+func (g *bombGuard) Read(p []byte) (int, error) {
+	n, err := g.ReadCloser.Read(p)
+	g.n += int64(n)
+	if g.maxSize > 0 && g.n > g.maxSize {
+		return 0, ErrDecompressedTooLarge
+	}
+	if g.maxRatio > 0 && g.n > ratioGrace && g.n/g.maxRatio > g.compressed.n {
+		return 0, ErrCompressionRatio
+	}
+	return n, err
+}
+
+// commandReader decompresses a stream with a command like zstd or xz,
+// which initramfs images carry to decompress themselves, rather than
+// linking in a decoder.
+type commandReader struct {
+	cmd    *exec.Cmd
+	out    io.ReadCloser
+	stderr bytes.Buffer
+	done   bool
+	err    error
+	// compression names the format in errors
+	compression string
+}
+
+func (f *Fetcher) newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	cmdPath := distro.ZstdCmd()
+	if f.zstdCmd != "" {
+		cmdPath = f.zstdCmd
+	}
+	return f.newCommandReader(r, "zstd", cmdPath)
+}
+
+func (f *Fetcher) newXzReader(r io.Reader) (io.ReadCloser, error) {
+	cmdPath := distro.XzCmd()
+	if f.xzCmd != "" {
+		cmdPath = f.xzCmd
+	}
+	return f.newCommandReader(r, "xz", cmdPath)
+}
+
+// newCommandReader returns the stream of r decompressed by cmdPath, which
+// takes the options of zstd and xz.
+func (f *Fetcher) newCommandReader(r io.Reader, compression, cmdPath string) (io.ReadCloser, error) {
+	z := &commandReader{
+		cmd:         exec.CommandContext(f.BaseContext(), cmdPath, "--decompress", "--stdout", "--quiet"),
+		compression: compression,
+	}
+	z.cmd.Stdin = r
+	z.cmd.Stderr = &z.stderr
+	out, err := z.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	z.out = out
+	if err := z.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("couldn't run %s: %v", cmdPath, err)
+	}
+	return z, nil
+}
+
+func (z *commandReader) Read(p []byte) (int, error) {
+	n, err := z.out.Read(p)
+	if err == io.EOF {
+		// the stream only ends well if the command exits successfully, e.g.
+		// rather than on truncated input
+		if werr := z.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close stops the command if the stream wasn't read to the end.
+func (z *commandReader) Close() error {
+	if !z.done {
+		z.cmd.Process.Kill()
+		z.wait()
+	}
+	return nil
+}
+
+func (z *commandReader) wait() error {
+	if !z.done {
+		z.done = true
+		if err := z.cmd.Wait(); err != nil {
+			if msg := strings.TrimSpace(z.stderr.String()); msg != "" {
+				err = fmt.Errorf("%v: %s", err, msg)
+			}
+			z.err = fmt.Errorf("decompressing %s: %v", z.compression, err)
+		}
+	}
+	return z.err
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestFetchCompressed(t *testing.T) {
+	for _, compression := range []string{"zstd", "xz"} {
+		testFetchCompressed(t, compression)
+	}
+}
+
+func testFetchCompressed(t *testing.T, compression string) {
+	cmdPath, err := exec.LookPath(compression)
+	if err != nil {
+		t.Logf("%s isn't installed, skipping", compression)
+		return
+	}
+	contents := []byte(strings.Repeat("Welcome to the machine\n", 1000))
+	cmd := exec.Command(cmdPath, "--compress", "--stdout", "--quiet")
+	cmd.Stdin = bytes.NewReader(contents)
+	compressed, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512(contents)
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, zstdCmd: cmdPath, xzCmd: cmdPath}
+
+	tests := []struct {
+		in      []byte
+		sum     []byte
+		wantErr bool
+	}{
+		{in: compressed, sum: sum[:]},
+		{in: compressed[:len(compressed)/2], wantErr: true},
+		{in: contents, wantErr: true},
+		{in: compressed, sum: make([]byte, sha512.Size), wantErr: true},
+	}
+
+	for i, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(test.in)
+		}))
+		u, err := url.Parse(srv.URL + "/contents." + compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := FetchOptions{Compression: compression}
+		if test.sum != nil {
+			opts.Hash = sha512.New()
+			opts.ExpectedSum = test.sum
+		}
+		out, err := f.FetchToBuffer(*u, opts)
+		srv.Close()
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s #%d: expected an error", compression, i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s #%d: unexpected error: %v", compression, i, err)
+		} else if !bytes.Equal(out, contents) {
+			t.Errorf("%s #%d: bad contents: want sha512 %s, got %q", compression, i, hex.EncodeToString(sum[:]), out)
+		}
+	}
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	compress := func(contents []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(contents)
+		zw.Close()
+		return buf.Bytes()
+	}
+	zeros := compress(make([]byte, 32<<20))
+	text := compress([]byte(strings.Repeat("Welcome to the machine\n", 1000)))
+
+	var served []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(served)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL + "/contents.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type in struct {
+		contents []byte
+		maxSize  string
+		maxRatio string
+	}
+
+	tests := []struct {
+		in  in
+		out error
+	}{
+		// gzip's ratio stays below the default limit
+		{in: in{contents: zeros}},
+		{in: in{contents: zeros, maxRatio: "100"}, out: ErrCompressionRatio},
+		{in: in{contents: zeros, maxRatio: "0"}},
+		// small contents aren't checked for their ratio
+		{in: in{contents: text, maxRatio: "2"}},
+		{in: in{contents: text, maxSize: "1000"}, out: ErrDecompressedTooLarge},
+		{in: in{contents: zeros, maxSize: "1000000000"}},
+	}
+
+	for i, test := range tests {
+		os.Setenv("IGNITION_MAX_DECOMPRESSED_SIZE", test.in.maxSize)
+		os.Setenv("IGNITION_MAX_COMPRESSION_RATIO", test.in.maxRatio)
+		served = test.in.contents
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		_, err := f.FetchToBuffer(*u, FetchOptions{Compression: "gzip"})
+		if err != test.out {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out, err)
+		}
+	}
+	os.Unsetenv("IGNITION_MAX_DECOMPRESSED_SIZE")
+	os.Unsetenv("IGNITION_MAX_COMPRESSION_RATIO")
+
+	// zstd compresses zeros far better than gzip, beyond the default limit
+	zstd, err := exec.LookPath("zstd")
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(zstd, "--compress", "--stdout", "--quiet", "-19")
+	cmd.Stdin = bytes.NewReader(make([]byte, 32<<20))
+	if served, err = cmd.Output(); err != nil {
+		t.Fatal(err)
+	}
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, zstdCmd: zstd}
+	if _, err := f.FetchToBuffer(*u, FetchOptions{Compression: "zstd"}); err != ErrCompressionRatio {
+		t.Errorf("zstd bomb: bad error: want %v, got %v", ErrCompressionRatio, err)
+	}
+}
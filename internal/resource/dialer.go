@@ -0,0 +1,384 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// Fetches connect to servers through a network stack. By default that's the
+// kernel's, but if the initramfs can't configure kernel networking, e.g.
+// because the driver of the NIC is only available later, a user-space stack
+// driving the NIC itself can be used instead. Stacks linked into Ignition
+// register themselves with RegisterNetworkStack; the socks5 stack reaches a
+// user-space stack running as its own process through a SOCKS5 endpoint.
+
+const (
+	// KernelNetworkStack is the name of the kernel's network stack.
+	KernelNetworkStack = "kernel"
+
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+var (
+	ErrDNSTimeout          = errors.New("name resolution timed out")
+	ErrNetworkStackUnknown = errors.New("unknown network stack")
+	ErrSOCKS               = errors.New("SOCKS5 endpoint refused the connection")
+	ErrTFTPNetworkStack    = errors.New("tftp is only supported by the kernel network stack")
+)
+
+// Dialer makes the connections of fetches.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NetworkStack returns a dialer connecting through the network stack at
+// endpoint, bound to the interface nic. An empty nic means any interface,
+// the meaning of endpoint is up to the stack.
+type NetworkStack func(nic, endpoint string) (Dialer, error)
+
+var networkStacks = map[string]NetworkStack{
+	KernelNetworkStack: kernelStack,
+	"socks5":           socksStack,
+}
+
+// RegisterNetworkStack makes the network stack available under name. It's
+// meant to be called by the init functions of stacks linked into Ignition.
+func RegisterNetworkStack(name string, stack NetworkStack) {
+	networkStacks[name] = stack
+}
+
+// NetworkStacks returns the names of the available network stacks.
+func NetworkStacks() []string {
+	var names []string
+	for name := range networkStacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDialer returns a dialer connecting through the network stack given as
+// its name, optionally followed by ":" and its endpoint, bound to the
+// interface nic.
+func NewDialer(stack, nic string) (Dialer, error) {
+	name, endpoint := stack, ""
+	if i := strings.Index(stack, ":"); i >= 0 {
+		name, endpoint = stack[:i], stack[i+1:]
+	}
+	s, ok := networkStacks[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %q", ErrNetworkStackUnknown, name)
+	}
+	return s(nic, endpoint)
+}
+
+// dialer returns the fetcher's dialer, setting it to one connecting through
+// the distro's network stack if it has none yet.
+func (f *Fetcher) dialer() (Dialer, error) {
+	if f.Dialer != nil {
+		return f.Dialer, nil
+	}
+	stack, nic := distro.NetworkStack(), distro.NetworkInterface()
+	dialer, err := NewDialer(stack, nic)
+	if err != nil {
+		return nil, fmt.Errorf("network stack %q: %v", stack, err)
+	}
+	if stack != KernelNetworkStack || nic != "" {
+		f.Logger.Info("fetching through network stack %q, interface %q", stack, nic)
+	}
+	logDials(dialer, f.Logger)
+	f.Dialer = dialer
+	return dialer, nil
+}
+
+// kernelStack dials through the kernel's network stack, resolving names
+// with the Go resolver. Connections, including those to name servers, are
+// bound to the interface nic if one is given.
+func kernelStack(nic, endpoint string) (Dialer, error) {
+	if endpoint != "" {
+		return nil, fmt.Errorf("the kernel network stack has no endpoint, got %q", endpoint)
+	}
+	var control func(network, address string, c syscall.RawConn) error
+	if nic != "" {
+		control = func(network, address string, c syscall.RawConn) error {
+			var err error
+			if ctlErr := c.Control(func(fd uintptr) {
+				err = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, nic)
+			}); ctlErr != nil {
+				return ctlErr
+			}
+			if err != nil {
+				return fmt.Errorf("binding to %q: %v", nic, err)
+			}
+			return nil
+		}
+	}
+	return &kernelDialer{
+		dialer: net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: dialKeepAlive,
+			Control:   control,
+			Resolver: &net.Resolver{
+				PreferGo: true,
+				Dial:     (&net.Dialer{Timeout: dialTimeout, Control: control}).DialContext,
+			},
+		},
+		dnsTimeout: distro.DNSTimeout(),
+	}, nil
+}
+
+// kernelDialer resolves names and connects to each of their addresses in
+// turn, bounding the resolution by its own timeout, so that a fetch timing
+// out can be told apart from a name server not answering. Each resolution
+// and connection attempt is logged, with its duration, if it has a logger.
+type kernelDialer struct {
+	dialer     net.Dialer
+	dnsTimeout time.Duration
+	logger     *log.Logger
+}
+
+// logDials makes dialer log its name resolutions and connection attempts
+// to logger, if it's the kernel network stack's.
+func logDials(dialer Dialer, logger *log.Logger) {
+	if d, ok := dialer.(*kernelDialer); ok {
+		d.logger = logger
+	}
+}
+
+func (d *kernelDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := d.resolve(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		target := net.JoinHostPort(ip.String(), port)
+		start := time.Now()
+		var conn net.Conn
+		conn, err = d.dialer.DialContext(ctx, network, target)
+		if err != nil {
+			d.log("connecting to %s (%s) failed after %v: %v", address, target, time.Since(start), err)
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		d.log("connected to %s (%s) in %v", address, target, time.Since(start))
+		return conn, nil
+	}
+	return nil, err
+}
+
+// resolve looks up the addresses of host usable with network, within the
+// dialer's timeout for name resolutions.
+func (d *kernelDialer) resolve(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	rctx := ctx
+	if d.dnsTimeout > 0 {
+		var cancel context.CancelFunc
+		rctx, cancel = context.WithTimeout(ctx, d.dnsTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	addrs, err := d.dialer.Resolver.LookupIPAddr(rctx, host)
+	if err != nil {
+		d.log("resolving %q failed after %v: %v", host, time.Since(start), err)
+		if ctx.Err() == nil && rctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("resolving %q: %v after %v", host, ErrDNSTimeout, d.dnsTimeout)
+		}
+		return nil, err
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		switch {
+		case network == "tcp4" || network == "udp4":
+			if addr.IP.To4() == nil {
+				continue
+			}
+		case network == "tcp6" || network == "udp6":
+			if addr.IP.To4() != nil {
+				continue
+			}
+		}
+		ips = append(ips, addr.IP)
+	}
+	d.log("resolved %q to %v in %v", host, ips, time.Since(start))
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host}
+	}
+	return ips, nil
+}
+
+func (d *kernelDialer) log(format string, a ...interface{}) {
+	if d.logger != nil {
+		d.logger.Info(format, a...)
+	}
+}
+
+// socksStack dials through a SOCKS5 endpoint, a unix socket given by its
+// absolute path or a host:port on the loopback interface, served by a
+// user-space network stack. Names are resolved by the stack. The stack
+// decides which interface it drives, so nic must be empty.
+func socksStack(nic, endpoint string) (Dialer, error) {
+	if endpoint == "" {
+		return nil, errors.New("the socks5 network stack needs an endpoint, e.g. socks5:/run/netstack.sock")
+	}
+	if nic != "" {
+		return nil, fmt.Errorf("the socks5 network stack can't be bound to %q, its endpoint's stack chooses the interface", nic)
+	}
+	network := "tcp"
+	if strings.HasPrefix(endpoint, "/") {
+		network = "unix"
+	}
+	return socksDialer{network: network, address: endpoint}, nil
+}
+
+type socksDialer struct {
+	network string
+	address string
+}
+
+// SOCKS5 (RFC 1928) constants
+const (
+	socksVersion   = 5
+	socksNoAuth    = 0
+	socksConnect   = 1
+	socksIPv4      = 1
+	socksDomain    = 3
+	socksIPv6      = 4
+	socksSucceeded = 0
+)
+
+func (d socksDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("the socks5 network stack only supports tcp, not %s", network)
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	conn, err := (&net.Dialer{Timeout: dialTimeout}).DialContext(ctx, d.network, d.address)
+	if err != nil {
+		return nil, err
+	}
+	// the handshake is bounded by the context like the dial
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+	if err := socksHandshake(conn, host, uint16(port)); err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("connecting to %s through %s: %v", address, d.address, err)
+	}
+	return conn, nil
+}
+
+// socksHandshake asks the SOCKS5 server on conn to connect to host:port,
+// without authentication.
+func socksHandshake(conn io.ReadWriter, host string, port uint16) error {
+	if _, err := conn.Write([]byte{socksVersion, 1, socksNoAuth}); err != nil {
+		return err
+	}
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion || reply[1] != socksNoAuth {
+		return ErrSOCKS
+	}
+
+	req := []byte{socksVersion, socksConnect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("host name %q is too long", host)
+		}
+		req = append(req, socksDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socksIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socksIPv6)
+		req = append(req, ip.To16()...)
+	}
+	req = append(req, 0, 0)
+	binary.BigEndian.PutUint16(req[len(req)-2:], port)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	var head [4]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return err
+	}
+	if head[0] != socksVersion {
+		return ErrSOCKS
+	}
+	if head[1] != socksSucceeded {
+		return fmt.Errorf("%v (reply %d)", ErrSOCKS, head[1])
+	}
+	// skip the address the server bound, and its port
+	var skip int
+	switch head[3] {
+	case socksIPv4:
+		skip = net.IPv4len + 2
+	case socksIPv6:
+		skip = net.IPv6len + 2
+	case socksDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return err
+		}
+		skip = int(l[0]) + 2
+	default:
+		return ErrSOCKS
+	}
+	_, err := io.CopyN(ioutil.Discard, conn, int64(skip))
+	return err
+}
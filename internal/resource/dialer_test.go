@@ -0,0 +1,202 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// serveSOCKS serves SOCKS5 connects on l like a user-space network stack
+// would, mapping the host name "config.example" to target and recording
+// the addresses asked for.
+func serveSOCKS(l net.Listener, target string, asked chan<- string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var b [262]byte
+			if _, err := io.ReadFull(conn, b[:3]); err != nil {
+				return
+			}
+			conn.Write([]byte{5, 0})
+			if _, err := io.ReadFull(conn, b[:5]); err != nil || b[3] != 3 {
+				return
+			}
+			name := make([]byte, int(b[4])+2)
+			if _, err := io.ReadFull(conn, name); err != nil {
+				return
+			}
+			host := string(name[:len(name)-2])
+			port := binary.BigEndian.Uint16(name[len(name)-2:])
+			asked <- net.JoinHostPort(host, strconv.Itoa(int(port)))
+			if host != "config.example" {
+				conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				return
+			}
+			defer upstream.Close()
+			conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 80})
+			go io.Copy(upstream, conn)
+			io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func TestSOCKSNetworkStack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ignition-socks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "netstack.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	asked := make(chan string, 10)
+	go serveSOCKS(l, srv.Listener.Addr().String(), asked)
+
+	dialer, err := NewDialer("socks5:"+sock, "")
+	if err != nil {
+		t.Fatalf("creating the dialer: %v", err)
+	}
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, Dialer: dialer}
+
+	// the name is resolved by the stack, not locally
+	u, _ := url.Parse("http://config.example/config.ign")
+	got, err := f.FetchToBuffer(*u, FetchOptions{})
+	if err != nil {
+		t.Fatalf("fetching: %v", err)
+	}
+	if string(got) != "contents" {
+		t.Errorf("fetched %q, want %q", got, "contents")
+	}
+	if a := <-asked; a != "config.example:80" {
+		t.Errorf("stack was asked for %q", a)
+	}
+
+	// fetches are retried until they time out
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	f.Context = ctx
+	u, _ = url.Parse("http://unreachable.example/config.ign")
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); err == nil {
+		t.Errorf("fetching through a refusing stack succeeded")
+	}
+	if a := <-asked; a != "unreachable.example:80" {
+		t.Errorf("stack was asked for %q", a)
+	}
+	f.Context = nil
+	if _, err := f.FetchToBuffer(url.URL{Scheme: "tftp", Host: "config.example", Path: "/config.ign"}, FetchOptions{}); err != ErrTFTPNetworkStack {
+		t.Errorf("tftp through the socks5 stack: want %v, got %v", ErrTFTPNetworkStack, err)
+	}
+}
+
+func TestNewDialer(t *testing.T) {
+	tests := []struct {
+		stack string
+		nic   string
+		ok    bool
+	}{
+		{stack: "kernel", ok: true},
+		{stack: "kernel", nic: "eth1", ok: true},
+		{stack: "kernel:/run/netstack.sock"},
+		{stack: "socks5:/run/netstack.sock", ok: true},
+		{stack: "socks5:127.0.0.1:1080", ok: true},
+		{stack: "socks5"},
+		{stack: "socks5:/run/netstack.sock", nic: "eth1"},
+		{stack: "netstack"},
+	}
+
+	for i, test := range tests {
+		_, err := NewDialer(test.stack, test.nic)
+		if (err == nil) != test.ok {
+			t.Errorf("#%d: %q on %q: unexpected error %v", i, test.stack, test.nic, err)
+		}
+	}
+}
+
+func TestKernelDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	dialer, err := NewDialer("kernel", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := dialer.(*kernelDialer)
+	logger := log.New(true)
+	logDials(d, &logger)
+	// a name server that never answers
+	d.dialer.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	d.dnsTimeout = 100 * time.Millisecond
+
+	conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatalf("dialing an address: %v", err)
+	}
+	conn.Close()
+
+	_, err = d.DialContext(context.Background(), "tcp", net.JoinHostPort("config.example", port))
+	if err == nil || !strings.Contains(err.Error(), ErrDNSTimeout.Error()) {
+		t.Errorf("resolving with a silent name server: want %v, got %v", ErrDNSTimeout, err)
+	}
+
+	// the timeout of the fetch still applies if it's shorter
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d.dnsTimeout = time.Minute
+	if _, err = d.DialContext(ctx, "tcp", net.JoinHostPort("config.example", port)); err == nil || strings.Contains(err.Error(), ErrDNSTimeout.Error()) {
+		t.Errorf("resolving within a fetch timing out: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+const (
+	// defaultGCSEndpoint serves the objects of Cloud Storage at
+	// /bucket/object.
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+
+	gceMetadataEndpoint = "http://metadata.google.internal"
+	gceTokenPath        = "/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// gcsRefreshWindow is the time before it expires that a token is
+	// renewed, so that no fetch uses it expired.
+	gcsRefreshWindow = time.Minute
+)
+
+// GCSCredentials are the access tokens of the service account of the GCE
+// instance, taken from the metadata server and renewed as they expire.
+type GCSCredentials struct {
+	// Endpoint is the metadata server. It defaults to the one of GCE.
+	Endpoint string
+
+	// Client makes the requests. If left nil, one dialing through the
+	// network stack and interface configured for the distro is created.
+	Client *http.Client
+
+	// CurrentTime returns the time tokens expire against. It defaults to
+	// time.Now.
+	CurrentTime func() time.Time
+
+	Logger *log.Logger
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewGCSCredentials returns the credentials of the instance's service
+// account.
+func NewGCSCredentials(l *log.Logger) *GCSCredentials {
+	return &GCSCredentials{Logger: l}
+}
+
+// gceToken is the document the metadata server describes a token with.
+type gceToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+func (c *GCSCredentials) now() time.Time {
+	if c.CurrentTime != nil {
+		return c.CurrentTime()
+	}
+	return time.Now()
+}
+
+// Token returns the access token of the service account, requesting a new
+// one if there's none or it's about to expire.
+func (c *GCSCredentials) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	if c.Client == nil {
+		client, err := metadataHTTPClient(c.Logger)
+		if err != nil {
+			return "", err
+		}
+		c.Client = client
+	}
+	endpoint := gceMetadataEndpoint
+	if c.Endpoint != "" {
+		endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+gceTokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	start := c.now()
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("service account token: %s", resp.Status)
+	}
+	var token gceToken
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding the service account token: %v", err)
+	}
+	if token.AccessToken == "" || !strings.EqualFold(token.TokenType, "Bearer") {
+		return "", fmt.Errorf("service account token of type %q", token.TokenType)
+	}
+	c.token = token.AccessToken
+	c.expiry = start.Add(time.Duration(token.ExpiresIn)*time.Second - gcsRefreshWindow)
+	return c.token, nil
+}
+
+// FetchFromGS fetches the Cloud Storage object of u, gs://bucket/object,
+// into dest over https. The fetch is authenticated with the token of the
+// instance's service account if the fetcher has GCSCredentials; without
+// them, or if no token can be had, the object is fetched anonymously,
+// which works for public objects only.
+func (f *Fetcher) FetchFromGS(u url.URL, dest io.Writer, opts FetchOptions) error {
+	object := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || object == "" {
+		return configErrors.ErrInvalidGSUrl
+	}
+	endpoint := f.gcsEndpoint
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+	api, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return err
+	}
+	api.Path += "/" + u.Host + "/" + object
+
+	headers := http.Header{}
+	for name, values := range opts.Headers {
+		headers[name] = values
+	}
+	if f.GCSCredentials != nil {
+		token, err := f.GCSCredentials.Token()
+		if err != nil {
+			f.Logger.Warning("fetching %q anonymously, failed to get the token of the instance's service account: %v", u.String(), err)
+		} else {
+			headers.Set("Authorization", "Bearer "+token)
+		}
+	}
+	opts.Headers = headers
+	return f.fetch(*api, dest, opts)
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestFetchFromGS(t *testing.T) {
+	tokens := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != gceTokenPath || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.NotFound(w, r)
+			return
+		}
+		tokens++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3599, "token_type": "Bearer"}`, tokens)
+	}))
+	defer metadata.Close()
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/public/file":
+			fmt.Fprint(w, "public")
+		case r.URL.Path == "/private/dir/file" && r.Header.Get("Authorization") == fmt.Sprintf("Bearer token-%d", tokens):
+			fmt.Fprint(w, "private")
+		case r.URL.Path == "/private/dir/file":
+			http.Error(w, "", http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer storage.Close()
+
+	now := time.Now()
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, gcsEndpoint: storage.URL}
+	fetch := func(s string) (string, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.FetchToBuffer(*u, FetchOptions{})
+		return string(data), err
+	}
+
+	// anonymous
+	if data, err := fetch("gs://public/file"); err != nil || data != "public" {
+		t.Errorf("public object: got %q, %v", data, err)
+	}
+	if _, err := fetch("gs://private/dir/file"); err == nil {
+		t.Errorf("private object fetched anonymously")
+	}
+	if _, err := fetch("gs://public/missing"); err != ErrNotFound {
+		t.Errorf("missing object: want %v, got %v", ErrNotFound, err)
+	}
+
+	f.GCSCredentials = &GCSCredentials{
+		Endpoint:    metadata.URL,
+		Client:      metadata.Client(),
+		CurrentTime: func() time.Time { return now },
+		Logger:      &logger,
+	}
+	for i := 0; i < 2; i++ {
+		if data, err := fetch("gs://private/dir/file"); err != nil || data != "private" {
+			t.Errorf("#%d: private object: got %q, %v", i, data, err)
+		}
+	}
+	if tokens != 1 {
+		t.Errorf("%d tokens requested, want the first reused", tokens)
+	}
+
+	// tokens are renewed as they expire
+	now = now.Add(time.Hour - gcsRefreshWindow)
+	if data, err := fetch("gs://private/dir/file"); err != nil || data != "private" || tokens != 2 {
+		t.Errorf("expired token: got %q, %v with %d tokens", data, err, tokens)
+	}
+
+	// without a token the object is fetched anonymously
+	f.GCSCredentials = &GCSCredentials{Endpoint: storage.URL, Client: storage.Client(), Logger: &logger}
+	if data, err := fetch("gs://public/file"); err != nil || data != "public" {
+		t.Errorf("public object without a token: got %q, %v", data, err)
+	}
+}
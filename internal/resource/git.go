@@ -0,0 +1,513 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+)
+
+// maxGitAdvertisementSize limits the capabilities and refs read from a git
+// server, which are small.
+const maxGitAdvertisementSize = 1 << 20
+
+var (
+	ErrGitProtocol     = errors.New("the git server doesn't support version 2 of the protocol")
+	ErrGitRefNotFound  = errors.New("no branch or tag of the repository has that name")
+	ErrGitPathNotFound = errors.New("path not found in the repository")
+	ErrGitNotAFile     = errors.New("path in the repository isn't a regular file")
+	ErrGitNotATree     = errors.New("path in the repository isn't a directory")
+
+	gitCommitIDRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// GitReference is a path in a repository at a ref, parsed from a url like
+// git+https://example.com/org/repo.git?ref=v1.2#path/in/repo.
+type GitReference struct {
+	// Repository is the https url of the repository.
+	Repository url.URL
+	// Ref is a branch, a tag, a full ref name or a commit ID. HEAD, the
+	// default branch, if empty.
+	Ref string
+	// Path is the path in the repository, the root if empty.
+	Path string
+}
+
+// ParseGitURL parses a git+https url.
+func ParseGitURL(u url.URL) (GitReference, error) {
+	if u.Scheme != "git+https" || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return GitReference{}, configErrors.ErrInvalidGitUrl
+	}
+	ref := GitReference{
+		Ref:  u.Query().Get("ref"),
+		Path: strings.Trim(u.Fragment, "/"),
+	}
+	if ref.Path != "" && (path.Clean(ref.Path) != ref.Path || ref.Path == ".." || strings.HasPrefix(ref.Path, "../")) {
+		return GitReference{}, configErrors.ErrInvalidGitUrl
+	}
+	ref.Repository = url.URL{
+		Scheme: "https",
+		User:   u.User,
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(u.Path, "/"),
+	}
+	return ref, nil
+}
+
+// gitSession talks version 2 of the git protocol over smart http to a
+// repository, with the headers of the fetch, see gitprotocol-v2(5).
+type gitSession struct {
+	f       *Fetcher
+	repo    url.URL
+	headers http.Header
+	// shallow is whether the server can fetch the commit without its
+	// history
+	shallow bool
+}
+
+func (s *gitSession) options(extra http.Header) FetchOptions {
+	headers := http.Header{"Git-Protocol": []string{"version=2"}}
+	for k, v := range s.headers {
+		headers[k] = v
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+	return FetchOptions{Headers: headers, MaxSize: maxGitAdvertisementSize}
+}
+
+func (s *gitSession) endpoint(p string) url.URL {
+	u := s.repo
+	if s.f.gitPlainHTTP {
+		u.Scheme = "http"
+	}
+	u.Path += p
+	return u
+}
+
+// capabilities reads the capabilities the server advertises, checking it
+// speaks version 2 with SHA-1 object IDs.
+func (s *gitSession) capabilities() error {
+	u := s.endpoint("/info/refs")
+	u.RawQuery = "service=git-upload-pack"
+	var buf bytes.Buffer
+	if err := s.f.fetch(u, &buf, s.options(nil)); err != nil {
+		return err
+	}
+	lines, err := readPktLines(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	// smart http may announce the service first
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# service=") {
+		lines = lines[1:]
+		if len(lines) > 0 && lines[0] == pktFlush {
+			lines = lines[1:]
+		}
+	}
+	if len(lines) == 0 || lines[0] != "version 2" {
+		return ErrGitProtocol
+	}
+	for _, l := range lines[1:] {
+		key, value := l, ""
+		if i := strings.IndexByte(l, '='); i >= 0 {
+			key, value = l[:i], l[i+1:]
+		}
+		switch key {
+		case "object-format":
+			if value != "sha1" {
+				return fmt.Errorf("unsupported object format %q of the git repository", value)
+			}
+		case "fetch":
+			for _, feature := range strings.Fields(value) {
+				if feature == "shallow" {
+					s.shallow = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// command runs a command of the protocol, returning the response.
+func (s *gitSession) command(name string, args []string) ([]byte, error) {
+	var req bytes.Buffer
+	writePktLine(&req, "command="+name+"\n")
+	req.WriteString(pktDelim)
+	for _, a := range args {
+		writePktLine(&req, a+"\n")
+	}
+	req.WriteString(pktFlush)
+
+	opts := s.options(http.Header{
+		"Content-Type": []string{"application/x-git-upload-pack-request"},
+		"Accept":       []string{"application/x-git-upload-pack-result"},
+	})
+	return s.f.PostToBuffer(s.endpoint("/git-upload-pack"), req.Bytes(), opts)
+}
+
+// resolve returns the ID of the commit the ref names, peeling annotated
+// tags. Commit IDs are taken as is.
+func (s *gitSession) resolve(ref string) (string, error) {
+	if gitCommitIDRegexp.MatchString(ref) {
+		return ref, nil
+	}
+	var names []string
+	switch {
+	case ref == "" || ref == "HEAD":
+		names = []string{"HEAD"}
+	case strings.HasPrefix(ref, "refs/"):
+		names = []string{ref}
+	default:
+		names = []string{"refs/heads/" + ref, "refs/tags/" + ref}
+	}
+	args := []string{"peel"}
+	for _, n := range names {
+		args = append(args, "ref-prefix "+n)
+	}
+	resp, err := s.command("ls-refs", args)
+	if err != nil {
+		return "", err
+	}
+	lines, err := readPktLines(resp)
+	if err != nil {
+		return "", err
+	}
+	refs := map[string]string{}
+	for _, l := range lines {
+		fields := strings.Fields(l)
+		if l == pktFlush || len(fields) < 2 {
+			continue
+		}
+		id := fields[0]
+		for _, attr := range fields[2:] {
+			if strings.HasPrefix(attr, "peeled:") {
+				id = strings.TrimPrefix(attr, "peeled:")
+			}
+		}
+		refs[fields[1]] = id
+	}
+	for _, n := range names {
+		if id, ok := refs[n]; ok && gitCommitIDRegexp.MatchString(id) {
+			return id, nil
+		}
+	}
+	return "", ErrGitRefNotFound
+}
+
+// fetchPack fetches the pack of the commit, without its history if the
+// server supports it.
+func (s *gitSession) fetchPack(commit string) (*gitPack, error) {
+	args := []string{"ofs-delta", "no-progress"}
+	if s.shallow {
+		args = append(args, "deepen 1")
+	}
+	args = append(args, "want "+commit, "done")
+	resp, err := s.command("fetch", args)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := readPackfileSection(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parsePack(pack)
+}
+
+// cloneGit fetches the commit the ref names and returns the pack and the ID
+// of its tree.
+func (f *Fetcher) cloneGit(ref GitReference, headers http.Header) (*gitPack, string, error) {
+	s := &gitSession{f: f, repo: ref.Repository, headers: headers}
+	if err := s.capabilities(); err != nil {
+		return nil, "", err
+	}
+	commit, err := s.resolve(ref.Ref)
+	if err != nil {
+		return nil, "", err
+	}
+	p, err := s.fetchPack(commit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch commit %s: %v", commit, err)
+	}
+	tree, err := p.commitTree(commit)
+	if err != nil {
+		return nil, "", err
+	}
+	repo := ref.Repository
+	repo.User = nil
+	f.Logger.Info("fetched commit %s of %s", commit, repo.String())
+	return p, tree, nil
+}
+
+// lookup returns the entry of the path below the tree, the tree itself if
+// the path is empty.
+func (p *gitPack) lookup(tree, name string) (gitTreeEntry, error) {
+	entry := gitTreeEntry{mode: gitModeDir, id: tree}
+	if name == "" {
+		return entry, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		if entry.mode != gitModeDir {
+			return gitTreeEntry{}, ErrGitPathNotFound
+		}
+		entries, err := p.tree(entry.id)
+		if err != nil {
+			return gitTreeEntry{}, err
+		}
+		found := false
+		for _, e := range entries {
+			if e.name == part {
+				entry, found = e, true
+				break
+			}
+		}
+		if !found {
+			return gitTreeEntry{}, ErrGitPathNotFound
+		}
+	}
+	return entry, nil
+}
+
+func (p *gitPack) tree(id string) ([]gitTreeEntry, error) {
+	typ, data, err := p.read(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != gitTree {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", id, gitTypeNames[typ])
+	}
+	return parseTree(data)
+}
+
+func (p *gitPack) blob(id string) ([]byte, error) {
+	typ, data, err := p.read(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != gitBlob {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", id, gitTypeNames[typ])
+	}
+	return data, nil
+}
+
+// FetchFromGit fetches the file at the path of the git+https url u into
+// dest, e.g. git+https://example.com/org/repo.git?ref=v1.2#etc/motd. The
+// commit the ref names is fetched without its history over smart http,
+// and the file found in its tree. The objects are verified against their
+// IDs, so that refs pinned to a commit ID are verified like hashes. The
+// headers of opts are sent with the requests, so that private repositories
+// can be fetched from.
+func (f *Fetcher) FetchFromGit(u url.URL, dest io.Writer, opts FetchOptions) error {
+	ref, err := ParseGitURL(u)
+	if err != nil {
+		return err
+	}
+	if ref.Path == "" {
+		return ErrGitNotAFile
+	}
+	p, tree, err := f.cloneGit(ref, opts.Headers)
+	if err != nil {
+		return err
+	}
+	e, err := p.lookup(tree, ref.Path)
+	if err != nil {
+		return err
+	}
+	if e.mode != gitModeFile && e.mode != gitModeExecutable {
+		return ErrGitNotAFile
+	}
+	data, err := p.blob(e.id)
+	if err != nil {
+		return err
+	}
+	return f.decompressCopyHashAndVerify(dest, bytes.NewReader(data), opts)
+}
+
+// GitTreeWriter writes the entries of a directory of a repository. Names
+// are relative to the directory, and parents come before their entries.
+type GitTreeWriter interface {
+	Mkdir(name string) error
+	WriteFile(name string, executable bool, contents []byte) error
+	Symlink(name, target string) error
+}
+
+// ExtractGitTree writes the directory at the path of the git+https url u,
+// the root of the repository if it has none, to w. Submodules are left
+// out, as they are other repositories.
+func (f *Fetcher) ExtractGitTree(u url.URL, opts FetchOptions, w GitTreeWriter) error {
+	ref, err := ParseGitURL(u)
+	if err != nil {
+		return err
+	}
+	p, tree, err := f.cloneGit(ref, opts.Headers)
+	if err != nil {
+		return err
+	}
+	e, err := p.lookup(tree, ref.Path)
+	if err != nil {
+		return err
+	}
+	if e.mode != gitModeDir {
+		return ErrGitNotATree
+	}
+	return f.writeGitTree(p, e.id, "", w)
+}
+
+func (f *Fetcher) writeGitTree(p *gitPack, id, dir string, w GitTreeWriter) error {
+	entries, err := p.tree(id)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := path.Join(dir, e.name)
+		switch e.mode {
+		case gitModeDir:
+			if err := w.Mkdir(name); err != nil {
+				return err
+			}
+			if err := f.writeGitTree(p, e.id, name, w); err != nil {
+				return err
+			}
+		case gitModeFile, gitModeExecutable:
+			data, err := p.blob(e.id)
+			if err != nil {
+				return err
+			}
+			if err := w.WriteFile(name, e.mode == gitModeExecutable, data); err != nil {
+				return err
+			}
+		case gitModeSymlink:
+			target, err := p.blob(e.id)
+			if err != nil {
+				return err
+			}
+			if err := w.Symlink(name, string(target)); err != nil {
+				return err
+			}
+		case gitModeSubmodule:
+			f.Logger.Warning("skipping submodule %q of the repository", name)
+		default:
+			return fmt.Errorf("%v: tree entry %q of mode %s", ErrGitPackCorrupt, name, e.mode)
+		}
+	}
+	return nil
+}
+
+// the special packets of the protocol
+const (
+	pktFlush = "0000"
+	pktDelim = "0001"
+	pktEnd   = "0002"
+)
+
+func writePktLine(b *bytes.Buffer, payload string) {
+	fmt.Fprintf(b, "%04x%s", len(payload)+4, payload)
+}
+
+// nextPkt returns the payload of the first packet of data, or the special
+// packet as is, and the rest of data.
+func nextPkt(data []byte) (string, []byte, error) {
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("git server sent a truncated response")
+	}
+	n, err := strconv.ParseUint(string(data[:4]), 16, 16)
+	if err != nil {
+		return "", nil, fmt.Errorf("git server sent an invalid packet length %q", data[:4])
+	}
+	switch {
+	case n < 4:
+		return string(data[:4]), data[4:], nil
+	case int(n) > len(data):
+		return "", nil, fmt.Errorf("git server sent a truncated response")
+	}
+	payload := string(data[4:n])
+	if strings.HasPrefix(payload, "ERR ") {
+		return "", nil, fmt.Errorf("git server: %s", strings.TrimSpace(payload[4:]))
+	}
+	return payload, data[n:], nil
+}
+
+// readPktLines returns the packets of a response, without their line
+// feeds.
+func readPktLines(data []byte) ([]string, error) {
+	var lines []string
+	for len(data) > 0 {
+		line, rest, err := nextPkt(data)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+		data = rest
+	}
+	return lines, nil
+}
+
+// readPackfileSection returns the pack of the response of a fetch, whose
+// data is multiplexed with progress and error messages on sidebands.
+func readPackfileSection(data []byte) ([]byte, error) {
+	// skip the sections before the pack, e.g. shallow-info
+	for {
+		line, rest, err := nextPkt(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+		if line == "packfile\n" {
+			break
+		}
+		for line != pktDelim {
+			if line == pktFlush || line == pktEnd {
+				return nil, fmt.Errorf("git server sent no pack")
+			}
+			if line, data, err = nextPkt(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var pack []byte
+	for {
+		line, rest, err := nextPkt(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+		if line == pktFlush || line == pktEnd {
+			return pack, nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case 1:
+			pack = append(pack, line[1:]...)
+		case 2:
+			// progress
+		case 3:
+			return nil, fmt.Errorf("git server: %s", strings.TrimSpace(line[1:]))
+		default:
+			return nil, fmt.Errorf("git server sent data on sideband %d", line[0])
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// testPack builds a pack, with objects stored whole or as deltas.
+type testPack struct {
+	buf     bytes.Buffer
+	count   uint32
+	offsets map[string]int
+}
+
+func (p *testPack) header(typ int, size int) {
+	c := byte(typ<<4) | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		p.buf.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+	p.buf.WriteByte(c)
+}
+
+func (p *testPack) deflate(data []byte) {
+	zw := zlib.NewWriter(&p.buf)
+	zw.Write(data)
+	zw.Close()
+}
+
+// add stores the object whole and returns its ID.
+func (p *testPack) add(typ int, contents []byte) string {
+	id := gitObjectID(typ, contents)
+	p.offsets[id] = 12 + p.buf.Len()
+	p.header(typ, len(contents))
+	p.deflate(contents)
+	p.count++
+	return id
+}
+
+// addOfsDelta stores the delta on the object of base, which must be in the
+// pack already.
+func (p *testPack) addOfsDelta(base string, delta []byte) {
+	off := 12 + p.buf.Len()
+	p.header(gitOfsDelta, len(delta))
+	dist := off - p.offsets[base]
+	enc := []byte{byte(dist & 0x7f)}
+	for dist >>= 7; dist > 0; dist >>= 7 {
+		dist--
+		enc = append([]byte{byte(0x80 | dist&0x7f)}, enc...)
+	}
+	p.buf.Write(enc)
+	p.deflate(delta)
+	p.count++
+}
+
+// addRefDelta stores the delta on the object of base, which may come later.
+func (p *testPack) addRefDelta(base string, delta []byte) {
+	p.header(gitRefDelta, len(delta))
+	id, _ := hex.DecodeString(base)
+	p.buf.Write(id)
+	p.deflate(delta)
+	p.count++
+}
+
+func (p *testPack) bytes() []byte {
+	var out bytes.Buffer
+	out.WriteString("PACK")
+	binary.Write(&out, binary.BigEndian, uint32(2))
+	binary.Write(&out, binary.BigEndian, p.count)
+	out.Write(p.buf.Bytes())
+	sum := sha1.Sum(out.Bytes())
+	out.Write(sum[:])
+	return out.Bytes()
+}
+
+// testDelta makes a delta copying the prefix of the base and appending
+// suffix.
+func testDelta(base []byte, prefix int, suffix string) []byte {
+	delta := []byte{byte(len(base)), byte(prefix + len(suffix))}
+	delta = append(delta, 0x80|0x10, byte(prefix))
+	delta = append(delta, byte(len(suffix)))
+	return append(delta, suffix...)
+}
+
+func testTree(entries ...gitTreeEntry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		id, _ := hex.DecodeString(e.id)
+		fmt.Fprintf(&buf, "%s %s\x00", e.mode, e.name)
+		buf.Write(id)
+	}
+	return buf.Bytes()
+}
+
+type recordingGitTree map[string]string
+
+func (r recordingGitTree) Mkdir(name string) error {
+	r[name] = "dir"
+	return nil
+}
+
+func (r recordingGitTree) WriteFile(name string, executable bool, contents []byte) error {
+	r[name] = fmt.Sprintf("file %v %s", executable, contents)
+	return nil
+}
+
+func (r recordingGitTree) Symlink(name, target string) error {
+	r[name] = "symlink " + target
+	return nil
+}
+
+func TestFetchFromGit(t *testing.T) {
+	p := &testPack{offsets: map[string]int{}}
+	motd := []byte("Welcome to the machine\n")
+	base := []byte("#!/bin/sh\necho base\n")
+	motdID := p.add(gitBlob, motd)
+	p.addOfsDelta(motdID, testDelta(motd, 11, "Ignition\n"))
+	issueID := gitObjectID(gitBlob, []byte("Welcome to Ignition\n"))
+	baseID := gitObjectID(gitBlob, base)
+	p.addRefDelta(baseID, testDelta(base, 15, "run\n"))
+	runID := gitObjectID(gitBlob, []byte("#!/bin/sh\necho run\n"))
+	p.add(gitBlob, base)
+	linkID := p.add(gitBlob, []byte("motd"))
+	etc := p.add(gitTree, testTree(
+		gitTreeEntry{gitModeFile, "issue", issueID},
+		gitTreeEntry{gitModeSymlink, "link", linkID},
+		gitTreeEntry{gitModeFile, "motd", motdID},
+	))
+	bin := p.add(gitTree, testTree(
+		gitTreeEntry{gitModeFile, "base", baseID},
+		gitTreeEntry{gitModeExecutable, "run", runID},
+	))
+	root := p.add(gitTree, testTree(
+		gitTreeEntry{gitModeDir, "bin", bin},
+		gitTreeEntry{gitModeDir, "etc", etc},
+		gitTreeEntry{gitModeSubmodule, "vendor", strings.Repeat("1", 40)},
+	))
+	commit := p.add(gitCommit, []byte("tree "+root+"\nauthor A <a@example.com> 0 +0000\ncommitter A <a@example.com> 0 +0000\n\nconfig\n"))
+	pack := p.bytes()
+	tag := strings.Repeat("2", 40)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Git-Protocol") != "version=2" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "", http.StatusForbidden)
+			return
+		}
+		var resp bytes.Buffer
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/org/config.git/info/refs":
+			for _, l := range []string{"version 2\n", "ls-refs\n", "fetch=shallow\n", "object-format=sha1\n"} {
+				writePktLine(&resp, l)
+			}
+			resp.WriteString(pktFlush)
+		case r.Method == http.MethodPost && r.URL.Path == "/org/config.git/git-upload-pack":
+			body, _ := ioutil.ReadAll(r.Body)
+			lines, err := readPktLines(body)
+			if err != nil || len(lines) == 0 {
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+			args := map[string]bool{}
+			for _, l := range lines[1:] {
+				args[l] = true
+			}
+			switch lines[0] {
+			case "command=ls-refs":
+				for _, ref := range []string{
+					commit + " HEAD",
+					commit + " refs/heads/main",
+					tag + " refs/tags/v1.0 peeled:" + commit,
+				} {
+					name := strings.Fields(ref)[1]
+					if args["ref-prefix "+name] {
+						writePktLine(&resp, ref+"\n")
+					}
+				}
+				resp.WriteString(pktFlush)
+			case "command=fetch":
+				if !args["want "+commit] || !args["deepen 1"] || !args["done"] {
+					http.Error(w, "", http.StatusBadRequest)
+					return
+				}
+				writePktLine(&resp, "shallow-info\n")
+				writePktLine(&resp, "shallow "+commit+"\n")
+				resp.WriteString(pktDelim)
+				writePktLine(&resp, "packfile\n")
+				writePktLine(&resp, "\x02Enumerating objects\n")
+				writePktLine(&resp, "\x01"+string(pack[:40]))
+				writePktLine(&resp, "\x01"+string(pack[40:]))
+				resp.WriteString(pktFlush)
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(resp.Bytes())
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, gitPlainHTTP: true}
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{in: "?ref=main#etc/motd", out: string(motd)},
+		{in: "?ref=v1.0#etc/issue", out: "Welcome to Ignition\n"},
+		{in: "?ref=" + commit + "#bin/run", out: "#!/bin/sh\necho run\n"},
+		{in: "#bin/base", out: string(base)},
+		{in: "?ref=refs/heads/main#etc/motd", out: string(motd)},
+		{in: "?ref=nope#etc/motd", err: ErrGitRefNotFound},
+		{in: "#etc/missing", err: ErrGitPathNotFound},
+		{in: "#etc/motd/more", err: ErrGitPathNotFound},
+		{in: "#etc", err: ErrGitNotAFile},
+		{in: "#etc/link", err: ErrGitNotAFile},
+		{in: "", err: ErrGitNotAFile},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse("git+https://" + host + "/org/config.git" + test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := f.FetchToBuffer(*u, FetchOptions{Headers: auth})
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && string(contents) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, contents)
+		}
+	}
+
+	trees := []struct {
+		in  string
+		out recordingGitTree
+		err error
+	}{
+		{
+			in: "?ref=main",
+			out: recordingGitTree{
+				"bin":       "dir",
+				"bin/base":  "file false " + string(base),
+				"bin/run":   "file true #!/bin/sh\necho run\n",
+				"etc":       "dir",
+				"etc/issue": "file false Welcome to Ignition\n",
+				"etc/link":  "symlink motd",
+				"etc/motd":  "file false " + string(motd),
+			},
+		},
+		{
+			in: "?ref=main#etc",
+			out: recordingGitTree{
+				"issue": "file false Welcome to Ignition\n",
+				"link":  "symlink motd",
+				"motd":  "file false " + string(motd),
+			},
+		},
+		{in: "#etc/motd", err: ErrGitNotATree},
+	}
+
+	for i, test := range trees {
+		u, err := url.Parse("git+https://" + host + "/org/config.git" + test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := recordingGitTree{}
+		err = f.ExtractGitTree(*u, FetchOptions{Headers: auth}, got)
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && !reflect.DeepEqual(test.out, got) {
+			t.Errorf("#%d: bad tree: want %v, got %v", i, test.out, got)
+		}
+	}
+}
+
+func TestParsePackCorrupt(t *testing.T) {
+	p := &testPack{offsets: map[string]int{}}
+	p.add(gitBlob, []byte("contents\n"))
+	pack := p.bytes()
+
+	flipped := append([]byte{}, pack...)
+	flipped[20] ^= 0xff
+	if _, err := parsePack(flipped); err == nil {
+		t.Errorf("pack with a bad checksum was accepted")
+	}
+
+	missing := &testPack{offsets: map[string]int{}}
+	missing.addRefDelta(strings.Repeat("3", 40), testDelta([]byte("base"), 2, "x"))
+	if _, err := parsePack(missing.bytes()); err == nil {
+		t.Errorf("pack missing a delta base was accepted")
+	}
+
+	if _, err := applyDelta([]byte("base"), testDelta([]byte("longer base"), 2, "x")); err == nil {
+		t.Errorf("delta on a base of another size was applied")
+	}
+}
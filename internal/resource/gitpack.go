@@ -0,0 +1,437 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the types of the objects of a pack, see gitformat-pack(5)
+const (
+	gitCommit   = 1
+	gitTree     = 2
+	gitBlob     = 3
+	gitTag      = 4
+	gitOfsDelta = 6
+	gitRefDelta = 7
+)
+
+// gitMaxDeltaChain is the longest chain of deltas resolved, well above the
+// depth git packs with, so that cycles of a corrupt pack end.
+const gitMaxDeltaChain = 1000
+
+var (
+	ErrGitPackCorrupt = errors.New("corrupt git pack")
+
+	gitTypeNames = map[int]string{
+		gitCommit: "commit",
+		gitTree:   "tree",
+		gitBlob:   "blob",
+		gitTag:    "tag",
+	}
+)
+
+// packEntry is an object of a pack, whose contents are deflated at offset
+// data. Deltas are applied to the object at offset base, or of ID baseID.
+type packEntry struct {
+	typ    int
+	size   int64
+	data   int64
+	base   int64
+	baseID string
+}
+
+// gitPack is a pack held in memory, whose objects are looked up by their
+// ID. The IDs are computed from the contents, so that the objects reached
+// from a commit are verified by its ID.
+type gitPack struct {
+	data    []byte
+	entries map[int64]*packEntry
+	ids     map[string]int64
+	// bases are the offsets of the objects deltas apply to, whose
+	// contents are kept once resolved
+	bases map[int64]bool
+	cache map[int64]gitObject
+}
+
+type gitObject struct {
+	typ      int
+	contents []byte
+}
+
+// parsePack indexes the objects of a pack, verifying its checksum.
+func parsePack(data []byte) (*gitPack, error) {
+	if len(data) < 32 || string(data[:4]) != "PACK" {
+		return nil, ErrGitPackCorrupt
+	}
+	end := len(data) - sha1.Size
+	if sum := sha1.Sum(data[:end]); !bytes.Equal(sum[:], data[end:]) {
+		return nil, fmt.Errorf("%v: checksum mismatch", ErrGitPackCorrupt)
+	}
+	if v := be32(data[4:]); v != 2 && v != 3 {
+		return nil, fmt.Errorf("unsupported git pack version %d", v)
+	}
+	count := be32(data[8:])
+
+	p := &gitPack{
+		data:    data,
+		entries: map[int64]*packEntry{},
+		ids:     map[string]int64{},
+		bases:   map[int64]bool{},
+		cache:   map[int64]gitObject{},
+	}
+	r := bytes.NewReader(data[:end])
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var deltas []int64
+	for i := uint32(0); i < count; i++ {
+		off := int64(end - r.Len())
+		e, err := readPackEntry(r, off)
+		if err != nil {
+			return nil, err
+		}
+		e.data = int64(end - r.Len())
+		p.entries[off] = e
+
+		// inflate the object to find where the next one starts, hashing
+		// it on the way if it's not a delta
+		h := sha1.New()
+		name, whole := gitTypeNames[e.typ]
+		if whole {
+			fmt.Fprintf(h, "%s %d\x00", name, e.size)
+		}
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, ErrGitPackCorrupt
+		}
+		n, err := io.Copy(h, zr)
+		if err != nil || n != e.size {
+			return nil, ErrGitPackCorrupt
+		}
+		if whole {
+			p.ids[hex.EncodeToString(h.Sum(nil))] = off
+		} else {
+			deltas = append(deltas, off)
+			if e.typ == gitOfsDelta {
+				p.bases[e.base] = true
+			}
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%v: trailing data", ErrGitPackCorrupt)
+	}
+
+	// resolve the deltas to learn their IDs; deltas on objects of the pack
+	// referenced by ID may come before them
+	for len(deltas) > 0 {
+		var pending []int64
+		for _, off := range deltas {
+			e := p.entries[off]
+			if e.typ == gitRefDelta {
+				base, ok := p.ids[e.baseID]
+				if !ok {
+					pending = append(pending, off)
+					continue
+				}
+				p.bases[base] = true
+			}
+			typ, contents, err := p.object(off, 0)
+			if err != nil {
+				return nil, err
+			}
+			p.ids[gitObjectID(typ, contents)] = off
+		}
+		if len(pending) == len(deltas) {
+			return nil, fmt.Errorf("%v: missing delta base %s", ErrGitPackCorrupt, p.entries[pending[0]].baseID)
+		}
+		deltas = pending
+	}
+	return p, nil
+}
+
+// readPackEntry reads the header of the object at off.
+func readPackEntry(r *bytes.Reader, off int64) (*packEntry, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, ErrGitPackCorrupt
+	}
+	e := &packEntry{typ: int(c>>4) & 7, size: int64(c & 0x0f)}
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		if shift > 56 {
+			return nil, ErrGitPackCorrupt
+		}
+		if c, err = r.ReadByte(); err != nil {
+			return nil, ErrGitPackCorrupt
+		}
+		e.size |= int64(c&0x7f) << shift
+	}
+
+	switch e.typ {
+	case gitCommit, gitTree, gitBlob, gitTag:
+	case gitOfsDelta:
+		// the distance to the base, in the big-endian encoding where
+		// each continuation adds one
+		if c, err = r.ReadByte(); err != nil {
+			return nil, ErrGitPackCorrupt
+		}
+		dist := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil || dist > 1<<48 {
+				return nil, ErrGitPackCorrupt
+			}
+			dist = (dist+1)<<7 | int64(c&0x7f)
+		}
+		e.base = off - dist
+		if dist == 0 || e.base < 12 {
+			return nil, ErrGitPackCorrupt
+		}
+	case gitRefDelta:
+		id := make([]byte, sha1.Size)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return nil, ErrGitPackCorrupt
+		}
+		e.baseID = hex.EncodeToString(id)
+	default:
+		return nil, fmt.Errorf("%v: object of type %d", ErrGitPackCorrupt, e.typ)
+	}
+	return e, nil
+}
+
+// inflate returns the stored contents of the entry, the delta itself for
+// deltas.
+func (p *gitPack) inflate(e *packEntry) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(p.data[e.data:]))
+	if err != nil {
+		return nil, ErrGitPackCorrupt
+	}
+	buf := make([]byte, e.size)
+	if _, err := io.ReadFull(zr, buf); err != nil {
+		return nil, ErrGitPackCorrupt
+	}
+	return buf, nil
+}
+
+// object returns the type and contents of the object at off, applying its
+// deltas.
+func (p *gitPack) object(off int64, depth int) (int, []byte, error) {
+	if depth > gitMaxDeltaChain {
+		return 0, nil, fmt.Errorf("%v: delta chain too long", ErrGitPackCorrupt)
+	}
+	if o, ok := p.cache[off]; ok {
+		return o.typ, o.contents, nil
+	}
+	e, ok := p.entries[off]
+	if !ok {
+		return 0, nil, fmt.Errorf("%v: no object at offset %d", ErrGitPackCorrupt, off)
+	}
+	contents, err := p.inflate(e)
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := e.typ
+	if typ == gitOfsDelta || typ == gitRefDelta {
+		base := e.base
+		if typ == gitRefDelta {
+			if base, ok = p.ids[e.baseID]; !ok {
+				return 0, nil, fmt.Errorf("%v: missing delta base %s", ErrGitPackCorrupt, e.baseID)
+			}
+		}
+		var baseContents []byte
+		if typ, baseContents, err = p.object(base, depth+1); err != nil {
+			return 0, nil, err
+		}
+		if contents, err = applyDelta(baseContents, contents); err != nil {
+			return 0, nil, err
+		}
+	}
+	if p.bases[off] {
+		p.cache[off] = gitObject{typ: typ, contents: contents}
+	}
+	return typ, contents, nil
+}
+
+// read returns the type and contents of the object of the ID.
+func (p *gitPack) read(id string) (int, []byte, error) {
+	off, ok := p.ids[id]
+	if !ok {
+		return 0, nil, fmt.Errorf("object %s isn't in the fetched pack", id)
+	}
+	return p.object(off, 0)
+}
+
+// applyDelta returns the object the delta makes of base, see the
+// deltified representation in gitformat-pack(5).
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, delta, ok := deltaSize(delta)
+	if !ok || srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("%v: delta base size mismatch", ErrGitPackCorrupt)
+	}
+	dstSize, delta, ok := deltaSize(delta)
+	if !ok || dstSize > 1<<32 {
+		return nil, ErrGitPackCorrupt
+	}
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			// copy from the base, with the offset and size bytes
+			// present as flagged by the op
+			var offset, size uint64
+			for i := uint(0); i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, ErrGitPackCorrupt
+				}
+				if i < 4 {
+					offset |= uint64(delta[0]) << (8 * i)
+				} else {
+					size |= uint64(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, ErrGitPackCorrupt
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			// insert the next op bytes
+			if int(op) > len(delta) {
+				return nil, ErrGitPackCorrupt
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, fmt.Errorf("%v: reserved delta instruction", ErrGitPackCorrupt)
+		}
+		if uint64(len(out)) > dstSize {
+			return nil, ErrGitPackCorrupt
+		}
+	}
+	if uint64(len(out)) != dstSize {
+		return nil, fmt.Errorf("%v: delta result size mismatch", ErrGitPackCorrupt)
+	}
+	return out, nil
+}
+
+// deltaSize reads a size of the header of a delta, in little-endian groups
+// of seven bits.
+func deltaSize(delta []byte) (uint64, []byte, bool) {
+	var size uint64
+	for i, shift := 0, uint(0); i < len(delta) && shift < 64; i, shift = i+1, shift+7 {
+		size |= uint64(delta[i]&0x7f) << shift
+		if delta[i]&0x80 == 0 {
+			return size, delta[i+1:], true
+		}
+	}
+	return 0, nil, false
+}
+
+func gitObjectID(typ int, contents []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", gitTypeNames[typ], len(contents))
+	h.Write(contents)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func be32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// gitTreeEntry is an entry of a tree object.
+type gitTreeEntry struct {
+	mode string
+	name string
+	id   string
+}
+
+// the modes of tree entries
+const (
+	gitModeDir        = "40000"
+	gitModeFile       = "100644"
+	gitModeExecutable = "100755"
+	gitModeSymlink    = "120000"
+	gitModeSubmodule  = "160000"
+)
+
+// parseTree returns the entries of a tree object. Names which would leave
+// the tree are rejected.
+func parseTree(data []byte) ([]gitTreeEntry, error) {
+	var entries []gitTreeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+1+sha1.Size {
+			return nil, fmt.Errorf("%v: malformed tree", ErrGitPackCorrupt)
+		}
+		e := gitTreeEntry{
+			mode: string(data[:sp]),
+			name: string(data[sp+1 : nul]),
+			id:   hex.EncodeToString(data[nul+1 : nul+1+sha1.Size]),
+		}
+		if e.name == "" || e.name == "." || e.name == ".." || strings.Contains(e.name, "/") {
+			return nil, fmt.Errorf("%v: tree entry %q", ErrGitPackCorrupt, e.name)
+		}
+		entries = append(entries, e)
+		data = data[nul+1+sha1.Size:]
+	}
+	return entries, nil
+}
+
+// commitTree returns the ID of the tree of the commit of the ID, peeling
+// annotated tags.
+func (p *gitPack) commitTree(id string) (string, error) {
+	for i := 0; i < 10; i++ {
+		typ, data, err := p.read(id)
+		if err != nil {
+			return "", err
+		}
+		var field string
+		switch typ {
+		case gitCommit:
+			field = "tree "
+		case gitTag:
+			field = "object "
+		default:
+			return "", fmt.Errorf("object %s is a %s, not a commit", id, gitTypeNames[typ])
+		}
+		line := string(data)
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		if !strings.HasPrefix(line, field) {
+			return "", fmt.Errorf("%v: malformed %s %s", ErrGitPackCorrupt, gitTypeNames[typ], id)
+		}
+		id = strings.TrimPrefix(line, field)
+		if typ == gitCommit {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("tag chain of %s too long", id)
+}
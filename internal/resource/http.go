@@ -15,21 +15,26 @@
 package resource
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io"
-	"net"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/earlyrand"
+	"github.com/flatcar/ignition/internal/fips"
 	"github.com/flatcar/ignition/internal/log"
 	"github.com/flatcar/ignition/internal/util"
 	"github.com/flatcar/ignition/internal/version"
@@ -40,11 +45,15 @@ import (
 )
 
 const (
-	initialBackoff = 100 * time.Millisecond
-	maxBackoff     = 5 * time.Second
+	// the backoff before the first retry, doubled for every further one
+	defaultBackoffBase = 200 * time.Millisecond
+	defaultBackoffMax  = 5 * time.Second
 
 	defaultHttpResponseHeaderTimeout = 10
 	defaultHttpTotalTimeout          = 0
+
+	// metadataTimeout limits the requests to metadata services
+	metadataTimeout = 5 * time.Second
 )
 
 var (
@@ -59,11 +68,23 @@ type HttpClient struct {
 	logger  *log.Logger
 	timeout time.Duration
 
+	// retries limits the retries of failed requests, if not negative
+	retries     int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	// redirects is the policy for following redirects
+	redirects types.Redirects
+	// limiter limits the rate at which the client's connections receive data
+	limiter *rateLimiter
+
 	transport *http.Transport
 	cas       map[string][]byte
 }
 
-func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.CaReference, proxy types.Proxy) error {
+func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, tlsCfg types.TLS, redirects types.Redirects, proxy types.Proxy) error {
+	if f.helper != nil {
+		return f.configureHelper(timeouts, tlsCfg, redirects, proxy)
+	}
 	if f.client == nil {
 		if err := f.newHttpClient(); err != nil {
 			return err
@@ -86,49 +107,122 @@ func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.
 	f.client.transport.ResponseHeaderTimeout = time.Duration(responseHeader) * time.Second
 	f.client.client.Transport = f.client.transport
 
-	// Update proxy
-	f.client.transport.Proxy = func(req *http.Request) (*url.URL, error) {
-		return proxyFuncFromIgnitionConfig(proxy)(req.URL)
+	// Update retries
+	f.client.retries = -1
+	if timeouts.HTTPRetries != nil {
+		f.client.retries = *timeouts.HTTPRetries
+	}
+	f.client.backoffBase = defaultBackoffBase
+	if timeouts.HTTPBackoffBaseMs != nil {
+		f.client.backoffBase = time.Duration(*timeouts.HTTPBackoffBaseMs) * time.Millisecond
+	}
+	f.client.backoffMax = defaultBackoffMax
+	if timeouts.HTTPBackoffMaxMs != nil {
+		f.client.backoffMax = time.Duration(*timeouts.HTTPBackoffMaxMs) * time.Millisecond
+	}
+	if f.client.backoffMax < f.client.backoffBase {
+		f.client.backoffMax = f.client.backoffBase
 	}
-	f.client.client.Transport = f.client.transport
 
-	// Update CAs
-	if len(cas) == 0 {
-		return nil
+	// Update the redirect policy
+	f.client.redirects = redirects
+	f.client.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return checkRedirect(redirects, req, via)
 	}
 
-	pool, err := x509.SystemCertPool()
+	// keep the rest of the TLS settings, like the protocols negotiated for
+	// HTTP/2
+	tlsConfig := f.client.transport.TLSClientConfig.Clone()
+
+	// Update pins and OCSP stapling
+	transport := f.client.transport
+	verify, err := verifyConnection(tlsCfg, func() time.Time { return tlsClock.Now(f.Logger, transport) })
 	if err != nil {
-		f.Logger.Err("Unable to read system certificate pool: %s", err)
 		return err
 	}
+	tlsConfig.VerifyConnection = verify
 
-	for _, ca := range cas {
-		cablob, err := f.getCABlob(ca)
+	// Update CAs
+	if len(tlsCfg.CertificateAuthorities) > 0 {
+		pool, err := f.certPool(tlsCfg.CertificateAuthorities)
 		if err != nil {
 			return err
 		}
-		block, _ := pem.Decode(cablob)
-		if block == nil {
-			f.Logger.Err("Unable to decode CA (%s)", ca.Source)
-			return ErrPEMDecodeFailed
+		tlsConfig.RootCAs = pool
+		if fips.Enabled() {
+			fips.RestrictTLS(tlsConfig)
 		}
+	}
 
-		cert, err := x509.ParseCertificate(block.Bytes)
+	// Update client certificates
+	if len(tlsCfg.ClientCertificates) > 0 {
+		certs, err := f.clientCertificates(tlsCfg.ClientCertificates)
 		if err != nil {
-			f.Logger.Err("Unable to parse CA (%s): %s", ca.Source, err)
 			return err
 		}
+		tlsConfig.Certificates = certs
+	}
+	f.client.transport.TLSClientConfig = tlsConfig
 
-		f.Logger.Info("Adding %q to list of CAs", cert.Subject.CommonName)
-		pool.AddCert(cert)
+	// Update proxy, once the TLS settings the PAC file of an auto-detected
+	// proxy is fetched with are in place
+	proxyFunc := proxyFuncFromIgnitionConfig(f.autoDetectProxy(proxy))
+	f.client.transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
 	}
+	f.client.client.Transport = f.client.transport
 
-	f.client.transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	if verify != nil || len(tlsCfg.ClientCertificates) > 0 {
+		// connections made before the checks applied, or without the
+		// client certificates, mustn't be reused
+		f.client.transport.CloseIdleConnections()
+	}
 
 	return nil
 }
 
+// certPool returns the system's certificate pool with cas added.
+func (f *Fetcher) certPool(cas []types.CaReference) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		f.Logger.Err("Unable to read system certificate pool: %s", err)
+		return nil, err
+	}
+
+	for _, ca := range cas {
+		cablob, err := f.getCABlob(ca)
+		if err != nil {
+			return nil, err
+		}
+		// a CA can be a bundle of certificates, like the root and
+		// intermediates of a PKI
+		added := 0
+		for rest := cablob; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				f.Logger.Err("Unable to parse CA (%s): %s", ca.Source, err)
+				return nil, err
+			}
+			f.Logger.Info("Adding %q to list of CAs", cert.Subject.CommonName)
+			pool.AddCert(cert)
+			added++
+		}
+		if added == 0 {
+			f.Logger.Err("Unable to decode CA (%s)", ca.Source)
+			return nil, ErrPEMDecodeFailed
+		}
+	}
+	return pool, nil
+}
+
 func (f *Fetcher) getCABlob(ca types.CaReference) ([]byte, error) {
 	if blob, ok := f.client.cas[ca.Source]; ok {
 		return blob, nil
@@ -195,27 +289,37 @@ func (f *Fetcher) RewriteCAsWithDataUrls(cas []types.CaReference) error {
 	return nil
 }
 
-// DefaultHTTPClient builds the default `http.client` for Ignition.
-func defaultHTTPClient() (*http.Client, error) {
+// DefaultHTTPClient builds the default `http.client` for Ignition, making
+// its connections with dialer.
+func defaultHTTPClient(logger *log.Logger, dialer Dialer) (*http.Client, error) {
 	urand, err := earlyrand.UrandomReader()
 	if err != nil {
 		return nil, err
 	}
 
+	var transport http.Transport
 	tlsConfig := tls.Config{
 		Rand: urand,
+		// certificates are checked against the time of the configured
+		// time source, if any, which is queried like any other fetch
+		Time: func() time.Time { return tlsClock.Now(logger, &transport) },
+	}
+	if fips.Enabled() {
+		fips.RestrictTLS(&tlsConfig)
 	}
-	transport := http.Transport{
+	transport = http.Transport{
 		ResponseHeaderTimeout: time.Duration(defaultHttpResponseHeaderTimeout) * time.Second,
-		Dial: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			Resolver: &net.Resolver{
-				PreferGo: true,
-			},
-		}).Dial,
-		TLSClientConfig:     &tlsConfig,
-		TLSHandshakeTimeout: 10 * time.Second,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       &tlsConfig,
+		TLSHandshakeTimeout:   10 * time.Second,
+		// Configs tend to reference many files on the same few servers,
+		// so negotiate HTTP/2 despite the custom dialer and TLS settings
+		// and keep enough idle connections around for the concurrent
+		// fetches to reuse them.
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
 	}
 	client := http.Client{
 		Transport: &transport,
@@ -223,31 +327,102 @@ func defaultHTTPClient() (*http.Client, error) {
 	return &client, nil
 }
 
+// metadataHTTPClient returns a client for the metadata services of cloud
+// platforms, which are reached directly through the network stack and
+// interface configured for the distro, and answer quickly if at all.
+func metadataHTTPClient(logger *log.Logger) (*http.Client, error) {
+	stack, nic := distro.NetworkStack(), distro.NetworkInterface()
+	dialer, err := NewDialer(stack, nic)
+	if err != nil {
+		return nil, fmt.Errorf("network stack %q: %v", stack, err)
+	}
+	client, err := defaultHTTPClient(logger, dialer)
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = metadataTimeout
+	return client, nil
+}
+
 // newHttpClient populates the fetcher with the default HTTP client.
 func (f *Fetcher) newHttpClient() error {
-	defaultClient, err := defaultHTTPClient()
+	dialer, err := f.dialer()
+	if err != nil {
+		return err
+	}
+	// fetches are throttled once a rate limit is set
+	limiter := &rateLimiter{}
+	defaultClient, err := defaultHTTPClient(f.Logger, dialer)
 	if err != nil {
 		return err
 	}
+	transport := defaultClient.Transport.(*http.Transport)
+	transport.DialContext = limiter.throttle(dialer)
 
 	f.client = &HttpClient{
-		client:    defaultClient,
-		logger:    f.Logger,
-		timeout:   time.Duration(defaultHttpTotalTimeout) * time.Second,
-		transport: defaultClient.Transport.(*http.Transport),
-		cas:       make(map[string][]byte),
+		client:      defaultClient,
+		logger:      f.Logger,
+		timeout:     time.Duration(defaultHttpTotalTimeout) * time.Second,
+		retries:     -1,
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+		limiter:     limiter,
+		transport:   transport,
+		cas:         make(map[string][]byte),
 	}
 	return nil
 }
 
-// getReaderWithHeader performs an HTTP GET on the provided URL with the
-// provided request header and returns the response body Reader, HTTP status
-// code, a cancel function for the result's context, and error (if any). By
-// default, User-Agent is added to the header but this can be overridden.
-func (c HttpClient) getReaderWithHeader(url string, header http.Header) (io.ReadCloser, int, context.CancelFunc, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// getWithHeader performs an HTTP GET on the provided URL with the provided
+// request header and returns the response, a cancel function for the
+// result's context, and error (if any). By default, User-Agent is added to
+// the header but this can be overridden. The request is cancelled along
+// with ctx.
+func (c HttpClient) getWithHeader(ctx context.Context, url string, header http.Header) (*http.Response, context.CancelFunc, error) {
+	return c.doWithHeader(ctx, "GET", url, nil, header)
+}
+
+// postReaderWithHeader performs an HTTP POST of body on the provided URL
+// with the provided request header and returns the response body Reader,
+// HTTP status code, a cancel function for the result's context, and error
+// (if any). The request is cancelled along with ctx.
+func (c HttpClient) postReaderWithHeader(ctx context.Context, url string, body []byte, header http.Header) (io.ReadCloser, int, context.CancelFunc, error) {
+	resp, cancelFn, err := c.doWithHeader(ctx, "POST", url, body, header)
 	if err != nil {
-		return nil, 0, nil, err
+		return nil, 0, cancelFn, err
+	}
+	return resp.Body, resp.StatusCode, cancelFn, nil
+}
+
+// traceTLS logs the TLS handshakes with host of the requests made with ctx,
+// with their duration; the kernel network stack's dialer logs the name
+// resolutions and connections preceding them.
+func (c HttpClient) traceTLS(ctx context.Context, host string) context.Context {
+	var start time.Time
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		TLSHandshakeStart: func() { start = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				c.logger.Info("TLS handshake with %s failed after %v: %v", host, time.Since(start), err)
+			} else {
+				c.logger.Info("TLS handshake with %s done in %v", host, time.Since(start))
+			}
+		},
+	})
+}
+
+// doWithHeader performs the request, retrying with backoff until the server
+// returns a status code below 500, the client's timeout or number of retries
+// is reached, or ctx is cancelled. Once out of retries, the last response is
+// returned even if it's a server error.
+func (c HttpClient) doWithHeader(parent context.Context, method, url string, body []byte, header http.Header) (*http.Response, context.CancelFunc, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	req.Header.Set("User-Agent", "Ignition/"+version.Raw)
@@ -259,41 +434,68 @@ func (c HttpClient) getReaderWithHeader(url string, header http.Header) (io.Read
 		}
 	}
 
-	ctx, cancelFn := context.WithCancel(context.Background())
+	ctx, cancelFn := context.WithCancel(parent)
 	if c.timeout != 0 {
 		cancelFn()
-		ctx, cancelFn = context.WithTimeout(context.Background(), c.timeout)
+		ctx, cancelFn = context.WithTimeout(parent, c.timeout)
 	}
 
-	duration := initialBackoff
+	duration := c.backoffBase
 	for attempt := 1; ; attempt++ {
-		c.logger.Info("GET %s: attempt #%d", url, attempt)
-		resp, err := c.client.Do(req.WithContext(ctx))
+		c.logger.Info("%s %s: attempt #%d", method, url, attempt)
+		if req.GetBody != nil {
+			// the body of the previous attempt has been consumed
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, cancelFn, err
+			}
+		}
+		resp, err := c.client.Do(req.WithContext(c.traceTLS(ctx, req.URL.Host)))
 
+		lastAttempt := c.retries >= 0 && attempt > c.retries
 		if err == nil {
-			c.logger.Info("GET result: %s", http.StatusText(resp.StatusCode))
-			if resp.StatusCode < 500 {
-				return resp.Body, resp.StatusCode, cancelFn, nil
+			c.logger.Info("%s result: %s", method, http.StatusText(resp.StatusCode))
+			if resp.StatusCode < 500 || lastAttempt {
+				return resp, cancelFn, nil
 			}
-			resp.Body.Close()
+			discardBody(resp.Body)
 		} else {
-			c.logger.Info("GET error: %v", err)
-		}
-
-		duration = duration * 2
-		if duration > maxBackoff {
-			duration = maxBackoff
+			c.logger.Info("%s error: %v", method, err)
+			if rerr, ok := refusedRedirect(err); ok {
+				return nil, cancelFn, rerr
+			}
+			if lastAttempt {
+				c.logger.Info("%s %s: giving up after %d attempts", method, url, attempt)
+				if perr := parent.Err(); perr != nil {
+					return nil, cancelFn, perr
+				}
+				return nil, cancelFn, err
+			}
 		}
 
 		// Wait before next attempt or exit if we timeout while waiting
 		select {
 		case <-time.After(duration):
 		case <-ctx.Done():
-			return nil, 0, cancelFn, ErrTimeout
+			if err := parent.Err(); err != nil {
+				return nil, cancelFn, err
+			}
+			return nil, cancelFn, ErrTimeout
+		}
+
+		if duration *= 2; duration > c.backoffMax {
+			duration = c.backoffMax
 		}
 	}
 }
 
+// discardBody reads the rest of a response body which isn't needed, up to a
+// limit, and closes it, so that the connection can be reused for the next
+// request.
+func discardBody(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, 64*1024))
+	body.Close()
+}
+
 func proxyFuncFromIgnitionConfig(proxy types.Proxy) func(*url.URL) (*url.URL, error) {
 	noProxy := translateNoProxySliceToString(proxy.NoProxy)
 	cfg := &httpproxy.Config{
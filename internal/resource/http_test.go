@@ -0,0 +1,477 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestHTTPConnectionReuse(t *testing.T) {
+	var conns, http1 int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			atomic.AddInt32(&http1, 1)
+		}
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("contents"))
+	}))
+	srv.EnableHTTP2 = true
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{CertificateAuthorities: []types.CaReference{{Source: dataurl.EncodeBytes(ca)}}}, types.Redirects{}, types.Proxy{}); err != nil {
+		t.Fatalf("configuring the CA: %v", err)
+	}
+
+	fetch := func(path string) error {
+		u, err := url.Parse(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		return err
+	}
+
+	if err := fetch("/file"); err != nil {
+		t.Fatalf("fetching: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := "/file"
+			if i%4 == 0 {
+				path = "/missing"
+			}
+			if err := fetch(path); err != nil && err != ErrNotFound {
+				t.Errorf("fetching %s: %v", path, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&http1); n != 0 {
+		t.Errorf("bad protocol: %d requests didn't use HTTP/2", n)
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("bad number of connections: want 1, got %d", n)
+	}
+}
+
+func TestFetchCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hang":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	type in struct {
+		path   string
+		helper bool
+	}
+
+	tests := []in{
+		{path: "/hang"},
+		{path: "/unavailable"},
+		{path: "/hang", helper: true},
+	}
+
+	for i, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger, Context: ctx}
+		if test.helper {
+			if err := f.startFetchHelper(os.Args[0], nil); err != nil {
+				t.Fatalf("#%d: starting fetch helper: %v", i, err)
+			}
+		}
+		u, err := url.Parse(srv.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		if err != context.Canceled {
+			t.Errorf("#%d: bad error: want %v, got %v", i, context.Canceled, err)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Errorf("#%d: fetch took %v to be cancelled", i, d)
+		}
+		f.StopFetchHelper()
+		cancel()
+	}
+}
+
+func TestFetchRetries(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first three requests fail
+		if atomic.AddInt32(&requests, 1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("contents"))
+	}))
+	defer srv.Close()
+
+	type in struct {
+		retries *int
+		base    *int
+		max     *int
+	}
+	type out struct {
+		err      error
+		requests int32
+		// minWait is the least time the backoff takes
+		minWait time.Duration
+	}
+
+	intp := func(i int) *int { return &i }
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{base: intp(20), max: intp(40)},
+			out: out{requests: 4, minWait: 100 * time.Millisecond},
+		},
+		{
+			in:  in{retries: intp(3), base: intp(1)},
+			out: out{requests: 4},
+		},
+		{
+			in:  in{retries: intp(1), base: intp(1)},
+			out: out{err: ErrFailed, requests: 2},
+		},
+		{
+			in:  in{retries: intp(0)},
+			out: out{err: ErrFailed, requests: 1},
+		},
+	}
+
+	for i, test := range tests {
+		atomic.StoreInt32(&requests, 0)
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		timeouts := types.Timeouts{HTTPRetries: test.in.retries, HTTPBackoffBaseMs: test.in.base, HTTPBackoffMaxMs: test.in.max}
+		if err := f.UpdateHttpTimeoutsAndCAs(timeouts, types.TLS{}, types.Redirects{}, types.Proxy{}); err != nil {
+			t.Fatalf("#%d: configuring the fetcher: %v", i, err)
+		}
+		u, err := url.Parse(srv.URL + "/file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.out.requests {
+			t.Errorf("#%d: bad number of requests: want %d, got %d", i, test.out.requests, n)
+		}
+		if d := time.Since(start); d < test.out.minWait {
+			t.Errorf("#%d: retried too fast: want at least %v, took %v", i, test.out.minWait, d)
+		}
+	}
+}
+
+func TestFetchResume(t *testing.T) {
+	contents := make([]byte, 1<<20)
+	for i := range contents {
+		contents[i] = byte(i * 7)
+	}
+	sum := sha512.Sum512(contents)
+
+	type in struct {
+		noRanges   bool
+		changeETag bool
+	}
+	type out struct {
+		ok       bool
+		requests int32
+	}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{},
+			out: out{ok: true, requests: 2},
+		},
+		{
+			// the resource changed, so it can't be resumed
+			in:  in{changeETag: true},
+			out: out{requests: 2},
+		},
+		{
+			in:  in{noRanges: true},
+			out: out{requests: 1},
+		},
+	}
+
+	for i, test := range tests {
+		var requests int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&requests, 1)
+			etag := `"v1"`
+			if test.in.changeETag && n > 1 {
+				etag = `"v2"`
+			}
+			w.Header().Set("ETag", etag)
+			if n == 1 {
+				// the connection breaks half way through
+				if !test.in.noRanges {
+					w.Header().Set("Accept-Ranges", "bytes")
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+				w.WriteHeader(http.StatusOK)
+				w.Write(contents[:len(contents)/2])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			if r.Header.Get("Range") != fmt.Sprintf("bytes=%d-", len(contents)/2) {
+				t.Errorf("#%d: resumed with range %q", i, r.Header.Get("Range"))
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(contents))
+		}))
+
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		u, err := url.Parse(srv.URL + "/image")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.FetchToBuffer(*u, FetchOptions{Hash: sha512.New(), ExpectedSum: sum[:]})
+		if test.out.ok {
+			if err != nil {
+				t.Errorf("#%d: fetching: %v", i, err)
+			} else if !bytes.Equal(got, contents) {
+				t.Errorf("#%d: fetched contents differ", i)
+			}
+		} else if err == nil {
+			t.Errorf("#%d: fetching succeeded", i)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.out.requests {
+			t.Errorf("#%d: bad number of requests: want %d, got %d", i, test.out.requests, n)
+		}
+		srv.Close()
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents"))
+	}))
+	defer target.Close()
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/file", http.StatusFound)
+	}))
+	defer tlsTarget.Close()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/hops":
+			// redirects twice on this host before serving the file
+			if r.URL.Query().Get("n") == "" {
+				http.Redirect(w, r, "/hops?n=1", http.StatusFound)
+			} else if r.URL.Query().Get("n") == "1" {
+				http.Redirect(w, r, "/hops?n=2", http.StatusFound)
+			} else {
+				w.Write([]byte("contents"))
+			}
+		case "/elsewhere":
+			http.Redirect(w, r, target.URL+"/file", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	intp := func(i int) *int { return &i }
+	tests := []struct {
+		url      string
+		policy   types.Redirects
+		err      error
+		requests int32
+	}{
+		{url: srv.URL + "/hops", requests: 3},
+		{url: srv.URL + "/hops", policy: types.Redirects{Limit: intp(2), SameHost: true}, requests: 3},
+		{url: srv.URL + "/hops", policy: types.Redirects{Limit: intp(1)}, err: ErrRedirectLimit, requests: 2},
+		{url: srv.URL + "/hops", policy: types.Redirects{Limit: intp(0)}, err: ErrRedirectLimit, requests: 1},
+		{url: srv.URL + "/elsewhere", requests: 1},
+		{url: srv.URL + "/elsewhere", policy: types.Redirects{SameHost: true}, err: ErrRedirectHost, requests: 1},
+		{url: tlsTarget.URL + "/file"},
+		{url: tlsTarget.URL + "/file", policy: types.Redirects{NoDowngrade: true}, err: ErrRedirectDowngrade},
+	}
+
+	for i, test := range tests {
+		atomic.StoreInt32(&requests, 0)
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{}, test.policy, types.Proxy{}); err != nil {
+			t.Fatalf("#%d: configuring the fetcher: %v", i, err)
+		}
+		f.client.transport.TLSClientConfig.RootCAs = tlsTarget.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// refused redirects aren't retried
+		got, err := f.FetchToBuffer(*u, FetchOptions{})
+		if err != test.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && string(got) != "contents" {
+			t.Errorf("#%d: fetched %q", i, got)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.requests {
+			t.Errorf("#%d: bad number of requests: want %d, got %d", i, test.requests, n)
+		}
+	}
+}
+
+func TestFetchRateLimit(t *testing.T) {
+	contents := make([]byte, 128<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(contents)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	if err := f.SetRateLimit(512 << 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// two concurrent fetches share the limit, taking at least half a
+	// second together
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := f.FetchToBuffer(*u, FetchOptions{}); err != nil {
+				t.Errorf("fetching: %v", err)
+			} else if len(got) != len(contents) {
+				t.Errorf("fetched %d bytes, want %d", len(got), len(contents))
+			}
+		}()
+	}
+	wg.Wait()
+	if d := time.Since(start); d < 400*time.Millisecond {
+		t.Errorf("fetches weren't limited: took %v", d)
+	}
+
+	if err := f.SetRateLimit(0); err != nil {
+		t.Fatal(err)
+	}
+	start = time.Now()
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); err != nil {
+		t.Errorf("fetching: %v", err)
+	}
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Errorf("fetch was limited after lifting the limit: took %v", d)
+	}
+}
+
+func TestFetchThroughProxy(t *testing.T) {
+	var requests int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// proxied requests name the absolute url of the resource
+		if r.URL.String() != "http://files.example/file" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("contents"))
+	}))
+	defer proxy.Close()
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	cfg := types.Proxy{
+		HTTPProxy: proxy.URL,
+		NoProxy:   []types.NoProxyItem{"internal.example"},
+	}
+	if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{}, types.Redirects{}, cfg); err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse("http://files.example/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := f.FetchToBuffer(*u, FetchOptions{}); err != nil {
+		t.Errorf("fetching: %v", err)
+	} else if string(got) != "contents" {
+		t.Errorf("fetched %q", got)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("bad number of proxied requests: want 1, got %d", n)
+	}
+
+	// hosts in noProxy are fetched directly
+	req, err := http.NewRequest("GET", "http://mirror.internal.example/file", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := f.client.transport.Proxy(req); err != nil || p != nil {
+		t.Errorf("noProxy host is proxied: %v, %v", p, err)
+	}
+}
@@ -0,0 +1,584 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// the largest image manifest and registry token read
+	maxImageManifestSize = 4 * 1024 * 1024
+
+	imageWhiteoutPrefix = ".wh."
+	imageOpaqueWhiteout = ".wh..wh..opq"
+)
+
+var (
+	ErrImageIndex        = errors.New("multi-architecture images aren't supported, reference the image of one architecture by its digest")
+	ErrImagePathNotFound = errors.New("path not found in image")
+	ErrImageNotFile      = errors.New("path in image isn't a regular file")
+
+	// imageManifestTypes are the manifests accepted from registries, the
+	// indexes only to report them
+	imageManifestTypes = []string{
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+	}
+
+	imageDigestRegexp = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+	// bearerParamRegexp matches the parameters of a Bearer challenge
+	bearerParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// ImageReference is a parsed docker:// url, e.g.
+// docker://quay.io/org/tools:1.2#/usr/bin/tool or
+// docker://quay.io/org/tools@sha256:...#/usr/bin/tool.
+type ImageReference struct {
+	// Registry is the host (and port) of the registry.
+	Registry string
+	// Name is the repository of the image in the registry.
+	Name string
+	// Reference is the digest the image is pinned to, or else its tag.
+	Reference string
+	// Path is the absolute path in the image.
+	Path string
+}
+
+// Pinned reports whether the image is referenced by its digest.
+func (r ImageReference) Pinned() bool {
+	return imageDigestRegexp.MatchString(r.Reference)
+}
+
+// ParseImageURL parses a docker:// url. Images on Docker Hub are referenced
+// by docker.io, and without an organization if they're official images.
+func ParseImageURL(u url.URL) (ImageReference, error) {
+	if u.Fragment == "" || !path.IsAbs(u.Fragment) {
+		return ImageReference{}, fmt.Errorf("image url %q doesn't name an absolute path in the image after '#'", u.String())
+	}
+	ref, err := parseImageReference(u)
+	if err != nil {
+		return ImageReference{}, err
+	}
+	ref.Path = path.Clean(u.Fragment)
+	return ref, nil
+}
+
+// parseImageReference parses the registry, repository and tag or digest of
+// a docker:// or oci:// url.
+func parseImageReference(u url.URL) (ImageReference, error) {
+	ref := ImageReference{Registry: u.Host}
+	name := strings.TrimPrefix(u.Path, "/")
+	if i := strings.Index(name, "@"); i >= 0 {
+		// a tag next to the digest is only informational
+		name, ref.Reference = name[:i], name[i+1:]
+		if !imageDigestRegexp.MatchString(ref.Reference) {
+			return ImageReference{}, fmt.Errorf("%s url %q has an invalid digest, only sha256 digests are supported", kindOfImageURL(u), u.String())
+		}
+		if j := strings.LastIndex(name, ":"); j > strings.LastIndex(name, "/") {
+			name = name[:j]
+		}
+	} else if j := strings.LastIndex(name, ":"); j > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:j], name[j+1:]
+	} else {
+		ref.Reference = "latest"
+	}
+	if ref.Registry == "" || name == "" || ref.Reference == "" {
+		return ImageReference{}, fmt.Errorf("%s url %q doesn't name a registry, %s, and tag or digest", kindOfImageURL(u), u.String(), kindOfImageURL(u))
+	}
+	if ref.Registry == "docker.io" {
+		ref.Registry = "registry-1.docker.io"
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	ref.Name = name
+	return ref, nil
+}
+
+func kindOfImageURL(u url.URL) string {
+	if u.Scheme == "oci" {
+		return "artifact"
+	}
+	return "image"
+}
+
+// registryURL returns the url of the registry API endpoint of the image.
+// Like docker, registries on the loopback interface are talked to over
+// http, all others over https.
+func (r ImageReference) registryURL(endpoint string) url.URL {
+	scheme := "https"
+	host := r.Registry
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		scheme = "http"
+	}
+	return url.URL{Scheme: scheme, Host: r.Registry, Path: "/v2/" + r.Name + "/" + endpoint}
+}
+
+type imageManifest struct {
+	MediaType string            `json:"mediaType"`
+	Layers    []imageDescriptor `json:"layers"`
+	Manifests []imageDescriptor `json:"manifests"`
+}
+
+type imageDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AuthChallengeError is returned for http(s) fetches refused with 401
+// Unauthorized along with a challenge, so that registry fetches can get a
+// token.
+type AuthChallengeError struct {
+	Challenge string
+}
+
+func (e AuthChallengeError) Error() string {
+	return authChallengePrefix + e.Challenge
+}
+
+const authChallengePrefix = "authentication required: "
+
+// imageSession fetches the manifest and blobs of an image, getting a token
+// from the registry if it asks for one. The token is requested with the
+// headers of the session, anonymously if there are none.
+type imageSession struct {
+	f       *Fetcher
+	ref     ImageReference
+	headers http.Header
+	token   string
+	// artifact accepts layers of any media type, which aren't read as
+	// tar archives.
+	artifact bool
+}
+
+// fetch fetches the endpoint of the image's repository into dest.
+func (s *imageSession) fetch(endpoint string, dest io.Writer, opts FetchOptions) error {
+	u := s.ref.registryURL(endpoint)
+	withToken := func() FetchOptions {
+		o := opts
+		o.Headers = http.Header{}
+		for k, v := range s.headers {
+			o.Headers[k] = v
+		}
+		for k, v := range opts.Headers {
+			o.Headers[k] = v
+		}
+		if s.token != "" {
+			o.Headers.Set("Authorization", "Bearer "+s.token)
+		}
+		return o
+	}
+	err := s.f.fetch(u, dest, withToken())
+	challenge, ok := err.(AuthChallengeError)
+	if !ok || s.token != "" {
+		return err
+	}
+	if s.token, err = s.getToken(challenge.Challenge); err != nil {
+		return fmt.Errorf("failed to get a token from the registry: %v", err)
+	}
+	return s.f.fetch(u, dest, withToken())
+}
+
+// getToken gets an anonymous pull token from the realm of a Bearer
+// challenge of the registry.
+func (s *imageSession) getToken(challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range bearerParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || (realm.Scheme != "https" && realm.Scheme != "http") {
+		return "", fmt.Errorf("invalid realm in challenge %q", challenge)
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + s.ref.Name + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	var buf bytes.Buffer
+	if err := s.f.fetch(*realm, &buf, FetchOptions{Headers: s.headers, MaxSize: maxImageManifestSize}); err != nil {
+		return "", err
+	}
+	var resp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		return "", err
+	}
+	if resp.Token == "" {
+		resp.Token = resp.AccessToken
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("no token in the response of %s", realm.Host)
+	}
+	return resp.Token, nil
+}
+
+// manifest fetches the manifest of the image, verifying it against the
+// digest the image is pinned to.
+func (s *imageSession) manifest() (imageManifest, error) {
+	var buf bytes.Buffer
+	opts := FetchOptions{
+		Headers: http.Header{"Accept": []string{strings.Join(imageManifestTypes, ", ")}},
+		MaxSize: maxImageManifestSize,
+	}
+	if s.ref.Pinned() {
+		opts.Hash = sha256.New()
+		opts.ExpectedSum, _ = hex.DecodeString(strings.TrimPrefix(s.ref.Reference, "sha256:"))
+	}
+	if err := s.fetch("manifests/"+s.ref.Reference, &buf, opts); err != nil {
+		return imageManifest{}, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	s.f.Logger.Info("image %s/%s:%s has digest sha256:%s", s.ref.Registry, s.ref.Name, s.ref.Reference, hex.EncodeToString(sum[:]))
+
+	var m imageManifest
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return imageManifest{}, fmt.Errorf("failed to parse image manifest: %v", err)
+	}
+	if len(m.Manifests) > 0 {
+		return imageManifest{}, ErrImageIndex
+	}
+	for _, l := range m.Layers {
+		if !imageDigestRegexp.MatchString(l.Digest) {
+			return imageManifest{}, fmt.Errorf("image layer has unsupported digest %q", l.Digest)
+		}
+		if s.artifact {
+			continue
+		}
+		if _, err := layerCompression(l.MediaType); err != nil {
+			return imageManifest{}, err
+		}
+	}
+	return m, nil
+}
+
+// layerCompression returns the compression of layers of the media type.
+func layerCompression(mediaType string) (string, error) {
+	switch mediaType {
+	case "application/vnd.oci.image.layer.v1.tar+gzip",
+		"application/vnd.docker.image.rootfs.diff.tar.gzip",
+		"application/vnd.docker.image.rootfs.foreign.diff.tar.gzip":
+		return "gzip", nil
+	case "application/vnd.oci.image.layer.v1.tar":
+		return "", nil
+	default:
+		return "", fmt.Errorf("image layer has unsupported media type %q", mediaType)
+	}
+}
+
+// layerOptions returns the options verifying a layer against its digest.
+func layerOptions(l imageDescriptor) FetchOptions {
+	sum, _ := hex.DecodeString(strings.TrimPrefix(l.Digest, "sha256:"))
+	return FetchOptions{Hash: sha256.New(), ExpectedSum: sum}
+}
+
+// readLayer reads the layer from r as a tar archive.
+func readLayer(l imageDescriptor, r io.Reader, read func(*tar.Reader) error) error {
+	if compression, _ := layerCompression(l.MediaType); compression == "gzip" {
+		compressed := &countingReader{r: r}
+		zr, err := gzip.NewReader(compressed)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = guardDecompression(zr, compressed)
+	}
+	return read(tar.NewReader(r))
+}
+
+// fetchLayer streams the layer into read as a tar archive, and fails if the
+// layer doesn't match its digest once it's read to the end. read's results
+// are only to be used if fetchLayer succeeds.
+func (s *imageSession) fetchLayer(l imageDescriptor, read func(*tar.Reader) error) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := readLayer(l, pr, read)
+		if err == nil {
+			// the digest covers the padding after the archive, too
+			_, err = io.Copy(ioutil.Discard, pr)
+		}
+		pr.CloseWithError(err)
+		done <- err
+	}()
+
+	err := s.fetch("blobs/"+l.Digest, pw, layerOptions(l))
+	pw.CloseWithError(err)
+	readErr := <-done
+	if err != nil {
+		return fmt.Errorf("failed to fetch image layer %s: %v", l.Digest, err)
+	}
+	if readErr != nil {
+		return fmt.Errorf("failed to read image layer %s: %v", l.Digest, readErr)
+	}
+	return nil
+}
+
+// imageEntryName returns the name of a layer entry as a clean relative
+// path, or false if the entry is to be skipped.
+func imageEntryName(name string) (string, bool) {
+	name = path.Clean("/" + name)[1:]
+	return name, name != ""
+}
+
+// relativeTo returns name relative to the directory dir, and whether it is
+// in it or is it.
+func relativeTo(name, dir string) (string, bool) {
+	if dir == "" || name == dir {
+		return strings.TrimPrefix(name[len(dir):], "/"), true
+	}
+	if strings.HasPrefix(name, dir+"/") {
+		return name[len(dir)+1:], true
+	}
+	return "", false
+}
+
+// FetchFromImage extracts the file at the path u names in the container
+// image u references into dest. The manifest of the image is verified if
+// the image is pinned to a digest, and every layer read is verified against
+// its digest.
+func (f *Fetcher) FetchFromImage(u url.URL, dest io.Writer, opts FetchOptions) error {
+	ref, err := ParseImageURL(u)
+	if err != nil {
+		return err
+	}
+	s := &imageSession{f: f, ref: ref}
+	m, err := s.manifest()
+	if err != nil {
+		return err
+	}
+	target := ref.Path[1:]
+
+	tmp, err := ioutil.TempFile("", "ignition-image")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// the topmost layer having the file or deleting it decides
+	for i := len(m.Layers) - 1; i >= 0; i-- {
+		var found, notFile, hidden bool
+		err := s.fetchLayer(m.Layers[i], func(tr *tar.Reader) error {
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				name, ok := imageEntryName(hdr.Name)
+				if !ok {
+					continue
+				}
+				dir, base := path.Split(name)
+				dir = strings.TrimSuffix(dir, "/")
+				switch {
+				case name == target:
+					found = true
+					if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+						notFile = true
+						continue
+					}
+					if _, err := io.Copy(tmp, tr); err != nil {
+						return err
+					}
+				case base == imageOpaqueWhiteout:
+					// the directory's contents in lower layers are hidden
+					if _, in := relativeTo(target, dir); in {
+						hidden = true
+					}
+				case strings.HasPrefix(base, imageWhiteoutPrefix):
+					// the file or one of its directories was deleted
+					if _, in := relativeTo(target, path.Join(dir, base[len(imageWhiteoutPrefix):])); in {
+						hidden = true
+					}
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if notFile {
+			return ErrImageNotFile
+		}
+		if found {
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			return f.decompressCopyHashAndVerify(dest, tmp, opts)
+		}
+		if hidden {
+			break
+		}
+	}
+	return ErrImagePathNotFound
+}
+
+// ImageTreeWriter is given the contents of a directory of a container image
+// by ExtractImageTree, layer by layer from the bottom up. Names are relative
+// to the directory; the empty name is the directory itself.
+type ImageTreeWriter interface {
+	// Remove deletes the node of the given name, which the layer deleted.
+	Remove(name string) error
+	// Clear deletes the contents of the directory of the given name, which
+	// the layer replaced.
+	Clear(name string) error
+	// Write creates the node of the given name from the layer's entry.
+	// Hard links are given with Linkname relative to the directory.
+	Write(name string, hdr *tar.Header, r io.Reader) error
+}
+
+// ExtractImageTree extracts the directory at the path u names in the
+// container image u references into w. Each layer is fetched into a
+// temporary file in tmpDir and verified against its digest before any of it
+// is given to w.
+func (f *Fetcher) ExtractImageTree(u url.URL, tmpDir string, w ImageTreeWriter) error {
+	ref, err := ParseImageURL(u)
+	if err != nil {
+		return err
+	}
+	s := &imageSession{f: f, ref: ref}
+	m, err := s.manifest()
+	if err != nil {
+		return err
+	}
+	root := ref.Path[1:]
+
+	for _, l := range m.Layers {
+		if err := f.applyImageLayer(s, l, root, tmpDir, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyImageLayer gives the entries of the layer below root to w. The
+// deletions of a layer only apply to the layers below it, so they're applied
+// before its other entries.
+func (f *Fetcher) applyImageLayer(s *imageSession, l imageDescriptor, root, tmpDir string, w ImageTreeWriter) error {
+	tmp, err := ioutil.TempFile(tmpDir, "layer")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := s.fetch("blobs/"+l.Digest, tmp, layerOptions(l)); err != nil {
+		return fmt.Errorf("failed to fetch image layer %s: %v", l.Digest, err)
+	}
+
+	for _, deletions := range []bool{true, false} {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		err := readLayer(l, tmp, func(tr *tar.Reader) error {
+			return applyImageEntries(tr, root, deletions, w)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply image layer %s: %v", l.Digest, err)
+		}
+	}
+	return nil
+}
+
+// applyImageEntries gives either the deletions or the other entries of the
+// layer below root to w.
+func applyImageEntries(tr *tar.Reader, root string, deletions bool, w ImageTreeWriter) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name, ok := imageEntryName(hdr.Name)
+		if !ok {
+			continue
+		}
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		isDeletion := strings.HasPrefix(base, imageWhiteoutPrefix)
+		if isDeletion != deletions {
+			continue
+		}
+		switch {
+		case base == imageOpaqueWhiteout:
+			if rel, in := relativeTo(dir, root); in {
+				err = w.Clear(rel)
+			} else if _, above := relativeTo(root, dir); above {
+				err = w.Clear("")
+			}
+		case isDeletion:
+			deleted := path.Join(dir, base[len(imageWhiteoutPrefix):])
+			if rel, in := relativeTo(deleted, root); in && rel != "" {
+				err = w.Remove(rel)
+			} else if _, above := relativeTo(root, deleted); above {
+				err = w.Clear("")
+			}
+		default:
+			rel, in := relativeTo(name, root)
+			if !in {
+				continue
+			}
+			if hdr.Typeflag == tar.TypeLink {
+				target, _ := imageEntryName(hdr.Linkname)
+				linkRel, linkIn := relativeTo(target, root)
+				if !linkIn || linkRel == "" {
+					return fmt.Errorf("hard link %q points out of the extracted directory", hdr.Name)
+				}
+				hdr.Linkname = linkRel
+			}
+			err = w.Write(rel, hdr, tr)
+		}
+		if err != nil {
+			return fmt.Errorf("%q: %v", hdr.Name, err)
+		}
+	}
+}
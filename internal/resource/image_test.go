@@ -0,0 +1,322 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/util"
+)
+
+func TestParseImageURL(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	tests := []struct {
+		in  string
+		out ImageReference
+		err bool
+	}{
+		{
+			in:  "docker://quay.io/example/tools:1.2#/usr/bin/tool",
+			out: ImageReference{Registry: "quay.io", Name: "example/tools", Reference: "1.2", Path: "/usr/bin/tool"},
+		},
+		{
+			in:  "docker://localhost:5000/tools#/usr/bin/../bin/tool",
+			out: ImageReference{Registry: "localhost:5000", Name: "tools", Reference: "latest", Path: "/usr/bin/tool"},
+		},
+		{
+			in:  "docker://quay.io/example/tools:1.2@" + digest + "#/usr/bin/tool",
+			out: ImageReference{Registry: "quay.io", Name: "example/tools", Reference: digest, Path: "/usr/bin/tool"},
+		},
+		{
+			in:  "docker://docker.io/alpine:3#/bin/busybox",
+			out: ImageReference{Registry: "registry-1.docker.io", Name: "library/alpine", Reference: "3", Path: "/bin/busybox"},
+		},
+		{
+			in:  "docker://quay.io/example/tools@md5:1234#/usr/bin/tool",
+			err: true,
+		},
+		{
+			in:  "docker://quay.io/example/tools:1.2",
+			err: true,
+		},
+		{
+			in:  "docker://quay.io/#/usr/bin/tool",
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := ParseImageURL(*u)
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %+v", i, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if ref != test.out {
+			t.Errorf("#%d: bad reference: want %+v, got %+v", i, test.out, ref)
+		}
+	}
+}
+
+type layerEntry struct {
+	name     string
+	contents string
+	typeflag byte
+	linkname string
+}
+
+func makeLayer(t *testing.T, entries []layerEntry) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0755}
+		if hdr.Typeflag == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(e.contents))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newTestRegistry serves an image of the given layers as "tools:1.0" and
+// under any digest, requiring a token from its realm, and returns the
+// server and the actual digest of the manifest.
+func newTestRegistry(t *testing.T, layers [][]byte) (*httptest.Server, string) {
+	blobs := map[string][]byte{}
+	var descriptors []imageDescriptor
+	for _, l := range layers {
+		d := digestOf(l)
+		blobs[d] = l
+		descriptors = append(descriptors, imageDescriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: d, Size: int64(len(l))})
+	}
+	manifest, err := json.Marshal(imageManifest{MediaType: "application/vnd.oci.image.manifest.v1+json", Layers: descriptors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := []byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"digest":"` + digestOf(manifest) + `"}]}`)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:tools:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/tools/manifests/1.0", strings.HasPrefix(r.URL.Path, "/v2/tools/manifests/sha256:"):
+			w.Write(manifest)
+		case r.URL.Path == "/v2/tools/manifests/multi":
+			w.Write(index)
+		case strings.HasPrefix(r.URL.Path, "/v2/tools/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/tools/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv, digestOf(manifest)
+}
+
+func TestFetchFromImage(t *testing.T) {
+	layers := [][]byte{
+		makeLayer(t, []layerEntry{
+			{name: "usr/", typeflag: tar.TypeDir},
+			{name: "usr/bin/tool", contents: "old tool"},
+			{name: "usr/bin/helper", contents: "helper"},
+			{name: "usr/bin/deleted", contents: "deleted"},
+			{name: "opt/app/config", contents: "config"},
+		}),
+		makeLayer(t, []layerEntry{
+			{name: "./usr/bin/tool", contents: "new tool"},
+			{name: "usr/bin/.wh.deleted"},
+			{name: "opt/app/.wh..wh..opq"},
+			{name: "usr/bin/link", typeflag: tar.TypeSymlink, linkname: "tool"},
+		}),
+	}
+	srv, digest := newTestRegistry(t, layers)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{in: "tools:1.0#/usr/bin/tool", out: "new tool"},
+		{in: "tools:1.0@" + digest + "#/usr/bin/tool", out: "new tool"},
+		{in: "tools:1.0#/usr/bin/helper", out: "helper"},
+		{in: "tools:1.0#/usr/bin/deleted", err: ErrImagePathNotFound},
+		{in: "tools:1.0#/opt/app/config", err: ErrImagePathNotFound},
+		{in: "tools:1.0#/usr/bin/link", err: ErrImageNotFile},
+		{in: "tools:multi#/usr/bin/tool", err: ErrImageIndex},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse("docker://" + host + "/" + test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := f.FetchToBuffer(*u, FetchOptions{})
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && string(contents) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, contents)
+		}
+	}
+
+	// a pinned image must match its digest
+	u, _ := url.Parse("docker://" + host + "/tools@sha256:" + strings.Repeat("0", 64) + "#/usr/bin/tool")
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); !reflect.DeepEqual(reflect.TypeOf(err), reflect.TypeOf(util.ErrHashMismatch{})) {
+		t.Errorf("bad error for image not matching its digest: %v", err)
+	}
+}
+
+type recordingTree struct {
+	ops []string
+}
+
+func (r *recordingTree) Remove(name string) error {
+	r.ops = append(r.ops, "remove "+name)
+	return nil
+}
+
+func (r *recordingTree) Clear(name string) error {
+	r.ops = append(r.ops, "clear "+name)
+	return nil
+}
+
+func (r *recordingTree) Write(name string, hdr *tar.Header, rd io.Reader) error {
+	b, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	op := "write " + name
+	switch hdr.Typeflag {
+	case tar.TypeReg:
+		op += " " + string(b)
+	case tar.TypeLink:
+		op += " -> " + hdr.Linkname
+	}
+	r.ops = append(r.ops, op)
+	return nil
+}
+
+func TestExtractImageTree(t *testing.T) {
+	layers := [][]byte{
+		makeLayer(t, []layerEntry{
+			{name: "opt/app/", typeflag: tar.TypeDir},
+			{name: "opt/app/bin/app", contents: "v1"},
+			{name: "opt/app/share/old", contents: "old"},
+			{name: "usr/bin/unrelated", contents: "unrelated"},
+		}),
+		makeLayer(t, []layerEntry{
+			{name: "opt/app/bin/app", contents: "v2"},
+			{name: "opt/app/bin/app-link", typeflag: tar.TypeLink, linkname: "opt/app/bin/app"},
+			// deletions apply to the lower layers, wherever they are
+			{name: "opt/app/share/.wh..wh..opq"},
+			{name: "opt/app/share/new", contents: "new"},
+			{name: "opt/app/bin/.wh.gone"},
+		}),
+	}
+	srv, _ := newTestRegistry(t, layers)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	u, _ := url.Parse("docker://" + host + "/tools:1.0#/opt/app")
+	var tree recordingTree
+	if err := f.ExtractImageTree(*u, dir, &tree); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"write ",
+		"write bin/app v1",
+		"write share/old old",
+		"clear share",
+		"remove bin/gone",
+		"write bin/app v2",
+		"write bin/app-link -> bin/app",
+		"write share/new new",
+	}
+	// deletions of a layer come before its entries, in any order
+	sort.Strings(tree.ops[3:5])
+	if !reflect.DeepEqual(expected, tree.ops) {
+		t.Errorf("bad operations:\nwant %q\ngot  %q", expected, tree.ops)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+const (
+	// IMDSProviderName is the provider name of the credentials of the
+	// instance role.
+	IMDSProviderName = "IMDSRoleProvider"
+
+	imdsEndpoint       = "http://169.254.169.254"
+	imdsTokenPath      = "/latest/api/token"
+	imdsRolesPath      = "/latest/meta-data/iam/security-credentials/"
+	imdsTokenHeader    = "X-aws-ec2-metadata-token"
+	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+
+	// imdsTokenTTL is the longest session the metadata service allows.
+	imdsTokenTTL = 6 * time.Hour
+	// imdsRefreshWindow is the time before they expire that tokens and
+	// credentials are renewed, so that no request uses them expired.
+	imdsRefreshWindow = time.Minute
+)
+
+// IMDSRoleProvider retrieves the credentials of the IAM role of the EC2
+// instance from the instance metadata service. The requests are made in an
+// IMDSv2 session, whose token is renewed as it expires, so that instances
+// requiring IMDSv2 are supported; if the service doesn't hand out tokens,
+// the requests are made without one, like with IMDSv1.
+//
+// Failures are reported with the EC2RoleRequestError code of the SDK's
+// provider, on which S3 fetches fall back to anonymous credentials.
+type IMDSRoleProvider struct {
+	credentials.Expiry
+
+	// Endpoint is the metadata service. It defaults to the one of EC2.
+	Endpoint string
+
+	// Client makes the requests. If left nil, one dialing through the
+	// network stack and interface configured for the distro is created.
+	Client *http.Client
+
+	Logger *log.Logger
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewIMDSCredentials returns the credentials of the instance role.
+func NewIMDSCredentials(l *log.Logger) *credentials.Credentials {
+	return credentials.NewCredentials(&IMDSRoleProvider{Logger: l})
+}
+
+// imdsCredentials is the document the metadata service describes the
+// credentials of a role with.
+type imdsCredentials struct {
+	Code            string
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+func imdsError(msg string, err error) error {
+	return awserr.New("EC2RoleRequestError", msg, err)
+}
+
+// Retrieve discovers the role of the instance and returns its credentials,
+// which expire shortly before the metadata service renews them.
+func (p *IMDSRoleProvider) Retrieve() (credentials.Value, error) {
+	value := credentials.Value{ProviderName: IMDSProviderName}
+
+	roles, err := p.get(imdsRolesPath)
+	if err != nil {
+		return value, imdsError("no EC2 instance role found", err)
+	}
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if role == "" {
+		return value, imdsError("no EC2 instance role found", nil)
+	}
+
+	body, err := p.get(imdsRolesPath + role)
+	if err != nil {
+		return value, imdsError(fmt.Sprintf("failed to get the credentials of role %q", role), err)
+	}
+	var creds imdsCredentials
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return value, imdsError(fmt.Sprintf("failed to decode the credentials of role %q", role), err)
+	}
+	if creds.Code != "Success" {
+		return value, imdsError(fmt.Sprintf("failed to get the credentials of role %q: %s", role, creds.Code), nil)
+	}
+
+	p.SetExpiration(creds.Expiration, imdsRefreshWindow)
+	p.Logger.Debug("using the credentials of instance role %q, expiring at %s", role, creds.Expiration)
+	value.AccessKeyID = creds.AccessKeyID
+	value.SecretAccessKey = creds.SecretAccessKey
+	value.SessionToken = creds.Token
+	return value, nil
+}
+
+func (p *IMDSRoleProvider) now() time.Time {
+	if p.CurrentTime != nil {
+		return p.CurrentTime()
+	}
+	return time.Now()
+}
+
+func (p *IMDSRoleProvider) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Client == nil {
+		client, err := metadataHTTPClient(p.Logger)
+		if err != nil {
+			return nil, err
+		}
+		p.Client = client
+	}
+	return p.Client, nil
+}
+
+// sessionToken returns the token of the IMDSv2 session, starting a new
+// session if there's none or it's about to expire. It returns an empty
+// token if the service doesn't support sessions.
+func (p *IMDSRoleProvider) sessionToken(client *http.Client, renew bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !renew && p.token != "" && p.now().Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPut, p.endpoint()+imdsTokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(imdsTokenTTLHeader, fmt.Sprint(int(imdsTokenTTL.Seconds())))
+	start := p.now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// IMDSv1 only; a 403 means the service is disabled, which the
+		// requests without a token report too
+		p.token = ""
+		return "", nil
+	}
+	token, err := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	p.token = strings.TrimSpace(string(token))
+	p.tokenExpiry = start.Add(imdsTokenTTL - imdsRefreshWindow)
+	return p.token, nil
+}
+
+// get returns the contents of the metadata at path, renewing the session
+// once if the service rejects its token.
+func (p *IMDSRoleProvider) get(path string) ([]byte, error) {
+	client, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	for renew := false; ; renew = true {
+		token, err := p.sessionToken(client, renew)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodGet, p.endpoint()+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set(imdsTokenHeader, token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return body, nil
+		case resp.StatusCode == http.StatusUnauthorized && !renew:
+			continue
+		default:
+			return nil, fmt.Errorf("%s: %s", path, resp.Status)
+		}
+	}
+}
+
+func (p *IMDSRoleProvider) endpoint() string {
+	if p.Endpoint != "" {
+		return strings.TrimSuffix(p.Endpoint, "/")
+	}
+	return imdsEndpoint
+}
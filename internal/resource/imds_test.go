@@ -0,0 +1,144 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/log"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// fakeIMDS is a metadata service requiring IMDSv2 sessions.
+type fakeIMDS struct {
+	role       string
+	sessions   int
+	expiration time.Time
+}
+
+func (m *fakeIMDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := fmt.Sprintf("token-%d", m.sessions)
+	if r.URL.Path == imdsTokenPath {
+		if r.Method != http.MethodPut || r.Header.Get(imdsTokenTTLHeader) == "" {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		m.sessions++
+		fmt.Fprintf(w, "token-%d", m.sessions)
+		return
+	}
+	if r.Header.Get(imdsTokenHeader) != token {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == imdsRolesPath && m.role != "":
+		fmt.Fprintln(w, m.role)
+	case r.URL.Path == imdsRolesPath+m.role && m.role != "":
+		fmt.Fprintf(w, `{"Code": "Success", "Type": "AWS-HMAC", "AccessKeyId": "AKID", "SecretAccessKey": "secret", "Token": "%s", "Expiration": "%s"}`,
+			token, m.expiration.UTC().Format(time.RFC3339))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestIMDSRoleProvider(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	imds := &fakeIMDS{role: "ignition-s3", expiration: now.Add(time.Hour)}
+	srv := httptest.NewServer(imds)
+	defer srv.Close()
+
+	logger := log.New(true)
+	p := &IMDSRoleProvider{Endpoint: srv.URL, Client: srv.Client(), Logger: &logger}
+	p.CurrentTime = func() time.Time { return now }
+
+	v, err := p.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.AccessKeyID != "AKID" || v.SecretAccessKey != "secret" || v.SessionToken != "token-1" || v.ProviderName != IMDSProviderName {
+		t.Errorf("unexpected credentials %+v", v)
+	}
+	if p.IsExpired() {
+		t.Errorf("fresh credentials are expired")
+	}
+
+	// the credentials expire shortly before the service renews them
+	now = now.Add(time.Hour - imdsRefreshWindow/2)
+	if !p.IsExpired() {
+		t.Errorf("credentials aren't expired at the end of their window")
+	}
+	imds.expiration = now.Add(time.Hour)
+	if _, err := p.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if imds.sessions != 1 {
+		t.Errorf("%d sessions started, want the first reused", imds.sessions)
+	}
+
+	// sessions are renewed as they expire
+	now = now.Add(imdsTokenTTL)
+	if v, err = p.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if imds.sessions != 2 || v.SessionToken != "token-2" {
+		t.Errorf("session not renewed: %d sessions, token %q", imds.sessions, v.SessionToken)
+	}
+
+	// and when the service rejects them before
+	imds.sessions = 5
+	if v, err = p.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if imds.sessions != 6 || v.SessionToken != "token-6" {
+		t.Errorf("session not renewed: %d sessions, token %q", imds.sessions, v.SessionToken)
+	}
+
+	// instances without a role fall back to anonymous credentials
+	imds.role = ""
+	_, err = p.Retrieve()
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "EC2RoleRequestError" {
+		t.Errorf("no role: unexpected error %v", err)
+	}
+}
+
+func TestIMDSRoleProviderV1(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			http.Error(w, "", http.StatusMethodNotAllowed)
+		case r.Header.Get(imdsTokenHeader) != "":
+			http.Error(w, "", http.StatusBadRequest)
+		case r.URL.Path == imdsRolesPath:
+			fmt.Fprint(w, "role")
+		case r.URL.Path == imdsRolesPath+"role":
+			fmt.Fprint(w, `{"Code": "Success", "AccessKeyId": "AKID", "SecretAccessKey": "secret", "Token": "t", "Expiration": "2026-10-01T13:00:00Z"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	p := &IMDSRoleProvider{Endpoint: srv.URL, Client: srv.Client(), Logger: &logger}
+	if v, err := p.Retrieve(); err != nil || v.AccessKeyID != "AKID" {
+		t.Errorf("got %+v, %v", v, err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"net/url"
+	"time"
+)
+
+// defaultMirrorTimeout is the time a source is tried before failing over to
+// the next one, if its timeout isn't set.
+const defaultMirrorTimeout = 60 * time.Second
+
+// Mirror is one of several sources serving the same resource.
+type Mirror struct {
+	URL url.URL
+	// Timeout limits the time spent fetching from URL, including retries.
+	// If unset, it defaults to a minute for every mirror but the last,
+	// which is fetched from with the configured total timeout.
+	Timeout time.Duration
+}
+
+// FetchToBufferFromMirrors fetches the resource from the first of mirrors
+// which serves it, trying them in order. The contents are passed to check,
+// if it is set, and contents check rejects fail over to the next mirror as
+// well. The error of the last mirror is returned if none served the
+// resource. A mirror answering a conditional request with ErrNotModified
+// ends the failover, as the caller already has the resource. The URL of the
+// mirror which served the resource is returned along with it.
+func (f *Fetcher) FetchToBufferFromMirrors(mirrors []Mirror, opts FetchOptions, check func([]byte) error) ([]byte, url.URL, error) {
+	var err error
+	for i, m := range mirrors {
+		opts.Timeout = m.Timeout
+		if opts.Timeout == 0 && i < len(mirrors)-1 {
+			opts.Timeout = defaultMirrorTimeout
+		}
+		if opts.Hash != nil {
+			opts.Hash.Reset()
+		}
+
+		if len(mirrors) > 1 {
+			f.Logger.Info("fetching from mirror %d of %d: %s", i+1, len(mirrors), RedactURL(m.URL))
+		}
+		start := time.Now()
+		var data []byte
+		data, err = f.FetchToBuffer(m.URL, opts)
+		if err == nil && check != nil {
+			err = check(data)
+		}
+		if err == ErrNotModified {
+			return nil, url.URL{}, err
+		}
+		if err == nil {
+			if len(mirrors) > 1 {
+				f.Logger.Info("mirror %s served the resource in %v", RedactURL(m.URL), time.Since(start).Round(time.Millisecond))
+			}
+			return data, m.URL, nil
+		}
+		if len(mirrors) > 1 {
+			f.Logger.Warning("mirror %s failed after %v: %v", RedactURL(m.URL), time.Since(start).Round(time.Millisecond), err)
+		}
+	}
+	return nil, url.URL{}, err
+}
+
+// RedactURL returns u for logging, without the contents of data urls, which
+// might contain secrets, and without the userinfo and the query, which might
+// contain credentials like the signatures of presigned urls.
+func RedactURL(u url.URL) string {
+	if u.Scheme == "data" {
+		return "data url"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	return u.String()
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+)
+
+// ociTitleAnnotation names the file a layer of an artifact holds; oras sets
+// it to the name of the pushed file.
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+var (
+	ErrArtifactLayerNotFound  = errors.New("no layer of the artifact has that title or digest")
+	ErrArtifactLayerAmbiguous = errors.New("the artifact has several layers, select one by its title or digest after '#'")
+)
+
+// artifactLayer returns the layer of the artifact m selected by the
+// fragment of an oci:// url: the layer of that title or digest, or the only
+// layer if there's no fragment.
+func artifactLayer(m imageManifest, selector string) (imageDescriptor, error) {
+	if selector == "" {
+		if len(m.Layers) != 1 {
+			return imageDescriptor{}, ErrArtifactLayerAmbiguous
+		}
+		return m.Layers[0], nil
+	}
+	var found []imageDescriptor
+	for _, l := range m.Layers {
+		if l.Digest == selector || l.Annotations[ociTitleAnnotation] == selector {
+			found = append(found, l)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return imageDescriptor{}, ErrArtifactLayerNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return imageDescriptor{}, fmt.Errorf("the artifact has %d layers titled %q", len(found), selector)
+	}
+}
+
+// FetchFromOCI fetches a layer of the artifact u references into dest, as
+// pushed by oras, e.g. oci://registry.example.com/tools/kubectl:v1.30#kubectl.
+// The layer is selected by its title or digest after '#', and fetched as
+// is, without reading it as a tar archive. The manifest of the artifact is
+// verified if it is pinned to a digest, and the layer against its digest.
+// The headers of opts are sent to the registry and to its token service,
+// so that private repositories can be fetched from.
+func (f *Fetcher) FetchFromOCI(u url.URL, dest io.Writer, opts FetchOptions) error {
+	ref, err := parseImageReference(u)
+	if err != nil {
+		return err
+	}
+	s := &imageSession{f: f, ref: ref, headers: opts.Headers, artifact: true}
+	m, err := s.manifest()
+	if err != nil {
+		return err
+	}
+	l, err := artifactLayer(m, u.Fragment)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile("", "ignition-artifact")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := s.fetch("blobs/"+l.Digest, tmp, layerOptions(l)); err != nil {
+		return fmt.Errorf("failed to fetch artifact layer %s: %v", l.Digest, err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return f.decompressCopyHashAndVerify(dest, tmp, opts)
+}
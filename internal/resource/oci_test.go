@@ -0,0 +1,126 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestFetchFromOCI(t *testing.T) {
+	blobs := map[string][]byte{}
+	var layers []imageDescriptor
+	for _, file := range []struct{ title, contents string }{
+		{"kubectl", "kubectl binary"},
+		{"worker.ign", `{"ignition":{"version":"2.4.0"}}`},
+	} {
+		d := digestOf([]byte(file.contents))
+		blobs[d] = []byte(file.contents)
+		layers = append(layers, imageDescriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      d,
+			Size:        int64(len(file.contents)),
+			Annotations: map[string]string{ociTitleAnnotation: file.title},
+		})
+	}
+	manifest, err := json.Marshal(imageManifest{MediaType: "application/vnd.oci.image.manifest.v1+json", Layers: layers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	single, err := json.Marshal(imageManifest{MediaType: "application/vnd.oci.image.manifest.v1+json", Layers: layers[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the token service wants the credentials of the config
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/tools/manifests/1.0":
+			w.Write(manifest)
+		case r.URL.Path == "/v2/tools/manifests/single":
+			w.Write(single)
+		case strings.HasPrefix(r.URL.Path, "/v2/tools/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/tools/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	auth := http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}
+
+	tests := []struct {
+		in      string
+		headers http.Header
+		out     string
+		err     error
+	}{
+		{in: "tools:1.0#kubectl", headers: auth, out: "kubectl binary"},
+		{in: "tools:1.0#worker.ign", headers: auth, out: `{"ignition":{"version":"2.4.0"}}`},
+		{in: "tools:1.0#" + layers[0].Digest, headers: auth, out: "kubectl binary"},
+		{in: "tools:single", headers: auth, out: "kubectl binary"},
+		{in: "tools:1.0", headers: auth, err: ErrArtifactLayerAmbiguous},
+		{in: "tools:1.0#missing", headers: auth, err: ErrArtifactLayerNotFound},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse("oci://" + host + "/" + test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := f.FetchToBuffer(*u, FetchOptions{Headers: test.headers})
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && string(contents) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, contents)
+		}
+	}
+
+	// without credentials, no token is handed out
+	u, _ := url.Parse("oci://" + host + "/tools:1.0#kubectl")
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); err == nil {
+		t.Errorf("artifact fetched without credentials")
+	}
+}
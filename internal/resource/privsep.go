@@ -0,0 +1,511 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/confine"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// The fetch helper is an unprivileged child process doing all http(s) and
+// tftp fetches, so that a compromise of the HTTP, TLS or decompression code
+// doesn't yield the privileges needed to write to disks. The parent sends it
+// one JSON request at a time on its stdin and reads the response from its
+// stdout as a sequence of frames: data frames carrying the fetched contents,
+// terminated by either a done frame or an error frame carrying the error
+// message. Hashes are verified by the parent, which doesn't trust the helper.
+
+const (
+	// fetchHelperEnv marks the fetch helper process.
+	fetchHelperEnv = "IGNITION_FETCH_HELPER"
+
+	// maxFrameSize bounds the payload of a frame, so a compromised helper
+	// can't make the parent allocate arbitrary amounts of memory.
+	maxFrameSize = 64 * 1024
+
+	// maxPostResponseSize bounds the response to a POST passed back by the
+	// helper, which is read into memory as a whole.
+	maxPostResponseSize = 1 << 20
+
+	frameData  = 'd'
+	frameDone  = 'o'
+	frameError = 'e'
+)
+
+var (
+	ErrFetchHelperProtocol = errors.New("fetch helper violated the protocol")
+
+	// helperErrors are the errors callers compare against, which have to
+	// keep their identity when they are passed back from the helper.
+	helperErrors = []error{
+		ErrSchemeUnsupported,
+		ErrNotFound,
+		ErrNotModified,
+		ErrTooLarge,
+		ErrFailed,
+		ErrCompressionUnsupported,
+		ErrDecompressedTooLarge,
+		ErrCompressionRatio,
+		ErrTimeout,
+		ErrRedirectLimit,
+		ErrRedirectHost,
+		ErrRedirectDowngrade,
+		configErrors.ErrCompressionInvalid,
+		configErrors.ErrHTML,
+	}
+)
+
+// helperRequest is a request sent to the fetch helper. Op is one of
+// "configure", "rateLimit", "get" and "post".
+type helperRequest struct {
+	Op              string          `json:"op"`
+	URL             string          `json:"url,omitempty"`
+	Headers         http.Header     `json:"headers,omitempty"`
+	HeadersRedirect http.Header     `json:"headersRedirect,omitempty"`
+	Compression     string          `json:"compression,omitempty"`
+	Timeout         time.Duration   `json:"timeout,omitempty"`
+	MaxSize         int64           `json:"maxSize,omitempty"`
+	RejectHTML      bool            `json:"rejectHTML,omitempty"`
+	Body            []byte          `json:"body,omitempty"`
+	Timeouts        types.Timeouts  `json:"timeouts"`
+	TLS             types.TLS       `json:"tls"`
+	Redirects       types.Redirects `json:"redirects"`
+	RateLimit       int64           `json:"rateLimit,omitempty"`
+	Proxy           types.Proxy     `json:"proxy"`
+}
+
+// fetchHelper is the parent's end of a fetch helper. Requests are served
+// one at a time.
+type fetchHelper struct {
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+	// err is set once the helper can't be used anymore.
+	err error
+}
+
+// InFetchHelper reports whether this process is the fetch helper of
+// another Ignition process.
+func InFetchHelper() bool {
+	return os.Getenv(fetchHelperEnv) != ""
+}
+
+// StartFetchHelper starts the fetch helper, through which f then does all
+// http(s) and tftp fetches. The helper runs as an unprivileged user without
+// capabilities, can't write anywhere and is denied the same system calls
+// as confined stages.
+func (f *Fetcher) StartFetchHelper() error {
+	return f.startFetchHelper("/proc/self/exe", &syscall.Credential{
+		Uid: uint32(distro.FetchHelperUID()),
+		Gid: uint32(distro.FetchHelperGID()),
+	})
+}
+
+func (f *Fetcher) startFetchHelper(path string, cred *syscall.Credential) error {
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), fetchHelperEnv+"=1")
+	cmd.Dir = "/"
+	cmd.Stderr = os.Stderr
+	// Pdeathsig can't be used as the thread starting the helper exits
+	// right away; the helper exits when its stdin is closed instead.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	res, err := confine.Start(cmd, confine.Policy{WritableFiles: []string{"/dev/null"}})
+	if err != nil {
+		return err
+	}
+	f.Logger.Info("started fetch helper (pid %d, Landlock ABI %d, seccomp %t)", cmd.Process.Pid, res.LandlockABI, res.Seccomp)
+	f.helper = &fetchHelper{
+		cmd: cmd,
+		in:  in,
+		out: bufio.NewReader(out),
+	}
+	return nil
+}
+
+// StopFetchHelper stops the fetch helper, if one was started. Fetches
+// through the stopped helper fail.
+func (f *Fetcher) StopFetchHelper() error {
+	h := f.helper
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return nil
+	}
+	h.err = errors.New("fetch helper was stopped")
+	h.in.Close()
+	return h.cmd.Wait()
+}
+
+// fail makes the helper unusable and kills it.
+func (h *fetchHelper) fail(err error) {
+	h.err = fmt.Errorf("fetch helper failed: %v", err)
+	h.in.Close()
+	h.cmd.Process.Kill()
+	h.cmd.Wait()
+}
+
+// roundTrip sends req to the helper and passes the response's contents to
+// handle. The rest of the response is discarded if handle fails, to stay in
+// step with the helper. If ctx is cancelled first, the helper is killed, as
+// the request can't be cancelled otherwise.
+func (h *fetchHelper) roundTrip(ctx context.Context, req helperRequest, handle func(io.Reader) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return h.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	if err := json.NewEncoder(h.in).Encode(req); err != nil {
+		h.fail(err)
+		return h.err
+	}
+	r := &frameReader{r: h.out}
+	err := handle(r)
+	io.Copy(ioutil.Discard, r)
+	if r.broken != nil {
+		h.fail(r.broken)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return h.err
+	}
+	if err == nil {
+		// handle may have stopped reading at the end of the contents
+		// without seeing whether the helper ran into an error.
+		if r.err != io.EOF {
+			err = r.err
+		}
+	}
+	return err
+}
+
+// fetchViaHelper has the helper fetch and decompress u, and verifies the
+// contents itself.
+func (f *Fetcher) fetchViaHelper(u url.URL, dest io.Writer, opts FetchOptions) error {
+	req := helperRequest{
+		Op:              "get",
+		URL:             u.String(),
+		Headers:         opts.Headers,
+		HeadersRedirect: opts.HeadersRedirect,
+		Compression:     opts.Compression,
+		Timeout:         opts.Timeout,
+		MaxSize:         opts.MaxSize,
+		RejectHTML:      opts.RejectHTML,
+	}
+	opts.Compression = ""
+	return f.helper.roundTrip(f.BaseContext(), req, func(r io.Reader) error {
+		return f.decompressCopyHashAndVerify(dest, r, opts)
+	})
+}
+
+// postViaHelper has the helper POST body to u and returns the response,
+// failing with ErrTooLarge if it's larger than maxPostResponseSize.
+func (f *Fetcher) postViaHelper(u url.URL, body []byte, opts FetchOptions) ([]byte, error) {
+	req := helperRequest{
+		Op:      "post",
+		URL:     u.String(),
+		Headers: opts.Headers,
+		Body:    body,
+	}
+	var resp []byte
+	err := f.helper.roundTrip(f.BaseContext(), req, func(r io.Reader) error {
+		var err error
+		resp, err = ioutil.ReadAll(io.LimitReader(r, maxPostResponseSize+1))
+		if err == nil && len(resp) > maxPostResponseSize {
+			err = ErrTooLarge
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// configureHelper passes the timeouts, TLS settings, redirect policy and
+// proxy to the helper.
+// The CAs and client certificates are fetched and verified here, and passed
+// on as data urls.
+func (f *Fetcher) configureHelper(timeouts types.Timeouts, tlsCfg types.TLS, redirects types.Redirects, proxy types.Proxy) error {
+	if f.client == nil {
+		// the client caches the fetched CAs
+		if err := f.newHttpClient(); err != nil {
+			return err
+		}
+	}
+	resolved := make([]types.CaReference, len(tlsCfg.CertificateAuthorities))
+	copy(resolved, tlsCfg.CertificateAuthorities)
+	if err := f.RewriteCAsWithDataUrls(resolved); err != nil {
+		return err
+	}
+	tlsCfg.CertificateAuthorities = resolved
+	clientCerts, err := f.rewriteClientCertificatesWithDataUrls(tlsCfg.ClientCertificates)
+	if err != nil {
+		return err
+	}
+	tlsCfg.ClientCertificates = clientCerts
+	req := helperRequest{
+		Op:        "configure",
+		Timeouts:  timeouts,
+		TLS:       tlsCfg,
+		Redirects: redirects,
+		Proxy:     proxy,
+	}
+	return f.helper.roundTrip(f.BaseContext(), req, func(r io.Reader) error {
+		_, err := io.Copy(ioutil.Discard, r)
+		return err
+	})
+}
+
+// ServeFetchHelper serves the requests of the Ignition process which
+// started this one as its fetch helper, until in is closed.
+func ServeFetchHelper(logger *log.Logger, in io.Reader, out io.Writer) error {
+	f := Fetcher{Logger: logger}
+	if err := f.newHttpClient(); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(in)
+	w := bufio.NewWriter(out)
+	for {
+		var req helperRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		err := f.serve(req, frameWriter{w})
+		if err != nil {
+			msg := err.Error()
+			if len(msg) > maxFrameSize {
+				msg = msg[:maxFrameSize]
+			}
+			err = writeFrame(w, frameError, []byte(msg))
+		} else {
+			err = writeFrame(w, frameDone, nil)
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serve serves a single request, writing the contents of the response to
+// dest.
+func (f *Fetcher) serve(req helperRequest, dest io.Writer) error {
+	switch req.Op {
+	case "configure":
+		return f.UpdateHttpTimeoutsAndCAs(req.Timeouts, req.TLS, req.Redirects, req.Proxy)
+	case "rateLimit":
+		return f.SetRateLimit(req.RateLimit)
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "tftp":
+	default:
+		return ErrSchemeUnsupported
+	}
+	switch req.Op {
+	case "get":
+		return f.fetch(*u, dest, FetchOptions{
+			Headers:         req.Headers,
+			HeadersRedirect: req.HeadersRedirect,
+			Compression:     req.Compression,
+			Timeout:         req.Timeout,
+			MaxSize:         req.MaxSize,
+			RejectHTML:      req.RejectHTML,
+		})
+	case "post":
+		body, err := f.PostToBuffer(*u, req.Body, FetchOptions{Headers: req.Headers})
+		if err != nil {
+			return err
+		}
+		_, err = dest.Write(body)
+		return err
+	default:
+		return fmt.Errorf("unknown request %q", req.Op)
+	}
+}
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	var header [5]byte
+	header[0] = typ
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// frameWriter writes data frames.
+type frameWriter struct {
+	w io.Writer
+}
+
+func (w frameWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxFrameSize {
+			chunk = chunk[:maxFrameSize]
+		}
+		if err := writeFrame(w.w, frameData, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// frameReader reads the contents of a single response. It returns io.EOF
+// at a done frame and the helper's error at an error frame.
+type frameReader struct {
+	r *bufio.Reader
+	// remaining is the number of bytes left in the current data frame.
+	remaining int
+	// err is returned once the response ended.
+	err error
+	// broken is set if the helper's output can't be trusted to be in
+	// step with the requests anymore.
+	broken error
+}
+
+func (r *frameReader) Read(p []byte) (int, error) {
+	for r.remaining == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.readHeader()
+	}
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= n
+	if err != nil {
+		r.breakWith(err)
+		return n, r.err
+	}
+	return n, nil
+}
+
+func (r *frameReader) readHeader() {
+	var header [5]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		r.breakWith(err)
+		return
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxFrameSize {
+		r.breakWith(ErrFetchHelperProtocol)
+		return
+	}
+	switch header[0] {
+	case frameData:
+		r.remaining = int(size)
+	case frameDone:
+		if size != 0 {
+			r.breakWith(ErrFetchHelperProtocol)
+			return
+		}
+		r.err = io.EOF
+	case frameError:
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r.r, msg); err != nil {
+			r.breakWith(err)
+			return
+		}
+		r.err = helperError(string(msg))
+	default:
+		r.breakWith(ErrFetchHelperProtocol)
+	}
+}
+
+func (r *frameReader) breakWith(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	r.broken = err
+	r.err = err
+	r.remaining = 0
+}
+
+// helperError returns the error the helper reported with msg.
+func helperError(msg string) error {
+	for _, err := range helperErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	if strings.HasPrefix(msg, authChallengePrefix) {
+		return AuthChallengeError{Challenge: msg[len(authChallengePrefix):]}
+	}
+	return errors.New(msg)
+}
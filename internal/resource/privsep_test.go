@@ -0,0 +1,252 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/util"
+)
+
+func TestMain(m *testing.M) {
+	// the tests start the test binary itself as the fetch helper
+	if InFetchHelper() {
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		logger := log.New(true)
+		if err := ServeFetchHelper(&logger, os.Stdin, out); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestFetchHelper(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789abcdef"), 3*maxFrameSize/16+7)
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(large)
+	gw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Write([]byte("hello"))
+		case "/large":
+			w.Write(large)
+		case "/gzip":
+			w.Write(gzipped.Bytes())
+		case "/post-large":
+			w.Write(bytes.Repeat([]byte("x"), maxPostResponseSize+1))
+		case "/echo":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(append([]byte(r.Method+" "), body...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	if err := f.startFetchHelper(os.Args[0], nil); err != nil {
+		t.Fatalf("starting fetch helper: %v", err)
+	}
+	defer f.StopFetchHelper()
+
+	sum := func(b []byte) []byte {
+		s := sha512.Sum512(b)
+		return s[:]
+	}
+	mustParse := func(s string) url.URL {
+		u, err := url.Parse(srv.URL + s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *u
+	}
+
+	type in struct {
+		path        string
+		compression string
+		expectedSum []byte
+	}
+	type out struct {
+		data []byte
+		err  error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{path: "/small"},
+			out: out{data: []byte("hello")},
+		},
+		{
+			in:  in{path: "/missing"},
+			out: out{err: ErrNotFound},
+		},
+		{
+			in:  in{path: "/large", expectedSum: sum(large)},
+			out: out{data: large},
+		},
+		{
+			in: in{path: "/large", expectedSum: sum([]byte("hello"))},
+			out: out{err: util.ErrHashMismatch{
+				Calculated: hex.EncodeToString(sum(large)),
+				Expected:   hex.EncodeToString(sum([]byte("hello"))),
+			}},
+		},
+		{
+			in:  in{path: "/gzip", compression: "gzip", expectedSum: sum(large)},
+			out: out{data: large},
+		},
+		{
+			in:  in{path: "/large", compression: "gzip"},
+			out: out{err: gzip.ErrHeader},
+		},
+		// the helper is still in step after the failed fetches
+		{
+			in:  in{path: "/small"},
+			out: out{data: []byte("hello")},
+		},
+	}
+
+	for i, test := range tests {
+		opts := FetchOptions{Compression: test.in.compression}
+		if test.in.expectedSum != nil {
+			opts.Hash = sha512.New()
+			opts.ExpectedSum = test.in.expectedSum
+		}
+		data, err := f.FetchToBuffer(mustParse(test.in.path), opts)
+		if test.out.err != nil {
+			if err == nil || err.Error() != test.out.err.Error() {
+				t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(data, test.out.data) {
+			t.Errorf("#%d: bad data: want %d bytes, got %d bytes", i, len(test.out.data), len(data))
+		}
+	}
+
+	resp, err := f.PostToBuffer(mustParse("/echo"), []byte("body"), FetchOptions{})
+	if err != nil || string(resp) != "POST body" {
+		t.Errorf("bad post response: want %q, got %q (%v)", "POST body", resp, err)
+	}
+	if _, err := f.PostToBuffer(mustParse("/post-large"), nil, FetchOptions{}); err != ErrTooLarge {
+		t.Errorf("bad error for a large post response: want %v, got %v", ErrTooLarge, err)
+	}
+	// the helper is still in step after the discarded response
+	resp, err = f.PostToBuffer(mustParse("/echo"), []byte("again"), FetchOptions{})
+	if err != nil || string(resp) != "POST again" {
+		t.Errorf("bad post response: want %q, got %q (%v)", "POST again", resp, err)
+	}
+
+	if err := f.StopFetchHelper(); err != nil {
+		t.Errorf("stopping fetch helper: %v", err)
+	}
+	if _, err := f.FetchToBuffer(mustParse("/small"), FetchOptions{}); err == nil {
+		t.Errorf("fetching through the stopped helper succeeded")
+	}
+}
+
+func TestFrameReader(t *testing.T) {
+	frames := func(write func(*bytes.Buffer)) *bufio.Reader {
+		var b bytes.Buffer
+		write(&b)
+		return bufio.NewReader(&b)
+	}
+
+	tests := []struct {
+		in     *bufio.Reader
+		data   string
+		err    error
+		broken bool
+	}{
+		{
+			in: frames(func(b *bytes.Buffer) {
+				frameWriter{b}.Write([]byte("hello "))
+				writeFrame(b, frameData, nil)
+				frameWriter{b}.Write([]byte("world"))
+				writeFrame(b, frameDone, nil)
+			}),
+			data: "hello world",
+		},
+		{
+			in: frames(func(b *bytes.Buffer) {
+				frameWriter{b}.Write([]byte("partial"))
+				writeFrame(b, frameError, []byte(ErrNotFound.Error()))
+			}),
+			data: "partial",
+			err:  ErrNotFound,
+		},
+		{
+			in: frames(func(b *bytes.Buffer) {
+				b.Write([]byte{frameData, 0xff, 0xff, 0xff, 0xff})
+			}),
+			err:    ErrFetchHelperProtocol,
+			broken: true,
+		},
+		{
+			in: frames(func(b *bytes.Buffer) {
+				writeFrame(b, 'x', nil)
+			}),
+			err:    ErrFetchHelperProtocol,
+			broken: true,
+		},
+		{
+			in: frames(func(b *bytes.Buffer) {
+				frameWriter{b}.Write([]byte("truncated"))
+			}),
+			data:   "truncated",
+			err:    io.ErrUnexpectedEOF,
+			broken: true,
+		},
+	}
+
+	for i, test := range tests {
+		r := &frameReader{r: test.in}
+		data, err := ioutil.ReadAll(r)
+		if string(data) != test.data {
+			t.Errorf("#%d: bad data: want %q, got %q", i, test.data, data)
+		}
+		if err != test.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		}
+		if (r.broken != nil) != test.broken {
+			t.Errorf("#%d: bad broken: want %t, got %v", i, test.broken, r.broken)
+		}
+	}
+}
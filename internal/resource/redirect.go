@@ -0,0 +1,71 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/flatcar/ignition/internal/config/types"
+)
+
+// defaultRedirectLimit is the number of redirects followed unless limited
+// further, the same as net/http's.
+const defaultRedirectLimit = 10
+
+var (
+	ErrRedirectLimit     = errors.New("too many redirects")
+	ErrRedirectHost      = errors.New("redirect to another host refused")
+	ErrRedirectDowngrade = errors.New("redirect from https to http refused")
+)
+
+// checkRedirect refuses to follow the redirect to req, after the requests
+// in via, if the policy forbids it.
+func checkRedirect(policy types.Redirects, req *http.Request, via []*http.Request) error {
+	limit := defaultRedirectLimit
+	if policy.Limit != nil {
+		limit = *policy.Limit
+	}
+	if len(via) > limit {
+		return ErrRedirectLimit
+	}
+	if policy.SameHost && req.URL.Host != via[0].URL.Host {
+		return ErrRedirectHost
+	}
+	if policy.NoDowngrade && req.URL.Scheme == "http" {
+		for _, prev := range via {
+			if prev.URL.Scheme == "https" {
+				return ErrRedirectDowngrade
+			}
+		}
+	}
+	return nil
+}
+
+// refusedRedirect returns the error of a request which failed because the
+// policy refused to follow a redirect, which retrying won't change, and
+// whether it did.
+func refusedRedirect(err error) (error, bool) {
+	uerr, ok := err.(*url.Error)
+	if !ok {
+		return nil, false
+	}
+	switch uerr.Err {
+	case ErrRedirectLimit, ErrRedirectHost, ErrRedirectDowngrade:
+		return uerr.Err, true
+	}
+	return nil, false
+}
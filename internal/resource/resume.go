@@ -0,0 +1,134 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResumes is the number of times a download interrupted part way is
+// resumed before giving up.
+const maxResumes = 10
+
+// resumingBody reads the body of a response, and if the connection breaks
+// before it's read in full, asks the server for the rest of it with a Range
+// request, so that large downloads don't start over. The bytes already read
+// have been written to the destination, and hashed, so the reader of the
+// body sees one continuous stream and verifies it as usual at its end.
+type resumingBody struct {
+	client HttpClient
+	ctx    context.Context
+	url    string
+	header http.Header
+	// validator is the strong ETag or the Last-Modified date of the
+	// response, with which If-Range makes sure the rest is of the same
+	// version of the resource
+	validator string
+
+	body    io.ReadCloser
+	read    int64
+	resumes int
+	cancels []context.CancelFunc
+}
+
+// resumable returns the body of resp, the response to a GET of u with
+// header, which is resumed if its download is interrupted and the server
+// supports it.
+func resumable(client HttpClient, ctx context.Context, u string, header http.Header, resp *http.Response) io.ReadCloser {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" || resp.Uncompressed {
+		return resp.Body
+	}
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		// weak ETags can't be used with If-Range
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		return resp.Body
+	}
+	return &resumingBody{
+		client:    client,
+		ctx:       ctx,
+		url:       u,
+		header:    header,
+		validator: validator,
+		body:      resp.Body,
+	}
+}
+
+func (b *resumingBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		if b.ctx.Err() != nil || b.resumes >= maxResumes {
+			return n, err
+		}
+		b.resumes++
+		b.client.logger.Info("GET %s: resuming at byte %d after: %v", b.url, b.read, err)
+		if rerr := b.resume(); rerr != nil {
+			b.client.logger.Info("GET %s: can't resume: %v", b.url, rerr)
+			b.resumes = maxResumes
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume replaces the body with the response to a Range request for the
+// bytes not read yet.
+func (b *resumingBody) resume() error {
+	b.body.Close()
+	header := http.Header{}
+	for key, values := range b.header {
+		header[key] = values
+	}
+	header.Set("Range", fmt.Sprintf("bytes=%d-", b.read))
+	header.Set("If-Range", b.validator)
+	resp, cancel, err := b.client.getWithHeader(b.ctx, b.url, header)
+	if cancel != nil {
+		b.cancels = append(b.cancels, cancel)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		// the resource changed, or the server ignored the range
+		discardBody(resp.Body)
+		return fmt.Errorf("server responded with %q", http.StatusText(resp.StatusCode))
+	}
+	if cr := resp.Header.Get("Content-Range"); !strings.HasPrefix(cr, fmt.Sprintf("bytes %d-", b.read)) {
+		discardBody(resp.Body)
+		return fmt.Errorf("server responded with range %q", cr)
+	}
+	b.body = resp.Body
+	return nil
+}
+
+func (b *resumingBody) Close() error {
+	err := b.body.Close()
+	for _, cancel := range b.cancels {
+		cancel()
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/fips"
+	"github.com/flatcar/ignition/internal/util"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// sftpHostKeyAlias is the name the host keys of a server are listed under
+// in the known_hosts file written for each fetch, whatever its address.
+const sftpHostKeyAlias = "ignition-sftp"
+
+var (
+	ErrSFTPNoKey        = errors.New("no key is configured for the sftp host")
+	ErrSFTPNetworkStack = errors.New("sftp is only supported by the kernel network stack")
+	ErrSFTPPrivsep      = errors.New("sftp is not supported with privilege-separated fetching")
+)
+
+// sftpHost is the private key a host of ignition.security.sftp is
+// authenticated with, and the host keys it must present.
+type sftpHost struct {
+	key      []byte
+	hostKeys []string
+}
+
+// UpdateSFTPHosts fetches the private keys of the hosts sftp resources are
+// fetched from and rewrites their sources to data urls, so that the config
+// can be cached without the need to fetch them again.
+func (f *Fetcher) UpdateSFTPHosts(hosts []types.SFTPHost) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	res := map[string]sftpHost{}
+	for i, h := range hosts {
+		key, err := f.fetchSFTPKey(h.PrivateKey)
+		if err != nil {
+			f.Logger.Err("unable to fetch the sftp key of %q: %v", h.Host, err)
+			return err
+		}
+		res[sftpHostKey(h.Host)] = sftpHost{key: key, hostKeys: h.HostKeys}
+
+		hosts[i].PrivateKey.HTTPHeaders = nil
+		hosts[i].PrivateKey.Source = dataurl.EncodeBytes(key)
+	}
+	f.sftpHosts = res
+	return nil
+}
+
+func (f *Fetcher) fetchSFTPKey(k types.SFTPPrivateKey) ([]byte, error) {
+	u, err := url.Parse(k.Source)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := util.GetHasher(k.Verification)
+	if err != nil {
+		return nil, err
+	}
+	var expectedSum []byte
+	if hasher != nil {
+		// the config was validated, so the hash is well-formed
+		_, sum, _ := util.HashParts(k.Verification)
+		if expectedSum, err = hex.DecodeString(sum); err != nil {
+			return nil, err
+		}
+	}
+	var headers http.Header
+	if len(k.HTTPHeaders) > 0 {
+		if headers, err = k.HTTPHeaders.Parse(); err != nil {
+			return nil, err
+		}
+	}
+	return f.FetchToBuffer(*u, FetchOptions{
+		Hash:        hasher,
+		Headers:     headers,
+		ExpectedSum: expectedSum,
+	})
+}
+
+// sftpHostKey returns the host and port of a host of
+// ignition.security.sftp, the port defaulting to 22.
+func sftpHostKey(host string) string {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return net.JoinHostPort(h, p)
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), "22")
+}
+
+// FetchFromSFTP fetches a file from u, like sftp://user@host:port/path,
+// with the sftp command into dest, returning an error if one is
+// encountered. The user is authenticated with the private key configured
+// for the host, and the host with its configured host keys. The sftp
+// command would run with all of Ignition's privileges, so it is refused
+// when fetches are privilege-separated.
+func (f *Fetcher) FetchFromSFTP(u url.URL, dest io.Writer, opts FetchOptions) error {
+	if f.helper != nil {
+		return ErrSFTPPrivsep
+	}
+	dialer, err := f.dialer()
+	if err != nil {
+		return err
+	}
+	// the sftp command makes its own connections
+	if _, ok := dialer.(*kernelDialer); !ok {
+		return ErrSFTPNetworkStack
+	}
+	if u.User == nil || u.User.Username() == "" || strings.ContainsAny(u.Path, "\"\\*?[\n\r") {
+		return configErrors.ErrInvalidSFTPUrl
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	host, ok := f.sftpHosts[net.JoinHostPort(u.Hostname(), port)]
+	if !ok {
+		return ErrSFTPNoKey
+	}
+
+	dir, err := ioutil.TempDir("", "ignition-sftp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, "key")
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	outPath := filepath.Join(dir, "out")
+	// ssh ignores private keys other users can read
+	if err := ioutil.WriteFile(keyPath, host.key, 0600); err != nil {
+		return err
+	}
+	var knownHosts strings.Builder
+	for _, k := range host.hostKeys {
+		fmt.Fprintf(&knownHosts, "%s %s\n", sftpHostKeyAlias, k)
+	}
+	if err := ioutil.WriteFile(knownHostsPath, []byte(knownHosts.String()), 0600); err != nil {
+		return err
+	}
+
+	target := u.Hostname()
+	if strings.Contains(target, ":") {
+		target = "[" + target + "]"
+	}
+	cmdPath := distro.SFTPCmd()
+	if f.sftpCmd != "" {
+		cmdPath = f.sftpCmd
+	}
+	args := []string{
+		"-b", "-",
+		"-P", port,
+		"-i", keyPath,
+		"-F", "/dev/null",
+		"-o", "User=" + u.User.Username(),
+		"-o", "IdentitiesOnly=yes",
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + knownHostsPath,
+		"-o", "GlobalKnownHostsFile=/dev/null",
+		"-o", "HostKeyAlias=" + sftpHostKeyAlias,
+		"-o", "ConnectTimeout=30",
+	}
+	if fips.Enabled() {
+		args = append(args, fips.RestrictSSH()...)
+	}
+	args = append(args, "--", target)
+	cmd := exec.CommandContext(f.BaseContext(), cmdPath, args...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("get \"%s\" \"%s\"\n", u.Path, outPath))
+	if _, err := f.Logger.LogCmd(cmd, "fetching %q", u.String()); err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "No such file") {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	out, err := os.Open(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return f.decompressCopyHashAndVerify(dest, out, opts)
+}
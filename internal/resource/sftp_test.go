@@ -0,0 +1,158 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// fakeSFTP serves the files below its root to the user "ignition" with the
+// key "secret key" at port 2222 of dropbox.example.com, checking the
+// options ssh is given, and records its arguments.
+const fakeSFTP = `#!/bin/sh
+root=$(dirname "$0")/root
+echo "$@" > "$(dirname "$0")/args"
+key= known= user= port= host=
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-i) key=$2; shift ;;
+	-P) port=$2; shift ;;
+	-o) case "$2" in
+		User=*) user=${2#User=} ;;
+		UserKnownHostsFile=*) known=${2#UserKnownHostsFile=} ;;
+		esac; shift ;;
+	--) host=$2; shift ;;
+	esac
+	shift
+done
+[ "$(cat "$key")" = "secret key" ] && [ "$user" = ignition ] || { echo "Permission denied (publickey)." >&2; exit 255; }
+grep -q "^ignition-sftp ssh-ed25519 " "$known" || { echo "Host key verification failed." >&2; exit 255; }
+[ "$host" = dropbox.example.com ] && [ "$port" = 2222 ] || { echo "Connection refused" >&2; exit 255; }
+read -r cmd remote local
+remote=$(echo "$remote" | tr -d '"') local=$(echo "$local" | tr -d '"')
+[ -f "$root$remote" ] || { echo "File \"$remote\" not found." >&2; exit 1; }
+cp "$root$remote" "$local"
+`
+
+func TestFetchFromSFTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-sftp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "root", "configs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "root", "configs", "node.ign"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "sftp"), []byte(fakeSFTP), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger, sftpCmd: filepath.Join(dir, "sftp")}
+	hosts := []types.SFTPHost{{
+		Host:       "dropbox.example.com:2222",
+		HostKeys:   []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f"},
+		PrivateKey: types.SFTPPrivateKey{Source: "data:,secret%20key"},
+	}}
+	if err := f.UpdateSFTPHosts(hosts); err != nil {
+		t.Fatalf("updating sftp hosts: %v", err)
+	}
+	if want := "data:text/plain;charset=utf-8;base64,c2VjcmV0IGtleQ=="; hosts[0].PrivateKey.Source != want {
+		t.Errorf("bad rewritten key source: want %q, got %q", want, hosts[0].PrivateKey.Source)
+	}
+
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{in: "sftp://ignition@dropbox.example.com:2222/configs/node.ign", out: "{}"},
+		{in: "sftp://ignition@dropbox.example.com:2222/configs/missing.ign", err: ErrNotFound},
+		{in: "sftp://ignition@dropbox.example.com/configs/node.ign", err: ErrSFTPNoKey},
+	}
+	for i, test := range tests {
+		u, err := url.Parse(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.FetchToBuffer(*u, FetchOptions{})
+		if !reflect.DeepEqual(test.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		} else if err == nil && string(got) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, got)
+		}
+	}
+
+	args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"-b -", "StrictHostKeyChecking=yes", "GlobalKnownHostsFile=/dev/null", "BatchMode=yes"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("sftp arguments %q lack %q", args, want)
+		}
+	}
+	if strings.Contains(string(args), "Ciphers=") {
+		t.Errorf("sftp arguments %q restrict the algorithms outside of FIPS mode", args)
+	}
+
+	// in FIPS mode, only approved algorithms are offered
+	fipsPath := filepath.Join(dir, "fips_enabled")
+	if err := ioutil.WriteFile(fipsPath, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("IGNITION_FIPS_ENABLED_PATH", fipsPath)
+	defer os.Unsetenv("IGNITION_FIPS_ENABLED_PATH")
+	u, err := url.Parse(tests[0].in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); err != nil {
+		t.Fatalf("fetching in FIPS mode: %v", err)
+	}
+	args, err = ioutil.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Ciphers=aes256-gcm@openssh.com,", "KexAlgorithms=ecdh-sha2-nistp256,", "MACs=hmac-sha2-256-etm@openssh.com,", "HostKeyAlgorithms=ecdsa-sha2-nistp256,"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("sftp arguments %q lack %q in FIPS mode", args, want)
+		}
+	}
+
+	// the sftp command isn't run when fetches are privilege-separated
+	if err := os.Remove(filepath.Join(dir, "args")); err != nil {
+		t.Fatal(err)
+	}
+	f.helper = &fetchHelper{}
+	if _, err := f.FetchToBuffer(*u, FetchOptions{}); err != ErrSFTPPrivsep {
+		t.Errorf("bad error with privsep: want %v, got %v", ErrSFTPPrivsep, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "args")); !os.IsNotExist(err) {
+		t.Errorf("sftp was run with privsep: %v", err)
+	}
+}
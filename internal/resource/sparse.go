@@ -0,0 +1,123 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// sparseBlockSize is the size of the blocks which are left as holes if
+// they're all zeros. It's the block size of the common filesystems, so
+// the holes line up with the filesystem's blocks.
+const sparseBlockSize = 4096
+
+var ErrSparseNotSequential = errors.New("sparse files must be written sequentially")
+
+// SparseWriter writes to a file, leaving holes in place of the blocks which
+// are all zeros, so that disk images don't take up the space of their
+// unused parts. The file is written from its current offset on; Close
+// gives it its full size, including holes at its end, and leaves the
+// offset at the end like a plain write would. It doesn't close the file.
+type SparseWriter struct {
+	file *os.File
+	// off is the offset of buf in the file
+	off int64
+	buf []byte
+}
+
+// NewSparseWriter returns a SparseWriter writing to file.
+func NewSparseWriter(file *os.File) (*SparseWriter, error) {
+	off, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return &SparseWriter{
+		file: file,
+		off:  off,
+		buf:  make([]byte, 0, sparseBlockSize),
+	}, nil
+}
+
+// Write buffers p up to the next block boundary, so that blocks of zeros
+// are recognized however the data is split up.
+func (s *SparseWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		l := sparseBlockSize - int((s.off+int64(len(s.buf)))%sparseBlockSize)
+		if l > len(p) {
+			l = len(p)
+		}
+		s.buf = append(s.buf, p[:l]...)
+		p = p[l:]
+		n += l
+		if (s.off+int64(len(s.buf)))%sparseBlockSize == 0 {
+			if err := s.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// WriteAt supports downloaders writing through io.WriterAt, as long as
+// they write in order.
+func (s *SparseWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off != s.off+int64(len(s.buf)) {
+		return 0, ErrSparseNotSequential
+	}
+	return s.Write(p)
+}
+
+// flush writes the buffered data unless it's all zeros.
+func (s *SparseWriter) flush() error {
+	if !allZeros(s.buf) {
+		if _, err := s.file.WriteAt(s.buf, s.off); err != nil {
+			return err
+		}
+	}
+	s.off += int64(len(s.buf))
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// Close writes the last partial block and extends the file over the holes
+// at its end.
+func (s *SparseWriter) Close() error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < s.off {
+		if err := s.file.Truncate(s.off); err != nil {
+			return err
+		}
+	}
+	_, err = s.file.Seek(s.off, io.SeekStart)
+	return err
+}
+
+func allZeros(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
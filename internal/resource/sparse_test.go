@@ -0,0 +1,174 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/log"
+)
+
+// sparseImage returns an image of 1 MiB with data in its first and an
+// unaligned middle block, and zeros everywhere else.
+func sparseImage() []byte {
+	image := make([]byte, 1<<20)
+	copy(image, "header")
+	copy(image[300000:], "data")
+	return image
+}
+
+// allocated returns the bytes allocated to the file at path.
+func allocated(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Sys().(*syscall.Stat_t).Blocks * 512
+}
+
+// chunkedReader returns its data in small reads which don't line up with
+// blocks, like a network connection.
+type chunkedReader struct {
+	data []byte
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	chunk := r.data
+	if len(chunk) > 1448 {
+		chunk = chunk[:1448]
+	}
+	n := copy(p, chunk)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestSparseWriter(t *testing.T) {
+	image := sparseImage()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "image", data: image},
+		{name: "trailing partial block", data: append(append([]byte{}, image...), make([]byte, 100)...)},
+		{name: "no zeros", data: bytes.Repeat([]byte("x"), 10000)},
+		{name: "empty"},
+	}
+
+	for _, test := range tests {
+		file, err := ioutil.TempFile("", "ignition-sparse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		w, err := NewSparseWriter(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, &chunkedReader{test.data}); err != nil {
+			t.Fatalf("%s: writing: %v", test.name, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: closing: %v", test.name, err)
+		}
+		if off, _ := file.Seek(0, io.SeekCurrent); off != int64(len(test.data)) {
+			t.Errorf("%s: offset %d, want %d", test.name, off, len(test.data))
+		}
+		got, err := ioutil.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, test.data) {
+			t.Errorf("%s: contents differ", test.name)
+		}
+		if len(test.data) == len(image) && allocated(t, file.Name()) > 4*sparseBlockSize {
+			t.Errorf("%s: %d bytes allocated", test.name, allocated(t, file.Name()))
+		}
+	}
+}
+
+func TestSparseWriterAt(t *testing.T) {
+	file, err := ioutil.TempFile("", "ignition-sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	w, err := NewSparseWriter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteAt(make([]byte, 5000), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteAt([]byte("x"), 6000); err != ErrSparseNotSequential {
+		t.Errorf("out of order write: want %v, got %v", ErrSparseNotSequential, err)
+	}
+	if _, err := w.WriteAt([]byte("x"), 5000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := append(make([]byte, 5000), 'x')
+	if got, _ := ioutil.ReadFile(file.Name()); !bytes.Equal(got, want) {
+		t.Errorf("contents differ")
+	}
+}
+
+func TestFetchSparse(t *testing.T) {
+	image := sparseImage()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(image)
+	}))
+	defer srv.Close()
+
+	file, err := ioutil.TempFile("", "ignition-sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	u, _ := url.Parse(srv.URL + "/disk.img")
+	if err := f.Fetch(*u, file, FetchOptions{Sparse: true}); err != nil {
+		t.Fatalf("fetching: %v", err)
+	}
+	got, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, image) {
+		t.Errorf("contents differ")
+	}
+	if a := allocated(t, file.Name()); a > 4*sparseBlockSize {
+		t.Errorf("%d bytes allocated", a)
+	}
+}
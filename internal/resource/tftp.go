@@ -0,0 +1,120 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+var (
+	ErrTFTPNoBootServer = errors.New("tftp url has no host and the kernel wasn't given a boot server")
+)
+
+// tftpBootServer returns the address of the server the machine was booted
+// from, for tftp urls without a host like tftp:///pxe/config.ign. It's the
+// boot server the kernel's IP autoconfiguration reports in pnpPath or, if
+// the network was configured by the initramfs, the server-ip field of the
+// ip= kernel argument in cmdlinePath.
+func tftpBootServer(pnpPath, cmdlinePath string) (string, error) {
+	pnp, err := ioutil.ReadFile(pnpPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(pnp))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "bootserver" {
+			if ip := net.ParseIP(fields[1]); ip != nil && !ip.IsUnspecified() {
+				return ip.String(), nil
+			}
+		}
+	}
+
+	cmdline, err := ioutil.ReadFile(cmdlinePath)
+	if err != nil {
+		return "", err
+	}
+	for _, arg := range strings.Fields(string(cmdline)) {
+		// ip=<client-ip>:<server-ip>:<gw-ip>:..., with IPv6 addresses
+		// in brackets
+		if !strings.HasPrefix(arg, "ip=") {
+			continue
+		}
+		if server := ipArgServer(strings.TrimPrefix(arg, "ip=")); server != "" {
+			return server, nil
+		}
+	}
+	return "", ErrTFTPNoBootServer
+}
+
+// ipArgServer returns the server-ip field of the value of an ip= kernel
+// argument, or "" if it has none.
+func ipArgServer(value string) string {
+	var fields []string
+	for value != "" {
+		var field string
+		if strings.HasPrefix(value, "[") {
+			end := strings.Index(value, "]")
+			if end < 0 {
+				return ""
+			}
+			field, value = value[1:end], value[end+1:]
+		} else if i := strings.Index(value, ":"); i >= 0 {
+			field, value = value[:i], value[i:]
+		} else {
+			field, value = value, ""
+		}
+		fields = append(fields, field)
+		if len(fields) == 2 {
+			break
+		}
+		if !strings.HasPrefix(value, ":") {
+			return ""
+		}
+		value = value[1:]
+	}
+	if len(fields) < 2 {
+		return ""
+	}
+	if ip := net.ParseIP(fields[1]); ip != nil && !ip.IsUnspecified() {
+		return ip.String()
+	}
+	return ""
+}
+
+// tftpHost returns the address to fetch u from, defaulting to the boot
+// server and the tftp port.
+func tftpHost(u url.URL) (string, error) {
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		var err error
+		if host, err = tftpBootServer(distro.NetPnpPath(), distro.KernelCmdlinePath()); err != nil {
+			return "", err
+		}
+	}
+	if port == "" {
+		port = "69"
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTFTPBootServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-tftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pnpPath := filepath.Join(dir, "pnp")
+	cmdlinePath := filepath.Join(dir, "cmdline")
+
+	type in struct {
+		pnp     string
+		cmdline string
+	}
+	type out struct {
+		server string
+		err    error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{pnp: "#PROTO: DHCP\ndomain example.com\nnameserver 10.0.0.1\nbootserver 10.0.0.2\n", cmdline: "ip=dhcp"},
+			out: out{server: "10.0.0.2"},
+		},
+		{
+			in:  in{pnp: "#PROTO: DHCP\nbootserver 0.0.0.0\n", cmdline: "console=ttyS0 ip=10.0.0.5:10.0.0.3:10.0.0.1:255.255.255.0::eth0:off"},
+			out: out{server: "10.0.0.3"},
+		},
+		{
+			in:  in{cmdline: "ip=[2001:db8::5]:[2001:db8::3]:[2001:db8::1]:64::eth0:none"},
+			out: out{server: "2001:db8::3"},
+		},
+		{
+			in:  in{cmdline: "ip=eth0:dhcp"},
+			out: out{err: ErrTFTPNoBootServer},
+		},
+		{
+			in:  in{cmdline: "ip=10.0.0.5::10.0.0.1:255.255.255.0::eth0:off"},
+			out: out{err: ErrTFTPNoBootServer},
+		},
+		{
+			in:  in{cmdline: "root=/dev/sda1"},
+			out: out{err: ErrTFTPNoBootServer},
+		},
+	}
+
+	for i, test := range tests {
+		os.Remove(pnpPath)
+		if test.in.pnp != "" {
+			if err := ioutil.WriteFile(pnpPath, []byte(test.in.pnp), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(cmdlinePath, []byte(test.in.cmdline), 0644); err != nil {
+			t.Fatal(err)
+		}
+		server, err := tftpBootServer(pnpPath, cmdlinePath)
+		if err != test.out.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		} else if server != test.out.server {
+			t.Errorf("#%d: bad server: want %q, got %q", i, test.out.server, server)
+		}
+	}
+}
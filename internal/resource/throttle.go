@@ -0,0 +1,125 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits the rate at which the connections it throttles
+// receive data, together, so that concurrent fetches share the bandwidth
+// allowed rather than each getting all of it.
+type rateLimiter struct {
+	mu sync.Mutex
+	// rate is in bytes per second, 0 meaning unlimited
+	rate int64
+	// next is when the bytes received so far are paid for
+	next time.Time
+}
+
+func (l *rateLimiter) setRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.next = time.Time{}
+}
+
+// chunk returns how many bytes a connection may read at once, so that it
+// waits often and briefly rather than seldom and long.
+func (l *rateLimiter) chunk() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == 0 {
+		return 0
+	}
+	n := l.rate / 8
+	if n < 512 {
+		n = 512
+	} else if n > 64<<10 {
+		n = 64 << 10
+	}
+	return int(n)
+}
+
+// delay accounts for n bytes received and returns how long to wait until
+// they're within the rate.
+func (l *rateLimiter) delay(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == 0 || n == 0 {
+		return 0
+	}
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	return l.next.Sub(now)
+}
+
+// throttle returns a dial function making connections with dialer whose
+// reads are limited to the rate.
+func (l *rateLimiter) throttle(dialer Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &throttledConn{Conn: conn, limiter: l}, nil
+	}
+}
+
+type throttledConn struct {
+	net.Conn
+	limiter *rateLimiter
+}
+
+func (c *throttledConn) Read(p []byte) (int, error) {
+	if chunk := c.limiter.chunk(); chunk > 0 && len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := c.Conn.Read(p)
+	if d := c.limiter.delay(n); d > 0 {
+		time.Sleep(d)
+	}
+	return n, err
+}
+
+// SetRateLimit limits the rate at which http(s) fetches receive data, all
+// of them together, to bytesPerSec, 0 meaning unlimited.
+func (f *Fetcher) SetRateLimit(bytesPerSec int64) error {
+	if f.helper != nil {
+		req := helperRequest{Op: "rateLimit", RateLimit: bytesPerSec}
+		return f.helper.roundTrip(f.BaseContext(), req, func(r io.Reader) error {
+			_, err := io.Copy(ioutil.Discard, r)
+			return err
+		})
+	}
+	if f.client == nil {
+		if err := f.newHttpClient(); err != nil {
+			return err
+		}
+	}
+	if bytesPerSec > 0 {
+		f.Logger.Info("limiting fetches to %d bytes per second", bytesPerSec)
+	}
+	f.client.limiter.setRate(bytesPerSec)
+	return nil
+}
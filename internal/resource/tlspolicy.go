@@ -0,0 +1,129 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/ocsp"
+)
+
+var (
+	ErrPinMismatch   = errors.New("none of the server's certificates matches a pinned public key")
+	ErrNoOCSPStaple  = errors.New("server didn't staple an OCSP response")
+	ErrNoOCSPIssuer  = errors.New("server's certificate has no issuer to check an OCSP response against")
+	ErrPinHashFormat = errors.New("public key pins must be of the form sha256-<hex digest>")
+)
+
+// verifyConnection returns the checks made on the server's certificates in
+// addition to their verification against the trusted CAs, or nil if the
+// config doesn't ask for any. now returns the time OCSP responses are checked
+// against.
+func verifyConnection(cfg types.TLS, now func() time.Time) (func(tls.ConnectionState) error, error) {
+	requireOCSP := cfg.RequireOCSPStapling != nil && *cfg.RequireOCSPStapling
+	if len(cfg.PublicKeyPins) == 0 && !requireOCSP {
+		return nil, nil
+	}
+
+	pins := make(map[string][][]byte)
+	for _, pin := range cfg.PublicKeyPins {
+		if !strings.HasPrefix(pin.Hash, "sha256-") {
+			return nil, ErrPinHashFormat
+		}
+		sum, err := hex.DecodeString(strings.TrimPrefix(pin.Hash, "sha256-"))
+		if err != nil || len(sum) != sha256.Size {
+			return nil, ErrPinHashFormat
+		}
+		host := strings.ToLower(pin.Host)
+		pins[host] = append(pins[host], sum)
+	}
+
+	return func(cs tls.ConnectionState) error {
+		if err := checkPins(pins, cs); err != nil {
+			return err
+		}
+		if requireOCSP {
+			return checkStapledOCSP(cs, now())
+		}
+		return nil
+	}, nil
+}
+
+// checkPins checks that a certificate of the verified chains has a pinned
+// public key. The pins for the server's host apply if there are any, the
+// pins without a host otherwise.
+func checkPins(pins map[string][][]byte, cs tls.ConnectionState) error {
+	host := strings.ToLower(cs.ServerName)
+	if host == "" && len(cs.PeerCertificates) > 0 {
+		// no SNI is sent for ip addresses; find the host in the
+		// certificate instead
+		for h := range pins {
+			if h != "" && cs.PeerCertificates[0].VerifyHostname(h) == nil {
+				host = h
+				break
+			}
+		}
+	}
+	sums, ok := pins[host]
+	if !ok {
+		sums = pins[""]
+	}
+	if len(sums) == 0 {
+		return nil
+	}
+
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pinned := range sums {
+				if bytes.Equal(sum[:], pinned) {
+					return nil
+				}
+			}
+		}
+	}
+	return ErrPinMismatch
+}
+
+// checkStapledOCSP checks that the server stapled a current OCSP response
+// stating that its certificate is good.
+func checkStapledOCSP(cs tls.ConnectionState, now time.Time) error {
+	if len(cs.OCSPResponse) == 0 {
+		return ErrNoOCSPStaple
+	}
+	var leaf, issuer *x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		if len(chain) > 1 {
+			leaf, issuer = chain[0], chain[1]
+			break
+		}
+	}
+	if issuer == nil {
+		return ErrNoOCSPIssuer
+	}
+	if err := ocsp.Verify(cs.OCSPResponse, leaf, issuer, now); err != nil {
+		return fmt.Errorf("checking stapled OCSP response: %v", err)
+	}
+	return nil
+}
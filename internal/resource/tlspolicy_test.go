@@ -0,0 +1,364 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/ocsp/ocsptest"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func newTestCert(t *testing.T, serial int64, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "ignition test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		parent, parentKey = template, key
+	} else {
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func pinOf(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256-" + hex.EncodeToString(sum[:])
+}
+
+func TestHTTPPinsAndOCSP(t *testing.T) {
+	ca, caKey := newTestCert(t, 1, nil, nil)
+	leaf, leafKey := newTestCert(t, 2, ca, caKey)
+	other, _ := newTestCert(t, 3, nil, nil)
+
+	now := time.Now()
+	good, err := ocsptest.Create(leaf, ca, nil, caKey, ocsptest.Good, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := ocsptest.Create(leaf, ca, nil, caKey, ocsptest.Revoked, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caRef := types.CaReference{Source: dataurl.EncodeBytes(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))}
+	yes := true
+	timeout := 1
+
+	type in struct {
+		staple []byte
+		pins   []types.PublicKeyPin
+		ocsp   *bool
+	}
+	type out struct {
+		fail bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{},
+			out: out{},
+		},
+		{
+			// pinned CA
+			in:  in{pins: []types.PublicKeyPin{{Hash: pinOf(ca)}}},
+			out: out{},
+		},
+		{
+			// pinned server key for the host
+			in:  in{pins: []types.PublicKeyPin{{Host: "127.0.0.1", Hash: pinOf(leaf)}}},
+			out: out{},
+		},
+		{
+			in:  in{pins: []types.PublicKeyPin{{Hash: pinOf(other)}}},
+			out: out{fail: true},
+		},
+		{
+			// the pin is for another host
+			in:  in{pins: []types.PublicKeyPin{{Host: "config.example.com", Hash: pinOf(other)}}},
+			out: out{},
+		},
+		{
+			// the host's pins replace the ones without a host
+			in: in{pins: []types.PublicKeyPin{
+				{Hash: pinOf(ca)},
+				{Host: "127.0.0.1", Hash: pinOf(other)},
+			}},
+			out: out{fail: true},
+		},
+		{
+			in:  in{staple: good, ocsp: &yes},
+			out: out{},
+		},
+		{
+			in:  in{ocsp: &yes},
+			out: out{fail: true},
+		},
+		{
+			in:  in{staple: revoked, ocsp: &yes},
+			out: out{fail: true},
+		},
+		{
+			// revocation is only checked when required
+			in:  in{staple: revoked},
+			out: out{},
+		},
+	}
+
+	for i, test := range tests {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("contents"))
+		}))
+		srv.TLS = &tls.Config{
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{leaf.Raw, ca.Raw},
+				PrivateKey:  leafKey,
+				OCSPStaple:  test.in.staple,
+			}},
+		}
+		srv.StartTLS()
+
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		tlsCfg := types.TLS{
+			CertificateAuthorities: []types.CaReference{caRef},
+			PublicKeyPins:          test.in.pins,
+			RequireOCSPStapling:    test.in.ocsp,
+		}
+		if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, tlsCfg, types.Redirects{}, types.Proxy{}); err != nil {
+			t.Fatalf("#%d: configuring TLS: %v", i, err)
+		}
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		if fail := err != nil; fail != test.out.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.out.fail, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestCABundles(t *testing.T) {
+	other, _ := newTestCert(t, 1, nil, nil)
+	ca, caKey := newTestCert(t, 2, nil, nil)
+	leaf, leafKey := newTestCert(t, 3, ca, caKey)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents"))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{leaf.Raw, ca.Raw},
+			PrivateKey:  leafKey,
+		}},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(blocks ...*pem.Block) types.CaReference {
+		var blob []byte
+		for _, block := range blocks {
+			blob = append(blob, pem.EncodeToMemory(block)...)
+		}
+		return types.CaReference{Source: dataurl.EncodeBytes(blob)}
+	}
+	otherBlock := &pem.Block{Type: "CERTIFICATE", Bytes: other.Raw}
+	caBlock := &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}
+	// blocks of other types in the bundle are skipped
+	paramsBlock := &pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x08}}
+
+	tests := []struct {
+		ca        types.CaReference
+		configErr error
+		fail      bool
+	}{
+		{ca: encode(otherBlock, paramsBlock, caBlock)},
+		{ca: encode(otherBlock), fail: true},
+		{ca: encode(paramsBlock), configErr: ErrPEMDecodeFailed},
+	}
+
+	timeout := 1
+	for i, test := range tests {
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		tlsCfg := types.TLS{CertificateAuthorities: []types.CaReference{test.ca}}
+		if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, tlsCfg, types.Redirects{}, types.Proxy{}); err != test.configErr {
+			t.Errorf("#%d: bad error configuring TLS: want %v, got %v", i, test.configErr, err)
+			continue
+		} else if err != nil {
+			continue
+		}
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+	}
+}
+
+func TestClientCertificates(t *testing.T) {
+	ca, caKey := newTestCert(t, 1, nil, nil)
+	leaf, leafKey := newTestCert(t, 2, ca, caKey)
+
+	// the CA of the client certificates, which newTestCert would restrict
+	// to server certificates
+	clientCAKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCATemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "ignition client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, clientCATemplate, clientCATemplate, clientCAKey.Public(), clientCAKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCA, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newClientCert := func(serial int64) types.ClientCertificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "ignition client"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, clientCA, key.Public(), clientCAKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.ClientCertificate{
+			Certificate: dataurl.EncodeBytes(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+			PrivateKey:  dataurl.EncodeBytes(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+		}
+	}
+	client := newClientCert(4)
+	other := newClientCert(5)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents"))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{leaf.Raw, ca.Raw},
+			PrivateKey:  leafKey,
+		}},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caRef := types.CaReference{Source: dataurl.EncodeBytes(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))}
+	tests := []struct {
+		certs     []types.ClientCertificate
+		configure bool
+		fail      bool
+	}{
+		{configure: true, fail: true},
+		{certs: []types.ClientCertificate{client}, configure: true},
+		// the certificate and key don't match
+		{certs: []types.ClientCertificate{{Certificate: client.Certificate, PrivateKey: other.PrivateKey}}},
+	}
+
+	timeout := 1
+	for i, test := range tests {
+		logger := log.New(true)
+		f := Fetcher{Logger: &logger}
+		tlsCfg := types.TLS{CertificateAuthorities: []types.CaReference{caRef}, ClientCertificates: test.certs}
+		err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, tlsCfg, types.Redirects{}, types.Proxy{})
+		if configured := err == nil; configured != test.configure {
+			t.Errorf("#%d: bad result configuring TLS: want success %v, got error %v", i, test.configure, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		_, err = f.FetchToBuffer(*u, FetchOptions{})
+		if fail := err != nil; fail != test.fail {
+			t.Errorf("#%d: bad result: want failure %v, got error %v", i, test.fail, err)
+		}
+	}
+}
@@ -24,13 +24,15 @@ import (
 	"hash"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"syscall"
+	"time"
 
+	"github.com/flatcar/ignition/config/shared/dataurl"
 	configErrors "github.com/flatcar/ignition/config/shared/errors"
 	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/log"
@@ -44,16 +46,24 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/pin/tftp"
-	"github.com/vincent-petithory/dataurl"
 )
 
 var (
 	ErrSchemeUnsupported      = errors.New("unsupported source scheme")
 	ErrPathNotAbsolute        = errors.New("path is not absolute")
 	ErrNotFound               = errors.New("resource not found")
+	ErrNotModified            = errors.New("resource not modified")
+	ErrTooLarge               = errors.New("resource exceeds the maximum size")
 	ErrFailed                 = errors.New("failed to fetch resource")
 	ErrCompressionUnsupported = errors.New("compression is not supported with that scheme")
 
+	// Schemes are the URL schemes resources can be fetched from
+	Schemes = []string{"http", "https", "tftp", "s3", "gs", "azblob", "data", "oem", "docker", "oci", "git+https", "sftp"}
+
+	// Compressions are the compressions of resources which can be
+	// decompressed as they're fetched
+	Compressions = []string{"gzip", "xz", "zstd"}
+
 	// ConfigHeaders are the HTTP headers that should be used when the Ignition
 	// config is being fetched
 	ConfigHeaders = http.Header{
@@ -77,9 +87,68 @@ type Fetcher struct {
 	// used to set credentials.
 	AWSSession *session.Session
 
+	// GCSCredentials authenticate gs fetches. If left nil, objects are
+	// fetched anonymously.
+	GCSCredentials *GCSCredentials
+
+	// gcsEndpoint replaces the endpoint of Cloud Storage in tests.
+	gcsEndpoint string
+
+	// AzureCredentials authenticate azblob fetches. If left nil, blobs are
+	// fetched anonymously.
+	AzureCredentials *AzureCredentials
+
+	// azblobEndpoint replaces the blob endpoints of storage accounts in
+	// tests, serving the blobs at /account/container/blob.
+	azblobEndpoint string
+
+	// gitPlainHTTP talks to git+https repositories over http in tests.
+	gitPlainHTTP bool
+
+	// sftpHosts are the keys of the hosts sftp resources are fetched
+	// from, by host and port, see UpdateSFTPHosts.
+	sftpHosts map[string]sftpHost
+
+	// sftpCmd replaces the sftp command in tests.
+	sftpCmd string
+
+	// zstdCmd and xzCmd replace the zstd and xz commands in tests.
+	zstdCmd string
+	xzCmd   string
+
 	// The region where the EC2 machine trying to fetch is.
 	// This is used as a hint to fetch the S3 bucket from the right partition and region.
 	S3RegionHint string
+
+	// OEMMountPath is where the OEM partition is already mounted, if it
+	// is. Otherwise it is mounted for each resource fetched from it.
+	OEMMountPath string
+
+	// Context cancels the fetches in flight when it's done, e.g. when
+	// Ignition is stopped. Fetches aren't cancelled if it's nil.
+	Context context.Context
+
+	// Dialer makes the connections of http(s) and s3 fetches. If left
+	// nil, it's set to one connecting through the network stack and
+	// interface configured for the distro.
+	Dialer Dialer
+
+	// helper is the unprivileged process doing the http(s) and tftp
+	// fetches, if one was started.
+	helper *fetchHelper
+
+	// wpadProxy is the proxy found through WPAD, if wpadDone.
+	wpadProxy string
+	wpadDone  bool
+}
+
+// BaseContext returns the context fetches are done in, which the users of
+// the fetcher also stop their other work on.
+func (f *Fetcher) BaseContext() context.Context {
+	if f.Context == nil {
+		return context.Background()
+	}
+	return f.Context
 }
 
 type FetchOptions struct {
@@ -104,12 +173,54 @@ type FetchOptions struct {
 	// Compression specifies the type of compression to use when decompressing
 	// the fetched object. If left empty, no decompression will be used.
 	Compression string
+
+	// Progress, if set, is written a copy of the decompressed data as it is
+	// fetched, e.g. to report the progress of large fetches. It has no effect
+	// on S3 fetches.
+	Progress io.Writer
+
+	// Timeout, if set, limits the time spent fetching http(s) resources,
+	// including retries, in place of the configured total timeout.
+	Timeout time.Duration
+
+	// MaxSize, if set, fails fetches of resources which are larger than
+	// MaxSize bytes once decompressed with ErrTooLarge. It has no effect on
+	// S3 fetches.
+	MaxSize int64
+
+	// RejectHTML fails http(s) fetches answered with an HTML page, like the
+	// login pages of captive portals, with configErrors.ErrHTML.
+	RejectHTML bool
+
+	// Sparse leaves holes in the destination file in place of the blocks
+	// of zeros, see SparseWriter. S3 objects are then downloaded one part
+	// at a time.
+	Sparse bool
+
+	// S3Credentials, if set, are used for S3 fetches in place of the
+	// credentials of the fetcher's AWSSession, e.g. those of the instance
+	// role from NewIMDSCredentials.
+	S3Credentials *credentials.Credentials
 }
 
-// FetchToBuffer will fetch the given url into a temporrary file, and then read
-// in the contents of the file and delete it. It will return the downloaded
-// contents, or an error if one was encountered.
+// FetchToBuffer will fetch the given url and return the downloaded contents,
+// or an error if one was encountered. The contents are streamed straight into
+// the returned buffer; only schemes using chunked downloads (s3) are spooled to
+// a temporary file first.
 func (f *Fetcher) FetchToBuffer(u url.URL, opts FetchOptions) ([]byte, error) {
+	if u.Scheme == "s3" {
+		return f.fetchToBufferViaFile(u, opts)
+	}
+	var buf bytes.Buffer
+	if err := f.fetch(u, &buf, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fetchToBufferViaFile will fetch the given url into a temporary file, and then
+// read in the contents of the file and delete it.
+func (f *Fetcher) fetchToBufferViaFile(u url.URL, opts FetchOptions) ([]byte, error) {
 	file, err := ioutil.TempFile("", "ignition")
 	if err != nil {
 		return nil, err
@@ -142,17 +253,52 @@ func (f *Fetcher) FetchToBuffer(u url.URL, opts FetchOptions) ([]byte, error) {
 // fetch chunks out of order, Fetch's behavior when dest is not an empty file is
 // undefined.
 func (f *Fetcher) Fetch(u url.URL, dest *os.File, opts FetchOptions) error {
+	if u.Scheme == "s3" {
+		return f.FetchFromS3(u, dest, opts)
+	}
+	if opts.Sparse {
+		w, err := NewSparseWriter(dest)
+		if err != nil {
+			return err
+		}
+		if err := f.fetch(u, w, opts); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	return f.fetch(u, dest, opts)
+}
+
+// fetch calls the appropriate FetchFrom* function for the schemes which stream
+// their data in order, and can therefore write into any io.Writer.
+func (f *Fetcher) fetch(u url.URL, dest io.Writer, opts FetchOptions) error {
 	switch u.Scheme {
 	case "http", "https":
+		if f.helper != nil {
+			return f.fetchViaHelper(u, dest, opts)
+		}
 		return f.FetchFromHTTP(u, dest, opts)
 	case "tftp":
+		if f.helper != nil {
+			return f.fetchViaHelper(u, dest, opts)
+		}
 		return f.FetchFromTFTP(u, dest, opts)
 	case "data":
 		return f.FetchFromDataURL(u, dest, opts)
+	case "gs":
+		return f.FetchFromGS(u, dest, opts)
+	case "azblob":
+		return f.FetchFromAzblob(u, dest, opts)
 	case "oem":
 		return f.FetchFromOEM(u, dest, opts)
-	case "s3":
-		return f.FetchFromS3(u, dest, opts)
+	case "docker":
+		return f.FetchFromImage(u, dest, opts)
+	case "oci":
+		return f.FetchFromOCI(u, dest, opts)
+	case "git+https":
+		return f.FetchFromGit(u, dest, opts)
+	case "sftp":
+		return f.FetchFromSFTP(u, dest, opts)
 	case "":
 		return nil
 	default:
@@ -162,11 +308,19 @@ func (f *Fetcher) Fetch(u url.URL, dest *os.File, opts FetchOptions) error {
 
 // FetchFromTFTP fetches a resource from u via TFTP into dest, returning an
 // error if one is encountered.
-func (f *Fetcher) FetchFromTFTP(u url.URL, dest *os.File, opts FetchOptions) error {
-	if !strings.ContainsRune(u.Host, ':') {
-		u.Host = u.Host + ":69"
+func (f *Fetcher) FetchFromTFTP(u url.URL, dest io.Writer, opts FetchOptions) error {
+	dialer, err := f.dialer()
+	if err != nil {
+		return err
 	}
-	c, err := tftp.NewClient(u.Host)
+	if _, ok := dialer.(*kernelDialer); !ok {
+		return ErrTFTPNetworkStack
+	}
+	host, err := tftpHost(u)
+	if err != nil {
+		return err
+	}
+	c, err := tftp.NewClient(host)
 	if err != nil {
 		return err
 	}
@@ -205,6 +359,16 @@ func (f *Fetcher) FetchFromTFTP(u url.URL, dest *os.File, opts FetchOptions) err
 		err = pWriter.Close()
 		doneChan <- err
 	}()
+	// the transfer can't be cancelled, so stop reading it instead
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-f.BaseContext().Done():
+			pReader.CloseWithError(f.BaseContext().Err())
+		case <-stop:
+		}
+	}()
 	err = f.decompressCopyHashAndVerify(dest, pReader, opts)
 	if err != nil {
 		return checkForDoneChanErr(err)
@@ -224,7 +388,7 @@ func (f *Fetcher) FetchFromTFTP(u url.URL, dest *os.File, opts FetchOptions) err
 
 // FetchFromHTTP fetches a resource from u via HTTP(S) into dest, returning an
 // error if one is encountered.
-func (f *Fetcher) FetchFromHTTP(u url.URL, dest *os.File, opts FetchOptions) error {
+func (f *Fetcher) FetchFromHTTP(u url.URL, dest io.Writer, opts FetchOptions) error {
 	// for the case when "config is not valid"
 	// this if necessary if not spawned through kola (e.g. Packet Dashboard)
 	if f.client == nil {
@@ -235,52 +399,147 @@ func (f *Fetcher) FetchFromHTTP(u url.URL, dest *os.File, opts FetchOptions) err
 		}
 	}
 
-	// Set headers that we want to use in case of HTTP redirection
-	f.client.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+	// Set headers that we want to use in case of HTTP redirection, on a
+	// copy of the client as files may be fetched concurrently
+	client := *f.client
+	httpClient := *client.client
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := checkRedirect(client.redirects, req, via); err != nil {
+			return err
+		}
 		req.Header = opts.HeadersRedirect
 		return nil
 	}
+	client.client = &httpClient
+	if opts.Timeout != 0 {
+		client.timeout = opts.Timeout
+	}
 
-	dataReader, status, ctxCancel, err := f.client.getReaderWithHeader(u.String(), opts.Headers)
+	resp, ctxCancel, err := client.getWithHeader(f.BaseContext(), u.String(), opts.Headers)
 	if ctxCancel != nil {
-		// whatever context getReaderWithHeader created for the request should
+		// whatever context getWithHeader created for the request should
 		// be cancelled once we're done reading the response
 		defer ctxCancel()
 	}
 	if err != nil {
 		return err
 	}
-	defer dataReader.Close()
+	// interrupted downloads are resumed where they broke off
+	body := resumable(client, f.BaseContext(), u.String(), opts.Headers, resp)
+	// responses which aren't read in full are drained so the connection
+	// can be reused
+	defer discardBody(body)
 
-	switch status {
+	switch resp.StatusCode {
 	case http.StatusOK, http.StatusNoContent:
 		break
 	case http.StatusNotFound:
 		return ErrNotFound
+	case http.StatusNotModified:
+		return ErrNotModified
+	case http.StatusUnauthorized:
+		if challenge := resp.Header.Get("WWW-Authenticate"); challenge != "" {
+			return AuthChallengeError{Challenge: challenge}
+		}
+		return ErrFailed
 	default:
 		return ErrFailed
 	}
 
-	return f.decompressCopyHashAndVerify(dest, dataReader, opts)
+	if opts.RejectHTML {
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+			return configErrors.ErrHTML
+		}
+	}
+	if opts.MaxSize > 0 && resp.ContentLength > opts.MaxSize && opts.Compression == "" {
+		return ErrTooLarge
+	}
+
+	return f.decompressCopyHashAndVerify(dest, body, opts)
+}
+
+// PostToBuffer performs an HTTP(S) POST of body to u and returns the response
+// body, or an error if the server did not respond with 200 OK.
+func (f *Fetcher) PostToBuffer(u url.URL, body []byte, opts FetchOptions) ([]byte, error) {
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return nil, ErrSchemeUnsupported
+	}
+	if f.helper != nil {
+		return f.postViaHelper(u, body, opts)
+	}
+
+	if f.client == nil {
+		if err := f.newHttpClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	dataReader, status, ctxCancel, err := f.client.postReaderWithHeader(f.BaseContext(), u.String(), body, opts.Headers)
+	if ctxCancel != nil {
+		defer ctxCancel()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer discardBody(dataReader)
+
+	switch status {
+	case http.StatusOK:
+		break
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, ErrFailed
+	}
+
+	return ioutil.ReadAll(dataReader)
 }
 
 // FetchFromDataURL writes the data stored in the dataurl u into dest, returning
 // an error if one is encountered.
-func (f *Fetcher) FetchFromDataURL(u url.URL, dest *os.File, opts FetchOptions) error {
+func (f *Fetcher) FetchFromDataURL(u url.URL, dest io.Writer, opts FetchOptions) error {
 	if opts.Compression != "" {
 		return ErrCompressionUnsupported
 	}
-	url, err := dataurl.DecodeString(u.String())
+	r, err := dataurl.NewReader(u.String())
 	if err != nil {
 		return err
 	}
+	if max := distro.MaxDataURLSize(); max > 0 {
+		r = &limitedReader{r: r, n: max, err: fmt.Errorf("data url exceeds the maximum size of %d bytes", max)}
+	}
 
-	return f.decompressCopyHashAndVerify(dest, bytes.NewBuffer(url.Data), opts)
+	return f.decompressCopyHashAndVerify(dest, r, opts)
+}
+
+// limitedReader reads from r, returning err once more than n bytes have been
+// read rather than silently truncating like io.LimitReader.
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, l.err
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, l.err
+	}
+	return n, err
 }
 
 // FetchFromOEM gets data off the oem partition as described by u and writes it
 // into dest, returning an error if one is encountered.
-func (f *Fetcher) FetchFromOEM(u url.URL, dest *os.File, opts FetchOptions) error {
+func (f *Fetcher) FetchFromOEM(u url.URL, dest io.Writer, opts FetchOptions) error {
 	path := filepath.Clean(u.Path)
 	if !filepath.IsAbs(path) {
 		f.Logger.Err("oem path is not absolute: %q", u.Path)
@@ -301,18 +560,22 @@ func (f *Fetcher) FetchFromOEM(u url.URL, dest *os.File, opts FetchOptions) erro
 	f.Logger.Info("oem config not found in %q, looking on oem partition",
 		distro.OEMLookasideDir())
 
-	oemMountPath, err := ioutil.TempDir("/mnt", "oem")
-	if err != nil {
-		f.Logger.Err("failed to create mount path for oem partition: %v", err)
-		return ErrFailed
-	}
-	// try oemMountPath, requires mounting it.
-	if err := f.mountOEM(oemMountPath); err != nil {
-		f.Logger.Err("failed to mount oem partition: %v", err)
-		return ErrFailed
+	oemMountPath := f.OEMMountPath
+	if oemMountPath == "" {
+		var err error
+		oemMountPath, err = ioutil.TempDir("/mnt", "oem")
+		if err != nil {
+			f.Logger.Err("failed to create mount path for oem partition: %v", err)
+			return ErrFailed
+		}
+		// try oemMountPath, requires mounting it.
+		if err := f.MountOEM(oemMountPath); err != nil {
+			f.Logger.Err("failed to mount oem partition: %v", err)
+			return ErrFailed
+		}
+		defer os.Remove(oemMountPath)
+		defer f.UmountOEM(oemMountPath)
 	}
-	defer os.Remove(oemMountPath)
-	defer f.umountOEM(oemMountPath)
 
 	absPath = filepath.Join(oemMountPath, path)
 	fi, err := os.Open(absPath)
@@ -333,7 +596,7 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 	if opts.Compression != "" {
 		return ErrCompressionUnsupported
 	}
-	ctx := context.Background()
+	ctx := f.BaseContext()
 	if f.client != nil && f.client.timeout != 0 {
 		var cancelFn context.CancelFunc
 		ctx, cancelFn = context.WithTimeout(ctx, f.client.timeout)
@@ -350,6 +613,9 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 		}
 	}
 	sess := f.AWSSession.Copy()
+	if opts.S3Credentials != nil {
+		sess.Config.Credentials = opts.S3Credentials
+	}
 
 	// Determine the partition and region this bucket is in
 	regionHint := "us-east-1"
@@ -376,8 +642,18 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 		Key:       &u.Path,
 		VersionId: versionId,
 	}
-	err = f.fetchFromS3WithCreds(ctx, dest, input, sess)
-	if err != nil {
+	if opts.Sparse {
+		w, err := NewSparseWriter(dest)
+		if err != nil {
+			return err
+		}
+		if err := f.fetchFromS3WithCreds(ctx, w, 1, input, sess); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	} else if err := f.fetchFromS3WithCreds(ctx, dest, s3manager.DefaultDownloadConcurrency, input, sess); err != nil {
 		return err
 	}
 	if opts.Hash != nil {
@@ -402,21 +678,27 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 	return nil
 }
 
-func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest *os.File, input *s3.GetObjectInput, sess *session.Session) error {
-	httpClient, err := defaultHTTPClient()
+func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest io.WriterAt, concurrency int, input *s3.GetObjectInput, sess *session.Session) error {
+	dialer, err := f.dialer()
+	if err != nil {
+		return err
+	}
+	httpClient, err := defaultHTTPClient(f.Logger, dialer)
 	if err != nil {
 		return err
 	}
 
 	awsConfig := aws.NewConfig().WithHTTPClient(httpClient)
 	s3Client := s3.New(sess, awsConfig)
-	downloader := s3manager.NewDownloaderWithClient(s3Client)
+	downloader := s3manager.NewDownloaderWithClient(s3Client, func(d *s3manager.Downloader) {
+		d.Concurrency = concurrency
+	})
 	if _, err := downloader.DownloadWithContext(ctx, dest, input); err != nil {
 		if awserrval, ok := err.(awserr.Error); ok && awserrval.Code() == "EC2RoleRequestError" {
 			// If this error was due to an EC2 role request error, try again
 			// with the anonymous credentials.
 			sess.Config.Credentials = credentials.AnonymousCredentials
-			return f.fetchFromS3WithCreds(ctx, dest, input, sess)
+			return f.fetchFromS3WithCreds(ctx, dest, concurrency, input, sess)
 		}
 		return err
 	}
@@ -424,16 +706,28 @@ func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest *os.File, input
 }
 
 // uncompress will wrap the given io.Reader in a decompresser specified in the
-// FetchOptions, and return an io.ReadCloser with the decompressed data stream.
+// FetchOptions, and return an io.ReadCloser with the decompressed data stream,
+// guarded against decompression bombs.
 func (f *Fetcher) uncompress(r io.Reader, opts FetchOptions) (io.ReadCloser, error) {
+	compressed := &countingReader{r: r}
+	var decompressed io.ReadCloser
+	var err error
 	switch opts.Compression {
 	case "":
 		return ioutil.NopCloser(r), nil
 	case "gzip":
-		return gzip.NewReader(r)
+		decompressed, err = gzip.NewReader(compressed)
+	case "xz":
+		decompressed, err = f.newXzReader(compressed)
+	case "zstd":
+		decompressed, err = f.newZstdReader(compressed)
 	default:
 		return nil, configErrors.ErrCompressionInvalid
 	}
+	if err != nil {
+		return nil, err
+	}
+	return guardDecompression(decompressed, compressed), nil
 }
 
 // decompressCopyHashAndVerify will decompress src if necessary, copy src into
@@ -450,6 +744,12 @@ func (f *Fetcher) decompressCopyHashAndVerify(dest io.Writer, src io.Reader, opt
 		opts.Hash.Reset()
 		dest = io.MultiWriter(dest, opts.Hash)
 	}
+	if opts.Progress != nil {
+		dest = io.MultiWriter(dest, opts.Progress)
+	}
+	if opts.MaxSize > 0 {
+		dest = &limitedWriter{w: dest, n: opts.MaxSize}
+	}
 	_, err = io.Copy(dest, decompressor)
 	if err != nil {
 		return err
@@ -467,9 +767,28 @@ func (f *Fetcher) decompressCopyHashAndVerify(dest io.Writer, src io.Reader, opt
 	return nil
 }
 
-// mountOEM waits for the presence of and mounts the oem partition at
+// limitedWriter writes to w until more than n bytes are written, failing
+// with ErrTooLarge then.
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, ErrTooLarge
+	}
+	l.n -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// oemMountFlags are the flags the oem partition is mounted with. Files are
+// only read from and written to it, never run.
+const oemMountFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC
+
+// MountOEM waits for the presence of and mounts the oem partition at
 // oemMountPath. oemMountPath will be created if it does not exist.
-func (f *Fetcher) mountOEM(oemMountPath string) error {
+func (f *Fetcher) MountOEM(oemMountPath string) error {
 	dev := []string{distro.OEMDevicePath()}
 	if err := systemd.WaitOnDevices(dev, "oem-cmdline"); err != nil {
 		f.Logger.Err("failed to wait for oem device: %v", err)
@@ -483,7 +802,7 @@ func (f *Fetcher) mountOEM(oemMountPath string) error {
 
 	if err := f.Logger.LogOp(
 		func() error {
-			return syscall.Mount(dev[0], oemMountPath, "ext4", 0, "")
+			return syscall.Mount(dev[0], oemMountPath, "ext4", oemMountFlags, "")
 		},
 		"mounting %q at %q", distro.OEMDevicePath(), oemMountPath,
 	); err != nil {
@@ -491,7 +810,7 @@ func (f *Fetcher) mountOEM(oemMountPath string) error {
 			distro.OEMDevicePath(), oemMountPath, err)
 		if err := f.Logger.LogOp(
 			func() error {
-				return syscall.Mount(dev[0], oemMountPath, "btrfs", 0, "")
+				return syscall.Mount(dev[0], oemMountPath, "btrfs", oemMountFlags, "")
 			},
 			"mounting %q at %q", distro.OEMDevicePath(), oemMountPath,
 		); err != nil {
@@ -503,8 +822,8 @@ func (f *Fetcher) mountOEM(oemMountPath string) error {
 	return nil
 }
 
-// umountOEM unmounts the oem partition at oemMountPath.
-func (f *Fetcher) umountOEM(oemMountPath string) {
+// UmountOEM unmounts the oem partition at oemMountPath.
+func (f *Fetcher) UmountOEM(oemMountPath string) {
 	f.Logger.LogOp(
 		func() error { return syscall.Unmount(oemMountPath, 0) },
 		"unmounting %q", oemMountPath,
@@ -0,0 +1,97 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	configErrors "github.com/flatcar/ignition/config/shared/errors"
+	"github.com/flatcar/ignition/internal/log"
+)
+
+func TestFetchFromHTTPLimits(t *testing.T) {
+	config := []byte(`{"ignition":{"version":"2.3.0"}}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.ign":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(config)
+		case "/portal":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body>Please accept the terms of use</body></html>"))
+		case "/chunked":
+			// no Content-Length, so the size is only known while reading
+			w.Write(bytes.Repeat([]byte(" "), 1024))
+			w.(http.Flusher).Flush()
+			w.Write(config)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+
+	type in struct {
+		path string
+		opts FetchOptions
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{path: "/config.ign", opts: FetchOptions{MaxSize: int64(len(config)), RejectHTML: true}},
+			out: out{},
+		},
+		{
+			in:  in{path: "/config.ign", opts: FetchOptions{MaxSize: int64(len(config)) - 1}},
+			out: out{err: ErrTooLarge},
+		},
+		{
+			in:  in{path: "/chunked", opts: FetchOptions{MaxSize: 1024}},
+			out: out{err: ErrTooLarge},
+		},
+		{
+			in:  in{path: "/portal", opts: FetchOptions{RejectHTML: true}},
+			out: out{err: configErrors.ErrHTML},
+		},
+		{
+			in:  in{path: "/portal"},
+			out: out{},
+		},
+	}
+
+	for i, test := range tests {
+		u, err := url.Parse(srv.URL + test.in.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.FetchToBuffer(*u, test.in.opts)
+		if !reflect.DeepEqual(test.out.err, err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+	}
+}
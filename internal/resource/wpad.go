@@ -0,0 +1,305 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/distro"
+)
+
+// If enabled for the distro, fetches go through the proxy of the WPAD url
+// (web proxy auto-discovery) the DHCP server handed out as option 252,
+// unless the config sets a proxy. Ignition can't evaluate the rules of the
+// PAC file the url points to, so the file's first PROXY directive is used
+// for every fetch not excluded by the config's noProxy, except for those of
+// link-local and metadata addresses, which no proxy can reach on behalf of
+// the instance.
+
+const (
+	// the key systemd-networkd records DHCP option 252 under in its leases
+	wpadLeaseKey = "OPTION_252"
+
+	wpadTimeout = 10 * time.Second
+	// PAC files are small scripts, anything larger is refused
+	maxPACSize = 1 << 20
+)
+
+var (
+	ErrWPADNoProxy = errors.New("the PAC file has no PROXY directive")
+
+	errNoWPADURL = errors.New("no WPAD url in the DHCP leases")
+
+	pacProxyRegexp = regexp.MustCompile(`\bPROXY\s+([^\s;"']+)`)
+)
+
+// wpadDirect are the destinations fetched directly regardless of the PAC
+// file: the link-local ranges, which the metadata services of most
+// platforms and IMDS are in, and the metadata services elsewhere.
+var wpadDirect = []types.NoProxyItem{
+	"169.254.0.0/16",
+	"fe80::/10",
+	"fd00:ec2::254",
+	"100.100.100.200",
+	"metadata.google.internal",
+}
+
+// autoDetectProxy returns proxy, or if it sets no proxy and proxy
+// auto-detection is enabled, the proxy of the WPAD url found in the DHCP
+// leases, keeping proxy's noProxy. Discovery failures leave fetches going
+// direct. Once a WPAD url was found, its outcome is kept for the following
+// calls.
+func (f *Fetcher) autoDetectProxy(proxy types.Proxy) types.Proxy {
+	if !distro.ProxyAutoDetect() || proxy.HTTPProxy != "" || proxy.HTTPSProxy != "" {
+		return proxy
+	}
+	if !f.wpadDone {
+		p, err := f.discoverProxy()
+		switch {
+		case err == errNoWPADURL:
+			f.Logger.Debug("no WPAD url in the DHCP leases, fetching directly")
+			return proxy
+		case err != nil:
+			f.Logger.Warning("proxy auto-detection failed, fetching directly: %v", err)
+		}
+		f.wpadProxy, f.wpadDone = p, true
+	}
+	if f.wpadProxy != "" {
+		proxy.HTTPProxy = f.wpadProxy
+		proxy.HTTPSProxy = f.wpadProxy
+		proxy.NoProxy = append(append([]types.NoProxyItem{}, proxy.NoProxy...), wpadDirect...)
+	}
+	return proxy
+}
+
+// discoverProxy fetches the PAC file of the WPAD url in the DHCP leases and
+// returns the url of its proxy, or "" if the file only has DIRECT rules.
+func (f *Fetcher) discoverProxy() (string, error) {
+	wpad, err := wpadURLFromLeases(distro.DHCPLeasesDir(), distro.NetworkInterface())
+	if err != nil {
+		return "", err
+	}
+	if wpad == "" {
+		return "", errNoWPADURL
+	}
+	u, err := url.Parse(wpad)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid WPAD url %q", wpad)
+	}
+
+	pac, err := f.fetchPAC(*u)
+	if err != nil {
+		return "", fmt.Errorf("fetching the PAC file %q: %v", wpad, err)
+	}
+	proxies := pacProxies(pac)
+	if len(proxies) == 0 {
+		if strings.Contains(pac, "DIRECT") {
+			f.Logger.Info("the PAC file %q only has DIRECT rules, fetching directly", wpad)
+			return "", nil
+		}
+		return "", fmt.Errorf("%q: %v", wpad, ErrWPADNoProxy)
+	}
+	if _, _, err := net.SplitHostPort(proxies[0]); err != nil {
+		return "", fmt.Errorf("%q: invalid proxy %q: %v", wpad, proxies[0], err)
+	}
+	if len(proxies) > 1 {
+		f.Logger.Warning("the PAC file %q names %d proxies, only %q is used", wpad, len(proxies), proxies[0])
+	}
+	f.Logger.Info("fetching through proxy %q, found in the PAC file %q", proxies[0], wpad)
+	return "http://" + proxies[0], nil
+}
+
+// fetchPAC fetches the PAC file at u directly, without a proxy, but
+// otherwise like any other fetch: with the configured CAs, TLS settings and
+// redirect policy.
+func (f *Fetcher) fetchPAC(u url.URL) (string, error) {
+	if f.client == nil {
+		if err := f.newHttpClient(); err != nil {
+			return "", err
+		}
+	}
+	transport := f.client.transport.Clone()
+	transport.Proxy = nil
+	defer transport.CloseIdleConnections()
+	client := http.Client{
+		Transport:     transport,
+		CheckRedirect: f.client.client.CheckRedirect,
+		Timeout:       wpadTimeout,
+	}
+	ctx, cancel := context.WithTimeout(f.BaseContext(), wpadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	pac, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPACSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(pac) > maxPACSize {
+		return "", fmt.Errorf("larger than %d bytes", maxPACSize)
+	}
+	return string(pac), nil
+}
+
+// pacProxies returns the distinct proxies of the PAC file's PROXY
+// directives, in the order they appear, ignoring those in comments.
+func pacProxies(pac string) []string {
+	var res []string
+	seen := map[string]struct{}{}
+	for _, m := range pacProxyRegexp.FindAllStringSubmatch(stripPACComments(pac), -1) {
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		res = append(res, m[1])
+	}
+	return res
+}
+
+// stripPACComments returns the PAC file without its // and /* */ comments.
+// Strings are kept as they are, so that urls in them aren't taken for
+// comments.
+func stripPACComments(pac string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(pac); i++ {
+		c := pac[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(pac) {
+				i++
+				b.WriteByte(pac[i])
+			} else if c == quote || c == '\n' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			b.WriteByte(c)
+		case strings.HasPrefix(pac[i:], "//"):
+			end := strings.IndexByte(pac[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end - 1
+		case strings.HasPrefix(pac[i:], "/*"):
+			end := strings.Index(pac[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			// keep the tokens around the comment apart
+			b.WriteByte(' ')
+			i += end + 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// wpadURLFromLeases returns the WPAD url of the first lease in dir having
+// one, only looking at the lease of the interface nic if it's given, or ""
+// if there is none. The leases are named by the index of their interface.
+func wpadURLFromLeases(dir, nic string) (string, error) {
+	var leases []string
+	if nic != "" {
+		iface, err := net.InterfaceByName(nic)
+		if err != nil {
+			return "", err
+		}
+		leases = []string{strconv.Itoa(iface.Index)}
+	} else {
+		entries, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		for _, e := range entries {
+			if _, err := strconv.Atoi(e.Name()); err == nil && e.Mode().IsRegular() {
+				leases = append(leases, e.Name())
+			}
+		}
+		sort.Slice(leases, func(i, j int) bool {
+			a, _ := strconv.Atoi(leases[i])
+			b, _ := strconv.Atoi(leases[j])
+			return a < b
+		})
+	}
+
+	for _, lease := range leases {
+		wpad, err := wpadURLFromLease(filepath.Join(dir, lease))
+		if err != nil {
+			return "", err
+		}
+		if wpad != "" {
+			return wpad, nil
+		}
+	}
+	return "", nil
+}
+
+// wpadURLFromLease returns the WPAD url recorded in the lease file at path,
+// or "" if there is none.
+func wpadURLFromLease(path string) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value := strings.TrimPrefix(scanner.Text(), wpadLeaseKey+"=")
+		if value == scanner.Text() {
+			continue
+		}
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid %s: %v", path, wpadLeaseKey, err)
+		}
+		// some servers terminate the url with a NUL
+		return strings.TrimRight(string(b), "\x00"), nil
+	}
+	return "", scanner.Err()
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/log"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestPACProxies(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{
+			in:  `function FindProxyForURL(url, host) { return "PROXY proxy.example:3128"; }`,
+			out: []string{"proxy.example:3128"},
+		},
+		{
+			in: `function FindProxyForURL(url, host) {
+	if (isPlainHostName(host)) return "DIRECT";
+	return "PROXY a.example:8080; PROXY b.example:8080; DIRECT";
+}`,
+			out: []string{"a.example:8080", "b.example:8080"},
+		},
+		{
+			in:  `if (x) return "PROXY p:80"; else return 'PROXY p:80';`,
+			out: []string{"p:80"},
+		},
+		{
+			in: `function FindProxyForURL(url, host) { return "DIRECT"; }`,
+		},
+		{
+			in: `var NOPROXY = 1; return "SOCKS s:1080";`,
+		},
+		// proxies in comments are ignored, urls in strings aren't comments
+		{
+			in: `// return "PROXY old.example:3128";
+/* return "PROXY older.example:3128";
+   return "PROXY oldest.example:3128"; */
+function FindProxyForURL(url, host) {
+	if (shExpMatch(url, "http://*")) return "PROXY http.example:8080"; // PROXY c.example:1
+	return /* "PROXY d.example:1"; */ "PROXY e.example:8080";
+}`,
+			out: []string{"http.example:8080", "e.example:8080"},
+		},
+		{
+			in:  `var s = "a \" // b"; return "PROXY g.example:8080"; /* unterminated PROXY f.example:1`,
+			out: []string{"g.example:8080"},
+		},
+	}
+
+	for i, test := range tests {
+		if got := pacProxies(test.in); !reflect.DeepEqual(got, test.out) {
+			t.Errorf("#%d: want %q, got %q", i, test.out, got)
+		}
+	}
+}
+
+func writeLease(t *testing.T, dir, name, wpad string) {
+	lease := "# This is private data. Do not parse.\nADDRESS=192.0.2.10\n"
+	if wpad != "" {
+		lease += "OPTION_252=" + hex.EncodeToString([]byte(wpad)) + "\n"
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(lease), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWPADURLFromLeases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-leases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if got, err := wpadURLFromLeases(filepath.Join(dir, "missing"), ""); err != nil || got != "" {
+		t.Errorf("missing leases: got %q, %v", got, err)
+	}
+
+	writeLease(t, dir, "2", "")
+	if got, err := wpadURLFromLeases(dir, ""); err != nil || got != "" {
+		t.Errorf("lease without WPAD url: got %q, %v", got, err)
+	}
+
+	writeLease(t, dir, "10", "http://wpad.example/wpad.dat")
+	writeLease(t, dir, "3", "http://wpad.site.example/proxy.pac\x00")
+	writeLease(t, dir, "notalease", "http://other.example/wpad.dat")
+	if got, err := wpadURLFromLeases(dir, ""); err != nil || got != "http://wpad.site.example/proxy.pac" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "1"), []byte("OPTION_252=zz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wpadURLFromLeases(dir, ""); err == nil {
+		t.Errorf("invalid option was accepted")
+	}
+}
+
+func TestAutoDetectProxy(t *testing.T) {
+	pac := `function FindProxyForURL(url, host) { return "PROXY proxy.example:3128; DIRECT"; }`
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(pac))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ignition-leases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("IGNITION_DHCP_LEASES_DIR", dir)
+	defer os.Unsetenv("IGNITION_DHCP_LEASES_DIR")
+
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	configured := types.Proxy{HTTPSProxy: "http://configured.example:8080"}
+
+	// disabled
+	if got := f.autoDetectProxy(types.Proxy{}); !reflect.DeepEqual(got, types.Proxy{}) {
+		t.Errorf("disabled: got %+v", got)
+	}
+
+	os.Setenv("IGNITION_PROXY_AUTO_DETECT", "true")
+	defer os.Unsetenv("IGNITION_PROXY_AUTO_DETECT")
+
+	// no WPAD url yet, looked up again on the next call
+	if got := f.autoDetectProxy(types.Proxy{}); !reflect.DeepEqual(got, types.Proxy{}) {
+		t.Errorf("no WPAD url: got %+v", got)
+	}
+	writeLease(t, dir, "2", srv.URL+"/wpad.dat")
+
+	// the config's proxy wins
+	if got := f.autoDetectProxy(configured); !reflect.DeepEqual(got, configured) {
+		t.Errorf("configured proxy: got %+v", got)
+	}
+	if requests != 0 {
+		t.Errorf("PAC file fetched %d times for a configured proxy", requests)
+	}
+
+	noProxy := []types.NoProxyItem{"internal.example"}
+	want := types.Proxy{
+		HTTPProxy:  "http://proxy.example:3128",
+		HTTPSProxy: "http://proxy.example:3128",
+		NoProxy:    append([]types.NoProxyItem{"internal.example"}, wpadDirect...),
+	}
+	for i := 0; i < 2; i++ {
+		if got := f.autoDetectProxy(types.Proxy{NoProxy: noProxy}); !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d: want %+v, got %+v", i, want, got)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("PAC file fetched %d times, want once", requests)
+	}
+
+	// metadata services are fetched directly
+	proxyFunc := proxyFuncFromIgnitionConfig(f.autoDetectProxy(types.Proxy{NoProxy: noProxy}))
+	for dest, proxied := range map[string]bool{
+		"https://example.com/config.ign":                      true,
+		"http://internal.example/config.ign":                  false,
+		"http://169.254.169.254/latest/user-data":             false,
+		"http://[fd00:ec2::254]/latest/user-data":             false,
+		"http://[fe80::1]/config":                             false,
+		"http://100.100.100.200/latest/user-data":             false,
+		"http://metadata.google.internal/computeMetadata/v1/": false,
+	} {
+		u, err := url.Parse(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := proxyFunc(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (p != nil) != proxied {
+			t.Errorf("%s: want proxied %v, got proxy %v", dest, proxied, p)
+		}
+	}
+
+	// PAC files without a proxy leave fetches going direct
+	pac = `function FindProxyForURL(url, host) { return "SOCKS socks.example:1080"; }`
+	f = Fetcher{Logger: &logger}
+	if got := f.autoDetectProxy(types.Proxy{}); !reflect.DeepEqual(got, types.Proxy{}) {
+		t.Errorf("no proxy in the PAC file: got %+v", got)
+	}
+}
+
+func TestFetchPACWithConfiguredCAs(t *testing.T) {
+	pac := `function FindProxyForURL(url, host) { return "PROXY proxy.example:3128"; }`
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(pac))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ignition-leases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeLease(t, dir, "2", srv.URL+"/wpad.dat")
+	os.Setenv("IGNITION_DHCP_LEASES_DIR", dir)
+	defer os.Unsetenv("IGNITION_DHCP_LEASES_DIR")
+	os.Setenv("IGNITION_PROXY_AUTO_DETECT", "true")
+	defer os.Unsetenv("IGNITION_PROXY_AUTO_DETECT")
+
+	// the PAC file is fetched with the CAs of the config
+	logger := log.New(true)
+	f := Fetcher{Logger: &logger}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	tlsCfg := types.TLS{CertificateAuthorities: []types.CaReference{{Source: dataurl.EncodeBytes(ca)}}}
+	if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, tlsCfg, types.Redirects{}, types.Proxy{}); err != nil {
+		t.Fatalf("configuring the CA: %v", err)
+	}
+	if want := "http://proxy.example:3128"; f.wpadProxy != want {
+		t.Errorf("bad proxy: want %q, got %q", want, f.wpadProxy)
+	}
+
+	// and fails without them
+	f = Fetcher{Logger: &logger}
+	if err := f.UpdateHttpTimeoutsAndCAs(types.Timeouts{}, types.TLS{}, types.Redirects{}, types.Proxy{}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.wpadDone || f.wpadProxy != "" {
+		t.Errorf("PAC file fetched from an untrusted server: %q", f.wpadProxy)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package roughtime implements a client of the Roughtime protocol, as
+// served by e.g. roughtime.sandbox.google.com, which gives the time signed
+// by the server, so that it can't be forged by the network.
+package roughtime
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+)
+
+const (
+	// requestSize is the size requests are padded to, so that the
+	// protocol can't be used to amplify attacks.
+	requestSize = 1024
+	nonceSize   = 64
+	hashSize    = 32
+
+	// the contexts the signatures are made in
+	delegationContext = "RoughTime v1 delegation signature--\x00"
+	responseContext   = "RoughTime v1 response signature\x00"
+)
+
+var (
+	tagCERT = tag("CERT")
+	tagDELE = tag("DELE")
+	tagINDX = tag("INDX")
+	tagMAXT = tag("MAXT")
+	tagMIDP = tag("MIDP")
+	tagMINT = tag("MINT")
+	tagNONC = tag("NONC")
+	tagPAD  = tag("PAD\xff")
+	tagPATH = tag("PATH")
+	tagPUBK = tag("PUBK")
+	tagRADI = tag("RADI")
+	tagROOT = tag("ROOT")
+	tagSIG  = tag("SIG\x00")
+	tagSREP = tag("SREP")
+
+	ErrInvalidMessage   = errors.New("invalid roughtime message")
+	ErrInvalidSignature = errors.New("invalid roughtime signature")
+	ErrInvalidProof     = errors.New("roughtime response doesn't answer the request")
+)
+
+func tag(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+// Query asks the Roughtime server at addr, whose long-term public key is
+// key, for the time. It returns the midpoint of the time the server gives
+// and its radius.
+func Query(addr string, key ed25519.PublicKey, rand io.Reader, timeout time.Duration) (time.Time, time.Duration, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return time.Time{}, 0, err
+	}
+	req, err := request(nonce)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return time.Time{}, 0, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return time.Time{}, 0, err
+	}
+	resp := make([]byte, 64*1024)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return verify(resp[:n], nonce, key)
+}
+
+// request returns a request for nonce, padded to requestSize.
+func request(nonce []byte) ([]byte, error) {
+	// two tags take a header of 16 bytes
+	return encode(map[uint32][]byte{
+		tagNONC: nonce,
+		tagPAD:  make([]byte, requestSize-16-len(nonce)),
+	})
+}
+
+// verify checks that resp is signed by the server with the long-term key
+// and answers the request with nonce, and returns the time it gives.
+func verify(resp, nonce []byte, key ed25519.PublicKey) (time.Time, time.Duration, error) {
+	msg, err := decode(resp)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	cert, err := decodeTag(msg, tagCERT)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	dele, ok := cert[tagDELE]
+	if !ok || len(cert[tagSIG]) != ed25519.SignatureSize {
+		return time.Time{}, 0, ErrInvalidMessage
+	}
+	if !ed25519.Verify(key, append([]byte(delegationContext), dele...), cert[tagSIG]) {
+		return time.Time{}, 0, ErrInvalidSignature
+	}
+	delegation, err := decode(dele)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	pubk := delegation[tagPUBK]
+	minT, okMin := uint64Tag(delegation, tagMINT)
+	maxT, okMax := uint64Tag(delegation, tagMAXT)
+	if len(pubk) != ed25519.PublicKeySize || !okMin || !okMax {
+		return time.Time{}, 0, ErrInvalidMessage
+	}
+
+	srep, ok := msg[tagSREP]
+	if !ok || len(msg[tagSIG]) != ed25519.SignatureSize {
+		return time.Time{}, 0, ErrInvalidMessage
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pubk), append([]byte(responseContext), srep...), msg[tagSIG]) {
+		return time.Time{}, 0, ErrInvalidSignature
+	}
+	signed, err := decode(srep)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	midp, okMidp := uint64Tag(signed, tagMIDP)
+	radi, okRadi := signed[tagRADI]
+	root := signed[tagROOT]
+	if !okMidp || !okRadi || len(radi) != 4 || len(root) != hashSize {
+		return time.Time{}, 0, ErrInvalidMessage
+	}
+	if midp < minT || midp > maxT {
+		return time.Time{}, 0, fmt.Errorf("roughtime response outside of the delegation's validity")
+	}
+
+	// the server answers a batch of requests at once, signing the root of
+	// a Merkle tree of their nonces
+	indx, path := msg[tagINDX], msg[tagPATH]
+	if len(indx) != 4 || len(path)%hashSize != 0 {
+		return time.Time{}, 0, ErrInvalidMessage
+	}
+	index := binary.LittleEndian.Uint32(indx)
+	hash := leafHash(nonce)
+	for ; len(path) > 0; path = path[hashSize:] {
+		if index&1 == 0 {
+			hash = nodeHash(hash, path[:hashSize])
+		} else {
+			hash = nodeHash(path[:hashSize], hash)
+		}
+		index >>= 1
+	}
+	if index != 0 || !bytes.Equal(hash, root) {
+		return time.Time{}, 0, ErrInvalidProof
+	}
+
+	t := time.Unix(0, 0).Add(time.Duration(midp) * time.Microsecond)
+	return t, time.Duration(binary.LittleEndian.Uint32(radi)) * time.Microsecond, nil
+}
+
+func leafHash(nonce []byte) []byte {
+	h := sha512.Sum512(append([]byte{0}, nonce...))
+	return h[:hashSize]
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha512.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)[:hashSize]
+}
+
+func uint64Tag(msg map[uint32][]byte, t uint32) (uint64, bool) {
+	v, ok := msg[t]
+	if !ok || len(v) != 8 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(v), true
+}
+
+func decodeTag(msg map[uint32][]byte, t uint32) (map[uint32][]byte, error) {
+	v, ok := msg[t]
+	if !ok {
+		return nil, ErrInvalidMessage
+	}
+	return decode(v)
+}
+
+// encode encodes msg as a Roughtime message: the number of tags, the
+// offsets of all values but the first, the tags in ascending order and then
+// the values.
+func encode(msg map[uint32][]byte) ([]byte, error) {
+	tags := make([]uint32, 0, len(msg))
+	for t, v := range msg {
+		if len(v)%4 != 0 {
+			return nil, ErrInvalidMessage
+		}
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, uint32(len(tags)))
+	offset := 0
+	for i, t := range tags {
+		if i > 0 {
+			binary.Write(&b, binary.LittleEndian, uint32(offset))
+		}
+		offset += len(msg[t])
+	}
+	for _, t := range tags {
+		binary.Write(&b, binary.LittleEndian, t)
+	}
+	for _, t := range tags {
+		b.Write(msg[t])
+	}
+	return b.Bytes(), nil
+}
+
+// decode decodes the Roughtime message b.
+func decode(b []byte) (map[uint32][]byte, error) {
+	if len(b) < 4 || len(b)%4 != 0 {
+		return nil, ErrInvalidMessage
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n == 0 {
+		return map[uint32][]byte{}, nil
+	}
+	headerSize := 4 + 4*(n-1) + 4*n
+	if n > len(b)/8 || headerSize > len(b) {
+		return nil, ErrInvalidMessage
+	}
+	values := b[headerSize:]
+
+	msg := make(map[uint32][]byte, n)
+	start := 0
+	var prev uint32
+	for i := 0; i < n; i++ {
+		t := binary.LittleEndian.Uint32(b[4+4*(n-1)+4*i:])
+		if i > 0 && t <= prev {
+			return nil, ErrInvalidMessage
+		}
+		prev = t
+
+		end := len(values)
+		if i < n-1 {
+			end = int(binary.LittleEndian.Uint32(b[4+4*i:]))
+		}
+		if end%4 != 0 || end < start || end > len(values) {
+			return nil, ErrInvalidMessage
+		}
+		msg[t] = values[start:end]
+		start = end
+	}
+	return msg, nil
+}
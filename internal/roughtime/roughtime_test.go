@@ -0,0 +1,214 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package roughtime
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+type server struct {
+	root      ed25519.PrivateKey
+	delegated ed25519.PrivateKey
+	minT      uint64
+	maxT      uint64
+}
+
+func newServer(t *testing.T) server {
+	_, root, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, delegated, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server{root: root, delegated: delegated, minT: 0, maxT: 1 << 62}
+}
+
+func u64(x uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, x)
+	return b
+}
+
+func u32(x uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, x)
+	return b
+}
+
+func mustEncode(t *testing.T, msg map[uint32][]byte) []byte {
+	b, err := encode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// respond answers the i-th of a batch of two requests with the given
+// nonces at midp.
+func (s server) respond(t *testing.T, nonces [2][]byte, i int, midp time.Time) []byte {
+	leaves := [2][]byte{leafHash(nonces[0]), leafHash(nonces[1])}
+	root := nodeHash(leaves[0], leaves[1])
+	srep := mustEncode(t, map[uint32][]byte{
+		tagROOT: root,
+		tagMIDP: u64(uint64(midp.UnixNano() / 1000)),
+		tagRADI: u32(1000000),
+	})
+	dele := mustEncode(t, map[uint32][]byte{
+		tagPUBK: s.delegated.Public().(ed25519.PublicKey),
+		tagMINT: u64(s.minT),
+		tagMAXT: u64(s.maxT),
+	})
+	cert := mustEncode(t, map[uint32][]byte{
+		tagDELE: dele,
+		tagSIG:  ed25519.Sign(s.root, append([]byte(delegationContext), dele...)),
+	})
+	return mustEncode(t, map[uint32][]byte{
+		tagSIG:  ed25519.Sign(s.delegated, append([]byte(responseContext), srep...)),
+		tagPATH: leaves[1-i],
+		tagSREP: srep,
+		tagCERT: cert,
+		tagINDX: u32(uint32(i)),
+	})
+}
+
+func TestVerify(t *testing.T) {
+	srv := newServer(t)
+	expired := srv
+	expired.maxT = 1
+	other := newServer(t)
+	now := time.Unix(1700000000, 0)
+	nonces := [2][]byte{bytes.Repeat([]byte{1}, nonceSize), bytes.Repeat([]byte{2}, nonceSize)}
+
+	type in struct {
+		resp  []byte
+		nonce []byte
+		key   ed25519.PublicKey
+	}
+	type out struct {
+		err bool
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{resp: srv.respond(t, nonces, 0, now), nonce: nonces[0], key: srv.root.Public().(ed25519.PublicKey)},
+			out: out{},
+		},
+		{
+			in:  in{resp: srv.respond(t, nonces, 1, now), nonce: nonces[1], key: srv.root.Public().(ed25519.PublicKey)},
+			out: out{},
+		},
+		{
+			// answers another request of the batch
+			in:  in{resp: srv.respond(t, nonces, 1, now), nonce: nonces[0], key: srv.root.Public().(ed25519.PublicKey)},
+			out: out{err: true},
+		},
+		{
+			// signed by another server
+			in:  in{resp: other.respond(t, nonces, 0, now), nonce: nonces[0], key: srv.root.Public().(ed25519.PublicKey)},
+			out: out{err: true},
+		},
+		{
+			// outside of the delegation
+			in:  in{resp: expired.respond(t, nonces, 0, now), nonce: nonces[0], key: expired.root.Public().(ed25519.PublicKey)},
+			out: out{err: true},
+		},
+		{
+			in:  in{resp: []byte{1, 0, 0}, nonce: nonces[0], key: srv.root.Public().(ed25519.PublicKey)},
+			out: out{err: true},
+		},
+	}
+
+	for i, test := range tests {
+		mid, radius, err := verify(test.in.resp, test.in.nonce, test.in.key)
+		if test.out.err != (err != nil) {
+			t.Errorf("#%d: bad error: want error %v, got %v", i, test.out.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !mid.Equal(now) || radius != time.Second {
+			t.Errorf("#%d: bad time: want %v±%v, got %v±%v", i, now, time.Second, mid, radius)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv := newServer(t)
+	now := time.Unix(1700000000, 0)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 2048)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		req, err := decode(buf[:n])
+		if err != nil || n < requestSize {
+			return
+		}
+		nonces := [2][]byte{req[tagNONC], bytes.Repeat([]byte{0}, nonceSize)}
+		conn.WriteTo(srv.respond(t, nonces, 0, now), addr)
+	}()
+
+	mid, _, err := Query(conn.LocalAddr().String(), srv.root.Public().(ed25519.PublicKey), rand.Reader, 5*time.Second)
+	if err != nil {
+		t.Fatalf("querying: %v", err)
+	}
+	if !mid.Equal(now) {
+		t.Errorf("bad time: want %v, got %v", now, mid)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	msg := map[uint32][]byte{
+		tagNONC: bytes.Repeat([]byte{7}, nonceSize),
+		tagPAD:  make([]byte, 8),
+		tagINDX: u32(3),
+	}
+	decoded, err := decode(mustEncode(t, msg))
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	for tag, v := range msg {
+		if !bytes.Equal(decoded[tag], v) {
+			t.Errorf("bad value of tag %x: want %x, got %x", tag, v, decoded[tag])
+		}
+	}
+
+	req, err := request(msg[tagNONC])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req) != requestSize {
+		t.Errorf("bad request size: want %d, got %d", requestSize, len(req))
+	}
+}
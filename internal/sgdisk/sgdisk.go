@@ -15,13 +15,14 @@
 package sgdisk
 
 import (
+	"bytes"
 	"fmt"
-	"io/ioutil"
 	"os/exec"
 
 	"github.com/flatcar/ignition/internal/config/types"
 	"github.com/flatcar/ignition/internal/distro"
 	"github.com/flatcar/ignition/internal/log"
+	"github.com/flatcar/ignition/internal/supervisor"
 )
 
 type Operation struct {
@@ -68,33 +69,14 @@ func (op *Operation) Pretend() (string, error) {
 	op.logger.Info("running sgdisk with options: %v", opts)
 
 	cmd := exec.Command(distro.SgdiskCmd(), opts...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return "", err
-	}
-	output, err := ioutil.ReadAll(stdout)
-	if err != nil {
-		return "", err
-	}
-
-	errors, err := ioutil.ReadAll(stderr)
-	if err != nil {
-		return "", err
-	}
-
-	if err := cmd.Wait(); err != nil {
-		return "", fmt.Errorf("Failed to pretend to create partitions. Err: %v. Stderr: %v", err, string(errors))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := supervisor.Run(cmd, distro.HelperTimeout()); err != nil {
+		return "", fmt.Errorf("Failed to pretend to create partitions. Err: %v. Stderr: %v", err, stderr.String())
 	}
 
-	return string(output), nil
+	return stdout.String(), nil
 }
 
 // Commit commits an partitioning operation.
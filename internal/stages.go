@@ -0,0 +1,33 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// The stages built into every Ignition binary. Downstream stages are added by
+// importing their package from a file with a build constraint, such as
+//
+//	// +build firmware
+//
+//	package main
+//
+//	import _ "example.com/ignition-firmware/stage"
+//
+// and building with GOTAGS=firmware ./build.
+
+import (
+	_ "github.com/flatcar/ignition/internal/exec/stages/disks"
+	_ "github.com/flatcar/ignition/internal/exec/stages/enroll"
+	_ "github.com/flatcar/ignition/internal/exec/stages/fetch"
+	_ "github.com/flatcar/ignition/internal/exec/stages/files"
+)
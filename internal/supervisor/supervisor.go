@@ -0,0 +1,131 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package supervisor runs external helper programs such as sgdisk, mdadm and
+// mkfs in their own process groups, so they can be timed out and killed along
+// with any children they spawned when they hang or Ignition is aborted.
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrAborted = errors.New("aborted")
+
+	mu sync.Mutex
+	// process group ids of the running helpers
+	running = map[int]struct{}{}
+	aborted bool
+)
+
+// TimeoutError is returned for helpers killed because they ran for longer
+// than their timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("killed after timing out after %s", e.Timeout)
+}
+
+// Process is a helper started by Start.
+type Process struct {
+	cmd     *exec.Cmd
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+// Start starts cmd in a new process group. If timeout is non-zero, the whole
+// group is killed once it ran for longer than timeout.
+func Start(cmd *exec.Cmd, timeout time.Duration) (*Process, error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	mu.Lock()
+	defer mu.Unlock()
+	if aborted {
+		return nil, ErrAborted
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	pgid := cmd.Process.Pid
+	running[pgid] = struct{}{}
+
+	p := &Process{cmd: cmd, timeout: timeout}
+	if timeout > 0 {
+		p.timer = time.AfterFunc(timeout, func() {
+			p.mu.Lock()
+			p.timedOut = true
+			p.mu.Unlock()
+			kill(pgid)
+		})
+	}
+	return p, nil
+}
+
+// Wait waits for the process to exit, returning a TimeoutError if it was
+// killed for running too long.
+func (p *Process) Wait() error {
+	err := p.cmd.Wait()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	mu.Lock()
+	delete(running, p.cmd.Process.Pid)
+	mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timedOut {
+		return TimeoutError{Timeout: p.timeout}
+	}
+	return err
+}
+
+// Run starts cmd like Start and waits for it to exit.
+func Run(cmd *exec.Cmd, timeout time.Duration) error {
+	p, err := Start(cmd, timeout)
+	if err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Abort kills the process groups of all running helpers and causes starting
+// further helpers to fail with ErrAborted.
+func Abort() {
+	mu.Lock()
+	defer mu.Unlock()
+	aborted = true
+	for pgid := range running {
+		kill(pgid)
+	}
+}
+
+func kill(pgid int) {
+	syscall.Kill(-pgid, syscall.SIGKILL)
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package supervisor
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	if err := Run(exec.Command("true"), time.Second); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := Run(exec.Command("false"), time.Second); err == nil {
+		t.Errorf("expected an error for a failing command")
+	}
+
+	// the timeout kills the whole process group, including the child
+	// keeping the output pipe open
+	cmd := exec.Command("sh", "-c", "sleep 10 & sleep 10")
+	cmd.Stdout = &nopWriter{}
+	start := time.Now()
+	err := Run(cmd, 100*time.Millisecond)
+	if _, ok := err.(TimeoutError); !ok {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("process group was not killed, took %s", elapsed)
+	}
+	if len(running) != 0 {
+		t.Errorf("processes still tracked as running: %v", running)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	defer func() {
+		mu.Lock()
+		aborted = false
+		mu.Unlock()
+	}()
+
+	p, err := Start(exec.Command("sleep", "10"), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	Abort()
+	if err := p.Wait(); err == nil {
+		t.Errorf("expected the aborted process to fail")
+	}
+	if _, err := Start(exec.Command("true"), 0); err != ErrAborted {
+		t.Errorf("bad error after abort: want %v, got %v", ErrAborted, err)
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
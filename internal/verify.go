@@ -0,0 +1,96 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/flatcar/ignition/internal/config"
+	"github.com/flatcar/ignition/internal/distro"
+	"github.com/flatcar/ignition/internal/verify"
+)
+
+// runVerify implements "ignition verify", which reports how the provisioned
+// files, units and users drifted from what Ignition left behind. It returns
+// the exit status: 0 without drift, 1 with drift, 2 for bad usage and 3 if
+// the machine couldn't be checked.
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	root := flags.String("root", "/", "root of the filesystem")
+	configPath := flags.String("config", "", "config whose passwd section to check the users and groups against")
+	asJSON := flags.Bool("json", false, "print the drift as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %v\n", flags.Args())
+		return 2
+	}
+	record := distro.ManagedPathsFile()
+	if record == "" {
+		fmt.Fprint(os.Stderr, "this build of Ignition keeps no record of managed paths\n")
+		return 3
+	}
+
+	drift, err := verify.ManagedPaths(*root, record)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check managed paths: %v\n", err)
+		return 3
+	}
+	if *configPath != "" {
+		raw, err := ioutil.ReadFile(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read config: %v\n", err)
+			return 3
+		}
+		cfg, rpt, err := config.Parse(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse config: %v\n%s", err, rpt)
+			return 3
+		}
+		passwdDrift, err := verify.Passwd(*root, cfg.Passwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to check users and groups: %v\n", err)
+			return 3
+		}
+		drift = append(drift, passwdDrift...)
+	}
+
+	if *asJSON {
+		out := struct {
+			Root  string         `json:"root"`
+			Drift []verify.Drift `json:"drift"`
+		}{filepath.Clean(*root), drift}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 3
+		}
+	} else {
+		for _, d := range drift {
+			fmt.Println(d)
+		}
+	}
+	if len(drift) > 0 {
+		return 1
+	}
+	return 0
+}
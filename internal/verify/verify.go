@@ -0,0 +1,224 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The verify package compares a provisioned machine against what Ignition
+// left it as, so that later changes to provisioned files, units and users
+// can be found.
+package verify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+// Drift describes how something provisioned by Ignition differs from what
+// Ignition left behind.
+type Drift struct {
+	// Path is the path on the root filesystem, for managed paths.
+	Path string `json:"path,omitempty"`
+	// User and Group name the user or group, for the passwd section.
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+	// Kind is what changed: "missing", "type", "mode", "owner",
+	// "contents" or "target" for paths, and "missing", "uid", "gid",
+	// "homeDir", "shell", "gecos", "primaryGroup" or "groups" for users
+	// and groups.
+	Kind string `json:"kind"`
+	// Want and Got describe the expected and the actual state.
+	Want string `json:"want,omitempty"`
+	Got  string `json:"got,omitempty"`
+}
+
+func (d Drift) String() string {
+	var subject string
+	switch {
+	case d.Path != "":
+		subject = d.Path
+	case d.User != "":
+		subject = fmt.Sprintf("user %q", d.User)
+	default:
+		subject = fmt.Sprintf("group %q", d.Group)
+	}
+	if d.Kind == "missing" {
+		return fmt.Sprintf("%s: missing", subject)
+	}
+	return fmt.Sprintf("%s: %s changed from %q to %q", subject, d.Kind, d.Want, d.Got)
+}
+
+// ManagedPaths compares the paths in the record at record, which like all
+// paths is relative to root, against the filesystem.
+func ManagedPaths(root, record string) ([]Drift, error) {
+	u := util.Util{DestDir: root}
+	paths, err := u.ReadManagedPaths(record)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no record of managed paths at %q", record)
+	} else if err != nil {
+		return nil, err
+	}
+
+	drift := []Drift{}
+	for _, want := range paths {
+		got, err := u.DescribeManaged(want.Path)
+		if os.IsNotExist(err) {
+			drift = append(drift, Drift{Path: want.Path, Kind: "missing"})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if want.Type != got.Type {
+			drift = append(drift, Drift{Path: want.Path, Kind: "type", Want: want.Type, Got: got.Type})
+			continue
+		}
+		if want.Mode != got.Mode {
+			drift = append(drift, Drift{Path: want.Path, Kind: "mode", Want: want.Mode, Got: got.Mode})
+		}
+		if want.UID != got.UID || want.GID != got.GID {
+			drift = append(drift, Drift{
+				Path: want.Path,
+				Kind: "owner",
+				Want: fmt.Sprintf("%d:%d", want.UID, want.GID),
+				Got:  fmt.Sprintf("%d:%d", got.UID, got.GID),
+			})
+		}
+		if want.SHA256 != got.SHA256 {
+			drift = append(drift, Drift{Path: want.Path, Kind: "contents", Want: "sha256-" + want.SHA256, Got: "sha256-" + got.SHA256})
+		}
+		if want.Target != got.Target {
+			drift = append(drift, Drift{Path: want.Path, Kind: "target", Want: want.Target, Got: got.Target})
+		}
+	}
+	return drift, nil
+}
+
+// Passwd compares the users and groups of the passwd section of config
+// against /etc/passwd and /etc/group below root. Only the settings given in
+// config are compared; password hashes and SSH keys aren't.
+func Passwd(root string, config types.Passwd) ([]Drift, error) {
+	users, err := ReadDatabase(filepath.Join(root, "etc/passwd"), 7)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := ReadDatabase(filepath.Join(root, "etc/group"), 4)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := map[string]string{}
+	for name, fields := range groups {
+		groupNames[fields[2]] = name
+	}
+
+	drift := []Drift{}
+	for _, g := range config.Groups {
+		fields, ok := groups[g.Name]
+		if !ok {
+			drift = append(drift, Drift{Group: g.Name, Kind: "missing"})
+			continue
+		}
+		if g.Gid != nil && strconv.Itoa(*g.Gid) != fields[2] {
+			drift = append(drift, Drift{Group: g.Name, Kind: "gid", Want: strconv.Itoa(*g.Gid), Got: fields[2]})
+		}
+	}
+
+	for _, c := range config.Users {
+		if c.Create != nil {
+			cu := c.Create
+			c.Gecos = cu.Gecos
+			c.HomeDir = cu.HomeDir
+			c.PrimaryGroup = cu.PrimaryGroup
+			c.Shell = cu.Shell
+			c.UID = cu.UID
+			c.Groups = nil
+			for _, g := range cu.Groups {
+				c.Groups = append(c.Groups, types.Group(g))
+			}
+		}
+		fields, ok := users[c.Name]
+		if !ok {
+			drift = append(drift, Drift{User: c.Name, Kind: "missing"})
+			continue
+		}
+		compare := func(kind, want, got string) {
+			if want != "" && want != got {
+				drift = append(drift, Drift{User: c.Name, Kind: kind, Want: want, Got: got})
+			}
+		}
+		if c.UID != nil {
+			compare("uid", strconv.Itoa(*c.UID), fields[2])
+		}
+		compare("primaryGroup", c.PrimaryGroup, groupNames[fields[3]])
+		compare("gecos", c.Gecos, fields[4])
+		compare("homeDir", c.HomeDir, fields[5])
+		compare("shell", c.Shell, fields[6])
+
+		var want, got []string
+		for _, g := range c.Groups {
+			want = append(want, string(g))
+			if fields, ok := groups[string(g)]; ok && hasMember(fields[3], c.Name) {
+				got = append(got, string(g))
+			}
+		}
+		if len(want) != len(got) {
+			drift = append(drift, Drift{
+				User: c.Name,
+				Kind: "groups",
+				Want: strings.Join(want, ","),
+				Got:  strings.Join(got, ","),
+			})
+		}
+	}
+	return drift, nil
+}
+
+// ReadDatabase reads a colon-separated database like /etc/passwd, keyed by
+// the first field. Lines with fewer than n fields are skipped.
+func ReadDatabase(path string, n int) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", n)
+		if len(fields) < n {
+			continue
+		}
+		if _, ok := entries[fields[0]]; !ok {
+			entries[fields[0]] = fields
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func hasMember(members, name string) bool {
+	for _, m := range strings.Split(members, ",") {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
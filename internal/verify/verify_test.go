@@ -0,0 +1,188 @@
+// Copyright 2026 The Ignition authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar/ignition/internal/config/types"
+	"github.com/flatcar/ignition/internal/exec/util"
+)
+
+func intp(i int) *int {
+	return &i
+}
+
+func TestManagedPaths(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, path := range []string{"etc/same", "etc/edited", "etc/chmod", "etc/removed"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(root, path), []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("/etc/same", filepath.Join(root, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	u := util.Util{DestDir: root, Managed: &util.ManagedPaths{}}
+	u.RecordManaged("/etc/same", "/etc/edited", "/etc/chmod", "/etc/removed", "/etc/link")
+	if err := u.WriteManagedPaths("/record.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, "etc/edited"), []byte("local edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "etc/chmod"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "etc/removed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/edited", filepath.Join(root, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err := ManagedPaths(root, "/record.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Drift{
+		{Path: "/etc/chmod", Kind: "mode", Want: "0644", Got: "0600"},
+		{Path: "/etc/edited", Kind: "contents",
+			Want: "sha256-f948600b2acca3a64efa8fe3380a7984c41eb13bdbeee1edef292128bf009637",
+			Got:  "sha256-52f2f0065eab36600dabc023026a10c61034dc373e77390457d4c2be9298cb9a"},
+		{Path: "/etc/link", Kind: "target", Want: "/etc/same", Got: "/etc/edited"},
+		{Path: "/etc/removed", Kind: "missing"},
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("bad drift:\nwant %+v\ngot  %+v", want, drift)
+	}
+
+	if _, err := ManagedPaths(root, "/missing.json"); err == nil {
+		t.Errorf("missing record: expected an error")
+	}
+}
+
+func TestPasswd(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "etc/passwd"), []byte(
+		"root:x:0:0:root:/root:/bin/bash\n"+
+			"core:x:500:500:CoreOS Admin:/home/core:/bin/bash\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "etc/group"), []byte(
+		"root:x:0:\n"+
+			"core:x:500:\n"+
+			"docker:x:233:core\n"+
+			"sudo:x:150:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in  types.Passwd
+		out []Drift
+	}{
+		{
+			in:  types.Passwd{},
+			out: []Drift{},
+		},
+		{
+			in: types.Passwd{
+				Users: []types.PasswdUser{{
+					Name:         "core",
+					UID:          intp(500),
+					PrimaryGroup: "core",
+					Gecos:        "CoreOS Admin",
+					HomeDir:      "/home/core",
+					Shell:        "/bin/bash",
+					Groups:       []types.Group{"docker"},
+				}},
+				Groups: []types.PasswdGroup{{Name: "docker", Gid: intp(233)}},
+			},
+			out: []Drift{},
+		},
+		{
+			in: types.Passwd{
+				Users: []types.PasswdUser{
+					{
+						Name:         "core",
+						UID:          intp(501),
+						PrimaryGroup: "docker",
+						Shell:        "/bin/zsh",
+						Groups:       []types.Group{"docker", "sudo"},
+					},
+					{Name: "user"},
+				},
+				Groups: []types.PasswdGroup{
+					{Name: "sudo", Gid: intp(27)},
+					{Name: "wheel"},
+				},
+			},
+			out: []Drift{
+				{Group: "sudo", Kind: "gid", Want: "27", Got: "150"},
+				{Group: "wheel", Kind: "missing"},
+				{User: "core", Kind: "uid", Want: "501", Got: "500"},
+				{User: "core", Kind: "primaryGroup", Want: "docker", Got: "core"},
+				{User: "core", Kind: "shell", Want: "/bin/zsh", Got: "/bin/bash"},
+				{User: "core", Kind: "groups", Want: "docker,sudo", Got: "docker"},
+				{User: "user", Kind: "missing"},
+			},
+		},
+		{
+			in: types.Passwd{
+				Users: []types.PasswdUser{{
+					Name:   "core",
+					Create: &types.Usercreate{HomeDir: "/var/home/core"},
+				}},
+			},
+			out: []Drift{
+				{User: "core", Kind: "homeDir", Want: "/var/home/core", Got: "/home/core"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		out, err := Passwd(root, test.in)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, out) {
+			t.Errorf("#%d: bad drift:\nwant %+v\ngot  %+v", i, test.out, out)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -80,16 +81,38 @@ func prepareRootPartitionForPasswd(ctx context.Context, root *types.Partition) e
 		}
 	}
 
-	// TODO: use the architecture, not hardcode amd64
-	_, err := run(ctx, "cp", "bin/amd64/id-stub", filepath.Join(mountPath, distro.IdCmd()))
+	_, err := run(ctx, "cp", filepath.Join("bin", runtime.GOARCH, "id-stub"), filepath.Join(mountPath, distro.IdCmd()))
 	if err != nil {
 		return err
 	}
 	// TODO: needed for user_group_lookup.c
-	_, err = run(ctx, "cp", "/lib64/libnss_files.so.2", filepath.Join(mountPath, "usr", "lib64"))
+	_, err = run(ctx, "cp", libnssFiles(), filepath.Join(mountPath, "usr", "lib64"))
 	return err
 }
 
+// multiarchTriplets are the directories of the libraries of the
+// architectures the tests run on, on distros with multiarch layouts.
+var multiarchTriplets = map[string]string{
+	"amd64":   "x86_64-linux-gnu",
+	"arm64":   "aarch64-linux-gnu",
+	"ppc64le": "powerpc64le-linux-gnu",
+	"s390x":   "s390x-linux-gnu",
+}
+
+// libnssFiles returns the path of the host's libnss_files.
+func libnssFiles() string {
+	candidates := []string{"/lib64/libnss_files.so.2"}
+	if triplet, ok := multiarchTriplets[runtime.GOARCH]; ok {
+		candidates = append(candidates, filepath.Join("/usr/lib", triplet, "libnss_files.so.2"))
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
 func getRootPartition(partitions []*types.Partition) *types.Partition {
 	for _, p := range partitions {
 		if p.Label == "ROOT" {
@@ -28,6 +28,7 @@ func init() {
 	register.Register(register.PositiveTest, ForceFileCreationNoOverwrite())
 	register.Register(register.PositiveTest, AppendToAFile())
 	register.Register(register.PositiveTest, AppendToNonexistentFile())
+	register.Register(register.PositiveTest, SetCapabilitiesAndXattrs())
 	// TODO: Investigate why ignition's C code hates our environment
 	// register.Register(register.PositiveTest, UserGroupByName())
 }
@@ -377,3 +378,47 @@ func AppendToNonexistentFile() types.Test {
 		ConfigMinVersion: configMinVersion,
 	}
 }
+
+func SetCapabilitiesAndXattrs() types.Test {
+	name := "Set the capabilities and xattrs of a file"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/usr/bin/ping",
+	      "contents": { "source": "data:,ping%0A" },
+	      "mode": 493,
+	      "capabilities": { "permitted": ["CAP_NET_RAW"], "effective": true },
+	      "xattrs": [{ "name": "user.origin", "value": "aWduaXRpb24=" }]
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "ping",
+				Directory: "usr/bin",
+			},
+			Contents: "ping\n",
+			Mode:     0755,
+			// the kernel's little-endian vfs_cap_data, the same on
+			// every architecture
+			Xattrs: map[string][]byte{
+				"security.capability": {0x01, 0, 0, 0x02, 0, 0x20, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				"user.origin":         []byte("ignition"),
+			},
+		},
+	})
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -36,6 +36,7 @@ type File struct {
 	Node
 	Contents string
 	Mode     int
+	Xattrs   map[string][]byte
 }
 
 type Directory struct {
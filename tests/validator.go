@@ -15,6 +15,7 @@
 package blackbox
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -27,7 +28,7 @@ import (
 	"syscall"
 	"testing"
 
-	"github.com/flatcar/ignition/internal/exec/util"
+	"github.com/flatcar/ignition/internal/probe"
 	"github.com/flatcar/ignition/tests/types"
 )
 
@@ -141,35 +142,24 @@ func formatUUID(s string) string {
 
 func validateFilesystems(t *testing.T, expected []*types.Partition) error {
 	for _, e := range expected {
-		if e.FilesystemType != "" {
-			filesystemType, err := util.FilesystemType(e.Device)
-			if err != nil {
-				return fmt.Errorf("couldn't determine filesystem type: %v", err)
-			}
-			if filesystemType != e.FilesystemType {
-				t.Errorf("FilesystemType does not match, expected:%q actual:%q",
-					e.FilesystemType, filesystemType)
-			}
+		if e.FilesystemType == "" && e.FilesystemUUID == "" && e.FilesystemLabel == "" {
+			continue
 		}
-		if e.FilesystemUUID != "" {
-			filesystemUUID, err := util.FilesystemUUID(e.Device)
-			if err != nil {
-				return fmt.Errorf("couldn't determine filesystem uuid: %v", err)
-			}
-			if formatUUID(filesystemUUID) != formatUUID(e.FilesystemUUID) {
-				t.Errorf("FilesystemUUID does not match, expected:%q actual:%q",
-					e.FilesystemUUID, filesystemUUID)
-			}
+		found, err := probe.Device(e.Device)
+		if err != nil {
+			return fmt.Errorf("couldn't probe filesystem: %v", err)
 		}
-		if e.FilesystemLabel != "" {
-			filesystemLabel, err := util.FilesystemLabel(e.Device)
-			if err != nil {
-				return fmt.Errorf("couldn't determine filesystem label: %v", err)
-			}
-			if filesystemLabel != e.FilesystemLabel {
-				t.Errorf("FilesystemLabel does not match, expected:%q actual:%q",
-					e.FilesystemLabel, filesystemLabel)
-			}
+		if e.FilesystemType != "" && found.Type != e.FilesystemType {
+			t.Errorf("FilesystemType does not match, expected:%q actual:%q",
+				e.FilesystemType, found.Type)
+		}
+		if e.FilesystemUUID != "" && formatUUID(found.UUID) != formatUUID(e.FilesystemUUID) {
+			t.Errorf("FilesystemUUID does not match, expected:%q actual:%q",
+				e.FilesystemUUID, found.UUID)
+		}
+		if e.FilesystemLabel != "" && found.Label != e.FilesystemLabel {
+			t.Errorf("FilesystemLabel does not match, expected:%q actual:%q",
+				e.FilesystemLabel, found.Label)
 		}
 	}
 	return nil
@@ -234,6 +224,24 @@ func validateFile(t *testing.T, partition *types.Partition, file types.File) {
 
 	validateMode(t, path, file.Mode)
 	validateNode(t, fileInfo, file.Node)
+	validateXattrs(t, path, file.Xattrs)
+}
+
+// validateXattrs compares the xattrs of the file byte by byte, so that values
+// encoded in the byte order of the host rather than the kernel's are caught
+// on big-endian machines.
+func validateXattrs(t *testing.T, path string, expected map[string][]byte) {
+	for name, want := range expected {
+		value := make([]byte, 256)
+		n, err := syscall.Getxattr(path, name, value)
+		if err != nil {
+			t.Errorf("Error getting xattr %s of %s: %v", name, path, err)
+			continue
+		}
+		if !bytes.Equal(want, value[:n]) {
+			t.Errorf("Xattr %s of %s does not match, expected:% x actual:% x", name, path, want, value[:n])
+		}
+	}
 }
 
 func validateDirectory(t *testing.T, partition *types.Partition, dir types.Directory) {
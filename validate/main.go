@@ -15,24 +15,41 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
 	config "github.com/flatcar/ignition/config/v2_4"
+	"github.com/flatcar/ignition/config/v2_4/types"
+	"github.com/flatcar/ignition/config/validate/report"
+	internalConfig "github.com/flatcar/ignition/internal/config"
 	"github.com/flatcar/ignition/internal/version"
 )
 
 var (
-	flagVersion bool
+	flagVersion    bool
+	flagRestricted bool
+	flagFIPS       bool
+	flagGraph      string
+	flagLint       bool
+	flagLintLevels string
+
+	lintSeverities map[string]string
 )
 
 func init() {
 	flag.BoolVar(&flagVersion, "version", false, "print the version of ignition-validate")
+	flag.BoolVar(&flagFIPS, "fips", false, "also check that the config only requires algorithms approved in FIPS mode")
+	flag.BoolVar(&flagRestricted, "restricted", false, "also check the config against the restricted execution policy")
+	flag.StringVar(&flagGraph, "graph", "", "print the dependency graph of the config's operations as \"dot\" or \"json\"")
+	flag.BoolVar(&flagLint, "lint", false, "also check the config against the lint rules: "+strings.Join(internalConfig.LintRuleNames(), ", "))
+	flag.StringVar(&flagLintLevels, "lint-severity", "", "override the severities of lint rules as comma separated <rule>=<error|warning|info|off> pairs")
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s config.ign [flags]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s [flags] config.ign\n\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 }
@@ -40,7 +57,7 @@ func init() {
 func main() {
 	flag.Parse()
 
-	runIgnValidate(os.Args[1:])
+	runIgnValidate(flag.Args())
 }
 
 func stdout(format string, a ...interface{}) {
@@ -66,8 +83,14 @@ func runIgnValidate(args []string) {
 		flag.Usage()
 		os.Exit(1)
 	}
-	var blob []byte
+	if flagGraph != "" && flagGraph != "dot" && flagGraph != "json" {
+		die("unknown graph format %q", flagGraph)
+	}
 	var err error
+	if lintSeverities, err = internalConfig.ParseLintSeverities(flagLintLevels); err != nil {
+		die("%v", err)
+	}
+	var blob []byte
 	if args[0] == "-" {
 		blob, err = ioutil.ReadAll(os.Stdin)
 	} else {
@@ -76,9 +99,25 @@ func runIgnValidate(args []string) {
 	if err != nil {
 		die("couldn't read config: %v", err)
 	}
-	_, rpt, err := config.Parse(blob)
+	bundle, err := internalConfig.ParseBundle(blob)
+	if err != nil {
+		die("couldn't parse bundle: %v", err)
+	}
+	if bundle != nil {
+		if flagGraph != "" {
+			die("the graph of a bundle can't be printed, select one of its configs")
+		}
+		validateBundle(*bundle)
+		return
+	}
+	cfg, rpt, err := validate(blob)
 	if len(rpt.Entries) > 0 {
-		stdout(rpt.String())
+		// keep stdout for the graph
+		if flagGraph != "" {
+			stderr(rpt.String())
+		} else {
+			stdout(rpt.String())
+		}
 	}
 	if rpt.IsFatal() {
 		os.Exit(1)
@@ -86,4 +125,60 @@ func runIgnValidate(args []string) {
 	if err != nil {
 		die("couldn't parse config: %v", err)
 	}
+
+	switch flagGraph {
+	case "dot":
+		fmt.Print(internalConfig.DependencyGraph(internalConfig.Translate(cfg)).DOT())
+	case "json":
+		b, err := json.MarshalIndent(internalConfig.DependencyGraph(internalConfig.Translate(cfg)), "", "  ")
+		if err != nil {
+			die("couldn't render graph: %v", err)
+		}
+		stdout("%s", b)
+	}
+}
+
+// validate parses the config and runs the checks requested by the flags.
+func validate(blob []byte) (types.Config, report.Report, error) {
+	cfg, rpt, err := config.Parse(blob)
+	if err == nil && !rpt.IsFatal() {
+		if flagRestricted {
+			rpt.Merge(internalConfig.ValidateRestricted(internalConfig.Translate(cfg)))
+		}
+		if flagFIPS {
+			rpt.Merge(internalConfig.ValidateFIPS(internalConfig.Translate(cfg)))
+		}
+		if flagLint {
+			rpt.Merge(internalConfig.Lint(internalConfig.Translate(cfg), lintSeverities))
+		}
+	}
+	return cfg, rpt, err
+}
+
+// validateBundle validates every config of the bundle, since which one is
+// applied is only known on the machine.
+func validateBundle(bundle internalConfig.Bundle) {
+	var names []string
+	for name := range bundle.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := false
+	for _, name := range names {
+		_, rpt, err := validate(bundle.Configs[name])
+		for i := range rpt.Entries {
+			rpt.Entries[i].Message = fmt.Sprintf("config %q: %s", name, rpt.Entries[i].Message)
+		}
+		if len(rpt.Entries) > 0 {
+			stdout(rpt.String())
+		}
+		if err != nil && !rpt.IsFatal() {
+			stderr("config %q: couldn't parse config: %v", name, err)
+		}
+		failed = failed || err != nil || rpt.IsFatal()
+	}
+	if failed {
+		os.Exit(1)
+	}
 }